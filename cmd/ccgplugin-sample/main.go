@@ -0,0 +1,47 @@
+// ccgplugin-sample is a sample CCG plugin input executable for hcsshim. It
+// resolves a plugin input of the form `file:<path>` to the contents of the
+// file and `env:<name>` to the value of the environment variable, so that the
+// credentials passed to the CCG plugin can be kept out of the credential spec.
+//
+// To use it, copy it to `<GUID>.exe` in the directory named by
+// HCSSHIM_CCG_PLUGIN_DIR, where GUID is the `PluginGUID` of the credential
+// specs it should resolve.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/pkg/ccgplugin"
+)
+
+func main() {
+	ccgplugin.Serve(context.Background(), resolve)
+}
+
+func resolve(_ context.Context, req *ccgplugin.Request) (*ccgplugin.Response, error) {
+	i := strings.Index(req.PluginInput, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("plugin input must be of the form file:<path> or env:<name>")
+	}
+	kind, ref := req.PluginInput[:i], req.PluginInput[i+1:]
+	switch kind {
+	case "file":
+		b, err := ioutil.ReadFile(ref)
+		if err != nil {
+			return nil, err
+		}
+		return &ccgplugin.Response{PluginInput: strings.TrimSpace(string(b))}, nil
+	case "env":
+		v, ok := os.LookupEnv(ref)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return &ccgplugin.Response{PluginInput: v}, nil
+	default:
+		return nil, fmt.Errorf("unsupported plugin input kind %q", kind)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/containerd/containerd/errdefs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// admissionPluginTimeout is the time that an admission plugin has to admit a
+// spec.
+const admissionPluginTimeout = 30 * time.Second
+
+// admissionRequest is written as JSON to the stdin of an admission plugin.
+//
+// The plugin writes the spec that the task is created with as JSON to its
+// stdout, or nothing to leave the spec unchanged. The UVM options of a pod are
+// parsed from the annotations of the sandbox spec after it is admitted, so
+// plugins set them through the annotations. To reject the task the plugin
+// exits with a non-zero exit code and the reason on its stderr.
+type admissionRequest struct {
+	ID     string      `json:"id"`
+	Bundle string      `json:"bundle"`
+	Spec   *specs.Spec `json:"spec"`
+}
+
+// admissionPlugins returns the executables in `dir`, in lexical order.
+func admissionPlugins(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var plugins []string
+	for _, e := range entries {
+		if e.Mode().IsRegular() && strings.EqualFold(filepath.Ext(e.Name()), ".exe") {
+			plugins = append(plugins, filepath.Join(dir, e.Name()))
+		}
+	}
+	return plugins, nil
+}
+
+// admitSpec runs the admission plugins in `dir` on the spec `s` of the task
+// `id`, replacing `s` with the spec returned by each plugin. It fails if any
+// plugin rejects the task.
+func admitSpec(ctx context.Context, dir, id, bundle string, s *specs.Spec) error {
+	plugins, err := admissionPlugins(dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to list admission plugins")
+	}
+	for _, p := range plugins {
+		if err := runAdmissionPlugin(ctx, p, &admissionRequest{
+			ID:     id,
+			Bundle: bundle,
+			Spec:   s,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runAdmissionPlugin(ctx context.Context, plugin string, req *admissionRequest) error {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, admissionPluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, plugin)
+	c.Stdin = bytes.NewReader(in)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+			return errors.Wrapf(errdefs.ErrFailedPrecondition, "task with id: '%s' rejected by admission plugin '%s': %s", req.ID, plugin, strings.TrimSpace(stderr.String()))
+		}
+		return errors.Wrapf(err, "failed to run admission plugin '%s'", plugin)
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	var spec specs.Spec
+	if err := json.Unmarshal(stdout.Bytes(), &spec); err != nil {
+		return errors.Wrapf(err, "invalid spec from admission plugin '%s'", plugin)
+	}
+	*req.Spec = spec
+	log.G(ctx).WithFields(logrus.Fields{
+		"tid":    req.ID,
+		"plugin": plugin,
+	}).Debug("spec mutated by admission plugin")
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_admissionPlugins(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admission")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"20-limits.exe", "10-defaults.EXE", "README.md"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "30-dir.exe"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := admissionPlugins(dir)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := []string{filepath.Join(dir, "10-defaults.EXE"), filepath.Join(dir, "20-limits.exe")}
+	if len(plugins) != len(expected) || plugins[0] != expected[0] || plugins[1] != expected[1] {
+		t.Fatalf("expected %v, got %v", expected, plugins)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/pkg/firewall"
 	"github.com/containerd/containerd/runtime/v2/task"
 	"github.com/gogo/protobuf/proto"
 	"github.com/sirupsen/logrus"
@@ -80,6 +83,13 @@ The delete command will be executed in the container's bundle as its cwd.
 					}
 				}
 			}
+			// Remove the firewall rules of a pod whose shim exited without
+			// removing them.
+			if strings.EqualFold(s[oci.AnnotationFirewallPublishedPorts], "true") {
+				if err := firewall.RemovePortRules(ctx, idFlag); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to remove firewall rules of '%s': %v", idFlag, err)
+				}
+			}
 		}
 
 		// hcsshim shim writes panic logs in the bundle directory in a file named "panic.log"
@@ -37,6 +37,7 @@ func newClonedExec(
 		isWCOW:      isWCOW,
 		spec:        spec,
 		io:          io,
+		resizer:     cmd.NewConsoleResizer(),
 		processDone: make(chan struct{}),
 		state:       shimExecStateCreated,
 		exitStatus:  255, // By design for non-exited process status.
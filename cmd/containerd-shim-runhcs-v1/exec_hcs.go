@@ -36,6 +36,7 @@ func newHcsExec(
 	id, bundle string,
 	isWCOW bool,
 	spec *specs.Process,
+	seccomp *specs.LinuxSeccomp,
 	io cmd.UpstreamIO) shimExec {
 	log.G(ctx).WithFields(logrus.Fields{
 		"tid":    tid,
@@ -53,7 +54,9 @@ func newHcsExec(
 		bundle:      bundle,
 		isWCOW:      isWCOW,
 		spec:        spec,
+		seccomp:     seccomp,
 		io:          io,
+		resizer:     cmd.NewConsoleResizer(),
 		processDone: make(chan struct{}),
 		state:       shimExecStateCreated,
 		exitStatus:  255, // By design for non-exited process status.
@@ -100,12 +103,21 @@ type hcsExec struct {
 	//
 	// This MUST be treated as read only in the lifetime of the exec.
 	spec *specs.Process
+	// seccomp is the seccomp profile of a true exec of an LCOW container, or
+	// `nil` to use the profile of the container.
+	//
+	// This MUST be treated as read only in the lifetime of the exec.
+	seccomp *specs.LinuxSeccomp
 	// io is the upstream io connections used for copying between the upstream
 	// io and the downstream io. The upstream IO MUST already be connected at
 	// create time in order to be valid.
 	//
 	// This MUST be treated as read only in the lifetime of the exec.
-	io              cmd.UpstreamIO
+	io cmd.UpstreamIO
+	// resizer applies the console size of the exec if `io.Terminal()`.
+	//
+	// This MUST be treated as read only in the lifetime of the exec.
+	resizer         *cmd.ConsoleResizer
 	processDone     chan struct{}
 	processDoneOnce sync.Once
 
@@ -206,12 +218,24 @@ func (he *hcsExec) startInternal(ctx context.Context, initializeContainer bool)
 		// An init exec passes the process as part of the config. We only pass
 		// the spec if this is a true exec.
 		cmd.Spec = he.spec
+		cmd.Seccomp = he.seccomp
+		if width, height, ok := he.resizer.Size(); ok && he.io.Terminal() {
+			// Create the process with the latest console size rather than
+			// resizing it once started.
+			spec := *he.spec
+			spec.ConsoleSize = &specs.Box{Width: uint(width), Height: uint(height)}
+			cmd.Spec = &spec
+			he.resizer.Created(width, height)
+		}
 	}
 	err = cmd.Start()
 	if err != nil {
 		return err
 	}
 	he.p = cmd
+	if he.io.Terminal() {
+		he.resizer.Start(context.Background(), cmd.Process, cmd.Log)
+	}
 
 	// Assign the PID and transition the state.
 	he.pid = he.p.Process.Pid()
@@ -312,9 +336,12 @@ func (he *hcsExec) ResizePty(ctx context.Context, width, height uint32) error {
 		return errors.Wrapf(errdefs.ErrFailedPrecondition, "exec: '%s' in task: '%s' is not a tty", he.id, he.tid)
 	}
 
-	if he.state == shimExecStateRunning {
-		return he.p.Process.ResizeConsole(ctx, uint16(width), uint16(height))
+	if he.state == shimExecStateExited {
+		return nil
 	}
+	// Resizes before the process is started are applied when it is created.
+	// Later resizes are coalesced and applied asynchronously.
+	he.resizer.Resize(uint16(width), uint16(height))
 	return nil
 }
 
@@ -435,6 +462,8 @@ func (he *hcsExec) waitForExit() {
 		log.G(ctx).WithField("exitCode", code).Debug("exited")
 	}
 
+	he.resizer.Stop()
+
 	he.sl.Lock()
 	he.state = shimExecStateExited
 	he.exitStatus = uint32(code)
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/eventlog"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// healthRemediationCollectLogs logs the shim and guest stacks when a pod
+	// becomes unhealthy.
+	healthRemediationCollectLogs = "collect_logs"
+	// healthRemediationRestartPod logs the stacks like
+	// `healthRemediationCollectLogs` and then terminates the pod, so that
+	// its sandbox task exits and the pod is restarted.
+	healthRemediationRestartPod = "restart_pod"
+)
+
+// healthCheckFailureThreshold is the number of consecutive failed health
+// checks after which a pod is unhealthy.
+const healthCheckFailureThreshold = 3
+
+// healthCheckSlowResponse is the guest response time above which a slow guest
+// is logged, even though the health check passes.
+const healthCheckSlowResponse = time.Second
+
+// validateHealthRemediation returns an error if `remediation` is not a known
+// remediation.
+func validateHealthRemediation(remediation string) error {
+	switch remediation {
+	case "", healthRemediationCollectLogs, healthRemediationRestartPod:
+		return nil
+	}
+	return errors.Wrapf(errdefs.ErrInvalidArgument, "invalid health check remediation: '%s'", remediation)
+}
+
+// healthChecker periodically checks that the guest of a pod responds and that
+// the containers of the pod respond while their init process runs. Once
+// `healthCheckFailureThreshold` checks in a row fail the pod is reported
+// unhealthy and `remediation` is applied.
+type healthChecker struct {
+	p           *pod
+	interval    time.Duration
+	remediation string
+	failures    int
+}
+
+// run checks the health of the pod every `interval` until its sandbox task
+// exits.
+func (hc *healthChecker) run(ctx context.Context) {
+	exited := make(chan struct{})
+	go func() {
+		hc.p.sandboxTask.Wait()
+		close(exited)
+	}()
+	t := time.NewTicker(hc.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-exited:
+			return
+		case <-t.C:
+		}
+		if err := hc.check(ctx); err != nil {
+			hc.fail(ctx, err)
+		} else if hc.failures != 0 {
+			log.G(ctx).WithField("pod-id", hc.p.id).Info("pod health check passed")
+			hc.failures = 0
+		}
+	}
+}
+
+// check checks the health of the pod once, failing if it does not respond
+// within `interval`.
+func (hc *healthChecker) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.interval)
+	defer cancel()
+
+	if hc.p.host != nil {
+		start := time.Now()
+		if err := hc.p.host.Ping(ctx); err != nil {
+			return errors.Wrap(err, "guest failed to respond")
+		}
+		if d := time.Since(start); d > healthCheckSlowResponse {
+			log.G(ctx).WithFields(logrus.Fields{
+				"pod-id":   hc.p.id,
+				"duration": d,
+			}).Warning("slow guest response")
+		}
+	}
+	tasks := []shimTask{hc.p.sandboxTask}
+	hc.p.workloadTasks.Range(func(key, value interface{}) bool {
+		// A nil task is a task that is still being created.
+		if wt, ok := value.(shimTask); ok {
+			tasks = append(tasks, wt)
+		}
+		return true
+	})
+	for _, t := range tasks {
+		if err := t.CheckHealth(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail records a failed health check and, once the pod is unhealthy, reports
+// it and applies the remediation.
+func (hc *healthChecker) fail(ctx context.Context, err error) {
+	hc.failures++
+	log.G(ctx).WithError(err).WithFields(logrus.Fields{
+		"pod-id":   hc.p.id,
+		"failures": hc.failures,
+	}).Warning("pod health check failed")
+	if hc.failures != healthCheckFailureThreshold {
+		return
+	}
+
+	eventlog.Error(ctx, eventlog.EventPodUnhealthy, "a pod failed its health checks", map[string]interface{}{
+		"pod-id":   hc.p.id,
+		"error":    err,
+		"failures": hc.failures,
+	})
+	if hc.remediation == "" {
+		return
+	}
+
+	sctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	guestStacks := hc.p.sandboxTask.DumpGuestStacks(sctx)
+	cancel()
+	log.G(ctx).WithFields(logrus.Fields{
+		"pod-id":      hc.p.id,
+		"stacks":      shimStacks(),
+		"guestStacks": guestStacks,
+	}).Error("unhealthy pod stacks")
+
+	if hc.remediation != healthRemediationRestartPod {
+		return
+	}
+	log.G(ctx).WithField("pod-id", hc.p.id).Error("terminating unhealthy pod")
+	if hc.p.host != nil {
+		err = hc.p.host.Terminate(ctx)
+	} else {
+		err = hc.p.sandboxTask.KillExec(ctx, "", 0x9, true)
+	}
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("pod-id", hc.p.id).Error("failed to terminate unhealthy pod")
+	}
+}
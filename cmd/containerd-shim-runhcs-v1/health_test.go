@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/containerd/containerd/errdefs"
+)
+
+func Test_validateHealthRemediation(t *testing.T) {
+	for _, r := range []string{"", healthRemediationCollectLogs, healthRemediationRestartPod} {
+		if err := validateHealthRemediation(r); err != nil {
+			t.Fatalf("expected no error for '%s', got: %v", r, err)
+		}
+	}
+	if err := validateHealthRemediation("reboot"); !errdefs.IsInvalidArgument(err) {
+		t.Fatalf("expected `errdefs.ErrInvalidArgument`, got: %v", err)
+	}
+}
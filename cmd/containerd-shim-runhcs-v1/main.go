@@ -10,6 +10,7 @@ import (
 	"github.com/Microsoft/go-winio/pkg/etw"
 	"github.com/Microsoft/go-winio/pkg/etwlogrus"
 	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
@@ -58,6 +59,11 @@ func etwCallback(sourceID guid.GUID, state etw.ProviderState, level etw.Level, m
 }
 
 func main() {
+	// Redaction must be set up before any hook that writes entries out.
+	if err := log.SetupRedactionFromEnvironment(); err != nil {
+		logrus.Error(err)
+	}
+
 	// Provider ID: 0b52781f-b24d-5685-ddf6-69830ed40ec3
 	// Provider and hook aren't closed explicitly, as they will exist until process exit.
 	provider, err := etw.NewProvider("Microsoft.Virtualization.RunHCS", etwCallback)
@@ -65,7 +71,8 @@ func main() {
 		logrus.Error(err)
 	} else {
 		if hook, err := etwlogrus.NewHookFromProvider(provider); err == nil {
-			logrus.AddHook(hook)
+			// The spans are written to the provider by the ETW exporter.
+			logrus.AddHook(&oc.SkipSpansHook{Hook: hook})
 		} else {
 			logrus.Error(err)
 		}
@@ -82,6 +89,9 @@ func main() {
 	// Register our OpenCensus logrus exporter
 	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
 	trace.RegisterExporter(&oc.LogrusExporter{})
+	if provider != nil {
+		trace.RegisterExporter(&oc.ETWExporter{Provider: provider, Redactor: log.EnvironmentRedactor()})
+	}
 
 	app := cli.NewApp()
 	app.Name = "containerd-shim-runhcs-v1"
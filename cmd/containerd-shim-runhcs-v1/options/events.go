@@ -0,0 +1,21 @@
+package options
+
+import "github.com/containerd/typeurl"
+
+// TaskCrashDumpEventTopic is the topic of the TaskCrashDump event, published
+// when the UVM of a task crashed or its GCS connection failed and a dump of it
+// was written to the `CrashDumpDirectory` of the shim options.
+const TaskCrashDumpEventTopic = "/tasks/crashdump"
+
+// TaskCrashDump is the event published on TaskCrashDumpEventTopic. It is
+// registered with typeurl and so is marshaled as JSON.
+type TaskCrashDump struct {
+	// ContainerID is the ID of the task whose UVM crashed.
+	ContainerID string `json:"container_id"`
+	// Path is the host path of the dump.
+	Path string `json:"path"`
+}
+
+func init() {
+	typeurl.Register(&TaskCrashDump{}, "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options", "TaskCrashDump")
+}
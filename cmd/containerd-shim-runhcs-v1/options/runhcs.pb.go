@@ -135,7 +135,69 @@ type Options struct {
 	ShareScratch bool `protobuf:"varint,14,opt,name=share_scratch,json=shareScratch,proto3" json:"share_scratch,omitempty"`
 	//NCProxyAddr is the address of the network configuration proxy service. If omitted
 	// the network is setup locally.
-	NCProxyAddr          string   `protobuf:"bytes,15,opt,name=NCProxyAddr,proto3" json:"NCProxyAddr,omitempty"`
+	NCProxyAddr string `protobuf:"bytes,15,opt,name=NCProxyAddr,proto3" json:"NCProxyAddr,omitempty"`
+	// pipe_security_descriptor is the SDDL applied to the named pipes served
+	// by the shim. If omitted the default named pipe ACL is used.
+	PipeSecurityDescriptor string `protobuf:"bytes,16,opt,name=pipe_security_descriptor,json=pipeSecurityDescriptor,proto3" json:"pipe_security_descriptor,omitempty"`
+	// compute_agent_pipe_security_descriptor is the SDDL applied to the
+	// compute agent named pipe of a pod. If omitted the default named pipe
+	// ACL is used.
+	ComputeAgentPipeSecurityDescriptor string `protobuf:"bytes,17,opt,name=compute_agent_pipe_security_descriptor,json=computeAgentPipeSecurityDescriptor,proto3" json:"compute_agent_pipe_security_descriptor,omitempty"`
+	// health_check_interval_in_seconds is the interval at which the shim
+	// probes the responsiveness of the guest of a pod and the init processes
+	// of its containers. If 0 or omitted, health checks are disabled.
+	HealthCheckIntervalInSeconds int32 `protobuf:"varint,18,opt,name=health_check_interval_in_seconds,json=healthCheckIntervalInSeconds,proto3" json:"health_check_interval_in_seconds,omitempty"`
+	// health_check_remediation is the action taken when a health check
+	// fails: `collect_logs` logs the guest and shim stacks, `restart_pod`
+	// also terminates the pod so that it is restarted. If omitted, failures
+	// are only reported.
+	HealthCheckRemediation string `protobuf:"bytes,19,opt,name=health_check_remediation,json=healthCheckRemediation,proto3" json:"health_check_remediation,omitempty"`
+	// admission_plugin_dir is a directory of executables that are run, in
+	// lexical order, to inspect and mutate the OCI spec of every task before
+	// it is created. A plugin rejects the task by exiting non-zero. If omitted,
+	// no admission plugins are run.
+	AdmissionPluginDir string `protobuf:"bytes,20,opt,name=admission_plugin_dir,json=admissionPluginDir,proto3" json:"admission_plugin_dir,omitempty"`
+	// node_shares is a comma separated list of the read-only host directories,
+	// each of the form `<name>=<host directory>`, that are shared into every UVM
+	// when it starts. Containers mount a share with a `nodeshare://<name>[/<path>]`
+	// mount source. If omitted, no directories are shared.
+	NodeShares string `protobuf:"bytes,21,opt,name=node_shares,json=nodeShares,proto3" json:"node_shares,omitempty"`
+	// tpm_state_directory is a host directory, owned by the shim, that the TPM state of every UVM with a TPM is kept in. Each UVM gets its own state file, which is removed when the UVM is deleted. If omitted, the TPM state is transient.
+	TpmStateDirectory string `protobuf:"bytes,22,opt,name=tpm_state_directory,json=tpmStateDirectory,proto3" json:"tpm_state_directory,omitempty"`
+	// console_log_directory is a host directory that the serial console output of every LCOW UVM, such as kernel panics, is appended to, in a file named `<UVM ID>-console.log`. The directory is created if needed. If omitted, the console output is not written to the host.
+	ConsoleLogDirectory string `protobuf:"bytes,23,opt,name=console_log_directory,json=consoleLogDirectory,proto3" json:"console_log_directory,omitempty"`
+	// oci_hooks_path is the absolute path of a directory in the guest of every LCOW UVM that the OCI hooks of containers are run from. Containers with a hook outside of the directory fail to create. If omitted, hooks are ignored.
+	OciHooksPath string `protobuf:"bytes,24,opt,name=oci_hooks_path,json=ociHooksPath,proto3" json:"oci_hooks_path,omitempty"`
+	// crash_dump_directory is a host directory that the dumps of every UVM
+	// whose guest crashes or whose GCS connection fails are written to, named
+	// after the UVM ID. The directory is created if needed. LCOW dumps require
+	// Windows Server 2022 or later. If omitted, no dumps are written.
+	CrashDumpDirectory string `protobuf:"bytes,25,opt,name=crash_dump_directory,json=crashDumpDirectory,proto3" json:"crash_dump_directory,omitempty"`
+	// approved_extensions is a comma separated list of the names of the extension
+	// VHDs, installed in the extensions directory of the LCOW boot files, that
+	// pods can attach with the extensions annotation. An extension that is not
+	// listed is rejected even if it is installed. If omitted, no extensions can
+	// be attached.
+	ApprovedExtensions string `protobuf:"bytes,26,opt,name=approved_extensions,json=approvedExtensions,proto3" json:"approved_extensions,omitempty"`
+	// allow_exec_escalation allows the exec processes of LCOW containers to have
+	// capabilities that the init process of the container does not have, and to
+	// replace the seccomp profile of a container that has one. If omitted, the
+	// capabilities of an exec must be a subset of those of the init process and
+	// only an exec of a container without a seccomp profile can set one.
+	AllowExecEscalation bool `protobuf:"varint,27,opt,name=allow_exec_escalation,json=allowExecEscalation,proto3" json:"allow_exec_escalation,omitempty"`
+	// enforce_read_only_rootfs makes the root file system of every container of an LCOW
+	// UVM read-only, with tmpfs mounts on its writable paths, regardless of the
+	// annotations of the pod or the container. If omitted, each container chooses.
+	EnforceReadOnlyRootfs bool `protobuf:"varint,28,opt,name=enforce_read_only_rootfs,json=enforceReadOnlyRootfs,proto3" json:"enforce_read_only_rootfs,omitempty"`
+	// dns_proxy_upstream is the host address (host[:port]) of a DNS server that the
+	// queries of LCOW guests whose pods opt in with the DNS proxy annotation are
+	// relayed to over vsock. If omitted, pods can not use the DNS proxy.
+	DnsProxyUpstream string `protobuf:"bytes,29,opt,name=dns_proxy_upstream,json=dnsProxyUpstream,proto3" json:"dns_proxy_upstream,omitempty"`
+	// allow_forwarded_ports allows pods to relay guest TCP ports of LCOW UVMs from
+	// the host loopback address with the forwarded ports annotation. This is
+	// intended for single node development setups only. If omitted, the annotation
+	// is rejected.
+	AllowForwardedPorts  bool     `protobuf:"varint,30,opt,name=allow_forwarded_ports,json=allowForwardedPorts,proto3" json:"allow_forwarded_ports,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -234,64 +296,87 @@ func init() {
 }
 
 var fileDescriptor_b643df6839c75082 = []byte{
-	// 899 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x94, 0x5b, 0x6f, 0xdb, 0x36,
-	0x18, 0x86, 0xad, 0x26, 0x71, 0xac, 0x2f, 0x71, 0xe2, 0x70, 0x06, 0x26, 0xa4, 0xab, 0x6d, 0xa4,
-	0x03, 0x9a, 0x62, 0x8d, 0x94, 0x74, 0x97, 0x1b, 0x30, 0xd4, 0x87, 0xb4, 0x1e, 0x9a, 0x44, 0x90,
-	0xb3, 0x74, 0x87, 0x0b, 0x42, 0x07, 0x5a, 0x16, 0x6a, 0x8a, 0x02, 0x49, 0x7b, 0x71, 0xaf, 0xf6,
-	0x13, 0xf6, 0xb3, 0x72, 0xb9, 0xcb, 0x0d, 0x03, 0xb2, 0xd5, 0xbf, 0x64, 0x10, 0x45, 0xb9, 0x5d,
-	0x10, 0xec, 0x66, 0x57, 0xa6, 0xde, 0xef, 0xe1, 0xcb, 0x8f, 0x87, 0xd7, 0x70, 0x11, 0x27, 0x72,
-	0x32, 0x0b, 0xec, 0x90, 0x51, 0xe7, 0x2c, 0x09, 0x39, 0x13, 0x6c, 0x2c, 0x9d, 0x49, 0x28, 0xc4,
-	0x24, 0xa1, 0x4e, 0x48, 0x23, 0x27, 0x64, 0xa9, 0xf4, 0x93, 0x94, 0xf0, 0xe8, 0x28, 0xd7, 0x8e,
-	0xf8, 0x2c, 0x9d, 0x84, 0xe2, 0x68, 0x7e, 0xe2, 0xb0, 0x4c, 0x26, 0x2c, 0x15, 0x4e, 0xa1, 0xd8,
-	0x19, 0x67, 0x92, 0xa1, 0xe6, 0x07, 0xde, 0xd6, 0x85, 0xf9, 0xc9, 0x7e, 0x33, 0x66, 0x31, 0x53,
-	0x80, 0x93, 0x8f, 0x0a, 0x76, 0xbf, 0x1d, 0x33, 0x16, 0x4f, 0x89, 0xa3, 0xbe, 0x82, 0xd9, 0xd8,
-	0x91, 0x09, 0x25, 0x42, 0xfa, 0x34, 0x2b, 0x80, 0x83, 0x3f, 0xaa, 0xb0, 0x79, 0x51, 0xac, 0x82,
-	0x9a, 0xb0, 0x11, 0x91, 0x60, 0x16, 0x5b, 0x46, 0xc7, 0x38, 0xac, 0x79, 0xc5, 0x07, 0x3a, 0x05,
-	0x50, 0x03, 0x2c, 0x17, 0x19, 0xb1, 0x1e, 0x74, 0x8c, 0xc3, 0x9d, 0xe7, 0x4f, 0xec, 0xfb, 0x7a,
-	0xb0, 0xb5, 0x91, 0xdd, 0xcf, 0xf9, 0xcb, 0x45, 0x46, 0x3c, 0x33, 0x2a, 0x87, 0xe8, 0x31, 0xd4,
-	0x39, 0x89, 0x13, 0x21, 0xf9, 0x02, 0x73, 0xc6, 0xa4, 0xb5, 0xd6, 0x31, 0x0e, 0x4d, 0x6f, 0xbb,
-	0x14, 0x3d, 0xc6, 0x64, 0x0e, 0x09, 0x3f, 0x8d, 0x02, 0x76, 0x8d, 0x13, 0xea, 0xc7, 0xc4, 0x5a,
-	0x2f, 0x20, 0x2d, 0x0e, 0x73, 0x0d, 0x3d, 0x85, 0x46, 0x09, 0x65, 0x53, 0x5f, 0x8e, 0x19, 0xa7,
-	0xd6, 0x86, 0xe2, 0x76, 0xb5, 0xee, 0x6a, 0x19, 0xfd, 0x04, 0x7b, 0x2b, 0x3f, 0xc1, 0xa6, 0x7e,
-	0xde, 0x9f, 0x55, 0x55, 0x7b, 0xb0, 0xff, 0x7b, 0x0f, 0x23, 0xbd, 0x62, 0x39, 0xcb, 0x2b, 0xd7,
-	0x5c, 0x29, 0xc8, 0x81, 0x66, 0xc0, 0x98, 0xc4, 0xe3, 0x64, 0x4a, 0x84, 0xda, 0x13, 0xce, 0x7c,
-	0x39, 0xb1, 0x36, 0x55, 0x2f, 0x7b, 0x79, 0xed, 0x34, 0x2f, 0xe5, 0x3b, 0x73, 0x7d, 0x39, 0x41,
-	0xcf, 0x00, 0xcd, 0x29, 0xce, 0x38, 0x0b, 0x89, 0x10, 0x8c, 0xe3, 0x90, 0xcd, 0x52, 0x69, 0xd5,
-	0x3a, 0xc6, 0xe1, 0x86, 0xd7, 0x98, 0x53, 0xb7, 0x2c, 0xf4, 0x72, 0x1d, 0xd9, 0xd0, 0x9c, 0x53,
-	0x4c, 0x09, 0x65, 0x7c, 0x81, 0x45, 0xf2, 0x8e, 0xe0, 0x24, 0xc5, 0x34, 0xb0, 0xcc, 0x92, 0x3f,
-	0x53, 0xa5, 0x51, 0xf2, 0x8e, 0x0c, 0xd3, 0xb3, 0x00, 0xb5, 0x00, 0x5e, 0xba, 0xdf, 0x5d, 0xbd,
-	0xea, 0xe7, 0x6b, 0x59, 0xa0, 0x9a, 0xf8, 0x48, 0x41, 0x5f, 0xc3, 0x43, 0x11, 0xfa, 0x53, 0x82,
-	0xc3, 0x6c, 0x86, 0xa7, 0x09, 0x4d, 0xa4, 0xc0, 0x92, 0x61, 0xbd, 0x2d, 0x6b, 0x4b, 0x5d, 0xfa,
-	0xa7, 0x0a, 0xe9, 0x65, 0xb3, 0xd7, 0x0a, 0xb8, 0x64, 0xfa, 0x1c, 0xd0, 0x19, 0x7c, 0x1e, 0x91,
-	0xb1, 0x3f, 0x9b, 0x4a, 0xbc, 0x3a, 0x37, 0x2c, 0x42, 0xee, 0xcb, 0x70, 0xb2, 0xea, 0x2e, 0x0e,
-	0xac, 0x6d, 0xd5, 0x5d, 0x5b, 0xb3, 0xbd, 0x12, 0x1d, 0x15, 0x64, 0xd1, 0xec, 0xcb, 0x00, 0x7d,
-	0x03, 0x8f, 0x4a, 0xbb, 0x39, 0xbd, 0xcf, 0xa7, 0xae, 0x7c, 0x2c, 0x0d, 0x5d, 0xd1, 0xbb, 0x06,
-	0xf9, 0x4b, 0x99, 0xf8, 0x9c, 0x94, 0x73, 0xad, 0x1d, 0xd5, 0xff, 0xb6, 0x12, 0x35, 0x8c, 0x3a,
-	0xb0, 0x75, 0xde, 0x73, 0x39, 0xbb, 0x5e, 0xbc, 0x88, 0x22, 0x6e, 0xed, 0xaa, 0x33, 0xf9, 0x58,
-	0x3a, 0x78, 0x0a, 0xe6, 0xea, 0xb5, 0x22, 0x13, 0x36, 0xce, 0xdd, 0xa1, 0x3b, 0x68, 0x54, 0x50,
-	0x0d, 0xd6, 0x4f, 0x87, 0xaf, 0x07, 0x0d, 0x03, 0x6d, 0xc2, 0xda, 0xe0, 0xf2, 0x4d, 0xe3, 0xc1,
-	0x81, 0x03, 0x8d, 0xbb, 0x8f, 0x02, 0x6d, 0xc1, 0xa6, 0xeb, 0x5d, 0xf4, 0x06, 0xa3, 0x51, 0xa3,
-	0x82, 0x76, 0x00, 0x5e, 0xfd, 0xe0, 0x0e, 0xbc, 0xab, 0xe1, 0xe8, 0xc2, 0x6b, 0x18, 0x07, 0x7f,
-	0xae, 0xc1, 0x8e, 0xbe, 0xd3, 0x3e, 0x91, 0x7e, 0x32, 0x15, 0xe8, 0x11, 0x80, 0x7a, 0xd7, 0x38,
-	0xf5, 0x29, 0x51, 0x39, 0x33, 0x3d, 0x53, 0x29, 0xe7, 0x3e, 0x25, 0xa8, 0x07, 0x10, 0x72, 0xe2,
-	0x4b, 0x12, 0x61, 0x5f, 0xaa, 0xac, 0x6d, 0x3d, 0xdf, 0xb7, 0x8b, 0x0c, 0xdb, 0x65, 0x86, 0xed,
-	0xcb, 0x32, 0xc3, 0xdd, 0xda, 0xcd, 0x6d, 0xbb, 0xf2, 0xeb, 0x5f, 0x6d, 0xc3, 0x33, 0xf5, 0xbc,
-	0x17, 0x12, 0x7d, 0x01, 0xe8, 0x2d, 0xe1, 0x29, 0x99, 0xe2, 0x3c, 0xec, 0xf8, 0xe4, 0xf8, 0x18,
-	0xa7, 0x42, 0xa5, 0x6d, 0xdd, 0xdb, 0x2d, 0x2a, 0xb9, 0xc3, 0xc9, 0xf1, 0xf1, 0xb9, 0x40, 0x36,
-	0x7c, 0xa2, 0x5f, 0x58, 0xc8, 0x28, 0x4d, 0x24, 0x0e, 0x16, 0x92, 0x08, 0x15, 0xbb, 0x75, 0x6f,
-	0xaf, 0x28, 0xf5, 0x54, 0xa5, 0x9b, 0x17, 0xd0, 0x29, 0x74, 0x34, 0xff, 0x33, 0xe3, 0x6f, 0x93,
-	0x34, 0xc6, 0x82, 0x48, 0x9c, 0xf1, 0x64, 0xee, 0x4b, 0xa2, 0x27, 0x6f, 0xa8, 0xc9, 0x9f, 0x15,
-	0xdc, 0x9b, 0x02, 0x1b, 0x11, 0xe9, 0x16, 0x50, 0xe1, 0xd3, 0x87, 0xf6, 0x3d, 0x3e, 0xea, 0xf2,
-	0x22, 0x6d, 0x53, 0x55, 0x36, 0x0f, 0xef, 0xda, 0x8c, 0x14, 0x53, 0xb8, 0x3c, 0x03, 0xd0, 0x69,
-	0xc2, 0x49, 0xa4, 0x72, 0x57, 0xef, 0xd6, 0x97, 0xb7, 0x6d, 0x53, 0x1f, 0xfb, 0xb0, 0xef, 0x99,
-	0x1a, 0x18, 0x46, 0xe8, 0x09, 0x34, 0x66, 0x82, 0xf0, 0x7f, 0x1d, 0x4b, 0x4d, 0x2d, 0x52, 0xcf,
-	0xf5, 0x0f, 0x87, 0xf2, 0x18, 0x36, 0xc9, 0x35, 0x09, 0x73, 0xcf, 0x3c, 0x6c, 0x66, 0x17, 0x96,
-	0xb7, 0xed, 0xea, 0xe0, 0x9a, 0x84, 0xc3, 0xbe, 0x57, 0xcd, 0x4b, 0xc3, 0xa8, 0x1b, 0xdd, 0xbc,
-	0x6f, 0x55, 0x7e, 0x7f, 0xdf, 0xaa, 0xfc, 0xb2, 0x6c, 0x19, 0x37, 0xcb, 0x96, 0xf1, 0xdb, 0xb2,
-	0x65, 0xfc, 0xbd, 0x6c, 0x19, 0x3f, 0x7e, 0xfb, 0xff, 0xff, 0xf1, 0xbf, 0xd2, 0xbf, 0xdf, 0x57,
-	0x82, 0xaa, 0xba, 0xf7, 0x2f, 0xff, 0x09, 0x00, 0x00, 0xff, 0xff, 0xc9, 0x0d, 0x33, 0xad, 0x48,
-	0x06, 0x00, 0x00,
+	// 1278 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcb, 0x6e, 0x1b, 0x37,
+	0x14, 0xb5, 0x12, 0xbf, 0x44, 0x3f, 0x22, 0xd3, 0x4a, 0x32, 0x75, 0x12, 0xc9, 0x70, 0x82, 0xc6,
+	0x41, 0x13, 0xc9, 0x4e, 0x17, 0x2d, 0xd0, 0x02, 0x45, 0x2c, 0xc9, 0x89, 0x8a, 0xd8, 0x16, 0x46,
+	0x4e, 0xd2, 0xc7, 0x82, 0xa0, 0x48, 0x4a, 0x22, 0x3c, 0x1c, 0x0e, 0x48, 0x8e, 0x62, 0x65, 0xd5,
+	0x4f, 0xe8, 0x67, 0x65, 0xd9, 0x65, 0x81, 0x02, 0x69, 0xe3, 0xaf, 0xe8, 0xb2, 0xe0, 0x43, 0xb2,
+	0x63, 0xb8, 0xdd, 0x74, 0xe5, 0xd1, 0x39, 0xe7, 0x9e, 0xb9, 0xbc, 0x73, 0x79, 0xaf, 0xc1, 0xd1,
+	0x80, 0x9b, 0x61, 0xde, 0xab, 0x11, 0x29, 0xea, 0x07, 0x9c, 0x28, 0xa9, 0x65, 0xdf, 0xd4, 0x87,
+	0x44, 0xeb, 0x21, 0x17, 0x75, 0x22, 0x68, 0x9d, 0xc8, 0xd4, 0x60, 0x9e, 0x32, 0x45, 0x9f, 0x58,
+	0xec, 0x89, 0xca, 0xd3, 0x21, 0xd1, 0x4f, 0x46, 0xbb, 0x75, 0x99, 0x19, 0x2e, 0x53, 0x5d, 0xf7,
+	0x48, 0x2d, 0x53, 0xd2, 0x48, 0x58, 0x3e, 0xd7, 0xd7, 0x02, 0x31, 0xda, 0xdd, 0x28, 0x0f, 0xe4,
+	0x40, 0x3a, 0x41, 0xdd, 0x3e, 0x79, 0xed, 0x46, 0x75, 0x20, 0xe5, 0x20, 0x61, 0x75, 0xf7, 0xab,
+	0x97, 0xf7, 0xeb, 0x86, 0x0b, 0xa6, 0x0d, 0x16, 0x99, 0x17, 0x6c, 0xfd, 0xbd, 0x0c, 0x16, 0x8e,
+	0xfc, 0x5b, 0x60, 0x19, 0xcc, 0x51, 0xd6, 0xcb, 0x07, 0x51, 0x61, 0xb3, 0xb0, 0xbd, 0x18, 0xfb,
+	0x1f, 0x70, 0x1f, 0x00, 0xf7, 0x80, 0xcc, 0x38, 0x63, 0xd1, 0xb5, 0xcd, 0xc2, 0xf6, 0xea, 0xd3,
+	0x87, 0xb5, 0xab, 0x72, 0xa8, 0x05, 0xa3, 0x5a, 0xd3, 0xea, 0x8f, 0xc7, 0x19, 0x8b, 0x8b, 0x74,
+	0xf2, 0x08, 0xef, 0x83, 0x15, 0xc5, 0x06, 0x5c, 0x1b, 0x35, 0x46, 0x4a, 0x4a, 0x13, 0x5d, 0xdf,
+	0x2c, 0x6c, 0x17, 0xe3, 0xe5, 0x09, 0x18, 0x4b, 0x69, 0xac, 0x48, 0xe3, 0x94, 0xf6, 0xe4, 0x29,
+	0xe2, 0x02, 0x0f, 0x58, 0x34, 0xeb, 0x45, 0x01, 0x6c, 0x5b, 0x0c, 0x3e, 0x02, 0xa5, 0x89, 0x28,
+	0x4b, 0xb0, 0xe9, 0x4b, 0x25, 0xa2, 0x39, 0xa7, 0xbb, 0x11, 0xf0, 0x4e, 0x80, 0xe1, 0xcf, 0x60,
+	0x6d, 0xea, 0xa7, 0x65, 0x82, 0x6d, 0x7e, 0xd1, 0xbc, 0x3b, 0x43, 0xed, 0xbf, 0xcf, 0xd0, 0x0d,
+	0x6f, 0x9c, 0x44, 0xc5, 0x25, 0x7d, 0x09, 0x81, 0x75, 0x50, 0xee, 0x49, 0x69, 0x50, 0x9f, 0x27,
+	0x4c, 0xbb, 0x33, 0xa1, 0x0c, 0x9b, 0x61, 0xb4, 0xe0, 0x72, 0x59, 0xb3, 0xdc, 0xbe, 0xa5, 0xec,
+	0xc9, 0x3a, 0xd8, 0x0c, 0xe1, 0x63, 0x00, 0x47, 0x02, 0x65, 0x4a, 0x12, 0xa6, 0xb5, 0x54, 0x88,
+	0xc8, 0x3c, 0x35, 0xd1, 0xe2, 0x66, 0x61, 0x7b, 0x2e, 0x2e, 0x8d, 0x44, 0x67, 0x42, 0x34, 0x2c,
+	0x0e, 0x6b, 0xa0, 0x3c, 0x12, 0x48, 0x30, 0x21, 0xd5, 0x18, 0x69, 0xfe, 0x8e, 0x21, 0x9e, 0x22,
+	0xd1, 0x8b, 0x8a, 0x13, 0xfd, 0x81, 0xa3, 0xba, 0xfc, 0x1d, 0x6b, 0xa7, 0x07, 0x3d, 0x58, 0x01,
+	0xe0, 0x79, 0xe7, 0xd5, 0xeb, 0x17, 0x4d, 0xfb, 0xae, 0x08, 0xb8, 0x24, 0x2e, 0x20, 0xf0, 0x5b,
+	0x70, 0x47, 0x13, 0x9c, 0x30, 0x44, 0xb2, 0x1c, 0x25, 0x5c, 0x70, 0xa3, 0x91, 0x91, 0x28, 0x1c,
+	0x2b, 0x5a, 0x72, 0x1f, 0xfd, 0xb6, 0x93, 0x34, 0xb2, 0xfc, 0xa5, 0x13, 0x1c, 0xcb, 0x50, 0x07,
+	0x78, 0x00, 0x1e, 0x50, 0xd6, 0xc7, 0x79, 0x62, 0xd0, 0xb4, 0x6e, 0x48, 0x13, 0x85, 0x0d, 0x19,
+	0x4e, 0xb3, 0x1b, 0xf4, 0xa2, 0x65, 0x97, 0x5d, 0x35, 0x68, 0x1b, 0x13, 0x69, 0xd7, 0x2b, 0x7d,
+	0xb2, 0xcf, 0x7b, 0xf0, 0x3b, 0x70, 0x6f, 0x62, 0x37, 0x12, 0x57, 0xf9, 0xac, 0x38, 0x9f, 0x28,
+	0x88, 0x5e, 0x8b, 0xcb, 0x06, 0xb6, 0x53, 0x86, 0x58, 0xb1, 0x49, 0x6c, 0xb4, 0xea, 0xf2, 0x5f,
+	0x76, 0x60, 0x10, 0xc3, 0x4d, 0xb0, 0x74, 0xd8, 0xe8, 0x28, 0x79, 0x3a, 0x7e, 0x46, 0xa9, 0x8a,
+	0x6e, 0xb8, 0x9a, 0x5c, 0x84, 0xe0, 0xd7, 0x20, 0xca, 0x78, 0xc6, 0x90, 0x66, 0x24, 0x57, 0xdc,
+	0x8c, 0x11, 0x65, 0x9a, 0x28, 0x9e, 0x19, 0xa9, 0xa2, 0x92, 0x93, 0xdf, 0xb2, 0x7c, 0x37, 0xd0,
+	0xcd, 0x29, 0x0b, 0x63, 0xf0, 0x39, 0x91, 0x22, 0xcb, 0x0d, 0x43, 0x78, 0xc0, 0x52, 0x83, 0xfe,
+	0xd5, 0x67, 0xcd, 0xf9, 0x6c, 0x05, 0xf5, 0x33, 0x2b, 0xee, 0x5c, 0xed, 0xb9, 0x0f, 0x36, 0x87,
+	0x0c, 0x27, 0x66, 0x88, 0xc8, 0x90, 0x91, 0x13, 0xc4, 0x53, 0xc3, 0xd4, 0x08, 0x27, 0xb6, 0x26,
+	0x9a, 0x11, 0x99, 0x52, 0x1d, 0x41, 0x57, 0x98, 0xbb, 0x5e, 0xd7, 0xb0, 0xb2, 0x76, 0x50, 0xb5,
+	0xd3, 0xae, 0xd7, 0xd8, 0x53, 0x7d, 0xe2, 0xa3, 0x98, 0x60, 0x94, 0xfb, 0xee, 0x5f, 0xf7, 0xa7,
+	0xba, 0x10, 0x1f, 0x9f, 0xb3, 0x70, 0x07, 0x94, 0x31, 0x15, 0x5c, 0x6b, 0x2e, 0x53, 0x94, 0x25,
+	0xf9, 0x80, 0xa7, 0x88, 0x72, 0x15, 0x95, 0x5d, 0x14, 0x9c, 0x72, 0x1d, 0x47, 0x35, 0xb9, 0x82,
+	0x55, 0xb0, 0x94, 0x4a, 0xca, 0x90, 0x2b, 0xbc, 0x8e, 0x6e, 0xfa, 0xbe, 0xb3, 0x50, 0xd7, 0x21,
+	0xb0, 0x06, 0xd6, 0x4d, 0x26, 0x90, 0x36, 0xd8, 0x30, 0xeb, 0xc5, 0x88, 0x91, 0x6a, 0x1c, 0xdd,
+	0xf2, 0xb7, 0xc4, 0x64, 0xa2, 0x6b, 0x99, 0xe6, 0x84, 0x80, 0x4f, 0xc1, 0x4d, 0x22, 0x53, 0x2d,
+	0x13, 0x86, 0x12, 0x39, 0xb8, 0x10, 0x71, 0xdb, 0x45, 0xac, 0x07, 0xf2, 0xa5, 0x1c, 0x9c, 0xc7,
+	0x3c, 0x00, 0xab, 0x92, 0x70, 0x34, 0x94, 0xf2, 0x44, 0xfb, 0x4b, 0x18, 0xf9, 0xc1, 0x21, 0x09,
+	0x7f, 0x61, 0x41, 0x77, 0x03, 0x76, 0x40, 0x99, 0x28, 0xac, 0x87, 0x88, 0xe6, 0x22, 0xbb, 0x60,
+	0xfc, 0x99, 0x3f, 0x9c, 0xe3, 0x9a, 0xb9, 0xc8, 0xce, 0x7d, 0xeb, 0x60, 0x1d, 0x67, 0x99, 0x92,
+	0x23, 0x46, 0x11, 0x3b, 0x35, 0x2c, 0xb5, 0x67, 0xd7, 0xd1, 0x46, 0xa8, 0x46, 0xa0, 0x5a, 0x53,
+	0xc6, 0x26, 0x8f, 0x93, 0x44, 0xbe, 0x45, 0xec, 0x94, 0x11, 0xc4, 0xec, 0x6d, 0xf2, 0x65, 0xbf,
+	0xe3, 0xda, 0x73, 0xdd, 0x91, 0xad, 0x53, 0x46, 0x5a, 0x53, 0x0a, 0x7e, 0x05, 0x22, 0x96, 0xf6,
+	0xa5, 0x22, 0x0c, 0x29, 0x86, 0x29, 0x92, 0x69, 0xe2, 0x47, 0x64, 0x5f, 0x47, 0x77, 0x5d, 0xd8,
+	0xcd, 0xc0, 0xc7, 0x0c, 0xd3, 0xa3, 0x34, 0x71, 0xb3, 0xb2, 0xaf, 0xed, 0x3c, 0xa1, 0xa9, 0xb6,
+	0x03, 0xe5, 0x74, 0x8c, 0xf2, 0x4c, 0x1b, 0xc5, 0xb0, 0x88, 0xee, 0xb9, 0xe4, 0x4a, 0x34, 0xd5,
+	0xae, 0xcd, 0x5f, 0x05, 0xfc, 0x3c, 0xb5, 0xbe, 0x54, 0x6f, 0xb1, 0xa2, 0x8c, 0xa2, 0x4c, 0x2a,
+	0xa3, 0xa3, 0xca, 0x85, 0xd4, 0xf6, 0x27, 0x5c, 0xc7, 0x52, 0x5b, 0x8f, 0x40, 0x71, 0x3a, 0xcc,
+	0x61, 0x11, 0xcc, 0x1d, 0x76, 0xda, 0x9d, 0x56, 0x69, 0x06, 0x2e, 0x82, 0xd9, 0xfd, 0xf6, 0xcb,
+	0x56, 0xa9, 0x00, 0x17, 0xc0, 0xf5, 0xd6, 0xf1, 0x9b, 0xd2, 0xb5, 0xad, 0x3a, 0x28, 0x5d, 0x9e,
+	0x99, 0x70, 0x09, 0x2c, 0x74, 0xe2, 0xa3, 0x46, 0xab, 0xdb, 0x2d, 0xcd, 0xc0, 0x55, 0x00, 0x5e,
+	0xfc, 0xd8, 0x69, 0xc5, 0xaf, 0xdb, 0xdd, 0xa3, 0xb8, 0x54, 0xd8, 0xfa, 0xe3, 0x3a, 0x58, 0x0d,
+	0x23, 0xaf, 0xc9, 0x0c, 0xe6, 0x89, 0x86, 0xf7, 0x00, 0x70, 0x63, 0x1f, 0xa5, 0x58, 0x30, 0xb7,
+	0x86, 0x8a, 0x71, 0xd1, 0x21, 0x87, 0x58, 0x30, 0xd8, 0x00, 0x80, 0x28, 0x86, 0x0d, 0xa3, 0x08,
+	0x1b, 0xb7, 0x8a, 0x96, 0x9e, 0x6e, 0xd4, 0xfc, 0x8a, 0xab, 0x4d, 0x56, 0x5c, 0xed, 0x78, 0xb2,
+	0xe2, 0xf6, 0x16, 0xdf, 0x7f, 0xa8, 0xce, 0xfc, 0xfa, 0x67, 0xb5, 0x10, 0x17, 0x43, 0xdc, 0x33,
+	0x03, 0xbf, 0x00, 0xf0, 0x84, 0xa9, 0x94, 0x25, 0xc8, 0xee, 0x42, 0xb4, 0xbb, 0xb3, 0x83, 0x52,
+	0xed, 0x96, 0xd1, 0x6c, 0x7c, 0xc3, 0x33, 0xd6, 0x61, 0x77, 0x67, 0xe7, 0xd0, 0xf5, 0x6e, 0x18,
+	0xc0, 0x44, 0x0a, 0xc1, 0x0d, 0xea, 0x8d, 0x0d, 0xd3, 0x6e, 0x2b, 0xcd, 0xc6, 0x6b, 0x9e, 0x6a,
+	0x38, 0x66, 0xcf, 0x12, 0xf6, 0x02, 0x07, 0xfd, 0x5b, 0xa9, 0x4e, 0x78, 0x3a, 0x40, 0x9a, 0x19,
+	0x94, 0x29, 0x3e, 0xb2, 0xcd, 0xef, 0x83, 0xe7, 0x5c, 0xf0, 0x5d, 0xaf, 0x7b, 0xe3, 0x65, 0x5d,
+	0x66, 0x3a, 0x5e, 0xe4, 0x7d, 0x9a, 0xa0, 0x7a, 0x85, 0x8f, 0xbb, 0x62, 0x34, 0xd8, 0xcc, 0x3b,
+	0x9b, 0x3b, 0x97, 0x6d, 0xdc, 0xa5, 0xa3, 0xde, 0xe5, 0x31, 0x00, 0x61, 0xd9, 0x20, 0x4e, 0xdd,
+	0x5a, 0x5a, 0xd9, 0x5b, 0x39, 0xfb, 0x50, 0x2d, 0x86, 0xb2, 0xb7, 0x9b, 0x71, 0x31, 0x08, 0xda,
+	0x14, 0x3e, 0x04, 0xa5, 0x5c, 0x33, 0xf5, 0x49, 0x59, 0x16, 0xdd, 0x4b, 0x56, 0x2c, 0x7e, 0x5e,
+	0x94, 0xfb, 0x60, 0xc1, 0x75, 0x37, 0xa7, 0x6e, 0x17, 0x15, 0xf7, 0xc0, 0xd9, 0x87, 0xea, 0xbc,
+	0x6d, 0xea, 0x76, 0x33, 0x9e, 0xb7, 0x54, 0x9b, 0xee, 0xd1, 0xf7, 0x1f, 0x2b, 0x33, 0xbf, 0x7f,
+	0xac, 0xcc, 0xfc, 0x72, 0x56, 0x29, 0xbc, 0x3f, 0xab, 0x14, 0x7e, 0x3b, 0xab, 0x14, 0xfe, 0x3a,
+	0xab, 0x14, 0x7e, 0xfa, 0xfe, 0xff, 0xff, 0x43, 0xf4, 0x4d, 0xf8, 0xfb, 0xc3, 0x4c, 0x6f, 0xde,
+	0x7d, 0xf7, 0x2f, 0xff, 0x19, 0x00, 0x81, 0x58, 0x7c, 0x0a, 0x67, 0x09, 0x00, 0x00,
 }
 
 func (m *Options) Marshal() (dAtA []byte, err error) {
@@ -405,6 +490,137 @@ func (m *Options) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.NCProxyAddr)))
 		i += copy(dAtA[i:], m.NCProxyAddr)
 	}
+	if len(m.PipeSecurityDescriptor) > 0 {
+		dAtA[i] = 0x82
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.PipeSecurityDescriptor)))
+		i += copy(dAtA[i:], m.PipeSecurityDescriptor)
+	}
+	if len(m.ComputeAgentPipeSecurityDescriptor) > 0 {
+		dAtA[i] = 0x8a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.ComputeAgentPipeSecurityDescriptor)))
+		i += copy(dAtA[i:], m.ComputeAgentPipeSecurityDescriptor)
+	}
+	if m.HealthCheckIntervalInSeconds != 0 {
+		dAtA[i] = 0x90
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(m.HealthCheckIntervalInSeconds))
+	}
+	if len(m.HealthCheckRemediation) > 0 {
+		dAtA[i] = 0x9a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.HealthCheckRemediation)))
+		i += copy(dAtA[i:], m.HealthCheckRemediation)
+	}
+	if len(m.AdmissionPluginDir) > 0 {
+		dAtA[i] = 0xa2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.AdmissionPluginDir)))
+		i += copy(dAtA[i:], m.AdmissionPluginDir)
+	}
+	if len(m.NodeShares) > 0 {
+		dAtA[i] = 0xaa
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.NodeShares)))
+		i += copy(dAtA[i:], m.NodeShares)
+	}
+	if len(m.TpmStateDirectory) > 0 {
+		dAtA[i] = 0xb2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.TpmStateDirectory)))
+		i += copy(dAtA[i:], m.TpmStateDirectory)
+	}
+	if len(m.ConsoleLogDirectory) > 0 {
+		dAtA[i] = 0xba
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.ConsoleLogDirectory)))
+		i += copy(dAtA[i:], m.ConsoleLogDirectory)
+	}
+	if len(m.OciHooksPath) > 0 {
+		dAtA[i] = 0xc2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.OciHooksPath)))
+		i += copy(dAtA[i:], m.OciHooksPath)
+	}
+	if len(m.CrashDumpDirectory) > 0 {
+		dAtA[i] = 0xca
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.CrashDumpDirectory)))
+		i += copy(dAtA[i:], m.CrashDumpDirectory)
+	}
+	if len(m.ApprovedExtensions) > 0 {
+		dAtA[i] = 0xd2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.ApprovedExtensions)))
+		i += copy(dAtA[i:], m.ApprovedExtensions)
+	}
+	if m.AllowExecEscalation {
+		dAtA[i] = 0xd8
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.AllowExecEscalation {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.EnforceReadOnlyRootfs {
+		dAtA[i] = 0xe0
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.EnforceReadOnlyRootfs {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.DnsProxyUpstream) > 0 {
+		dAtA[i] = 0xea
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRunhcs(dAtA, i, uint64(len(m.DnsProxyUpstream)))
+		i += copy(dAtA[i:], m.DnsProxyUpstream)
+	}
+	if m.AllowForwardedPorts {
+		dAtA[i] = 0xf0
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.AllowForwardedPorts {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -548,6 +764,62 @@ func (m *Options) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovRunhcs(uint64(l))
 	}
+	l = len(m.PipeSecurityDescriptor)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.ComputeAgentPipeSecurityDescriptor)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	if m.HealthCheckIntervalInSeconds != 0 {
+		n += 2 + sovRunhcs(uint64(m.HealthCheckIntervalInSeconds))
+	}
+	l = len(m.HealthCheckRemediation)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.AdmissionPluginDir)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.NodeShares)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.TpmStateDirectory)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.ConsoleLogDirectory)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.OciHooksPath)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.CrashDumpDirectory)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	l = len(m.ApprovedExtensions)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	if m.AllowExecEscalation {
+		n += 3
+	}
+	if m.EnforceReadOnlyRootfs {
+		n += 3
+	}
+	l = len(m.DnsProxyUpstream)
+	if l > 0 {
+		n += 2 + l + sovRunhcs(uint64(l))
+	}
+	if m.AllowForwardedPorts {
+		n += 3
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -627,6 +899,21 @@ func (this *Options) String() string {
 		`DefaultVmScratchSizeInGb:` + fmt.Sprintf("%v", this.DefaultVmScratchSizeInGb) + `,`,
 		`ShareScratch:` + fmt.Sprintf("%v", this.ShareScratch) + `,`,
 		`NCProxyAddr:` + fmt.Sprintf("%v", this.NCProxyAddr) + `,`,
+		`PipeSecurityDescriptor:` + fmt.Sprintf("%v", this.PipeSecurityDescriptor) + `,`,
+		`ComputeAgentPipeSecurityDescriptor:` + fmt.Sprintf("%v", this.ComputeAgentPipeSecurityDescriptor) + `,`,
+		`HealthCheckIntervalInSeconds:` + fmt.Sprintf("%v", this.HealthCheckIntervalInSeconds) + `,`,
+		`HealthCheckRemediation:` + fmt.Sprintf("%v", this.HealthCheckRemediation) + `,`,
+		`AdmissionPluginDir:` + fmt.Sprintf("%v", this.AdmissionPluginDir) + `,`,
+		`NodeShares:` + fmt.Sprintf("%v", this.NodeShares) + `,`,
+		`TpmStateDirectory:` + fmt.Sprintf("%v", this.TpmStateDirectory) + `,`,
+		`ConsoleLogDirectory:` + fmt.Sprintf("%v", this.ConsoleLogDirectory) + `,`,
+		`OciHooksPath:` + fmt.Sprintf("%v", this.OciHooksPath) + `,`,
+		`CrashDumpDirectory:` + fmt.Sprintf("%v", this.CrashDumpDirectory) + `,`,
+		`ApprovedExtensions:` + fmt.Sprintf("%v", this.ApprovedExtensions) + `,`,
+		`AllowExecEscalation:` + fmt.Sprintf("%v", this.AllowExecEscalation) + `,`,
+		`EnforceReadOnlyRootfs:` + fmt.Sprintf("%v", this.EnforceReadOnlyRootfs) + `,`,
+		`DnsProxyUpstream:` + fmt.Sprintf("%v", this.DnsProxyUpstream) + `,`,
+		`AllowForwardedPorts:` + fmt.Sprintf("%v", this.AllowForwardedPorts) + `,`,
 		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
 		`}`,
 	}, "")
@@ -1054,6 +1341,437 @@ func (m *Options) Unmarshal(dAtA []byte) error {
 			}
 			m.NCProxyAddr = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PipeSecurityDescriptor", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PipeSecurityDescriptor = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ComputeAgentPipeSecurityDescriptor", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ComputeAgentPipeSecurityDescriptor = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 18:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HealthCheckIntervalInSeconds", wireType)
+			}
+			m.HealthCheckIntervalInSeconds = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HealthCheckIntervalInSeconds |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 19:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HealthCheckRemediation", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.HealthCheckRemediation = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AdmissionPluginDir", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AdmissionPluginDir = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 21:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NodeShares", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NodeShares = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 22:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TpmStateDirectory", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TpmStateDirectory = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 23:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConsoleLogDirectory", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ConsoleLogDirectory = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 24:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OciHooksPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OciHooksPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 25:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CrashDumpDirectory", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CrashDumpDirectory = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 26:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApprovedExtensions", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ApprovedExtensions = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 27:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllowExecEscalation", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AllowExecEscalation = bool(v != 0)
+		case 28:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EnforceReadOnlyRootfs", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.EnforceReadOnlyRootfs = bool(v != 0)
+		case 29:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DnsProxyUpstream", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DnsProxyUpstream = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 30:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllowForwardedPorts", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AllowForwardedPorts = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRunhcs(dAtA[iNdEx:])
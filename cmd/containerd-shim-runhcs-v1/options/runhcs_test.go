@@ -0,0 +1,35 @@
+package options
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// Test_Descriptor_Fields checks the compiled-in descriptor against the
+// generated types, so a field added to runhcs.proto without regenerating the
+// descriptor is caught here rather than by a client decoding the options.
+func Test_Descriptor_Fields(t *testing.T) {
+	for _, msg := range []descriptor.Message{&Options{}, &ProcessDetails{}} {
+		_, md := descriptor.ForMessage(msg)
+		fields := make(map[int32]string)
+		for _, f := range md.Field {
+			fields[f.GetNumber()] = f.GetName()
+		}
+		props := proto.GetProperties(reflect.TypeOf(msg).Elem())
+		for _, p := range props.Prop {
+			if p.Tag == 0 {
+				continue
+			}
+			if name, ok := fields[int32(p.Tag)]; !ok || name != p.OrigName {
+				t.Errorf("%s: field %d %q is not in the descriptor (found %q)", md.GetName(), p.Tag, p.OrigName, name)
+			}
+			delete(fields, int32(p.Tag))
+		}
+		for num, name := range fields {
+			t.Errorf("%s: descriptor field %d %q has no Go field", md.GetName(), num, name)
+		}
+	}
+}
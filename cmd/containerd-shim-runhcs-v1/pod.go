@@ -6,17 +6,23 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/lcow"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/internal/ownership"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/Microsoft/hcsshim/osversion"
+	"github.com/Microsoft/hcsshim/pkg/firewall"
 	eventstypes "github.com/containerd/containerd/api/events"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/runtime"
 	"github.com/containerd/containerd/runtime/v2/task"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -50,9 +56,20 @@ type shimPod interface {
 	// the `shimExecStateRunning, shimExecStateExited` states. If the exec is
 	// not in this state this pod MUST return `errdefs.ErrFailedPrecondition`.
 	KillTask(ctx context.Context, tid, eid string, signal uint32, all bool) error
+	// WaitForStartDependencies blocks until every container that the task
+	// `tid` must be started after has reached its start condition. See
+	// `oci.AnnotationStartAfter`.
+	//
+	// If a dependency can not reach its condition this pod MUST return
+	// `errdefs.ErrFailedPrecondition`.
+	WaitForStartDependencies(ctx context.Context, tid string) error
+	// TaskStarted records that the init exec of the task `tid` has started and
+	// met its readiness gate, releasing the tasks waiting in
+	// `WaitForStartDependencies` for it to be started.
+	TaskStarted(tid string)
 }
 
-func createPod(ctx context.Context, events publisher, req *task.CreateTaskRequest, s *specs.Spec) (shimPod, error) {
+func createPod(ctx context.Context, events publisher, req *task.CreateTaskRequest, s *specs.Spec, shimOpts *runhcsopts.Options) (_ shimPod, err error) {
 	log.G(ctx).WithField("tid", req.ID).Debug("createPod")
 
 	if osversion.Get().Build < osversion.RS5 {
@@ -63,6 +80,11 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 	if err != nil {
 		return nil, err
 	}
+	if shimOpts != nil {
+		if err := validateHealthRemediation(shimOpts.HealthCheckRemediation); err != nil {
+			return nil, err
+		}
+	}
 	if ct != oci.KubernetesContainerTypeSandbox {
 		return nil, errors.Wrapf(
 			errdefs.ErrFailedPrecondition,
@@ -80,19 +102,62 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 			sid)
 	}
 
-	owner := filepath.Base(os.Args[0])
+	owner := ownership.Label(filepath.Base(os.Args[0]))
 	isWCOW := oci.IsWCOW(s)
 
 	var parent *uvm.UtilityVM
-	if oci.IsIsolated(s) {
+	attached := false
+	if id, ok := s.Annotations[oci.AnnotationAttachUVM]; ok {
+		if isWCOW {
+			return nil, errors.Wrapf(errdefs.ErrFailedPrecondition, "annotation '%s' is only supported for LCOW", oci.AnnotationAttachUVM)
+		}
+		// Take over a detached persistent UVM rather than creating one.
+		parent, err = uvm.Attach(ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to attach to utility VM %q", id)
+		}
+		attached = true
+		// The UVM is already running, so a missing reservation must not stop
+		// it from being used.
+		if res, err := attachUVMReservation(ctx, id); err != nil {
+			log.G(ctx).WithError(err).Warning("utility VM attached without a capacity reservation")
+		} else {
+			releaseOnExit(parent, res)
+		}
+	} else if oci.IsIsolated(s) {
 		// Create the UVM parent
 		opts, err := oci.SpecToUVMCreateOpts(ctx, s, fmt.Sprintf("%s@vm", req.ID), owner)
 		if err != nil {
 			return nil, err
 		}
+		if err := oci.UpdateCreateOptsFromOptions(opts, shimOpts); err != nil {
+			return nil, err
+		}
+		// The compute agent ACL comes only from the shim options so that it
+		// can not be loosened by pod annotations.
+		if shimOpts != nil && shimOpts.ComputeAgentPipeSecurityDescriptor != "" {
+			switch o := opts.(type) {
+			case *uvm.OptionsLCOW:
+				o.ComputeAgentSecurityDescriptor = shimOpts.ComputeAgentPipeSecurityDescriptor
+			case *uvm.OptionsWCOW:
+				o.ComputeAgentSecurityDescriptor = shimOpts.ComputeAgentPipeSecurityDescriptor
+			}
+		}
+		res, err := reserveUVMCapacity(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		started := false
+		defer func() {
+			// release the reservation if the uvm was never started
+			if !started {
+				_ = res.Release()
+			}
+		}()
 		switch opts.(type) {
 		case *uvm.OptionsLCOW:
 			lopts := (opts).(*uvm.OptionsLCOW)
+			lopts.SandboxID = req.ID
 			parent, err = uvm.CreateLCOW(ctx, lopts)
 			if err != nil {
 				return nil, err
@@ -125,12 +190,31 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 			parent.Close()
 			return nil, err
 		}
+		started = true
+		releaseOnExit(parent, res)
+		if lopts, ok := opts.(*uvm.OptionsLCOW); ok {
+			for i := range lopts.Volumes {
+				if err = lcow.CreateVolume(ctx, parent, &lopts.Volumes[i], req.Bundle); err != nil {
+					parent.Close()
+					return nil, errors.Wrapf(err, "failed to create pod volume %q", lopts.Volumes[i].Name)
+				}
+			}
+		}
 	} else if !isWCOW {
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "oci spec does not contain WCOW or LCOW spec")
 	}
 	defer func() {
-		// clean up the uvm if we fail any further operations
+		// clean up the uvm if we fail any further operations. An attached
+		// persistent uvm is detached again so that it is left running for
+		// the next pod that attaches to it.
 		if err != nil && parent != nil {
+			if attached {
+				derr := parent.Detach(ctx)
+				if derr == nil {
+					return
+				}
+				log.G(ctx).WithError(derr).Warning("failed to detach utility VM, closing it")
+			}
 			parent.Close()
 		}
 	}()
@@ -203,12 +287,38 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 		}
 		// LCOW (and WCOW Process Isolated for the time being) requires a real
 		// task for the sandbox.
-		lt, err := newHcsTask(ctx, events, parent, true, req, s)
+		lt, err := newHcsTask(ctx, events, parent, true, req, s, p.workloadCPUQuotas)
 		if err != nil {
 			return nil, err
 		}
 		p.sandboxTask = lt
 	}
+
+	if oci.ParseAnnotationsFirewallPublishedPorts(ctx, s) && s.Windows != nil && s.Windows.Network != nil && s.Windows.Network.NetworkNamespace != "" {
+		var rules []firewall.PortRule
+		rules, err = firewall.NamespacePortRules(s.Windows.Network.NetworkNamespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get published ports for pod %q", req.ID)
+		}
+		if err = firewall.AddPortRules(ctx, req.ID, rules); err != nil {
+			return nil, err
+		}
+		go func() {
+			p.sandboxTask.Wait()
+			if err := firewall.RemovePortRules(context.Background(), req.ID); err != nil {
+				log.G(ctx).WithError(err).Error("failed to remove firewall rules")
+			}
+		}()
+	}
+
+	if shimOpts != nil && shimOpts.HealthCheckIntervalInSeconds > 0 {
+		hc := &healthChecker{
+			p:           &p,
+			interval:    time.Duration(shimOpts.HealthCheckIntervalInSeconds) * time.Second,
+			remediation: shimOpts.HealthCheckRemediation,
+		}
+		go hc.run(context.Background())
+	}
 	return &p, nil
 }
 
@@ -237,6 +347,17 @@ type pod struct {
 	// to release the lock to allow concurrent creates.
 	wcl           sync.Mutex
 	workloadTasks sync.Map
+
+	// depsl guards the start dependencies of the workload tasks.
+	// startDependencies are the `[]oci.StartDependency` of each workload task
+	// by ID, containerNames the IDs of the workload tasks by CRI container name
+	// and startedTasks the IDs of the started workload tasks. depsChanged is
+	// closed and replaced whenever a task is added to one of them.
+	depsl             sync.Mutex
+	startDependencies map[string][]oci.StartDependency
+	containerNames    map[string]string
+	startedTasks      map[string]struct{}
+	depsChanged       chan struct{}
 }
 
 func (p *pod) ID() string {
@@ -300,17 +421,32 @@ func (p *pod) CreateTask(ctx context.Context, req *task.CreateTaskRequest, s *sp
 		return nil, err
 	}
 
+	deps, err := oci.ParseStartDependencies(s.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	name := s.Annotations[oci.KubernetesContainerNameAnnotation]
+	if err := p.addStartDependencies(req.ID, name, deps); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			p.removeStartDependencies(req.ID, name)
+		}
+	}()
+
 	var st shimTask
 	if templateID != "" {
 		st, err = newClonedHcsTask(ctx, p.events, p.host, false, req, s, templateID)
 	} else {
-		st, err = newHcsTask(ctx, p.events, p.host, false, req, s)
+		st, err = newHcsTask(ctx, p.events, p.host, false, req, s, nil)
 	}
 	if err != nil {
 		return nil, err
 	}
 
 	p.workloadTasks.Store(req.ID, st)
+	p.notifyStartDependents()
 	return st, nil
 }
 
@@ -325,6 +461,19 @@ func (p *pod) GetTask(tid string) (shimTask, error) {
 	return raw.(shimTask), nil
 }
 
+// workloadCPUQuotas returns the CPU quotas of the LCOW workload containers of
+// the pod that have one.
+func (p *pod) workloadCPUQuotas() []*uvm.ContainerCPUQuota {
+	var quotas []*uvm.ContainerCPUQuota
+	p.workloadTasks.Range(func(key, value interface{}) bool {
+		if ht, ok := value.(*hcsTask); ok && ht.cpuQuota != nil {
+			quotas = append(quotas, ht.cpuQuota)
+		}
+		return true
+	})
+	return quotas
+}
+
 func (p *pod) KillTask(ctx context.Context, tid, eid string, signal uint32, all bool) error {
 	t, err := p.GetTask(tid)
 	if err != nil {
@@ -351,3 +500,190 @@ func (p *pod) KillTask(ctx context.Context, tid, eid string, signal uint32, all
 	})
 	return eg.Wait()
 }
+
+// startDependencyTimeout is how long the start of a task waits for all of its
+// start dependencies. It is below the 2 minute runtime request timeout of the
+// kubelet so that the start fails with the dependency that was not met rather
+// than the request being cancelled.
+const startDependencyTimeout = 90 * time.Second
+
+// addStartDependencies records `deps` as the start dependencies of the task
+// `tid` named `name`. Returns `errdefs.ErrFailedPrecondition` if the task would
+// be started after itself, directly or through the start dependencies of the
+// other tasks.
+func (p *pod) addStartDependencies(tid, name string, deps []oci.StartDependency) error {
+	p.depsl.Lock()
+	defer p.depsl.Unlock()
+	for _, d := range deps {
+		if p.startsAfterL(d.Container, tid, name, make(map[string]bool)) {
+			return errors.Wrapf(errdefs.ErrFailedPrecondition, "task with id: '%s' cannot be started after '%s' as it forms a cycle", tid, d.Container)
+		}
+	}
+	if len(deps) > 0 {
+		if p.startDependencies == nil {
+			p.startDependencies = make(map[string][]oci.StartDependency)
+		}
+		p.startDependencies[tid] = deps
+	}
+	if name != "" {
+		if p.containerNames == nil {
+			p.containerNames = make(map[string]string)
+		}
+		p.containerNames[name] = tid
+	}
+	return nil
+}
+
+func (p *pod) removeStartDependencies(tid, name string) {
+	p.depsl.Lock()
+	defer p.depsl.Unlock()
+	delete(p.startDependencies, tid)
+	if name != "" && p.containerNames[name] == tid {
+		delete(p.containerNames, name)
+	}
+}
+
+// startsAfterL returns true if `container` is the task `tid` named `name`, or
+// must be started after it through its start dependencies. `seen` is the set
+// of tasks already visited. The caller MUST hold `p.depsl`.
+func (p *pod) startsAfterL(container, tid, name string, seen map[string]bool) bool {
+	if container == tid || (name != "" && container == name) {
+		return true
+	}
+	if id, ok := p.containerNames[container]; ok {
+		container = id
+	}
+	if seen[container] {
+		return false
+	}
+	seen[container] = true
+	for _, d := range p.startDependencies[container] {
+		if p.startsAfterL(d.Container, tid, name, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyStartDependents wakes the tasks waiting for their start dependencies
+// to check them again.
+func (p *pod) notifyStartDependents() {
+	p.depsl.Lock()
+	defer p.depsl.Unlock()
+	if p.depsChanged != nil {
+		close(p.depsChanged)
+		p.depsChanged = nil
+	}
+}
+
+// startDependentsChanged returns a channel that is closed on the next call to
+// `notifyStartDependents`.
+func (p *pod) startDependentsChanged() <-chan struct{} {
+	p.depsl.Lock()
+	defer p.depsl.Unlock()
+	if p.depsChanged == nil {
+		p.depsChanged = make(chan struct{})
+	}
+	return p.depsChanged
+}
+
+func (p *pod) TaskStarted(tid string) {
+	p.depsl.Lock()
+	if p.startedTasks == nil {
+		p.startedTasks = make(map[string]struct{})
+	}
+	p.startedTasks[tid] = struct{}{}
+	p.depsl.Unlock()
+	p.notifyStartDependents()
+}
+
+func (p *pod) WaitForStartDependencies(ctx context.Context, tid string) error {
+	p.depsl.Lock()
+	deps := p.startDependencies[tid]
+	p.depsl.Unlock()
+	if len(deps) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, startDependencyTimeout)
+	defer cancel()
+	for _, d := range deps {
+		if err := p.waitForStartDependency(ctx, tid, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupWorkloadTask returns the ID and the workload task named `container` by
+// ID or by CRI container name, or a nil task if it has not been created yet.
+func (p *pod) lookupWorkloadTask(container string) (string, shimTask) {
+	p.depsl.Lock()
+	if id, ok := p.containerNames[container]; ok {
+		container = id
+	}
+	p.depsl.Unlock()
+	raw, ok := p.workloadTasks.Load(container)
+	if !ok || raw == nil {
+		return container, nil
+	}
+	return container, raw.(shimTask)
+}
+
+func (p *pod) isTaskStarted(tid string) bool {
+	p.depsl.Lock()
+	defer p.depsl.Unlock()
+	_, ok := p.startedTasks[tid]
+	return ok
+}
+
+// waitForStartDependency waits for the dependency `d` of the task `tid` to
+// reach its condition. The conditions are driven by the guest: a task is
+// started once the guest has started its init process and the task has met
+// its readiness gate, and has completed once the guest has reported the exit
+// of its init process.
+func (p *pod) waitForStartDependency(ctx context.Context, tid string, d oci.StartDependency) error {
+	log.G(ctx).WithFields(logrus.Fields{
+		"tid":        tid,
+		"dependency": d.Container,
+		"condition":  d.Condition,
+	}).Debug("waiting for start dependency")
+
+	var exited <-chan *task.StateResponse
+	for {
+		changed := p.startDependentsChanged()
+		id, t := p.lookupWorkloadTask(d.Container)
+		if t != nil && exited == nil {
+			if e, err := t.GetExec(""); err == nil {
+				exited = waitForExecExit(e)
+			}
+		}
+		if t != nil && d.Condition == oci.StartConditionStarted && p.isTaskStarted(id) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "task with id: '%s' timed out waiting for dependency '%s' to be %s", tid, d.Container, d.Condition)
+		case status := <-exited:
+			// A dependency that exited with exit code 0 has met either
+			// condition, even if it exited before it was recorded as started.
+			if d.Condition == oci.StartConditionStarted && p.isTaskStarted(id) {
+				return nil
+			}
+			if status.ExitStatus != 0 {
+				return errors.Wrapf(errdefs.ErrFailedPrecondition, "task with id: '%s' cannot start as dependency '%s' exited with code %d", tid, d.Container, status.ExitStatus)
+			}
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// waitForExecExit returns a channel that receives the status of `e` once it
+// has exited.
+func waitForExecExit(e shimExec) <-chan *task.StateResponse {
+	exited := make(chan *task.StateResponse, 1)
+	go func() {
+		exited <- e.Wait()
+	}()
+	return exited
+}
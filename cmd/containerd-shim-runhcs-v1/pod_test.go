@@ -6,10 +6,14 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/runtime/v2/task"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
 )
 
 var _ = (shimPod)(&testShimPod{})
@@ -44,6 +48,12 @@ func (tsp *testShimPod) KillTask(ctx context.Context, tid, eid string, signal ui
 	return s.KillExec(ctx, eid, signal, all)
 }
 
+func (tsp *testShimPod) WaitForStartDependencies(ctx context.Context, tid string) error {
+	return nil
+}
+
+func (tsp *testShimPod) TaskStarted(tid string) {}
+
 // Pod tests
 
 func setupTestPodWithFakes(t *testing.T) (*pod, *testShimTask) {
@@ -205,3 +215,171 @@ func Test_pod_KillTask_WorkloadID_2ndExecID_All_Error(t *testing.T) {
 		verifyExpectedError(t, nil, err, errdefs.ErrFailedPrecondition)
 	}
 }
+
+// dependencyTestShimTask is a test task whose init exec does not exit until
+// `exit` is called.
+type dependencyTestShimTask struct {
+	*testShimTask
+	exited chan struct{}
+}
+
+func newDependencyTestShimTask(tid string) *dependencyTestShimTask {
+	return &dependencyTestShimTask{
+		testShimTask: &testShimTask{
+			id:   tid,
+			exec: newTestShimExec(tid, tid, int(rand.Int31())),
+		},
+		exited: make(chan struct{}),
+	}
+}
+
+func (dt *dependencyTestShimTask) GetExec(eid string) (shimExec, error) {
+	if eid == "" {
+		return &dependencyTestShimExec{testShimExec: dt.exec, exited: dt.exited}, nil
+	}
+	return dt.testShimTask.GetExec(eid)
+}
+
+func (dt *dependencyTestShimTask) exit(status uint32) {
+	dt.exec.state = shimExecStateExited
+	dt.exec.status = status
+	close(dt.exited)
+}
+
+type dependencyTestShimExec struct {
+	*testShimExec
+	exited chan struct{}
+}
+
+func (de *dependencyTestShimExec) Wait() *task.StateResponse {
+	<-de.exited
+	return de.Status()
+}
+
+func addDependencyTestTask(t *testing.T, p *pod, name string, deps []oci.StartDependency) *dependencyTestShimTask {
+	dt := newDependencyTestShimTask(name + "-id")
+	if err := p.addStartDependencies(dt.id, name, deps); err != nil {
+		t.Fatalf("should not have failed, got: %v", err)
+	}
+	p.workloadTasks.Store(dt.id, dt)
+	p.notifyStartDependents()
+	return dt
+}
+
+func waitForStartDependenciesAsync(p *pod, tid string, timeout time.Duration) <-chan error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	result := make(chan error, 1)
+	go func() {
+		defer cancel()
+		result <- p.WaitForStartDependencies(ctx, tid)
+	}()
+	return result
+}
+
+func Test_pod_addStartDependencies_Self_Error(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	err := p.addStartDependencies("app-id", "app", []oci.StartDependency{{Container: "app", Condition: oci.StartConditionStarted}})
+	verifyExpectedError(t, nil, err, errdefs.ErrFailedPrecondition)
+}
+
+func Test_pod_addStartDependencies_Cycle_Error(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	addDependencyTestTask(t, p, "a", []oci.StartDependency{{Container: "b", Condition: oci.StartConditionCompleted}})
+	addDependencyTestTask(t, p, "b", []oci.StartDependency{{Container: "c-id", Condition: oci.StartConditionStarted}})
+
+	err := p.addStartDependencies("c-id", "c", []oci.StartDependency{{Container: "a", Condition: oci.StartConditionStarted}})
+	verifyExpectedError(t, nil, err, errdefs.ErrFailedPrecondition)
+
+	// A dependency on the start of the cycle, without closing it, is allowed.
+	if err := p.addStartDependencies("d-id", "d", []oci.StartDependency{{Container: "a", Condition: oci.StartConditionStarted}}); err != nil {
+		t.Fatalf("should not have failed, got: %v", err)
+	}
+}
+
+func Test_pod_WaitForStartDependencies_NoDependencies_Success(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	if err := p.WaitForStartDependencies(context.Background(), "app-id"); err != nil {
+		t.Fatalf("should not have failed, got: %v", err)
+	}
+}
+
+func Test_pod_WaitForStartDependencies_Started_Success(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	addDependencyTestTask(t, p, "app", []oci.StartDependency{{Container: "proxy", Condition: oci.StartConditionStarted}})
+	result := waitForStartDependenciesAsync(p, "app-id", 10*time.Second)
+
+	// The dependency is created after the wait started.
+	dt := addDependencyTestTask(t, p, "proxy", nil)
+	select {
+	case err := <-result:
+		t.Fatalf("should not have returned before the dependency started, got: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	p.TaskStarted(dt.id)
+	if err := <-result; err != nil {
+		t.Fatalf("should not have failed, got: %v", err)
+	}
+}
+
+func Test_pod_WaitForStartDependencies_Completed_Success(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	dt := addDependencyTestTask(t, p, "init", nil)
+	addDependencyTestTask(t, p, "app", []oci.StartDependency{{Container: "init", Condition: oci.StartConditionCompleted}})
+	result := waitForStartDependenciesAsync(p, "app-id", 10*time.Second)
+
+	p.TaskStarted(dt.id)
+	select {
+	case err := <-result:
+		t.Fatalf("should not have returned before the dependency exited, got: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	dt.exit(0)
+	if err := <-result; err != nil {
+		t.Fatalf("should not have failed, got: %v", err)
+	}
+}
+
+func Test_pod_WaitForStartDependencies_Completed_NonZeroExit_Error(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	dt := addDependencyTestTask(t, p, "init", nil)
+	addDependencyTestTask(t, p, "app", []oci.StartDependency{{Container: "init", Condition: oci.StartConditionCompleted}})
+	result := waitForStartDependenciesAsync(p, "app-id", 10*time.Second)
+
+	dt.exit(1)
+	verifyExpectedError(t, nil, <-result, errdefs.ErrFailedPrecondition)
+}
+
+func Test_pod_WaitForStartDependencies_Started_ExitBeforeStart_Error(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	dt := addDependencyTestTask(t, p, "proxy", nil)
+	addDependencyTestTask(t, p, "app", []oci.StartDependency{{Container: "proxy", Condition: oci.StartConditionStarted}})
+	result := waitForStartDependenciesAsync(p, "app-id", 10*time.Second)
+
+	dt.exit(1)
+	verifyExpectedError(t, nil, <-result, errdefs.ErrFailedPrecondition)
+}
+
+func Test_pod_WaitForStartDependencies_Timeout_Error(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	addDependencyTestTask(t, p, "app", []oci.StartDependency{{Container: "proxy", Condition: oci.StartConditionStarted}})
+
+	err := <-waitForStartDependenciesAsync(p, "app-id", 100*time.Millisecond)
+	if errors.Cause(err) != context.DeadlineExceeded {
+		t.Fatalf("expected error: %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func Test_pod_workloadCPUQuotas(t *testing.T) {
+	p, _ := setupTestPodWithFakes(t)
+	// Tasks that are not LCOW hcsTasks with a quota are skipped.
+	setupTestTaskInPod(t, p)
+	p.workloadTasks.Store("creating", nil)
+	p.workloadTasks.Store("unlimited", &hcsTask{id: "unlimited"})
+	quota := &uvm.ContainerCPUQuota{Quota: 10000}
+	p.workloadTasks.Store("limited", &hcsTask{id: "limited", cpuQuota: quota})
+
+	quotas := p.workloadCPUQuotas()
+	if len(quotas) != 1 || quotas[0] != quota {
+		t.Fatalf("expected the quota of the limited task, got: %+v", quotas)
+	}
+}
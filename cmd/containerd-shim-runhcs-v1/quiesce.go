@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+)
+
+// quiesceFunc freezes the guest file systems of the SCSI disks `hostPaths` of
+// a task host and returns the function that thaws them, as
+// `(*uvm.UtilityVM).QuiesceSCSI`.
+type quiesceFunc func(ctx context.Context, hostPaths []string, timeout time.Duration) (func(context.Context) error, error)
+
+// quiescer tracks the disks of a task host that a `DiagQuiesce` request froze,
+// so that a later `DiagQuiesce` request with `Thaw` set thaws them. Only one
+// set of disks can be frozen at a time.
+type quiescer struct {
+	m    sync.Mutex
+	thaw func(context.Context) error
+}
+
+// handle freezes the disks of `req` with `quiesce`, or thaws the frozen disks
+// if `req.Thaw` is set.
+//
+// The disks are no longer tracked once a thaw is requested, even if it fails,
+// as the guest thaws them by itself once the timeout of the freeze elapses.
+func (q *quiescer) handle(ctx context.Context, req *shimdiag.QuiesceRequest, quiesce quiesceFunc) error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if req.Thaw {
+		if q.thaw == nil {
+			return errors.Wrap(errdefs.ErrFailedPrecondition, "no disks are quiesced")
+		}
+		thaw := q.thaw
+		q.thaw = nil
+		return thaw(ctx)
+	}
+	if q.thaw != nil {
+		return errors.Wrap(errdefs.ErrFailedPrecondition, "disks are already quiesced")
+	}
+	if len(req.HostPaths) == 0 {
+		return errors.Wrap(errdefs.ErrInvalidArgument, "no disks to quiesce")
+	}
+	thaw, err := quiesce(ctx, req.HostPaths, time.Duration(req.TimeoutInSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	q.thaw = thaw
+	return nil
+}
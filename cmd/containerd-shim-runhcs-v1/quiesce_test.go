@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/containerd/containerd/errdefs"
+)
+
+// fakeQuiesce records the calls of a quiesceFunc and of the thaw function it
+// returns.
+type fakeQuiesce struct {
+	hostPaths []string
+	timeout   time.Duration
+	quiesced  int
+	thawed    int
+}
+
+func (f *fakeQuiesce) quiesce(ctx context.Context, hostPaths []string, timeout time.Duration) (func(context.Context) error, error) {
+	f.hostPaths = hostPaths
+	f.timeout = timeout
+	f.quiesced++
+	return func(context.Context) error {
+		f.thawed++
+		return nil
+	}, nil
+}
+
+func Test_quiescer_FreezeThaw(t *testing.T) {
+	var (
+		q quiescer
+		f fakeQuiesce
+	)
+	paths := []string{"C:\\a.vhdx", "C:\\b.vhdx"}
+	if err := q.handle(context.TODO(), &shimdiag.QuiesceRequest{HostPaths: paths, TimeoutInSeconds: 30}, f.quiesce); err != nil {
+		t.Fatalf("failed to quiesce: %v", err)
+	}
+	if f.quiesced != 1 || !reflect.DeepEqual(f.hostPaths, paths) || f.timeout != 30*time.Second {
+		t.Fatalf("unexpected quiesce: %+v", f)
+	}
+	if err := q.handle(context.TODO(), &shimdiag.QuiesceRequest{Thaw: true}, f.quiesce); err != nil {
+		t.Fatalf("failed to thaw: %v", err)
+	}
+	if f.thawed != 1 {
+		t.Fatalf("expected 1 thaw, got: %d", f.thawed)
+	}
+	// The disks can be quiesced again once thawed.
+	if err := q.handle(context.TODO(), &shimdiag.QuiesceRequest{HostPaths: paths}, f.quiesce); err != nil {
+		t.Fatalf("failed to quiesce again: %v", err)
+	}
+	if f.quiesced != 2 || f.timeout != 0 {
+		t.Fatalf("unexpected quiesce: %+v", f)
+	}
+}
+
+func Test_quiescer_AlreadyQuiesced_Error(t *testing.T) {
+	var (
+		q quiescer
+		f fakeQuiesce
+	)
+	req := &shimdiag.QuiesceRequest{HostPaths: []string{"C:\\a.vhdx"}}
+	if err := q.handle(context.TODO(), req, f.quiesce); err != nil {
+		t.Fatalf("failed to quiesce: %v", err)
+	}
+
+	err := q.handle(context.TODO(), req, f.quiesce)
+
+	verifyExpectedError(t, nil, err, errdefs.ErrFailedPrecondition)
+	if f.quiesced != 1 {
+		t.Fatalf("expected 1 quiesce, got: %d", f.quiesced)
+	}
+}
+
+func Test_quiescer_ThawNotQuiesced_Error(t *testing.T) {
+	var (
+		q quiescer
+		f fakeQuiesce
+	)
+
+	err := q.handle(context.TODO(), &shimdiag.QuiesceRequest{Thaw: true}, f.quiesce)
+
+	verifyExpectedError(t, nil, err, errdefs.ErrFailedPrecondition)
+}
+
+func Test_quiescer_NoHostPaths_Error(t *testing.T) {
+	var (
+		q quiescer
+		f fakeQuiesce
+	)
+
+	err := q.handle(context.TODO(), &shimdiag.QuiesceRequest{}, f.quiesce)
+
+	verifyExpectedError(t, nil, err, errdefs.ErrInvalidArgument)
+	if f.quiesced != 0 {
+		t.Fatalf("expected no quiesce, got: %d", f.quiesced)
+	}
+}
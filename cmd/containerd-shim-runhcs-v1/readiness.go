@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// readinessPollInterval is how often the readiness gate of a task is checked
+// while waiting for it.
+const readinessPollInterval = time.Second
+
+// readinessCommand returns the command that checks the readiness gate `g` in
+// the container `c`. It exits with exit code 0 once the gate is met.
+func readinessCommand(ctx context.Context, c cow.ProcessHost, g *oci.ReadinessGate) *cmd.Cmd {
+	if c.OS() == "windows" {
+		var line string
+		switch g.Kind {
+		case oci.ReadinessFile:
+			line = fmt.Sprintf(`cmd /c if exist "%s" (exit 0) else (exit 1)`, g.Value)
+		case oci.ReadinessPort:
+			line = fmt.Sprintf(`cmd /c netstat -an | findstr /r /c:"TCP.*:%s .*LISTENING"`, g.Value)
+		default:
+			line = "cmd /c " + g.Value
+		}
+		rc := cmd.CommandContext(ctx, c, "cmd")
+		rc.Spec.CommandLine = line
+		return rc
+	}
+	switch g.Kind {
+	case oci.ReadinessFile:
+		return cmd.CommandContext(ctx, c, "test", "-e", g.Value)
+	case oci.ReadinessPort:
+		// A listening socket has the state 0A in /proc/net/tcp.
+		port, _ := strconv.ParseUint(g.Value, 10, 16)
+		pattern := fmt.Sprintf(":%04X [0-9A-F]+:[0-9A-F]{4} 0A", port)
+		return cmd.CommandContext(ctx, c, "grep", "-qsE", pattern, "/proc/net/tcp", "/proc/net/tcp6")
+	default:
+		return cmd.CommandContext(ctx, c, "sh", "-c", g.Value)
+	}
+}
+
+// waitForReadiness blocks until the container `c` of the task `tid` meets
+// the readiness gate `g`, its init exec `init` exits or `g.Timeout` elapses.
+func waitForReadiness(ctx context.Context, tid string, c cow.ProcessHost, init shimExec, g *oci.ReadinessGate) error {
+	l := log.G(ctx).WithFields(logrus.Fields{
+		"tid":   tid,
+		"kind":  g.Kind,
+		"value": g.Value,
+	})
+	l.Debug("waiting for readiness gate")
+
+	ctx, cancel := context.WithTimeout(ctx, g.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for {
+		if init.State() == shimExecStateExited {
+			return errors.Wrapf(errdefs.ErrFailedPrecondition, "task with id: '%s' exited before it was ready", tid)
+		}
+		err := readinessCommand(ctx, c, g).Run()
+		if err == nil {
+			l.Debug("readiness gate met")
+			return nil
+		}
+		l.WithError(err).Trace("readiness gate not met")
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "task with id: '%s' timed out waiting for readiness gate '%s:%s'", tid, g.Kind, g.Value)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Microsoft/hcsshim/internal/reservation"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+)
+
+// reserveUVMCapacity reserves the memory and processors requested in the UVM
+// create options `opts` against the host's configured UVM capacity, so that a
+// UVM that does not fit is rejected before HCS is asked to create it. The
+// reservation is made for the UVM ID so that the process that attaches to a
+// detached persistent UVM can take it over.
+func reserveUVMCapacity(ctx context.Context, opts interface{}) (*reservation.Reservation, error) {
+	var o *uvm.Options
+	switch opts := opts.(type) {
+	case *uvm.OptionsLCOW:
+		o = opts.Options
+	case *uvm.OptionsWCOW:
+		o = opts.Options
+	default:
+		return nil, errors.Errorf("unexpected UVM create options type %T", opts)
+	}
+	id := o.ID
+	usage := reservation.Usage{
+		MemoryMB:   o.MemorySizeInMB,
+		Processors: uint32(o.ProcessorCount),
+	}
+	r, err := reservation.NewDefaultManager().Reserve(ctx, id, usage)
+	if err != nil {
+		if errors.Cause(err) == reservation.ErrCapacityExhausted {
+			return nil, errors.Wrapf(errdefs.ErrUnavailable, "cannot create UVM %s: %s", id, err)
+		}
+		return nil, errors.Wrapf(err, "failed to reserve capacity for UVM %s", id)
+	}
+	return r, nil
+}
+
+// attachUVMReservation takes over the reservation left by the process that
+// detached the persistent UVM `id`.
+func attachUVMReservation(ctx context.Context, id string) (*reservation.Reservation, error) {
+	r, err := reservation.NewDefaultManager().Attach(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to take over the capacity reservation of UVM %s", id)
+	}
+	return r, nil
+}
+
+// releaseOnExit hands `r` to the started `host` and releases it once `host` has
+// exited. If `host` is detached the reservation is detached with it instead, so
+// that it is kept until the UVM is attached to again.
+func releaseOnExit(host *uvm.UtilityVM, r *reservation.Reservation) {
+	host.SetReservation(r)
+	go func() {
+		_ = host.Wait()
+		_ = r.Release()
+	}()
+}
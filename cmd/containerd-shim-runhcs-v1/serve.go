@@ -13,6 +13,7 @@ import (
 
 	"github.com/Microsoft/go-winio"
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/privileges"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
 	"github.com/Microsoft/hcsshim/pkg/octtrpc"
 	"github.com/containerd/containerd/log"
@@ -86,6 +87,11 @@ var serveCommand = cli.Command{
 			logrus.SetLevel(logrus.DebugLevel)
 		}
 
+		pipeSecurityDescriptor := shimOpts.PipeSecurityDescriptor
+		if pipeSecurityDescriptor == "" && !privileges.IsAdministrator() {
+			pipeSecurityDescriptor = privileges.RestrictedPipeSecurityDescriptor
+		}
+
 		switch shimOpts.DebugType {
 		case runhcsopts.Options_NPIPE:
 			logrus.SetFormatter(&logrus.TextFormatter{
@@ -99,7 +105,7 @@ var serveCommand = cli.Command{
 			// TODO: JTERRY75 switch containerd to use the protected path.
 			//const logAddrFmt = "\\\\.\\pipe\\ProtectedPrefix\\Administrators\\containerd-shim-%s-%s-log"
 			const logAddrFmt = "\\\\.\\pipe\\containerd-shim-%s-%s-log"
-			logl, err := winio.ListenPipe(fmt.Sprintf(logAddrFmt, namespaceFlag, idFlag), nil)
+			logl, err := winio.ListenPipe(fmt.Sprintf(logAddrFmt, namespaceFlag, idFlag), &winio.PipeConfig{SecurityDescriptor: pipeSecurityDescriptor})
 			if err != nil {
 				return err
 			}
@@ -139,6 +145,13 @@ var serveCommand = cli.Command{
 			logrus.SetOutput(ioutil.Discard)
 		}
 
+		// Enable the privileges the shim requires up front, rather than
+		// failing on first use, so that missing ones are reported when the
+		// shim runs under a restricted account.
+		for _, p := range privileges.Enable(privileges.Shim) {
+			logrus.WithField("privilege", p.Name).Warningf("privilege is not held by the shim account, %s will fail", p.Reason)
+		}
+
 		os.Stdin.Close()
 
 		// Force the cli.ErrWriter to be os.Stdout for this. We use stderr for
@@ -177,7 +190,7 @@ var serveCommand = cli.Command{
 		task.RegisterTaskService(s, svc)
 		shimdiag.RegisterShimDiagService(s, svc)
 
-		sl, err := winio.ListenPipe(socket, nil)
+		sl, err := winio.ListenPipe(socket, &winio.PipeConfig{SecurityDescriptor: pipeSecurityDescriptor})
 		if err != nil {
 			return err
 		}
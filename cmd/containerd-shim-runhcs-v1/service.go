@@ -290,6 +290,54 @@ func (s *service) DiagShare(ctx context.Context, req *shimdiag.ShareRequest) (_
 	return r, errdefs.ToGRPC(e)
 }
 
+func (s *service) DiagQuiesce(ctx context.Context, req *shimdiag.QuiesceRequest) (_ *shimdiag.QuiesceResponse, err error) {
+	ctx, span := trace.StartSpan(ctx, "DiagQuiesce")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	span.AddAttributes(
+		trace.StringAttribute("hostpaths", strings.Join(req.HostPaths, ",")),
+		trace.Int64Attribute("timeout", int64(req.TimeoutInSeconds)),
+		trace.BoolAttribute("thaw", req.Thaw))
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagQuiesceInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
+func (s *service) DiagResizeDisk(ctx context.Context, req *shimdiag.ResizeDiskRequest) (_ *shimdiag.ResizeDiskResponse, err error) {
+	ctx, span := trace.StartSpan(ctx, "DiagResizeDisk")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	span.AddAttributes(
+		trace.StringAttribute("hostpath", req.HostPath),
+		trace.Int64Attribute("size", int64(req.SizeInBytes)))
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagResizeDiskInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
+func (s *service) DiagNetworkInterfaces(ctx context.Context, req *shimdiag.NetworkInterfacesRequest) (_ *shimdiag.NetworkInterfacesResponse, err error) {
+	ctx, span := trace.StartSpan(ctx, "DiagNetworkInterfaces")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagNetworkInterfacesInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
 func (s *service) ResizePty(ctx context.Context, req *task.ResizePtyRequest) (_ *google_protobuf1.Empty, err error) {
 	ctx, span := trace.StartSpan(ctx, "ResizePty")
 	defer span.End()
@@ -432,15 +480,7 @@ func (s *service) DiagStacks(ctx context.Context, req *shimdiag.StacksRequest) (
 		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
 	}
 
-	buf := make([]byte, 4096)
-	for {
-		buf = buf[:runtime.Stack(buf, true)]
-		if len(buf) < cap(buf) {
-			break
-		}
-		buf = make([]byte, 2*len(buf))
-	}
-	resp := &shimdiag.StacksResponse{Stacks: string(buf)}
+	resp := &shimdiag.StacksResponse{Stacks: shimStacks()}
 
 	t, _ := s.getTask(s.tid)
 	if t != nil {
@@ -464,3 +504,16 @@ func (s *service) DiagPid(ctx context.Context, req *shimdiag.PidRequest) (*shimd
 		Pid: int32(os.Getpid()),
 	}, nil
 }
+
+// shimStacks returns the stacks of all goroutines of the shim.
+func shimStacks() string {
+	buf := make([]byte, 4096)
+	for {
+		buf = buf[:runtime.Stack(buf, true)]
+		if len(buf) < cap(buf) {
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	return string(buf)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
 	containerd_v1_types "github.com/containerd/containerd/api/types/task"
@@ -144,6 +145,12 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 		spec.Windows.LayerFolders = append(spec.Windows.LayerFolders, m.Source)
 	}
 
+	if shimOpts != nil && shimOpts.AdmissionPluginDir != "" {
+		if err := admitSpec(ctx, shimOpts.AdmissionPluginDir, req.ID, req.Bundle, &spec); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Terminal && req.Stderr != "" {
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "if using terminal, stderr must be empty")
 	}
@@ -163,7 +170,7 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 			resp.Pid = uint32(e.Pid())
 			return resp, nil
 		}
-		pod, err = createPod(ctx, s.events, req, &spec)
+		pod, err = createPod(ctx, s.events, req, &spec, shimOpts)
 		if err != nil {
 			s.cl.Unlock()
 			return nil, err
@@ -173,7 +180,7 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 		resp.Pid = uint32(e.Pid())
 		s.taskOrPod.Store(pod)
 	} else {
-		t, err := newHcsStandaloneTask(ctx, s.events, req, &spec)
+		t, err := newHcsStandaloneTask(ctx, s.events, req, &spec, shimOpts)
 		if err != nil {
 			s.cl.Unlock()
 			return nil, err
@@ -195,10 +202,36 @@ func (s *service) startInternal(ctx context.Context, req *task.StartRequest) (*t
 	if err != nil {
 		return nil, err
 	}
+	if s.isSandbox && req.ExecID == "" {
+		p, err := s.getPod()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.WaitForStartDependencies(ctx, req.ID); err != nil {
+			return nil, err
+		}
+	}
 	err = e.Start(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if req.ExecID == "" {
+		if err := t.WaitForReadiness(ctx); err != nil {
+			// Fail the start rather than leave a task running that was never
+			// ready.
+			if kerr := t.KillExec(ctx, "", 0x9, true); kerr != nil {
+				log.G(ctx).WithError(kerr).WithField("tid", req.ID).Warning("failed to kill task that was not ready")
+			}
+			return nil, err
+		}
+		if s.isSandbox {
+			p, err := s.getPod()
+			if err != nil {
+				return nil, err
+			}
+			p.TaskStarted(req.ID)
+		}
+	}
 	return &task.StartResponse{
 		Pid: uint32(e.Pid()),
 	}, nil
@@ -347,6 +380,40 @@ func (s *service) diagShareInternal(ctx context.Context, req *shimdiag.ShareRequ
 	return &shimdiag.ShareResponse{}, nil
 }
 
+func (s *service) diagQuiesceInternal(ctx context.Context, req *shimdiag.QuiesceRequest) (*shimdiag.QuiesceResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Quiesce(ctx, req); err != nil {
+		return nil, err
+	}
+	return &shimdiag.QuiesceResponse{}, nil
+}
+
+func (s *service) diagResizeDiskInternal(ctx context.Context, req *shimdiag.ResizeDiskRequest) (*shimdiag.ResizeDiskResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.ResizeDisk(ctx, req); err != nil {
+		return nil, err
+	}
+	return &shimdiag.ResizeDiskResponse{}, nil
+}
+
+func (s *service) diagNetworkInterfacesInternal(ctx context.Context, req *shimdiag.NetworkInterfacesRequest) (*shimdiag.NetworkInterfacesResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	names, err := t.NetworkInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &shimdiag.NetworkInterfacesResponse{InterfaceNames: names}, nil
+}
+
 func (s *service) resizePtyInternal(ctx context.Context, req *task.ResizePtyRequest) (*google_protobuf1.Empty, error) {
 	t, err := s.getTask(req.ID)
 	if err != nil {
@@ -380,7 +447,21 @@ func (s *service) closeIOInternal(ctx context.Context, req *task.CloseIORequest)
 }
 
 func (s *service) updateInternal(ctx context.Context, req *task.UpdateTaskRequest) (*google_protobuf1.Empty, error) {
-	return nil, errdefs.ErrNotImplemented
+	t, err := s.getTask(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Resources == nil {
+		return nil, errors.Wrap(errdefs.ErrInvalidArgument, "resources must be set on update")
+	}
+	resources, err := typeurl.UnmarshalAny(req.Resources)
+	if err != nil {
+		return nil, errors.Wrap(errdefs.ErrInvalidArgument, err.Error())
+	}
+	if err := t.Update(ctx, resources); err != nil {
+		return nil, err
+	}
+	return empty, nil
 }
 
 func (s *service) waitInternal(ctx context.Context, req *task.WaitRequest) (*task.WaitResponse, error) {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Microsoft/go-winio"
 	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/internal/privileges"
 	"github.com/containerd/containerd/runtime/v2/shim"
 	"github.com/containerd/containerd/runtime/v2/task"
 	"github.com/containerd/ttrpc"
@@ -52,7 +53,12 @@ The start command can either start a new shim or return an address to an existin
 		// isolated Windows Container, or a hypervisor isolated Linux Container
 		// on Windows.
 
-		const addrFmt = "\\\\.\\pipe\\ProtectedPrefix\\Administrators\\containerd-shim-%s-%s-pipe"
+		addrFmt := "\\\\.\\pipe\\ProtectedPrefix\\Administrators\\containerd-shim-%s-%s-pipe"
+		if !privileges.IsAdministrator() {
+			// Only administrators can create pipes under the protected
+			// prefix. The pipe is created with a restricted ACL instead.
+			addrFmt = "\\\\.\\pipe\\containerd-shim-%s-%s-pipe"
+		}
 
 		var (
 			address string
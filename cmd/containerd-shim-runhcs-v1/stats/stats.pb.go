@@ -32,11 +32,16 @@ type Statistics struct {
 	// Types that are valid to be assigned to Container:
 	//	*Statistics_Windows
 	//	*Statistics_Linux
-	Container            isStatistics_Container    `protobuf_oneof:"container"`
-	VM                   *VirtualMachineStatistics `protobuf:"bytes,3,opt,name=vm,proto3" json:"vm,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
-	XXX_unrecognized     []byte                    `json:"-"`
-	XXX_sizecache        int32                     `json:"-"`
+	Container isStatistics_Container    `protobuf_oneof:"container"`
+	VM        *VirtualMachineStatistics `protobuf:"bytes,3,opt,name=vm,proto3" json:"vm,omitempty"`
+	// core_scheduling is the Linux core scheduling cookie of an LCOW container
+	// in its UVM: `container` for a cookie of its own, `group:<name>` for the
+	// cookie shared by a group of containers, or empty if the container is not
+	// core scheduled.
+	CoreScheduling       string   `protobuf:"bytes,4,opt,name=core_scheduling,json=coreScheduling,proto3" json:"core_scheduling,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Statistics) Reset()      { *m = Statistics{} }
@@ -532,72 +537,74 @@ func init() {
 }
 
 var fileDescriptor_23217f96da3a05cc = []byte{
-	// 1037 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x6d, 0x6f, 0xdb, 0x44,
-	0x1c, 0x8f, 0xb3, 0x3e, 0x24, 0x37, 0xda, 0xb4, 0xb7, 0x76, 0x84, 0x48, 0x24, 0x6b, 0x90, 0xf6,
-	0x00, 0x34, 0xa1, 0xa3, 0x1a, 0x1a, 0x0c, 0x4d, 0xa4, 0x02, 0x0d, 0xb1, 0x84, 0x72, 0xe9, 0x03,
-	0x02, 0x21, 0x73, 0xb1, 0xaf, 0xee, 0xa9, 0xb6, 0xcf, 0xba, 0x3b, 0xbb, 0xac, 0xaf, 0xf8, 0x08,
-	0x7c, 0xac, 0x22, 0xde, 0xec, 0x25, 0xaf, 0x32, 0x96, 0x6f, 0x80, 0x84, 0x78, 0x3d, 0xf9, 0xee,
-	0x9c, 0xb8, 0xed, 0xba, 0xb6, 0xda, 0x9b, 0xc8, 0xfe, 0xff, 0x1e, 0xee, 0xff, 0x70, 0xe7, 0x0b,
-	0x78, 0xea, 0x51, 0xb9, 0x1f, 0x0f, 0x5a, 0x0e, 0x0b, 0xda, 0x5d, 0xea, 0x70, 0x26, 0xd8, 0x9e,
-	0x6c, 0xef, 0x3b, 0x42, 0xec, 0xd3, 0xa0, 0xed, 0x04, 0x6e, 0xdb, 0x61, 0xa1, 0xc4, 0x34, 0x24,
-	0xdc, 0x5d, 0x4d, 0x63, 0xab, 0x3c, 0x0e, 0xf7, 0x1d, 0xb1, 0x9a, 0xac, 0xb5, 0x85, 0xc4, 0x52,
-	0xe8, 0xdf, 0x56, 0xc4, 0x99, 0x64, 0xb0, 0x36, 0x21, 0xb7, 0x34, 0xaf, 0xa5, 0xe1, 0x64, 0xad,
-	0xb6, 0xe4, 0x31, 0x8f, 0x29, 0x5a, 0x3b, 0x7d, 0xd2, 0x8a, 0x5a, 0xc3, 0x63, 0xcc, 0xf3, 0x49,
-	0x5b, 0xbd, 0x0d, 0xe2, 0xbd, 0xb6, 0xa4, 0x01, 0x11, 0x12, 0x07, 0x91, 0x21, 0xac, 0xe7, 0x12,
-	0x9c, 0xb8, 0xb7, 0x1d, 0x8f, 0xb3, 0x38, 0x32, 0xab, 0xb7, 0x93, 0xb5, 0x76, 0x40, 0x24, 0xa7,
-	0x8e, 0x49, 0xa4, 0xf9, 0xbf, 0x05, 0x40, 0x5f, 0x62, 0x49, 0x85, 0xa4, 0x8e, 0x80, 0x08, 0xcc,
-	0x1e, 0xd2, 0xd0, 0x65, 0x87, 0xa2, 0x6a, 0xdd, 0xb2, 0xee, 0x5e, 0xbf, 0xff, 0xa0, 0x75, 0x7e,
-	0xa6, 0xad, 0x5d, 0x4d, 0xdd, 0xc8, 0x18, 0x13, 0xa3, 0x27, 0x05, 0x94, 0x19, 0xc1, 0x87, 0x60,
-	0xda, 0xa7, 0x61, 0xfc, 0x5b, 0xb5, 0xa8, 0x1c, 0x57, 0x5a, 0x94, 0xe5, 0x4d, 0x4d, 0x82, 0xa9,
-	0x5f, 0x57, 0xa7, 0xf6, 0xa4, 0x80, 0xb4, 0x02, 0x3e, 0x05, 0xc5, 0x24, 0xa8, 0x5e, 0x53, 0xba,
-	0xf5, 0x37, 0x65, 0xb2, 0x43, 0xb9, 0x8c, 0xb1, 0xdf, 0xc5, 0xce, 0x3e, 0x0d, 0xc9, 0x24, 0x8f,
-	0xce, 0xcc, 0x68, 0xd8, 0x28, 0xee, 0x74, 0x51, 0x31, 0x09, 0x3a, 0xd7, 0x41, 0x79, 0x6c, 0xd1,
-	0xfc, 0xf7, 0x1a, 0xa8, 0x9d, 0x9f, 0x3f, 0xec, 0x80, 0xf2, 0xb8, 0xc1, 0xa6, 0x15, 0xb5, 0x96,
-	0x1e, 0x41, 0x2b, 0x1b, 0x41, 0x6b, 0x2b, 0x63, 0x74, 0x4a, 0xc7, 0xc3, 0x46, 0xe1, 0x8f, 0x17,
-	0x0d, 0x0b, 0x4d, 0x64, 0x70, 0x07, 0x2c, 0x8d, 0xd7, 0xb3, 0x85, 0xc4, 0x5c, 0xda, 0x29, 0x68,
-	0xfa, 0x70, 0x39, 0x3b, 0xe8, 0xe4, 0x92, 0xe3, 0x32, 0xa5, 0xc0, 0x7b, 0xa0, 0x1c, 0x47, 0xa9,
-	0x93, 0x1d, 0x0a, 0xd5, 0x9c, 0xa9, 0xce, 0x3b, 0xa3, 0x61, 0xa3, 0xb4, 0xad, 0x82, 0xbd, 0x3e,
-	0x2a, 0x69, 0xb8, 0x27, 0xe0, 0x2f, 0xa0, 0x1c, 0x71, 0xe6, 0x10, 0x21, 0x18, 0xaf, 0x4e, 0xa9,
-	0x75, 0x1f, 0x5f, 0x65, 0xa2, 0x9b, 0x99, 0x78, 0xd2, 0x1a, 0x34, 0x71, 0x84, 0x5b, 0x60, 0x26,
-	0x20, 0x01, 0xe3, 0xcf, 0xaa, 0xd3, 0xca, 0xfb, 0xd1, 0x55, 0xbc, 0xbb, 0x4a, 0x99, 0x33, 0x36,
-	0x5e, 0x70, 0x17, 0xcc, 0x0a, 0xc9, 0x38, 0xf6, 0x48, 0x75, 0x46, 0xd9, 0x7e, 0x79, 0xb5, 0x4d,
-	0xa8, 0xa4, 0x39, 0xdf, 0xcc, 0xad, 0xf9, 0xc2, 0x02, 0x1f, 0x5c, 0xa2, 0x42, 0xf8, 0x08, 0x2c,
-	0x48, 0x26, 0xb1, 0x6f, 0xf3, 0x38, 0xcc, 0xfa, 0x6c, 0xa9, 0x3e, 0xc3, 0xd1, 0xb0, 0x31, 0xbf,
-	0x95, 0x62, 0x48, 0x43, 0xbd, 0x3e, 0x9a, 0x97, 0xf9, 0xf7, 0x74, 0xbf, 0x57, 0x32, 0x5d, 0x2c,
-	0x08, 0x4f, 0xc5, 0x45, 0x25, 0x5e, 0x1c, 0x0d, 0x1b, 0x73, 0x86, 0xb7, 0x2d, 0x08, 0xef, 0xf5,
-	0xd1, 0x1c, 0xcf, 0xbd, 0x0a, 0xf8, 0x18, 0x2c, 0x66, 0xd2, 0x03, 0xc2, 0x43, 0xe2, 0x4f, 0x26,
-	0x7c, 0x63, 0x34, 0x6c, 0x54, 0x8c, 0xf8, 0x3b, 0x85, 0xf5, 0xfa, 0x28, 0x5b, 0xc8, 0x04, 0x44,
-	0xf3, 0x3f, 0x0b, 0xdc, 0xba, 0xa8, 0xcf, 0xf0, 0x21, 0x78, 0x4f, 0x77, 0xda, 0x8e, 0x05, 0xf6,
-	0x88, 0xed, 0xb0, 0x20, 0xa0, 0xd2, 0x1e, 0x3c, 0x93, 0xc4, 0xd4, 0x89, 0x6e, 0x6a, 0xc2, 0x76,
-	0x8a, 0x6f, 0x28, 0xb8, 0x93, 0xa2, 0xb0, 0x03, 0xea, 0xaf, 0x93, 0x46, 0x04, 0x1f, 0x18, 0xbd,
-	0x2a, 0x15, 0xd5, 0xce, 0xe8, 0x37, 0x09, 0x3e, 0xd0, 0x1e, 0x3f, 0x80, 0xdb, 0x27, 0x3c, 0x22,
-	0x4e, 0x13, 0x2c, 0x89, 0x7d, 0xc8, 0xf8, 0x01, 0x0d, 0x3d, 0x5b, 0x90, 0x2c, 0x17, 0x55, 0x39,
-	0x5a, 0xc9, 0x79, 0x6d, 0x6a, 0xee, 0xae, 0xa6, 0xf6, 0x89, 0x4e, 0x2b, 0x1d, 0xec, 0xca, 0x85,
-	0xfb, 0x00, 0xde, 0x07, 0xcb, 0x9c, 0x60, 0xd7, 0x76, 0x58, 0x1c, 0x4a, 0x3b, 0x64, 0x3c, 0xc0,
-	0x3e, 0x3d, 0x22, 0xae, 0xa9, 0xf9, 0x46, 0x0a, 0x6e, 0xa4, 0x58, 0x6f, 0x0c, 0xc1, 0xdb, 0xa0,
-	0xa2, 0x34, 0x82, 0x1e, 0x91, 0x13, 0x15, 0xce, 0xa5, 0xe1, 0x3e, 0x3d, 0x22, 0xba, 0xa8, 0x75,
-	0x70, 0xf3, 0x90, 0x53, 0x49, 0xce, 0x9a, 0xeb, 0x22, 0x96, 0x14, 0x7a, 0xda, 0xfd, 0x2e, 0x58,
-	0xd0, 0xaa, 0x9c, 0xfd, 0x94, 0xe2, 0xcf, 0xab, 0xf8, 0xd8, 0xbf, 0xf9, 0x97, 0x05, 0xaa, 0xe7,
-	0x7d, 0xe4, 0xe0, 0xcf, 0xf9, 0x53, 0x6e, 0x5d, 0x7c, 0x64, 0x4e, 0x1a, 0x5d, 0x70, 0xc6, 0xd1,
-	0xf8, 0x8c, 0xeb, 0xef, 0xd6, 0xe7, 0x97, 0x77, 0x3e, 0xef, 0x84, 0x37, 0x31, 0x58, 0xb9, 0x30,
-	0x87, 0xb7, 0x3b, 0x85, 0xcd, 0x3f, 0x2d, 0x50, 0x7f, 0x73, 0x36, 0xf0, 0x43, 0xb0, 0x78, 0x76,
-	0xcf, 0xe9, 0xbd, 0x50, 0x39, 0x3c, 0xb9, 0xc3, 0xe0, 0xc7, 0x00, 0x26, 0xda, 0xcd, 0x0e, 0x99,
-	0x6b, 0xc6, 0xac, 0x3a, 0x32, 0x87, 0x16, 0x0c, 0xd2, 0x63, 0xae, 0x9e, 0x30, 0xec, 0x82, 0x72,
-	0x12, 0xd8, 0xa6, 0x6d, 0xfa, 0xfa, 0xfa, 0xe4, 0xaa, 0x6d, 0x43, 0xa5, 0x24, 0xd0, 0x4f, 0xcd,
-	0xe7, 0x45, 0xb0, 0xf4, 0x3a, 0x0a, 0xbc, 0x07, 0x16, 0x70, 0x82, 0xa9, 0x8f, 0x07, 0x3e, 0xc9,
-	0x96, 0x4b, 0x0b, 0x98, 0x46, 0x95, 0x71, 0xdc, 0x50, 0x1f, 0x80, 0x77, 0x4f, 0x53, 0xed, 0x41,
-	0xbc, 0xb7, 0x47, 0xb8, 0xaa, 0x62, 0x1a, 0x2d, 0x9f, 0x52, 0x74, 0x14, 0x08, 0xef, 0xa4, 0x07,
-	0x40, 0x10, 0x9e, 0x10, 0x37, 0x5f, 0xd0, 0x14, 0x9a, 0xcf, 0xc2, 0x66, 0x81, 0x3b, 0xa0, 0x82,
-	0x85, 0xa0, 0x5e, 0x38, 0x21, 0x9a, 0xad, 0x9c, 0x85, 0x0d, 0xf1, 0x7d, 0x00, 0x84, 0x1f, 0xd9,
-	0xd8, 0x91, 0x34, 0x21, 0xea, 0xe2, 0x28, 0xa1, 0xb2, 0xf0, 0xa3, 0xaf, 0x54, 0x00, 0x7e, 0x04,
-	0x16, 0x07, 0xd8, 0xc7, 0xa1, 0x93, 0xce, 0x85, 0x84, 0x69, 0x42, 0xae, 0xba, 0x07, 0x4a, 0x68,
-	0x61, 0x0c, 0x7c, 0xad, 0xe3, 0xf0, 0x33, 0x50, 0x75, 0x03, 0x9b, 0x45, 0x84, 0x63, 0x49, 0x59,
-	0x68, 0xd3, 0xd0, 0x8e, 0x38, 0xf3, 0x38, 0x11, 0xa2, 0x3a, 0xab, 0x34, 0xcb, 0x6e, 0xf0, 0x7d,
-	0x06, 0x7f, 0x1b, 0x6e, 0x1a, 0xb0, 0xf3, 0xeb, 0xf1, 0xcb, 0x7a, 0xe1, 0xef, 0x97, 0xf5, 0xc2,
-	0xef, 0xa3, 0xba, 0x75, 0x3c, 0xaa, 0x5b, 0xcf, 0x47, 0x75, 0xeb, 0x9f, 0x51, 0xdd, 0xfa, 0xe9,
-	0x9b, 0xb7, 0xfd, 0xa3, 0xf7, 0x85, 0xfa, 0xfd, 0xb1, 0x30, 0x98, 0x51, 0x37, 0xfb, 0xa7, 0xaf,
-	0x02, 0x00, 0x00, 0xff, 0xff, 0xb6, 0xe8, 0x0f, 0x6d, 0x3b, 0x0a, 0x00, 0x00,
+	// 1062 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xdb, 0x6e, 0x1b, 0x45,
+	0x1f, 0xf7, 0xba, 0x39, 0xd8, 0x93, 0x2f, 0x71, 0x32, 0x4d, 0xfa, 0x19, 0x4b, 0xd8, 0x89, 0x91,
+	0xda, 0x14, 0x88, 0x97, 0x84, 0xa8, 0xa8, 0x50, 0x54, 0xe1, 0x08, 0x54, 0x44, 0x6d, 0xc2, 0x38,
+	0x07, 0x04, 0x42, 0xcb, 0x78, 0x77, 0xb2, 0x1e, 0x65, 0x77, 0x67, 0x35, 0x33, 0xbb, 0xa1, 0xb9,
+	0xe2, 0x11, 0x78, 0x03, 0x5e, 0x27, 0x88, 0x9b, 0x5e, 0x72, 0xe5, 0x52, 0xbf, 0x01, 0x12, 0x0f,
+	0x80, 0x76, 0x66, 0xd7, 0xde, 0x24, 0x4d, 0xd3, 0xa8, 0x37, 0xd6, 0xee, 0xff, 0x77, 0x98, 0xff,
+	0x61, 0x66, 0xc7, 0xe0, 0xa9, 0x4b, 0xe5, 0x20, 0xea, 0xb7, 0x6c, 0xe6, 0x9b, 0x1d, 0x6a, 0x73,
+	0x26, 0xd8, 0x91, 0x34, 0x07, 0xb6, 0x10, 0x03, 0xea, 0x9b, 0xb6, 0xef, 0x98, 0x36, 0x0b, 0x24,
+	0xa6, 0x01, 0xe1, 0xce, 0x46, 0x12, 0xdb, 0xe0, 0x51, 0x30, 0xb0, 0xc5, 0x46, 0xbc, 0x69, 0x0a,
+	0x89, 0xa5, 0xd0, 0xbf, 0xad, 0x90, 0x33, 0xc9, 0x60, 0x6d, 0x42, 0x6e, 0x69, 0x5e, 0x4b, 0xc3,
+	0xf1, 0x66, 0x6d, 0xd9, 0x65, 0x2e, 0x53, 0x34, 0x33, 0x79, 0xd2, 0x8a, 0x5a, 0xc3, 0x65, 0xcc,
+	0xf5, 0x88, 0xa9, 0xde, 0xfa, 0xd1, 0x91, 0x29, 0xa9, 0x4f, 0x84, 0xc4, 0x7e, 0x98, 0x12, 0xb6,
+	0x73, 0x09, 0x4e, 0xdc, 0x4d, 0xdb, 0xe5, 0x2c, 0x0a, 0xd3, 0xd5, 0xcd, 0x78, 0xd3, 0xf4, 0x89,
+	0xe4, 0xd4, 0x4e, 0x13, 0x69, 0xfe, 0x5e, 0x04, 0xa0, 0x27, 0xb1, 0xa4, 0x42, 0x52, 0x5b, 0x40,
+	0x04, 0x66, 0x4f, 0x68, 0xe0, 0xb0, 0x13, 0x51, 0x35, 0x56, 0x8d, 0xf5, 0xb9, 0xad, 0x07, 0xad,
+	0xab, 0x33, 0x6d, 0x1d, 0x6a, 0xea, 0x4e, 0xc6, 0x98, 0x18, 0x3d, 0x29, 0xa0, 0xcc, 0x08, 0x3e,
+	0x04, 0xd3, 0x1e, 0x0d, 0xa2, 0x5f, 0xaa, 0x45, 0xe5, 0xb8, 0xd6, 0xa2, 0x2c, 0x6f, 0x9a, 0x26,
+	0x98, 0xf8, 0x75, 0x74, 0x6a, 0x4f, 0x0a, 0x48, 0x2b, 0xe0, 0x53, 0x50, 0x8c, 0xfd, 0xea, 0x2d,
+	0xa5, 0xdb, 0x7e, 0x5d, 0x26, 0x07, 0x94, 0xcb, 0x08, 0x7b, 0x1d, 0x6c, 0x0f, 0x68, 0x40, 0x26,
+	0x79, 0xb4, 0x67, 0x46, 0xc3, 0x46, 0xf1, 0xa0, 0x83, 0x8a, 0xb1, 0x0f, 0xef, 0x81, 0x8a, 0xcd,
+	0x38, 0xb1, 0x84, 0x3d, 0x20, 0x4e, 0xe4, 0xd1, 0xc0, 0xad, 0x4e, 0xad, 0x1a, 0xeb, 0x65, 0xb4,
+	0x90, 0x84, 0x7b, 0xe3, 0x68, 0x7b, 0x0e, 0x94, 0xc7, 0x6b, 0x35, 0xff, 0xb9, 0x05, 0x6a, 0x57,
+	0x17, 0x0a, 0xdb, 0xa0, 0x3c, 0x9e, 0x44, 0xda, 0xb3, 0x5a, 0x4b, 0xcf, 0xaa, 0x95, 0xcd, 0xaa,
+	0xb5, 0x97, 0x31, 0xda, 0xa5, 0xb3, 0x61, 0xa3, 0xf0, 0xdb, 0x8b, 0x86, 0x81, 0x26, 0x32, 0x78,
+	0x00, 0x96, 0xc7, 0xeb, 0x59, 0x42, 0x62, 0x2e, 0xad, 0x04, 0xac, 0x16, 0x6f, 0x60, 0x07, 0xed,
+	0x5c, 0x72, 0x5c, 0x26, 0x14, 0x78, 0x1f, 0x94, 0xa3, 0x30, 0x71, 0xb2, 0x02, 0xa1, 0xba, 0x38,
+	0xd5, 0xfe, 0xdf, 0x68, 0xd8, 0x28, 0xed, 0xab, 0x60, 0xb7, 0x87, 0x4a, 0x1a, 0xee, 0x0a, 0xf8,
+	0x13, 0x28, 0x87, 0x9c, 0xd9, 0x44, 0x08, 0xc6, 0x55, 0x57, 0xe6, 0xb6, 0x1e, 0xdf, 0x64, 0xf4,
+	0xbb, 0x99, 0x78, 0xd2, 0x1a, 0x34, 0x71, 0x84, 0x7b, 0x60, 0xc6, 0x27, 0x3e, 0xe3, 0xcf, 0xaa,
+	0xd3, 0xca, 0xfb, 0xd1, 0x4d, 0xbc, 0x3b, 0x4a, 0x99, 0x33, 0x4e, 0xbd, 0xe0, 0x21, 0x98, 0x15,
+	0x92, 0x71, 0xec, 0x92, 0xea, 0x8c, 0xb2, 0xfd, 0xfc, 0x66, 0xbb, 0x55, 0x49, 0x73, 0xbe, 0x99,
+	0x5b, 0xf3, 0x85, 0x01, 0xde, 0x7b, 0x83, 0x0a, 0xe1, 0x23, 0xb0, 0x28, 0x99, 0xc4, 0x9e, 0xc5,
+	0xa3, 0x20, 0xeb, 0xb3, 0xa1, 0xfa, 0x0c, 0x47, 0xc3, 0xc6, 0xc2, 0x5e, 0x82, 0x21, 0x0d, 0x75,
+	0x7b, 0x68, 0x41, 0xe6, 0xdf, 0x93, 0x83, 0x51, 0xc9, 0x74, 0x91, 0x20, 0x3c, 0x11, 0x17, 0x95,
+	0x78, 0x69, 0x34, 0x6c, 0xcc, 0xa7, 0xbc, 0x7d, 0x41, 0x78, 0xb7, 0x87, 0xe6, 0x79, 0xee, 0x55,
+	0xc0, 0xc7, 0x60, 0x29, 0x93, 0x1e, 0x13, 0x1e, 0x10, 0x6f, 0x32, 0xe1, 0xdb, 0xa3, 0x61, 0xa3,
+	0x92, 0x8a, 0xbf, 0x51, 0x58, 0xb7, 0x87, 0x2a, 0xfc, 0x5c, 0x40, 0x34, 0xff, 0x35, 0xc0, 0xea,
+	0x75, 0x7d, 0x86, 0x0f, 0xc1, 0x3b, 0xba, 0xd3, 0x56, 0x24, 0xb0, 0x4b, 0x2c, 0x9b, 0xf9, 0x3e,
+	0x95, 0x56, 0xff, 0x99, 0x24, 0x69, 0x9d, 0xe8, 0x8e, 0x26, 0xec, 0x27, 0xf8, 0x8e, 0x82, 0xdb,
+	0x09, 0x0a, 0xdb, 0xa0, 0xfe, 0x2a, 0x69, 0x48, 0xf0, 0x71, 0xaa, 0x57, 0xa5, 0xa2, 0xda, 0x25,
+	0xfd, 0x2e, 0xc1, 0xc7, 0xda, 0xe3, 0x3b, 0x70, 0xf7, 0x9c, 0x47, 0xc8, 0x69, 0x8c, 0x25, 0xb1,
+	0x4e, 0x18, 0x3f, 0xa6, 0x81, 0x6b, 0x09, 0x92, 0xe5, 0xa2, 0x2a, 0x47, 0x6b, 0x39, 0xaf, 0x5d,
+	0xcd, 0x3d, 0xd4, 0xd4, 0x1e, 0xd1, 0x69, 0x25, 0x83, 0x5d, 0xbb, 0x76, 0x1f, 0xc0, 0x2d, 0xb0,
+	0xc2, 0x09, 0x76, 0x2c, 0x9b, 0x45, 0x81, 0xb4, 0x02, 0xc6, 0x7d, 0xec, 0xd1, 0x53, 0xe2, 0xa4,
+	0x35, 0xdf, 0x4e, 0xc0, 0x9d, 0x04, 0xeb, 0x8e, 0x21, 0x78, 0x17, 0x54, 0x94, 0x46, 0xd0, 0x53,
+	0x72, 0xae, 0xc2, 0xf9, 0x24, 0xdc, 0xa3, 0xa7, 0x44, 0x17, 0xb5, 0x0d, 0xee, 0x9c, 0x70, 0x2a,
+	0xc9, 0x65, 0x73, 0x5d, 0xc4, 0xb2, 0x42, 0x2f, 0xba, 0xaf, 0x83, 0x45, 0xad, 0xca, 0xd9, 0x4f,
+	0x29, 0xfe, 0x82, 0x8a, 0x8f, 0xfd, 0x9b, 0x7f, 0x1a, 0xa0, 0x7a, 0xd5, 0xd7, 0x10, 0xfe, 0x98,
+	0x3f, 0xe5, 0xc6, 0xf5, 0x47, 0xe6, 0xbc, 0xd1, 0x35, 0x67, 0x1c, 0x8d, 0xcf, 0xb8, 0xfe, 0x6e,
+	0x7d, 0xfa, 0xe6, 0xce, 0x57, 0x9d, 0xf0, 0x26, 0x06, 0x6b, 0xd7, 0xe6, 0xf0, 0x76, 0xa7, 0xb0,
+	0xf9, 0x87, 0x01, 0xea, 0xaf, 0xcf, 0x06, 0xbe, 0x0f, 0x96, 0x2e, 0xef, 0x39, 0xbd, 0x17, 0x2a,
+	0x27, 0xe7, 0x77, 0x18, 0xfc, 0x10, 0xc0, 0x58, 0xbb, 0x59, 0x01, 0x73, 0xd2, 0x31, 0xab, 0x8e,
+	0xcc, 0xa3, 0xc5, 0x14, 0xe9, 0x32, 0x47, 0x4f, 0x18, 0x76, 0x40, 0x39, 0xf6, 0xad, 0xb4, 0x6d,
+	0xfa, 0x9e, 0xfb, 0xe8, 0xa6, 0x6d, 0x43, 0xa5, 0xd8, 0xd7, 0x4f, 0xcd, 0xe7, 0x45, 0xb0, 0xfc,
+	0x2a, 0x0a, 0xbc, 0x0f, 0x16, 0x71, 0x8c, 0xa9, 0x87, 0xfb, 0x1e, 0xc9, 0x96, 0x4b, 0x0a, 0x98,
+	0x46, 0x95, 0x71, 0x3c, 0xa5, 0x3e, 0x00, 0xff, 0xbf, 0x48, 0xb5, 0xfa, 0xd1, 0xd1, 0x11, 0xe1,
+	0xaa, 0x8a, 0x69, 0xb4, 0x72, 0x41, 0xd1, 0x56, 0x60, 0x72, 0xbb, 0x72, 0x22, 0x08, 0x8f, 0x89,
+	0x93, 0x2f, 0x68, 0x0a, 0x2d, 0x64, 0xe1, 0x74, 0x81, 0x7b, 0xa0, 0x82, 0x85, 0xa0, 0x6e, 0x30,
+	0x21, 0xa6, 0x5b, 0x39, 0x0b, 0xa7, 0xc4, 0x77, 0x01, 0x10, 0x5e, 0x68, 0x61, 0x5b, 0xd2, 0x98,
+	0xa8, 0x8b, 0xa3, 0x84, 0xca, 0xc2, 0x0b, 0xbf, 0x50, 0x01, 0xf8, 0x01, 0x58, 0xea, 0x63, 0x0f,
+	0x07, 0x76, 0x32, 0x17, 0x12, 0x24, 0x09, 0x39, 0xea, 0x1e, 0x28, 0xa1, 0xc5, 0x31, 0xf0, 0xa5,
+	0x8e, 0xc3, 0x4f, 0x40, 0xd5, 0xf1, 0x2d, 0x16, 0x12, 0x8e, 0x25, 0x65, 0x81, 0x45, 0x03, 0x2b,
+	0xe4, 0xcc, 0xe5, 0x44, 0x88, 0xea, 0xac, 0xd2, 0xac, 0x38, 0xfe, 0xb7, 0x19, 0xfc, 0x75, 0xb0,
+	0x9b, 0x82, 0xed, 0x9f, 0xcf, 0x5e, 0xd6, 0x0b, 0x7f, 0xbd, 0xac, 0x17, 0x7e, 0x1d, 0xd5, 0x8d,
+	0xb3, 0x51, 0xdd, 0x78, 0x3e, 0xaa, 0x1b, 0x7f, 0x8f, 0xea, 0xc6, 0x0f, 0x5f, 0xbd, 0xed, 0x3f,
+	0xc2, 0xcf, 0xd4, 0xef, 0xf7, 0x85, 0xfe, 0x8c, 0xba, 0xd9, 0x3f, 0xfe, 0x6f, 0x00, 0x55, 0x1e,
+	0xeb, 0x06, 0x64, 0x0a, 0x00, 0x00,
 }
 
 func (m *Statistics) Marshal() (dAtA []byte, err error) {
@@ -632,6 +639,12 @@ func (m *Statistics) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n2
 	}
+	if len(m.CoreScheduling) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintStats(dAtA, i, uint64(len(m.CoreScheduling)))
+		i += copy(dAtA[i:], m.CoreScheduling)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1052,6 +1065,10 @@ func (m *Statistics) Size() (n int) {
 		l = m.VM.Size()
 		n += 1 + l + sovStats(uint64(l))
 	}
+	l = len(m.CoreScheduling)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1289,6 +1306,7 @@ func (this *Statistics) String() string {
 	s := strings.Join([]string{`&Statistics{`,
 		`Container:` + fmt.Sprintf("%v", this.Container) + `,`,
 		`VM:` + strings.Replace(fmt.Sprintf("%v", this.VM), "VirtualMachineStatistics", "VirtualMachineStatistics", 1) + `,`,
+		`CoreScheduling:` + fmt.Sprintf("%v", this.CoreScheduling) + `,`,
 		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
 		`}`,
 	}, "")
@@ -1566,6 +1584,38 @@ func (m *Statistics) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CoreScheduling", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CoreScheduling = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
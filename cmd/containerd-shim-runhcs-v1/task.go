@@ -81,11 +81,43 @@ type shimTask interface {
 	//
 	// If the host is not hypervisor isolated returns error.
 	Share(ctx context.Context, req *shimdiag.ShareRequest) error
+	// Quiesce freezes the guest file systems of SCSI disks of the host UVM,
+	// such as the scratch disks of containers, so that backups can snapshot
+	// the disks, or thaws them once `req.Thaw` is set.
+	//
+	// If the host is not hypervisor isolated returns error.
+	Quiesce(ctx context.Context, req *shimdiag.QuiesceRequest) error
+	// ResizeDisk grows the SCSI disk `req.HostPath` of the host UVM, such as
+	// the scratch disk of a container, and the file system on it to
+	// `req.SizeInBytes` while the UVM is running.
+	//
+	// If the host is not hypervisor isolated returns error.
+	ResizeDisk(ctx context.Context, req *shimdiag.ResizeDiskRequest) error
+	// NetworkInterfaces returns a map of HNS endpoint ID to the name of its
+	// network interface in the host UVM, for the network namespace of the
+	// task. This is only supported for LCOW.
+	//
+	// If the host is not hypervisor isolated returns error.
+	NetworkInterfaces(ctx context.Context) (map[string]string, error)
+	// CheckHealth returns an error if the init process of the task is running
+	// but its container does not respond.
+	CheckHealth(ctx context.Context) error
+	// WaitForReadiness blocks until the started task meets its readiness gate,
+	// if any. It returns an error if the init process exits or the gate is not
+	// met within its timeout.
+	WaitForReadiness(ctx context.Context) error
 	// Stats returns various metrics for the task.
 	//
 	// If the host is hypervisor isolated and this task owns the host additional
 	// metrics on the UVM may be returned as well.
 	Stats(ctx context.Context) (*stats.Statistics, error)
+	// Update updates the resources of the task to `resources`, a
+	// `*specs.LinuxResources` or `*specs.WindowsResources`.
+	//
+	// Only the memory and processor limits of a task that owns its hypervisor
+	// isolated host are applied, to the host. Otherwise this task MUST return
+	// `errdefs.ErrNotImplemented`.
+	Update(ctx context.Context, resources interface{}) error
 }
 
 // isStatsNotFound returns true if the err corresponds to a scenario
@@ -27,6 +27,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/hcsoci"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/internal/ownership"
 	"github.com/Microsoft/hcsshim/internal/resources"
 	"github.com/Microsoft/hcsshim/internal/schema1"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
@@ -35,7 +36,7 @@ import (
 	"github.com/Microsoft/hcsshim/osversion"
 )
 
-func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.CreateTaskRequest, s *specs.Spec) (shimTask, error) {
+func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.CreateTaskRequest, s *specs.Spec, shimOpts *runhcsopts.Options) (shimTask, error) {
 	log.G(ctx).WithField("tid", req.ID).Debug("newHcsStandaloneTask")
 
 	ct, _, err := oci.GetSandboxTypeAndID(s.Annotations)
@@ -50,7 +51,7 @@ func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.Creat
 			ct)
 	}
 
-	owner := filepath.Base(os.Args[0])
+	owner := ownership.Label(filepath.Base(os.Args[0]))
 
 	var parent *uvm.UtilityVM
 	if osversion.Get().Build >= osversion.RS5 && oci.IsIsolated(s) {
@@ -59,9 +60,24 @@ func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.Creat
 		if err != nil {
 			return nil, err
 		}
+		if err := oci.UpdateCreateOptsFromOptions(opts, shimOpts); err != nil {
+			return nil, err
+		}
+		res, err := reserveUVMCapacity(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		started := false
+		defer func() {
+			// release the reservation if the uvm was never started
+			if !started {
+				_ = res.Release()
+			}
+		}()
 		switch opts.(type) {
 		case *uvm.OptionsLCOW:
 			lopts := (opts).(*uvm.OptionsLCOW)
+			lopts.SandboxID = req.ID
 			parent, err = uvm.CreateLCOW(ctx, lopts)
 			if err != nil {
 				return nil, err
@@ -92,12 +108,15 @@ func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.Creat
 		err = parent.Start(ctx)
 		if err != nil {
 			parent.Close()
+			return nil, err
 		}
+		started = true
+		releaseOnExit(parent, res)
 	} else if !oci.IsWCOW(s) {
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "oci spec does not contain WCOW or LCOW spec")
 	}
 
-	shim, err := newHcsTask(ctx, events, parent, true, req, s)
+	shim, err := newHcsTask(ctx, events, parent, true, req, s, nil)
 	if err != nil {
 		if parent != nil {
 			parent.Close()
@@ -110,21 +129,29 @@ func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.Creat
 // newHcsTask creates a container within `parent` and its init exec process in
 // the `shimExecCreated` state and returns the task that tracks its lifetime.
 //
-// If `parent == nil` the container is created on the host.
+// If `parent == nil` the container is created on the host. If set,
+// `hostedCPUQuotas` returns the CPU quotas of the other containers in `parent`,
+// which are rescaled along with the quota of this container when an update of
+// the task changes the processor limit of `parent`.
 func newHcsTask(
 	ctx context.Context,
 	events publisher,
 	parent *uvm.UtilityVM,
 	ownsParent bool,
 	req *task.CreateTaskRequest,
-	s *specs.Spec) (_ shimTask, err error) {
+	s *specs.Spec,
+	hostedCPUQuotas func() []*uvm.ContainerCPUQuota) (_ shimTask, err error) {
 	log.G(ctx).WithFields(logrus.Fields{
 		"tid":        req.ID,
 		"ownsParent": ownsParent,
 	}).Debug("newHcsTask")
 
-	owner := filepath.Base(os.Args[0])
+	owner := ownership.Label(filepath.Base(os.Args[0]))
 	isTemplate := oci.ParseAnnotationsSaveAsTemplate(ctx, s)
+	readiness, err := oci.ParseReadinessGate(ctx, s.Annotations)
+	if err != nil {
+		return nil, err
+	}
 
 	io, err := cmd.NewUpstreamIO(ctx, req.ID, req.Stdout, req.Stderr, req.Stdin, req.Terminal)
 	if err != nil {
@@ -174,6 +201,14 @@ func newHcsTask(
 		closed:     make(chan struct{}),
 		taskSpec:   s,
 		isTemplate: isTemplate,
+		readiness:  readiness,
+	}
+	if shimOpts != nil {
+		ht.allowExecEscalation = shimOpts.AllowExecEscalation
+	}
+	if !ht.isWCOW {
+		ht.cpuQuota = newContainerCPUQuota(system, s)
+		ht.hostedCPUQuotas = hostedCPUQuotas
 	}
 	ht.init = newHcsExec(
 		ctx,
@@ -185,6 +220,7 @@ func newHcsTask(
 		req.Bundle,
 		ht.isWCOW,
 		s.Process,
+		nil,
 		io,
 	)
 
@@ -245,7 +281,7 @@ func newClonedHcsTask(
 		"templateid": templateID,
 	}).Debug("newClonedHcsTask")
 
-	owner := filepath.Base(os.Args[0])
+	owner := ownership.Label(filepath.Base(os.Args[0]))
 
 	if parent.OS() != "windows" {
 		return nil, fmt.Errorf("cloned task can only be created inside a windows host")
@@ -405,6 +441,25 @@ type hcsTask struct {
 
 	// taskSpec represents the spec/configuration for this task.
 	taskSpec *specs.Spec
+
+	// allowExecEscalation is set if the exec processes of an LCOW task can
+	// have capabilities that its init process does not have, and replace the
+	// seccomp profile of the container. Set from the runtime options.
+	allowExecEscalation bool
+
+	// cpuQuota is the CFS quota of an LCOW container with a CPU quota, which is
+	// rescaled when an update changes the processor limit of its host, or nil.
+	cpuQuota *uvm.ContainerCPUQuota
+	// hostedCPUQuotas, if set, returns the CPU quotas of the other containers
+	// in `host`, such as the workload containers of a pod.
+	hostedCPUQuotas func() []*uvm.ContainerCPUQuota
+
+	// quiescer tracks the disks of `host` frozen by Quiesce.
+	quiescer quiescer
+
+	// readiness is the readiness gate of the task, or nil if the start of the
+	// task is reported as soon as its init process is created.
+	readiness *oci.ReadinessGate
 }
 
 func (ht *hcsTask) ID() string {
@@ -425,6 +480,18 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 		return errors.Wrapf(errdefs.ErrFailedPrecondition, "exec: '' in task: '%s' must be running to create additional execs", ht.id)
 	}
 
+	var seccomp *specs.LinuxSeccomp
+	if !ht.isWCOW && ht.taskSpec != nil {
+		if err := oci.NormalizeProcessEncoding(ctx, spec); err != nil {
+			return errors.Wrapf(errdefs.ErrInvalidArgument, "exec: '%s' in task: '%s': %s", req.ExecID, ht.id, err)
+		}
+		var err error
+		seccomp, err = ht.validateExecSecurity(req, spec)
+		if err != nil {
+			return errors.Wrapf(errdefs.ErrInvalidArgument, "exec: '%s' in task: '%s': %s", req.ExecID, ht.id, err)
+		}
+	}
+
 	io, err := cmd.NewUpstreamIO(ctx, req.ID, req.Stdout, req.Stderr, req.Stdin, req.Terminal)
 	if err != nil {
 		return err
@@ -440,6 +507,7 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 		ht.init.Status().Bundle,
 		ht.isWCOW,
 		spec,
+		seccomp,
 		io,
 	)
 
@@ -455,6 +523,31 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 		})
 }
 
+// validateExecSecurity returns the seccomp profile of the LCOW exec `req`, or
+// nil if it runs with the profile of the container, after checking that the
+// exec does not escalate the privileges of the init process `spec` unless the
+// runtime options of the task allow it.
+func (ht *hcsTask) validateExecSecurity(req *task.ExecProcessRequest, spec *specs.Process) (*specs.LinuxSeccomp, error) {
+	var seccomp *specs.LinuxSeccomp
+	if req.Spec != nil {
+		var err error
+		seccomp, err = oci.ParseExecSeccomp(req.Spec.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ht.allowExecEscalation {
+		return seccomp, nil
+	}
+	if err := oci.ValidateExecCapabilities(ht.taskSpec.Process, spec); err != nil {
+		return nil, err
+	}
+	if seccomp != nil && ht.taskSpec.Linux != nil && ht.taskSpec.Linux.Seccomp != nil {
+		return nil, errors.New("exec can not replace the seccomp profile of the container")
+	}
+	return seccomp, nil
+}
+
 func (ht *hcsTask) GetExec(eid string) (shimExec, error) {
 	if eid == "" {
 		return ht.init, nil
@@ -643,6 +736,18 @@ func (ht *hcsTask) waitForHostExit() {
 	} else {
 		log.G(ctx).Debug("host virtual machine exited")
 	}
+	if path, ok := ht.host.CrashDump(); ok {
+		log.G(ctx).WithField("path", path).Error("host virtual machine crashed")
+		if err := ht.events.publishEvent(
+			ctx,
+			runhcsopts.TaskCrashDumpEventTopic,
+			&runhcsopts.TaskCrashDump{
+				ContainerID: ht.id,
+				Path:        path,
+			}); err != nil {
+			log.G(ctx).WithError(err).Error("failed to publish TaskCrashDumpEventTopic")
+		}
+	}
 
 	ht.execs.Range(func(key, value interface{}) bool {
 		ex := value.(shimExec)
@@ -738,8 +843,19 @@ func (ht *hcsTask) closeHost(ctx context.Context) {
 		log.G(ctx).Debug("hcsTask::closeHostOnce")
 
 		if ht.ownsHost && ht.host != nil {
-			if err := ht.host.Close(); err != nil {
-				log.G(ctx).WithError(err).Error("failed host vm shutdown")
+			detached := false
+			if ht.host.IsPersistent() {
+				// Keep the UVM running for the next pod that attaches to it.
+				if err := ht.host.Detach(ctx); err != nil {
+					log.G(ctx).WithError(err).Error("failed to detach persistent host vm")
+				} else {
+					detached = true
+				}
+			}
+			if !detached {
+				if err := ht.host.Close(); err != nil {
+					log.G(ctx).WithError(err).Error("failed host vm shutdown")
+				}
 			}
 		}
 		// Send the `init` exec exit notification always.
@@ -780,6 +896,23 @@ func (ht *hcsTask) DumpGuestStacks(ctx context.Context) string {
 	return ""
 }
 
+func (ht *hcsTask) CheckHealth(ctx context.Context) error {
+	if ht.init.State() != shimExecStateRunning {
+		return nil
+	}
+	if _, err := ht.c.Properties(ctx); err != nil {
+		return errors.Wrapf(err, "task with id: '%s' failed to respond", ht.id)
+	}
+	return nil
+}
+
+func (ht *hcsTask) WaitForReadiness(ctx context.Context) error {
+	if ht.readiness == nil {
+		return nil
+	}
+	return waitForReadiness(ctx, ht.id, ht.c, ht.init, ht.readiness)
+}
+
 func (ht *hcsTask) Share(ctx context.Context, req *shimdiag.ShareRequest) error {
 	if ht.host == nil {
 		return errTaskNotIsolated
@@ -837,6 +970,27 @@ func hcsPropertiesToWindowsStats(props *hcsschema.Properties) *stats.Statistics_
 	return wcs
 }
 
+func (ht *hcsTask) Quiesce(ctx context.Context, req *shimdiag.QuiesceRequest) error {
+	if ht.host == nil {
+		return errTaskNotIsolated
+	}
+	return ht.quiescer.handle(ctx, req, ht.host.QuiesceSCSI)
+}
+
+func (ht *hcsTask) ResizeDisk(ctx context.Context, req *shimdiag.ResizeDiskRequest) error {
+	if ht.host == nil {
+		return errTaskNotIsolated
+	}
+	return ht.host.ResizeSCSI(ctx, req.HostPath, req.SizeInBytes)
+}
+
+func (ht *hcsTask) NetworkInterfaces(ctx context.Context) (map[string]string, error) {
+	if ht.host == nil {
+		return nil, errTaskNotIsolated
+	}
+	return ht.host.NetworkInterfaceNames(ht.cr.NetNS())
+}
+
 func (ht *hcsTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	s := &stats.Statistics{}
 	props, err := ht.c.PropertiesV2(ctx, hcsschema.PTStatistics)
@@ -850,6 +1004,9 @@ func (ht *hcsTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 			s.Container = &stats.Statistics_Linux{Linux: props.Metrics}
 		}
 	}
+	if !ht.isWCOW && ht.host != nil {
+		s.CoreScheduling = ht.host.CoreSchedulingCookie(ht.taskSpec.Annotations[oci.KubernetesContainerNameAnnotation])
+	}
 	if ht.ownsHost && ht.host != nil {
 		vmStats, err := ht.host.Stats(ctx)
 		if err != nil && !isStatsNotFound(err) {
@@ -859,3 +1016,143 @@ func (ht *hcsTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	}
 	return s, nil
 }
+
+func (ht *hcsTask) Update(ctx context.Context, resources interface{}) error {
+	if !ht.ownsHost || ht.host == nil {
+		return errors.Wrap(errdefs.ErrNotImplemented, "only the resources of a task that owns its hypervisor isolated host can be updated")
+	}
+	return updateHostResources(ctx, ht.host, resources, ht.cpuQuotas())
+}
+
+// newContainerCPUQuota returns the CFS quota of the LCOW container `c` created
+// from `s`, or nil if it has no CPU quota.
+func newContainerCPUQuota(c cow.Container, s *specs.Spec) *uvm.ContainerCPUQuota {
+	if s.Linux == nil || s.Linux.Resources == nil || s.Linux.Resources.CPU == nil {
+		return nil
+	}
+	cpu := s.Linux.Resources.CPU
+	if cpu.Quota == nil || *cpu.Quota <= 0 {
+		return nil
+	}
+	q := &uvm.ContainerCPUQuota{
+		Container: c,
+		Quota:     *cpu.Quota,
+	}
+	if cpu.Period != nil {
+		q.Period = *cpu.Period
+	}
+	return q
+}
+
+// cpuQuotas returns the CPU quotas of the containers in the host of the task,
+// including its own.
+func (ht *hcsTask) cpuQuotas() []*uvm.ContainerCPUQuota {
+	var quotas []*uvm.ContainerCPUQuota
+	if ht.cpuQuota != nil {
+		quotas = append(quotas, ht.cpuQuota)
+	}
+	if ht.hostedCPUQuotas != nil {
+		quotas = append(quotas, ht.hostedCPUQuotas()...)
+	}
+	return quotas
+}
+
+// defaultCFSPeriod is the CFS period in microseconds of a Linux CPU quota
+// without a period.
+const defaultCFSPeriod = 100000
+
+const (
+	// minCPUShares and maxCPUShares are the range of Linux CPU shares.
+	minCPUShares = 2
+	maxCPUShares = 262144
+	// maxProcessorWeight is the highest HCS processor weight.
+	maxProcessorWeight = 10000
+)
+
+// linuxCPUSharesToWeight maps the Linux CPU shares `shares` linearly from
+// [2, 262144] to the HCS processor weight range of [1, 10000], as runc maps
+// shares to the cgroup v2 CPU weight, so the default of 1024 shares is a weight
+// of 39. Shares out of range are clamped.
+func linuxCPUSharesToWeight(shares uint64) int32 {
+	if shares < minCPUShares {
+		shares = minCPUShares
+	} else if shares > maxCPUShares {
+		shares = maxCPUShares
+	}
+	return int32(1 + (shares-minCPUShares)*(maxProcessorWeight-1)/(maxCPUShares-minCPUShares))
+}
+
+// updateHostResources applies the memory limit and the processor limit and
+// weight of `resources` to `host`. The other resources are not applied.
+//
+// The processor limit of Linux resources is their CFS quota spread across the
+// vCPUs of `host`, and their processor weight is their CPU shares mapped to
+// the range of the weight. The processor limit and weight of Windows resources
+// are their CPU maximum and shares, as at create. The CPU quotas of
+// `containers` are rescaled with the processor limit.
+func updateHostResources(ctx context.Context, host *uvm.UtilityVM, resources interface{}, containers []*uvm.ContainerCPUQuota) error {
+	var (
+		memory uint64
+		limit  int32
+		weight int32
+	)
+	switch r := resources.(type) {
+	case *specs.LinuxResources:
+		if r.Memory != nil && r.Memory.Limit != nil && *r.Memory.Limit > 0 {
+			memory = uint64(*r.Memory.Limit)
+		}
+		if r.CPU != nil && r.CPU.Quota != nil && *r.CPU.Quota > 0 {
+			period := uint64(defaultCFSPeriod)
+			if r.CPU.Period != nil && *r.CPU.Period > 0 {
+				period = *r.CPU.Period
+			}
+			l := uint64(*r.CPU.Quota) * 100000 / (period * uint64(host.ProcessorCount()))
+			if l == 0 {
+				l = 1
+			} else if l > 100000 {
+				l = 100000
+			}
+			limit = int32(l)
+		}
+		if r.CPU != nil && r.CPU.Shares != nil && *r.CPU.Shares > 0 {
+			weight = linuxCPUSharesToWeight(*r.CPU.Shares)
+		}
+	case *specs.WindowsResources:
+		if r.Memory != nil && r.Memory.Limit != nil {
+			memory = *r.Memory.Limit
+		}
+		if r.CPU != nil && r.CPU.Maximum != nil {
+			limit = int32(*r.CPU.Maximum)
+		}
+		if r.CPU != nil && r.CPU.Shares != nil {
+			weight = int32(*r.CPU.Shares)
+		}
+	default:
+		return errors.Wrapf(errdefs.ErrInvalidArgument, "invalid resources type %T", resources)
+	}
+	if memory == 0 && limit == 0 && weight == 0 {
+		return errors.Wrap(errdefs.ErrNotImplemented, "only the memory and processor limits of a task can be updated")
+	}
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"memory": memory,
+		"limit":  limit,
+		"weight": weight,
+	}).Debug("updating host resources")
+	if memory != 0 {
+		if err := host.UpdateMemory(ctx, memory); err != nil {
+			return errors.Wrap(err, "failed to update host memory")
+		}
+	}
+	if limit != 0 {
+		if err := host.UpdateProcessorLimit(ctx, limit, containers); err != nil {
+			return errors.Wrap(err, "failed to update host processor limit")
+		}
+	}
+	if weight != 0 {
+		if err := host.UpdateProcessorWeight(ctx, weight); err != nil {
+			return errors.Wrap(err, "failed to update host processor weight")
+		}
+	}
+	return nil
+}
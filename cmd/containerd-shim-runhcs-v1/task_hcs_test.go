@@ -7,7 +7,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/runtime/v2/task"
+	google_protobuf1 "github.com/gogo/protobuf/types"
+	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
 func setupTestHcsTask(t *testing.T) (*hcsTask, *testShimExec, *testShimExec) {
@@ -301,3 +306,153 @@ func Test_hcsTask_DeleteExec_2ndExecID_ExitedState_Success(t *testing.T) {
 	}
 	verifyDeleteSuccessValues(t, pid, status, at, second)
 }
+
+func Test_hcsTask_Quiesce_ProcessIsolated_Error(t *testing.T) {
+	lt, _, _ := setupTestHcsTask(t)
+
+	err := lt.Quiesce(context.TODO(), &shimdiag.QuiesceRequest{HostPaths: []string{"C:\\scratch.vhdx"}})
+
+	verifyExpectedError(t, nil, err, errTaskNotIsolated)
+}
+
+func Test_hcsTask_ResizeDisk_ProcessIsolated_Error(t *testing.T) {
+	lt, _, _ := setupTestHcsTask(t)
+
+	err := lt.ResizeDisk(context.TODO(), &shimdiag.ResizeDiskRequest{HostPath: "C:\\scratch.vhdx", SizeInBytes: 1 << 30})
+
+	verifyExpectedError(t, nil, err, errTaskNotIsolated)
+}
+
+func setupTestExecSecurity(t *testing.T) (*hcsTask, *specs.Process) {
+	lt, _, _ := setupTestHcsTask(t)
+	lt.taskSpec = &specs.Spec{
+		Process: &specs.Process{
+			Capabilities: &specs.LinuxCapabilities{
+				Effective: []string{"CAP_KILL"},
+			},
+		},
+		Linux: &specs.Linux{
+			Seccomp: &specs.LinuxSeccomp{DefaultAction: specs.ActErrno},
+		},
+	}
+	spec := &specs.Process{
+		Capabilities: &specs.LinuxCapabilities{
+			Effective: []string{"CAP_SYS_ADMIN"},
+		},
+	}
+	return lt, spec
+}
+
+func newTestExecRequest(process string) *task.ExecProcessRequest {
+	return &task.ExecProcessRequest{
+		Spec: &google_protobuf1.Any{Value: []byte(process)},
+	}
+}
+
+func Test_hcsTask_ValidateExecSecurity_Capabilities_Error(t *testing.T) {
+	lt, spec := setupTestExecSecurity(t)
+
+	if _, err := lt.validateExecSecurity(newTestExecRequest(`{}`), spec); err == nil {
+		t.Fatal("expected the capability escalation to be rejected")
+	}
+}
+
+func Test_hcsTask_ValidateExecSecurity_Seccomp_Error(t *testing.T) {
+	lt, _ := setupTestExecSecurity(t)
+
+	req := newTestExecRequest(`{"seccomp":{"defaultAction":"SCMP_ACT_ALLOW"}}`)
+	if _, err := lt.validateExecSecurity(req, &specs.Process{}); err == nil {
+		t.Fatal("expected the seccomp profile override to be rejected")
+	}
+}
+
+func Test_hcsTask_ValidateExecSecurity_NoContainerSeccomp_Success(t *testing.T) {
+	lt, _ := setupTestExecSecurity(t)
+	lt.taskSpec.Linux = nil
+
+	req := newTestExecRequest(`{"seccomp":{"defaultAction":"SCMP_ACT_ERRNO"}}`)
+	seccomp, err := lt.validateExecSecurity(req, &specs.Process{})
+	if err != nil {
+		t.Fatalf("should not have failed with error: %v", err)
+	}
+	if seccomp == nil || seccomp.DefaultAction != specs.ActErrno {
+		t.Fatalf("expected the exec seccomp profile, got: %+v", seccomp)
+	}
+}
+
+func Test_hcsTask_ValidateExecSecurity_AllowExecEscalation_Success(t *testing.T) {
+	lt, spec := setupTestExecSecurity(t)
+	lt.allowExecEscalation = true
+
+	req := newTestExecRequest(`{"seccomp":{"defaultAction":"SCMP_ACT_ALLOW"}}`)
+	seccomp, err := lt.validateExecSecurity(req, spec)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %v", err)
+	}
+	if seccomp == nil || seccomp.DefaultAction != specs.ActAllow {
+		t.Fatalf("expected the exec seccomp profile, got: %+v", seccomp)
+	}
+}
+
+func Test_newContainerCPUQuota(t *testing.T) {
+	quota := int64(50000)
+	period := uint64(200000)
+	s := &specs.Spec{
+		Linux: &specs.Linux{
+			Resources: &specs.LinuxResources{
+				CPU: &specs.LinuxCPU{Quota: &quota, Period: &period},
+			},
+		},
+	}
+	q := newContainerCPUQuota(nil, s)
+	if q == nil || q.Quota != quota || q.Period != period {
+		t.Fatalf("expected quota %d and period %d, got: %+v", quota, period, q)
+	}
+
+	unlimited := int64(-1)
+	for _, s := range []*specs.Spec{
+		{},
+		{Linux: &specs.Linux{Resources: &specs.LinuxResources{}}},
+		{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Quota: &unlimited}}}},
+	} {
+		if q := newContainerCPUQuota(nil, s); q != nil {
+			t.Fatalf("expected no quota, got: %+v", q)
+		}
+	}
+}
+
+func Test_hcsTask_cpuQuotas(t *testing.T) {
+	lt, _, _ := setupTestHcsTask(t)
+	if quotas := lt.cpuQuotas(); len(quotas) != 0 {
+		t.Fatalf("expected no quotas, got: %+v", quotas)
+	}
+
+	own := &uvm.ContainerCPUQuota{Quota: 10000}
+	hosted := &uvm.ContainerCPUQuota{Quota: 20000}
+	lt.cpuQuota = own
+	lt.hostedCPUQuotas = func() []*uvm.ContainerCPUQuota {
+		return []*uvm.ContainerCPUQuota{hosted}
+	}
+	quotas := lt.cpuQuotas()
+	if len(quotas) != 2 || quotas[0] != own || quotas[1] != hosted {
+		t.Fatalf("expected the task and hosted quotas, got: %+v", quotas)
+	}
+}
+
+func Test_linuxCPUSharesToWeight(t *testing.T) {
+	for _, c := range []struct {
+		shares uint64
+		weight int32
+	}{
+		{0, 1},
+		{2, 1},
+		{1024, 39},
+		{131073, 5000},
+		{262144, 10000},
+		{1 << 20, 10000},
+	} {
+		if w := linuxCPUSharesToWeight(c.shares); w != c.weight {
+			t.Fatalf("expected shares %d to be weight %d, got: %d", c.shares, c.weight, w)
+		}
+	}
+}
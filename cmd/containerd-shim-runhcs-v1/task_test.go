@@ -91,10 +91,30 @@ func (tst *testShimTask) DumpGuestStacks(ctx context.Context) string {
 	return ""
 }
 
+func (tst *testShimTask) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (tst *testShimTask) WaitForReadiness(ctx context.Context) error {
+	return nil
+}
+
 func (tst *testShimTask) Share(ctx context.Context, req *shimdiag.ShareRequest) error {
 	return errors.New("not implemented")
 }
 
+func (tst *testShimTask) Quiesce(ctx context.Context, req *shimdiag.QuiesceRequest) error {
+	return errors.New("not implemented")
+}
+
+func (tst *testShimTask) ResizeDisk(ctx context.Context, req *shimdiag.ResizeDiskRequest) error {
+	return errors.New("not implemented")
+}
+
+func (tst *testShimTask) NetworkInterfaces(ctx context.Context) (map[string]string, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (tst *testShimTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	if tst.isWCOW {
 		return getWCOWTestStats(), nil
@@ -102,6 +122,10 @@ func (tst *testShimTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	return getLCOWTestStats(), nil
 }
 
+func (tst *testShimTask) Update(ctx context.Context, resources interface{}) error {
+	return errdefs.ErrNotImplemented
+}
+
 func getWCOWTestStats() *stats.Statistics {
 	return &stats.Statistics{
 		Container: &stats.Statistics_Windows{
@@ -229,6 +229,18 @@ func (wpst *wcowPodSandboxTask) waitParentExit() {
 	if werr != nil {
 		log.G(ctx).WithError(werr).Error("parent wait failed")
 	}
+	if path, ok := wpst.host.CrashDump(); ok {
+		log.G(ctx).WithField("path", path).Error("parent crashed")
+		if err := wpst.events.publishEvent(
+			ctx,
+			options.TaskCrashDumpEventTopic,
+			&options.TaskCrashDump{
+				ContainerID: wpst.id,
+				Path:        path,
+			}); err != nil {
+			log.G(ctx).WithError(err).Error("failed to publish TaskCrashDumpEventTopic")
+		}
+	}
 	// The UVM came down. Force transition the init task (if it wasn't
 	// already) to unblock any waiters since the platform wont send any
 	// events for this fake process.
@@ -257,6 +269,16 @@ func (wpst *wcowPodSandboxTask) DumpGuestStacks(ctx context.Context) string {
 	return ""
 }
 
+func (wpst *wcowPodSandboxTask) CheckHealth(ctx context.Context) error {
+	// There is no container backing the sandbox task.
+	return nil
+}
+
+func (wpst *wcowPodSandboxTask) WaitForReadiness(ctx context.Context) error {
+	// There is no workload in the sandbox task.
+	return nil
+}
+
 func (wpst *wcowPodSandboxTask) Share(ctx context.Context, req *shimdiag.ShareRequest) error {
 	if wpst.host == nil {
 		return errTaskNotIsolated
@@ -264,6 +286,21 @@ func (wpst *wcowPodSandboxTask) Share(ctx context.Context, req *shimdiag.ShareRe
 	return wpst.host.Share(ctx, req.HostPath, req.UvmPath, req.ReadOnly)
 }
 
+func (wpst *wcowPodSandboxTask) Quiesce(ctx context.Context, req *shimdiag.QuiesceRequest) error {
+	return errors.Wrap(errdefs.ErrNotImplemented, "quiescing disks is only supported for LCOW")
+}
+
+func (wpst *wcowPodSandboxTask) ResizeDisk(ctx context.Context, req *shimdiag.ResizeDiskRequest) error {
+	if wpst.host == nil {
+		return errTaskNotIsolated
+	}
+	return wpst.host.ResizeSCSI(ctx, req.HostPath, req.SizeInBytes)
+}
+
+func (wpst *wcowPodSandboxTask) NetworkInterfaces(ctx context.Context) (map[string]string, error) {
+	return nil, errors.Wrap(errdefs.ErrNotImplemented, "guest network interface names are only supported for LCOW")
+}
+
 func (wpst *wcowPodSandboxTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	stats := &stats.Statistics{}
 	vmStats, err := wpst.host.Stats(ctx)
@@ -273,3 +310,10 @@ func (wpst *wcowPodSandboxTask) Stats(ctx context.Context) (*stats.Statistics, e
 	stats.VM = vmStats
 	return stats, nil
 }
+
+func (wpst *wcowPodSandboxTask) Update(ctx context.Context, resources interface{}) error {
+	if wpst.host == nil {
+		return errors.Wrap(errdefs.ErrNotImplemented, "only the resources of a hypervisor isolated pod can be updated")
+	}
+	return updateHostResources(ctx, wpst.host, resources, nil)
+}
@@ -13,6 +13,9 @@ type config struct {
 	TTRPCAddr      string `json:"ttrpc,omitempty"`
 	GRPCAddr       string `json:"grpc,omitempty"`
 	NodeNetSvcAddr string `json:"node_net_svc_addr,omitempty"`
+	// TTRPCSecurityDescriptor is the SDDL applied to the ttrpc named pipe.
+	// If omitted the default named pipe ACL is used.
+	TTRPCSecurityDescriptor string `json:"ttrpc_security_descriptor,omitempty"`
 	// Timeout in seconds to wait to connect to a NodeNetworkService.
 	// 0 represents no timeout and ncproxy will continuously try and connect in the
 	// background.
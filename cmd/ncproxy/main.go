@@ -35,6 +35,11 @@ var (
 )
 
 func main() {
+	// Redaction must be set up before any hook that writes entries out.
+	if err := log.SetupRedactionFromEnvironment(); err != nil {
+		logrus.Error(err)
+	}
+
 	// Provider ID: cf9f01fe-87b3-568d-ecef-9f54b7c5ff70
 	// Hook isn't closed explicitly, as it will exist until process exit.
 	if hook, err := etwlogrus.NewHook("Microsoft.Virtualization.NCProxy"); err == nil {
@@ -39,7 +39,7 @@ func (s *server) setup(ctx context.Context) (net.Listener, net.Listener, error)
 	ncproxygrpc.RegisterNetworkConfigProxyServer(s.grpc, &grpcService{})
 	ncproxyttrpc.RegisterNetworkConfigProxyService(s.ttrpc, &ttrpcService{})
 
-	ttrpcListener, err := winio.ListenPipe(s.conf.TTRPCAddr, nil)
+	ttrpcListener, err := winio.ListenPipe(s.conf.TTRPCAddr, &winio.PipeConfig{SecurityDescriptor: s.conf.TTRPCSecurityDescriptor})
 	if err != nil {
 		log.G(ctx).WithError(err).Errorf("failed to listen on %s", s.conf.TTRPCAddr)
 		return nil, nil, err
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Microsoft/go-winio"
 	"github.com/Microsoft/go-winio/pkg/etwlogrus"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/regstate"
 	"github.com/Microsoft/hcsshim/internal/runhcs"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -51,6 +52,11 @@ Where "<container-id>" is your name for the instance of the container that you a
 )
 
 func main() {
+	// Redaction must be set up before any hook that writes entries out.
+	if err := log.SetupRedactionFromEnvironment(); err != nil {
+		logrus.Error(err)
+	}
+
 	// Provider ID: 0b52781f-b24d-5685-ddf6-69830ed40ec3
 	// Hook isn't closed explicitly, as it will exist until process exit.
 	if hook, err := etwlogrus.NewHook("Microsoft.Virtualization.RunHCS"); err == nil {
@@ -116,7 +122,7 @@ func main() {
 		shimCommand,
 		startCommand,
 		stateCommand,
-		// updateCommand,
+		updateCommand,
 		vmshimCommand,
 	}
 	app.Before = func(context *cli.Context) error {
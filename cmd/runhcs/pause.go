@@ -2,6 +2,7 @@ package main
 
 import (
 	gcontext "context"
+	"fmt"
 
 	"github.com/Microsoft/hcsshim/internal/appargs"
 	"github.com/urfave/cli"
@@ -25,6 +26,13 @@ Use runhcs list to identify instances of containers and their current status.`,
 			return err
 		}
 		defer container.Close()
+		status, err := container.Status()
+		if err != nil {
+			return err
+		}
+		if status != containerRunning {
+			return fmt.Errorf("container is %s, not running", status)
+		}
 		if err := container.hc.Pause(gcontext.Background()); err != nil {
 			return err
 		}
@@ -51,6 +59,13 @@ Use runhcs list to identify instances of containers and their current status.`,
 			return err
 		}
 		defer container.Close()
+		status, err := container.Status()
+		if err != nil {
+			return err
+		}
+		if status != containerPaused {
+			return fmt.Errorf("container is %s, not paused", status)
+		}
 		if err := container.hc.Resume(gcontext.Background()); err != nil {
 			return err
 		}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	gcontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli"
+)
+
+const (
+	// maxWindowsCPUWeight is the largest CPU shares and CPU maximum of a
+	// Windows container, as HCS takes both out of 10000.
+	maxWindowsCPUWeight = 10000
+)
+
+var updateCommand = cli.Command{
+	Name:  "update",
+	Usage: "update container resource constraints",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container to be
+updated.`,
+	Description: `The update command updates the resource constraints of a running or paused
+container. The constraints are read as JSON from the file given with
+--resources, or from stdin if it is "-", and the flags override them. For a
+Linux container the JSON is an OCI LinuxResources object, and for a Windows
+container an OCI WindowsResources object.
+
+Only hypervisor isolated containers can be updated.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "resources, r",
+			Usage: `path to the JSON file with the resource constraints, or "-" to read them from stdin`,
+		},
+		cli.Uint64Flag{
+			Name:  "cpu-shares",
+			Usage: "relative CPU weight of the container, between 1 and 10000 for a Windows container",
+		},
+		cli.Int64Flag{
+			Name:  "cpu-quota",
+			Usage: "CFS quota of the container in microseconds per period (Linux only)",
+		},
+		cli.Uint64Flag{
+			Name:  "cpu-period",
+			Usage: "CFS period of the container in microseconds (Linux only)",
+		},
+		cli.StringFlag{
+			Name:  "cpuset-cpus",
+			Usage: "CPUs that the container runs on (Linux only)",
+		},
+		cli.Uint64Flag{
+			Name:  "cpu-count",
+			Usage: "number of CPUs available to the container (Windows only)",
+		},
+		cli.Uint64Flag{
+			Name:  "cpu-maximum",
+			Usage: "portion of the CPU cycles that the container can use, in units of 1/100th of a percent, between 1 and 10000 (Windows only)",
+		},
+		cli.Int64Flag{
+			Name:  "memory",
+			Usage: "memory limit of the container in bytes",
+		},
+		cli.Int64Flag{
+			Name:  "pids-limit",
+			Usage: "maximum number of processes in the container (Linux only)",
+		},
+	},
+	Before: appargs.Validate(argID),
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		c, err := getContainer(id, true)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		status, err := c.Status()
+		if err != nil {
+			return err
+		}
+		if status != containerRunning && status != containerPaused {
+			return fmt.Errorf("container is %s, not running or paused", status)
+		}
+		if !c.IsHost && c.HostID == "" {
+			return errors.New("update is only supported for hypervisor isolated containers")
+		}
+
+		var constraints guestrequest.LCOWContainerConstraints
+		if c.Spec.Linux != nil {
+			r, err := linuxResourcesFromCli(context)
+			if err != nil {
+				return err
+			}
+			constraints.Linux = *r
+		} else {
+			r, err := windowsResourcesFromCli(context)
+			if err != nil {
+				return err
+			}
+			constraints.Windows = *r
+		}
+		req := &hcsschema.ModifySettingRequest{
+			GuestRequest: guestrequest.GuestRequest{
+				ResourceType: guestrequest.ResourceTypeContainerConstraints,
+				RequestType:  requesttype.Update,
+				Settings:     constraints,
+			},
+		}
+		if err := c.hc.Modify(gcontext.Background(), req); err != nil {
+			return err
+		}
+
+		// Record the constraints in effect in the persisted spec.
+		if c.Spec.Linux != nil {
+			if c.Spec.Linux.Resources == nil {
+				c.Spec.Linux.Resources = &specs.LinuxResources{}
+			}
+			mergeLinuxResources(c.Spec.Linux.Resources, &constraints.Linux)
+		} else {
+			if c.Spec.Windows.Resources == nil {
+				c.Spec.Windows.Resources = &specs.WindowsResources{}
+			}
+			mergeWindowsResources(c.Spec.Windows.Resources, &constraints.Windows)
+		}
+		return stateKey.Set(c.ID, keyState, &c.persistedState)
+	},
+}
+
+// readResources reads the JSON resources of the --resources flag into `v`, if
+// it is set.
+func readResources(context *cli.Context, v interface{}) error {
+	path := context.String("resources")
+	if path == "" {
+		return nil
+	}
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode resources: %s", err)
+	}
+	return nil
+}
+
+func linuxResourcesFromCli(context *cli.Context) (*specs.LinuxResources, error) {
+	for _, f := range []string{"cpu-count", "cpu-maximum"} {
+		if context.IsSet(f) {
+			return nil, fmt.Errorf("--%s is not supported for Linux containers", f)
+		}
+	}
+	r := &specs.LinuxResources{}
+	if err := readResources(context, r); err != nil {
+		return nil, err
+	}
+	cpu := func() *specs.LinuxCPU {
+		if r.CPU == nil {
+			r.CPU = &specs.LinuxCPU{}
+		}
+		return r.CPU
+	}
+	if context.IsSet("cpu-shares") {
+		v := context.Uint64("cpu-shares")
+		cpu().Shares = &v
+	}
+	if context.IsSet("cpu-quota") {
+		v := context.Int64("cpu-quota")
+		cpu().Quota = &v
+	}
+	if context.IsSet("cpu-period") {
+		v := context.Uint64("cpu-period")
+		cpu().Period = &v
+	}
+	if context.IsSet("cpuset-cpus") {
+		cpu().Cpus = context.String("cpuset-cpus")
+	}
+	if context.IsSet("memory") {
+		v := context.Int64("memory")
+		if r.Memory == nil {
+			r.Memory = &specs.LinuxMemory{}
+		}
+		r.Memory.Limit = &v
+	}
+	if context.IsSet("pids-limit") {
+		r.Pids = &specs.LinuxPids{Limit: context.Int64("pids-limit")}
+	}
+	return r, nil
+}
+
+func windowsResourcesFromCli(context *cli.Context) (*specs.WindowsResources, error) {
+	for _, f := range []string{"cpu-quota", "cpu-period", "cpuset-cpus", "pids-limit"} {
+		if context.IsSet(f) {
+			return nil, fmt.Errorf("--%s is not supported for Windows containers", f)
+		}
+	}
+	r := &specs.WindowsResources{}
+	if err := readResources(context, r); err != nil {
+		return nil, err
+	}
+	cpu := func() *specs.WindowsCPUResources {
+		if r.CPU == nil {
+			r.CPU = &specs.WindowsCPUResources{}
+		}
+		return r.CPU
+	}
+	if context.IsSet("cpu-shares") {
+		v, err := windowsCPUWeightFromCli(context, "cpu-shares")
+		if err != nil {
+			return nil, err
+		}
+		cpu().Shares = &v
+	}
+	if context.IsSet("cpu-count") {
+		v := context.Uint64("cpu-count")
+		cpu().Count = &v
+	}
+	if context.IsSet("cpu-maximum") {
+		v, err := windowsCPUWeightFromCli(context, "cpu-maximum")
+		if err != nil {
+			return nil, err
+		}
+		cpu().Maximum = &v
+	}
+	if context.IsSet("memory") {
+		m := context.Int64("memory")
+		if m < 0 {
+			return nil, fmt.Errorf("--memory must not be negative for Windows containers, got %d", m)
+		}
+		v := uint64(m)
+		if r.Memory == nil {
+			r.Memory = &specs.WindowsMemoryResources{}
+		}
+		r.Memory.Limit = &v
+	}
+	if err := validateWindowsResources(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// windowsCPUWeightFromCli returns the value of the CPU weight flag `name`, or an
+// error if it is not between 1 and `maxWindowsCPUWeight`. It is checked before
+// it is narrowed to the `uint16` of the OCI spec so that a value that does not
+// fit is rejected rather than truncated.
+func windowsCPUWeightFromCli(context *cli.Context, name string) (uint16, error) {
+	v := context.Uint64(name)
+	if v < 1 || v > maxWindowsCPUWeight {
+		return 0, fmt.Errorf("--%s must be between 1 and %d, got %d", name, maxWindowsCPUWeight, v)
+	}
+	return uint16(v), nil
+}
+
+// validateWindowsResources checks that the CPU shares and CPU maximum of `r`,
+// which can also come from the --resources JSON, are between 1 and
+// `maxWindowsCPUWeight`.
+func validateWindowsResources(r *specs.WindowsResources) error {
+	if r.CPU == nil {
+		return nil
+	}
+	if r.CPU.Shares != nil && (*r.CPU.Shares < 1 || *r.CPU.Shares > maxWindowsCPUWeight) {
+		return fmt.Errorf("CPU shares must be between 1 and %d, got %d", maxWindowsCPUWeight, *r.CPU.Shares)
+	}
+	if r.CPU.Maximum != nil && (*r.CPU.Maximum < 1 || *r.CPU.Maximum > maxWindowsCPUWeight) {
+		return fmt.Errorf("CPU maximum must be between 1 and %d, got %d", maxWindowsCPUWeight, *r.CPU.Maximum)
+	}
+	return nil
+}
+
+// mergeLinuxResources sets the CPU, memory and pids constraints that are set
+// in `src` in `dst`.
+func mergeLinuxResources(dst, src *specs.LinuxResources) {
+	if src.CPU != nil {
+		if dst.CPU == nil {
+			dst.CPU = &specs.LinuxCPU{}
+		}
+		if src.CPU.Shares != nil {
+			dst.CPU.Shares = src.CPU.Shares
+		}
+		if src.CPU.Quota != nil {
+			dst.CPU.Quota = src.CPU.Quota
+		}
+		if src.CPU.Period != nil {
+			dst.CPU.Period = src.CPU.Period
+		}
+		if src.CPU.Cpus != "" {
+			dst.CPU.Cpus = src.CPU.Cpus
+		}
+	}
+	if src.Memory != nil {
+		if dst.Memory == nil {
+			dst.Memory = &specs.LinuxMemory{}
+		}
+		if src.Memory.Limit != nil {
+			dst.Memory.Limit = src.Memory.Limit
+		}
+	}
+	if src.Pids != nil {
+		dst.Pids = src.Pids
+	}
+}
+
+// mergeWindowsResources sets the CPU and memory constraints that are set in
+// `src` in `dst`.
+func mergeWindowsResources(dst, src *specs.WindowsResources) {
+	if src.CPU != nil {
+		if dst.CPU == nil {
+			dst.CPU = &specs.WindowsCPUResources{}
+		}
+		if src.CPU.Count != nil {
+			dst.CPU.Count = src.CPU.Count
+		}
+		if src.CPU.Shares != nil {
+			dst.CPU.Shares = src.CPU.Shares
+		}
+		if src.CPU.Maximum != nil {
+			dst.CPU.Maximum = src.CPU.Maximum
+		}
+	}
+	if src.Memory != nil {
+		if dst.Memory == nil {
+			dst.Memory = &specs.WindowsMemoryResources{}
+		}
+		if src.Memory.Limit != nil {
+			dst.Memory.Limit = src.Memory.Limit
+		}
+	}
+}
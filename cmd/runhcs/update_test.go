@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli"
+)
+
+// newUpdateContext returns the context of the update command run with the
+// flags `args`.
+func newUpdateContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet(updateCommand.Name, flag.ContinueOnError)
+	for _, f := range updateCommand.Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("failed to parse %v: %s", args, err)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+// writeResources writes the JSON resources `data` to a temporary file and
+// returns its path, which the caller removes.
+func writeResources(t *testing.T, data string) string {
+	f, err := ioutil.TempFile("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		os.Remove(f.Name())
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func Test_windowsResourcesFromCli(t *testing.T) {
+	r, err := windowsResourcesFromCli(newUpdateContext(t, "--cpu-shares", "10000", "--cpu-maximum", "1", "--cpu-count", "2", "--memory", "1024"))
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if *r.CPU.Shares != 10000 || *r.CPU.Maximum != 1 || *r.CPU.Count != 2 || *r.Memory.Limit != 1024 {
+		t.Fatalf("unexpected resources %+v, %+v", r.CPU, r.Memory)
+	}
+}
+
+func Test_windowsResourcesFromCli_OutOfRange(t *testing.T) {
+	for _, args := range [][]string{
+		{"--cpu-shares", "0"},
+		{"--cpu-shares", "10001"},
+		// 65537 is 1 once truncated to a uint16.
+		{"--cpu-shares", "65537"},
+		{"--cpu-maximum", "0"},
+		{"--cpu-maximum", "10001"},
+		{"--cpu-maximum", "65537"},
+		{"--memory", "-1"},
+		{"--cpu-quota", "1000"},
+	} {
+		if _, err := windowsResourcesFromCli(newUpdateContext(t, args...)); err == nil {
+			t.Fatalf("expected an error for %v", args)
+		}
+	}
+}
+
+func Test_windowsResourcesFromCli_ResourcesFile(t *testing.T) {
+	path := writeResources(t, `{"cpu":{"shares":500,"maximum":20000}}`)
+	defer os.Remove(path)
+	if _, err := windowsResourcesFromCli(newUpdateContext(t, "--resources", path)); err == nil {
+		t.Fatal("expected an error for a CPU maximum out of range in the resources file")
+	}
+
+	// The flags override the resources file.
+	r, err := windowsResourcesFromCli(newUpdateContext(t, "--resources", path, "--cpu-maximum", "5000"))
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if *r.CPU.Shares != 500 || *r.CPU.Maximum != 5000 {
+		t.Fatalf("unexpected CPU resources %+v", r.CPU)
+	}
+}
+
+func Test_linuxResourcesFromCli(t *testing.T) {
+	path := writeResources(t, `{"cpu":{"shares":512,"quota":1000},"pids":{"limit":10}}`)
+	defer os.Remove(path)
+	r, err := linuxResourcesFromCli(newUpdateContext(t, "--resources", path, "--cpu-quota", "2000", "--cpuset-cpus", "0-1", "--memory", "-1"))
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if *r.CPU.Shares != 512 || *r.CPU.Quota != 2000 || r.CPU.Cpus != "0-1" || *r.Memory.Limit != -1 || r.Pids.Limit != 10 {
+		t.Fatalf("unexpected resources %+v, %+v, %+v", r.CPU, r.Memory, r.Pids)
+	}
+
+	for _, args := range [][]string{
+		{"--cpu-count", "2"},
+		{"--cpu-maximum", "5000"},
+	} {
+		if _, err := linuxResourcesFromCli(newUpdateContext(t, args...)); err == nil {
+			t.Fatalf("expected an error for %v", args)
+		}
+	}
+}
+
+func Test_mergeLinuxResources(t *testing.T) {
+	shares, quota, newQuota, limit := uint64(512), int64(1000), int64(2000), int64(4096)
+	dst := &specs.LinuxResources{
+		CPU: &specs.LinuxCPU{Shares: &shares, Quota: &quota, Cpus: "0"},
+	}
+	mergeLinuxResources(dst, &specs.LinuxResources{
+		CPU:    &specs.LinuxCPU{Quota: &newQuota},
+		Memory: &specs.LinuxMemory{Limit: &limit},
+	})
+	expected := &specs.LinuxResources{
+		CPU:    &specs.LinuxCPU{Shares: &shares, Quota: &newQuota, Cpus: "0"},
+		Memory: &specs.LinuxMemory{Limit: &limit},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, dst)
+	}
+}
+
+func Test_mergeWindowsResources(t *testing.T) {
+	shares, maximum, newMaximum, count := uint16(500), uint16(1000), uint16(2000), uint64(2)
+	dst := &specs.WindowsResources{
+		CPU: &specs.WindowsCPUResources{Shares: &shares, Maximum: &maximum},
+	}
+	mergeWindowsResources(dst, &specs.WindowsResources{
+		CPU: &specs.WindowsCPUResources{Count: &count, Maximum: &newMaximum},
+	})
+	expected := &specs.WindowsResources{
+		CPU: &specs.WindowsCPUResources{Count: &count, Shares: &shares, Maximum: &newMaximum},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, dst)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+// timeoutExitCode is the exit code of `timeout` when the command timed out.
+const timeoutExitCode = 124
+
+var (
+	captureInterface string
+	captureDuration  time.Duration
+	captureCount     int
+	captureSnaplen   int
+	captureTcpdump   string
+)
+
+var captureCommand = cli.Command{
+	Name:  "capture",
+	Usage: "Captures the packets of an interface in a shim's hosting Linux utility VM",
+	ArgsUsage: `[flags] <shim name> <output file> [filter...]
+
+The capture is written in pcap format to the host file "<output file>". It runs
+tcpdump in the utility VM, which must be in the root file system or in an
+extension, and stops after --duration or --count packets, whichever is first.
+The optional filter is a tcpdump filter expression.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "interface,i",
+			Usage:       "the guest `interface` to capture, or \"any\"",
+			Value:       "eth0",
+			Destination: &captureInterface},
+		cli.DurationFlag{
+			Name:        "duration,d",
+			Usage:       "the maximum `duration` of the capture",
+			Value:       30 * time.Second,
+			Destination: &captureDuration},
+		cli.IntFlag{
+			Name:        "count,c",
+			Usage:       "the maximum `number` of packets to capture",
+			Value:       10000,
+			Destination: &captureCount},
+		cli.IntFlag{
+			Name:        "snaplen,s",
+			Usage:       "the number of `bytes` captured of each packet, or 0 for the tcpdump default",
+			Destination: &captureSnaplen},
+		cli.StringFlag{
+			Name:        "tcpdump",
+			Usage:       "the `path` of tcpdump in the utility VM",
+			Value:       "tcpdump",
+			Destination: &captureTcpdump},
+	},
+	Before: appargs.Validate(appargs.String, appargs.String, appargs.Rest(appargs.String)),
+	Action: func(clictx *cli.Context) error {
+		args := clictx.Args()
+		if captureDuration < time.Second || captureCount <= 0 {
+			return fmt.Errorf("the capture must be bounded by a duration of at least 1s and a positive packet count")
+		}
+		shim, err := getShim(args[0])
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		cmd := []string{
+			"timeout", "-s", "INT", strconv.Itoa(int(captureDuration / time.Second)),
+			captureTcpdump, "-i", captureInterface, "-U", "-w", "-", "-c", strconv.Itoa(captureCount),
+		}
+		if captureSnaplen != 0 {
+			cmd = append(cmd, "-s", strconv.Itoa(captureSnaplen))
+		}
+		cmd = append(cmd, args[2:]...)
+
+		stdout, err := makePipe(f, false)
+		if err != nil {
+			return err
+		}
+		stderr, err := makePipe(os.Stderr, false)
+		if err != nil {
+			return err
+		}
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, os.Interrupt)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-ch
+			cancel()
+		}()
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagExecInHost(ctx, &shimdiag.ExecProcessRequest{
+			Args:   cmd,
+			Stdout: stdout,
+			Stderr: stderr,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.ExitCode != 0 && resp.ExitCode != timeoutExitCode {
+			return fmt.Errorf("capture failed with exit code %d", resp.ExitCode)
+		}
+		fmt.Printf("Captured to %s\n", args[1])
+		return nil
+	},
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/Microsoft/go-winio"
@@ -31,7 +32,12 @@ func (r rawConReader) Read(b []byte) (int, error) {
 	return n, err
 }
 
-var execTty bool
+var (
+	execTty     bool
+	execUser    string
+	execEnvFile string
+)
+
 var execCommand = cli.Command{
 	Name:      "exec",
 	Usage:     "Executes a command in a shim's hosting utility VM",
@@ -41,6 +47,14 @@ var execCommand = cli.Command{
 			Name:        "tty,t",
 			Usage:       "run with a terminal",
 			Destination: &execTty},
+		cli.StringFlag{
+			Name:        "user,u",
+			Usage:       "run as `user[:group]` in Linux UVMs, or as the user in Windows UVMs",
+			Destination: &execUser},
+		cli.StringFlag{
+			Name:        "env-file",
+			Usage:       "add the KEY=VALUE variables in the host `file` to the environment",
+			Destination: &execEnvFile},
 	},
 	SkipArgReorder: true,
 	Before:         appargs.Validate(appargs.String, appargs.String, appargs.Rest(appargs.String)),
@@ -51,6 +65,16 @@ var execCommand = cli.Command{
 			return err
 		}
 
+		envFile := execEnvFile
+		if envFile != "" {
+			// The file is read by the shim, which has a different working
+			// directory.
+			envFile, err = filepath.Abs(envFile)
+			if err != nil {
+				return err
+			}
+		}
+
 		var osStdin io.Reader = os.Stdin
 		if execTty {
 			// Enable raw mode on the client's console.
@@ -98,6 +122,8 @@ var execCommand = cli.Command{
 			Stdout:   stdout,
 			Stderr:   stderr,
 			Terminal: execTty,
+			User:     execUser,
+			EnvFile:  envFile,
 		})
 		if err != nil {
 			return err
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var interfacesCommand = cli.Command{
+	Name:      "interfaces",
+	Usage:     "Lists the guest network interface of each endpoint in a shim's hosting utility VM",
+	ArgsUsage: "<shim name>",
+	Before:    appargs.Validate(appargs.String),
+	Action: func(c *cli.Context) error {
+		shim, err := getShim(c.Args()[0])
+		if err != nil {
+			return err
+		}
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagNetworkInterfaces(context.Background(), &shimdiag.NetworkInterfacesRequest{})
+		if err != nil {
+			return err
+		}
+
+		endpoints := make([]string, 0, len(resp.InterfaceNames))
+		for id := range resp.InterfaceNames {
+			endpoints = append(endpoints, id)
+		}
+		sort.Strings(endpoints)
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+		fmt.Fprintln(w, "Endpoint \t Interface")
+		for _, id := range endpoints {
+			fmt.Fprintf(w, "%s \t %s\n", id, resp.InterfaceNames[id])
+		}
+		w.Flush()
+		return nil
+	},
+}
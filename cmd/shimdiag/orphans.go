@@ -0,0 +1,49 @@
+package main
+
+import (
+	gcontext "context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/ownership"
+	"github.com/urfave/cli"
+)
+
+var orphansCommand = cli.Command{
+	Name:  "orphans",
+	Usage: "Lists the compute systems and HNS endpoints whose creating shim is gone",
+	Description: `Compute systems created by a shim are labeled with the process ID of the
+shim, and the HNS endpoints that it adds to them are recorded with it. A
+compute system or endpoint whose shim has exited, such as after a crash, is an
+orphan. With --clean the orphans are cleaned up: the compute systems are
+terminated, containers first, and then the endpoints are deleted.`,
+	ArgsUsage: "[flags]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "clean",
+			Usage: "Terminates the orphaned compute systems and deletes the orphaned endpoints",
+		},
+	},
+	Before: appargs.Validate(),
+	Action: func(ctx *cli.Context) error {
+		orphans, err := ownership.FindOrphans(gcontext.Background())
+		if err != nil {
+			return err
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintln(w, "Id\tType\tOwner\tPid")
+		for _, o := range orphans {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", o.ID, o.SystemType, o.Owner, o.PID)
+		}
+		w.Flush()
+
+		if ctx.Bool("clean") {
+			return ownership.Cleanup(gcontext.Background(), orphans)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var quiesceCommand = cli.Command{
+	Name:      "quiesce",
+	Usage:     "Freeze or thaw the file systems of disks in a shim's hosting utility VM",
+	ArgsUsage: "[flags] <shim name> [host_path...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "thaw",
+			Usage: "Thaw the disks frozen by an earlier quiesce",
+		},
+		cli.UintFlag{
+			Name:  "timeout",
+			Usage: "Seconds after which the guest thaws the disks by itself, or 0 for the guest default",
+		},
+	},
+	Before: appargs.Validate(appargs.String, appargs.Rest(appargs.String)),
+	Action: func(c *cli.Context) error {
+		args := c.Args()
+		var (
+			thaw      = c.Bool("thaw")
+			shimName  = args[0]
+			hostPaths = []string(args[1:])
+		)
+		if !thaw && len(hostPaths) == 0 {
+			return fmt.Errorf("no host paths of disks to quiesce")
+		}
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		req := &shimdiag.QuiesceRequest{
+			HostPaths:        hostPaths,
+			TimeoutInSeconds: uint32(c.Uint("timeout")),
+			Thaw:             thaw,
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		if _, err := svc.DiagQuiesce(context.Background(), req); err != nil {
+			if thaw {
+				return fmt.Errorf("failed to thaw disks in %s: %s", shimName, err)
+			}
+			return fmt.Errorf("failed to quiesce %s in %s: %s", strings.Join(hostPaths, ", "), shimName, err)
+		}
+
+		if thaw {
+			fmt.Printf("Thawed disks in %s\n", shimName)
+		} else {
+			fmt.Printf("Quiesced %s in %s\n", strings.Join(hostPaths, ", "), shimName)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var resizeCommand = cli.Command{
+	Name:      "resize",
+	Usage:     "Grow a disk, such as a container scratch, in a shim's hosting utility VM",
+	ArgsUsage: "<shim name> <host_path> <size_in_gb>",
+	Before:    appargs.Validate(appargs.String, appargs.String, appargs.String),
+	Action: func(c *cli.Context) error {
+		args := c.Args()
+		var (
+			shimName = args[0]
+			hostPath = args[1]
+		)
+		sizeInGB, err := strconv.ParseUint(args[2], 10, 32)
+		if err != nil || sizeInGB == 0 {
+			return fmt.Errorf("invalid disk size %q", args[2])
+		}
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		req := &shimdiag.ResizeDiskRequest{
+			HostPath:    hostPath,
+			SizeInBytes: sizeInGB * 1024 * 1024 * 1024,
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		if _, err := svc.DiagResizeDisk(context.Background(), req); err != nil {
+			return fmt.Errorf("failed to resize %s in %s: %s", hostPath, shimName, err)
+		}
+
+		fmt.Printf("Resized %s in %s to %dGB\n", hostPath, shimName, sizeInGB)
+		return nil
+	},
+}
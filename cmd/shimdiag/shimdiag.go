@@ -29,6 +29,11 @@ func main() {
 		execCommand,
 		stacksCommand,
 		shareCommand,
+		quiesceCommand,
+		resizeCommand,
+		interfacesCommand,
+		captureCommand,
+		orphansCommand,
 	}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
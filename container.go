@@ -53,6 +53,26 @@ const (
 // Supported resource types are Network and Request Types are Add/Remove
 type ResourceModificationRequestResponse = schema1.ResourceModificationRequestResponse
 
+// Notification is a notification received for a container, see
+// SubscribeContainer.
+type Notification = hcs.Notification
+
+// NotificationType is the type of a Notification.
+type NotificationType = hcs.NotificationType
+
+// NotificationType const
+const (
+	NotificationSystemExited          = hcs.NotificationSystemExited
+	NotificationGuestConnectionClosed = hcs.NotificationGuestConnectionClosed
+	NotificationResourceModified      = hcs.NotificationResourceModified
+	NotificationCrashInitiated        = hcs.NotificationCrashInitiated
+	NotificationServiceDisconnect     = hcs.NotificationServiceDisconnect
+)
+
+// NotificationSubscription delivers the notifications of a container on its
+// Events channel until it is closed.
+type NotificationSubscription = hcs.Subscription
+
 type container struct {
 	system   *hcs.System
 	waitOnce sync.Once
@@ -70,13 +90,20 @@ func init() {
 }
 
 // CreateContainer creates a new container with the given configuration but does not start it.
+//
+// Deprecated: Use CreateContainerContext.
 func CreateContainer(id string, c *ContainerConfig) (Container, error) {
+	return CreateContainerContext(context.Background(), id, c)
+}
+
+// CreateContainerContext creates a new container with the given configuration but does not start it.
+func CreateContainerContext(ctx context.Context, id string, c *ContainerConfig) (Container, error) {
 	fullConfig, err := mergemaps.MergeJSON(c, createContainerAdditionalJSON)
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge additional JSON '%s': %s", createContainerAdditionalJSON, err)
 	}
 
-	system, err := hcs.CreateComputeSystem(context.Background(), id, fullConfig)
+	system, err := hcs.CreateComputeSystem(ctx, id, fullConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -84,8 +111,23 @@ func CreateContainer(id string, c *ContainerConfig) (Container, error) {
 }
 
 // OpenContainer opens an existing container by ID.
+//
+// Deprecated: Use OpenContainerContext.
 func OpenContainer(id string) (Container, error) {
-	system, err := hcs.OpenComputeSystem(context.Background(), id)
+	return OpenContainerContext(context.Background(), id)
+}
+
+// OpenContainerContext opens an existing container by ID.
+func OpenContainerContext(ctx context.Context, id string) (Container, error) {
+	c, err := openContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func openContainer(ctx context.Context, id string) (*container, error) {
+	system, err := hcs.OpenComputeSystem(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +135,40 @@ func OpenContainer(id string) (Container, error) {
 }
 
 // GetContainers gets a list of the containers on the system that match the query
+//
+// Deprecated: Use GetContainersContext.
 func GetContainers(q ComputeSystemQuery) ([]ContainerProperties, error) {
-	return hcs.GetComputeSystems(context.Background(), q)
+	return GetContainersContext(context.Background(), q)
+}
+
+// GetContainersContext gets a list of the containers on the system that match the query
+func GetContainersContext(ctx context.Context, q ComputeSystemQuery) ([]ContainerProperties, error) {
+	return hcs.GetComputeSystems(ctx, q)
+}
+
+// ListContainers returns the containers on the node owned by one of `owners`,
+// with their state and runtime properties. If no owners are given the
+// containers of every owner are returned.
+func ListContainers(ctx context.Context, owners ...string) ([]ContainerProperties, error) {
+	return hcs.ListComputeSystems(ctx, hcs.SystemTypeContainer, owners...)
+}
+
+// ListUtilityVMs returns the utility VMs on the node owned by one of `owners`,
+// with their state and runtime properties. If no owners are given the utility
+// VMs of every owner are returned.
+func ListUtilityVMs(ctx context.Context, owners ...string) ([]ContainerProperties, error) {
+	return hcs.ListComputeSystems(ctx, hcs.SystemTypeVirtualMachine, owners...)
+}
+
+// SubscribeContainer returns a subscription to the exit, guest connection and
+// modify notifications of `c`, which must have been returned by
+// CreateContainerContext or OpenContainerContext.
+func SubscribeContainer(c Container) (*NotificationSubscription, error) {
+	cont, ok := c.(*container)
+	if !ok {
+		return nil, fmt.Errorf("unsupported container type %T", c)
+	}
+	return cont.system.Subscribe(), nil
 }
 
 // Start synchronously starts the container.
@@ -163,18 +163,21 @@ func Convert(r io.Reader, w io.ReadWriteSeeker, options ...Option) error {
 		return err
 	}
 	if p.appendVhdFooter {
-		size, err := w.Seek(0, io.SeekEnd)
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.BigEndian, makeFixedVHDFooter(size))
-		if err != nil {
-			return err
-		}
+		return ConvertToVhd(w)
 	}
 	return nil
 }
 
+// ConvertToVhd makes the file system image `w` a fixed VHD by appending a VHD
+// footer to it.
+func ConvertToVhd(w io.WriteSeeker) error {
+	size, err := w.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, makeFixedVHDFooter(size))
+}
+
 // ReadExt4SuperBlock reads and returns ext4 super block from VHD
 //
 // The layout on disk is as follows:
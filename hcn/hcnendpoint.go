@@ -1,11 +1,13 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/interop"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -62,7 +64,15 @@ type PolicyEndpointRequest struct {
 	Policies []EndpointPolicy `json:",omitempty"`
 }
 
-func getEndpoint(endpointGuid guid.GUID, query string) (*HostComputeEndpoint, error) {
+func getEndpoint(endpointGuid guid.GUID, query string) (endpoint *HostComputeEndpoint, err error) {
+	err = retry.Do(context.Background(), "hcn::getEndpoint", func() (err error) {
+		endpoint, err = queryEndpoint(endpointGuid, query)
+		return err
+	})
+	return endpoint, err
+}
+
+func queryEndpoint(endpointGuid guid.GUID, query string) (*HostComputeEndpoint, error) {
 	// Open endpoint.
 	var (
 		endpointHandle   hcnEndpoint
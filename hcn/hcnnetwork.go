@@ -1,11 +1,13 @@
 package hcn
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/interop"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -110,7 +112,15 @@ type PolicyNetworkRequest struct {
 	Policies []NetworkPolicy `json:",omitempty"`
 }
 
-func getNetwork(networkGuid guid.GUID, query string) (*HostComputeNetwork, error) {
+func getNetwork(networkGuid guid.GUID, query string) (network *HostComputeNetwork, err error) {
+	err = retry.Do(context.Background(), "hcn::getNetwork", func() (err error) {
+		network, err = queryNetwork(networkGuid, query)
+		return err
+	})
+	return network, err
+}
+
+func queryNetwork(networkGuid guid.GUID, query string) (*HostComputeNetwork, error) {
 	// Open network.
 	var (
 		networkHandle    hcnNetwork
@@ -1,6 +1,8 @@
 package hcsshim
 
 import (
+	"context"
+
 	"github.com/Microsoft/hcsshim/internal/hns"
 )
 
@@ -38,7 +40,14 @@ func HNSListEndpointRequest() ([]HNSEndpoint, error) {
 }
 
 // HotAttachEndpoint makes a HCS Call to attach the endpoint to the container
+//
+// Deprecated: Use HotAttachEndpointContext.
 func HotAttachEndpoint(containerID string, endpointID string) error {
+	return HotAttachEndpointContext(context.Background(), containerID, endpointID)
+}
+
+// HotAttachEndpointContext makes a HCS Call to attach the endpoint to the container
+func HotAttachEndpointContext(ctx context.Context, containerID string, endpointID string) error {
 	endpoint, err := GetHNSEndpointByID(endpointID)
 	if err != nil {
 		return err
@@ -47,11 +56,18 @@ func HotAttachEndpoint(containerID string, endpointID string) error {
 	if isAttached {
 		return err
 	}
-	return modifyNetworkEndpoint(containerID, endpointID, Add)
+	return modifyNetworkEndpoint(ctx, containerID, endpointID, Add)
 }
 
 // HotDetachEndpoint makes a HCS Call to detach the endpoint from the container
+//
+// Deprecated: Use HotDetachEndpointContext.
 func HotDetachEndpoint(containerID string, endpointID string) error {
+	return HotDetachEndpointContext(context.Background(), containerID, endpointID)
+}
+
+// HotDetachEndpointContext makes a HCS Call to detach the endpoint from the container
+func HotDetachEndpointContext(ctx context.Context, containerID string, endpointID string) error {
 	endpoint, err := GetHNSEndpointByID(endpointID)
 	if err != nil {
 		return err
@@ -60,12 +76,12 @@ func HotDetachEndpoint(containerID string, endpointID string) error {
 	if !isAttached {
 		return err
 	}
-	return modifyNetworkEndpoint(containerID, endpointID, Remove)
+	return modifyNetworkEndpoint(ctx, containerID, endpointID, Remove)
 }
 
 // ModifyContainer corresponding to the container id, by sending a request
-func modifyContainer(id string, request *ResourceModificationRequestResponse) error {
-	container, err := OpenContainer(id)
+func modifyContainer(ctx context.Context, id string, request *ResourceModificationRequestResponse) error {
+	container, err := openContainer(ctx, id)
 	if err != nil {
 		if IsNotExist(err) {
 			return ErrComputeSystemDoesNotExist
@@ -73,7 +89,7 @@ func modifyContainer(id string, request *ResourceModificationRequestResponse) er
 		return getInnerError(err)
 	}
 	defer container.Close()
-	err = container.Modify(request)
+	err = convertSystemError(container.system.Modify(ctx, request), container)
 	if err != nil {
 		if IsNotSupported(err) {
 			return ErrPlatformNotSupported
@@ -84,13 +100,13 @@ func modifyContainer(id string, request *ResourceModificationRequestResponse) er
 	return nil
 }
 
-func modifyNetworkEndpoint(containerID string, endpointID string, request RequestType) error {
+func modifyNetworkEndpoint(ctx context.Context, containerID string, endpointID string, request RequestType) error {
 	requestMessage := &ResourceModificationRequestResponse{
 		Resource: Network,
 		Request:  request,
 		Data:     endpointID,
 	}
-	err := modifyContainer(containerID, requestMessage)
+	err := modifyContainer(ctx, containerID, requestMessage)
 
 	if err != nil {
 		return err
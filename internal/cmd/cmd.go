@@ -27,6 +27,10 @@ type Cmd struct {
 	// The OCI spec for the process.
 	Spec *specs.Process
 
+	// Seccomp is the seccomp profile of the process, overriding that of its
+	// container. Only used for LCOW.
+	Seccomp *specs.LinuxSeccomp
+
 	// Standard IO streams to relay to/from the process.
 	Stdin  io.Reader
 	Stdout io.Writer
@@ -82,7 +86,8 @@ func (err *ExitError) Error() string {
 // Additional fields to hcsschema.ProcessParameters used by LCOW
 type lcowProcessParameters struct {
 	hcsschema.ProcessParameters
-	OCIProcess *specs.Process `json:"OciProcess,omitempty"`
+	OCIProcess *specs.Process      `json:"OciProcess,omitempty"`
+	OCISeccomp *specs.LinuxSeccomp `json:"OciSeccomp,omitempty"`
 }
 
 // escapeArgs makes a Windows-style escaped command line from a set of arguments
@@ -185,6 +190,7 @@ func (c *Cmd) Start() error {
 				CreateStdErrPipe: c.Stderr != nil,
 			},
 			OCIProcess: c.Spec,
+			OCISeccomp: c.Seccomp,
 		}
 		x = lpp
 	}
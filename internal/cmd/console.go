@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/sirupsen/logrus"
+)
+
+// consoleResizeRetries is the number of times a failed resize is retried
+// before waiting for the next resize.
+const consoleResizeRetries = 3
+
+// consoleResizeRetryDelay is the delay before a failed resize is retried.
+const consoleResizeRetryDelay = 100 * time.Millisecond
+
+// ConsoleResizer applies the console size of a process with a terminal, which
+// is either a pseudo console for WCOW or a PTY for LCOW.
+//
+// Resizes requested before the process is started are kept so that the
+// process is created with the latest size. Resizes requested while the
+// process runs are coalesced: only the latest size is sent to the process,
+// so that a burst of resizes (such as when a window is dragged) does not
+// queue a request per event.
+type ConsoleResizer struct {
+	mu            sync.Mutex
+	width, height uint16
+	hasSize       bool
+	// applied is true if the current size has been applied to the process.
+	applied bool
+
+	wake     chan struct{}
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewConsoleResizer returns a ConsoleResizer without a size.
+func NewConsoleResizer() *ConsoleResizer {
+	return &ConsoleResizer{
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+}
+
+// Resize sets the console size to `width` by `height` and, if the process has
+// been started, signals that it must be applied.
+func (r *ConsoleResizer) Resize(width, height uint16) {
+	r.mu.Lock()
+	r.width, r.height = width, height
+	r.hasSize = true
+	r.applied = false
+	r.mu.Unlock()
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Size returns the latest console size, if any. If the process is created
+// with the size, `Created` must be called before `Start`.
+func (r *ConsoleResizer) Size() (width, height uint16, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.width, r.height, r.hasSize
+}
+
+// Created records that the process was created with the size returned by
+// `Size`, so that it is not applied again unless it changes.
+func (r *ConsoleResizer) Created(width, height uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hasSize && r.width == width && r.height == height {
+		r.applied = true
+	}
+}
+
+// Start applies the console size to `p` until `Stop` is called.
+func (r *ConsoleResizer) Start(ctx context.Context, p cow.Process, l *logrus.Entry) {
+	if l == nil {
+		l = log.G(ctx)
+	}
+	go func() {
+		for {
+			r.apply(ctx, p, l)
+			select {
+			case <-r.stop:
+				return
+			case <-r.wake:
+			}
+		}
+	}()
+}
+
+// apply applies the latest size to `p` if it has not been applied yet.
+func (r *ConsoleResizer) apply(ctx context.Context, p cow.Process, l *logrus.Entry) {
+	failures := 0
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		r.mu.Lock()
+		if !r.hasSize || r.applied {
+			r.mu.Unlock()
+			return
+		}
+		width, height := r.width, r.height
+		r.mu.Unlock()
+
+		err := p.ResizeConsole(ctx, width, height)
+		if err == nil {
+			r.mu.Lock()
+			// A newer size set during the resize is applied on the next
+			// iteration.
+			if r.width == width && r.height == height {
+				r.applied = true
+			}
+			r.mu.Unlock()
+			continue
+		}
+		l.WithError(err).WithFields(logrus.Fields{
+			"width":  width,
+			"height": height,
+		}).Warning("failed to resize console")
+		failures++
+		if failures > consoleResizeRetries {
+			return
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(consoleResizeRetryDelay):
+		}
+	}
+}
+
+// Stop stops applying resizes. It is safe to call multiple times.
+func (r *ConsoleResizer) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
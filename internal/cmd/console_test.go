@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/cow"
+)
+
+type consoleSize struct {
+	width, height uint16
+}
+
+// resizeProcess is a process that reports its console resizes on `calls` and
+// waits on `release`, if set, before each resize returns. The first `failures`
+// resizes fail.
+type resizeProcess struct {
+	cow.Process
+	calls    chan consoleSize
+	release  chan struct{}
+	failures int
+}
+
+func (p *resizeProcess) ResizeConsole(ctx context.Context, width, height uint16) error {
+	p.calls <- consoleSize{width, height}
+	if p.release != nil {
+		<-p.release
+	}
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("resize failed")
+	}
+	return nil
+}
+
+func newResizeProcess() *resizeProcess {
+	return &resizeProcess{calls: make(chan consoleSize, 16)}
+}
+
+func expectResize(t *testing.T, p *resizeProcess, expected consoleSize) {
+	select {
+	case size := <-p.calls:
+		if size != expected {
+			t.Fatalf("expected resize to %+v, got %+v", expected, size)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for resize to %+v", expected)
+	}
+}
+
+func expectNoResize(t *testing.T, p *resizeProcess) {
+	select {
+	case size := <-p.calls:
+		t.Fatalf("expected no resize, got %+v", size)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func Test_ConsoleResizer_Created_NotReapplied(t *testing.T) {
+	r := NewConsoleResizer()
+	defer r.Stop()
+	r.Resize(80, 24)
+	width, height, ok := r.Size()
+	if !ok || width != 80 || height != 24 {
+		t.Fatalf("expected size 80x24, got %dx%d (%v)", width, height, ok)
+	}
+	r.Created(width, height)
+
+	p := newResizeProcess()
+	r.Start(context.Background(), p, nil)
+	expectNoResize(t, p)
+
+	r.Resize(100, 30)
+	expectResize(t, p, consoleSize{100, 30})
+}
+
+func Test_ConsoleResizer_BeforeStart_AppliesLatest(t *testing.T) {
+	r := NewConsoleResizer()
+	defer r.Stop()
+	r.Resize(80, 24)
+	r.Resize(120, 40)
+
+	p := newResizeProcess()
+	r.Start(context.Background(), p, nil)
+	expectResize(t, p, consoleSize{120, 40})
+	expectNoResize(t, p)
+}
+
+func Test_ConsoleResizer_Coalesces(t *testing.T) {
+	r := NewConsoleResizer()
+	defer r.Stop()
+	r.Resize(80, 24)
+
+	p := newResizeProcess()
+	p.release = make(chan struct{})
+	r.Start(context.Background(), p, nil)
+	expectResize(t, p, consoleSize{80, 24})
+
+	// Resizes while the first is in flight only apply the latest size.
+	r.Resize(90, 25)
+	r.Resize(100, 26)
+	r.Resize(110, 27)
+	p.release <- struct{}{}
+	expectResize(t, p, consoleSize{110, 27})
+	p.release <- struct{}{}
+	expectNoResize(t, p)
+}
+
+func Test_ConsoleResizer_RetriesFailure(t *testing.T) {
+	r := NewConsoleResizer()
+	defer r.Stop()
+	r.Resize(80, 24)
+
+	p := newResizeProcess()
+	p.failures = consoleResizeRetries
+	r.Start(context.Background(), p, nil)
+	for i := 0; i <= consoleResizeRetries; i++ {
+		expectResize(t, p, consoleSize{80, 24})
+	}
+	expectNoResize(t, p)
+}
+
+func Test_ConsoleResizer_Stop(t *testing.T) {
+	r := NewConsoleResizer()
+	p := newResizeProcess()
+	r.Start(context.Background(), p, nil)
+	r.Stop()
+	r.Stop()
+
+	r.Resize(80, 24)
+	expectNoResize(t, p)
+}
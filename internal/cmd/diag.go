@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
@@ -13,10 +17,23 @@ import (
 )
 
 // ExecInUvm is a helper function used to execute commands specified in `req` inside the given UVM.
+//
+// The command runs as `req.User` if set, which for Linux UVMs is in the form
+// `user[:group]` with each a name or an ID, and as root or SYSTEM otherwise.
+// The variables in the host file `req.EnvFile`, if set, are added to the
+// command's environment.
 func ExecInUvm(ctx context.Context, vm *uvm.UtilityVM, req *shimdiag.ExecProcessRequest) (int, error) {
 	if len(req.Args) == 0 {
 		return 0, errors.New("missing command")
 	}
+	var env []string
+	if req.EnvFile != "" {
+		var err error
+		env, err = readEnvFile(req.EnvFile)
+		if err != nil {
+			return 0, err
+		}
+	}
 	np, err := NewNpipeIO(ctx, req.Stdin, req.Stdout, req.Stderr, req.Terminal)
 	if err != nil {
 		return 0, err
@@ -26,9 +43,12 @@ func ExecInUvm(ctx context.Context, vm *uvm.UtilityVM, req *shimdiag.ExecProcess
 	if req.Workdir != "" {
 		cmd.Spec.Cwd = req.Workdir
 	}
-	if vm.OS() == "windows" {
+	if req.User != "" {
+		cmd.Spec.User.Username = req.User
+	} else if vm.OS() == "windows" {
 		cmd.Spec.User.Username = `NT AUTHORITY\SYSTEM`
 	}
+	cmd.Spec.Env = mergeEnv(cmd.Spec.Env, env)
 	cmd.Spec.Terminal = req.Terminal
 	cmd.Stdin = np.Stdin()
 	cmd.Stdout = np.Stdout()
@@ -38,6 +58,50 @@ func ExecInUvm(ctx context.Context, vm *uvm.UtilityVM, req *shimdiag.ExecProcess
 	return cmd.ExitState.ExitCode(), err
 }
 
+// readEnvFile returns the variables in the environment file at `path`, which
+// has a `KEY=VALUE` variable per line. Empty lines and lines starting with `#`
+// are ignored.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errorspkg.Wrap(err, "failed to open environment file")
+	}
+	defer f.Close()
+	var env []string
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Index(line, "=") <= 0 {
+			return nil, fmt.Errorf("environment file %s: line %d is not in the form KEY=VALUE", path, n)
+		}
+		env = append(env, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, errorspkg.Wrap(err, "failed to read environment file")
+	}
+	return env, nil
+}
+
+// mergeEnv returns `base` with the variables in `env` added, replacing those
+// of the same name.
+func mergeEnv(base, env []string) []string {
+	merged := make([]string, 0, len(base)+len(env))
+	index := make(map[string]int)
+	for _, v := range append(append([]string{}, base...), env...) {
+		k := strings.SplitN(v, "=", 2)[0]
+		if i, ok := index[k]; ok {
+			merged[i] = v
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, v)
+	}
+	return merged
+}
+
 // ExecInShimHost is a helper function used to execute commands specified in `req` in the shim's
 // hosting system.
 func ExecInShimHost(ctx context.Context, req *shimdiag.ExecProcessRequest) (int, error) {
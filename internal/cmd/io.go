@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/url"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -40,9 +41,40 @@ type UpstreamIO interface {
 	Terminal() bool
 }
 
-// NewUpstreamIO returns an UpstreamIO instance. Currently we only support named pipes and binary
-// logging driver for container IO. When using binary logger `stdout` and `stderr` are assumed to be
-// the same and the value of `stderr` is completely ignored.
+// UpstreamIOBackend creates the UpstreamIO of the container or process `id`
+// for stdio URIs with the scheme that it is registered for. Any of `stdin`,
+// `stdout` and `stderr` may be `""` if the stream is not used.
+type UpstreamIOBackend func(ctx context.Context, id, stdin, stdout, stderr string, terminal bool) (UpstreamIO, error)
+
+var (
+	upstreamIOBackendsMu sync.Mutex
+	upstreamIOBackends   = map[string]UpstreamIOBackend{
+		"binary": func(ctx context.Context, id, stdin, stdout, stderr string, terminal bool) (UpstreamIO, error) {
+			u, err := url.Parse(stdout)
+			if err != nil {
+				return nil, err
+			}
+			return NewBinaryIO(ctx, id, u)
+		},
+		"npipe": NewDialIO,
+		"tcp":   NewDialIO,
+	}
+)
+
+// RegisterUpstreamIOBackend registers `backend` to create the UpstreamIO of
+// stdio URIs with the scheme `scheme`, replacing any backend registered for
+// it.
+func RegisterUpstreamIOBackend(scheme string, backend UpstreamIOBackend) {
+	upstreamIOBackendsMu.Lock()
+	defer upstreamIOBackendsMu.Unlock()
+	upstreamIOBackends[scheme] = backend
+}
+
+// NewUpstreamIO returns an UpstreamIO instance. A `stdout` that is not a URI is
+// the path of a named pipe, as are `stdin` and `stderr`. Otherwise the backend
+// registered for the scheme of `stdout` creates the IO, such as the binary
+// logging driver for "binary", in which case `stdout` and `stderr` are assumed
+// to be the same and the value of `stderr` is completely ignored.
 func NewUpstreamIO(ctx context.Context, id string, stdout string, stderr string, stdin string, terminal bool) (UpstreamIO, error) {
 	u, err := url.Parse(stdout)
 
@@ -51,10 +83,11 @@ func NewUpstreamIO(ctx context.Context, id string, stdout string, stderr string,
 		return NewNpipeIO(ctx, stdin, stdout, stderr, terminal)
 	}
 
-	// Create IO for binary logging driver.
-	if u.Scheme != "binary" {
-		return nil, errors.Errorf("scheme must be 'binary', got: '%s'", u.Scheme)
+	upstreamIOBackendsMu.Lock()
+	backend, ok := upstreamIOBackends[u.Scheme]
+	upstreamIOBackendsMu.Unlock()
+	if !ok {
+		return nil, errors.Errorf("unsupported stdio scheme: '%s'", u.Scheme)
 	}
-
-	return NewBinaryIO(ctx, id, u)
+	return backend(ctx, id, stdin, stdout, stderr, terminal)
 }
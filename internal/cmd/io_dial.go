@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+
+	winio "github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// NewDialIO creates upstream io connected to each of the stdio URIs that are
+// set, so that log collectors and attach tools can consume the streams
+// directly. A URI is a "tcp://<host>:<port>" loopback address, a
+// "npipe://<server>/pipe/<name>" named pipe or the path of a named pipe. TCP
+// connections are neither authenticated nor encrypted, so only the local
+// machine can be dialed over TCP, see `loopbackTCPAddress`. It is
+// the callers responsibility to validate that `if terminal == true`,
+// `stderr == ""`.
+func NewDialIO(ctx context.Context, id, stdin, stdout, stderr string, terminal bool) (UpstreamIO, error) {
+	log.G(ctx).WithFields(logrus.Fields{
+		"stdin":    stdin,
+		"stdout":   stdout,
+		"stderr":   stderr,
+		"terminal": terminal}).Debug("NewDialIO")
+
+	return dialUpstreamIO(ctx, stdin, stdout, stderr, terminal, dialStdio)
+}
+
+func dialStdio(ctx context.Context, s string) (net.Conn, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return winio.DialPipeContext(ctx, s)
+	}
+	switch u.Scheme {
+	case "tcp":
+		addr, err := loopbackTCPAddress(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	case "npipe":
+		return winio.DialPipeContext(ctx, npipeURIPath(u))
+	}
+	return nil, errors.Errorf("unsupported stdio scheme: '%s'", u.Scheme)
+}
+
+// loopbackTCPAddress returns the address to dial for the "<host>:<port>"
+// address `hostport` of a TCP stdio URI, or an error if the host is not a
+// loopback IP or "localhost". As the stdio streams are sent in plain text, this
+// keeps them from being sent to, or read by, another machine.
+func loopbackTCPAddress(hostport string) (string, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid tcp stdio address: '%s'", hostport)
+	}
+	if strings.EqualFold(host, "localhost") {
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", errors.Errorf("tcp stdio address must be a loopback address: '%s'", hostport)
+	}
+	return hostport, nil
+}
+
+// npipeURIPath returns the named pipe path of the "npipe://<server>/pipe/<name>"
+// URI `u`, `\\<server>\pipe\<name>`. The server defaults to the local machine.
+func npipeURIPath(u *url.URL) string {
+	server := u.Host
+	if server == "" {
+		server = "."
+	}
+	return `\\` + server + strings.ReplaceAll(u.Path, "/", `\`)
+}
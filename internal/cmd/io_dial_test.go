@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func Test_npipeURIPath(t *testing.T) {
+	for uri, expected := range map[string]string{
+		"npipe:///pipe/logs":         `\\.\pipe\logs`,
+		"npipe://./pipe/logs/stdout": `\\.\pipe\logs\stdout`,
+		"npipe://server/pipe/logs":   `\\server\pipe\logs`,
+	} {
+		u, err := url.Parse(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := npipeURIPath(u); p != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, uri, p)
+		}
+	}
+}
+
+func Test_loopbackTCPAddress(t *testing.T) {
+	for hostport, expected := range map[string]string{
+		"127.0.0.1:8080": "127.0.0.1:8080",
+		"127.1.2.3:8080": "127.1.2.3:8080",
+		"[::1]:8080":     "[::1]:8080",
+		"localhost:8080": "127.0.0.1:8080",
+		"LocalHost:8080": "127.0.0.1:8080",
+	} {
+		addr, err := loopbackTCPAddress(hostport)
+		if err != nil {
+			t.Fatalf("should not have failed for %s with error: %s", hostport, err)
+		}
+		if addr != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, hostport, addr)
+		}
+	}
+	for _, hostport := range []string{
+		"10.0.0.1:8080",
+		"0.0.0.0:8080",
+		"[::]:8080",
+		"example.com:8080",
+		":8080",
+		"127.0.0.1",
+	} {
+		if _, err := loopbackTCPAddress(hostport); err == nil {
+			t.Fatalf("expected an error for %s", hostport)
+		}
+	}
+}
+
+func Test_NewUpstreamIO_TCP_NotLoopback(t *testing.T) {
+	_, err := NewUpstreamIO(context.Background(), "test", "tcp://10.0.0.1:8080", "", "", false)
+	if err == nil {
+		t.Fatal("expected an error for a stdio address that is not a loopback address")
+	}
+}
+
+func Test_NewUpstreamIO_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer c.Close()
+		b, _ := ioutil.ReadAll(c)
+		received <- string(b)
+	}()
+
+	ctx := context.Background()
+	uio, err := NewUpstreamIO(ctx, "test", "tcp://"+l.Addr().String(), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uio.Stdout().Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	uio.Close(ctx)
+	if s := <-received; s != "hello" {
+		t.Fatalf("expected 'hello', got '%s'", s)
+	}
+}
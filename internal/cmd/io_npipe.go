@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"io"
+	"net"
 	"sync"
 
 	winio "github.com/Microsoft/go-winio"
@@ -19,6 +20,14 @@ func NewNpipeIO(ctx context.Context, stdin, stdout, stderr string, terminal bool
 		"stderr":   stderr,
 		"terminal": terminal}).Debug("NewNpipeIO")
 
+	return dialUpstreamIO(ctx, stdin, stdout, stderr, terminal, func(ctx context.Context, path string) (net.Conn, error) {
+		return winio.DialPipeContext(ctx, path)
+	})
+}
+
+// dialUpstreamIO creates upstream io connected with `dial` to each of the
+// stdio paths that are set.
+func dialUpstreamIO(ctx context.Context, stdin, stdout, stderr string, terminal bool, dial func(context.Context, string) (net.Conn, error)) (_ UpstreamIO, err error) {
 	nio := &npipeio{
 		stdin:    stdin,
 		stdout:   stdout,
@@ -31,21 +40,21 @@ func NewNpipeIO(ctx context.Context, stdin, stdout, stderr string, terminal bool
 		}
 	}()
 	if stdin != "" {
-		c, err := winio.DialPipeContext(ctx, stdin)
+		c, err := dial(ctx, stdin)
 		if err != nil {
 			return nil, err
 		}
 		nio.sin = c
 	}
 	if stdout != "" {
-		c, err := winio.DialPipeContext(ctx, stdout)
+		c, err := dial(ctx, stdout)
 		if err != nil {
 			return nil, err
 		}
 		nio.sout = c
 	}
 	if stderr != "" {
-		c, err := winio.DialPipeContext(ctx, stderr)
+		c, err := dial(ctx, stderr)
 		if err != nil {
 			return nil, err
 		}
@@ -56,6 +65,8 @@ func NewNpipeIO(ctx context.Context, stdin, stdout, stderr string, terminal bool
 
 var _ = (UpstreamIO)(&npipeio{})
 
+// npipeio is the upstream io of a connection to each of the stdio paths, such
+// as a named pipe or a TCP connection.
 type npipeio struct {
 	// stdin, stdout, stderr are the original paths used to open the connections.
 	//
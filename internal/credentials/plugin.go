@@ -0,0 +1,98 @@
+// +build windows
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/pkg/ccgplugin"
+	"github.com/sirupsen/logrus"
+)
+
+// PluginDirEnv is the environment variable that names the directory holding
+// the executables that retrieve the input of CCG plugins. The executable for
+// a plugin is named after its GUID, for example
+// `e4781092-f116-4b79-b55e-28eb6a224e26.exe`. See package ccgplugin for the
+// protocol.
+const PluginDirEnv = "HCSSHIM_CCG_PLUGIN_DIR"
+
+// ResolvePluginInput returns `credSpec` with the plugin input of its host
+// account config replaced by the one returned by the executable registered
+// for its CCG plugin. `credSpec` is returned as is if it has no host account
+// config or no executable is registered for the plugin.
+func ResolvePluginInput(ctx context.Context, id, credSpec string) (string, error) {
+	dir := os.Getenv(PluginDirEnv)
+	if dir == "" {
+		return credSpec, nil
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal([]byte(credSpec), &spec); err != nil {
+		return "", fmt.Errorf("failed to unmarshal credential spec: %s", err)
+	}
+	adConfig, _ := spec["ActiveDirectoryConfig"].(map[string]interface{})
+	hostConfig, _ := adConfig["HostAccountConfig"].(map[string]interface{})
+	pluginGUID, _ := hostConfig["PluginGUID"].(string)
+	if pluginGUID == "" {
+		return credSpec, nil
+	}
+	g, err := guid.FromString(strings.Trim(pluginGUID, "{}"))
+	if err != nil {
+		return "", fmt.Errorf("invalid CCG plugin GUID %q: %s", pluginGUID, err)
+	}
+	path := filepath.Join(dir, strings.ToLower(g.String())+".exe")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return credSpec, nil
+	} else if err != nil {
+		return "", err
+	}
+	pluginInput, _ := hostConfig["PluginInput"].(string)
+	resp, err := runPlugin(ctx, path, &ccgplugin.Request{
+		ContainerID: id,
+		PluginGUID:  g.String(),
+		PluginInput: pluginInput,
+	})
+	if err != nil {
+		return "", fmt.Errorf("CCG plugin %s failed: %s", g, err)
+	}
+	hostConfig["PluginInput"] = resp.PluginInput
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// runPlugin runs the plugin executable at `path` for `req`. The plugin input
+// is not logged as it may hold credentials.
+func runPlugin(ctx context.Context, path string, req *ccgplugin.Request) (*ccgplugin.Response, error) {
+	log.G(ctx).WithFields(logrus.Fields{
+		"containerID": req.ContainerID,
+		"plugin":      path,
+	}).Debug("running CCG plugin")
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(b)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	resp := &ccgplugin.Response{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %s", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+// +build windows
+
+// Package csv detects container storage on Cluster Shared Volumes (CSV),
+// including those backed by Storage Spaces Direct, so that layers and scratch
+// VHDs can be shared by the nodes of a failover cluster.
+//
+// CSV needs the following to be handled differently than local NTFS or ReFS
+// volumes:
+//
+//   - Per VM access grants modify the DACL of the file for every utility VM,
+//     which is coordinated through the CSV coordinator node and fails while
+//     the file is in redirected mode. The virtual machines group is granted
+//     access instead.
+//   - The host cache of a node must not hold writes to a VHD that another
+//     node may open after a failover, so writable VHDs on CSV are attached
+//     uncached.
+//   - Files in redirected mode have all their IO sent over the network to the
+//     coordinator node, which is reported as it is a large slowdown that is
+//     otherwise invisible.
+package csv
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const fileSystemName = "CSVFS"
+
+var (
+	volumesMu sync.Mutex
+	// volumes caches whether each volume root is a CSV.
+	volumes = make(map[string]bool)
+)
+
+// IsCSV returns true if `path` is on a Cluster Shared Volume. `path` does not
+// need to exist but its volume must be mounted.
+func IsCSV(path string) (bool, error) {
+	root, err := volumeRoot(path)
+	if err != nil {
+		return false, err
+	}
+	volumesMu.Lock()
+	defer volumesMu.Unlock()
+	if v, ok := volumes[root]; ok {
+		return v, nil
+	}
+	rootp, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return false, err
+	}
+	var fsName [windows.MAX_PATH + 1]uint16
+	if err := windows.GetVolumeInformation(rootp, nil, 0, nil, nil, nil, &fsName[0], uint32(len(fsName))); err != nil {
+		return false, err
+	}
+	v := strings.EqualFold(windows.UTF16ToString(fsName[:]), fileSystemName)
+	volumes[root] = v
+	return v, nil
+}
+
+func volumeRoot(path string) (string, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	var root [windows.MAX_LONG_PATH]uint16
+	if err := windows.GetVolumePathName(p, &root[0], uint32(len(root))); err != nil {
+		return "", err
+	}
+	return strings.ToLower(windows.UTF16ToString(root[:])), nil
+}
+
+const (
+	// fsctlCSVControl is FSCTL_CSV_CONTROL.
+	fsctlCSVControl = 0x000902d4
+	// csvControlQueryRedirectState is CsvControlQueryRedirectState.
+	csvControlQueryRedirectState = 4
+)
+
+// csvQueryRedirectState is CSV_QUERY_REDIRECT_STATE.
+type csvQueryRedirectState struct {
+	MdsNodeID      uint32
+	DsNodeID       uint32
+	FileRedirected bool
+}
+
+// IsRedirected returns true if IO to the file at `path` on a Cluster Shared
+// Volume is redirected to the coordinator node.
+func IsRedirected(path string) (bool, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false, err
+	}
+	h, err := windows.CreateFile(p, 0, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return false, err
+	}
+	defer windows.CloseHandle(h)
+	op := uint32(csvControlQueryRedirectState)
+	var state csvQueryRedirectState
+	var returned uint32
+	if err := windows.DeviceIoControl(h, fsctlCSVControl, (*byte)(unsafe.Pointer(&op)), uint32(unsafe.Sizeof(op)), (*byte)(unsafe.Pointer(&state)), uint32(unsafe.Sizeof(state)), &returned, nil); err != nil {
+		return false, err
+	}
+	return state.FileRedirected, nil
+}
@@ -0,0 +1,105 @@
+// +build windows
+
+// Package eventlog writes a curated set of critical container runtime
+// failures to the Windows Application event log, so that node monitoring that
+// only watches the event log sees them. Each condition has a stable event ID.
+//
+// Everything else is only logged through logrus and ETW.
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	syseventlog "golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Source is the event source of the events.
+const Source = "hcsshim"
+
+// EventID is the stable ID of a critical condition. IDs are limited to 1-1000
+// as messages are formatted by EventCreate.exe's message file.
+type EventID uint32
+
+const (
+	// EventUVMBootFailure is reported when a utility VM fails to start.
+	EventUVMBootFailure EventID = 100
+	// EventGCSConnectionLost is reported when the connection to the guest
+	// compute service of a running utility VM fails.
+	EventGCSConnectionLost EventID = 101
+	// EventLayerCorruption is reported when a container layer is found to be
+	// corrupt.
+	EventLayerCorruption EventID = 102
+	// EventPodUnhealthy is reported when a pod fails its health checks.
+	EventPodUnhealthy EventID = 103
+)
+
+func (id EventID) String() string {
+	switch id {
+	case EventUVMBootFailure:
+		return "UVMBootFailure"
+	case EventGCSConnectionLost:
+		return "GCSConnectionLost"
+	case EventLayerCorruption:
+		return "LayerCorruption"
+	case EventPodUnhealthy:
+		return "PodUnhealthy"
+	default:
+		return fmt.Sprintf("EventID(%d)", uint32(id))
+	}
+}
+
+var (
+	openOnce sync.Once
+	eventLog *syseventlog.Log
+)
+
+// open returns the event log, registering the event source if required, or
+// nil if it can not be opened.
+func open(ctx context.Context) *syseventlog.Log {
+	openOnce.Do(func() {
+		// Fails if the source is already registered, or if the process
+		// lacks the access to register it. Events are still written in the
+		// latter case but Event Viewer can not format their message.
+		_ = syseventlog.InstallAsEventCreate(Source, syseventlog.Error|syseventlog.Warning|syseventlog.Info)
+		l, err := syseventlog.Open(Source)
+		if err != nil {
+			log.G(ctx).WithError(err).Warning("failed to open the event log")
+			return
+		}
+		eventLog = l
+	})
+	return eventLog
+}
+
+// Error writes the critical condition `id` to the event log as an error, with
+// `msg` and `fields` as the message. Failure to write the event is only
+// logged.
+func Error(ctx context.Context, id EventID, msg string, fields map[string]interface{}) {
+	l := open(ctx)
+	if l == nil {
+		return
+	}
+	if err := l.Error(uint32(id), format(msg, fields)); err != nil {
+		log.G(ctx).WithError(err).WithField("eventID", id).Warning("failed to write to the event log")
+	}
+}
+
+// format returns `msg` followed by `fields` one per line, sorted by name.
+func format(msg string, fields map[string]interface{}) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\r\n%s: %v", k, fields[k])
+	}
+	return b.String()
+}
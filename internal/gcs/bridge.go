@@ -15,7 +15,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/eventlog"
+	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	"golang.org/x/sys/windows"
 )
 
@@ -115,6 +118,11 @@ func (brdg *bridge) kill(err error) {
 	brdg.brdgErr = err
 	if err != nil {
 		brdg.log.WithError(err).Error("bridge forcibly terminating")
+		fields := map[string]interface{}{"error": err}
+		for k, v := range brdg.log.Data {
+			fields[k] = v
+		}
+		eventlog.Error(context.Background(), eventlog.EventGCSConnectionLost, "the connection to the guest compute service of a utility VM was lost", fields)
 	} else {
 		brdg.log.Debug("bridge terminating")
 	}
@@ -169,24 +177,90 @@ func (call *rpc) complete(err error) {
 	close(call.ch)
 }
 
-type rpcError struct {
-	result  int32
-	message string
+// GuestError is the error returned by the guest for a failed RPC.
+type GuestError struct {
+	// Result is the HRESULT of the failure.
+	Result int32
+	// Message is the error message returned by the guest.
+	Message string
+	// Records are the error records returned by the guest, the record of the
+	// original failure first.
+	Records []ErrorRecord
 }
 
-func (err *rpcError) Error() string {
-	msg := err.message
+func (err *GuestError) Error() string {
+	msg := err.Message
 	if msg == "" {
-		msg = windows.Errno(err.result).Error()
+		msg = windows.Errno(err.Result).Error()
+	}
+	msg = "guest RPC failure: " + msg
+	if rec := err.cause(); rec != nil {
+		if rec.Syscall != "" {
+			msg += fmt.Sprintf(" (%s failed with errno %d)", rec.Syscall, rec.Errno)
+		}
+		if rec.Hint != "" {
+			msg += ": " + rec.Hint
+		}
+	}
+	return msg
+}
+
+func (err *GuestError) cause() *ErrorRecord {
+	if len(err.Records) == 0 {
+		return nil
+	}
+	return &err.Records[0]
+}
+
+// Errno returns the error number of the failing guest system call, or 0 if
+// the guest did not report one.
+func (err *GuestError) Errno() int32 {
+	if rec := err.cause(); rec != nil {
+		return rec.Errno
+	}
+	return 0
+}
+
+// Syscall returns the name of the failing guest system call, or "" if the
+// guest did not report one.
+func (err *GuestError) Syscall() string {
+	if rec := err.cause(); rec != nil {
+		return rec.Syscall
+	}
+	return ""
+}
+
+// Hint returns the remediation hint returned by the guest, if any.
+func (err *GuestError) Hint() string {
+	if rec := err.cause(); rec != nil {
+		return rec.Hint
+	}
+	return ""
+}
+
+// StackTrace returns the guest stack trace of the original failure, if any.
+func (err *GuestError) StackTrace() string {
+	if rec := err.cause(); rec != nil {
+		return rec.StackTrace
+	}
+	return ""
+}
+
+// AsGuestError returns the GuestError that caused `err`, looking through
+// errors wrapped with github.com/pkg/errors or fmt.Errorf %w.
+func AsGuestError(err error) (*GuestError, bool) {
+	var gerr *GuestError
+	if errors.As(err, &gerr) {
+		return gerr, true
 	}
-	return "guest RPC failure: " + msg
+	return nil, false
 }
 
 // IsNotExist is a helper function to determine if the inner rpc error is Not Exist
 func IsNotExist(err error) bool {
 	switch rerr := err.(type) {
-	case *rpcError:
-		return uint32(rerr.result) == hrComputeSystemDoesNotExist
+	case *GuestError:
+		return uint32(rerr.Result) == hrComputeSystemDoesNotExist
 	}
 	return false
 }
@@ -201,7 +275,7 @@ func (call *rpc) Err() error {
 	if resp.Result == 0 {
 		return nil
 	}
-	return &rpcError{result: resp.Result, message: resp.ErrorMessage}
+	return &GuestError{Result: resp.Result, Message: resp.ErrorMessage, Records: resp.ErrorRecords}
 }
 
 // Done returns whether the RPC has completed.
@@ -225,7 +299,12 @@ func (call *rpc) Wait() {
 // If allowCancel is set and the context becomes done, returns an error without
 // waiting for a response. Avoid this on messages that are not idempotent or
 // otherwise safe to ignore the response of.
-func (brdg *bridge) RPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, allowCancel bool) error {
+func (brdg *bridge) RPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, allowCancel bool) (err error) {
+	ctx, span := trace.StartSpan(ctx, "gcs::bridge::RPC")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("proc", (msgTypeRequest | msgType(proc)).String()))
+
 	call, err := brdg.AsyncRPC(ctx, proc, req, resp)
 	if err != nil {
 		return err
@@ -334,6 +413,8 @@ func (brdg *bridge) recvLoop() error {
 						"file":           rec.FileName,
 						"line":           rec.Line,
 						"function":       rec.FunctionName,
+						"errno":          rec.Errno,
+						"syscall":        rec.Syscall,
 					}).Error("bridge RPC error record")
 				}
 			}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"reflect"
 	"strings"
@@ -196,3 +197,26 @@ func TestBridgeNotifyFailure(t *testing.T) {
 		t.Error("unexpected result: ", err)
 	}
 }
+
+func TestGuestErrorDetails(t *testing.T) {
+	gerr := &GuestError{
+		Result:  -2147467259,
+		Message: "mount failed",
+		Records: []ErrorRecord{{Errno: 2, Syscall: "mount", Hint: "check the device exists"}},
+	}
+	wrapped := fmt.Errorf("guest modify: %w", gerr)
+	got, ok := AsGuestError(wrapped)
+	if !ok || got != gerr {
+		t.Fatalf("expected the guest error to be found, got %v", got)
+	}
+	if got.Errno() != 2 || got.Syscall() != "mount" || got.Hint() != "check the device exists" {
+		t.Fatalf("unexpected details %+v", got.Records[0])
+	}
+	expected := "guest RPC failure: mount failed (mount failed with errno 2): check the device exists"
+	if gerr.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, gerr.Error())
+	}
+	if _, ok := AsGuestError(errors.New("not a guest error")); ok {
+		t.Fatal("expected no guest error")
+	}
+}
@@ -53,7 +53,7 @@ func (gc *GuestConnection) CreateContainer(ctx context.Context, cid string, conf
 		ContainerConfig: anyInString{config},
 	}
 	var resp containerCreateResponse
-	err = gc.brdg.RPC(ctx, rpcCreate, &req, &resp, false)
+	err = gc.bridge().RPC(ctx, rpcCreate, &req, &resp, false)
 	if err != nil {
 		return nil, err
 	}
@@ -126,7 +126,7 @@ func (c *Container) Modify(ctx context.Context, config interface{}) (err error)
 		Request:     config,
 	}
 	var resp responseBase
-	return c.gc.brdg.RPC(ctx, rpcModifySettings, &req, &resp, false)
+	return c.gc.bridge().RPC(ctx, rpcModifySettings, &req, &resp, false)
 }
 
 // Properties returns the requested container properties targeting a V1 schema container.
@@ -141,7 +141,7 @@ func (c *Container) Properties(ctx context.Context, types ...schema1.PropertyTyp
 		Query:       containerPropertiesQuery{PropertyTypes: types},
 	}
 	var resp containerGetPropertiesResponse
-	err = c.gc.brdg.RPC(ctx, rpcGetProperties, &req, &resp, true)
+	err = c.gc.bridge().RPC(ctx, rpcGetProperties, &req, &resp, true)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +160,7 @@ func (c *Container) PropertiesV2(ctx context.Context, types ...hcsschema.Propert
 		Query:       containerPropertiesQueryV2{PropertyTypes: types},
 	}
 	var resp containerGetPropertiesResponseV2
-	err = c.gc.brdg.RPC(ctx, rpcGetProperties, &req, &resp, true)
+	err = c.gc.bridge().RPC(ctx, rpcGetProperties, &req, &resp, true)
 	if err != nil {
 		return nil, err
 	}
@@ -176,13 +176,13 @@ func (c *Container) Start(ctx context.Context) (err error) {
 
 	req := makeRequest(ctx, c.id)
 	var resp responseBase
-	return c.gc.brdg.RPC(ctx, rpcStart, &req, &resp, false)
+	return c.gc.bridge().RPC(ctx, rpcStart, &req, &resp, false)
 }
 
 func (c *Container) shutdown(ctx context.Context, proc rpcProc) error {
 	req := makeRequest(ctx, c.id)
 	var resp responseBase
-	err := c.gc.brdg.RPC(ctx, proc, &req, &resp, true)
+	err := c.gc.bridge().RPC(ctx, proc, &req, &resp, true)
 	if err != nil {
 		if uint32(resp.Result) != hrComputeSystemDoesNotExist {
 			return err
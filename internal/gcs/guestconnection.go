@@ -11,6 +11,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Microsoft/go-winio"
 	"github.com/Microsoft/go-winio/pkg/guid"
@@ -19,6 +20,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/logfields"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/schema1"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/sirupsen/logrus"
 	"go.opencensus.io/trace"
 )
@@ -54,6 +56,26 @@ type GuestConnectionConfig struct {
 	Log *logrus.Entry
 	// IoListen is the function to use to create listeners for the stdio connections.
 	IoListen IoListenFunc
+	// Timeout is the time after which an RPC without a response is considered
+	// hung and the bridge fails. Defaults to 5 minutes.
+	Timeout time.Duration
+	// Reconnect, if set, is called when the bridge fails to wait for a new
+	// connection from a restarted GCS. Outstanding container and process
+	// waits are re-issued on the new connection; those that the guest no
+	// longer knows of complete as if the container or process exited. RPCs
+	// issued while reconnecting fail. If Reconnect returns an error, the
+	// guest connection terminates.
+	Reconnect func(ctx context.Context) (io.ReadWriteCloser, error)
+	// Reconnected, if set, is called once RPCs are issued on the connection
+	// from a restarted GCS, to restore the guest configuration that the GCS
+	// lost when it restarted. If it returns an error, the guest connection
+	// terminates.
+	Reconnected func(ctx context.Context) error
+	// Failed, if set, is called with the error of the bridge when the
+	// connection terminates because the bridge failed and was not
+	// reconnected, before the container waits complete. It is not called when
+	// the connection is closed.
+	Failed func(err error)
 }
 
 // Connect establishes a GCS connection. `gcc.Conn` will be closed by this function.
@@ -63,17 +85,20 @@ func (gcc *GuestConnectionConfig) Connect(ctx context.Context, isColdStart bool)
 	defer func() { oc.SetSpanStatus(span, err) }()
 
 	gc := &GuestConnection{
-		nextPort:   firstIoChannelVsockPort,
-		notifyChs:  make(map[string]chan struct{}),
-		ioListenFn: gcc.IoListen,
-	}
-	gc.brdg = newBridge(gcc.Conn, gc.notify, gcc.Log)
-	gc.brdg.Start()
-	go func() {
-		_ = gc.brdg.Wait()
-		gc.clearNotifies()
-	}()
-	err = gc.connect(ctx, isColdStart)
+		nextPort:    firstIoChannelVsockPort,
+		notifyChs:   make(map[string]chan struct{}),
+		ioListenFn:  gcc.IoListen,
+		log:         gcc.Log,
+		timeout:     gcc.Timeout,
+		reconnectFn: gcc.Reconnect,
+		reconnected: gcc.Reconnected,
+		failed:      gcc.Failed,
+		bridgeCh:    make(chan struct{}),
+	}
+	gc.ctx, gc.cancel = context.WithCancel(context.Background())
+	gc.brdg = gc.newBridge(gcc.Conn)
+	go gc.monitor(gc.brdg)
+	err = gc.connect(ctx, gc.brdg, isColdStart)
 	if err != nil {
 		gc.Close()
 		return nil, err
@@ -83,13 +108,25 @@ func (gcc *GuestConnectionConfig) Connect(ctx context.Context, isColdStart bool)
 
 // GuestConnection represents a connection to the GCS.
 type GuestConnection struct {
-	brdg       *bridge
-	ioListenFn IoListenFunc
-	mu         sync.Mutex
-	nextPort   uint32
-	notifyChs  map[string]chan struct{}
-	caps       schema1.GuestDefinedCapabilities
-	os         string
+	brdg        *bridge
+	ioListenFn  IoListenFunc
+	log         *logrus.Entry
+	timeout     time.Duration
+	reconnectFn func(ctx context.Context) (io.ReadWriteCloser, error)
+	reconnected func(ctx context.Context) error
+	failed      func(err error)
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.Mutex
+	nextPort    uint32
+	notifyChs   map[string]chan struct{}
+	caps        schema1.GuestDefinedCapabilities
+	os          string
+	// bridgeCh is closed when brdg is replaced by a reconnect, or when the
+	// connection terminates.
+	bridgeCh   chan struct{}
+	terminated bool
+	closed     bool
 }
 
 var _ cow.ProcessHost = &GuestConnection{}
@@ -104,18 +141,150 @@ func (gc *GuestConnection) Protocol() uint32 {
 	return protocolVersion
 }
 
-// connect establishes a GCS connection. It must not be called more than once.
+// newBridge starts a bridge on `conn`.
+func (gc *GuestConnection) newBridge(conn io.ReadWriteCloser) *bridge {
+	brdg := newBridge(conn, gc.notify, gc.log)
+	if gc.timeout != 0 {
+		brdg.Timeout = gc.timeout
+	}
+	brdg.Start()
+	return brdg
+}
+
+// bridge returns the current bridge.
+func (gc *GuestConnection) bridge() *bridge {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.brdg
+}
+
+// monitor waits for `brdg` to fail and, if configured, reconnects to a
+// restarted GCS. Once the connection terminates, all container waits
+// complete.
+func (gc *GuestConnection) monitor(brdg *bridge) {
+	var err error
+	for {
+		err = brdg.Wait()
+		if err == nil || gc.reconnectFn == nil {
+			break
+		}
+		brdg, err = gc.reconnect(err)
+		if err != nil {
+			gc.log.WithError(err).Error("failed to reconnect to the GCS")
+			break
+		}
+	}
+	gc.mu.Lock()
+	closed := gc.closed
+	gc.mu.Unlock()
+	if err != nil && !closed && gc.failed != nil {
+		gc.failed(err)
+	}
+	gc.mu.Lock()
+	gc.terminated = true
+	close(gc.bridgeCh)
+	gc.mu.Unlock()
+	gc.clearNotifies()
+}
+
+// reconnect waits for a new connection from a restarted GCS after the bridge
+// failed with `brdgErr`, replaces the bridge with it, and re-synchronizes the
+// state of the containers.
+func (gc *GuestConnection) reconnect(brdgErr error) (_ *bridge, err error) {
+	ctx, span := trace.StartSpan(gc.ctx, "gcs::GuestConnection::reconnect")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	gc.log.WithError(brdgErr).Warning("waiting for the GCS to reconnect")
+	conn, err := gc.reconnectFn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	brdg := gc.newBridge(conn)
+	if err := gc.connect(ctx, brdg, false); err != nil {
+		brdg.Close()
+		return nil, err
+	}
+	gc.mu.Lock()
+	if gc.closed {
+		gc.mu.Unlock()
+		brdg.Close()
+		return nil, errors.New("guest connection closed")
+	}
+	gc.brdg = brdg
+	close(gc.bridgeCh)
+	gc.bridgeCh = make(chan struct{})
+	gc.mu.Unlock()
+	gc.log.Info("reconnected to the GCS")
+	if gc.reconnected != nil {
+		if err := gc.reconnected(ctx); err != nil {
+			brdg.Close()
+			return nil, fmt.Errorf("failed to restore the guest configuration: %s", err)
+		}
+	}
+	gc.resyncContainers(ctx, brdg)
+	return brdg, nil
+}
+
+// waitReconnect waits for `brdg` to be replaced by a reconnect and returns the
+// new bridge, or nil if the connection terminated instead.
+func (gc *GuestConnection) waitReconnect(brdg *bridge) *bridge {
+	if gc.reconnectFn == nil {
+		return nil
+	}
+	for {
+		gc.mu.Lock()
+		cur, ch, terminated := gc.brdg, gc.bridgeCh, gc.terminated
+		gc.mu.Unlock()
+		if terminated {
+			return nil
+		}
+		if cur != brdg {
+			return cur
+		}
+		<-ch
+	}
+}
+
+// resyncContainers completes the waits of the containers that the guest no
+// longer knows of after a reconnect.
+func (gc *GuestConnection) resyncContainers(ctx context.Context, brdg *bridge) {
+	gc.mu.Lock()
+	cids := make([]string, 0, len(gc.notifyChs))
+	for cid := range gc.notifyChs {
+		cids = append(cids, cid)
+	}
+	gc.mu.Unlock()
+	for _, cid := range cids {
+		req := containerGetProperties{
+			requestBase: makeRequest(ctx, cid),
+		}
+		var resp containerGetPropertiesResponse
+		err := brdg.RPC(ctx, rpcGetProperties, &req, &resp, true)
+		if err == nil {
+			continue
+		}
+		if !IsNotExist(err) {
+			gc.log.WithError(err).WithField(logfields.ContainerID, cid).Warning("failed to query container after reconnect")
+			continue
+		}
+		_ = gc.notify(&containerNotification{requestBase: requestBase{ContainerID: cid}})
+	}
+}
+
+// connect establishes a GCS connection on `brdg`. It must not be called more
+// than once per bridge.
 // isColdStart should be true when the UVM is being connected to for the first time post-boot.
 // It should be false for subsequent connections (e.g. when connecting to a UVM that has
-// been cloned).
-func (gc *GuestConnection) connect(ctx context.Context, isColdStart bool) (err error) {
+// been cloned, or reconnecting to a restarted GCS).
+func (gc *GuestConnection) connect(ctx context.Context, brdg *bridge, isColdStart bool) (err error) {
 	req := negotiateProtocolRequest{
 		MinimumVersion: protocolVersion,
 		MaximumVersion: protocolVersion,
 	}
 	var resp negotiateProtocolResponse
 	resp.Capabilities.GuestDefinedCapabilities = &gc.caps
-	err = gc.brdg.RPC(ctx, rpcNegotiateProtocol, &req, &resp, true)
+	err = brdg.RPC(ctx, rpcNegotiateProtocol, &req, &resp, true)
 	if err != nil {
 		return err
 	}
@@ -134,14 +303,14 @@ func (gc *GuestConnection) connect(ctx context.Context, isColdStart bool) (err e
 			}},
 		}
 		var createResp responseBase
-		err = gc.brdg.RPC(ctx, rpcCreate, &createReq, &createResp, true)
+		err = brdg.RPC(ctx, rpcCreate, &createReq, &createResp, true)
 		if err != nil {
 			return err
 		}
 		if resp.Capabilities.SendHostStartMessage {
 			startReq := makeRequest(ctx, nullContainerID)
 			var startResp responseBase
-			err = gc.brdg.RPC(ctx, rpcStart, &startReq, &startResp, true)
+			err = brdg.RPC(ctx, rpcStart, &startReq, &startResp, true)
 			if err != nil {
 				return err
 			}
@@ -162,7 +331,7 @@ func (gc *GuestConnection) Modify(ctx context.Context, settings interface{}) (er
 		Request:     settings,
 	}
 	var resp responseBase
-	return gc.brdg.RPC(ctx, rpcModifySettings, &req, &resp, false)
+	return gc.bridge().RPC(ctx, rpcModifySettings, &req, &resp, false)
 }
 
 func (gc *GuestConnection) DumpStacks(ctx context.Context) (response string, err error) {
@@ -176,7 +345,7 @@ func (gc *GuestConnection) DumpStacks(ctx context.Context) (response string, err
 
 	var resp dumpStacksResponse
 
-	err = gc.brdg.RPC(ctx, rpcDumpStacks, &req, &resp, false)
+	err = gc.bridge().RPC(ctx, rpcDumpStacks, &req, &resp, false)
 	return resp.GuestStacks, err
 }
 
@@ -190,7 +359,7 @@ func (gc *GuestConnection) DeleteContainerState(ctx context.Context, cid string)
 		requestBase: makeRequest(ctx, cid),
 	}
 	var resp responseBase
-	return gc.brdg.RPC(ctx, rpcDeleteContainerState, &req, &resp, false)
+	return gc.bridge().RPC(ctx, rpcDeleteContainerState, &req, &resp, false)
 }
 
 func (gc *GuestConnection) UpdateContainer(ctx context.Context, cid string, resources interface{}) (err error) {
@@ -209,16 +378,23 @@ func (gc *GuestConnection) UpdateContainer(ctx context.Context, cid string, reso
 		Resources:   string(resourcesJSON),
 	}
 	var resp responseBase
-	return gc.brdg.RPC(ctx, rpcUpdateContainer, &req, &resp, false)
+	return gc.bridge().RPC(ctx, rpcUpdateContainer, &req, &resp, false)
 }
 
 // Close terminates the guest connection. It is undefined to call any other
 // methods on the connection after this is called.
 func (gc *GuestConnection) Close() error {
-	if gc.brdg == nil {
+	gc.mu.Lock()
+	brdg := gc.brdg
+	gc.closed = true
+	gc.mu.Unlock()
+	if gc.cancel != nil {
+		gc.cancel()
+	}
+	if brdg == nil {
 		return nil
 	}
-	return gc.brdg.Close()
+	return brdg.Close()
 }
 
 // CreateProcess creates a process in the container host.
@@ -230,6 +406,56 @@ func (gc *GuestConnection) CreateProcess(ctx context.Context, settings interface
 	return gc.exec(ctx, nullContainerID, settings)
 }
 
+// ExecInContainer creates a process in the container `cid`, which may have
+// been created by another connection to the guest.
+func (gc *GuestConnection) ExecInContainer(ctx context.Context, cid string, settings interface{}) (_ cow.Process, err error) {
+	ctx, span := trace.StartSpan(ctx, "gcs::GuestConnection::ExecInContainer")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("cid", cid))
+
+	return gc.exec(ctx, cid, settings)
+}
+
+// ContainerPropertiesV2 returns the requested properties of the container
+// `cid`, which may have been created by another connection to the guest.
+func (gc *GuestConnection) ContainerPropertiesV2(ctx context.Context, cid string, types ...hcsschema.PropertyType) (_ *hcsschema.Properties, err error) {
+	ctx, span := trace.StartSpan(ctx, "gcs::GuestConnection::ContainerPropertiesV2")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("cid", cid))
+
+	req := containerGetPropertiesV2{
+		requestBase: makeRequest(ctx, cid),
+		Query:       containerPropertiesQueryV2{PropertyTypes: types},
+	}
+	var resp containerGetPropertiesResponseV2
+	err = gc.bridge().RPC(ctx, rpcGetProperties, &req, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	return (*hcsschema.Properties)(&resp.Properties), nil
+}
+
+// Ping sends a request to the GCS that has no effect and returns once the GCS
+// responds, whether the request succeeded or not, to check that it is
+// responsive.
+func (gc *GuestConnection) Ping(ctx context.Context) (err error) {
+	ctx, span := trace.StartSpan(ctx, "gcs::GuestConnection::Ping")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	req := containerGetPropertiesV2{
+		requestBase: makeRequest(ctx, nullContainerID),
+	}
+	var resp containerGetPropertiesResponseV2
+	err = gc.bridge().RPC(ctx, rpcGetProperties, &req, &resp, true)
+	if _, ok := AsGuestError(err); ok {
+		return nil
+	}
+	return err
+}
+
 // OS returns the operating system of the container's host, "windows" or "linux".
 func (gc *GuestConnection) OS() string {
 	return gc.os
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -113,6 +114,11 @@ func simpleGcsLoop(t *testing.T, rw io.ReadWriter) error {
 			}
 		case rpcWaitForProcess:
 			// nothing
+		case rpcGetProperties:
+			err := sendJSON(t, rw, msgTypeResponse|msgType(proc), id, &containerGetPropertiesResponse{})
+			if err != nil {
+				return err
+			}
 		case rpcShutdownForced:
 			var req requestBase
 			err = json.Unmarshal(b, &req)
@@ -255,6 +261,198 @@ func TestGcsWaitProcessBridgeTerminated(t *testing.T) {
 	}
 }
 
+func TestGcsReconnect(t *testing.T) {
+	s, c := pipeConn()
+	go simpleGcs(t, c)
+	reconnected := make(chan struct{})
+	gcc := &GuestConnectionConfig{
+		Conn:     s,
+		Log:      logrus.NewEntry(logrus.StandardLogger()),
+		IoListen: npipeIoListen,
+		Reconnect: func(ctx context.Context) (io.ReadWriteCloser, error) {
+			s, c := pipeConn()
+			go simpleGcs(t, c)
+			close(reconnected)
+			return s, nil
+		},
+	}
+	gc, err := gcc.Connect(context.Background(), true)
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	defer gc.Close()
+	ctr, err := gc.CreateContainer(context.Background(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctr.Close()
+	gc.bridge().kill(errors.New("message timeout"))
+	<-reconnected
+	// RPCs fail until the new bridge has negotiated the protocol.
+	for i := 0; ; i++ {
+		err = ctr.Terminate(context.Background())
+		if err == nil {
+			break
+		}
+		if i == 100 {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	err = ctr.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGcsReconnected(t *testing.T) {
+	s, c := pipeConn()
+	go simpleGcs(t, c)
+	var gc *GuestConnection
+	reconnected := make(chan error, 1)
+	gcc := &GuestConnectionConfig{
+		Conn:     s,
+		Log:      logrus.NewEntry(logrus.StandardLogger()),
+		IoListen: npipeIoListen,
+		Reconnect: func(ctx context.Context) (io.ReadWriteCloser, error) {
+			s, c := pipeConn()
+			go simpleGcs(t, c)
+			return s, nil
+		},
+		// The configuration is restored on the new bridge.
+		Reconnected: func(ctx context.Context) error {
+			ctr, err := gc.CreateContainer(ctx, "bar", nil)
+			if err == nil {
+				ctr.Close()
+			}
+			reconnected <- err
+			return err
+		},
+	}
+	gc, err := gcc.Connect(context.Background(), true)
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	defer gc.Close()
+	gc.bridge().kill(errors.New("message timeout"))
+	if err := <-reconnected; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGcsReconnectedFailure(t *testing.T) {
+	s, c := pipeConn()
+	go simpleGcs(t, c)
+	gcc := &GuestConnectionConfig{
+		Conn:     s,
+		Log:      logrus.NewEntry(logrus.StandardLogger()),
+		IoListen: npipeIoListen,
+		Reconnect: func(ctx context.Context) (io.ReadWriteCloser, error) {
+			s, c := pipeConn()
+			go simpleGcs(t, c)
+			return s, nil
+		},
+		Reconnected: func(ctx context.Context) error {
+			return errors.New("failed")
+		},
+	}
+	gc, err := gcc.Connect(context.Background(), true)
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	defer gc.Close()
+	ctr, err := gc.CreateContainer(context.Background(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctr.Close()
+	gc.bridge().kill(errors.New("message timeout"))
+	// The connection terminates, so the container wait completes.
+	if err := ctr.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	gc.mu.Lock()
+	terminated := gc.terminated
+	gc.mu.Unlock()
+	if !terminated {
+		t.Fatal("expected the guest connection to terminate")
+	}
+}
+
+func TestGcsFailed(t *testing.T) {
+	s, c := pipeConn()
+	go simpleGcs(t, c)
+	failed := make(chan error, 1)
+	gcc := &GuestConnectionConfig{
+		Conn:     s,
+		Log:      logrus.NewEntry(logrus.StandardLogger()),
+		IoListen: npipeIoListen,
+		Failed: func(err error) {
+			failed <- err
+		},
+	}
+	gc, err := gcc.Connect(context.Background(), true)
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	defer gc.Close()
+	ctr, err := gc.CreateContainer(context.Background(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctr.Close()
+	gc.bridge().kill(errors.New("message timeout"))
+	// Failed is called before the container waits complete.
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Fatal("expected the error of the bridge")
+		}
+	default:
+		if err := ctr.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		t.Fatal("expected Failed to be called before the container wait completed")
+	}
+}
+
+func TestGcsFailedNotCalledOnClose(t *testing.T) {
+	s, c := pipeConn()
+	go simpleGcs(t, c)
+	failed := make(chan error, 1)
+	gcc := &GuestConnectionConfig{
+		Conn:     s,
+		Log:      logrus.NewEntry(logrus.StandardLogger()),
+		IoListen: npipeIoListen,
+		Failed: func(err error) {
+			failed <- err
+		},
+	}
+	gc, err := gcc.Connect(context.Background(), true)
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	ctr, err := gc.CreateContainer(context.Background(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctr.Close()
+	gc.Close()
+	if err := ctr.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-failed:
+		t.Fatalf("expected Failed not to be called when closed, got: %s", err)
+	default:
+	}
+}
+
 func Test_makeRequestNoSpan(t *testing.T) {
 	r := makeRequest(context.Background(), t.Name())
 
@@ -28,6 +28,8 @@ type Process struct {
 	id                    uint32
 	waitCall              *rpc
 	waitResp              containerWaitForProcessResponse
+	waitBrdg              *bridge
+	waitDone              chan struct{}
 	stdin, stdout, stderr *ioChannel
 	stdinCloseWriteOnce   sync.Once
 	stdinCloseWriteErr    error
@@ -57,7 +59,7 @@ func (gc *GuestConnection) exec(ctx context.Context, cid string, params interfac
 		},
 	}
 
-	p := &Process{gc: gc, cid: cid}
+	p := &Process{gc: gc, cid: cid, waitDone: make(chan struct{})}
 	defer func() {
 		if err != nil {
 			p.Close()
@@ -98,8 +100,9 @@ func (gc *GuestConnection) exec(ctx context.Context, cid string, params interfac
 		hvsockSettings.StdErr = &g
 	}
 
+	brdg := gc.bridge()
 	var resp containerExecuteProcessResponse
-	err = gc.brdg.RPC(ctx, rpcExecuteProcess, &req, &resp, false)
+	err = brdg.RPC(ctx, rpcExecuteProcess, &req, &resp, false)
 	if err != nil {
 		return nil, err
 	}
@@ -111,10 +114,11 @@ func (gc *GuestConnection) exec(ctx context.Context, cid string, params interfac
 		ProcessID:   p.id,
 		TimeoutInMs: 0xffffffff,
 	}
-	p.waitCall, err = gc.brdg.AsyncRPC(ctx, rpcWaitForProcess, &waitReq, &p.waitResp)
+	p.waitCall, err = brdg.AsyncRPC(ctx, rpcWaitForProcess, &waitReq, &p.waitResp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait on process, leaking process: %s", err)
 	}
+	p.waitBrdg = brdg
 	go p.waitBackground()
 	return p, nil
 }
@@ -161,7 +165,7 @@ func (p *Process) CloseStdin(ctx context.Context) (err error) {
 // ExitCode returns the process's exit code, or an error if the process is still
 // running or the exit code is otherwise unknown.
 func (p *Process) ExitCode() (_ int, err error) {
-	if !p.waitCall.Done() {
+	if !p.exited() {
 		return -1, errors.New("process not exited")
 	}
 	if err := p.waitCall.Err(); err != nil {
@@ -206,7 +210,7 @@ func (p *Process) ResizeConsole(ctx context.Context, width, height uint16) (err
 		Width:       width,
 	}
 	var resp responseBase
-	return p.gc.brdg.RPC(ctx, rpcResizeConsole, &req, &resp, true)
+	return p.gc.bridge().RPC(ctx, rpcResizeConsole, &req, &resp, true)
 }
 
 // Signal sends a signal to the process, returning whether it was delivered.
@@ -226,12 +230,12 @@ func (p *Process) Signal(ctx context.Context, options interface{}) (_ bool, err
 	var resp responseBase
 	// FUTURE: SIGKILL is idempotent and can safely be cancelled, but this interface
 	//		   does currently make it easy to determine what signal is being sent.
-	err = p.gc.brdg.RPC(ctx, rpcSignalProcess, &req, &resp, false)
+	err = p.gc.bridge().RPC(ctx, rpcSignalProcess, &req, &resp, false)
 	if err != nil {
 		if uint32(resp.Result) != hrNotFound {
 			return false, err
 		}
-		if !p.waitCall.Done() {
+		if !p.exited() {
 			log.G(ctx).WithFields(logrus.Fields{
 				logrus.ErrorKey:       err,
 				logfields.ContainerID: p.cid,
@@ -251,10 +255,20 @@ func (p *Process) Stdio() (stdin io.Writer, stdout, stderr io.Reader) {
 
 // Wait waits for the process (or guest connection) to terminate.
 func (p *Process) Wait() error {
-	p.waitCall.Wait()
+	<-p.waitDone
 	return p.waitCall.Err()
 }
 
+// exited returns whether the wait for the process has completed.
+func (p *Process) exited() bool {
+	select {
+	case <-p.waitDone:
+		return true
+	default:
+		return false
+	}
+}
+
 func (p *Process) waitBackground() {
 	ctx, span := trace.StartSpan(context.Background(), "gcs::Process::waitBackground")
 	defer span.End()
@@ -262,7 +276,30 @@ func (p *Process) waitBackground() {
 		trace.StringAttribute("cid", p.cid),
 		trace.Int64Attribute("pid", int64(p.id)))
 
-	p.waitCall.Wait()
+	for {
+		p.waitCall.Wait()
+		if p.waitCall.brdgErr == nil {
+			break
+		}
+		// The bridge failed. If the GCS reconnects, wait again, as the
+		// process may have outlived the GCS.
+		brdg := p.gc.waitReconnect(p.waitBrdg)
+		if brdg == nil {
+			break
+		}
+		waitReq := containerWaitForProcess{
+			requestBase: makeRequest(ctx, p.cid),
+			ProcessID:   p.id,
+			TimeoutInMs: 0xffffffff,
+		}
+		call, err := brdg.AsyncRPC(ctx, rpcWaitForProcess, &waitReq, &p.waitResp)
+		if err != nil {
+			log.G(ctx).WithError(err).Warning("failed to wait on process after reconnect")
+			break
+		}
+		p.waitBrdg, p.waitCall = brdg, call
+	}
+	close(p.waitDone)
 	ec, err := p.ExitCode()
 	if err != nil {
 		log.G(ctx).WithError(err).Error("failed wait")
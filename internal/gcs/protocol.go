@@ -172,10 +172,12 @@ type responseBase struct {
 	Result       int32         // HResult
 	ErrorMessage string        `json:",omitempty"`
 	ActivityID   guid.GUID     `json:"ActivityId,omitempty"`
-	ErrorRecords []errorRecord `json:",omitempty"`
+	ErrorRecords []ErrorRecord `json:",omitempty"`
 }
 
-type errorRecord struct {
+// ErrorRecord is a record of a failure in the guest returned with a failed
+// RPC.
+type ErrorRecord struct {
 	Result       int32 // HResult
 	Message      string
 	StackTrace   string `json:",omitempty"`
@@ -183,6 +185,12 @@ type errorRecord struct {
 	FileName     string
 	Line         uint32
 	FunctionName string `json:",omitempty"`
+	// Errno and Syscall are the error number and name of the guest system
+	// call that failed, if any. Hint is a suggestion of how to remediate the
+	// failure. They are only set by guests that report them.
+	Errno   int32  `json:",omitempty"`
+	Syscall string `json:",omitempty"`
+	Hint    string `json:",omitempty"`
 }
 
 func (resp *responseBase) Base() *responseBase {
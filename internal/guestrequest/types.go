@@ -2,6 +2,7 @@ package guestrequest
 
 import (
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // Arguably, many of these (at least CombinedLayers) should have been generated
@@ -19,8 +20,23 @@ type CombinedLayers struct {
 	ContainerRootPath string            `json:"ContainerRootPath,omitempty"`
 	Layers            []hcsschema.Layer `json:"Layers,omitempty"`
 	ScratchPath       string            `json:"ScratchPath,omitempty"`
+	// LayerFilesystem is the filesystem of the layers for linux. If empty the
+	// layers are ext4.
+	LayerFilesystem string `json:"LayerFilesystem,omitempty"`
+	// ScratchQuotaInBytes is the project quota that the linux GCS places on
+	// the upper and work directories on ScratchPath. 0 is unlimited.
+	ScratchQuotaInBytes uint64 `json:"ScratchQuotaInBytes,omitempty"`
+	// ScratchTmpfsSizeInBytes, if non-zero, is the size of a tmpfs that the
+	// linux GCS mounts at ScratchPath for the upper and work directories, in
+	// place of a scratch disk. The tmpfs is unmounted when the combined layers
+	// are removed.
+	ScratchTmpfsSizeInBytes uint64 `json:"ScratchTmpfsSizeInBytes,omitempty"`
 }
 
+// LayerFilesystemErofs is the CombinedLayers LayerFilesystem of EROFS layer
+// images.
+const LayerFilesystemErofs = "erofs"
+
 // Defines the schema for hosted settings passed to GCS and/or OpenGCS
 
 // SCSI. Scratch space for remote file-system commands, or R/W layer for containers
@@ -41,8 +57,56 @@ type LCOWMappedDirectory struct {
 	Port      int32  `json:"Port,omitempty"`
 	ShareName string `json:"ShareName,omitempty"` // If empty not using ANames (not currently supported)
 	ReadOnly  bool   `json:"ReadOnly,omitempty"`
+	// MSize is the maximum 9P message size that the guest negotiates for the
+	// mount. Larger messages reduce the round trips of large reads and
+	// writes. 0 uses the guest default.
+	MSize uint32 `json:"MSize,omitempty"`
+	// Cache is the v9fs cache mode of the mount, one of the Plan9Cache
+	// values. Empty uses the guest default, which is no caching.
+	Cache Plan9Cache `json:"Cache,omitempty"`
+}
+
+// Plan9Cache is a v9fs cache mode.
+type Plan9Cache string
+
+const (
+	// Plan9CacheNone caches nothing, which is coherent with changes made on
+	// the host.
+	Plan9CacheNone Plan9Cache = "none"
+	// Plan9CacheLoose caches data and metadata in the guest without checking
+	// for changes made on the host.
+	Plan9CacheLoose Plan9Cache = "loose"
+	// Plan9CacheMmap caches only the data of memory mapped files.
+	Plan9CacheMmap Plan9Cache = "mmap"
+)
+
+// LCOWMappedDirectoryChanges relays changes to the host directory of the
+// Plan9 share mounted at `MountPath`, so that the guest can generate inotify
+// events for them.
+type LCOWMappedDirectoryChanges struct {
+	MountPath string           `json:"MountPath,omitempty"`
+	Changes   []LCOWFileChange `json:"Changes,omitempty"`
 }
 
+// LCOWFileChange is a change to the file at `Path`, relative to the share
+// root with forward slashes.
+type LCOWFileChange struct {
+	Path   string           `json:"Path,omitempty"`
+	Action FileChangeAction `json:"Action,omitempty"`
+}
+
+// FileChangeAction is the kind of a LCOWFileChange.
+type FileChangeAction string
+
+const (
+	FileChangeAdded    FileChangeAction = "Added"
+	FileChangeRemoved  FileChangeAction = "Removed"
+	FileChangeModified FileChangeAction = "Modified"
+	// FileChangeOverflow is sent with an empty path when changes were lost
+	// and any file in the share may have changed.
+	FileChangeOverflow FileChangeAction = "Overflow"
+)
+
 // Read-only layers over VPMem
 type LCOWMappedVPMemDevice struct {
 	DeviceNumber uint32 `json:"DeviceNumber,omitempty"`
@@ -64,6 +128,97 @@ type LCOWNetworkAdapter struct {
 	DNSServerList   string `json:",omitempty"`
 	EnableLowMetric bool   `json:",omitempty"`
 	EncapOverhead   uint16 `json:",omitempty"`
+	// InterfaceName is the name the guest should give the adapter's network
+	// interface. It is derived from `ID` so that it is stable regardless of
+	// the order in which the guest probes devices.
+	InterfaceName string `json:",omitempty"`
+	// IPv6 configuration of the adapter. `DNSServerList` may contain both IPv4
+	// and IPv6 servers.
+	IPv6Address        string `json:",omitempty"`
+	IPv6PrefixLength   uint8  `json:",omitempty"`
+	IPv6GatewayAddress string `json:",omitempty"`
+	// DisableIPv6RouterAdvertisements stops the guest from accepting IPv6
+	// router advertisements on the adapter so that only the static IPv6
+	// configuration above is used.
+	DisableIPv6RouterAdvertisements bool `json:",omitempty"`
+	// HostDNSProxyPort is the vsock port of the host DNS proxy. If set the
+	// guest should point its stub resolver at the proxy instead of using
+	// `DNSServerList` directly.
+	HostDNSProxyPort uint32 `json:",omitempty"`
+	// SecondaryIPAddresses are additional addresses (and their subnets) to
+	// configure on the adapter alongside `IPAddress` and `IPv6Address`. Used
+	// for VIP and alias IP configurations.
+	SecondaryIPAddresses []LCOWIPAddress `json:",omitempty"`
+	// VlanID and VSID are the VLAN and virtual subnet tags that the host
+	// applies to the adapter's traffic. The guest uses these to verify the
+	// adapter is attached to the expected tenant network. `0` means untagged.
+	VlanID uint32 `json:",omitempty"`
+	VSID   uint32 `json:",omitempty"`
+	// EnableDHCP requests that the guest acquire the adapter's address,
+	// gateway and DNS settings with a DHCP client rather than using the static
+	// configuration above.
+	EnableDHCP bool `json:",omitempty"`
+}
+
+// LCOWIPAddress is an IPv4 or IPv6 address and its prefix length.
+type LCOWIPAddress struct {
+	IPAddress    string `json:",omitempty"`
+	PrefixLength uint8  `json:",omitempty"`
+}
+
+// LCOWPortForward asks the guest to relay connections made to the host
+// loopback address on `Port` to `Port` on localhost in the guest. The guest
+// keeps a connection to the vsock port `VsockPort` open for the host to take.
+type LCOWPortForward struct {
+	Port      uint16 `json:",omitempty"`
+	VsockPort uint32 `json:",omitempty"`
+}
+
+// LCOWHvSocketFirewall asks the guest to refuse the inbound hvsocket
+// connections to any service ID other than `AllowedServiceIDs`. The
+// connections that the guest makes to the host, such as the GCS bridge and
+// process IO, are not affected. An empty list refuses all inbound connections.
+type LCOWHvSocketFirewall struct {
+	AllowedServiceIDs []string `json:"AllowedServiceIDs"`
+}
+
+// LCOWFilesystemFreeze asks the guest to freeze the file systems mounted at
+// `MountPaths` (as fsfreeze does), so that the disks backing them are
+// crash-consistent until they are thawed. The guest thaws them by itself after
+// `TimeoutInSeconds`, so that a host that fails to thaw them does not block the
+// writes of the containers indefinitely.
+type LCOWFilesystemFreeze struct {
+	MountPaths       []string `json:"MountPaths"`
+	TimeoutInSeconds uint32   `json:",omitempty"`
+}
+
+// LCOWFilesystemTrim asks the guest to discard the unused blocks of the file
+// systems mounted at `MountPaths` (as fstrim does), so that the host can
+// reclaim the space of the disks backing them.
+type LCOWFilesystemTrim struct {
+	MountPaths []string `json:"MountPaths"`
+}
+
+// LCOWBinfmt asks the guest to register `Interpreters` with binfmt_misc, so
+// that the binaries of foreign architectures run through them. They are
+// registered with the fix binary flag, so that containers use them without
+// having the interpreters in their root file systems.
+type LCOWBinfmt struct {
+	Interpreters []LCOWBinfmtInterpreter `json:"Interpreters"`
+}
+
+// LCOWBinfmtInterpreter is the interpreter at `Path` in the guest for the
+// binaries of the qemu-user architecture `Architecture`, such as "aarch64".
+type LCOWBinfmtInterpreter struct {
+	Architecture string `json:",omitempty"`
+	Path         string `json:",omitempty"`
+}
+
+// LCOWContainerConstraints updates the resource constraints of a running
+// container in the guest.
+type LCOWContainerConstraints struct {
+	Windows specs.WindowsResources `json:",omitempty"`
+	Linux   specs.LinuxResources   `json:",omitempty"`
 }
 
 type ResourceType string
@@ -78,6 +233,25 @@ const (
 	ResourceTypeVPMemDevice       ResourceType = "VPMemDevice"
 	ResourceTypeVPCIDevice        ResourceType = "VPCIDevice"
 	ResourceTypeHvSocket          ResourceType = "HvSocket"
+	ResourceTypePortForward       ResourceType = "PortForward"
+	// ResourceTypeMappedDirectoryChanges is an update request with the
+	// changes to the host directory of a mapped directory.
+	ResourceTypeMappedDirectoryChanges ResourceType = "MappedDirectoryChanges"
+	// ResourceTypeHvSocketFirewall is an add request with the hvsocket
+	// services that the guest accepts inbound connections to.
+	ResourceTypeHvSocketFirewall ResourceType = "HvSocketFirewall"
+	// ResourceTypeFilesystemFreeze is an add request that freezes file
+	// systems in the guest, and a remove request that thaws them.
+	ResourceTypeFilesystemFreeze ResourceType = "FilesystemFreeze"
+	// ResourceTypeFilesystemTrim is an update request that trims file systems
+	// in the guest.
+	ResourceTypeFilesystemTrim ResourceType = "FilesystemTrim"
+	// ResourceTypeBinfmt is an add request with the binfmt interpreters that
+	// the guest registers.
+	ResourceTypeBinfmt ResourceType = "Binfmt"
+	// ResourceTypeContainerConstraints is a modify request sent to a
+	// container, not the UVM.
+	ResourceTypeContainerConstraints ResourceType = "ContainerConstraints"
 )
 
 // GuestRequest is for modify commands passed to the guest.
@@ -120,3 +294,31 @@ const (
 type SignalProcessOptionsWCOW struct {
 	Signal SignalValueWCOW `json:",omitempty"`
 }
+
+// NetworkPolicyAction is the action the guest takes for egress traffic
+// matching a NetworkPolicy.
+type NetworkPolicyAction string
+
+const (
+	NetworkPolicyActionAllow NetworkPolicyAction = "allow"
+	NetworkPolicyActionDeny  NetworkPolicyAction = "deny"
+)
+
+// NetworkPolicy is a declarative egress policy programmed by the LCOW guest
+// into the network namespace of a container.
+type NetworkPolicy struct {
+	// DefaultAction is applied to egress traffic that does not match any of
+	// `EgressRules`.
+	DefaultAction NetworkPolicyAction `json:",omitempty"`
+	// EgressRules are the destinations that this container is allowed to
+	// reach when `DefaultAction` is deny.
+	EgressRules []NetworkPolicyRule `json:",omitempty"`
+}
+
+// NetworkPolicyRule matches egress traffic to `CIDR`. If `Protocol` is empty
+// all protocols match, if `Ports` is empty all ports match.
+type NetworkPolicyRule struct {
+	CIDR     string   `json:",omitempty"`
+	Protocol string   `json:",omitempty"` // "tcp" or "udp"
+	Ports    []uint16 `json:",omitempty"`
+}
@@ -90,6 +90,8 @@ type notificationChannel chan error
 type notifcationWatcherContext struct {
 	channels notificationChannels
 	handle   vmcompute.HcsCallback
+	// observer, if set, is called with every notification received.
+	observer func(hcsNotification, error)
 
 	systemID  string
 	processID int
@@ -153,6 +155,9 @@ func notificationWatcher(notificationType hcsNotification, callbackNumber uintpt
 	}
 	log.Debug("HCS notification")
 
+	if context.observer != nil {
+		context.observer(notificationType, result)
+	}
 	if channel, ok := context.channels[notificationType]; ok {
 		channel <- result
 	}
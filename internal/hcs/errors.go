@@ -73,6 +73,11 @@ var (
 	// ErrVmcomputeUnknownMessage is an error encountered guest compute system doesn't support the message
 	ErrVmcomputeUnknownMessage = syscall.Errno(0xc037010b)
 
+	// ErrNoSystemResources is an error encountered when the host does not have
+	// the resources for the request, such as the large pages to back the
+	// memory of a VM with
+	ErrNoSystemResources = syscall.Errno(0x5aa)
+
 	// ErrVmcomputeUnexpectedExit is an error encountered when the compute system terminates unexpectedly
 	ErrVmcomputeUnexpectedExit = syscall.Errno(0xC0370106)
 
@@ -155,6 +160,10 @@ func (e *HcsError) Timeout() bool {
 	return ok && err.Timeout()
 }
 
+func (e *HcsError) Unwrap() error {
+	return e.Err
+}
+
 // ProcessError is an error encountered in HCS during an operation on a Process object
 type ProcessError struct {
 	SystemID string
@@ -198,6 +207,10 @@ func (e *SystemError) Timeout() bool {
 	return ok && err.Timeout()
 }
 
+func (e *SystemError) Unwrap() error {
+	return e.Err
+}
+
 func makeSystemError(system *System, op string, extra string, err error, events []ErrorEvent) error {
 	// Don't double wrap errors
 	if _, ok := err.(*SystemError); ok {
@@ -230,6 +243,10 @@ func (e *ProcessError) Timeout() bool {
 	return ok && err.Timeout()
 }
 
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
 func makeProcessError(process *Process, op string, err error, events []ErrorEvent) error {
 	// Don't double wrap errors
 	if _, ok := err.(*ProcessError); ok {
@@ -319,6 +336,13 @@ func IsAccessIsDenied(err error) bool {
 	return err == ErrVmcomputeOperationAccessIsDenied
 }
 
+// IsNoSystemResources returns true when err is caused by
+// `ErrNoSystemResources`.
+func IsNoSystemResources(err error) bool {
+	err = getInnerError(err)
+	return err == ErrNoSystemResources
+}
+
 func getInnerError(err error) error {
 	switch pe := err.(type) {
 	case nil:
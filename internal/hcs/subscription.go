@@ -0,0 +1,126 @@
+package hcs
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationType is the type of a compute system notification.
+type NotificationType string
+
+const (
+	// NotificationSystemExited is sent when the compute system exits.
+	NotificationSystemExited NotificationType = "SystemExited"
+	// NotificationGuestConnectionClosed is sent when the connection to the
+	// guest of the compute system is lost.
+	NotificationGuestConnectionClosed NotificationType = "GuestConnectionClosed"
+	// NotificationResourceModified is sent when a modify of the compute
+	// system succeeds.
+	NotificationResourceModified NotificationType = "ResourceModified"
+	// NotificationCrashInitiated is sent when the guest of the compute system
+	// crashes.
+	NotificationCrashInitiated NotificationType = "CrashInitiated"
+	// NotificationServiceDisconnect is sent when the connection to the HCS is
+	// lost.
+	NotificationServiceDisconnect NotificationType = "ServiceDisconnect"
+)
+
+// subscriptionBufferSize is the number of notifications queued for a
+// subscriber before new ones are dropped.
+const subscriptionBufferSize = 16
+
+// Notification is a notification received for a compute system.
+type Notification struct {
+	Type     NotificationType
+	SystemID string
+	// Err is the status of the notification, if it reported a failure.
+	Err error
+}
+
+// Subscription receives the notifications of a compute system.
+type Subscription struct {
+	system *System
+	events chan Notification
+}
+
+// Events returns the channel the notifications are delivered on. The channel
+// is closed when the subscription or the compute system is closed. If the
+// subscriber does not keep up notifications are dropped rather than blocking
+// the compute system.
+func (s *Subscription) Events() <-chan Notification {
+	return s.events
+}
+
+// Close stops the delivery of notifications and closes the events channel.
+func (s *Subscription) Close() {
+	s.system.subscribersLock.Lock()
+	defer s.system.subscribersLock.Unlock()
+	if _, ok := s.system.subscribers[s]; ok {
+		delete(s.system.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Subscribe returns a subscription to the exit, guest connection and modify
+// notifications of the compute system, so that callers don't have to poll
+// its properties.
+func (computeSystem *System) Subscribe() *Subscription {
+	s := &Subscription{
+		system: computeSystem,
+		events: make(chan Notification, subscriptionBufferSize),
+	}
+	computeSystem.subscribersLock.Lock()
+	defer computeSystem.subscribersLock.Unlock()
+	if computeSystem.subscribersClosed {
+		close(s.events)
+		return s
+	}
+	if computeSystem.subscribers == nil {
+		computeSystem.subscribers = make(map[*Subscription]struct{})
+	}
+	computeSystem.subscribers[s] = struct{}{}
+	return s
+}
+
+// notificationTypes maps the HCS notifications that are delivered to
+// subscribers to their NotificationType.
+var notificationTypes = map[hcsNotification]NotificationType{
+	hcsNotificationSystemExited:                NotificationSystemExited,
+	hcsNotificationSystemGuestConnectionClosed: NotificationGuestConnectionClosed,
+	hcsNotificationSystemCrashInitiated:        NotificationCrashInitiated,
+	hcsNotificationServiceDisconnect:           NotificationServiceDisconnect,
+}
+
+// observeNotification is called from the HCS notification callback and must
+// not block.
+func (computeSystem *System) observeNotification(hn hcsNotification, result error) {
+	if t, ok := notificationTypes[hn]; ok {
+		computeSystem.publish(Notification{Type: t, SystemID: computeSystem.id, Err: result})
+	}
+}
+
+func (computeSystem *System) publish(n Notification) {
+	computeSystem.subscribersLock.Lock()
+	defer computeSystem.subscribersLock.Unlock()
+	for s := range computeSystem.subscribers {
+		select {
+		case s.events <- n:
+		default:
+			logrus.WithFields(logrus.Fields{
+				"system-id":         computeSystem.id,
+				"notification-type": string(n.Type),
+			}).Warning("dropped notification for slow subscriber")
+		}
+	}
+}
+
+// closeSubscribers closes the events channel of every subscriber. Subscribe
+// calls made afterwards return closed subscriptions.
+func (computeSystem *System) closeSubscribers() {
+	computeSystem.subscribersLock.Lock()
+	defer computeSystem.subscribersLock.Unlock()
+	for s := range computeSystem.subscribers {
+		close(s.events)
+	}
+	computeSystem.subscribers = nil
+	computeSystem.subscribersClosed = true
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/cow"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"github.com/Microsoft/hcsshim/internal/schema1"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/timeout"
@@ -29,6 +30,12 @@ type System struct {
 	waitError      error
 	exitError      error
 	os, typ        string
+
+	// subscribers receive the notifications of the compute system, see
+	// Subscribe.
+	subscribersLock   sync.Mutex
+	subscribers       map[*Subscription]struct{}
+	subscribersClosed bool
 }
 
 func newSystem(id string) *System {
@@ -99,7 +106,14 @@ func OpenComputeSystem(ctx context.Context, id string) (*System, error) {
 	operation := "hcsshim::OpenComputeSystem"
 
 	computeSystem := newSystem(id)
-	handle, resultJSON, err := vmcompute.HcsOpenComputeSystem(ctx, id)
+	var (
+		handle     vmcompute.HcsSystem
+		resultJSON string
+	)
+	err := retry.Do(ctx, operation, func() (err error) {
+		handle, resultJSON, err = vmcompute.HcsOpenComputeSystem(ctx, id)
+		return err
+	})
 	events := processHcsResult(ctx, resultJSON)
 	if err != nil {
 		return nil, makeSystemError(computeSystem, operation, "", err, events)
@@ -146,6 +160,38 @@ func (computeSystem *System) IsOCI() bool {
 	return computeSystem.os == "linux" && computeSystem.typ == "container"
 }
 
+// The compute system types used to filter the query of GetComputeSystems.
+const (
+	SystemTypeContainer      = "Container"
+	SystemTypeVirtualMachine = "VirtualMachine"
+)
+
+// ListComputeSystems returns the compute systems of type `systemType` owned by
+// one of `owners`. If no owners are given the systems of every owner are
+// returned.
+//
+// An owner also matches the owner labels that the shim creates its compute
+// systems with, `<owner>;pid=<pid>;start=<start>`, so the systems are filtered
+// here rather than by the exact match of the HCS query.
+func ListComputeSystems(ctx context.Context, systemType string, owners ...string) ([]schema1.ContainerProperties, error) {
+	systems, err := GetComputeSystems(ctx, schema1.ComputeSystemQuery{
+		Types: []string{systemType},
+	})
+	if err != nil || len(owners) == 0 {
+		return systems, err
+	}
+	matched := []schema1.ContainerProperties{}
+	for _, s := range systems {
+		for _, owner := range owners {
+			if s.Owner == owner || strings.HasPrefix(s.Owner, owner+";") {
+				matched = append(matched, s)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
 // GetComputeSystems gets a list of the compute systems on the system that match the query
 func GetComputeSystems(ctx context.Context, q schema1.ComputeSystemQuery) ([]schema1.ContainerProperties, error) {
 	operation := "hcsshim::GetComputeSystems"
@@ -155,7 +201,11 @@ func GetComputeSystems(ctx context.Context, q schema1.ComputeSystemQuery) ([]sch
 		return nil, err
 	}
 
-	computeSystemsJSON, resultJSON, err := vmcompute.HcsEnumerateComputeSystems(ctx, string(queryb))
+	var computeSystemsJSON, resultJSON string
+	err = retry.Do(ctx, operation, func() (err error) {
+		computeSystemsJSON, resultJSON, err = vmcompute.HcsEnumerateComputeSystems(ctx, string(queryb))
+		return err
+	})
 	events := processHcsResult(ctx, resultJSON)
 	if err != nil {
 		return nil, &HcsError{Op: operation, Err: err, Events: events}
@@ -307,7 +357,11 @@ func (computeSystem *System) Properties(ctx context.Context, types ...schema1.Pr
 		return nil, makeSystemError(computeSystem, operation, "", err, nil)
 	}
 
-	propertiesJSON, resultJSON, err := vmcompute.HcsGetComputeSystemProperties(ctx, computeSystem.handle, string(queryBytes))
+	var propertiesJSON, resultJSON string
+	err = retry.Do(ctx, operation, func() (err error) {
+		propertiesJSON, resultJSON, err = vmcompute.HcsGetComputeSystemProperties(ctx, computeSystem.handle, string(queryBytes))
+		return err
+	})
 	events := processHcsResult(ctx, resultJSON)
 	if err != nil {
 		return nil, makeSystemError(computeSystem, operation, "", err, events)
@@ -336,7 +390,11 @@ func (computeSystem *System) PropertiesV2(ctx context.Context, types ...hcsschem
 		return nil, makeSystemError(computeSystem, operation, "", err, nil)
 	}
 
-	propertiesJSON, resultJSON, err := vmcompute.HcsGetComputeSystemProperties(ctx, computeSystem.handle, string(queryBytes))
+	var propertiesJSON, resultJSON string
+	err = retry.Do(ctx, operation, func() (err error) {
+		propertiesJSON, resultJSON, err = vmcompute.HcsGetComputeSystemProperties(ctx, computeSystem.handle, string(queryBytes))
+		return err
+	})
 	events := processHcsResult(ctx, resultJSON)
 	if err != nil {
 		return nil, makeSystemError(computeSystem, operation, "", err, events)
@@ -549,6 +607,7 @@ func (computeSystem *System) Close() (err error) {
 		computeSystem.waitError = ErrAlreadyClosed
 		close(computeSystem.waitBlock)
 	})
+	computeSystem.closeSubscribers()
 
 	return nil
 }
@@ -557,6 +616,7 @@ func (computeSystem *System) registerCallback(ctx context.Context) error {
 	callbackContext := &notifcationWatcherContext{
 		channels: newSystemChannels(),
 		systemID: computeSystem.id,
+		observer: computeSystem.observeNotification,
 	}
 
 	callbackMapLock.Lock()
@@ -633,5 +693,6 @@ func (computeSystem *System) Modify(ctx context.Context, config interface{}) err
 		return makeSystemError(computeSystem, operation, requestJSON, err, events)
 	}
 
+	computeSystem.publish(Notification{Type: NotificationResourceModified, SystemID: computeSystem.id})
 	return nil
 }
@@ -28,6 +28,10 @@ func (e *HcsError) Error() string {
 	return s
 }
 
+func (e *HcsError) Unwrap() error {
+	return e.Err
+}
+
 func New(err error, title, rest string) error {
 	// Pass through DLL errors directly since they do not originate from HCS.
 	if _, ok := err.(*syscall.DLLError); ok {
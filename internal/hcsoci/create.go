@@ -230,6 +230,10 @@ func configureSandboxNetwork(ctx context.Context, coi *createOptionsInternal, r
 		// container but not a workload container in a sandbox that inherits
 		// the namespace.
 		if ct == oci.KubernetesContainerTypeNone || ct == oci.KubernetesContainerTypeSandbox {
+			// A namespace that fails to be configured part way is removed
+			// from the UVM by ConfigureNetworking itself, so the teardown is
+			// only registered, with the resources that the "release container
+			// resources" step rolls back, once the setup succeeded.
 			if err := coi.HostingSystem.ConfigureNetworking(ctx, coi.actualNetworkNamespace); err != nil {
 				// No network setup type was specified for this UVM. Create and assign one here unless
 				// we received a different error.
@@ -268,10 +272,14 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 	}
 
 	r := resources.NewContainerResources(createOptions.ID)
+	tx := &createTransaction{}
+	tx.onRollback("release container resources", func(ctx context.Context) error {
+		return resources.ReleaseResources(ctx, r, coi.HostingSystem, true)
+	})
 	defer func() {
 		if err != nil {
 			if !coi.DoNotReleaseResourcesOnFailure {
-				_ = resources.ReleaseResources(ctx, r, coi.HostingSystem, true)
+				_ = tx.rollback(ctx)
 			}
 		}
 	}()
@@ -385,10 +393,14 @@ func CloneContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.Co
 	}
 
 	r := resources.NewContainerResources(createOptions.ID)
+	tx := &createTransaction{}
+	tx.onRollback("release container resources", func(ctx context.Context) error {
+		return resources.ReleaseResources(ctx, r, coi.HostingSystem, true)
+	})
 	defer func() {
 		if err != nil {
 			if !coi.DoNotReleaseResourcesOnFailure {
-				_ = resources.ReleaseResources(ctx, r, coi.HostingSystem, true)
+				_ = tx.rollback(ctx)
 			}
 		}
 	}()
@@ -415,6 +427,10 @@ func CloneContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.Co
 	if err != nil {
 		return nil, r, err
 	}
+	tx.onRollback("close cloned container", func(ctx context.Context) error {
+		_ = c.Terminate(ctx)
+		return c.Close()
+	})
 
 	// Everything that is usually added to the container during the createContainer
 	// request (via the gcsDocument) must be hot added here.
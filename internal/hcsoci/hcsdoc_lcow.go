@@ -5,14 +5,29 @@ package hcsoci
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
 
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oci"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 )
 
-func createLCOWSpec(coi *createOptionsInternal) (*specs.Spec, error) {
+const (
+	// tpmDevicePath is the TPM device of an LCOW guest. The TPM character
+	// device is always the misc device 10:224.
+	tpmDevicePath  = "/dev/tpm0"
+	tpmDeviceMajor = 10
+	tpmDeviceMinor = 224
+)
+
+func createLCOWSpec(ctx context.Context, coi *createOptionsInternal) (*specs.Spec, error) {
 	// Remarshal the spec to perform a deep copy.
 	j, err := json.Marshal(coi.Spec)
 	if err != nil {
@@ -32,8 +47,76 @@ func createLCOWSpec(coi *createOptionsInternal) (*specs.Spec, error) {
 		setWindowsDevices(coi, spec)
 	}
 
-	// Hooks are not supported (they should be run in the host)
-	spec.Hooks = nil
+	// Hand the guest the validated form of any network policy so that it
+	// doesn't have to deal with malformed input.
+	policy, err := oci.ParseAnnotationsNetworkPolicy(coi.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		j, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		spec.Annotations[oci.AnnotationNetworkPolicy] = string(j)
+	}
+
+	// Only forward egress shaping values that parsed so the guest doesn't
+	// need to handle malformed input.
+	delete(spec.Annotations, oci.AnnotationNetworkEgressBandwidth)
+	delete(spec.Annotations, oci.AnnotationNetworkEgressBurst)
+	if bandwidth, burst := oci.ParseAnnotationsNetworkEgressShaping(ctx, coi.Spec); bandwidth != 0 {
+		spec.Annotations[oci.AnnotationNetworkEgressBandwidth] = strconv.FormatUint(bandwidth, 10)
+		if burst != 0 {
+			spec.Annotations[oci.AnnotationNetworkEgressBurst] = strconv.FormatUint(burst, 10)
+		}
+	}
+
+	// Block IO limits are applied by the guest to the container's scratch and
+	// volume devices. `spec.Linux.Resources.BlockIO` refers to host device
+	// numbers that mean nothing in the guest so only the storage QoS
+	// annotations are forwarded, and only if they parsed.
+	if spec.Annotations == nil {
+		spec.Annotations = make(map[string]string)
+	}
+	delete(spec.Annotations, oci.AnnotationContainerStorageQoSIopsMaximum)
+	delete(spec.Annotations, oci.AnnotationContainerStorageQoSBandwidthMaximum)
+	if iops := oci.ParseAnnotationsStorageIops(ctx, coi.Spec, oci.AnnotationContainerStorageQoSIopsMaximum, 0); iops > 0 {
+		spec.Annotations[oci.AnnotationContainerStorageQoSIopsMaximum] = strconv.FormatInt(int64(iops), 10)
+	}
+	if bps := oci.ParseAnnotationsStorageBps(ctx, coi.Spec, oci.AnnotationContainerStorageQoSBandwidthMaximum, 0); bps > 0 {
+		spec.Annotations[oci.AnnotationContainerStorageQoSBandwidthMaximum] = strconv.FormatInt(int64(bps), 10)
+	}
+
+	// The core scheduling cookie comes from the settings of the UVM, never
+	// from the container, so that an untrusted container can't join the group
+	// of another. A guest without support would silently run the container
+	// without isolation, so fail instead.
+	delete(spec.Annotations, oci.AnnotationContainerProcessorCoreScheduling)
+	if cookie := coi.HostingSystem.CoreSchedulingCookie(spec.Annotations[oci.KubernetesContainerNameAnnotation]); cookie != "" {
+		if !coi.HostingSystem.CoreSchedulingSupported() {
+			return nil, errors.New("core scheduling is not supported by the guest")
+		}
+		spec.Annotations[oci.AnnotationContainerProcessorCoreScheduling] = cookie
+	}
+
+	if err := validateLCOWCPUSet(coi, spec); err != nil {
+		return nil, err
+	}
+
+	if err := oci.NormalizeProcessEncoding(ctx, spec.Process); err != nil {
+		return nil, err
+	}
+
+	// Hooks are only run by the guest from the UVM's hooks path. Otherwise
+	// they are not supported (they should be run in the host).
+	if err := validateLCOWHooks(coi, spec); err != nil {
+		return nil, err
+	}
+
+	if err := setLCOWReadOnlyRootfs(ctx, coi, spec); err != nil {
+		return nil, err
+	}
 
 	// Clear unsupported features
 	spec.Linux.CgroupsPath = "" // GCS controls its cgroups hierarchy on its own.
@@ -46,9 +129,178 @@ func createLCOWSpec(coi *createOptionsInternal) (*specs.Spec, error) {
 	}
 	spec.Linux.Seccomp = nil
 
+	// The device cgroup rules of the host were cleared above, so this must
+	// come after for the rule that allows the TPM to be kept.
+	if err := setLCOWTPMDevice(ctx, coi, spec); err != nil {
+		return nil, err
+	}
+
 	return spec, nil
 }
 
+// validateLCOWHooks checks that every hook of the container is in the hooks
+// path of the UVM, so that the guest only runs approved binaries, and clears
+// the hooks if the UVM does not run hooks.
+func validateLCOWHooks(coi *createOptionsInternal, spec *specs.Spec) error {
+	if spec.Hooks == nil {
+		return nil
+	}
+	hooksPath := ""
+	if coi.HostingSystem != nil {
+		hooksPath = coi.HostingSystem.OCIHooksPath()
+	}
+	if hooksPath == "" {
+		spec.Hooks = nil
+		return nil
+	}
+	hooksPath = path.Clean(hooksPath)
+	for _, hooks := range [][]specs.Hook{
+		spec.Hooks.Prestart,
+		spec.Hooks.CreateRuntime,
+		spec.Hooks.CreateContainer,
+		spec.Hooks.StartContainer,
+		spec.Hooks.Poststart,
+		spec.Hooks.Poststop,
+	} {
+		for _, h := range hooks {
+			p := path.Clean(h.Path)
+			if !path.IsAbs(p) || path.Dir(p) != hooksPath {
+				return fmt.Errorf("hook %s is not in the UVM hooks path %s", h.Path, hooksPath)
+			}
+		}
+	}
+	return nil
+}
+
+// setLCOWReadOnlyRootfs makes the root file system of the container read-only
+// if its UVM requires it or if it asks for it with
+// `oci.AnnotationContainerRootfsReadOnly`.
+func setLCOWReadOnlyRootfs(ctx context.Context, coi *createOptionsInternal, spec *specs.Spec) error {
+	required := coi.HostingSystem != nil && coi.HostingSystem.ReadOnlyRootfs()
+	return setReadOnlyRootfs(ctx, coi.Spec, spec, required)
+}
+
+// setReadOnlyRootfs makes the root file system of `spec` read-only if
+// `required` is set or if `s` asks for it, and mounts a tmpfs of the writable
+// path size on each of the writable paths of `s` that `spec` does not already
+// mount so that the container can still write to them.
+func setReadOnlyRootfs(ctx context.Context, s, spec *specs.Spec, required bool) error {
+	if !required && !oci.ParseAnnotationsRootfsReadOnly(ctx, s) {
+		return nil
+	}
+	paths, err := oci.ParseAnnotationsRootfsWritablePaths(s)
+	if err != nil {
+		return err
+	}
+	size, err := oci.ParseAnnotationsRootfsWritablePathSize(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if spec.Root == nil {
+		spec.Root = &specs.Root{}
+	}
+	spec.Root.Readonly = true
+
+	mounted := make(map[string]bool)
+	for _, m := range spec.Mounts {
+		mounted[path.Clean(m.Destination)] = true
+	}
+	for _, p := range paths {
+		p = path.Clean(p)
+		if mounted[p] {
+			continue
+		}
+		mounted[p] = true
+		spec.Mounts = append(spec.Mounts, specs.Mount{
+			Destination: p,
+			Type:        "tmpfs",
+			Source:      "tmpfs",
+			Options:     []string{"nosuid", "nodev", "mode=1777", fmt.Sprintf("size=%d", size)},
+		})
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"writablePaths": paths,
+		"size":          size,
+	}).Debug("read-only root file system")
+	return nil
+}
+
+// setLCOWTPMDevice adds the TPM of the UVM to the devices of the container as
+// /dev/tpm0, and allows it in the device cgroup of the container, if it asks
+// for it with `oci.AnnotationContainerTPM`.
+func setLCOWTPMDevice(ctx context.Context, coi *createOptionsInternal, spec *specs.Spec) error {
+	if !oci.ParseAnnotationsTPM(ctx, coi.Spec) {
+		return nil
+	}
+	if coi.HostingSystem == nil || !coi.HostingSystem.TPMEnabled() {
+		return errors.New("the container requests a TPM but its UVM does not have one")
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &specs.LinuxResources{}
+	}
+	major, minor := int64(tpmDeviceMajor), int64(tpmDeviceMinor)
+	spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, specs.LinuxDeviceCgroup{
+		Allow:  true,
+		Type:   "c",
+		Major:  &major,
+		Minor:  &minor,
+		Access: "rwm",
+	})
+	for _, d := range spec.Linux.Devices {
+		if d.Path == tpmDevicePath {
+			return nil
+		}
+	}
+	mode := os.FileMode(0660)
+	var id uint32
+	spec.Linux.Devices = append(spec.Linux.Devices, specs.LinuxDevice{
+		Path:     tpmDevicePath,
+		Type:     "c",
+		Major:    tpmDeviceMajor,
+		Minor:    tpmDeviceMinor,
+		FileMode: &mode,
+		UID:      &id,
+		GID:      &id,
+	})
+	return nil
+}
+
+// validateLCOWCPUSet checks that the container's cpuset only names vCPUs and
+// memory nodes that exist in the UVM, so that a bad cpuset fails here rather
+// than when the guest applies it to the container's cgroup.
+func validateLCOWCPUSet(coi *createOptionsInternal, spec *specs.Spec) error {
+	if coi.HostingSystem == nil || spec.Linux.Resources == nil || spec.Linux.Resources.CPU == nil {
+		return nil
+	}
+	cpu := spec.Linux.Resources.CPU
+	if cpu.Cpus != "" {
+		cpus, err := oci.ParseCPUSet(cpu.Cpus)
+		if err != nil {
+			return err
+		}
+		count := uint32(coi.HostingSystem.ProcessorCount())
+		for _, c := range cpus {
+			if c >= count {
+				return fmt.Errorf("cpuset cpu %d does not exist in UVM %s with %d processors", c, coi.HostingSystem.ID(), count)
+			}
+		}
+	}
+	if cpu.Mems != "" {
+		mems, err := oci.ParseCPUSet(cpu.Mems)
+		if err != nil {
+			return err
+		}
+		nodes := coi.HostingSystem.NUMANodeCount()
+		for _, m := range mems {
+			if m >= nodes {
+				return fmt.Errorf("cpuset memory node %d does not exist in UVM %s with %d NUMA nodes", m, coi.HostingSystem.ID(), nodes)
+			}
+		}
+	}
+	return nil
+}
+
 func setWindowsNetworkNamespace(coi *createOptionsInternal, spec *specs.Spec) {
 	if coi.Spec.Windows.Network != nil &&
 		coi.Spec.Windows.Network.NetworkNamespace != "" {
@@ -77,7 +329,7 @@ type linuxHostedSystem struct {
 }
 
 func createLinuxContainerDocument(ctx context.Context, coi *createOptionsInternal, guestRoot string) (*linuxHostedSystem, error) {
-	spec, err := createLCOWSpec(coi)
+	spec, err := createLCOWSpec(ctx, coi)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,95 @@
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func tmpfsMount(destination string, size uint64) specs.Mount {
+	return specs.Mount{
+		Destination: destination,
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     []string{"nosuid", "nodev", "mode=1777", fmt.Sprintf("size=%d", size)},
+	}
+}
+
+func Test_SetReadOnlyRootfs_NotRequested(t *testing.T) {
+	s := &specs.Spec{}
+	spec := &specs.Spec{Root: &specs.Root{Path: "rootfs"}}
+	if err := setReadOnlyRootfs(context.Background(), s, spec, false); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if spec.Root.Readonly || len(spec.Mounts) != 0 {
+		t.Fatalf("expected the spec to be unchanged, got root %+v and mounts %+v", spec.Root, spec.Mounts)
+	}
+}
+
+func Test_SetReadOnlyRootfs_Required(t *testing.T) {
+	// The annotation of the container can not opt out of a required read-only
+	// root file system.
+	s := &specs.Spec{
+		Annotations: map[string]string{oci.AnnotationContainerRootfsReadOnly: "false"},
+	}
+	spec := &specs.Spec{}
+	if err := setReadOnlyRootfs(context.Background(), s, spec, true); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if spec.Root == nil || !spec.Root.Readonly {
+		t.Fatalf("expected a read-only root file system, got %+v", spec.Root)
+	}
+	expected := []specs.Mount{
+		tmpfsMount("/tmp", oci.DefaultWritablePathSizeInBytes),
+		tmpfsMount("/run", oci.DefaultWritablePathSizeInBytes),
+	}
+	if !reflect.DeepEqual(spec.Mounts, expected) {
+		t.Fatalf("expected mounts %+v, got %+v", expected, spec.Mounts)
+	}
+}
+
+func Test_SetReadOnlyRootfs_Annotations(t *testing.T) {
+	s := &specs.Spec{
+		Annotations: map[string]string{
+			oci.AnnotationContainerRootfsReadOnly:                "true",
+			oci.AnnotationContainerRootfsWritablePaths:           "/tmp, /var/cache/, /var/cache",
+			oci.AnnotationContainerRootfsWritablePathSizeInBytes: "1048576",
+		},
+	}
+	data := specs.Mount{Destination: "/tmp/", Type: "bind", Source: "/data"}
+	spec := &specs.Spec{Mounts: []specs.Mount{data}}
+	if err := setReadOnlyRootfs(context.Background(), s, spec, false); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if spec.Root == nil || !spec.Root.Readonly {
+		t.Fatalf("expected a read-only root file system, got %+v", spec.Root)
+	}
+	// The paths that the spec already mounts are kept, and each path is only
+	// mounted once.
+	expected := []specs.Mount{data, tmpfsMount("/var/cache", 1048576)}
+	if !reflect.DeepEqual(spec.Mounts, expected) {
+		t.Fatalf("expected mounts %+v, got %+v", expected, spec.Mounts)
+	}
+}
+
+func Test_SetReadOnlyRootfs_InvalidAnnotations(t *testing.T) {
+	for _, annotations := range []map[string]string{
+		{oci.AnnotationContainerRootfsWritablePaths: "tmp"},
+		{oci.AnnotationContainerRootfsWritablePathSizeInBytes: "0"},
+	} {
+		s := &specs.Spec{Annotations: annotations}
+		spec := &specs.Spec{}
+		if err := setReadOnlyRootfs(context.Background(), s, spec, true); err == nil {
+			t.Fatalf("expected an error for annotations %v", annotations)
+		}
+		if spec.Root != nil || len(spec.Mounts) != 0 {
+			t.Fatalf("expected the spec to be unchanged for annotations %v, got root %+v and mounts %+v", annotations, spec.Root, spec.Mounts)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/processorinfo"
 	"github.com/Microsoft/hcsshim/internal/schema1"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/timezone"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/Microsoft/hcsshim/internal/uvmfolder"
 	"github.com/Microsoft/hcsshim/internal/wclayer"
@@ -298,6 +299,10 @@ func createWindowsContainerDocument(ctx context.Context, coi *createOptionsInter
 		}
 	}
 
+	if err := setWCOWTimeZone(ctx, coi, v2Container); err != nil {
+		return nil, nil, err
+	}
+
 	if coi.Spec.Root == nil {
 		return nil, nil, fmt.Errorf("spec is invalid - root isn't populated")
 	}
@@ -407,3 +412,40 @@ func parseAssignedDevices(ctx context.Context, coi *createOptionsInternal, v2 *h
 	v2.AssignedDevices = v2AssignedDevices
 	return nil
 }
+
+// setWCOWTimeZone adds the registry changes that set the time zone of the
+// container to `v2Container`, if the container has a time zone. See
+// `oci.AnnotationContainerTimeZone`.
+func setWCOWTimeZone(ctx context.Context, coi *createOptionsInternal, v2Container *hcsschema.Container) error {
+	id := coi.Spec.Annotations[oci.AnnotationContainerTimeZone]
+	fromEnv := false
+	if id == "" && coi.Spec.Process != nil {
+		for _, e := range coi.Spec.Process.Env {
+			if strings.HasPrefix(e, "TZ=") {
+				id = strings.TrimPrefix(e, "TZ=")
+				fromEnv = true
+			}
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	values, err := timezone.RegistryValues(id)
+	if err != nil {
+		if fromEnv {
+			// TZ is also used in the POSIX form understood by the C runtime.
+			log.G(ctx).WithError(err).Debug("TZ is not a Windows time zone, using the default time zone")
+			return nil
+		}
+		return err
+	}
+	if coi.isV1Argon() || coi.isV1Xenon() {
+		log.G(ctx).WithField("timeZone", id).Warning("time zones are not supported for schema v1 containers, using the default time zone")
+		return nil
+	}
+	if v2Container.RegistryChanges == nil {
+		v2Container.RegistryChanges = &hcsschema.RegistryChanges{}
+	}
+	v2Container.RegistryChanges.AddValues = append(v2Container.RegistryChanges.AddValues, values...)
+	return nil
+}
@@ -0,0 +1,94 @@
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/oci"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/schemaversion"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// timeZoneKeyName returns the time zone set by the registry changes of `c`,
+// or "" if there is none.
+func timeZoneKeyName(c *hcsschema.Container) string {
+	if c.RegistryChanges == nil {
+		return ""
+	}
+	for _, v := range c.RegistryChanges.AddValues {
+		if v.Name == "TimeZoneKeyName" {
+			return v.StringValue
+		}
+	}
+	return ""
+}
+
+func Test_SetWCOWTimeZone(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		annotation string
+		env        []string
+		expected   string
+	}{
+		{name: "unset"},
+		{name: "annotation", annotation: "UTC", expected: "UTC"},
+		{name: "environment", env: []string{"TZ=UTC"}, expected: "UTC"},
+		{name: "annotation over environment", annotation: "UTC", env: []string{"TZ=Not A Time Zone"}, expected: "UTC"},
+		// TZ may be in the POSIX form, which is left to the C runtime.
+		{name: "posix environment", env: []string{"TZ=PST8PDT"}},
+	} {
+		s := &specs.Spec{
+			Windows: &specs.Windows{},
+			Process: &specs.Process{Env: tc.env},
+		}
+		if tc.annotation != "" {
+			s.Annotations = map[string]string{oci.AnnotationContainerTimeZone: tc.annotation}
+		}
+		coi := &createOptionsInternal{
+			CreateOptions:       &CreateOptions{Spec: s},
+			actualSchemaVersion: schemaversion.SchemaV21(),
+		}
+		c := &hcsschema.Container{}
+		if err := setWCOWTimeZone(context.Background(), coi, c); err != nil {
+			t.Fatalf("%s: should not have failed with error: %s", tc.name, err)
+		}
+		if actual := timeZoneKeyName(c); actual != tc.expected {
+			t.Fatalf("%s: expected time zone %q, got %q", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func Test_SetWCOWTimeZone_InvalidAnnotation(t *testing.T) {
+	s := &specs.Spec{
+		Windows:     &specs.Windows{},
+		Annotations: map[string]string{oci.AnnotationContainerTimeZone: "Not A Time Zone"},
+	}
+	coi := &createOptionsInternal{
+		CreateOptions:       &CreateOptions{Spec: s},
+		actualSchemaVersion: schemaversion.SchemaV21(),
+	}
+	if err := setWCOWTimeZone(context.Background(), coi, &hcsschema.Container{}); err == nil {
+		t.Fatal("expected an error for an unknown time zone")
+	}
+}
+
+func Test_SetWCOWTimeZone_SchemaV1(t *testing.T) {
+	s := &specs.Spec{
+		Windows:     &specs.Windows{},
+		Annotations: map[string]string{oci.AnnotationContainerTimeZone: "UTC"},
+	}
+	coi := &createOptionsInternal{
+		CreateOptions:       &CreateOptions{Spec: s},
+		actualSchemaVersion: schemaversion.SchemaV10(),
+	}
+	c := &hcsschema.Container{}
+	if err := setWCOWTimeZone(context.Background(), coi, c); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if c.RegistryChanges != nil {
+		t.Fatalf("expected no registry changes for a schema v1 container, got %+v", c.RegistryChanges)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/hns"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/ownership"
 	"github.com/Microsoft/hcsshim/internal/resources"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/sirupsen/logrus"
@@ -32,7 +33,10 @@ func createNetworkNamespace(ctx context.Context, coi *createOptionsInternal, r *
 	r.SetNetNS(netID)
 	r.SetCreatedNetNS(true)
 
-	endpoints := make([]string, 0)
+	// Track the namespace before adding any endpoints so that the ones that
+	// were added are removed if a later one fails.
+	endpoints := &uvm.NetworkEndpoints{EndpointIDs: make([]string, 0), Namespace: netID}
+	r.Add(endpoints)
 	for _, endpointID := range coi.Spec.Windows.Network.EndpointList {
 		err = hns.AddNamespaceEndpoint(netID, endpointID)
 		if err != nil {
@@ -42,8 +46,11 @@ func createNetworkNamespace(ctx context.Context, coi *createOptionsInternal, r *
 			"netID":      netID,
 			"endpointID": endpointID,
 		}).Info("added network endpoint to namespace")
-		endpoints = append(endpoints, endpointID)
+		endpoints.EndpointIDs = append(endpoints.EndpointIDs, endpointID)
+		// The record is only used to find orphans, so a failure is logged.
+		if err := ownership.ClaimEndpoint(endpointID, coi.actualOwner); err != nil {
+			log.G(ctx).WithError(err).WithField("endpointID", endpointID).Warning("failed to record the owner of the endpoint")
+		}
 	}
-	r.Add(&uvm.NetworkEndpoints{EndpointIDs: endpoints, Namespace: netID})
 	return nil
 }
@@ -0,0 +1,40 @@
+// +build windows
+
+package hcsoci
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/pkg/errors"
+)
+
+// parseNodeShareMount returns the node share of the UVM that the mount source
+// `source`, `nodeshare://<name>[/<path>]`, refers to and the path in the share.
+// The path can not escape the share.
+func parseNodeShareMount(coi *createOptionsInternal, source string) (uvm.NodeShare, string, error) {
+	if coi.HostingSystem == nil {
+		return uvm.NodeShare{}, "", errors.Errorf("node share mount %s is only supported for hypervisor isolated containers", source)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(source, uvm.NodeSharePrefix), "/", 2)
+	share, err := coi.HostingSystem.NodeShare(parts[0])
+	if err != nil {
+		return uvm.NodeShare{}, "", errors.Wrapf(err, "node share mount %s", source)
+	}
+	rel := ""
+	if len(parts) == 2 {
+		rel = strings.TrimPrefix(path.Clean("/"+parts[1]), "/")
+	}
+	return share, rel, nil
+}
+
+// addReadOnlyOption adds the `ro` option to `options` if it is not already set.
+func addReadOnlyOption(options []string) []string {
+	for _, o := range options {
+		if strings.ToLower(o) == "ro" {
+			return options
+		}
+	}
+	return append(options, "ro")
+}
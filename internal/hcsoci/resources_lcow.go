@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Microsoft/hcsshim/internal/layers"
@@ -35,6 +36,20 @@ func getGPUVHDPath(coi *createOptionsInternal) (string, error) {
 	return gpuVHDPath, nil
 }
 
+// validateSandboxMountSize returns an error if the `size` option of the
+// sandbox mount `m` is not a number of bytes, rather than leaving the volume
+// without a quota.
+func validateSandboxMountSize(m specs.Mount) error {
+	for _, o := range m.Options {
+		if strings.HasPrefix(o, "size=") {
+			if _, err := strconv.ParseUint(strings.TrimPrefix(o, "size="), 10, 64); err != nil {
+				return fmt.Errorf("invalid size option %q for mount %s", o, m.Destination)
+			}
+		}
+	}
+	return nil
+}
+
 func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *resources.Resources, isSandbox bool) error {
 	if coi.Spec.Root == nil {
 		coi.Spec.Root = &specs.Root{}
@@ -42,12 +57,22 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 	containerRootInUVM := r.ContainerRootInUVM()
 	if coi.Spec.Windows != nil && len(coi.Spec.Windows.LayerFolders) > 0 {
 		log.G(ctx).Debug("hcsshim::allocateLinuxResources mounting storage")
-		rootPath, err := layers.MountContainerLayers(ctx, coi.Spec.Windows.LayerFolders, containerRootInUVM, coi.HostingSystem)
+		opts := &layers.MountOptions{
+			ScratchQuotaInBytes:     oci.ParseAnnotationsStorageScratchQuota(ctx, coi.Spec),
+			TmpfsScratchSizeInBytes: oci.ParseAnnotationsStorageScratchTmpfsSize(ctx, coi.Spec),
+		}
+		rootPath, err := layers.MountContainerLayersWithOptions(ctx, coi.Spec.Windows.LayerFolders, containerRootInUVM, coi.HostingSystem, opts)
 		if err != nil {
 			return errors.Wrap(err, "failed to mount container storage")
 		}
 		coi.Spec.Root.Path = rootPath
-		layers := layers.NewImageLayers(coi.HostingSystem, containerRootInUVM, coi.Spec.Windows.LayerFolders, isSandbox)
+		// The layers of a sandbox are left for the UVM to be torn down, unless
+		// the UVM is persistent and outlives the sandbox.
+		skipCleanup := isSandbox && !coi.HostingSystem.IsPersistent()
+		layers := layers.NewImageLayers(coi.HostingSystem, containerRootInUVM, coi.Spec.Windows.LayerFolders, skipCleanup)
+		if opts.TmpfsScratchSizeInBytes != 0 {
+			layers.SetTmpfsScratch()
+		}
 		r.SetLayers(layers)
 	} else if coi.Spec.Root.Path != "" {
 		// This is the "Plan 9" root filesystem.
@@ -115,9 +140,58 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 				uvmPathForFile = scsiMount.UVMPath
 				r.Add(scsiMount)
 				coi.Spec.Mounts[i].Type = "none"
+			} else if mount.Type == "bind" && uvm.IsPipe(hostPath) {
+				// A host named pipe is exposed in the container as a UNIX socket
+				// at the mount destination.
+				l.Debug("hcsshim::allocateLinuxResources Adding named pipe to socket relay for OCI mount")
+				relay, err := coi.HostingSystem.AddPipeToSocketRelay(ctx, hostPath)
+				if err != nil {
+					return errors.Wrapf(err, "adding socket relay for mount %+v", mount)
+				}
+				r.Add(relay)
+				uvmPathForFile = fmt.Sprintf("%s%d", uvm.LCOWSocketRelayConnectPrefix, relay.Port)
+				coi.Spec.Mounts[i].Type = "none"
+			} else if mount.Type == "bind" && uvm.IsPipe(mount.Destination) {
+				// A UNIX socket in the container at the mount source is exposed on
+				// the host as the named pipe at the mount destination.
+				l.Debug("hcsshim::allocateLinuxResources Adding socket to named pipe relay for OCI mount")
+				relay, err := coi.HostingSystem.AddSocketToPipeRelay(ctx, mount.Destination)
+				if err != nil {
+					return errors.Wrapf(err, "adding socket relay for mount %+v", mount)
+				}
+				r.Add(relay)
+				uvmPathForFile = fmt.Sprintf("%s%d", uvm.LCOWSocketRelayListenPrefix, relay.Port)
+				coi.Spec.Mounts[i].Destination = mount.Source
+				coi.Spec.Mounts[i].Type = "none"
+			} else if strings.HasPrefix(mount.Source, "volume://") {
+				// Mounts of a pod volume created with the UVM are specified with
+				// a 'volume://' prefix followed by the name of the volume.
+				// example: volume://cache destination:/b/dirInContainer
+				v, err := coi.HostingSystem.Volume(strings.TrimPrefix(mount.Source, "volume://"))
+				if err != nil {
+					return errors.Wrapf(err, "adding pod volume mount %+v", mount)
+				}
+				uvmPathForFile = v.UVMPath
+			} else if strings.HasPrefix(mount.Source, uvm.NodeSharePrefix) {
+				// Mounts of a node share added to every UVM are specified with a
+				// 'nodeshare://' prefix followed by the name of the share and an
+				// optional path in it. They are always read-only.
+				// example: nodeshare://certs/ca.pem destination:/etc/ssl/certs/ca.pem
+				share, rel, err := parseNodeShareMount(coi, mount.Source)
+				if err != nil {
+					return err
+				}
+				uvmPathForFile = path.Join(fmt.Sprintf(uvm.LCOWNodeSharePathFmt, share.Name), rel)
+				coi.Spec.Mounts[i].Options = addReadOnlyOption(mount.Options)
 			} else if strings.HasPrefix(mount.Source, "sandbox://") {
 				// Mounts that map to a path in UVM are specified with 'sandbox://' prefix.
 				// example: sandbox:///a/dirInUvm destination:/b/dirInContainer
+				//
+				// A `size=<bytes>` option is enforced by the guest as a project
+				// quota on the directory, for emptyDir style volumes.
+				if err := validateSandboxMountSize(mount); err != nil {
+					return err
+				}
 				uvmPathForFile = mount.Source
 			} else {
 				st, err := os.Stat(hostPath)
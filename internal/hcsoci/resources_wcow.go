@@ -9,17 +9,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/Microsoft/hcsshim/internal/copyfile"
 	"github.com/Microsoft/hcsshim/internal/credentials"
 	"github.com/Microsoft/hcsshim/internal/layers"
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/resources"
 	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r *resources.Resources, isSandbox bool) error {
@@ -52,7 +56,7 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 	if coi.Spec.Root.Path == "" && (coi.HostingSystem != nil || coi.Spec.Windows.HyperV == nil) {
 		log.G(ctx).Debug("hcsshim::allocateWindowsResources mounting storage")
 		containerRootInUVM := r.ContainerRootInUVM()
-		containerRootPath, err := layers.MountContainerLayers(ctx, coi.Spec.Windows.LayerFolders, containerRootInUVM, coi.HostingSystem)
+		containerRootPath, err := layers.MountContainerLayers(ctx, coi.Spec.Windows.LayerFolders, containerRootInUVM, coi.HostingSystem, 0)
 		if err != nil {
 			return errors.Wrap(err, "failed to mount container storage")
 		}
@@ -61,6 +65,10 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 		r.SetLayers(layers)
 	}
 
+	if err := setupImageVolumes(ctx, coi, r, scratchFolder); err != nil {
+		return err
+	}
+
 	if err := setupMounts(ctx, coi, r); err != nil {
 		return err
 	}
@@ -69,6 +77,10 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 		// Only need to create a CCG instance for v2 containers
 		if schemaversion.IsV21(coi.actualSchemaVersion) {
 			hypervisorIsolated := coi.HostingSystem != nil
+			cs, err := credentials.ResolvePluginInput(ctx, coi.actualID, cs)
+			if err != nil {
+				return err
+			}
 			ccgInstance, ccgResource, err := credentials.CreateCredentialGuard(ctx, coi.actualID, cs, hypervisorIsolated)
 			if err != nil {
 				return err
@@ -105,6 +117,92 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 	return nil
 }
 
+// setupImageVolumes materializes the volumes that the container's image
+// declares in `oci.AnnotationContainerImageVolumes`. As with Docker, a volume
+// that the spec already mounts is left to that mount, and the others are
+// mounted from a new directory under `scratchFolder`, seeded with the content
+// that the image has at the volume path, so that their data is not written to
+// the container's scratch layer. The directories are removed when the
+// container's resources are released.
+func setupImageVolumes(ctx context.Context, coi *createOptionsInternal, r *resources.Resources, scratchFolder string) error {
+	volumes := oci.ParseAnnotationsImageVolumes(coi.Spec)
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	normalize := func(p string) string {
+		return strings.ToLower(strings.TrimRight(filepath.Clean(p), `\`))
+	}
+	mounted := make(map[string]bool)
+	for _, m := range coi.Spec.Mounts {
+		mounted[normalize(m.Destination)] = true
+	}
+	volumesFolder := filepath.Join(scratchFolder, "volumes")
+	r.AddFunc("image-volumes", func(context.Context) error {
+		return os.RemoveAll(volumesFolder)
+	})
+	layerFolders := coi.Spec.Windows.LayerFolders[:len(coi.Spec.Windows.LayerFolders)-1]
+	for i, v := range volumes {
+		if !filepath.IsAbs(v) {
+			return fmt.Errorf("image volume %s is not an absolute path", v)
+		}
+		if mounted[normalize(v)] {
+			continue
+		}
+		mounted[normalize(v)] = true
+
+		// The directories are named by the index of the volume, as the paths
+		// of two volumes can not be mapped to distinct names reliably.
+		source := filepath.Join(volumesFolder, strconv.Itoa(i))
+		if err := os.MkdirAll(source, 0777); err != nil {
+			return errors.Wrapf(err, "failed to create image volume %s", v)
+		}
+		if err := copyImageVolumeContent(ctx, layerFolders, v, source); err != nil {
+			return errors.Wrapf(err, "failed to copy image content of volume %s", v)
+		}
+		log.G(ctx).WithFields(logrus.Fields{
+			"volume": v,
+			"source": source,
+		}).Debug("hcsshim::allocateWindowsResources materializing image volume")
+		coi.Spec.Mounts = append(coi.Spec.Mounts, specs.Mount{
+			Source:      source,
+			Destination: v,
+		})
+	}
+	return nil
+}
+
+// copyImageVolumeContent copies the files that the image layers `layerFolders`,
+// ordered from the top-most to the base layer, have under the container path
+// `volume` to `dest`. The layers are copied from the base up so that the files
+// of an upper layer replace those of the layers below it. Files that an upper
+// layer deletes are not removed, and links are skipped.
+func copyImageVolumeContent(ctx context.Context, layerFolders []string, volume, dest string) error {
+	rel := strings.TrimPrefix(filepath.Clean(volume), filepath.VolumeName(volume))
+	for i := len(layerFolders) - 1; i >= 0; i-- {
+		root := filepath.Join(layerFolders[i], "Files", rel)
+		if st, err := os.Stat(root); err != nil || !st.IsDir() {
+			continue
+		}
+		if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(dest, strings.TrimPrefix(path, root))
+			switch {
+			case info.IsDir():
+				return os.MkdirAll(target, 0777)
+			case info.Mode().IsRegular():
+				return copyfile.CopyFile(ctx, path, target, true)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // setupMounts adds the custom mounts requested in the container configuration of this
 // request.
 func setupMounts(ctx context.Context, coi *createOptionsInternal, r *resources.Resources) error {
@@ -115,6 +213,17 @@ func setupMounts(ctx context.Context, coi *createOptionsInternal, r *resources.R
 		if mount.Destination == "" || mount.Source == "" {
 			return fmt.Errorf("invalid OCI spec - a mount must have both source and a destination: %+v", mount)
 		}
+		if strings.HasPrefix(mount.Source, uvm.NodeSharePrefix) {
+			// A node share is mounted read-only from its host directory, which
+			// is already shared into the UVM.
+			share, rel, err := parseNodeShareMount(coi, mount.Source)
+			if err != nil {
+				return err
+			}
+			mount.Source = filepath.Join(share.HostPath, filepath.FromSlash(rel))
+			mount.Options = addReadOnlyOption(mount.Options)
+			coi.Spec.Mounts[i] = mount
+		}
 		switch mount.Type {
 		case "":
 		case "physical-disk":
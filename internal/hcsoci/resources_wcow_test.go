@@ -0,0 +1,120 @@
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/Microsoft/hcsshim/internal/resources"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func writeLayerFile(t *testing.T, layer, path, content string) {
+	path = filepath.Join(layer, "Files", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupImageVolumesTest returns the create options of a container with the
+// image volumes `volumes` and the spec mounts `mounts`, its scratch folder, and
+// the temporary directory of its layers, which the caller removes.
+func setupImageVolumesTest(t *testing.T, volumes string, mounts []specs.Mount) (*createOptionsInternal, string, string) {
+	dir, err := ioutil.TempDir("", "imagevolumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := filepath.Join(dir, "top")
+	base := filepath.Join(dir, "base")
+	scratch := filepath.Join(dir, "scratch")
+	writeLayerFile(t, base, `data\base.txt`, "base")
+	writeLayerFile(t, base, `data\sub\shared.txt`, "base")
+	writeLayerFile(t, top, `data\sub\shared.txt`, "top")
+
+	coi := &createOptionsInternal{
+		CreateOptions: &CreateOptions{
+			Spec: &specs.Spec{
+				Annotations: map[string]string{oci.AnnotationContainerImageVolumes: volumes},
+				Mounts:      mounts,
+				Windows:     &specs.Windows{LayerFolders: []string{top, base, scratch}},
+			},
+		},
+	}
+	return coi, scratch, dir
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func Test_SetupImageVolumes_DistinctSources(t *testing.T) {
+	coi, scratch, dir := setupImageVolumesTest(t, `C:\a_b,C:\a\b`, nil)
+	defer os.RemoveAll(dir)
+	r := resources.NewContainerResources(t.Name())
+
+	if err := setupImageVolumes(context.Background(), coi, r, scratch); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if len(coi.Spec.Mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got: %+v", coi.Spec.Mounts)
+	}
+	if coi.Spec.Mounts[0].Source == coi.Spec.Mounts[1].Source {
+		t.Fatalf("expected distinct sources, got: %+v", coi.Spec.Mounts)
+	}
+}
+
+func Test_SetupImageVolumes_SkipsMounted(t *testing.T) {
+	mounts := []specs.Mount{{Source: `C:\host`, Destination: `c:\data\`}}
+	coi, scratch, dir := setupImageVolumesTest(t, `C:\data`, mounts)
+	defer os.RemoveAll(dir)
+	r := resources.NewContainerResources(t.Name())
+
+	if err := setupImageVolumes(context.Background(), coi, r, scratch); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if len(coi.Spec.Mounts) != 1 || coi.Spec.Mounts[0].Source != `C:\host` {
+		t.Fatalf("expected only the spec mount, got: %+v", coi.Spec.Mounts)
+	}
+}
+
+func Test_SetupImageVolumes_CopiesImageContent(t *testing.T) {
+	coi, scratch, dir := setupImageVolumesTest(t, `C:\data`, nil)
+	defer os.RemoveAll(dir)
+	r := resources.NewContainerResources(t.Name())
+
+	if err := setupImageVolumes(context.Background(), coi, r, scratch); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if len(coi.Spec.Mounts) != 1 {
+		t.Fatalf("expected 1 mount, got: %+v", coi.Spec.Mounts)
+	}
+	source := coi.Spec.Mounts[0].Source
+	if actual := readFile(t, filepath.Join(source, "base.txt")); actual != "base" {
+		t.Fatalf("expected base layer content, got: %q", actual)
+	}
+	if actual := readFile(t, filepath.Join(source, "sub", "shared.txt")); actual != "top" {
+		t.Fatalf("expected top layer content, got: %q", actual)
+	}
+}
+
+func Test_SetupImageVolumes_RelativeVolume_Error(t *testing.T) {
+	coi, scratch, dir := setupImageVolumesTest(t, `data`, nil)
+	defer os.RemoveAll(dir)
+	r := resources.NewContainerResources(t.Name())
+
+	if err := setupImageVolumes(context.Background(), coi, r, scratch); err == nil {
+		t.Fatal("expected relative volume to be rejected")
+	}
+}
@@ -0,0 +1,63 @@
+package hcsoci
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/sirupsen/logrus"
+)
+
+// createTransaction records the compensating actions for the steps of a
+// container create. If the create fails the actions of the steps that
+// completed are run in reverse order, so that a partial failure doesn't leak
+// network endpoints, mounted layers or a half configured container.
+//
+// Resources that outlive a successful create are tracked by
+// `resources.Resources`, the transaction only owns the steps until they are
+// either handed over to it or rolled back.
+type createTransaction struct {
+	steps []*createStep
+}
+
+type createStep struct {
+	name string
+	undo func(context.Context) error
+}
+
+// onRollback registers `undo` as the compensating action of the step `name`.
+// The returned func removes the action again, for when the step's cleanup
+// has been handed over to the container's resources.
+func (t *createTransaction) onRollback(name string, undo func(context.Context) error) (done func()) {
+	s := &createStep{name: name, undo: undo}
+	t.steps = append(t.steps, s)
+	return func() {
+		for i, step := range t.steps {
+			if step == s {
+				t.steps = append(t.steps[:i], t.steps[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// rollback runs the registered compensating actions in reverse order. All
+// actions are run even if some of them fail.
+func (t *createTransaction) rollback(ctx context.Context) error {
+	failed := false
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		s := t.steps[i]
+		if err := s.undo(ctx); err != nil {
+			log.G(ctx).WithFields(logrus.Fields{
+				"step":          s.name,
+				logrus.ErrorKey: err,
+			}).Error("failed to roll back container create step")
+			failed = true
+		}
+	}
+	t.steps = nil
+	if failed {
+		return errors.New("failed to roll back one or more container create steps")
+	}
+	return nil
+}
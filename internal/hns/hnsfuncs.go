@@ -1,11 +1,13 @@
 package hns
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/Microsoft/hcsshim/internal/hcserror"
 	"github.com/Microsoft/hcsshim/internal/interop"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,7 +15,14 @@ func hnsCallRawResponse(method, path, request string) (*hnsResponse, error) {
 	var responseBuffer *uint16
 	logrus.Debugf("[%s]=>[%s] Request : %s", method, path, request)
 
-	err := _hnsCall(method, path, request, &responseBuffer)
+	call := func() error { return _hnsCall(method, path, request, &responseBuffer) }
+	var err error
+	if method == "GET" {
+		// Only queries are retried, the other requests are not idempotent.
+		err = retry.Do(context.Background(), "hnsCall "+path, call)
+	} else {
+		err = call()
+	}
 	if err != nil {
 		return nil, hcserror.New(err, "hnsCall ", "")
 	}
@@ -43,7 +43,7 @@ func mountLayers(ctx context.Context, s *specs.Spec) error {
 
 	if s.Root.Path == "" {
 		log.G(ctx).Debug("mounting job container storage")
-		containerRootPath, err := layers.MountContainerLayers(ctx, s.Windows.LayerFolders, "", nil)
+		containerRootPath, err := layers.MountContainerLayers(ctx, s.Windows.LayerFolders, "", nil, 0)
 		if err != nil {
 			return errors.Wrap(err, "failed to mount container storage")
 		}
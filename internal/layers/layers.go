@@ -6,9 +6,13 @@ package layers
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/Microsoft/hcsshim/internal/eventlog"
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/ospath"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/uvm"
@@ -16,6 +20,8 @@ import (
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+	"golang.org/x/sys/windows"
 )
 
 // ImageLayers contains all the layers for an image.
@@ -27,6 +33,9 @@ type ImageLayers struct {
 	// down a sandbox container since the UVM will be torn down shortly after and the resources
 	// can be cleaned up on the host.
 	skipCleanup bool
+	// tmpfsScratch is set if the writable layer is a tmpfs in the UVM, so
+	// there is no scratch VHD to remove.
+	tmpfsScratch bool
 }
 
 func NewImageLayers(vm *uvm.UtilityVM, containerRootInUVM string, layers []string, skipCleanup bool) *ImageLayers {
@@ -38,6 +47,12 @@ func NewImageLayers(vm *uvm.UtilityVM, containerRootInUVM string, layers []strin
 	}
 }
 
+// SetTmpfsScratch records that the layers were mounted with a tmpfs scratch,
+// see MountOptions.
+func (layers *ImageLayers) SetTmpfsScratch() {
+	layers.tmpfsScratch = true
+}
+
 // Release unmounts all of the layers located in the layers array.
 func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
 	if layers.skipCleanup && layers.vm != nil {
@@ -47,6 +62,9 @@ func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
 	if layers.vm == nil || all {
 		op = UnmountOperationAll
 	}
+	if layers.tmpfsScratch {
+		op &^= UnmountOperationSCSI
+	}
 	var crp string
 	if layers.vm != nil {
 		crp = containerRootfsPath(layers.vm, layers.containerRootInUVM)
@@ -68,8 +86,47 @@ func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
 //                    inside the utility VM which is a GUID mapping of the scratch folder. Each
 //                    of the layers are the VSMB locations where the read-only layers are mounted.
 //
+// For LCOW `scratchQuotaInBytes` limits the size of the container's writable
+// layer on its scratch, which may be shared with other containers. 0 is
+// unlimited. It is ignored for WCOW.
+//
 // TODO dcantah: Keep better track of the layers that are added, don't simply discard the SCSI, VSMB, etc. resource types gotten inside.
-func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot string, uvm *uvmpkg.UtilityVM) (_ string, err error) {
+func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot string, uvm *uvmpkg.UtilityVM, scratchQuotaInBytes uint64) (_ string, err error) {
+	return MountContainerLayersWithOptions(ctx, layerFolders, guestRoot, uvm, &MountOptions{
+		ScratchQuotaInBytes: scratchQuotaInBytes,
+	})
+}
+
+// MountOptions are the optional settings for mounting container layers. They
+// are ignored for WCOW.
+type MountOptions struct {
+	// ScratchQuotaInBytes limits the size of an LCOW container's writable layer
+	// on its scratch, see MountContainerLayers.
+	ScratchQuotaInBytes uint64
+	// TmpfsScratchSizeInBytes, if non-zero, backs the writable layer of an LCOW
+	// container with a tmpfs of this size in the UVM instead of the scratch
+	// VHD, which is not attached. Writes are much faster, but they count
+	// against the memory of the UVM and are lost when the container exits. The
+	// ImageLayers of the container must be marked with SetTmpfsScratch. It is
+	// not supported with a block device rootfs.
+	TmpfsScratchSizeInBytes uint64
+}
+
+// MountContainerLayersWithOptions is MountContainerLayers with the settings
+// in `opts`, which may be nil.
+func MountContainerLayersWithOptions(ctx context.Context, layerFolders []string, guestRoot string, uvm *uvmpkg.UtilityVM, opts *MountOptions) (_ string, err error) {
+	ctx, span := trace.StartSpan(ctx, "layers::MountContainerLayers")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.Int64Attribute("layers", int64(len(layerFolders))))
+	defer func() {
+		if err != nil && isCorruptionError(err) {
+			eventlog.Error(ctx, eventlog.EventLayerCorruption, "a container layer is corrupt", map[string]interface{}{
+				"layerFolders": layerFolders,
+				"error":        err,
+			})
+		}
+	}()
 	log.G(ctx).WithField("layerFolders", layerFolders).Debug("hcsshim::mountContainerLayers")
 
 	if uvm == nil {
@@ -106,6 +163,20 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 	// V2 UVM
 	log.G(ctx).WithField("os", uvm.OS()).Debug("hcsshim::mountContainerLayers V2 UVM")
 
+	if opts == nil {
+		opts = &MountOptions{}
+	}
+	tmpfsScratch := uvm.OS() == "linux" && opts.TmpfsScratchSizeInBytes != 0
+
+	if hostPath, ok := blockDeviceRootfsPath(uvm.OS(), layerFolders); ok {
+		// The rootfs VHD is the writable layer, and its attachment is only
+		// released with the SCSI resources of the container.
+		if tmpfsScratch {
+			return "", errors.New("a tmpfs scratch is not supported with a block device rootfs")
+		}
+		return mountBlockDeviceRootfs(ctx, uvm, hostPath, guestRoot)
+	}
+
 	var (
 		layersAdded       []string
 		lcowUvmLayerPaths []string
@@ -128,7 +199,14 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 		}
 	}()
 
-	for _, layerPath := range layerFolders[:len(layerFolders)-1] {
+	var (
+		lcowLayerVHDs []string
+		erofs         bool
+	)
+	if uvm.OS() == "linux" {
+		lcowLayerVHDs, erofs = lcowLayerFiles(uvm.ErofsLayersSupported(), layerFolders[:len(layerFolders)-1])
+	}
+	for i, layerPath := range layerFolders[:len(layerFolders)-1] {
 		log.G(ctx).WithField("layerPath", layerPath).Debug("mounting layer")
 		if uvm.OS() == "windows" {
 			options := uvm.DefaultVSMBOptions(true)
@@ -142,7 +220,7 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 			layersAdded = append(layersAdded, layerPath)
 		} else {
 			var (
-				layerPath = filepath.Join(layerPath, "layer.vhd")
+				layerPath = lcowLayerVHDs[i]
 				uvmPath   string
 			)
 			uvmPath, err = addLCOWLayer(ctx, uvm, layerPath)
@@ -155,34 +233,41 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 	}
 
 	containerScratchPathInUVM := ospath.Join(uvm.OS(), guestRoot)
-	hostPath, err := getScratchVHDPath(layerFolders)
-	if err != nil {
-		return "", fmt.Errorf("failed to get scratch VHD path in layer folders: %s", err)
-	}
-	log.G(ctx).WithField("hostPath", hostPath).Debug("mounting scratch VHD")
+	// A tmpfs scratch is mounted by the guest when the layers are combined.
+	if !tmpfsScratch {
+		var (
+			hostPath  string
+			scsiMount *uvmpkg.SCSIMount
+		)
+		hostPath, err = getScratchVHDPath(layerFolders)
+		if err != nil {
+			return "", fmt.Errorf("failed to get scratch VHD path in layer folders: %s", err)
+		}
+		log.G(ctx).WithField("hostPath", hostPath).Debug("mounting scratch VHD")
 
-	scsiMount, err := uvm.AddSCSI(ctx, hostPath, containerScratchPathInUVM, false, uvmpkg.VMAccessTypeIndividual)
-	if err != nil {
-		return "", fmt.Errorf("failed to add SCSI scratch VHD: %s", err)
-	}
+		scsiMount, err = uvm.AddSCSI(ctx, hostPath, containerScratchPathInUVM, false, uvmpkg.VMAccessTypeIndividual)
+		if err != nil {
+			return "", fmt.Errorf("failed to add SCSI scratch VHD: %s", err)
+		}
 
-	// This handles the case where we want to share a scratch disk for multiple containers instead
-	// of mounting a new one. Pass a unique value for `ScratchPath` to avoid container upper and
-	// work directories colliding in the UVM.
-	if scsiMount.RefCount() > 1 && uvm.OS() == "linux" {
-		scratchFmt := fmt.Sprintf("container_%s", filepath.Base(containerScratchPathInUVM))
-		containerScratchPathInUVM = ospath.Join("linux", scsiMount.UVMPath, scratchFmt)
-	} else {
-		containerScratchPathInUVM = scsiMount.UVMPath
-	}
+		// This handles the case where we want to share a scratch disk for multiple containers instead
+		// of mounting a new one. Pass a unique value for `ScratchPath` to avoid container upper and
+		// work directories colliding in the UVM.
+		if scsiMount.RefCount() > 1 && uvm.OS() == "linux" {
+			scratchFmt := fmt.Sprintf("container_%s", filepath.Base(containerScratchPathInUVM))
+			containerScratchPathInUVM = ospath.Join("linux", scsiMount.UVMPath, scratchFmt)
+		} else {
+			containerScratchPathInUVM = scsiMount.UVMPath
+		}
 
-	defer func() {
-		if err != nil {
-			if err := uvm.RemoveSCSI(ctx, hostPath); err != nil {
-				log.G(ctx).WithError(err).Warn("failed to remove scratch on cleanup")
+		defer func() {
+			if err != nil {
+				if err := uvm.RemoveSCSI(ctx, hostPath); err != nil {
+					log.G(ctx).WithError(err).Warn("failed to remove scratch on cleanup")
+				}
 			}
-		}
-	}()
+		}()
+	}
 
 	var rootfs string
 	if uvm.OS() == "windows" {
@@ -197,7 +282,11 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 		rootfs = containerScratchPathInUVM
 	} else {
 		rootfs = ospath.Join(uvm.OS(), guestRoot, uvmpkg.RootfsPath)
-		err = uvm.CombineLayersLCOW(ctx, lcowUvmLayerPaths, containerScratchPathInUVM, rootfs)
+		err = uvm.CombineLayersLCOWWithOptions(ctx, lcowUvmLayerPaths, containerScratchPathInUVM, rootfs, &uvmpkg.CombineLayersOptions{
+			Erofs:                   erofs,
+			ScratchQuotaInBytes:     opts.ScratchQuotaInBytes,
+			TmpfsScratchSizeInBytes: opts.TmpfsScratchSizeInBytes,
+		})
 	}
 	if err != nil {
 		return "", err
@@ -207,11 +296,23 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 }
 
 func addLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath string) (uvmPath string, err error) {
+	hostPath, unlock, err := acquireSharedLayer(ctx, uvm.ID(), layerPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_, _ = releaseSharedLayer(ctx, uvm.ID(), layerPath)
+			pruneSharedLayer(ctx, uvm.ID(), layerPath)
+		}
+	}()
+	defer unlock()
+
 	// don't try to add as vpmem when we want additional devices on the uvm to be fully physically backed
 	if !uvm.DevicesPhysicallyBacked() {
 		// We first try vPMEM and if it is full or the file is too large we
 		// fall back to SCSI.
-		uvmPath, err = uvm.AddVPMEM(ctx, layerPath)
+		uvmPath, err = uvm.AddVPMEM(ctx, hostPath)
 		if err == nil {
 			log.G(ctx).WithFields(logrus.Fields{
 				"layerPath": layerPath,
@@ -224,7 +325,7 @@ func addLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath string)
 	}
 
 	uvmPath = fmt.Sprintf(uvmpkg.LCOWGlobalMountPrefix, uvm.UVMMountCounter())
-	sm, err := uvm.AddSCSI(ctx, layerPath, uvmPath, true, uvmpkg.VMAccessTypeNoop)
+	sm, err := uvm.AddSCSI(ctx, hostPath, uvmPath, true, uvmpkg.VMAccessTypeNoop)
 	if err != nil {
 		return "", fmt.Errorf("failed to add SCSI layer: %s", err)
 	}
@@ -236,8 +337,14 @@ func addLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath string)
 }
 
 func removeLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath string) error {
+	hostPath, err := releaseSharedLayer(ctx, uvm.ID(), layerPath)
+	if err != nil {
+		return err
+	}
+	defer pruneSharedLayer(ctx, uvm.ID(), layerPath)
+
 	// Assume it was added to vPMEM and fall back to SCSI
-	err := uvm.RemoveVPMEM(ctx, layerPath)
+	err = uvm.RemoveVPMEM(ctx, hostPath)
 	if err == nil {
 		log.G(ctx).WithFields(logrus.Fields{
 			"layerPath": layerPath,
@@ -245,7 +352,7 @@ func removeLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath strin
 		}).Debug("Removed LCOW layer")
 		return nil
 	} else if err == uvmpkg.ErrNotAttached {
-		err = uvm.RemoveSCSI(ctx, layerPath)
+		err = uvm.RemoveSCSI(ctx, hostPath)
 		if err == nil {
 			log.G(ctx).WithFields(logrus.Fields{
 				"layerPath": layerPath,
@@ -274,7 +381,11 @@ const (
 )
 
 // UnmountContainerLayers is a helper for clients to hide all the complexity of layer unmounting
-func UnmountContainerLayers(ctx context.Context, layerFolders []string, containerRootPath string, uvm *uvmpkg.UtilityVM, op UnmountOperation) error {
+func UnmountContainerLayers(ctx context.Context, layerFolders []string, containerRootPath string, uvm *uvmpkg.UtilityVM, op UnmountOperation) (err error) {
+	ctx, span := trace.StartSpan(ctx, "layers::UnmountContainerLayers")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.Int64Attribute("layers", int64(len(layerFolders))))
 	log.G(ctx).WithField("layerFolders", layerFolders).Debug("hcsshim::unmountContainerLayers")
 	if uvm == nil {
 		// Must be an argon - folders are mounted on the host
@@ -293,6 +404,13 @@ func UnmountContainerLayers(ctx context.Context, layerFolders []string, containe
 
 	// V2 Xenon
 
+	if hostPath, ok := blockDeviceRootfsPath(uvm.OS(), layerFolders); ok {
+		if (op & UnmountOperationSCSI) != UnmountOperationSCSI {
+			return nil
+		}
+		return uvm.RemoveSCSI(ctx, hostPath)
+	}
+
 	// Base+Scratch as a minimum. This is different to v1 which only requires the scratch
 	if len(layerFolders) < 2 {
 		return errors.New("at least two layers are required for unmount")
@@ -343,8 +461,8 @@ func UnmountContainerLayers(ctx context.Context, layerFolders []string, containe
 	// and only removed once the count drops to zero. This allows multiple containers to
 	// share layers. Note that SCSI is used on large layers.
 	if uvm.OS() == "linux" && (op&UnmountOperationVPMEM) == UnmountOperationVPMEM {
-		for _, layerPath := range layerFolders[:len(layerFolders)-1] {
-			hostPath := filepath.Join(layerPath, "layer.vhd")
+		hostPaths, _ := lcowLayerFiles(uvm.ErofsLayersSupported(), layerFolders[:len(layerFolders)-1])
+		for _, hostPath := range hostPaths {
 			if err := removeLCOWLayer(ctx, uvm, hostPath); err != nil {
 				log.G(ctx).WithError(err).Warn("remove layer failed")
 				if retError == nil {
@@ -382,6 +500,75 @@ func containerRootfsPath(uvm *uvm.UtilityVM, rootPath string) string {
 	return ospath.Join(uvm.OS(), rootPath, uvmpkg.RootfsPath)
 }
 
+const (
+	lcowLayerVHD = "layer.vhd"
+	// lcowErofsLayerVHD is a VHD holding an EROFS image of the layer, that
+	// may be written next to layer.vhd by the snapshotter.
+	lcowErofsLayerVHD = "layer.erofs.vhd"
+)
+
+// lcowLayerFiles returns the host paths of the VHDs to attach for the
+// read-only `layerFolders`, and if they are EROFS images. The EROFS images are
+// only used if the guest supports them, as reported by `erofs`, and every
+// layer has one, as the guest cannot combine EROFS and ext4 layers.
+func lcowLayerFiles(erofs bool, layerFolders []string) ([]string, bool) {
+	if erofs {
+		for _, l := range layerFolders {
+			if _, err := os.Stat(filepath.Join(l, lcowErofsLayerVHD)); err != nil {
+				erofs = false
+				break
+			}
+		}
+	}
+	name := lcowLayerVHD
+	if erofs {
+		name = lcowErofsLayerVHD
+	}
+	var paths []string
+	for _, l := range layerFolders {
+		paths = append(paths, filepath.Join(l, name))
+	}
+	return paths, erofs
+}
+
+// lcowRootfsVHD is a writable VHD holding the flattened image and scratch of
+// an LCOW container, that may be written to the scratch folder by the
+// snapshotter in place of sandbox.vhdx.
+const lcowRootfsVHD = "rootfs.vhdx"
+
+// blockDeviceRootfsPath returns the host path of the rootfs VHD if the
+// container's rootfs is a single block device rather than layers combined
+// with overlayfs in the guest. `guestOS` is the operating system of the UVM.
+func blockDeviceRootfsPath(guestOS string, layerFolders []string) (string, bool) {
+	if guestOS != "linux" || len(layerFolders) == 0 {
+		return "", false
+	}
+	hostPath := filepath.Join(layerFolders[len(layerFolders)-1], lcowRootfsVHD)
+	if _, err := os.Stat(hostPath); err != nil {
+		return "", false
+	}
+	return hostPath, true
+}
+
+// mountBlockDeviceRootfs attaches the rootfs VHD at `hostPath` as the rootfs
+// of the container at `guestRoot`. The disk is mounted directly, so writes do
+// not pay for overlayfs copy ups, but it cannot be shared between containers.
+func mountBlockDeviceRootfs(ctx context.Context, vm *uvmpkg.UtilityVM, hostPath, guestRoot string) (string, error) {
+	log.G(ctx).WithField("hostPath", hostPath).Debug("mounting block device rootfs")
+	rootfs := ospath.Join(vm.OS(), guestRoot, uvmpkg.RootfsPath)
+	sm, err := vm.AddSCSI(ctx, hostPath, rootfs, false, uvmpkg.VMAccessTypeIndividual)
+	if err != nil {
+		return "", fmt.Errorf("failed to add SCSI rootfs VHD: %s", err)
+	}
+	if sm.RefCount() > 1 {
+		if err := vm.RemoveSCSI(ctx, hostPath); err != nil {
+			log.G(ctx).WithError(err).Warn("failed to remove rootfs on cleanup")
+		}
+		return "", fmt.Errorf("rootfs VHD %s is already in use by another container", hostPath)
+	}
+	return sm.UVMPath, nil
+}
+
 func getScratchVHDPath(layerFolders []string) (string, error) {
 	hostPath := filepath.Join(layerFolders[len(layerFolders)-1], "sandbox.vhdx")
 	// For LCOW, we can reuse another container's scratch space (usually the sandbox container's).
@@ -396,3 +583,12 @@ func getScratchVHDPath(layerFolders []string) (string, error) {
 	}
 	return hostPath, nil
 }
+
+// isCorruptionError returns true if `err` is caused by a corrupt layer, either
+// on the host or, for an LCOW layer, as reported by the guest when mounting
+// it.
+func isCorruptionError(err error) bool {
+	return errors.Is(err, windows.ERROR_FILE_CORRUPT) ||
+		errors.Is(err, windows.ERROR_DISK_CORRUPT) ||
+		strings.Contains(err.Error(), "structure needs cleaning")
+}
@@ -0,0 +1,94 @@
+// +build windows
+
+package layers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newLayerFolders creates `n` layer folders in `dir` and returns their paths.
+func newLayerFolders(t *testing.T, dir string, n int) []string {
+	var folders []string
+	for i := 0; i < n; i++ {
+		folder, err := ioutil.TempDir(dir, "layer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		folders = append(folders, folder)
+	}
+	return folders
+}
+
+// touch creates the empty file `name` in `dir`.
+func touch(t *testing.T, dir, name string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LCOWLayerFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	folders := newLayerFolders(t, dir, 2)
+	paths := func(name string) []string {
+		var p []string
+		for _, f := range folders {
+			p = append(p, filepath.Join(f, name))
+		}
+		return p
+	}
+	for _, f := range folders {
+		touch(t, f, lcowLayerVHD)
+	}
+	touch(t, folders[0], lcowErofsLayerVHD)
+
+	// The EROFS images are only used once every layer has one.
+	if actual, erofs := lcowLayerFiles(true, folders); erofs || !reflect.DeepEqual(actual, paths(lcowLayerVHD)) {
+		t.Fatalf("expected ext4 layers with a missing EROFS image %v, got %v (erofs %t)", paths(lcowLayerVHD), actual, erofs)
+	}
+
+	touch(t, folders[1], lcowErofsLayerVHD)
+	if actual, erofs := lcowLayerFiles(true, folders); !erofs || !reflect.DeepEqual(actual, paths(lcowErofsLayerVHD)) {
+		t.Fatalf("expected EROFS layers %v, got %v (erofs %t)", paths(lcowErofsLayerVHD), actual, erofs)
+	}
+	if actual, erofs := lcowLayerFiles(false, folders); erofs || !reflect.DeepEqual(actual, paths(lcowLayerVHD)) {
+		t.Fatalf("expected ext4 layers without guest support %v, got %v (erofs %t)", paths(lcowLayerVHD), actual, erofs)
+	}
+}
+
+func Test_BlockDeviceRootfsPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	folders := newLayerFolders(t, dir, 2)
+	if _, ok := blockDeviceRootfsPath("linux", folders); ok {
+		t.Fatal("expected layers without a rootfs VHD to be combined")
+	}
+	// Only the rootfs VHD of the scratch folder is used.
+	touch(t, folders[0], lcowRootfsVHD)
+	if _, ok := blockDeviceRootfsPath("linux", folders); ok {
+		t.Fatal("expected a rootfs VHD outside of the scratch folder to be ignored")
+	}
+	touch(t, folders[1], lcowRootfsVHD)
+	expected := filepath.Join(folders[1], lcowRootfsVHD)
+	if path, ok := blockDeviceRootfsPath("linux", folders); !ok || path != expected {
+		t.Fatalf("expected rootfs VHD %s, got %q (%t)", expected, path, ok)
+	}
+	if _, ok := blockDeviceRootfsPath("windows", folders); ok {
+		t.Fatal("expected the rootfs VHD to be ignored for WCOW")
+	}
+	if _, ok := blockDeviceRootfsPath("linux", nil); ok {
+		t.Fatal("expected no rootfs VHD without layer folders")
+	}
+}
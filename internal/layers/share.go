@@ -0,0 +1,268 @@
+//go:build windows
+// +build windows
+
+package layers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Microsoft/go-winio/pkg/security"
+	"github.com/Microsoft/hcsshim/internal/copyfile"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// SharedLayerRootEnv is the environment variable that names the directory
+// read-only LCOW layer VHDs are shared from. When set, every layer VHD with
+// the same content is attached from a single file in this directory by all
+// the utility VMs on the node, so that the host keeps one copy of it on disk
+// and in the page cache. The directory should be on the same volume as the
+// layer store, otherwise each layer is copied to it on first attach.
+const SharedLayerRootEnv = "HCSSHIM_SHARED_LAYER_ROOT"
+
+const (
+	sharedLayerFile = "layer.vhd"
+	sharedLockFile  = "lock"
+	sharedRefsDir   = "refs"
+	// layerDigestSuffix is the suffix of the file next to a layer VHD that
+	// caches its digest. Layer VHDs are immutable once created so the digest
+	// is only computed once.
+	layerDigestSuffix = ".sha256"
+)
+
+var (
+	sharedLayersMu sync.Mutex
+	// sharedLayerRefs counts the attachments of each shared layer by the
+	// utility VMs in this process, keyed by the path of the VM's ref file.
+	sharedLayerRefs = make(map[string]int)
+	// layerDigests caches the digests of the layer VHDs by host path.
+	layerDigests = make(map[string]string)
+)
+
+// acquireSharedLayer returns the host path to attach for the layer VHD
+// `layerPath` to the utility VM `vmID`, creating the shared copy if required.
+// `unlock` must be called once the returned path has been attached, which
+// prevents another process from removing the shared copy before that.
+func acquireSharedLayer(ctx context.Context, vmID, layerPath string) (_ string, unlock func(), err error) {
+	root := os.Getenv(SharedLayerRootEnv)
+	if root == "" {
+		return layerPath, func() {}, nil
+	}
+	digest, err := layerDigest(layerPath)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get digest of %s", layerPath)
+	}
+	dir := filepath.Join(root, digest)
+	if err := os.MkdirAll(filepath.Join(dir, sharedRefsDir), 0); err != nil {
+		return "", nil, err
+	}
+	unlock, err = lockSharedLayer(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if err != nil {
+			unlock()
+		}
+	}()
+
+	sharedLayersMu.Lock()
+	defer sharedLayersMu.Unlock()
+	ref := filepath.Join(dir, sharedRefsDir, vmID)
+	if sharedLayerRefs[ref] == 0 {
+		if err := ioutil.WriteFile(ref, nil, 0); err != nil {
+			return "", nil, err
+		}
+	}
+	shared := filepath.Join(dir, sharedLayerFile)
+	if _, err := os.Stat(shared); os.IsNotExist(err) {
+		if err := linkOrCopyLayer(ctx, layerPath, shared); err != nil {
+			if sharedLayerRefs[ref] == 0 {
+				_ = os.Remove(ref)
+			}
+			return "", nil, errors.Wrapf(err, "failed to share layer %s", layerPath)
+		}
+	} else if err != nil {
+		return "", nil, err
+	}
+	sharedLayerRefs[ref]++
+	log.G(ctx).WithFields(logrus.Fields{
+		"layerPath":  layerPath,
+		"sharedPath": shared,
+	}).Debug("using shared LCOW layer")
+	return shared, unlock, nil
+}
+
+// releaseSharedLayer drops the reference of the utility VM `vmID` to the
+// shared copy of `layerPath` and returns the host path that was attached.
+// pruneSharedLayer must be called once the path has been detached.
+func releaseSharedLayer(ctx context.Context, vmID, layerPath string) (string, error) {
+	root := os.Getenv(SharedLayerRootEnv)
+	if root == "" {
+		return layerPath, nil
+	}
+	digest, err := layerDigest(layerPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get digest of %s", layerPath)
+	}
+	dir := filepath.Join(root, digest)
+	shared := filepath.Join(dir, sharedLayerFile)
+	ref := filepath.Join(dir, sharedRefsDir, vmID)
+
+	sharedLayersMu.Lock()
+	defer sharedLayersMu.Unlock()
+	if sharedLayerRefs[ref] == 0 {
+		return shared, nil
+	}
+	if sharedLayerRefs[ref]--; sharedLayerRefs[ref] > 0 {
+		return shared, nil
+	}
+	delete(sharedLayerRefs, ref)
+	return shared, nil
+}
+
+// pruneSharedLayer removes the ref of `vmID` to the shared copy of
+// `layerPath` once it is no longer attached, along with the refs of utility
+// VMs that no longer exist, and removes the shared copy if no refs remain.
+func pruneSharedLayer(ctx context.Context, vmID, layerPath string) {
+	root := os.Getenv(SharedLayerRootEnv)
+	if root == "" {
+		return
+	}
+	digest, err := layerDigest(layerPath)
+	if err != nil {
+		return
+	}
+	dir := filepath.Join(root, digest)
+	ref := filepath.Join(dir, sharedRefsDir, vmID)
+
+	unlock, err := lockSharedLayer(dir)
+	if err != nil {
+		log.G(ctx).WithError(err).Warning("failed to lock shared layer")
+		return
+	}
+	defer unlock()
+	sharedLayersMu.Lock()
+	defer sharedLayersMu.Unlock()
+	if sharedLayerRefs[ref] > 0 {
+		return
+	}
+	_ = os.Remove(ref)
+	refs, err := ioutil.ReadDir(filepath.Join(dir, sharedRefsDir))
+	if err != nil {
+		return
+	}
+	for _, r := range refs {
+		// Refs can be left behind by processes that exited without
+		// releasing them.
+		if _, ok := sharedLayerRefs[filepath.Join(dir, sharedRefsDir, r.Name())]; ok {
+			return
+		}
+		system, err := hcs.OpenComputeSystem(ctx, r.Name())
+		if err == nil {
+			system.Close()
+			return
+		} else if !hcs.IsNotExist(err) {
+			return
+		}
+		_ = os.Remove(filepath.Join(dir, sharedRefsDir, r.Name()))
+	}
+	// This fails while another utility VM still has the file open.
+	if err := os.Remove(filepath.Join(dir, sharedLayerFile)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	log.G(ctx).WithField("sharedPath", dir).Debug("removed shared LCOW layer")
+}
+
+// lockSharedLayer takes the lock of the shared layer directory `dir` that
+// serializes processes attaching and removing it. It must be taken before
+// `sharedLayersMu`.
+func lockSharedLayer(dir string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(dir, sharedLockFile), os.O_RDWR|os.O_CREATE, 0)
+	if err != nil {
+		return nil, err
+	}
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to lock shared layer")
+	}
+	return func() {
+		_ = windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+		f.Close()
+	}, nil
+}
+
+// linkOrCopyLayer creates the shared copy `shared` of `layerPath` as a hard
+// link, or as a copy if `layerPath` is on another volume.
+func linkOrCopyLayer(ctx context.Context, layerPath, shared string) error {
+	tmp := shared + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Link(layerPath, tmp); err != nil {
+		log.G(ctx).WithError(err).WithField("layerPath", layerPath).Debug("failed to link layer, copying it")
+		if err := copyfile.CopyFile(ctx, layerPath, tmp, true); err != nil {
+			return err
+		}
+		// A hard link shares the access of the layer VHD, a copy must be
+		// granted it as layers are attached without granting access.
+		if err := security.GrantVmGroupAccess(tmp); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, shared); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// layerDigest returns the hex encoded SHA256 digest of the layer VHD
+// `layerPath`.
+func layerDigest(layerPath string) (string, error) {
+	sharedLayersMu.Lock()
+	digest, ok := layerDigests[layerPath]
+	sharedLayersMu.Unlock()
+	if ok {
+		return digest, nil
+	}
+
+	if b, err := ioutil.ReadFile(layerPath + layerDigestSuffix); err == nil {
+		digest = strings.TrimSpace(string(b))
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			digest = ""
+		}
+	}
+	if digest == "" {
+		f, err := os.Open(layerPath)
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		digest = hex.EncodeToString(h.Sum(nil))
+		// The layer folder may be read-only, in which case the digest is
+		// only cached in memory.
+		_ = ioutil.WriteFile(layerPath+layerDigestSuffix, []byte(digest), 0644)
+	}
+
+	sharedLayersMu.Lock()
+	layerDigests[layerPath] = digest
+	sharedLayersMu.Unlock()
+	return digest, nil
+}
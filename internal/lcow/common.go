@@ -16,6 +16,30 @@ import (
 // formatDiskUvm creates a utility vm, mounts the disk as a scsi disk onto to the VM
 // and then formats it with ext4.
 func formatDiskUvm(ctx context.Context, lcowUVM *uvm.UtilityVM, controller int, lun int32, destPath string) error {
+	device, err := scsiDeviceInUvm(ctx, lcowUVM, controller, lun, destPath)
+	if err != nil {
+		return err
+	}
+
+	// Format it ext4
+	mkfsCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	cmd := cmdpkg.CommandContext(mkfsCtx, lcowUVM, "mkfs.ext4", "-q", "-E", "lazy_itable_init=0,nodiscard", "-O", `^has_journal,sparse_super2,^resize_inode`, device)
+	var mkfsStderr bytes.Buffer
+	cmd.Stderr = &mkfsStderr
+	err = cmd.Run()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s. detailed error: %s", cmd.Spec.Args, destPath, err, mkfsStderr.String())
+	}
+
+	log.G(ctx).WithField("dest", destPath).Debug("lcow::FormatDisk complete")
+
+	return nil
+}
+
+// scsiDeviceInUvm waits for the SCSI disk at `controller` and `lun` to come
+// online in the UVM and returns its device path, for example `/dev/sda`.
+func scsiDeviceInUvm(ctx context.Context, lcowUVM *uvm.UtilityVM, controller int, lun int32, destPath string) (string, error) {
 	// Validate /sys/bus/scsi/devices/C:0:0:L exists as a directory
 	devicePath := fmt.Sprintf("/sys/bus/scsi/devices/%d:0:0:%d/block", controller, lun)
 	testdCtx, cancel := context.WithTimeout(ctx, timeout.TestDRetryLoop)
@@ -27,7 +51,7 @@ func formatDiskUvm(ctx context.Context, lcowUVM *uvm.UtilityVM, controller int,
 			break
 		}
 		if _, ok := err.(*cmdpkg.ExitError); !ok {
-			return fmt.Errorf("failed to run %+v following hot-add %s to utility VM: %s", cmd.Spec.Args, destPath, err)
+			return "", fmt.Errorf("failed to run %+v following hot-add %s to utility VM: %s", cmd.Spec.Args, destPath, err)
 		}
 		time.Sleep(time.Millisecond * 10)
 	}
@@ -39,26 +63,12 @@ func formatDiskUvm(ctx context.Context, lcowUVM *uvm.UtilityVM, controller int,
 	lsOutput, err := cmd.Output()
 	cancel()
 	if err != nil {
-		return fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s", cmd.Spec.Args, destPath, err)
+		return "", fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s", cmd.Spec.Args, destPath, err)
 	}
 	device := fmt.Sprintf(`/dev/%s`, bytes.TrimSpace(lsOutput))
 	log.G(ctx).WithFields(logrus.Fields{
 		"dest":   destPath,
 		"device": device,
 	}).Debug("lcow::FormatDisk device guest location")
-
-	// Format it ext4
-	mkfsCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
-	cmd = cmdpkg.CommandContext(mkfsCtx, lcowUVM, "mkfs.ext4", "-q", "-E", "lazy_itable_init=0,nodiscard", "-O", `^has_journal,sparse_super2,^resize_inode`, device)
-	var mkfsStderr bytes.Buffer
-	cmd.Stderr = &mkfsStderr
-	err = cmd.Run()
-	cancel()
-	if err != nil {
-		return fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s. detailed error: %s", cmd.Spec.Args, destPath, err, mkfsStderr.String())
-	}
-
-	log.G(ctx).WithField("dest", destPath).Debug("lcow::FormatDisk complete")
-
-	return nil
+	return device, nil
 }
@@ -0,0 +1,194 @@
+package lcow
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Microsoft/go-winio/vhd"
+	"github.com/Microsoft/hcsshim/ext4/tar2ext4"
+	cmdpkg "github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/sirupsen/logrus"
+)
+
+// LayerFormat is the file system of a layer VHD created by ConvertTarToVhd.
+type LayerFormat string
+
+const (
+	// LayerFormatExt4 creates an ext4 layer VHD, named `layer.vhd` in a
+	// layer folder.
+	LayerFormatExt4 LayerFormat = "ext4"
+	// LayerFormatErofs creates an EROFS layer VHD, named `layer.erofs.vhd`
+	// in a layer folder.
+	LayerFormatErofs LayerFormat = "erofs"
+)
+
+const (
+	// superblockOffset is the offset of the ext4 and EROFS superblocks from
+	// the start of the device.
+	superblockOffset = 1024
+
+	ext4Magic          = 0xef53
+	ext4MagicOffset    = 56
+	ext4BlocksOffset   = 4
+	ext4LogBlockOffset = 24
+
+	erofsMagic           = 0xe0f5e1e2
+	erofsMagicOffset     = 0
+	erofsBlkSzBitsOffset = 12
+	erofsBlocksOffset    = 36
+)
+
+// ConvertTarToVhd uses a utility VM to convert the layer tar stream `r` into a
+// fixed VHD `destFile` holding a file system of `format`, so that the tar is
+// never parsed on the host. The conversion is done on a temporary disk of
+// `sizeGB`, which must be large enough to hold the unpacked layer.
+//
+// The utility VM must be running an image that contains `tar2ext4` for ext4
+// and `mkfs.erofs` for EROFS.
+func ConvertTarToVhd(ctx context.Context, lcowUVM *uvm.UtilityVM, r io.Reader, destFile string, format LayerFormat, sizeGB uint32) (err error) {
+	if lcowUVM == nil {
+		return fmt.Errorf("no uvm")
+	}
+
+	if lcowUVM.OS() != "linux" {
+		return errors.New("lcow::ConvertTarToVhd requires a linux utility VM to operate")
+	}
+
+	var convertArgs []string
+	switch format {
+	case LayerFormatExt4:
+		convertArgs = []string{"tar2ext4", "-overlay", "-o"}
+	case LayerFormatErofs:
+		convertArgs = []string{"mkfs.erofs", "--quiet", "--tar=f", "--aufs"}
+	default:
+		return fmt.Errorf("unsupported layer format %q", format)
+	}
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"dest":   destFile,
+		"format": format,
+		"sizeGB": sizeGB,
+	}).Debug("lcow::ConvertTarToVhd opts")
+
+	workFile := destFile + ".work.vhdx"
+	if err := vhd.CreateVhdx(workFile, sizeGB, defaultVhdxBlockSizeMB); err != nil {
+		return fmt.Errorf("failed to create VHDx %s: %s", workFile, err)
+	}
+	defer os.Remove(workFile)
+
+	scsi, err := lcowUVM.AddSCSI(ctx, workFile, "", false, uvm.VMAccessTypeIndividual) // No destination as not formatted
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := lcowUVM.RemoveSCSI(ctx, workFile); rerr != nil && err == nil {
+			err = fmt.Errorf("failed to hot-remove: %s", rerr)
+		}
+	}()
+
+	device, err := scsiDeviceInUvm(ctx, lcowUVM, scsi.Controller, scsi.LUN, workFile)
+	if err != nil {
+		return err
+	}
+
+	// Stream the tar into the converter. No timeout is applied as the time
+	// taken depends on the size of the layer.
+	if format == LayerFormatExt4 {
+		convertArgs = append(convertArgs, device)
+	} else {
+		convertArgs = append(convertArgs, device, "/dev/stdin")
+	}
+	cmd := cmdpkg.CommandContext(ctx, lcowUVM, convertArgs[0], convertArgs[1:]...)
+	cmd.Stdin = r
+	var convertStderr bytes.Buffer
+	cmd.Stderr = &convertStderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to `%+v` in utility VM: %s. detailed error: %s", cmd.Spec.Args, err, convertStderr.String())
+	}
+
+	size, err := fileSystemSize(ctx, lcowUVM, device, format)
+	if err != nil {
+		return err
+	}
+	if size == 0 || size > int64(sizeGB)<<30 {
+		return fmt.Errorf("invalid file system size %d on a %dGB device", size, sizeGB)
+	}
+
+	// Copy only the file system out of the scratch device.
+	f, err := os.Create(destFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(destFile)
+		}
+	}()
+	cmd = cmdpkg.CommandContext(ctx, lcowUVM, "head", "-c", strconv.FormatInt(size, 10), device)
+	cmd.Stdout = f
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to `%+v` in utility VM: %s", cmd.Spec.Args, err)
+	}
+	if fi, err := f.Stat(); err != nil {
+		return err
+	} else if fi.Size() != size {
+		return fmt.Errorf("copied %d bytes of a %d byte file system", fi.Size(), size)
+	}
+	if err := tar2ext4.ConvertToVhd(f); err != nil {
+		return fmt.Errorf("failed to append VHD footer to %s: %s", destFile, err)
+	}
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"dest": destFile,
+		"size": size,
+	}).Debug("lcow::ConvertTarToVhd complete")
+	return nil
+}
+
+// fileSystemSize reads the superblock of the file system of `format` on
+// `device` and returns the size of the file system in bytes. The superblock is
+// validated on the host as the device was written by the guest.
+func fileSystemSize(ctx context.Context, lcowUVM *uvm.UtilityVM, device string, format LayerFormat) (int64, error) {
+	cmd := cmdpkg.CommandContext(ctx, lcowUVM, "dd", "if="+device, "bs=1024", "skip=1", "count=1", "status=none")
+	sb, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to `%+v` in utility VM: %s", cmd.Spec.Args, err)
+	}
+	if len(sb) != superblockOffset {
+		return 0, fmt.Errorf("short superblock read of %d bytes", len(sb))
+	}
+
+	var blockSize, blocks uint64
+	switch format {
+	case LayerFormatExt4:
+		if binary.LittleEndian.Uint16(sb[ext4MagicOffset:]) != ext4Magic {
+			return 0, errors.New("invalid ext4 superblock")
+		}
+		logBlockSize := binary.LittleEndian.Uint32(sb[ext4LogBlockOffset:])
+		if logBlockSize > 6 {
+			return 0, fmt.Errorf("invalid ext4 block size %d", logBlockSize)
+		}
+		blockSize = 1024 << logBlockSize
+		blocks = uint64(binary.LittleEndian.Uint32(sb[ext4BlocksOffset:]))
+	case LayerFormatErofs:
+		if binary.LittleEndian.Uint32(sb[erofsMagicOffset:]) != erofsMagic {
+			return 0, errors.New("invalid EROFS superblock")
+		}
+		blkSzBits := sb[erofsBlkSzBitsOffset]
+		if blkSzBits < 9 || blkSzBits > 16 {
+			return 0, fmt.Errorf("invalid EROFS block size bits %d", blkSzBits)
+		}
+		blockSize = 1 << blkSzBits
+		blocks = uint64(binary.LittleEndian.Uint32(sb[erofsBlocksOffset:]))
+	}
+	return int64(blocks * blockSize), nil
+}
@@ -0,0 +1,127 @@
+package lcow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	cmdpkg "github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/timeout"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/sirupsen/logrus"
+)
+
+var volumeNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateVolumeName returns an error if `name` can not be used as the name of
+// a pod volume.
+func ValidateVolumeName(name string) error {
+	if !volumeNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid pod volume name %q", name)
+	}
+	return nil
+}
+
+// CreateVolume creates the pod volume described by `opts` in the utility VM
+// and registers it, so that containers of the pod can mount it with a
+// `volume://<name>` mount source. The VHD of a scratch backed volume is
+// created in `hostDir`.
+func CreateVolume(ctx context.Context, lcowUVM *uvm.UtilityVM, opts *uvm.VolumeOptions, hostDir string) (err error) {
+	if err := ValidateVolumeName(opts.Name); err != nil {
+		return err
+	}
+	v := &uvm.Volume{
+		VolumeOptions: *opts,
+		UVMPath:       fmt.Sprintf(uvm.LCOWVolumePathFmt, opts.Name),
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"name":   v.Name,
+		"medium": v.Medium,
+		"size":   v.SizeInBytes,
+	}).Debug("lcow::CreateVolume")
+
+	switch v.Medium {
+	case uvm.VolumeMediumTmpfs:
+		if err := runInUvm(ctx, lcowUVM, "mkdir", "-p", v.UVMPath); err != nil {
+			return err
+		}
+		args := []string{"mount", "-t", "tmpfs"}
+		if v.SizeInBytes != 0 {
+			args = append(args, "-o", "size="+strconv.FormatUint(v.SizeInBytes, 10))
+		}
+		if err := runInUvm(ctx, lcowUVM, append(args, "tmpfs", v.UVMPath)...); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				_ = runInUvm(ctx, lcowUVM, "umount", v.UVMPath)
+			}
+		}()
+	case uvm.VolumeMediumScratch:
+		sizeGB := uint32(DefaultScratchSizeGB)
+		if v.SizeInBytes != 0 {
+			sizeGB = uint32((v.SizeInBytes + 1<<30 - 1) >> 30)
+		}
+		v.HostPath = filepath.Join(hostDir, fmt.Sprintf("volume-%s.vhdx", v.Name))
+		if err := CreateScratch(ctx, lcowUVM, v.HostPath, sizeGB, ""); err != nil {
+			return err
+		}
+		if _, err := lcowUVM.AddSCSI(ctx, v.HostPath, v.UVMPath, false, uvm.VMAccessTypeIndividual); err != nil {
+			os.Remove(v.HostPath)
+			return err
+		}
+		defer func() {
+			if err != nil {
+				_ = lcowUVM.RemoveSCSI(ctx, v.HostPath)
+				os.Remove(v.HostPath)
+			}
+		}()
+	default:
+		return fmt.Errorf("unsupported pod volume medium %q", v.Medium)
+	}
+	return lcowUVM.RegisterVolume(v)
+}
+
+// DestroyVolume unmounts the pod volume `name` from the utility VM and removes
+// it. Volumes that are not destroyed are removed when the utility VM is
+// closed.
+func DestroyVolume(ctx context.Context, lcowUVM *uvm.UtilityVM, name string) error {
+	v, err := lcowUVM.Volume(name)
+	if err != nil {
+		return err
+	}
+	switch v.Medium {
+	case uvm.VolumeMediumTmpfs:
+		if err := runInUvm(ctx, lcowUVM, "umount", v.UVMPath); err != nil {
+			return err
+		}
+	case uvm.VolumeMediumScratch:
+		if err := lcowUVM.RemoveSCSI(ctx, v.HostPath); err != nil {
+			return err
+		}
+		if err := os.Remove(v.HostPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	lcowUVM.UnregisterVolume(name)
+	return nil
+}
+
+// runInUvm runs a command in the utility VM that is expected to complete
+// quickly.
+func runInUvm(ctx context.Context, lcowUVM *uvm.UtilityVM, args ...string) error {
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	defer cancel()
+	cmd := cmdpkg.CommandContext(cmdCtx, lcowUVM, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to `%+v` in utility VM: %s. detailed error: %s", cmd.Spec.Args, err, stderr.String())
+	}
+	return nil
+}
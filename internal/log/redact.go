@@ -0,0 +1,171 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactionConfigEnv is the environment variable that names the JSON file
+// holding the RedactionConfig applied by SetupRedactionFromEnvironment.
+const RedactionConfigEnv = "HCSSHIM_LOG_REDACTION_CONFIG"
+
+// Redacted replaces the redacted values.
+const Redacted = "<redacted>"
+
+// RedactionConfig is the operator configuration of what is redacted from log
+// fields and span attributes before they reach any output or hook.
+type RedactionConfig struct {
+	// Fields are the keys of fields whose whole value is redacted, for
+	// example "args" or "configuration".
+	Fields []string `json:"fields,omitempty"`
+	// Patterns are regular expressions matched against every string value
+	// and the log message. If a pattern has capture groups only the groups
+	// are redacted, otherwise the whole match is.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Redactor redacts the values selected by a RedactionConfig.
+type Redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor for `config`.
+func NewRedactor(config *RedactionConfig) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]struct{})}
+	for _, f := range config.Fields {
+		r.fields[f] = struct{}{}
+	}
+	for _, p := range config.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %s", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// RedactString returns `s` with every match of the redaction patterns
+// redacted. A nil Redactor redacts nothing.
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, Redacted)
+			continue
+		}
+		var out []byte
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			// m[0:2] is the whole match, the groups follow it.
+			for g := 2; g+1 < len(m); g += 2 {
+				if m[g] < 0 || m[g] < last {
+					continue
+				}
+				out = append(out, s[last:m[g]]...)
+				out = append(out, Redacted...)
+				last = m[g+1]
+			}
+		}
+		s = string(append(out, s[last:]...))
+	}
+	return s
+}
+
+// RedactField returns the value to log for the field `key` with value `v`. A
+// nil Redactor redacts nothing.
+func (r *Redactor) RedactField(key string, v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+	if _, ok := r.fields[key]; ok {
+		return Redacted
+	}
+	if len(r.patterns) == 0 {
+		return v
+	}
+	switch t := v.(type) {
+	case string:
+		return r.RedactString(t)
+	case error:
+		return r.RedactString(t.Error())
+	case fmt.Stringer:
+		return r.RedactString(t.String())
+	}
+	return v
+}
+
+// RedactionHook is a logrus hook that redacts the fields and message of
+// every entry. It must be added before any hook that writes entries out.
+type RedactionHook struct {
+	r *Redactor
+}
+
+var _ logrus.Hook = &RedactionHook{}
+
+// NewRedactionHook returns a hook that redacts entries with `r`.
+func NewRedactionHook(r *Redactor) *RedactionHook {
+	return &RedactionHook{r: r}
+}
+
+// Levels returns all levels.
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts `e`. The fields are replaced rather than modified in place
+// as the map may be shared with the entry the caller logged from.
+func (h *RedactionHook) Fire(e *logrus.Entry) error {
+	data := make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		data[k] = h.r.RedactField(k, v)
+	}
+	e.Data = data
+	e.Message = h.r.RedactString(e.Message)
+	return nil
+}
+
+// environmentRedactor is the Redactor set up by SetupRedactionFromEnvironment.
+var environmentRedactor *Redactor
+
+// EnvironmentRedactor returns the Redactor of the RedactionHook added by
+// SetupRedactionFromEnvironment, or nil if none was, for the exporters that
+// write out data without going through the standard logger.
+func EnvironmentRedactor() *Redactor {
+	return environmentRedactor
+}
+
+// SetupRedactionFromEnvironment adds a RedactionHook to the standard logger
+// if `RedactionConfigEnv` names a redaction config file. Spans exported with
+// the oc.LogrusExporter are redacted as well as their attributes become log
+// fields, and the oc.ETWExporter redacts spans with EnvironmentRedactor. It
+// must be called before any other hook is added.
+func SetupRedactionFromEnvironment() error {
+	path := os.Getenv(RedactionConfigEnv)
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read redaction config: %s", err)
+	}
+	var config RedactionConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return fmt.Errorf("failed to parse redaction config %s: %s", path, err)
+	}
+	r, err := NewRedactor(&config)
+	if err != nil {
+		return err
+	}
+	logrus.AddHook(NewRedactionHook(r))
+	environmentRedactor = r
+	return nil
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func Test_Redactor(t *testing.T) {
+	r, err := NewRedactor(&RedactionConfig{
+		Fields:   []string{"args"},
+		Patterns: []string{`PASSWORD=(\S+)`, `secret-[0-9]+`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{key: "args", value: "mysql -p hunter2", expected: Redacted},
+		{key: "env", value: "PATH=C:\\ PASSWORD=hunter2 USER=a", expected: "PATH=C:\\ PASSWORD=" + Redacted + " USER=a"},
+		{key: "error", value: errors.New("bad token secret-1234"), expected: "bad token " + Redacted},
+		{key: "pid", value: 10, expected: 10},
+	}
+	for _, tt := range tests {
+		if v := r.RedactField(tt.key, tt.value); v != tt.expected {
+			t.Errorf("RedactField(%q, %v) = %v, expected %v", tt.key, tt.value, v, tt.expected)
+		}
+	}
+}
+
+func Test_RedactionHook(t *testing.T) {
+	r, err := NewRedactor(&RedactionConfig{Fields: []string{"configuration"}, Patterns: []string{`hunter2`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := logrus.Fields{"configuration": `{"Environment":{"A":"B"}}`, "cid": "c1"}
+	e := &logrus.Entry{Data: data, Message: "login hunter2"}
+	if err := NewRedactionHook(r).Fire(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Data["configuration"] != Redacted || e.Data["cid"] != "c1" || e.Message != "login "+Redacted {
+		t.Fatalf("unexpected redacted entry %v %q", e.Data, e.Message)
+	}
+	if data["configuration"] == Redacted {
+		t.Fatal("expected the caller's fields to be left alone")
+	}
+}
+
+func Test_NewRedactor_InvalidPattern(t *testing.T) {
+	if _, err := NewRedactor(&RedactionConfig{Patterns: []string{"("}}); err == nil {
+		t.Fatal("expected invalid pattern to fail")
+	}
+}
+
+func Test_Redactor_Nil(t *testing.T) {
+	var r *Redactor
+	if v := r.RedactField("args", "mysql -p hunter2"); v != "mysql -p hunter2" {
+		t.Fatalf("expected a nil Redactor to leave fields alone, got %v", v)
+	}
+	if s := r.RedactString("PASSWORD=hunter2"); s != "PASSWORD=hunter2" {
+		t.Fatalf("expected a nil Redactor to leave strings alone, got %q", s)
+	}
+}
@@ -0,0 +1,57 @@
+// +build windows
+
+package oc
+
+import (
+	"fmt"
+
+	"github.com/Microsoft/go-winio/pkg/etw"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"go.opencensus.io/trace"
+)
+
+var _ = (trace.Exporter)(&ETWExporter{})
+
+// ETWExporter is an OpenCensus `trace.Exporter` that exports `trace.SpanData`
+// as ETW events of `Provider`, so that the duration of operations such as
+// utility VM start, layer mounts and bridge RPCs can be analyzed with WPA.
+//
+// Each span is written as a `Span` event with the span name, start time,
+// duration in microseconds, IDs and attributes as fields. The event is
+// written at `etw.LevelInfo`, or `etw.LevelError` for a span with a non-zero
+// status. The attributes and error are redacted with `Redactor`, as the
+// LogrusExporter spans are by the redaction hook.
+//
+// The logrus hook of `Provider`, if any, must be wrapped with SkipSpansHook so
+// that the spans of a LogrusExporter are not written to `Provider` a second
+// time.
+type ETWExporter struct {
+	Provider *etw.Provider
+	Redactor *log.Redactor
+}
+
+// ExportSpan exports `s` if `e.Provider` is enabled for its level.
+func (e *ETWExporter) ExportSpan(s *trace.SpanData) {
+	level := etw.LevelInfo
+	if s.Status.Code != 0 {
+		level = etw.LevelError
+	}
+	if !e.Provider.IsEnabledForLevel(level) {
+		return
+	}
+	fields := []etw.FieldOpt{
+		etw.StringField("Name", s.Name),
+		etw.Time("StartTime", s.StartTime),
+		etw.Uint64Field("DurationMicroseconds", uint64(s.EndTime.Sub(s.StartTime).Microseconds())),
+		etw.StringField("TraceID", s.TraceID.String()),
+		etw.StringField("SpanID", s.SpanID.String()),
+		etw.StringField("ParentSpanID", s.ParentSpanID.String()),
+	}
+	if s.Status.Code != 0 {
+		fields = append(fields, etw.StringField("Error", e.Redactor.RedactString(s.Status.Message)))
+	}
+	for k, v := range s.Attributes {
+		fields = append(fields, etw.StringField(k, fmt.Sprint(e.Redactor.RedactField(k, v))))
+	}
+	_ = e.Provider.WriteEvent("Span", etw.WithEventOpts(etw.WithLevel(level)), fields)
+}
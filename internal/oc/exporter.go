@@ -7,6 +7,10 @@ import (
 
 var _ = (trace.Exporter)(&LogrusExporter{})
 
+// spanMessage is the message of the entries of the spans exported by
+// LogrusExporter.
+const spanMessage = "Span"
+
 // LogrusExporter is an OpenCensus `trace.Exporter` that exports
 // `trace.SpanData` to logrus output.
 type LogrusExporter struct {
@@ -39,5 +43,20 @@ func (le *LogrusExporter) ExportSpan(s *trace.SpanData) {
 		level = logrus.ErrorLevel
 		baseEntry.Data[logrus.ErrorKey] = s.Status.Message
 	}
-	baseEntry.Log(level, "Span")
+	baseEntry.Log(level, spanMessage)
+}
+
+// SkipSpansHook wraps a logrus hook so that it is not fired for the entries of
+// the spans exported by LogrusExporter, such as the ETW hook of a provider that
+// an ETWExporter writes the spans to already.
+type SkipSpansHook struct {
+	logrus.Hook
+}
+
+// Fire fires the wrapped hook unless `e` is the entry of a span.
+func (h *SkipSpansHook) Fire(e *logrus.Entry) error {
+	if _, ok := e.Data["spanID"]; ok && e.Message == spanMessage {
+		return nil
+	}
+	return h.Hook.Fire(e)
 }
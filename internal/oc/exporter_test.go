@@ -0,0 +1,43 @@
+package oc
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// countHook counts the entries it is fired for.
+type countHook struct {
+	fired []*logrus.Entry
+}
+
+func (h *countHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *countHook) Fire(e *logrus.Entry) error {
+	h.fired = append(h.fired, e)
+	return nil
+}
+
+func Test_SkipSpansHook(t *testing.T) {
+	inner := &countHook{}
+	logger := logrus.StandardLogger()
+	hooks := logger.ReplaceHooks(make(logrus.LevelHooks))
+	defer logger.ReplaceHooks(hooks)
+	logger.AddHook(&SkipSpansHook{Hook: inner})
+
+	(&LogrusExporter{}).ExportSpan(&trace.SpanData{Name: "Start"})
+	logrus.Info(spanMessage)
+	logrus.WithField("spanID", "0").Info("not a span")
+
+	if len(inner.fired) != 2 {
+		t.Fatalf("expected the hook to be fired for 2 entries, got: %d", len(inner.fired))
+	}
+	for _, e := range inner.fired {
+		if _, ok := e.Data["name"]; ok {
+			t.Fatalf("expected the span entry to be skipped, got: %v", e.Data)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package oci
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCPUSet parses a Linux cpuset list such as `0-2,4` as used by
+// `spec.Linux.Resources.CPU.Cpus` and `Mems`, returning the sorted and
+// de-duplicated set of indexes it contains.
+func ParseCPUSet(s string) ([]uint32, error) {
+	set := make(map[uint32]bool)
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		bounds := strings.SplitN(r, "-", 2)
+		start, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpuset %q: %s", s, err)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseUint(bounds[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpuset %q: %s", s, err)
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid cpuset %q: range %s is reversed", s, r)
+			}
+		}
+		for i := start; i <= end; i++ {
+			set[uint32(i)] = true
+		}
+	}
+	result := make([]uint32, 0, len(set))
+	for i := range set {
+		result = append(result, i)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
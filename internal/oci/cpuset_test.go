@@ -0,0 +1,30 @@
+package oci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseCPUSet(t *testing.T) {
+	for s, expected := range map[string][]uint32{
+		"":         {},
+		"0":        {0},
+		"0-2,4":    {0, 1, 2, 4},
+		"4, 1-2,2": {1, 2, 4},
+		"3-3":      {3},
+		"0,,1":     {0, 1},
+	} {
+		actual, err := ParseCPUSet(s)
+		if err != nil {
+			t.Fatalf("ParseCPUSet(%q) failed: %s", s, err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("ParseCPUSet(%q) = %v, expected %v", s, actual, expected)
+		}
+	}
+	for _, s := range []string{"a", "2-1", "1-", "-1", "0-x"} {
+		if _, err := ParseCPUSet(s); err == nil {
+			t.Fatalf("ParseCPUSet(%q) should have failed", s)
+		}
+	}
+}
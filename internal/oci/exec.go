@@ -0,0 +1,126 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// execProcess holds the fields of the OCI process of an exec, as sent by the
+// client, that are not part of `specs.Process`.
+type execProcess struct {
+	// Seccomp is the seccomp profile that the LCOW guest applies to the exec
+	// instead of the seccomp profile of its container, so that debug execs
+	// can be restricted differently than the init process.
+	Seccomp *specs.LinuxSeccomp `json:"seccomp,omitempty"`
+}
+
+// ParseExecSeccomp parses the seccomp profile of an exec from the JSON encoded
+// OCI process `process` of the exec request. If the process has no `seccomp`
+// field returns `nil, nil`.
+func ParseExecSeccomp(process []byte) (*specs.LinuxSeccomp, error) {
+	var p execProcess
+	if err := json.Unmarshal(process, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse exec seccomp profile: %s", err)
+	}
+	if p.Seccomp != nil && p.Seccomp.DefaultAction == "" {
+		return nil, errors.New("invalid exec seccomp profile: missing defaultAction")
+	}
+	return p.Seccomp, nil
+}
+
+// ValidateExecCapabilities checks that the capabilities of the exec process
+// `p` do not exceed those of the init process `init` of its container. If `p`
+// has no capabilities it runs with the capabilities of the init process.
+func ValidateExecCapabilities(init, p *specs.Process) error {
+	if p.Capabilities == nil {
+		return nil
+	}
+	var caps specs.LinuxCapabilities
+	if init != nil && init.Capabilities != nil {
+		caps = *init.Capabilities
+	}
+	for _, set := range []struct {
+		name       string
+		exec, init []string
+	}{
+		{"bounding", p.Capabilities.Bounding, caps.Bounding},
+		{"effective", p.Capabilities.Effective, caps.Effective},
+		{"inheritable", p.Capabilities.Inheritable, caps.Inheritable},
+		{"permitted", p.Capabilities.Permitted, caps.Permitted},
+		{"ambient", p.Capabilities.Ambient, caps.Ambient},
+	} {
+		allowed := make(map[string]bool, len(set.init))
+		for _, c := range set.init {
+			allowed[c] = true
+		}
+		for _, c := range set.exec {
+			if !allowed[c] {
+				return fmt.Errorf("exec capability %s is not in the %s set of the init process", c, set.name)
+			}
+		}
+	}
+	return nil
+}
+
+// ProcessEncodingError is returned by NormalizeProcessEncoding for an argument
+// or environment variable of a Linux process that can not be passed to the
+// process.
+type ProcessEncodingError struct {
+	// Field is the field of the process, `args` or `env`.
+	Field string
+	// Index is the index of the value in the field.
+	Index int
+	// Reason describes what is wrong with the value.
+	Reason string
+}
+
+func (e *ProcessEncodingError) Error() string {
+	return fmt.Sprintf("invalid process %s[%d]: %s", e.Field, e.Index, e.Reason)
+}
+
+// NormalizeProcessEncoding checks the arguments and environment of the Linux
+// process `p` before it is sent to the guest, so that a malformed value fails
+// with a `*ProcessEncodingError` naming it rather than deep in the guest.
+//
+// A value with a NUL byte can not be passed to a Linux process and neither can
+// an environment variable that is not of the form `NAME=value`, so these are
+// rejected. A value that is not valid UTF-8 can not be encoded in the JSON sent
+// to the guest, so its invalid bytes are replaced by the Unicode replacement
+// character, as the JSON encoding would do silently, and a warning is logged.
+func NormalizeProcessEncoding(ctx context.Context, p *specs.Process) error {
+	if p == nil {
+		return nil
+	}
+	normalize := func(field string, values []string) error {
+		for i, v := range values {
+			if strings.IndexByte(v, 0) != -1 {
+				return &ProcessEncodingError{Field: field, Index: i, Reason: "contains a NUL byte"}
+			}
+			if field == "env" {
+				if strings.IndexByte(v, '=') <= 0 {
+					return &ProcessEncodingError{Field: field, Index: i, Reason: "is not of the form NAME=value"}
+				}
+			}
+			if !utf8.ValidString(v) {
+				log.G(ctx).WithFields(logrus.Fields{
+					"field": field,
+					"index": i,
+				}).Warning("replacing the invalid UTF-8 bytes of a process value")
+				values[i] = strings.ToValidUTF8(v, string(utf8.RuneError))
+			}
+		}
+		return nil
+	}
+	if err := normalize("args", p.Args); err != nil {
+		return err
+	}
+	return normalize("env", p.Env)
+}
@@ -0,0 +1,82 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func Test_NormalizeProcessEncoding(t *testing.T) {
+	p := &specs.Process{
+		Args: []string{"sh", "-c", "echo \xff"},
+		Env:  []string{"PATH=/bin", "EMPTY="},
+	}
+	if err := NormalizeProcessEncoding(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"sh", "-c", "echo �"}; !reflect.DeepEqual(p.Args, expected) {
+		t.Fatalf("expected args %q, got %q", expected, p.Args)
+	}
+
+	for _, p := range []*specs.Process{
+		{Args: []string{"sh", "a\x00b"}},
+		{Args: []string{"sh"}, Env: []string{"PATH"}},
+		{Args: []string{"sh"}, Env: []string{"=value"}},
+	} {
+		var perr *ProcessEncodingError
+		if err := NormalizeProcessEncoding(context.Background(), p); !errors.As(err, &perr) {
+			t.Fatalf("expected a ProcessEncodingError for %+v, got %v", p, err)
+		}
+	}
+}
+
+func Test_ParseExecSeccomp(t *testing.T) {
+	seccomp, err := ParseExecSeccomp([]byte(`{"args":["sh"]}`))
+	if err != nil || seccomp != nil {
+		t.Fatalf("expected no seccomp profile, got %+v, %v", seccomp, err)
+	}
+	seccomp, err = ParseExecSeccomp([]byte(`{"args":["sh"],"seccomp":{"defaultAction":"SCMP_ACT_ERRNO"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seccomp == nil || seccomp.DefaultAction != specs.ActErrno {
+		t.Fatalf("expected the exec seccomp profile, got %+v", seccomp)
+	}
+	for _, process := range []string{`{"seccomp":{}}`, `{"seccomp":1}`} {
+		if _, err := ParseExecSeccomp([]byte(process)); err == nil {
+			t.Fatalf("expected %s to fail", process)
+		}
+	}
+}
+
+func Test_ValidateExecCapabilities(t *testing.T) {
+	init := &specs.Process{
+		Capabilities: &specs.LinuxCapabilities{
+			Bounding:  []string{"CAP_CHOWN", "CAP_KILL"},
+			Effective: []string{"CAP_CHOWN", "CAP_KILL"},
+		},
+	}
+	for _, p := range []*specs.Process{
+		{},
+		{Capabilities: &specs.LinuxCapabilities{}},
+		{Capabilities: &specs.LinuxCapabilities{Bounding: []string{"CAP_KILL"}, Effective: []string{"CAP_KILL"}}},
+	} {
+		if err := ValidateExecCapabilities(init, p); err != nil {
+			t.Fatalf("expected %+v to be allowed, got %v", p.Capabilities, err)
+		}
+	}
+	for _, p := range []*specs.Process{
+		{Capabilities: &specs.LinuxCapabilities{Bounding: []string{"CAP_SYS_ADMIN"}}},
+		{Capabilities: &specs.LinuxCapabilities{Effective: []string{"CAP_CHOWN"}, Ambient: []string{"CAP_CHOWN"}}},
+	} {
+		if err := ValidateExecCapabilities(init, p); err == nil {
+			t.Fatalf("expected %+v to be rejected", p.Capabilities)
+		}
+	}
+	if err := ValidateExecCapabilities(nil, &specs.Process{Capabilities: &specs.LinuxCapabilities{Bounding: []string{"CAP_KILL"}}}); err == nil {
+		t.Fatal("expected capabilities of an init process without capabilities to be rejected")
+	}
+}
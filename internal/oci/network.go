@@ -0,0 +1,93 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	// AnnotationNetworkEgressBandwidth is the maximum egress rate in bits per
+	// second that the LCOW guest allows on the container's network interface.
+	AnnotationNetworkEgressBandwidth = "io.microsoft.container.network.egress.bandwidth"
+	// AnnotationNetworkEgressBurst is the burst size in bytes allowed above
+	// `AnnotationNetworkEgressBandwidth`. Only valid when the bandwidth is
+	// also set. If omitted the guest picks a burst based on the rate.
+	AnnotationNetworkEgressBurst = "io.microsoft.container.network.egress.burst"
+)
+
+// ParseAnnotationsNetworkEgressShaping searches `s.Annotations` for the
+// egress bandwidth and burst annotations. If the bandwidth is not set both
+// values are returned as `0`.
+func ParseAnnotationsNetworkEgressShaping(ctx context.Context, s *specs.Spec) (bandwidth, burst uint64) {
+	bandwidth = parseAnnotationsUint64(ctx, s.Annotations, AnnotationNetworkEgressBandwidth, 0)
+	burst = parseAnnotationsUint64(ctx, s.Annotations, AnnotationNetworkEgressBurst, 0)
+	if bandwidth == 0 && burst != 0 {
+		log.G(ctx).WithField(logfields.OCIAnnotation, AnnotationNetworkEgressBurst).Warning("annotation ignored without an egress bandwidth")
+		burst = 0
+	}
+	return bandwidth, burst
+}
+
+// AnnotationNetworkPolicy is a JSON encoded `guestrequest.NetworkPolicy` that
+// the LCOW guest programs into the container's network namespace. This gives
+// pods basic egress filtering without an external CNI chain.
+//
+// Example: `{"DefaultAction":"deny","EgressRules":[{"CIDR":"10.0.0.0/8","Protocol":"tcp","Ports":[443]}]}`
+const AnnotationNetworkPolicy = "io.microsoft.container.network.policy"
+
+// ParseAnnotationsNetworkPolicy searches `s.Annotations` for the network
+// policy annotation and if found validates and normalizes the policy. If the
+// annotation is not found returns `nil, nil`.
+func ParseAnnotationsNetworkPolicy(s *specs.Spec) (*guestrequest.NetworkPolicy, error) {
+	v, ok := s.Annotations[AnnotationNetworkPolicy]
+	if !ok {
+		return nil, nil
+	}
+	policy := &guestrequest.NetworkPolicy{}
+	if err := json.Unmarshal([]byte(v), policy); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", AnnotationNetworkPolicy, err)
+	}
+
+	switch guestrequest.NetworkPolicyAction(strings.ToLower(string(policy.DefaultAction))) {
+	case "", guestrequest.NetworkPolicyActionAllow:
+		policy.DefaultAction = guestrequest.NetworkPolicyActionAllow
+	case guestrequest.NetworkPolicyActionDeny:
+		policy.DefaultAction = guestrequest.NetworkPolicyActionDeny
+	default:
+		return nil, fmt.Errorf("invalid '%s': unknown default action '%s'", AnnotationNetworkPolicy, policy.DefaultAction)
+	}
+
+	for i, rule := range policy.EgressRules {
+		_, ipNet, err := net.ParseCIDR(rule.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s': rule %d: %s", AnnotationNetworkPolicy, i, err)
+		}
+		policy.EgressRules[i].CIDR = ipNet.String()
+
+		protocol := strings.ToLower(rule.Protocol)
+		switch protocol {
+		case "", "tcp", "udp":
+		default:
+			return nil, fmt.Errorf("invalid '%s': rule %d: unsupported protocol '%s'", AnnotationNetworkPolicy, i, rule.Protocol)
+		}
+		policy.EgressRules[i].Protocol = protocol
+
+		if len(rule.Ports) > 0 && protocol == "" {
+			return nil, fmt.Errorf("invalid '%s': rule %d: ports require a protocol", AnnotationNetworkPolicy, i)
+		}
+		for _, p := range rule.Ports {
+			if p == 0 {
+				return nil, fmt.Errorf("invalid '%s': rule %d: port 0 is not allowed", AnnotationNetworkPolicy, i)
+			}
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,100 @@
+package oci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func Test_ParseAnnotationsNetworkPolicy_NoAnnotation(t *testing.T) {
+	s := &specs.Spec{
+		Linux: &specs.Linux{},
+	}
+	policy, err := ParseAnnotationsNetworkPolicy(s)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if policy != nil {
+		t.Fatal("should have returned nil policy when annotation is not provided")
+	}
+}
+
+func Test_ParseAnnotationsNetworkPolicy_Normalized(t *testing.T) {
+	s := &specs.Spec{
+		Linux: &specs.Linux{},
+		Annotations: map[string]string{
+			AnnotationNetworkPolicy: `{"DefaultAction":"Deny","EgressRules":[{"CIDR":"10.1.2.3/8","Protocol":"TCP","Ports":[443]}]}`,
+		},
+	}
+	policy, err := ParseAnnotationsNetworkPolicy(s)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if policy.DefaultAction != guestrequest.NetworkPolicyActionDeny {
+		t.Fatalf("expected default action deny, got: %s", policy.DefaultAction)
+	}
+	if len(policy.EgressRules) != 1 {
+		t.Fatalf("expected 1 egress rule, got: %d", len(policy.EgressRules))
+	}
+	if policy.EgressRules[0].CIDR != "10.0.0.0/8" {
+		t.Fatalf("expected CIDR to be normalized, got: %s", policy.EgressRules[0].CIDR)
+	}
+	if policy.EgressRules[0].Protocol != "tcp" {
+		t.Fatalf("expected protocol to be normalized, got: %s", policy.EgressRules[0].Protocol)
+	}
+}
+
+func Test_ParseAnnotationsNetworkPolicy_Invalid(t *testing.T) {
+	for _, v := range []string{
+		`not json`,
+		`{"DefaultAction":"drop"}`,
+		`{"EgressRules":[{"CIDR":"10.0.0.1"}]}`,
+		`{"EgressRules":[{"CIDR":"10.0.0.0/8","Protocol":"icmp"}]}`,
+		`{"EgressRules":[{"CIDR":"10.0.0.0/8","Ports":[80]}]}`,
+		`{"EgressRules":[{"CIDR":"10.0.0.0/8","Protocol":"udp","Ports":[0]}]}`,
+	} {
+		s := &specs.Spec{
+			Linux: &specs.Linux{},
+			Annotations: map[string]string{
+				AnnotationNetworkPolicy: v,
+			},
+		}
+		if _, err := ParseAnnotationsNetworkPolicy(s); err == nil {
+			t.Fatalf("should have failed with error for policy: %s", v)
+		}
+	}
+}
+
+func Test_ParseAnnotationsNetworkEgressShaping(t *testing.T) {
+	for _, tc := range []struct {
+		bandwidth, burst       string
+		expBandwidth, expBurst uint64
+	}{
+		{"", "", 0, 0},
+		{"1000000", "", 1000000, 0},
+		{"1000000", "65536", 1000000, 65536},
+		{"18446744073709551615", "1", 18446744073709551615, 1},
+		// Invalid and out of range values are ignored.
+		{"fast", "65536", 0, 0},
+		{"-1", "", 0, 0},
+		{"18446744073709551616", "", 0, 0},
+		{"1000000", "-1", 1000000, 0},
+		// The burst is ignored without a bandwidth.
+		{"", "65536", 0, 0},
+		{"0", "65536", 0, 0},
+	} {
+		a := make(map[string]string)
+		if tc.bandwidth != "" {
+			a[AnnotationNetworkEgressBandwidth] = tc.bandwidth
+		}
+		if tc.burst != "" {
+			a[AnnotationNetworkEgressBurst] = tc.burst
+		}
+		bandwidth, burst := ParseAnnotationsNetworkEgressShaping(context.Background(), &specs.Spec{Annotations: a})
+		if bandwidth != tc.expBandwidth || burst != tc.expBurst {
+			t.Fatalf("bandwidth %q and burst %q: expected %d and %d, got %d and %d", tc.bandwidth, tc.burst, tc.expBandwidth, tc.expBurst, bandwidth, burst)
+		}
+	}
+}
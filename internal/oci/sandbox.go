@@ -1,7 +1,11 @@
 package oci
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // KubernetesContainerTypeAnnotation is the annotation used by CRI to define the `ContainerType`.
@@ -11,6 +15,153 @@ const KubernetesContainerTypeAnnotation = "io.kubernetes.cri.container-type"
 // KubernetesContainerTypeAnnotation == "sandbox"` ID.
 const KubernetesSandboxIDAnnotation = "io.kubernetes.cri.sandbox-id"
 
+// KubernetesContainerNameAnnotation is the annotation used by CRI to define
+// the name of a container in its pod.
+const KubernetesContainerNameAnnotation = "io.kubernetes.cri.container-name"
+
+// AnnotationStartAfter is a comma separated list of containers of the same pod
+// that must reach a condition before the container is started, each of the
+// form `<container>[:<condition>]`. A container is named by its ID or by its
+// CRI container name and the condition is one of `StartCondition`, defaulting
+// to `completed`.
+//
+// Example: `init-certs:completed,proxy:started`
+const AnnotationStartAfter = "io.microsoft.container.startafter"
+
+// StartCondition is the condition a container must reach before the
+// containers that depend on it are started.
+type StartCondition string
+
+const (
+	// StartConditionStarted is met once the container's init process has
+	// started and the container has met its readiness gate, if any. Starting a
+	// dependent container fails if it exits before then.
+	StartConditionStarted StartCondition = "started"
+	// StartConditionCompleted is met once the container's init process has
+	// exited with exit code 0. Starting a dependent container fails if it
+	// exits with any other exit code.
+	StartConditionCompleted StartCondition = "completed"
+)
+
+// StartDependency is a container that must reach `Condition` before a
+// dependent container is started.
+type StartDependency struct {
+	Container string
+	Condition StartCondition
+}
+
+// ParseStartDependencies parses the `AnnotationStartAfter` annotation of
+// `specAnnotations`. If the annotation is not found returns `nil, nil`.
+func ParseStartDependencies(specAnnotations map[string]string) ([]StartDependency, error) {
+	v, ok := specAnnotations[AnnotationStartAfter]
+	if !ok {
+		return nil, nil
+	}
+	var deps []StartDependency
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		d := StartDependency{
+			Container: parts[0],
+			Condition: StartConditionCompleted,
+		}
+		if len(parts) == 2 {
+			d.Condition = StartCondition(parts[1])
+		}
+		switch d.Condition {
+		case StartConditionStarted, StartConditionCompleted:
+		default:
+			return nil, fmt.Errorf("invalid '%s': unknown condition '%s' of '%s'", AnnotationStartAfter, d.Condition, d.Container)
+		}
+		if d.Container == "" {
+			return nil, fmt.Errorf("invalid '%s': empty container in '%s'", AnnotationStartAfter, entry)
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
+
+// AnnotationReadinessGate is a condition that the container must meet before
+// its start is reported, so that a workload is not considered started until
+// it can serve. It is of the form `<kind>:<value>` where the kind is one of
+// `ReadinessKind`. The condition is checked by running a command in the
+// container, so the container image must have its binaries: `test` for
+// `file`, `grep` for `port` and `sh` for `exec` in LCOW, and `cmd` for every
+// kind in WCOW, with `netstat` and `findstr` for `port`.
+//
+// Example: `port:8080`
+const AnnotationReadinessGate = "io.microsoft.container.readiness"
+
+// AnnotationReadinessTimeoutInSeconds is the time that the container has to
+// meet its `AnnotationReadinessGate` before starting it fails. Defaults to
+// `DefaultReadinessTimeout`. For a CRI client the timeout should stay below
+// its request timeout, such as the 2 minute `--runtime-request-timeout` of the
+// kubelet, or the client gives up on the start before the gate fails it.
+const AnnotationReadinessTimeoutInSeconds = "io.microsoft.container.readiness.timeoutinseconds"
+
+// DefaultReadinessTimeout is the default time that a container has to meet
+// its readiness gate. It is below the default 2 minute request timeout of the
+// kubelet, so that a container that is never ready fails its start with the
+// readiness error rather than with the kubelet's timeout.
+const DefaultReadinessTimeout = 90 * time.Second
+
+// ReadinessKind is the kind of condition of a readiness gate.
+type ReadinessKind string
+
+const (
+	// ReadinessFile is met once the file at the path in the container exists.
+	ReadinessFile ReadinessKind = "file"
+	// ReadinessPort is met once a process in the container listens on the
+	// TCP port.
+	ReadinessPort ReadinessKind = "port"
+	// ReadinessExec is met once the command, run by the shell of the
+	// container, exits with exit code 0.
+	ReadinessExec ReadinessKind = "exec"
+)
+
+// ReadinessGate is a condition that a container must meet within `Timeout`
+// before its start is reported.
+type ReadinessGate struct {
+	Kind    ReadinessKind
+	Value   string
+	Timeout time.Duration
+}
+
+// ParseReadinessGate parses the `AnnotationReadinessGate` and
+// `AnnotationReadinessTimeoutInSeconds` annotations of `specAnnotations`. If
+// there is no readiness gate returns `nil, nil`.
+func ParseReadinessGate(ctx context.Context, specAnnotations map[string]string) (*ReadinessGate, error) {
+	v, ok := specAnnotations[AnnotationReadinessGate]
+	if !ok {
+		return nil, nil
+	}
+	parts := strings.SplitN(v, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid '%s': '%s' is not of the form '<kind>:<value>'", AnnotationReadinessGate, v)
+	}
+	g := &ReadinessGate{
+		Kind:    ReadinessKind(parts[0]),
+		Value:   parts[1],
+		Timeout: DefaultReadinessTimeout,
+	}
+	switch g.Kind {
+	case ReadinessFile, ReadinessExec:
+	case ReadinessPort:
+		if port, err := strconv.ParseUint(g.Value, 10, 16); err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid '%s': invalid port '%s'", AnnotationReadinessGate, g.Value)
+		}
+	default:
+		return nil, fmt.Errorf("invalid '%s': unknown kind '%s'", AnnotationReadinessGate, g.Kind)
+	}
+	if t := parseAnnotationsUint32(ctx, specAnnotations, AnnotationReadinessTimeoutInSeconds, 0); t != 0 {
+		g.Timeout = time.Duration(t) * time.Second
+	}
+	return g, nil
+}
+
 // KubernetesContainerType defines the valid types of the
 // `KubernetesContainerTypeAnnotation` annotation.
 type KubernetesContainerType string
@@ -1,6 +1,10 @@
 package oci
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+)
 
 func Test_GetSandboxTypeAndID_TypeContainer_NoID_Failure(t *testing.T) {
 	a := map[string]string{
@@ -96,3 +100,87 @@ func Test_GetSandboxTypeAndID_TypeSandbox_ValidID_Success(t *testing.T) {
 		t.Fatalf("should of returned valid id got: %s", id)
 	}
 }
+
+func Test_ParseStartDependencies(t *testing.T) {
+	a := map[string]string{
+		AnnotationStartAfter: "init, proxy:started ,certs:completed",
+	}
+	deps, err := ParseStartDependencies(a)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := []StartDependency{
+		{Container: "init", Condition: StartConditionCompleted},
+		{Container: "proxy", Condition: StartConditionStarted},
+		{Container: "certs", Condition: StartConditionCompleted},
+	}
+	if len(deps) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, deps)
+	}
+	for i := range deps {
+		if deps[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, deps)
+		}
+	}
+}
+
+func Test_ParseStartDependencies_InvalidCondition_Failure(t *testing.T) {
+	a := map[string]string{
+		AnnotationStartAfter: "init:ready",
+	}
+	if _, err := ParseStartDependencies(a); err == nil {
+		t.Fatal("should have failed with error")
+	}
+}
+
+func Test_ParseReadinessGate(t *testing.T) {
+	a := map[string]string{
+		AnnotationReadinessGate:             "exec:curl -f http://localhost:8080/healthz",
+		AnnotationReadinessTimeoutInSeconds: "30",
+	}
+	g, err := ParseReadinessGate(context.Background(), a)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := ReadinessGate{
+		Kind:    ReadinessExec,
+		Value:   "curl -f http://localhost:8080/healthz",
+		Timeout: 30 * time.Second,
+	}
+	if g == nil || *g != expected {
+		t.Fatalf("expected %v, got %v", expected, g)
+	}
+}
+
+func Test_ParseReadinessGate_DefaultTimeout(t *testing.T) {
+	a := map[string]string{
+		AnnotationReadinessGate: "port:8080",
+	}
+	g, err := ParseReadinessGate(context.Background(), a)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := ReadinessGate{
+		Kind:    ReadinessPort,
+		Value:   "8080",
+		Timeout: DefaultReadinessTimeout,
+	}
+	if g == nil || *g != expected {
+		t.Fatalf("expected %v, got %v", expected, g)
+	}
+	// The start must fail on the gate before the kubelet gives up on it.
+	if g.Timeout >= 2*time.Minute {
+		t.Fatalf("expected the default timeout to be below the kubelet request timeout, got %s", g.Timeout)
+	}
+}
+
+func Test_ParseReadinessGate_Invalid_Failure(t *testing.T) {
+	for _, v := range []string{"port:0", "port:http", "port:65536", "ready"} {
+		a := map[string]string{
+			AnnotationReadinessGate: v,
+		}
+		if _, err := ParseReadinessGate(context.Background(), a); err == nil {
+			t.Fatalf("%s should have failed with error", v)
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -64,8 +67,17 @@ const (
 	// `WindowsPodSandboxConfig` for setting this correctly. It should not be
 	// used via OCI runtimes and rather use `spec.Windows.Resources.CPU.Shares`.
 	AnnotationContainerProcessorWeight = "io.microsoft.container.processor.weight"
+	// AnnotationContainerProcessorCoreScheduling is the Linux core scheduling
+	// cookie of an LCOW container as sent to the guest, `container` for a
+	// cookie of its own or `group:<name>` for the cookie shared by a group of
+	// containers. It is only set by the shim from the core scheduling settings
+	// of the UVM, see `annotationCoreScheduling`, and is dropped from the spec
+	// of the container, so that a container can not join the group of another.
+	AnnotationContainerProcessorCoreScheduling = "io.microsoft.container.processor.corescheduling"
 	// AnnotationContainerStorageQoSBandwidthMaximum overrides the container
-	// storage bandwidth per second set via the OCI spec.
+	// storage bandwidth per second set via the OCI spec. For LCOW it is applied
+	// by the guest as the read and write `bps` limits of the container's
+	// `io.max` on its scratch and volume devices.
 	//
 	// Note: This is only present because CRI does not (currently) have a
 	// `WindowsPodSandboxConfig` for setting this correctly. It should not be
@@ -73,13 +85,64 @@ const (
 	// `spec.Windows.Resources.Storage.Bps`.
 	AnnotationContainerStorageQoSBandwidthMaximum = "io.microsoft.container.storage.qos.bandwidthmaximum"
 	// AnnotationContainerStorageQoSIopsMaximum overrides the container storage
-	// maximum iops set via the OCI spec.
+	// maximum iops set via the OCI spec. For LCOW it is applied by the guest as
+	// the read and write `iops` limits of the container's `io.max` on its
+	// scratch and volume devices.
 	//
 	// Note: This is only present because CRI does not (currently) have a
 	// `WindowsPodSandboxConfig` for setting this correctly. It should not be
 	// used via OCI runtimes and rather use
 	// `spec.Windows.Resources.Storage.Iops`.
 	AnnotationContainerStorageQoSIopsMaximum = "io.microsoft.container.storage.qos.iopsmaximum"
+	// AnnotationContainerStorageScratchQuotaInBytes limits the size of the
+	// writable layer of an LCOW container. It is enforced by the guest with a
+	// project quota so that containers sharing the pod's scratch cannot fill
+	// it.
+	AnnotationContainerStorageScratchQuotaInBytes = "io.microsoft.container.storage.scratch.quotainbytes"
+	// AnnotationContainerStorageScratchTmpfsSizeInBytes backs the writable
+	// layer of an LCOW container with a tmpfs of this size in the UVM instead
+	// of its scratch VHD. This is much faster for short lived containers that
+	// write a lot of data they do not keep, but the data counts against the
+	// memory of the UVM and is lost when the container exits. The size must
+	// be smaller than the memory of the UVM, and the container must not use a
+	// block device rootfs.
+	AnnotationContainerStorageScratchTmpfsSizeInBytes = "io.microsoft.container.storage.scratch.tmpfssizeinbytes"
+	// AnnotationContainerImageVolumes is a comma separated list of the absolute
+	// paths of a Windows container that its image declares as volumes, as
+	// with the Dockerfile VOLUME instruction. Each path that the spec does not
+	// already mount is mounted from a directory in the container's scratch
+	// folder, seeded with the image content at the path and removed with the
+	// container.
+	AnnotationContainerImageVolumes = "io.microsoft.container.imagevolumes"
+	// AnnotationContainerRootfsReadOnly makes the root file system of an LCOW
+	// container read-only, with a tmpfs mounted on each of the paths of
+	// `AnnotationContainerRootfsWritablePaths` that the spec does not already
+	// mount. It is enforced for every container of a UVM when the shim option
+	// `EnforceReadOnlyRootfs` is set, and can not be turned off by annotation.
+	AnnotationContainerRootfsReadOnly = "io.microsoft.container.rootfs.readonly"
+	// AnnotationContainerRootfsWritablePaths is a comma separated list of the
+	// absolute paths of a container with a read-only root file system that
+	// are kept writable with a tmpfs. Defaults to `DefaultWritablePaths`.
+	AnnotationContainerRootfsWritablePaths = "io.microsoft.container.rootfs.writablepaths"
+	// AnnotationContainerRootfsWritablePathSizeInBytes is the size of each of
+	// the tmpfs mounts on the writable paths of a container with a read-only
+	// root file system. The data written to them counts against the memory
+	// of the UVM. Defaults to `DefaultWritablePathSizeInBytes`.
+	AnnotationContainerRootfsWritablePathSizeInBytes = "io.microsoft.container.rootfs.writablepathsizeinbytes"
+	// AnnotationContainerTPM exposes the TPM of the UVM as /dev/tpm0 in an
+	// LCOW container. The UVM must be created with
+	// `io.microsoft.virtualmachine.tpm.enabled`.
+	AnnotationContainerTPM = "io.microsoft.container.tpm"
+	// AnnotationContainerTimeZone is the Windows time zone ID of a Windows
+	// container, such as `Pacific Standard Time`. If unset, a `TZ` variable
+	// in the environment of the container's process that names a Windows
+	// time zone is used instead. Otherwise the container uses the time zone
+	// of the host (or UVM).
+	AnnotationContainerTimeZone = "io.microsoft.container.timezone"
+	// AnnotationFirewallPublishedPorts creates Windows Firewall rules allowing
+	// inbound traffic to the ports published by the NAT policies of a pod's
+	// endpoints. The rules are removed when the pod is deleted.
+	AnnotationFirewallPublishedPorts = "io.microsoft.network.firewall.publishedports"
 	// AnnotationGPUVHDPath overrides the default path to search for the gpu vhd
 	AnnotationGPUVHDPath = "io.microsoft.lcow.gpuvhdpath"
 	// AnnotationAssignedDeviceKernelDrivers indicates what drivers to install in the pod during device
@@ -87,6 +150,10 @@ const (
 	// files and information needed to install given driver(s). This may include .sys,
 	// .inf, .cer, and/or other files used during standard installation with pnputil.
 	AnnotationAssignedDeviceKernelDrivers = "io.microsoft.assigneddevice.kerneldrivers"
+	// AnnotationAttachUVM is the ID of a detached persistent UVM that a pod
+	// attaches to, rather than creating a new UVM. The UVM options of the pod
+	// are ignored.
+	AnnotationAttachUVM = "io.microsoft.virtualmachine.lcow.attach"
 	// AnnotationHostProcessInheritUser indicates whether to ignore the username passed in to run a host process
 	// container as and instead inherit the user token from the executable that is launching the container process.
 	AnnotationHostProcessInheritUser = "microsoft.com/hostprocess-inherit-user"
@@ -162,6 +229,183 @@ const (
 	annotationTemplateID         = "io.microsoft.virtualmachine.templateid"
 	annotationNetworkConfigProxy = "io.microsoft.network.ncproxy"
 	AnnotationNcproxyContainerID = "io.microsoft.network.ncproxy.containerid"
+
+	// annotationEnableGuestDHCP indicates that NICs hot-added to an LCOW UVM
+	// should be configured by a DHCP client in the guest rather than with the
+	// static addressing of the HNS endpoint.
+	annotationEnableGuestDHCP = "io.microsoft.virtualmachine.lcow.enableguestdhcp"
+
+	// annotationDisableIPv6RA indicates that the LCOW guest should not accept
+	// IPv6 router advertisements on hot-added NICs and only use the static
+	// IPv6 configuration of the HNS endpoint.
+	annotationDisableIPv6RA = "io.microsoft.virtualmachine.lcow.disableipv6ra"
+
+	// annotationDNSProxy relays the DNS queries of the LCOW guest over vsock to
+	// the DNS server set by the `DnsProxyUpstream` shim runtime option. This
+	// lets pods use host resolver configuration such as split DNS or VPN
+	// without access to the host network. The pod can not choose the server.
+	annotationDNSProxy = "io.microsoft.virtualmachine.lcow.dnsproxy"
+
+	// annotationForwardedPorts is a comma separated list of guest TCP ports
+	// that are relayed from the same port on the host loopback address. This
+	// is intended for single node development setups only, and requires the
+	// external guest connection and the `AllowForwardedPorts` shim runtime
+	// option.
+	annotationForwardedPorts = "io.microsoft.virtualmachine.lcow.forwardedports"
+
+	// annotationVolumes is a comma separated list of pod volumes, each of the
+	// form `<name>:<medium>[:<size in bytes>]` with a medium of `tmpfs` or
+	// `scratch`, that are created in an LCOW UVM for the lifetime of the pod.
+	// Containers of the pod mount a volume with a `volume://<name>` source.
+	annotationVolumes = "io.microsoft.virtualmachine.lcow.volumes"
+
+	// annotationGCSWatchdogTimeout is the number of seconds after which an
+	// operation sent to the LCOW GCS without a response fails the GCS
+	// connection. Defaults to 5 minutes.
+	annotationGCSWatchdogTimeout = "io.microsoft.virtualmachine.lcow.gcswatchdogtimeout"
+
+	// annotationGCSRecoveryTimeout is the number of seconds to wait for a
+	// restarted LCOW GCS to reconnect after the GCS connection fails, rather
+	// than failing the containers of the UVM. Containers and processes that
+	// the restarted GCS still knows of keep running. Requires the external
+	// GCS bridge and a guest init that restarts the GCS.
+	annotationGCSRecoveryTimeout = "io.microsoft.virtualmachine.lcow.gcsrecoverytimeout"
+
+	// annotationPlan9ChangeNotify enables relaying the changes to the host
+	// directories of Plan9 shares to the LCOW guest, so that workloads
+	// watching mounted directories (such as configmaps and secrets) with
+	// inotify see updates without polling.
+	annotationPlan9ChangeNotify = "io.microsoft.virtualmachine.lcow.plan9changenotify"
+
+	// annotationPersistent creates an LCOW UVM that outlives the pod that
+	// created it. Once the pod's containers are removed the UVM is detached
+	// rather than terminated, and a later pod can attach to it with
+	// `AnnotationAttachUVM` rather than booting a new UVM. Requires the
+	// external GCS bridge and a guest init that restarts the GCS.
+	annotationPersistent = "io.microsoft.virtualmachine.lcow.persistent"
+
+	// annotationHvSocketAllowList is a comma separated list of the hvsocket
+	// service IDs, or vsock ports, that the LCOW guest accepts inbound
+	// connections to. All other inbound connections are refused, so that only
+	// the expected host services can open channels into a locked down pod. An
+	// empty value refuses all inbound connections. Requires the external GCS
+	// bridge and guest support.
+	annotationHvSocketAllowList = "io.microsoft.virtualmachine.lcow.hvsocket.allowlist"
+
+	// annotationScratchTrimInterval is the number of seconds between trims of
+	// the scratch disks of an LCOW UVM. Each trim discards the unused blocks
+	// of the file systems in the guest, so that long running pods return the
+	// space freed by their containers. The VHDs are compacted on the host once
+	// they are removed from the UVM.
+	// Requires the external GCS bridge and guest support.
+	annotationScratchTrimInterval = "io.microsoft.virtualmachine.lcow.scratch.trimintervalinseconds"
+
+	// annotationEmulatedArchitectures is a comma separated list of the foreign
+	// architectures, such as "arm64", whose binaries an LCOW pod runs through
+	// the qemu-user interpreters of the binfmt extension installed with the
+	// boot files. This lets the images of other architectures run in the pod,
+	// slowly, such as for multi-arch builds. Requires the external GCS bridge
+	// and guest support.
+	annotationEmulatedArchitectures = "io.microsoft.virtualmachine.lcow.emulatedarchitectures"
+
+	// annotationExtensions is a comma separated list of the names of the
+	// extension VHDs, installed in the extensions directory of the LCOW boot
+	// files, that the guest mounts at boot. Containers of the pod mount an
+	// extension with a `sandbox:///extensions/<name>` mount source. This adds
+	// optional tooling, such as debuggers or profilers, to a pod without
+	// rebuilding the root file system. Only the extensions approved in the
+	// shim options can be attached.
+	annotationExtensions = "io.microsoft.virtualmachine.lcow.extensions"
+
+	// annotationCoreScheduling gives every container of an LCOW UVM a Linux
+	// core scheduling cookie, so that the processes of a container never run on
+	// the SMT siblings of a core at the same time as the processes of another.
+	// It has no effect in a UVM without SMT, see
+	// `io.microsoft.virtualmachine.computetopology.processor.hwthreadspercore`,
+	// and creating a container fails if the guest does not support it.
+	annotationCoreScheduling = "io.microsoft.virtualmachine.lcow.corescheduling"
+
+	// annotationCoreSchedulingGroups is a comma separated list of
+	// `<container name>=<group>` entries. The containers of the pod in a group,
+	// such as containers that trust each other, share a core scheduling cookie,
+	// and the other containers get a cookie of their own. The names are the
+	// CRI container names. Requires `annotationCoreScheduling`.
+	annotationCoreSchedulingGroups = "io.microsoft.virtualmachine.lcow.corescheduling.groups"
+
+	// annotationEntropySeedBytes is the number of bytes of host random data
+	// that the entropy pool of an LCOW guest is seeded with at boot, so that
+	// workloads reading /dev/random do not block on a freshly booted kernel.
+	// 0 disables seeding.
+	annotationEntropySeedBytes = "io.microsoft.virtualmachine.lcow.entropyseedbytes"
+
+	// annotationRequireEntropySeed is whether an LCOW UVM fails to start if
+	// the entropy seed can not be delivered. Defaults to true.
+	annotationRequireEntropySeed = "io.microsoft.virtualmachine.lcow.requireentropyseed"
+
+	// annotationPlan9MSize is the maximum 9P message size negotiated by the
+	// Plan9 mounts of an LCOW UVM. Larger values speed up large reads and
+	// writes of bind mounted directories.
+	annotationPlan9MSize = "io.microsoft.virtualmachine.lcow.plan9.msize"
+
+	// annotationPlan9Cache is the v9fs cache mode of the Plan9 mounts of an
+	// LCOW UVM, `none`, `loose` or `mmap`. `loose` caches metadata and data
+	// in the guest, which greatly speeds up workloads that stat many files
+	// (such as builds), but does not see changes made on the host once a file
+	// is cached.
+	annotationPlan9Cache = "io.microsoft.virtualmachine.lcow.plan9.cache"
+
+	// annotationProcessorAffinity is a comma separated list of host logical
+	// processor indexes that the UVM's vCPUs are restricted to.
+	annotationProcessorAffinity = "io.microsoft.virtualmachine.computetopology.processor.affinity"
+
+	// annotationProcessorAffinityNUMANodes is a comma separated list of host
+	// NUMA nodes whose logical processors the UVM's vCPUs are restricted to.
+	annotationProcessorAffinityNUMANodes = "io.microsoft.virtualmachine.computetopology.processor.affinity.numanodes"
+
+	// annotationNUMANodes is a comma separated list of the virtual NUMA nodes
+	// of the UVM, each `<vCPU count>:<memory in MB>`, such as `4:8192,4:8192`.
+	// The nodes must add up to the vCPUs and memory of the UVM.
+	annotationNUMANodes = "io.microsoft.virtualmachine.computetopology.numa.nodes"
+
+	// annotationTPMEnabled adds a TPM 2.0 device to the UVM.
+	annotationTPMEnabled = "io.microsoft.virtualmachine.tpm.enabled"
+
+	// annotationEnableColdHint allows the UVM's guest to report memory it is
+	// not using to the host so that idle pods return memory aggressively.
+	// Requires io.microsoft.virtualmachine.computetopology.memory.allowovercommit.
+	annotationEnableColdHint = "io.microsoft.virtualmachine.computetopology.memory.enablecoldhint"
+
+	// annotationSCSIControllerCount is the number of SCSI controllers added to
+	// an LCOW UVM, so that pods attaching many volumes are not limited to the
+	// 64 LUNs of a single controller.
+	annotationSCSIControllerCount = "io.microsoft.virtualmachine.devices.scsi.controllercount"
+
+	// annotationProcessorReservation is the percentage of each vCPU that the
+	// host guarantees to the UVM, in the same units as
+	// io.microsoft.virtualmachine.computetopology.processor.limit.
+	annotationProcessorReservation = "io.microsoft.virtualmachine.computetopology.processor.reservation"
+
+	// annotationLatencySensitive asks the host to favor scheduling latency for
+	// the UVM's vCPUs, for jitter sensitive workloads.
+	annotationLatencySensitive = "io.microsoft.virtualmachine.computetopology.processor.latencysensitive"
+
+	// annotationProcessorHwThreadsPerCore is the number of hardware threads of
+	// each virtual core exposed to the UVM. `1` disables SMT in the guest.
+	annotationProcessorHwThreadsPerCore = "io.microsoft.virtualmachine.computetopology.processor.hwthreadspercore"
+
+	// annotationEnableNestedVirtualization exposes the virtualization
+	// extensions of the host processors to the UVM, so that containers can run
+	// KVM or Hyper-V based workloads.
+	annotationEnableNestedVirtualization = "io.microsoft.virtualmachine.computetopology.processor.exposevirtualizationextensions"
+
+	// annotationRequireCoreScheduler fails the creation of the UVM unless the
+	// host uses the core hypervisor scheduler.
+	annotationRequireCoreScheduler = "io.microsoft.virtualmachine.computetopology.processor.requirecorescheduler"
+
+	// annotationEnableLargePages backs the UVM's memory with host large pages.
+	// Requires io.microsoft.virtualmachine.fullyphysicallybacked or
+	// io.microsoft.virtualmachine.computetopology.memory.allowovercommit=false.
+	annotationEnableLargePages = "io.microsoft.virtualmachine.computetopology.memory.largepages"
 )
 
 // parseAnnotationsBool searches `a` for `key` and if found verifies that the
@@ -239,8 +483,8 @@ func ParseAnnotationsCPUWeight(ctx context.Context, s *specs.Spec, annotation st
 // annotation. If not found searches `s` for the Windows Storage section. If
 // neither are found returns `def`.
 func ParseAnnotationsStorageIops(ctx context.Context, s *specs.Spec, annotation string, def int32) int32 {
-	if m := parseAnnotationsUint64(ctx, s.Annotations, annotation, 0); m != 0 {
-		return int32(m)
+	if m := parseAnnotationsStorageLimit(ctx, s.Annotations, annotation); m != 0 {
+		return m
 	}
 	if s.Windows != nil &&
 		s.Windows.Resources != nil &&
@@ -256,8 +500,8 @@ func ParseAnnotationsStorageIops(ctx context.Context, s *specs.Spec, annotation
 // If not found searches `s` for the Windows Storage section. If neither are
 // found returns `def`.
 func ParseAnnotationsStorageBps(ctx context.Context, s *specs.Spec, annotation string, def int32) int32 {
-	if m := parseAnnotationsUint64(ctx, s.Annotations, annotation, 0); m != 0 {
-		return int32(m)
+	if m := parseAnnotationsStorageLimit(ctx, s.Annotations, annotation); m != 0 {
+		return m
 	}
 	if s.Windows != nil &&
 		s.Windows.Resources != nil &&
@@ -269,6 +513,92 @@ func ParseAnnotationsStorageBps(ctx context.Context, s *specs.Spec, annotation s
 	return def
 }
 
+// parseAnnotationsStorageLimit searches `a` for the storage QoS limit `key` and
+// if found verifies that the value is an unsigned integer that fits a limit.
+// Returns `0` if `key` is not found or the value is invalid.
+func parseAnnotationsStorageLimit(ctx context.Context, a map[string]string, key string) int32 {
+	m := parseAnnotationsUint64(ctx, a, key, 0)
+	if m > math.MaxInt32 {
+		log.G(ctx).WithFields(logrus.Fields{
+			logfields.OCIAnnotation: key,
+			logfields.Value:         a[key],
+		}).Warningf("annotation value must not be greater than %d", math.MaxInt32)
+		return 0
+	}
+	return int32(m)
+}
+
+// ParseAnnotationsStorageScratchQuota searches `s.Annotations` for the
+// scratch quota annotation. If not found returns 0, which is unlimited.
+func ParseAnnotationsStorageScratchQuota(ctx context.Context, s *specs.Spec) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, AnnotationContainerStorageScratchQuotaInBytes, 0)
+}
+
+// ParseAnnotationsStorageScratchTmpfsSize searches `s.Annotations` for the
+// tmpfs scratch size annotation. If not found returns 0, which uses the
+// scratch VHD.
+func ParseAnnotationsStorageScratchTmpfsSize(ctx context.Context, s *specs.Spec) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, AnnotationContainerStorageScratchTmpfsSizeInBytes, 0)
+}
+
+// DefaultWritablePaths are the paths of a container with a read-only root file
+// system that are kept writable if `AnnotationContainerRootfsWritablePaths` is
+// not set.
+var DefaultWritablePaths = []string{"/tmp", "/run"}
+
+// DefaultWritablePathSizeInBytes is the size of each of the tmpfs mounts on the
+// writable paths of a container with a read-only root file system if
+// `AnnotationContainerRootfsWritablePathSizeInBytes` is not set.
+const DefaultWritablePathSizeInBytes = 64 * 1024 * 1024
+
+// ParseAnnotationsRootfsReadOnly searches `s.Annotations` for the read-only
+// root file system annotation. If not found returns false.
+func ParseAnnotationsRootfsReadOnly(ctx context.Context, s *specs.Spec) bool {
+	return parseAnnotationsBool(ctx, s.Annotations, AnnotationContainerRootfsReadOnly, false)
+}
+
+// ParseAnnotationsTPM searches `s.Annotations` for the container TPM
+// annotation. If not found returns false.
+func ParseAnnotationsTPM(ctx context.Context, s *specs.Spec) bool {
+	return parseAnnotationsBool(ctx, s.Annotations, AnnotationContainerTPM, false)
+}
+
+// ParseAnnotationsRootfsWritablePaths searches `s.Annotations` for the
+// writable paths annotation. If not found returns `DefaultWritablePaths`.
+// Returns an error if any of the paths is not absolute.
+func ParseAnnotationsRootfsWritablePaths(s *specs.Spec) ([]string, error) {
+	paths := parseAnnotationsStringList(s.Annotations, AnnotationContainerRootfsWritablePaths, DefaultWritablePaths)
+	for _, p := range paths {
+		if !path.IsAbs(p) {
+			return nil, fmt.Errorf("writable path %q in annotation %s is not absolute", p, AnnotationContainerRootfsWritablePaths)
+		}
+	}
+	return paths, nil
+}
+
+// ParseAnnotationsRootfsWritablePathSize searches `s.Annotations` for the
+// writable path size annotation. If not found returns
+// `DefaultWritablePathSizeInBytes`. Returns an error if the size is 0.
+func ParseAnnotationsRootfsWritablePathSize(ctx context.Context, s *specs.Spec) (uint64, error) {
+	size := parseAnnotationsUint64(ctx, s.Annotations, AnnotationContainerRootfsWritablePathSizeInBytes, DefaultWritablePathSizeInBytes)
+	if size == 0 {
+		return 0, fmt.Errorf("annotation %s must be greater than 0", AnnotationContainerRootfsWritablePathSizeInBytes)
+	}
+	return size, nil
+}
+
+// ParseAnnotationsImageVolumes searches `s.Annotations` for the image volumes
+// annotation. If not found returns nil.
+func ParseAnnotationsImageVolumes(s *specs.Spec) []string {
+	return parseAnnotationsStringList(s.Annotations, AnnotationContainerImageVolumes, nil)
+}
+
+// ParseAnnotationsFirewallPublishedPorts searches `s.Annotations` for the
+// firewall published ports annotation. If not found returns false.
+func ParseAnnotationsFirewallPublishedPorts(ctx context.Context, s *specs.Spec) bool {
+	return parseAnnotationsBool(ctx, s.Annotations, AnnotationFirewallPublishedPorts, false)
+}
+
 // ParseAnnotationsMemory searches `s.Annotations` for the memory annotation. If
 // not found searches `s` for the Windows memory section. If neither are found
 // returns `def`.
@@ -344,6 +674,184 @@ func parseAnnotationsUint64(ctx context.Context, a map[string]string, key string
 	return def
 }
 
+// parseAnnotationsUintList searches `a` for `key` and if found verifies that
+// the value is a comma separated list of unsigned integers of `bitSize` bits.
+// Returns false if `key` is not found or any value is invalid.
+func parseAnnotationsUintList(ctx context.Context, a map[string]string, key string, bitSize int) ([]uint64, bool) {
+	v, ok := a[key]
+	if !ok {
+		return nil, false
+	}
+	var values []uint64
+	for _, p := range strings.Split(v, ",") {
+		value, err := strconv.ParseUint(strings.TrimSpace(p), 10, bitSize)
+		if err != nil {
+			log.G(ctx).WithFields(logrus.Fields{
+				logfields.OCIAnnotation: key,
+				logfields.Value:         v,
+				logrus.ErrorKey:         err,
+			}).Warning("annotation could not be parsed")
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// parseAnnotationsPorts searches `a` for `key` and if found verifies that the
+// value is a comma separated list of non-zero 16 bit port numbers. If `key` is
+// not found or any port is invalid returns `def`.
+func parseAnnotationsPorts(ctx context.Context, a map[string]string, key string, def []uint16) []uint16 {
+	values, ok := parseAnnotationsUintList(ctx, a, key, 16)
+	if !ok {
+		return def
+	}
+	ports := make([]uint16, 0, len(values))
+	for _, v := range values {
+		if v == 0 {
+			log.G(ctx).WithFields(logrus.Fields{
+				logfields.OCIAnnotation: key,
+				logfields.Value:         a[key],
+			}).Warning("annotation port must be non-zero")
+			return def
+		}
+		ports = append(ports, uint16(v))
+	}
+	return ports
+}
+
+// parseAnnotationsVolumes searches `a` for `key` and if found verifies that
+// the value is a comma separated list of `<name>:<medium>[:<size in bytes>]`
+// pod volumes. If `key` is not found or any volume is invalid returns `def`.
+func parseAnnotationsVolumes(ctx context.Context, a map[string]string, key string, def []uvm.VolumeOptions) []uvm.VolumeOptions {
+	v, ok := a[key]
+	if !ok {
+		return def
+	}
+	var volumes []uvm.VolumeOptions
+	for _, entry := range strings.Split(v, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		valid := len(parts) == 2 || len(parts) == 3
+		var vol uvm.VolumeOptions
+		if valid {
+			vol.Name = parts[0]
+			vol.Medium = uvm.VolumeMedium(parts[1])
+			valid = vol.Name != "" && (vol.Medium == uvm.VolumeMediumTmpfs || vol.Medium == uvm.VolumeMediumScratch)
+		}
+		if valid && len(parts) == 3 {
+			size, err := strconv.ParseUint(parts[2], 10, 64)
+			valid = err == nil
+			vol.SizeInBytes = size
+		}
+		if !valid {
+			log.G(ctx).WithFields(logrus.Fields{
+				logfields.OCIAnnotation: key,
+				logfields.Value:         v,
+			}).Warning("annotation pod volume could not be parsed")
+			return def
+		}
+		volumes = append(volumes, vol)
+	}
+	return volumes
+}
+
+// parseAnnotationsCoreSchedulingGroups searches `a` for `key` and if found
+// parses the value as a comma separated list of `<container name>=<group>`
+// core scheduling groups. If `key` is not found or any entry is invalid
+// returns `def`.
+func parseAnnotationsCoreSchedulingGroups(ctx context.Context, a map[string]string, key string, def map[string]string) map[string]string {
+	v, ok := a[key]
+	if !ok {
+		return def
+	}
+	groups := make(map[string]string)
+	for _, entry := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			groups[parts[0]] = parts[1]
+			continue
+		}
+		log.G(ctx).WithFields(logrus.Fields{
+			logfields.OCIAnnotation: key,
+			logfields.Value:         v,
+		}).Warning("annotation core scheduling groups could not be parsed")
+		return def
+	}
+	return groups
+}
+
+// parseNodeShares parses the comma separated `<name>=<host directory>` node
+// shares `v` of the `NodeShares` runtime option.
+func parseNodeShares(v string) ([]uvm.NodeShare, error) {
+	var shares []uvm.NodeShare
+	for _, entry := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid node share %q, expected <name>=<host directory>", entry)
+		}
+		shares = append(shares, uvm.NodeShare{Name: parts[0], HostPath: parts[1]})
+	}
+	return shares, nil
+}
+
+// parseAnnotationsNUMANodes searches `a` for `key` and if found parses the
+// value as a comma separated list of `<vCPU count>:<memory in MB>` virtual
+// NUMA nodes. If `key` is not found or any node is invalid returns `def`.
+func parseAnnotationsNUMANodes(ctx context.Context, a map[string]string, key string, def []uvm.NUMANode) []uvm.NUMANode {
+	v, ok := a[key]
+	if !ok {
+		return def
+	}
+	var nodes []uvm.NUMANode
+	for _, entry := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if len(parts) == 2 {
+			processors, perr := strconv.ParseUint(parts[0], 10, 32)
+			memory, merr := strconv.ParseUint(parts[1], 10, 64)
+			if perr == nil && merr == nil {
+				nodes = append(nodes, uvm.NUMANode{ProcessorCount: uint32(processors), MemorySizeInMB: memory})
+				continue
+			}
+		}
+		log.G(ctx).WithFields(logrus.Fields{
+			logfields.OCIAnnotation: key,
+			logfields.Value:         v,
+		}).Warning("annotation NUMA nodes could not be parsed")
+		return def
+	}
+	return nodes
+}
+
+// parseAnnotationsUint32List searches `a` for `key` and if found verifies that
+// the value is a comma separated list of 32 bit unsigned integers. If `key` is
+// not found or any value is invalid returns `def`.
+func parseAnnotationsUint32List(ctx context.Context, a map[string]string, key string, def []uint32) []uint32 {
+	values, ok := parseAnnotationsUintList(ctx, a, key, 32)
+	if !ok {
+		return def
+	}
+	result := make([]uint32, 0, len(values))
+	for _, v := range values {
+		result = append(result, uint32(v))
+	}
+	return result
+}
+
+// parseAnnotationsUint8List searches `a` for `key` and if found verifies that
+// the value is a comma separated list of 8 bit unsigned integers. If `key` is
+// not found or any value is invalid returns `def`.
+func parseAnnotationsUint8List(ctx context.Context, a map[string]string, key string, def []uint8) []uint8 {
+	values, ok := parseAnnotationsUintList(ctx, a, key, 8)
+	if !ok {
+		return def
+	}
+	result := make([]uint8, 0, len(values))
+	for _, v := range values {
+		result = append(result, uint8(v))
+	}
+	return result
+}
+
 // parseAnnotationsString searches `a` for `key`. If `key` is not found returns `def`.
 func parseAnnotationsString(a map[string]string, key string, def string) string {
 	if v, ok := a[key]; ok {
@@ -352,6 +860,28 @@ func parseAnnotationsString(a map[string]string, key string, def string) string
 	return def
 }
 
+// parseAnnotationsStringList searches `a` for `key` and if found returns its
+// comma separated entries, which is an empty list for an empty value. If `key`
+// is not found returns `def`.
+func parseAnnotationsStringList(a map[string]string, key string, def []string) []string {
+	v, ok := a[key]
+	if !ok {
+		return def
+	}
+	return splitList(v)
+}
+
+// splitList returns the non-empty entries of the comma separated list `v`.
+func splitList(v string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(v, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
 // ParseAnnotationsSaveAsTemplate searches for the boolean value which specifies
 // if this create request should be considered as a template creation request. If value
 // is found the returns the actual value, returns false otherwise.
@@ -454,17 +984,48 @@ func SpecToUVMCreateOpts(ctx context.Context, s *specs.Spec, id, owner string) (
 		lopts.AllowOvercommit = parseAnnotationsBool(ctx, s.Annotations, annotationAllowOvercommit, lopts.AllowOvercommit)
 		lopts.EnableDeferredCommit = parseAnnotationsBool(ctx, s.Annotations, annotationEnableDeferredCommit, lopts.EnableDeferredCommit)
 		lopts.EnableColdDiscardHint = parseAnnotationsBool(ctx, s.Annotations, annotationEnableColdDiscardHint, lopts.EnableColdDiscardHint)
+		lopts.EnableColdHint = parseAnnotationsBool(ctx, s.Annotations, annotationEnableColdHint, lopts.EnableColdHint)
+		lopts.EnableLargePages = parseAnnotationsBool(ctx, s.Annotations, annotationEnableLargePages, lopts.EnableLargePages)
 		lopts.ProcessorCount = ParseAnnotationsCPUCount(ctx, s, annotationProcessorCount, lopts.ProcessorCount)
 		lopts.ProcessorLimit = ParseAnnotationsCPULimit(ctx, s, annotationProcessorLimit, lopts.ProcessorLimit)
 		lopts.ProcessorWeight = ParseAnnotationsCPUWeight(ctx, s, annotationProcessorWeight, lopts.ProcessorWeight)
+		lopts.ProcessorReservation = int32(parseAnnotationsUint32(ctx, s.Annotations, annotationProcessorReservation, uint32(lopts.ProcessorReservation)))
+		lopts.LatencySensitive = parseAnnotationsBool(ctx, s.Annotations, annotationLatencySensitive, lopts.LatencySensitive)
+		lopts.HwThreadsPerCore = int32(parseAnnotationsUint32(ctx, s.Annotations, annotationProcessorHwThreadsPerCore, uint32(lopts.HwThreadsPerCore)))
+		lopts.EnableNestedVirtualization = parseAnnotationsBool(ctx, s.Annotations, annotationEnableNestedVirtualization, lopts.EnableNestedVirtualization)
+		lopts.RequireCoreScheduler = parseAnnotationsBool(ctx, s.Annotations, annotationRequireCoreScheduler, lopts.RequireCoreScheduler)
 		lopts.VPMemDeviceCount = parseAnnotationsUint32(ctx, s.Annotations, annotationVPMemCount, lopts.VPMemDeviceCount)
 		lopts.VPMemSizeBytes = parseAnnotationsUint64(ctx, s.Annotations, annotationVPMemSize, lopts.VPMemSizeBytes)
+		lopts.SCSIControllerCount = parseAnnotationsUint32(ctx, s.Annotations, annotationSCSIControllerCount, lopts.SCSIControllerCount)
 		lopts.StorageQoSBandwidthMaximum = ParseAnnotationsStorageBps(ctx, s, annotationStorageQoSBandwidthMaximum, lopts.StorageQoSBandwidthMaximum)
 		lopts.StorageQoSIopsMaximum = ParseAnnotationsStorageIops(ctx, s, annotationStorageQoSIopsMaximum, lopts.StorageQoSIopsMaximum)
 		lopts.VPCIEnabled = parseAnnotationsBool(ctx, s.Annotations, annotationVPCIEnabled, lopts.VPCIEnabled)
+		lopts.EnableGuestDHCP = parseAnnotationsBool(ctx, s.Annotations, annotationEnableGuestDHCP, lopts.EnableGuestDHCP)
+		lopts.DisableIPv6RA = parseAnnotationsBool(ctx, s.Annotations, annotationDisableIPv6RA, lopts.DisableIPv6RA)
+		lopts.EnableDNSProxy = parseAnnotationsBool(ctx, s.Annotations, annotationDNSProxy, lopts.EnableDNSProxy)
+		lopts.ForwardedPorts = parseAnnotationsPorts(ctx, s.Annotations, annotationForwardedPorts, lopts.ForwardedPorts)
+		lopts.Volumes = parseAnnotationsVolumes(ctx, s.Annotations, annotationVolumes, lopts.Volumes)
+		lopts.GCSWatchdogTimeout = parseAnnotationsUint32(ctx, s.Annotations, annotationGCSWatchdogTimeout, lopts.GCSWatchdogTimeout)
+		lopts.GCSRecoveryTimeout = parseAnnotationsUint32(ctx, s.Annotations, annotationGCSRecoveryTimeout, lopts.GCSRecoveryTimeout)
+		lopts.Plan9ChangeNotify = parseAnnotationsBool(ctx, s.Annotations, annotationPlan9ChangeNotify, lopts.Plan9ChangeNotify)
+		lopts.Persistent = parseAnnotationsBool(ctx, s.Annotations, annotationPersistent, lopts.Persistent)
+		lopts.HvSocketAllowList = parseAnnotationsStringList(s.Annotations, annotationHvSocketAllowList, lopts.HvSocketAllowList)
+		lopts.ScratchTrimInterval = parseAnnotationsUint32(ctx, s.Annotations, annotationScratchTrimInterval, lopts.ScratchTrimInterval)
+		lopts.EmulatedArchitectures = parseAnnotationsStringList(s.Annotations, annotationEmulatedArchitectures, lopts.EmulatedArchitectures)
+		lopts.Extensions = parseAnnotationsStringList(s.Annotations, annotationExtensions, lopts.Extensions)
+		lopts.CoreScheduling = parseAnnotationsBool(ctx, s.Annotations, annotationCoreScheduling, lopts.CoreScheduling)
+		lopts.CoreSchedulingGroups = parseAnnotationsCoreSchedulingGroups(ctx, s.Annotations, annotationCoreSchedulingGroups, lopts.CoreSchedulingGroups)
+		lopts.EntropySeedBytes = parseAnnotationsUint32(ctx, s.Annotations, annotationEntropySeedBytes, lopts.EntropySeedBytes)
+		lopts.RequireEntropySeed = parseAnnotationsBool(ctx, s.Annotations, annotationRequireEntropySeed, lopts.RequireEntropySeed)
+		lopts.Plan9MSize = parseAnnotationsUint32(ctx, s.Annotations, annotationPlan9MSize, lopts.Plan9MSize)
+		lopts.Plan9Cache = parseAnnotationsString(s.Annotations, annotationPlan9Cache, lopts.Plan9Cache)
 		lopts.BootFilesPath = parseAnnotationsString(s.Annotations, annotationBootFilesRootPath, lopts.BootFilesPath)
 		lopts.ExternalGuestConnection = parseAnnotationsBool(ctx, s.Annotations, annotationUseExternalGCSBridge, lopts.ExternalGuestConnection)
 		lopts.CPUGroupID = parseAnnotationsString(s.Annotations, annotationCPUGroupID, lopts.CPUGroupID)
+		lopts.ProcessorAffinity = parseAnnotationsUint32List(ctx, s.Annotations, annotationProcessorAffinity, lopts.ProcessorAffinity)
+		lopts.ProcessorAffinityNUMANodes = parseAnnotationsUint8List(ctx, s.Annotations, annotationProcessorAffinityNUMANodes, lopts.ProcessorAffinityNUMANodes)
+		lopts.NUMANodes = parseAnnotationsNUMANodes(ctx, s.Annotations, annotationNUMANodes, lopts.NUMANodes)
+		lopts.EnableTPM = parseAnnotationsBool(ctx, s.Annotations, annotationTPMEnabled, lopts.EnableTPM)
 		lopts.NetworkConfigProxy = parseAnnotationsString(s.Annotations, annotationNetworkConfigProxy, lopts.NetworkConfigProxy)
 		handleAnnotationPreferredRootFSType(ctx, s.Annotations, lopts)
 		handleAnnotationKernelDirectBoot(ctx, s.Annotations, lopts)
@@ -481,14 +1042,25 @@ func SpecToUVMCreateOpts(ctx context.Context, s *specs.Spec, id, owner string) (
 		wopts.HighMMIOGapInMB = parseAnnotationsUint64(ctx, s.Annotations, annotationMemoryHighMMIOGapInMB, wopts.HighMMIOGapInMB)
 		wopts.AllowOvercommit = parseAnnotationsBool(ctx, s.Annotations, annotationAllowOvercommit, wopts.AllowOvercommit)
 		wopts.EnableDeferredCommit = parseAnnotationsBool(ctx, s.Annotations, annotationEnableDeferredCommit, wopts.EnableDeferredCommit)
+		wopts.EnableColdHint = parseAnnotationsBool(ctx, s.Annotations, annotationEnableColdHint, wopts.EnableColdHint)
+		wopts.EnableLargePages = parseAnnotationsBool(ctx, s.Annotations, annotationEnableLargePages, wopts.EnableLargePages)
 		wopts.ProcessorCount = ParseAnnotationsCPUCount(ctx, s, annotationProcessorCount, wopts.ProcessorCount)
 		wopts.ProcessorLimit = ParseAnnotationsCPULimit(ctx, s, annotationProcessorLimit, wopts.ProcessorLimit)
 		wopts.ProcessorWeight = ParseAnnotationsCPUWeight(ctx, s, annotationProcessorWeight, wopts.ProcessorWeight)
+		wopts.ProcessorReservation = int32(parseAnnotationsUint32(ctx, s.Annotations, annotationProcessorReservation, uint32(wopts.ProcessorReservation)))
+		wopts.LatencySensitive = parseAnnotationsBool(ctx, s.Annotations, annotationLatencySensitive, wopts.LatencySensitive)
+		wopts.HwThreadsPerCore = int32(parseAnnotationsUint32(ctx, s.Annotations, annotationProcessorHwThreadsPerCore, uint32(wopts.HwThreadsPerCore)))
+		wopts.EnableNestedVirtualization = parseAnnotationsBool(ctx, s.Annotations, annotationEnableNestedVirtualization, wopts.EnableNestedVirtualization)
+		wopts.RequireCoreScheduler = parseAnnotationsBool(ctx, s.Annotations, annotationRequireCoreScheduler, wopts.RequireCoreScheduler)
 		wopts.StorageQoSBandwidthMaximum = ParseAnnotationsStorageBps(ctx, s, annotationStorageQoSBandwidthMaximum, wopts.StorageQoSBandwidthMaximum)
 		wopts.StorageQoSIopsMaximum = ParseAnnotationsStorageIops(ctx, s, annotationStorageQoSIopsMaximum, wopts.StorageQoSIopsMaximum)
 		wopts.ExternalGuestConnection = parseAnnotationsBool(ctx, s.Annotations, annotationUseExternalGCSBridge, wopts.ExternalGuestConnection)
 		wopts.DisableCompartmentNamespace = parseAnnotationsBool(ctx, s.Annotations, annotationDisableCompartmentNamespace, wopts.DisableCompartmentNamespace)
 		wopts.CPUGroupID = parseAnnotationsString(s.Annotations, annotationCPUGroupID, wopts.CPUGroupID)
+		wopts.ProcessorAffinity = parseAnnotationsUint32List(ctx, s.Annotations, annotationProcessorAffinity, wopts.ProcessorAffinity)
+		wopts.ProcessorAffinityNUMANodes = parseAnnotationsUint8List(ctx, s.Annotations, annotationProcessorAffinityNUMANodes, wopts.ProcessorAffinityNUMANodes)
+		wopts.NUMANodes = parseAnnotationsNUMANodes(ctx, s.Annotations, annotationNUMANodes, wopts.NUMANodes)
+		wopts.EnableTPM = parseAnnotationsBool(ctx, s.Annotations, annotationTPMEnabled, wopts.EnableTPM)
 		wopts.NetworkConfigProxy = parseAnnotationsString(s.Annotations, annotationNetworkConfigProxy, wopts.NetworkConfigProxy)
 		handleAnnotationFullyPhysicallyBacked(ctx, s.Annotations, wopts)
 		if err := handleCloneAnnotations(ctx, s.Annotations, wopts); err != nil {
@@ -528,3 +1100,44 @@ func UpdateSpecFromOptions(s specs.Spec, opts *runhcsopts.Options) specs.Spec {
 
 	return s
 }
+
+// UpdateCreateOptsFromOptions sets the settings of `opts`, as returned by
+// SpecToUVMCreateOpts, that name paths owned by the shim or its UVMs. These
+// are only taken from the shim options, never from annotations, so that a pod
+// can not pick the host paths that its UVM reads, writes or changes the access
+// of, nor the guest binaries that its containers are allowed to run as hooks,
+// nor opt its containers out of a read-only root file system.
+func UpdateCreateOptsFromOptions(opts interface{}, shimOpts *runhcsopts.Options) error {
+	if shimOpts == nil {
+		return nil
+	}
+	var uopts *uvm.Options
+	switch o := opts.(type) {
+	case *uvm.OptionsLCOW:
+		uopts = o.Options
+		if shimOpts.ConsoleLogDirectory != "" && o.ConsolePipe == "" {
+			o.ConsoleLogFile = filepath.Join(shimOpts.ConsoleLogDirectory, o.ID+"-console.log")
+		}
+		o.OCIHooksPath = shimOpts.OciHooksPath
+		o.ApprovedExtensions = splitList(shimOpts.ApprovedExtensions)
+		o.ReadOnlyRootfs = shimOpts.EnforceReadOnlyRootfs
+		o.DNSProxyUpstream = shimOpts.DnsProxyUpstream
+		o.AllowForwardedPorts = shimOpts.AllowForwardedPorts
+	case *uvm.OptionsWCOW:
+		uopts = o.Options
+	default:
+		return nil
+	}
+	uopts.CrashDumpDirectory = shimOpts.CrashDumpDirectory
+	if uopts.EnableTPM {
+		uopts.TPMStateDirectory = shimOpts.TpmStateDirectory
+	}
+	if shimOpts.NodeShares != "" {
+		shares, err := parseNodeShares(shimOpts.NodeShares)
+		if err != nil {
+			return fmt.Errorf("runtime option NodeShares: %s", err)
+		}
+		uopts.NodeShares = shares
+	}
+	return nil
+}
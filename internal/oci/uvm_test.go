@@ -1,9 +1,12 @@
 package oci
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
@@ -74,3 +77,535 @@ func Test_SpecUpdate_ProcessorCount_NoAnnotation_WithOpts(t *testing.T) {
 		t.Fatal("should have updated annotation to default when annotation is not provided in the spec")
 	}
 }
+
+func Test_CreateOptsUpdate_TPMStateDirectory(t *testing.T) {
+	opts := &runhcsopts.Options{
+		TpmStateDirectory: `C:\tpm`,
+	}
+	lopts := uvm.NewDefaultOptionsLCOW(t.Name(), "")
+	if err := UpdateCreateOptsFromOptions(lopts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if lopts.TPMStateDirectory != "" {
+		t.Fatal("should not have set the TPM state directory of a UVM without a TPM")
+	}
+
+	lopts.EnableTPM = true
+	if err := UpdateCreateOptsFromOptions(lopts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if lopts.TPMStateDirectory != `C:\tpm` {
+		t.Fatalf("expected the TPM state directory from the options, got: %q", lopts.TPMStateDirectory)
+	}
+}
+
+func Test_CreateOptsUpdate_ConsoleLogDirectory(t *testing.T) {
+	opts := &runhcsopts.Options{
+		ConsoleLogDirectory: `C:\console`,
+	}
+	lopts := uvm.NewDefaultOptionsLCOW(t.Name(), "")
+	if err := UpdateCreateOptsFromOptions(lopts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if expected := `C:\console\` + t.Name() + "-console.log"; lopts.ConsoleLogFile != expected {
+		t.Fatalf("expected console log file %q, got: %q", expected, lopts.ConsoleLogFile)
+	}
+
+	lopts = uvm.NewDefaultOptionsLCOW(t.Name(), "")
+	lopts.ConsolePipe = `\\.\pipe\console`
+	if err := UpdateCreateOptsFromOptions(lopts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if lopts.ConsoleLogFile != "" {
+		t.Fatal("should not have captured the console of a UVM with a console pipe")
+	}
+}
+
+func Test_ParseAnnotationsPorts(t *testing.T) {
+	def := []uint16{1}
+	for v, expected := range map[string][]uint16{
+		"8080":         {8080},
+		"80, 443,8080": {80, 443, 8080},
+		"0":            def,
+		"70000":        def,
+		"80,http":      def,
+		"":             def,
+	} {
+		a := map[string]string{annotationForwardedPorts: v}
+		actual := parseAnnotationsPorts(context.Background(), a, annotationForwardedPorts, def)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("parseAnnotationsPorts(%q) = %v, expected %v", v, actual, expected)
+		}
+	}
+	if actual := parseAnnotationsPorts(context.Background(), map[string]string{}, annotationForwardedPorts, def); !reflect.DeepEqual(actual, def) {
+		t.Fatalf("expected default %v when annotation is not set, got %v", def, actual)
+	}
+}
+
+func Test_ParseAnnotationsVolumes(t *testing.T) {
+	var def []uvm.VolumeOptions
+	for v, expected := range map[string][]uvm.VolumeOptions{
+		"cache:tmpfs": {{Name: "cache", Medium: uvm.VolumeMediumTmpfs}},
+		"cache:tmpfs:1024, data:scratch:1073741824": {
+			{Name: "cache", Medium: uvm.VolumeMediumTmpfs, SizeInBytes: 1024},
+			{Name: "data", Medium: uvm.VolumeMediumScratch, SizeInBytes: 1 << 30},
+		},
+		"cache":          def,
+		"cache:disk":     def,
+		":tmpfs":         def,
+		"cache:tmpfs:1G": def,
+	} {
+		a := map[string]string{annotationVolumes: v}
+		actual := parseAnnotationsVolumes(context.Background(), a, annotationVolumes, def)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("parseAnnotationsVolumes(%q) = %v, expected %v", v, actual, expected)
+		}
+	}
+}
+
+func Test_ParseAnnotationsRootfsWritablePaths(t *testing.T) {
+	s := &specs.Spec{}
+	if actual, err := ParseAnnotationsRootfsWritablePaths(s); err != nil || !reflect.DeepEqual(actual, DefaultWritablePaths) {
+		t.Fatalf("expected default %v when annotation is not set, got %v, %v", DefaultWritablePaths, actual, err)
+	}
+	s.Annotations = map[string]string{AnnotationContainerRootfsWritablePaths: "/tmp, /var/cache"}
+	if actual, err := ParseAnnotationsRootfsWritablePaths(s); err != nil || !reflect.DeepEqual(actual, []string{"/tmp", "/var/cache"}) {
+		t.Fatalf("unexpected writable paths %v, %v", actual, err)
+	}
+	s.Annotations[AnnotationContainerRootfsWritablePaths] = "tmp"
+	if _, err := ParseAnnotationsRootfsWritablePaths(s); err == nil {
+		t.Fatal("expected an error for a relative writable path")
+	}
+}
+
+func Test_ParseAnnotationsCoreSchedulingGroups(t *testing.T) {
+	def := map[string]string{"default": "group"}
+	for v, expected := range map[string]map[string]string{
+		`app=web`:                   {"app": "web"},
+		`app=web, sidecar=web,db=d`: {"app": "web", "sidecar": "web", "db": "d"},
+		`app`:                       def,
+		`app=`:                      def,
+		`=web`:                      def,
+		`app=web,`:                  def,
+	} {
+		a := map[string]string{annotationCoreSchedulingGroups: v}
+		actual := parseAnnotationsCoreSchedulingGroups(context.Background(), a, annotationCoreSchedulingGroups, def)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected groups %v for %q, got: %v", expected, v, actual)
+		}
+	}
+}
+
+func Test_SpecToUVMCreateOpts_CoreScheduling(t *testing.T) {
+	s := &specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			annotationCoreScheduling:       "true",
+			annotationCoreSchedulingGroups: "app=web,sidecar=web",
+		},
+	}
+	opts, err := SpecToUVMCreateOpts(context.Background(), s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	lopts := opts.(*uvm.OptionsLCOW)
+	if !lopts.CoreScheduling {
+		t.Fatal("expected core scheduling to be enabled")
+	}
+	if expected := map[string]string{"app": "web", "sidecar": "web"}; !reflect.DeepEqual(lopts.CoreSchedulingGroups, expected) {
+		t.Fatalf("expected groups %v, got: %v", expected, lopts.CoreSchedulingGroups)
+	}
+}
+
+func Test_ParseNodeShares(t *testing.T) {
+	for v, expected := range map[string][]uvm.NodeShare{
+		`certs=C:\certs`: {{Name: "certs", HostPath: `C:\certs`}},
+		`certs=C:\certs, tz=C:\zone=info`: {
+			{Name: "certs", HostPath: `C:\certs`},
+			{Name: "tz", HostPath: `C:\zone=info`},
+		},
+	} {
+		actual, err := parseNodeShares(v)
+		if err != nil {
+			t.Fatalf("parseNodeShares(%q) failed: %s", v, err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("parseNodeShares(%q) = %v, expected %v", v, actual, expected)
+		}
+	}
+	for _, v := range []string{`certs`, `=C:\certs`, `certs=`, `certs=C:\certs,`} {
+		if _, err := parseNodeShares(v); err == nil {
+			t.Fatalf("parseNodeShares(%q) should have failed", v)
+		}
+	}
+}
+
+func Test_CreateOptsUpdate_NodeShares_IgnoresAnnotation(t *testing.T) {
+	opts := &runhcsopts.Options{
+		NodeShares: `certs=C:\certs`,
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			"io.microsoft.virtualmachine.nodeshares": `host=C:\`,
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := []uvm.NodeShare{{Name: "certs", HostPath: `C:\certs`}}
+	if actual := createOpts.(*uvm.OptionsLCOW).NodeShares; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected node shares %v, got: %v", expected, actual)
+	}
+}
+
+func Test_CreateOptsUpdate_OCIHooksPath_IgnoresAnnotation(t *testing.T) {
+	opts := &runhcsopts.Options{
+		OciHooksPath: "/usr/share/hooks",
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			"io.microsoft.virtualmachine.lcow.ocihookspath": "/",
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if actual := createOpts.(*uvm.OptionsLCOW).OCIHooksPath; actual != "" {
+		t.Fatalf("expected the annotation to be ignored, got hooks path: %q", actual)
+	}
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if actual := createOpts.(*uvm.OptionsLCOW).OCIHooksPath; actual != opts.OciHooksPath {
+		t.Fatalf("expected hooks path %q, got: %q", opts.OciHooksPath, actual)
+	}
+}
+
+func Test_CreateOptsUpdate_CrashDumpDirectory_IgnoresAnnotation(t *testing.T) {
+	opts := &runhcsopts.Options{
+		CrashDumpDirectory: `C:\dumps`,
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			"io.microsoft.virtualmachine.crashdump.directory": `C:\Windows\System32`,
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if actual := createOpts.(*uvm.OptionsWCOW).CrashDumpDirectory; actual != "" {
+		t.Fatalf("expected the annotation to be ignored, got crash dump directory: %q", actual)
+	}
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if actual := createOpts.(*uvm.OptionsWCOW).CrashDumpDirectory; actual != opts.CrashDumpDirectory {
+		t.Fatalf("expected crash dump directory %q, got: %q", opts.CrashDumpDirectory, actual)
+	}
+}
+
+func Test_CreateOptsUpdate_ApprovedExtensions(t *testing.T) {
+	opts := &runhcsopts.Options{
+		ApprovedExtensions: "gdb, perf,",
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			annotationExtensions: "gdb",
+			"io.microsoft.virtualmachine.lcow.approvedextensions": "rootkit",
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	lopts := createOpts.(*uvm.OptionsLCOW)
+	if lopts.ApprovedExtensions != nil {
+		t.Fatalf("expected no approved extensions before the update, got: %v", lopts.ApprovedExtensions)
+	}
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if expected := []string{"gdb", "perf"}; !reflect.DeepEqual(lopts.ApprovedExtensions, expected) {
+		t.Fatalf("expected approved extensions %v, got: %v", expected, lopts.ApprovedExtensions)
+	}
+	if expected := []string{"gdb"}; !reflect.DeepEqual(lopts.Extensions, expected) {
+		t.Fatalf("expected extensions %v, got: %v", expected, lopts.Extensions)
+	}
+}
+
+func Test_CreateOptsUpdate_DNSProxyUpstream(t *testing.T) {
+	opts := &runhcsopts.Options{
+		DnsProxyUpstream: "10.0.0.53",
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			annotationDNSProxy: "true",
+			"io.microsoft.virtualmachine.lcow.dnsproxy.upstream": "192.0.2.1",
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	lopts := createOpts.(*uvm.OptionsLCOW)
+	if !lopts.EnableDNSProxy {
+		t.Fatal("expected the DNS proxy to be enabled")
+	}
+	if lopts.DNSProxyUpstream != "" {
+		t.Fatalf("expected no DNS proxy upstream before the update, got: %q", lopts.DNSProxyUpstream)
+	}
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if lopts.DNSProxyUpstream != opts.DnsProxyUpstream {
+		t.Fatalf("expected DNS proxy upstream %q, got: %q", opts.DnsProxyUpstream, lopts.DNSProxyUpstream)
+	}
+}
+
+func Test_CreateOptsUpdate_AllowForwardedPorts(t *testing.T) {
+	s := &specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			annotationForwardedPorts: "8080",
+		},
+	}
+	for _, allow := range []bool{false, true} {
+		createOpts, err := SpecToUVMCreateOpts(context.Background(), s, t.Name(), "")
+		if err != nil {
+			t.Fatalf("should not have failed with error: %s", err)
+		}
+		lopts := createOpts.(*uvm.OptionsLCOW)
+		if err := UpdateCreateOptsFromOptions(createOpts, &runhcsopts.Options{AllowForwardedPorts: allow}); err != nil {
+			t.Fatalf("should not have failed with error: %s", err)
+		}
+		if lopts.AllowForwardedPorts != allow {
+			t.Fatalf("expected AllowForwardedPorts %v, got: %v", allow, lopts.AllowForwardedPorts)
+		}
+		if expected := []uint16{8080}; !reflect.DeepEqual(lopts.ForwardedPorts, expected) {
+			t.Fatalf("expected forwarded ports %v, got: %v", expected, lopts.ForwardedPorts)
+		}
+	}
+}
+
+func Test_CreateOptsUpdate_EnforceReadOnlyRootfs(t *testing.T) {
+	opts := &runhcsopts.Options{
+		EnforceReadOnlyRootfs: true,
+	}
+	s := UpdateSpecFromOptions(specs.Spec{
+		Linux:   &specs.Linux{},
+		Windows: &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: map[string]string{
+			"io.microsoft.virtualmachine.lcow.readonlyrootfs": "false",
+		},
+	}, opts)
+	createOpts, err := SpecToUVMCreateOpts(context.Background(), &s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	lopts := createOpts.(*uvm.OptionsLCOW)
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if !lopts.ReadOnlyRootfs {
+		t.Fatal("expected a read-only root file system to be enforced")
+	}
+
+	opts.EnforceReadOnlyRootfs = false
+	s.Annotations["io.microsoft.virtualmachine.lcow.readonlyrootfs"] = "true"
+	if err := UpdateCreateOptsFromOptions(createOpts, opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if lopts.ReadOnlyRootfs {
+		t.Fatal("expected a read-only root file system to not be enforced by annotation")
+	}
+}
+
+func Test_ParseAnnotationsRootfsWritablePathSize(t *testing.T) {
+	s := &specs.Spec{}
+	if actual, err := ParseAnnotationsRootfsWritablePathSize(context.Background(), s); err != nil || actual != DefaultWritablePathSizeInBytes {
+		t.Fatalf("expected default %d when annotation is not set, got %d, %v", DefaultWritablePathSizeInBytes, actual, err)
+	}
+	s.Annotations = map[string]string{AnnotationContainerRootfsWritablePathSizeInBytes: "1048576"}
+	if actual, err := ParseAnnotationsRootfsWritablePathSize(context.Background(), s); err != nil || actual != 1048576 {
+		t.Fatalf("expected size 1048576, got %d, %v", actual, err)
+	}
+	s.Annotations[AnnotationContainerRootfsWritablePathSizeInBytes] = "0"
+	if _, err := ParseAnnotationsRootfsWritablePathSize(context.Background(), s); err == nil {
+		t.Fatal("expected an error for a size of 0")
+	}
+}
+
+func Test_ParseAnnotationsNUMANodes(t *testing.T) {
+	def := []uvm.NUMANode{{ProcessorCount: 1, MemorySizeInMB: 1024}}
+	for v, expected := range map[string][]uvm.NUMANode{
+		`2:4096`: {{ProcessorCount: 2, MemorySizeInMB: 4096}},
+		`2:4096, 4:8192`: {
+			{ProcessorCount: 2, MemorySizeInMB: 4096},
+			{ProcessorCount: 4, MemorySizeInMB: 8192},
+		},
+		`2`:       def,
+		`2:`:      def,
+		`-1:512`:  def,
+		`2:4096,`: def,
+	} {
+		a := map[string]string{annotationNUMANodes: v}
+		actual := parseAnnotationsNUMANodes(context.Background(), a, annotationNUMANodes, def)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("parseAnnotationsNUMANodes(%q) = %v, expected %v", v, actual, expected)
+		}
+	}
+}
+
+// specToLCOWCreateOpts returns the LCOW UVM create options of a pod with the
+// annotations `a`.
+func specToLCOWCreateOpts(t *testing.T, a map[string]string) *uvm.OptionsLCOW {
+	s := &specs.Spec{
+		Linux:       &specs.Linux{},
+		Windows:     &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+		Annotations: a,
+	}
+	opts, err := SpecToUVMCreateOpts(context.Background(), s, t.Name(), "")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	return opts.(*uvm.OptionsLCOW)
+}
+
+func Test_SpecToUVMCreateOpts_EnableGuestDHCP(t *testing.T) {
+	for v, expected := range map[string]bool{
+		"true":  true,
+		"TRUE":  true,
+		"false": false,
+		"1":     false,
+		"yes":   false,
+		"":      false,
+	} {
+		lopts := specToLCOWCreateOpts(t, map[string]string{annotationEnableGuestDHCP: v})
+		if lopts.EnableGuestDHCP != expected {
+			t.Fatalf("annotation %q: expected EnableGuestDHCP %t, got %t", v, expected, lopts.EnableGuestDHCP)
+		}
+	}
+}
+
+func Test_SpecToUVMCreateOpts_DisableIPv6RA(t *testing.T) {
+	for v, expected := range map[string]bool{
+		"true":    true,
+		"True":    true,
+		"false":   false,
+		"0":       false,
+		"disable": false,
+	} {
+		lopts := specToLCOWCreateOpts(t, map[string]string{annotationDisableIPv6RA: v})
+		if lopts.DisableIPv6RA != expected {
+			t.Fatalf("annotation %q: expected DisableIPv6RA %t, got %t", v, expected, lopts.DisableIPv6RA)
+		}
+	}
+	if lopts := specToLCOWCreateOpts(t, nil); lopts.DisableIPv6RA {
+		t.Fatal("expected router advertisements to be accepted by default")
+	}
+}
+
+func Test_SpecToUVMCreateOpts_EnableColdHint(t *testing.T) {
+	for v, expected := range map[string]bool{
+		"true":  true,
+		"false": false,
+		"on":    false,
+	} {
+		a := map[string]string{annotationEnableColdHint: v}
+		if lopts := specToLCOWCreateOpts(t, a); lopts.EnableColdHint != expected {
+			t.Fatalf("annotation %q: expected LCOW EnableColdHint %t, got %t", v, expected, lopts.EnableColdHint)
+		}
+		s := &specs.Spec{
+			Windows:     &specs.Windows{HyperV: &specs.WindowsHyperV{}},
+			Annotations: a,
+		}
+		opts, err := SpecToUVMCreateOpts(context.Background(), s, t.Name(), "")
+		if err != nil {
+			t.Fatalf("should not have failed with error: %s", err)
+		}
+		if wopts := opts.(*uvm.OptionsWCOW); wopts.EnableColdHint != expected {
+			t.Fatalf("annotation %q: expected WCOW EnableColdHint %t, got %t", v, expected, wopts.EnableColdHint)
+		}
+	}
+}
+
+func Test_SpecToUVMCreateOpts_SCSIControllerCount(t *testing.T) {
+	def := uvm.NewDefaultOptionsLCOW(t.Name(), "").SCSIControllerCount
+	for v, expected := range map[string]uint32{
+		"0": 0,
+		"2": 2,
+		"4": 4,
+		// Counts above the maximum are rejected when the UVM is created.
+		"5":          5,
+		"two":        def,
+		"-1":         def,
+		"4294967296": def,
+	} {
+		lopts := specToLCOWCreateOpts(t, map[string]string{annotationSCSIControllerCount: v})
+		if lopts.SCSIControllerCount != expected {
+			t.Fatalf("annotation %q: expected SCSIControllerCount %d, got %d", v, expected, lopts.SCSIControllerCount)
+		}
+	}
+}
+
+func Test_ParseAnnotationsStorageQoS(t *testing.T) {
+	specIops, specBps := uint64(300), uint64(4096)
+	for v, expected := range map[string]int32{
+		"1":          1,
+		"1000":       1000,
+		"2147483647": 2147483647,
+		"0":          -1,
+		"fast":       -1,
+		"-1":         -1,
+		// Values that do not fit a limit are ignored, rather than truncated.
+		"2147483648": -1,
+		"4294967296": -1,
+	} {
+		s := &specs.Spec{
+			Annotations: map[string]string{
+				AnnotationContainerStorageQoSIopsMaximum:      v,
+				AnnotationContainerStorageQoSBandwidthMaximum: v,
+			},
+		}
+		if iops := ParseAnnotationsStorageIops(context.Background(), s, AnnotationContainerStorageQoSIopsMaximum, -1); iops != expected {
+			t.Fatalf("annotation %q: expected iops %d, got %d", v, expected, iops)
+		}
+		if bps := ParseAnnotationsStorageBps(context.Background(), s, AnnotationContainerStorageQoSBandwidthMaximum, -1); bps != expected {
+			t.Fatalf("annotation %q: expected bps %d, got %d", v, expected, bps)
+		}
+
+		// An annotation that is not used falls back to the spec.
+		s.Windows = &specs.Windows{
+			Resources: &specs.WindowsResources{
+				Storage: &specs.WindowsStorageResources{Iops: &specIops, Bps: &specBps},
+			},
+		}
+		expIops, expBps := expected, expected
+		if expected == -1 {
+			expIops, expBps = int32(specIops), int32(specBps)
+		}
+		if iops := ParseAnnotationsStorageIops(context.Background(), s, AnnotationContainerStorageQoSIopsMaximum, -1); iops != expIops {
+			t.Fatalf("annotation %q: expected iops %d from the spec, got %d", v, expIops, iops)
+		}
+		if bps := ParseAnnotationsStorageBps(context.Background(), s, AnnotationContainerStorageQoSBandwidthMaximum, -1); bps != expBps {
+			t.Fatalf("annotation %q: expected bps %d from the spec, got %d", v, expBps, bps)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ownership
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/regstate"
+)
+
+const (
+	// SystemTypeEndpoint is the `Orphan.SystemType` of an HNS endpoint.
+	SystemTypeEndpoint = "Endpoint"
+
+	endpointsRoot = "OwnedEndpoints"
+	endpointsKey  = "Owner"
+)
+
+// ClaimEndpoint records that the HNS endpoint `id` was added to a compute
+// system created with the owner label `label`, so that it is found by
+// FindOrphans once the owning process is gone. HNS endpoints have no owner
+// field of their own. Nothing is recorded if `label` is not an owner label.
+//
+// The records are kept in volatile registry keys, which do not outlive the
+// endpoints on a reboot.
+func ClaimEndpoint(id, label string) error {
+	info, ok := Parse(label)
+	if !ok {
+		return nil
+	}
+	k, err := regstate.Open(endpointsRoot, false)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if err := k.Set(id, endpointsKey, info); regstate.IsNotFoundError(err) {
+		return k.Create(id, endpointsKey, info)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReleaseEndpoint removes the record of ClaimEndpoint for the HNS endpoint
+// `id`, once it is removed from the compute system or the compute system is
+// closed.
+func ReleaseEndpoint(id string) error {
+	k, err := regstate.Open(endpointsRoot, false)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if err := k.Remove(id); err != nil && !regstate.IsNotFoundError(err) {
+		return err
+	}
+	return nil
+}
+
+// findOrphanedEndpoints returns the claimed HNS endpoints whose owning process
+// is gone.
+func findOrphanedEndpoints(ctx context.Context) ([]Orphan, error) {
+	k, err := regstate.Open(endpointsRoot, false)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	ids, err := k.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	var orphans []Orphan
+	for _, id := range ids {
+		var info Info
+		if err := k.Get(id, endpointsKey, &info); err != nil {
+			log.G(ctx).WithError(err).WithField("id", id).Warning("failed to read the owner of an endpoint")
+			continue
+		}
+		alive, err := info.Alive()
+		if err != nil {
+			log.G(ctx).WithError(err).WithField("id", id).Warning("failed to check the owner of an endpoint")
+			continue
+		}
+		if !alive {
+			orphans = append(orphans, Orphan{ID: id, SystemType: SystemTypeEndpoint, Info: info})
+		}
+	}
+	return orphans, nil
+}
+
+// deleteEndpoint deletes the orphaned HNS endpoint `id` and its record.
+func deleteEndpoint(id string) error {
+	endpoint, err := hns.GetHNSEndpointByID(id)
+	if err == nil {
+		_, err = endpoint.Delete()
+	}
+	// The endpoint may have been deleted since, such as by its CNI plugin.
+	if err != nil && !strings.Contains(err.Error(), "Element not found.") {
+		return err
+	}
+	return ReleaseEndpoint(id)
+}
@@ -0,0 +1,200 @@
+// Package ownership labels the compute systems created by a process with the
+// identity of that process, so that the compute systems left behind when the
+// process dies, such as the UVMs of a crashed shim, can be found and cleaned
+// up.
+package ownership
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code of a process that has not exited.
+const stillActive = 259
+
+// Info is the identity of the process that created a compute system.
+type Info struct {
+	// Owner is the owner that the process gave the compute system, such as
+	// its executable name.
+	Owner string
+	// PID is the process ID of the process.
+	PID int
+	// Start is the creation time of the process, in 100ns intervals since
+	// January 1, 1601. It tells the process apart from a later process that
+	// reuses its PID.
+	Start int64
+}
+
+// Label returns the owner label of a compute system that the current process
+// creates with the owner `owner`.
+//
+// The label is `owner;pid=<pid>;start=<creation time>`. If the creation time of
+// the current process can not be read `owner` is returned, and the compute
+// system is never considered orphaned.
+func Label(owner string) string {
+	start, err := processStart(os.Getpid())
+	if err != nil {
+		return owner
+	}
+	return fmt.Sprintf("%s;pid=%d;start=%d", owner, os.Getpid(), start)
+}
+
+// Owner returns the owner of the owner label `label`, or `label` if it is not
+// an owner label.
+func Owner(label string) string {
+	if info, ok := Parse(label); ok {
+		return info.Owner
+	}
+	return label
+}
+
+// Parse parses the owner label `label`. Returns false if `label` is not an
+// owner label.
+func Parse(label string) (Info, bool) {
+	parts := strings.Split(label, ";")
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "pid=") || !strings.HasPrefix(parts[2], "start=") {
+		return Info{}, false
+	}
+	pid, err := strconv.Atoi(strings.TrimPrefix(parts[1], "pid="))
+	if err != nil {
+		return Info{}, false
+	}
+	start, err := strconv.ParseInt(strings.TrimPrefix(parts[2], "start="), 10, 64)
+	if err != nil {
+		return Info{}, false
+	}
+	return Info{Owner: parts[0], PID: pid, Start: start}, true
+}
+
+// Alive returns true if the process identified by `info` is still running.
+func (info Info) Alive() (bool, error) {
+	start, err := processStart(info.PID)
+	if err == windows.ERROR_INVALID_PARAMETER {
+		// There is no process with this PID.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return start == info.Start, nil
+}
+
+// processStart returns the creation time of the running process `pid`, or
+// ERROR_INVALID_PARAMETER if it does not exist or has exited.
+func processStart(pid int) (int64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return 0, err
+	}
+	if code != stillActive {
+		return 0, windows.ERROR_INVALID_PARAMETER
+	}
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	return int64(creation.HighDateTime)<<32 | int64(creation.LowDateTime), nil
+}
+
+// Orphan is a compute system or HNS endpoint whose owning process is gone.
+type Orphan struct {
+	ID         string
+	SystemType string
+	Info
+}
+
+// FindOrphans returns the compute systems with an owner label whose creating
+// process is no longer running, and the HNS endpoints claimed by such a
+// process with ClaimEndpoint. Compute systems without an owner label, such as
+// those of persistent UVMs, are never returned.
+func FindOrphans(ctx context.Context) ([]Orphan, error) {
+	systems, err := hcs.GetComputeSystems(ctx, schema1.ComputeSystemQuery{})
+	if err != nil {
+		return nil, err
+	}
+	var orphans []Orphan
+	for _, s := range systems {
+		info, ok := Parse(s.Owner)
+		if !ok {
+			continue
+		}
+		alive, err := info.Alive()
+		if err != nil {
+			log.G(ctx).WithError(err).WithField("id", s.ID).Warning("failed to check the owner of a compute system")
+			continue
+		}
+		if !alive {
+			orphans = append(orphans, Orphan{ID: s.ID, SystemType: s.SystemType, Info: info})
+		}
+	}
+	endpoints, err := findOrphanedEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(orphans, endpoints...), nil
+}
+
+// Cleanup terminates the compute systems and deletes the HNS endpoints
+// `orphans`. The containers are terminated before the VMs so that a VM is not
+// torn down under its hosted containers, and the endpoints are deleted once no
+// VM uses them. Every orphan is attempted and the first failure is returned.
+//
+// Terminating a UVM also releases the VSMB and Plan9 shares, SCSI disks and
+// NICs that were added to it.
+func Cleanup(ctx context.Context, orphans []Orphan) error {
+	var firstErr error
+	for _, systemType := range []string{hcs.SystemTypeContainer, hcs.SystemTypeVirtualMachine, SystemTypeEndpoint} {
+		for _, o := range orphans {
+			if o.SystemType != systemType {
+				continue
+			}
+			var err error
+			if systemType == SystemTypeEndpoint {
+				err = deleteEndpoint(o.ID)
+			} else {
+				err = terminate(ctx, o.ID)
+			}
+			if err != nil {
+				log.G(ctx).WithError(err).WithFields(logrus.Fields{
+					"id":    o.ID,
+					"type":  o.SystemType,
+					"owner": o.Owner,
+					"pid":   o.PID,
+				}).Warning("failed to clean up orphan")
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+	return firstErr
+}
+
+func terminate(ctx context.Context, id string) error {
+	system, err := hcs.OpenComputeSystem(ctx, id)
+	if err != nil {
+		if hcs.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer system.Close()
+	if err := system.Terminate(ctx); err != nil && !hcs.IsAlreadyStopped(err) && !hcs.IsPending(err) {
+		return err
+	}
+	return system.Wait()
+}
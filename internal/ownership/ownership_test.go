@@ -0,0 +1,43 @@
+package ownership
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	info, ok := Parse("containerd-shim-runhcs-v1.exe;pid=1234;start=132000000000000000")
+	if !ok {
+		t.Fatal("expected an owner label")
+	}
+	expected := Info{Owner: "containerd-shim-runhcs-v1.exe", PID: 1234, Start: 132000000000000000}
+	if info != expected {
+		t.Fatalf("expected %+v, got %+v", expected, info)
+	}
+	for _, label := range []string{"", "runhcs", "runhcs;pid=1", "runhcs;pid=x;start=1", "runhcs;start=1;pid=1"} {
+		if _, ok := Parse(label); ok {
+			t.Fatalf("expected %q not to be an owner label", label)
+		}
+	}
+}
+
+func TestLabelAlive(t *testing.T) {
+	label := Label("test")
+	info, ok := Parse(label)
+	if !ok {
+		t.Fatalf("expected %q to be an owner label", label)
+	}
+	if Owner(label) != "test" {
+		t.Fatalf("expected owner test, got %s", Owner(label))
+	}
+	alive, err := info.Alive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !alive {
+		t.Fatal("expected the current process to be alive")
+	}
+	info.Start++
+	if alive, _ := info.Alive(); alive {
+		t.Fatal("expected a process with another start time not to be alive")
+	}
+}
@@ -0,0 +1,70 @@
+// +build windows
+
+// Package privileges enables the privileges that containerd-shim-runhcs-v1
+// requires, so that it can run under a restricted account rather than
+// LocalSystem.
+//
+// The shim is started by containerd and runs under the same account. To run
+// both under a virtual service account:
+//
+//  1. Configure the containerd service to run as its virtual account with
+//     `sc.exe config containerd obj= "NT SERVICE\containerd"`.
+//  2. Add `NT SERVICE\containerd` to the `Hyper-V Administrators` group so
+//     that it can use the Host Compute Service.
+//  3. Assign the account the privileges in `Shim` in the local security
+//     policy (User Rights Assignment).
+//
+// An account that is not a member of Administrators can not create pipes
+// under `\\.\pipe\ProtectedPrefix\Administrators`, so the shim serves its
+// pipes outside of the protected prefix, with an ACL that only allows SYSTEM,
+// Administrators and the creating account unless `pipe_security_descriptor`
+// is set in the shim options.
+package privileges
+
+import (
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// RestrictedPipeSecurityDescriptor is the SDDL of the pipes served by a shim
+// that is not running as an administrator. It allows SYSTEM, Administrators
+// and the owner of the pipe.
+const RestrictedPipeSecurityDescriptor = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;OW)"
+
+// Privilege is a privilege and the operations that fail without it.
+type Privilege struct {
+	Name   string
+	Reason string
+}
+
+// Shim are the privileges required by containerd-shim-runhcs-v1.
+var Shim = []Privilege{
+	{winio.SeBackupPrivilege, "reading container layers and sharing them with utility VMs"},
+	{winio.SeRestorePrivilege, "writing container layers"},
+	{"SeCreateSymbolicLinkPrivilege", "creating symbolic links for mounts of process isolated containers"},
+	{"SeManageVolumePrivilege", "mounting and expanding container scratch volumes"},
+	{"SeIncreaseQuotaPrivilege", "setting memory and CPU limits of job objects"},
+}
+
+// Enable enables `privs` for the process and returns those that could not be
+// enabled, because the account does not hold them.
+func Enable(privs []Privilege) []Privilege {
+	var missing []Privilege
+	for _, p := range privs {
+		if err := winio.EnableProcessPrivileges([]string{p.Name}); err != nil {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// IsAdministrator returns true if the process is running as an elevated
+// member of Administrators.
+func IsAdministrator() bool {
+	sid, err := windows.CreateWellKnownSid(windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return false
+	}
+	member, err := windows.Token(0).IsMember(sid)
+	return err == nil && member
+}
@@ -0,0 +1,98 @@
+package processorinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SchedulerType is the type of the hypervisor scheduler of the host.
+type SchedulerType uint32
+
+// The values are those the hypervisor reports on boot.
+const (
+	SchedulerTypeUnknown      SchedulerType = 0
+	SchedulerTypeClassicNoSMT SchedulerType = 1
+	SchedulerTypeClassic      SchedulerType = 2
+	SchedulerTypeCore         SchedulerType = 3
+	SchedulerTypeRoot         SchedulerType = 4
+)
+
+func (t SchedulerType) String() string {
+	switch t {
+	case SchedulerTypeClassicNoSMT:
+		return "classic (SMT disabled)"
+	case SchedulerTypeClassic:
+		return "classic"
+	case SchedulerTypeCore:
+		return "core"
+	case SchedulerTypeRoot:
+		return "root"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint32(t))
+	}
+}
+
+// schedulerTypeQuery selects the event the hypervisor logs on boot with the
+// type of its scheduler.
+const schedulerTypeQuery = "*[System[Provider[@Name='Microsoft-Windows-Hyper-V-Hypervisor'] and (EventID=2)]]"
+
+var (
+	schedulerTypeOnce sync.Once
+	schedulerType     SchedulerType
+	schedulerTypeErr  error
+)
+
+// HostSchedulerType returns the type of the hypervisor scheduler the host was
+// booted with. The scheduler can only change on reboot so the result is
+// cached.
+func HostSchedulerType(ctx context.Context) (SchedulerType, error) {
+	schedulerTypeOnce.Do(func() {
+		schedulerType, schedulerTypeErr = querySchedulerType(ctx)
+	})
+	return schedulerType, schedulerTypeErr
+}
+
+func querySchedulerType(ctx context.Context) (SchedulerType, error) {
+	cmd := exec.CommandContext(ctx, "wevtutil.exe", "qe", "System", "/q:"+schedulerTypeQuery, "/c:1", "/rd:true", "/f:xml")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return SchedulerTypeUnknown, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseSchedulerTypeEvent(stdout.Bytes())
+}
+
+// parseSchedulerTypeEvent returns the scheduler type in the `SchedulerType`
+// data of the XML rendered event `b`.
+func parseSchedulerTypeEvent(b []byte) (SchedulerType, error) {
+	var event struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"EventData>Data"`
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return SchedulerTypeUnknown, errors.New("no hypervisor scheduler type event found")
+	}
+	if err := xml.Unmarshal(b, &event); err != nil {
+		return SchedulerTypeUnknown, fmt.Errorf("failed to parse hypervisor scheduler type event: %s", err)
+	}
+	for _, d := range event.Data {
+		if d.Name == "SchedulerType" {
+			v, err := strconv.ParseUint(strings.TrimSpace(d.Value), 0, 32)
+			if err != nil {
+				return SchedulerTypeUnknown, fmt.Errorf("invalid hypervisor scheduler type %q", d.Value)
+			}
+			return SchedulerType(v), nil
+		}
+	}
+	return SchedulerTypeUnknown, errors.New("hypervisor scheduler type event has no SchedulerType")
+}
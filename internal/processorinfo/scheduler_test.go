@@ -0,0 +1,21 @@
+package processorinfo
+
+import "testing"
+
+func TestParseSchedulerTypeEvent(t *testing.T) {
+	event := `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Microsoft-Windows-Hyper-V-Hypervisor'/><EventID>2</EventID></System><EventData><Data Name='SchedulerType'>0x3</Data></EventData></Event>`
+	st, err := parseSchedulerTypeEvent([]byte(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != SchedulerTypeCore {
+		t.Fatalf("expected %s scheduler, got %s", SchedulerTypeCore, st)
+	}
+
+	if _, err := parseSchedulerTypeEvent([]byte(" \r\n")); err == nil {
+		t.Fatal("expected an error for no event")
+	}
+	if _, err := parseSchedulerTypeEvent([]byte(`<Event><EventData><Data Name='Other'>1</Data></EventData></Event>`)); err == nil {
+		t.Fatal("expected an error for an event without a scheduler type")
+	}
+}
@@ -0,0 +1,391 @@
+// Package reservation tracks host memory and logical processor capacity
+// reserved by UVMs across all processes on the host, so that a UVM that does
+// not fit can be rejected before any call to HCS is made.
+//
+// Every reservation is a file in a shared directory that is held locked by the
+// owning process for the lifetime of the reservation. The lock is dropped by
+// the OS if the owner exits without releasing it, at which point the file is
+// considered stale and is removed by the next process making a reservation.
+//
+// The reservation of a UVM that outlives its process, such as a detached
+// persistent UVM, is marked detached in its file instead. It is kept without
+// an owner until a process takes it over.
+package reservation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	// ErrCapacityExhausted is returned when a reservation does not fit in the
+	// remaining host capacity.
+	ErrCapacityExhausted = errors.New("host UVM capacity reservations exhausted")
+	// ErrNotDetached is returned when taking over a reservation that does not
+	// exist or is not detached.
+	ErrNotDetached = errors.New("UVM reservation is not detached")
+)
+
+const (
+	reservationFileExt = ".json"
+	managerLockFile    = ".lock"
+)
+
+// Limits is the host capacity available to UVMs. A zero value for any limit
+// means that it is not enforced.
+type Limits struct {
+	// MaxUVMs is the maximum number of UVMs that can hold a reservation.
+	MaxUVMs uint32
+	// MemoryMB is the total memory in MB that can be reserved by UVMs.
+	MemoryMB uint64
+	// Processors is the total number of vCPUs that can be reserved by UVMs.
+	Processors uint32
+}
+
+// Enabled returns if any limit is enforced.
+func (l Limits) Enabled() bool {
+	return l.MaxUVMs != 0 || l.MemoryMB != 0 || l.Processors != 0
+}
+
+// LimitsFromEnvironment returns the limits configured with the
+// HCSSHIM_RESERVATION_MAXUVMS, HCSSHIM_RESERVATION_MEMORYMB and
+// HCSSHIM_RESERVATION_PROCESSORS environment variables.
+func LimitsFromEnvironment() Limits {
+	return Limits{
+		MaxUVMs:    uint32(uintFromEnvironment("HCSSHIM_RESERVATION_MAXUVMS", 32)),
+		MemoryMB:   uintFromEnvironment("HCSSHIM_RESERVATION_MEMORYMB", 64),
+		Processors: uint32(uintFromEnvironment("HCSSHIM_RESERVATION_PROCESSORS", 32)),
+	}
+}
+
+func uintFromEnvironment(env string, bitSize int) uint64 {
+	if v := os.Getenv(env); v != "" {
+		if u, err := strconv.ParseUint(v, 10, bitSize); err == nil {
+			return u
+		}
+	}
+	return 0
+}
+
+// DefaultDirectory returns the directory holding the host's reservations. It
+// can be overridden with the HCSSHIM_RESERVATION_DIR environment variable.
+func DefaultDirectory() string {
+	if dir := os.Getenv("HCSSHIM_RESERVATION_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("ProgramData"), "Microsoft", "hcsshim", "reservations")
+}
+
+// Manager makes reservations against a host's capacity.
+type Manager struct {
+	dir    string
+	limits Limits
+}
+
+// NewManager returns a manager that keeps its reservations in `dir` and
+// enforces `limits`.
+func NewManager(dir string, limits Limits) *Manager {
+	return &Manager{
+		dir:    dir,
+		limits: limits,
+	}
+}
+
+// NewDefaultManager returns a manager using `DefaultDirectory` and
+// `LimitsFromEnvironment`.
+func NewDefaultManager() *Manager {
+	return NewManager(DefaultDirectory(), LimitsFromEnvironment())
+}
+
+// Usage is the capacity held by a single reservation.
+type Usage struct {
+	MemoryMB   uint64 `json:"MemoryMB,omitempty"`
+	Processors uint32 `json:"Processors,omitempty"`
+}
+
+// record is the content of a reservation file.
+type record struct {
+	Usage
+	// Detached is set while the reservation has no owning process.
+	Detached bool `json:",omitempty"`
+}
+
+// writeRecord replaces the content of the reservation file `f` with `rec`.
+func writeRecord(f *os.File, rec *record) error {
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(rec)
+}
+
+// Reservation is capacity held by a single UVM.
+type Reservation struct {
+	// ID is the ID the reservation was made for.
+	ID    string
+	Usage Usage
+
+	path string
+	file *os.File
+	once sync.Once
+}
+
+// Release releases the reservation's capacity. It is safe to call more than
+// once.
+func (r *Reservation) Release() (err error) {
+	r.once.Do(func() {
+		if r.file == nil {
+			return
+		}
+		_ = unlockReservation(r.file)
+		if err = r.file.Close(); err != nil {
+			return
+		}
+		err = os.Remove(r.path)
+	})
+	return err
+}
+
+// Detach gives up the ownership of the reservation without releasing its
+// capacity, which stays reserved until a process takes it over with
+// `Manager.Attach`. Once detached, further calls to Release and Detach do
+// nothing.
+func (r *Reservation) Detach() (err error) {
+	r.once.Do(func() {
+		if r.file == nil {
+			return
+		}
+		// If the record can not be written the file is left as it is, so
+		// that it is removed as stale once unlocked.
+		err = writeRecord(r.file, &record{Usage: r.Usage, Detached: true})
+		_ = unlockReservation(r.file)
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// The reservation lock is taken on a single byte far beyond the end of the
+// file, so that other processes can still read the reservation's usage.
+func lockOverlapped() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: 1}
+}
+
+func lockReservation(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, lockOverlapped())
+}
+
+func unlockReservation(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockOverlapped())
+}
+
+// lockManager serializes reservations between processes. The returned
+// function releases the lock.
+func (m *Manager) lockManager() (func(), error) {
+	f, err := os.OpenFile(filepath.Join(m.dir, managerLockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	ol := &windows.Overlapped{}
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+		f.Close()
+	}, nil
+}
+
+// activeUsage returns the usage of every live reservation, removing the files
+// of reservations whose owner has exited. Must be called with the manager lock
+// held.
+func (m *Manager) activeUsage(ctx context.Context) (map[string]Usage, error) {
+	entries, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]Usage)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), reservationFileExt) {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), reservationFileExt)
+		path := filepath.Join(m.dir, e.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		held := true
+		if err := lockReservation(f); err == nil {
+			_ = unlockReservation(f)
+			held = false
+		} else if err != windows.ERROR_LOCK_VIOLATION {
+			f.Close()
+			return nil, err
+		}
+		var rec record
+		err = json.NewDecoder(f).Decode(&rec)
+		f.Close()
+		if !held && (err != nil || !rec.Detached) {
+			// Nobody holds this reservation anymore.
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+			log.G(ctx).WithField("id", id).Debug("removed stale UVM reservation")
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read UVM reservation %s", path)
+		}
+		active[id] = rec.Usage
+	}
+	return active, nil
+}
+
+// Reserve reserves `usage` of the host's capacity for the UVM `id`. Returns an
+// error wrapping `ErrCapacityExhausted` if the reservation does not fit. If no
+// limits are enforced the returned reservation holds nothing.
+func (m *Manager) Reserve(ctx context.Context, id string, usage Usage) (_ *Reservation, err error) {
+	if !m.limits.Enabled() {
+		return &Reservation{ID: id, Usage: usage}, nil
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return nil, err
+	}
+	unlock, err := m.lockManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock UVM reservations")
+	}
+	defer unlock()
+
+	active, err := m.activeUsage(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to enumerate UVM reservations")
+	}
+	if _, ok := active[id]; ok {
+		return nil, fmt.Errorf("UVM reservation %s already exists", id)
+	}
+	total := usage
+	for _, u := range active {
+		total.MemoryMB += u.MemoryMB
+		total.Processors += u.Processors
+	}
+	if m.limits.MaxUVMs != 0 && uint32(len(active)) >= m.limits.MaxUVMs {
+		return nil, errors.Wrapf(ErrCapacityExhausted, "%d of %d UVMs already reserved", len(active), m.limits.MaxUVMs)
+	}
+	if m.limits.MemoryMB != 0 && total.MemoryMB > m.limits.MemoryMB {
+		return nil, errors.Wrapf(ErrCapacityExhausted, "reserving %dMB of memory would exceed the %dMB limit by %dMB", usage.MemoryMB, m.limits.MemoryMB, total.MemoryMB-m.limits.MemoryMB)
+	}
+	if m.limits.Processors != 0 && total.Processors > m.limits.Processors {
+		return nil, errors.Wrapf(ErrCapacityExhausted, "reserving %d processors would exceed the %d processor limit by %d", usage.Processors, m.limits.Processors, total.Processors-m.limits.Processors)
+	}
+
+	path := filepath.Join(m.dir, id+reservationFileExt)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+		}
+	}()
+	if err := json.NewEncoder(f).Encode(&record{Usage: usage}); err != nil {
+		return nil, err
+	}
+	if err := lockReservation(f); err != nil {
+		return nil, err
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"id":         id,
+		"memoryMB":   usage.MemoryMB,
+		"processors": usage.Processors,
+	}).Debug("reserved UVM capacity")
+	return &Reservation{
+		ID:    id,
+		Usage: usage,
+		path:  path,
+		file:  f,
+	}, nil
+}
+
+// Attach takes over the detached reservation of the UVM `id`, so that it is
+// owned by this process until released or detached again. Returns an error
+// wrapping `ErrNotDetached` if there is no detached reservation for `id`. If no
+// limits are enforced the returned reservation holds nothing.
+func (m *Manager) Attach(ctx context.Context, id string) (_ *Reservation, err error) {
+	if !m.limits.Enabled() {
+		return &Reservation{ID: id}, nil
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return nil, err
+	}
+	unlock, err := m.lockManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock UVM reservations")
+	}
+	defer unlock()
+
+	path := filepath.Join(m.dir, id+reservationFileExt)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrNotDetached, "no reservation for UVM %s", id)
+		}
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+		}
+	}()
+	if err := lockReservation(f); err != nil {
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return nil, errors.Wrapf(ErrNotDetached, "reservation for UVM %s is held by another process", id)
+		}
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = unlockReservation(f)
+		}
+	}()
+	var rec record
+	if err := json.NewDecoder(f).Decode(&rec); err != nil {
+		return nil, errors.Wrapf(err, "failed to read UVM reservation %s", path)
+	}
+	if !rec.Detached {
+		return nil, errors.Wrapf(ErrNotDetached, "reservation for UVM %s", id)
+	}
+	if err := writeRecord(f, &record{Usage: rec.Usage}); err != nil {
+		return nil, err
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"id":         id,
+		"memoryMB":   rec.MemoryMB,
+		"processors": rec.Processors,
+	}).Debug("attached UVM capacity reservation")
+	return &Reservation{
+		ID:    id,
+		Usage: rec.Usage,
+		path:  path,
+		file:  f,
+	}, nil
+}
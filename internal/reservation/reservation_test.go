@@ -0,0 +1,104 @@
+package reservation
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestReserveAndRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reservation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	m := NewManager(dir, Limits{MaxUVMs: 2, MemoryMB: 2048})
+	r1, err := m.Reserve(ctx, "uvm1", Usage{MemoryMB: 1024, Processors: 2})
+	if err != nil {
+		t.Fatalf("failed to reserve: %s", err)
+	}
+	if _, err := m.Reserve(ctx, "uvm2", Usage{MemoryMB: 2048}); errors.Cause(err) != ErrCapacityExhausted {
+		t.Fatalf("expected memory reservation to be exhausted, got: %v", err)
+	}
+	r2, err := m.Reserve(ctx, "uvm2", Usage{MemoryMB: 1024})
+	if err != nil {
+		t.Fatalf("failed to reserve: %s", err)
+	}
+	if _, err := m.Reserve(ctx, "uvm3", Usage{}); errors.Cause(err) != ErrCapacityExhausted {
+		t.Fatalf("expected UVM count reservation to be exhausted, got: %v", err)
+	}
+	if err := r1.Release(); err != nil {
+		t.Fatalf("failed to release: %s", err)
+	}
+	if err := r1.Release(); err != nil {
+		t.Fatalf("second release should be a no-op: %s", err)
+	}
+	r3, err := m.Reserve(ctx, "uvm3", Usage{MemoryMB: 1024})
+	if err != nil {
+		t.Fatalf("failed to reserve after release: %s", err)
+	}
+	_ = r2.Release()
+	_ = r3.Release()
+}
+
+func TestReserveDisabled(t *testing.T) {
+	m := NewManager("", Limits{})
+	r, err := m.Reserve(context.Background(), "uvm", Usage{MemoryMB: 1 << 40})
+	if err != nil {
+		t.Fatalf("reservation should always succeed without limits: %s", err)
+	}
+	if err := r.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetachAndAttach(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reservation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	m := NewManager(dir, Limits{MaxUVMs: 1})
+	r, err := m.Reserve(ctx, "uvm1", Usage{MemoryMB: 1024})
+	if err != nil {
+		t.Fatalf("failed to reserve: %s", err)
+	}
+	if _, err := m.Attach(ctx, "uvm1"); errors.Cause(err) != ErrNotDetached {
+		t.Fatalf("expected attaching a held reservation to fail, got: %v", err)
+	}
+	if err := r.Detach(); err != nil {
+		t.Fatalf("failed to detach: %s", err)
+	}
+	// A detached reservation keeps its capacity even though it has no owner.
+	if _, err := m.Reserve(ctx, "uvm2", Usage{}); errors.Cause(err) != ErrCapacityExhausted {
+		t.Fatalf("expected detached reservation to keep its capacity, got: %v", err)
+	}
+	if _, err := m.Attach(ctx, "uvm2"); errors.Cause(err) != ErrNotDetached {
+		t.Fatalf("expected attaching a missing reservation to fail, got: %v", err)
+	}
+	a, err := m.Attach(ctx, "uvm1")
+	if err != nil {
+		t.Fatalf("failed to attach: %s", err)
+	}
+	if a.Usage.MemoryMB != 1024 {
+		t.Fatalf("expected attached usage of 1024MB, got: %+v", a.Usage)
+	}
+	if _, err := m.Attach(ctx, "uvm1"); errors.Cause(err) != ErrNotDetached {
+		t.Fatalf("expected attaching an attached reservation to fail, got: %v", err)
+	}
+	if err := a.Release(); err != nil {
+		t.Fatalf("failed to release: %s", err)
+	}
+	r2, err := m.Reserve(ctx, "uvm2", Usage{})
+	if err != nil {
+		t.Fatalf("failed to reserve after release: %s", err)
+	}
+	_ = r2.Release()
+}
@@ -5,11 +5,13 @@ package resources
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/Microsoft/hcsshim/internal/credentials"
 	"github.com/Microsoft/hcsshim/internal/layers"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/sirupsen/logrus"
 )
 
 // NetNS returns the network namespace for the container
@@ -50,7 +52,31 @@ func (r *Resources) SetLayers(l *layers.ImageLayers) {
 // Add adds one or more resource closers to the resources struct to be
 // tracked for release later on
 func (r *Resources) Add(newResources ...ResourceCloser) {
-	r.resources = append(r.resources, newResources...)
+	for _, c := range newResources {
+		r.resources = append(r.resources, Resource{
+			Type:   fmt.Sprintf("%T", c),
+			Owner:  r.id,
+			Closer: c,
+		})
+	}
+}
+
+// AddFunc adds a resource of type `kind` that is released by calling
+// `release`, for resources that don't have a type implementing
+// ResourceCloser.
+func (r *Resources) AddFunc(kind string, release func(context.Context) error) {
+	r.resources = append(r.resources, Resource{
+		Type:   kind,
+		Owner:  r.id,
+		Closer: releaseFunc(release),
+	})
+}
+
+// List returns the resources currently tracked, in the order they were
+// added. After ReleaseResources it holds only the resources that failed to
+// release.
+func (r *Resources) List() []Resource {
+	return append([]Resource(nil), r.resources...)
 }
 
 // Resources is the structure returned as part of creating a container. It holds
@@ -74,7 +100,24 @@ type Resources struct {
 	// layers is a pointer to a struct of the layers paths of a container
 	layers *layers.ImageLayers
 	// resources is a slice of the resources associated with a container
-	resources []ResourceCloser
+	resources []Resource
+}
+
+// Resource is a single resource tracked by Resources.
+type Resource struct {
+	// Type describes the kind of resource, for a ResourceCloser added with Add
+	// it is the Go type of the closer.
+	Type string
+	// Owner is the ID of the container the resource was allocated for.
+	Owner string
+	// Closer releases the resource.
+	Closer ResourceCloser
+}
+
+type releaseFunc func(context.Context) error
+
+func (f releaseFunc) Release(ctx context.Context) error {
+	return f(ctx)
 }
 
 // ResourceCloser is a generic interface for the releasing of a resource. If a resource implements
@@ -105,40 +148,46 @@ func ReleaseResources(ctx context.Context, r *Resources, vm *uvm.UtilityVM, all
 		}
 	}
 
-	releaseErr := false
 	// Release resources in reverse order so that the most recently
 	// added are cleaned up first. We don't return an error right away
 	// so that other resources still get cleaned up in the case of one
-	// or more failing.
+	// or more failing. Resources that fail to release are kept so that they
+	// can be reported with List.
+	var leaked []Resource
+	release := func(res Resource) {
+		if err := res.Closer.Release(ctx); err != nil {
+			log.G(ctx).WithFields(logrus.Fields{
+				"resource": res.Type,
+				"owner":    res.Owner,
+			}).WithError(err).Error("failed to release container resource")
+			leaked = append(leaked, res)
+		}
+	}
 	for i := len(r.resources) - 1; i >= 0; i-- {
-		switch r.resources[i].(type) {
+		res := r.resources[i]
+		switch res.Closer.(type) {
 		case *uvm.NetworkEndpoints:
 			if r.createdNetNS {
-				if err := r.resources[i].Release(ctx); err != nil {
-					log.G(ctx).WithError(err).Error("failed to release container resource")
-					releaseErr = true
-				}
+				release(res)
 				r.createdNetNS = false
 			}
 		case *credentials.CCGResource:
-			if err := r.resources[i].Release(ctx); err != nil {
-				log.G(ctx).WithError(err).Error("failed to release container resource")
-				releaseErr = true
-			}
+			release(res)
 		default:
 			// Don't need to check if vm != nil here anymore as they wouldnt
 			// have been added in the first place. All resources have embedded
 			// vm they belong to.
 			if all {
-				if err := r.resources[i].Release(ctx); err != nil {
-					log.G(ctx).WithError(err).Error("failed to release container resource")
-					releaseErr = true
-				}
+				release(res)
 			}
 		}
 	}
-	r.resources = nil
-	if releaseErr {
+	// Restore the original order of the leaked resources.
+	for i, j := 0, len(leaked)-1; i < j; i, j = i+1, j-1 {
+		leaked[i], leaked[j] = leaked[j], leaked[i]
+	}
+	r.resources = leaked
+	if len(leaked) > 0 {
 		return errors.New("failed to release one or more container resources")
 	}
 
@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_ReleaseResources_Order(t *testing.T) {
+	r := NewContainerResources("test")
+	var released []string
+	for _, kind := range []string{"first", "second", "third"} {
+		kind := kind
+		r.AddFunc(kind, func(context.Context) error {
+			released = append(released, kind)
+			return nil
+		})
+	}
+	if err := ReleaseResources(context.Background(), r, nil, true); err != nil {
+		t.Fatalf("failed to release resources: %s", err)
+	}
+	expected := []string{"third", "second", "first"}
+	if !reflect.DeepEqual(released, expected) {
+		t.Fatalf("expected release order %v, got %v", expected, released)
+	}
+	if l := r.List(); len(l) != 0 {
+		t.Fatalf("expected no resources after release, got %v", l)
+	}
+}
+
+func Test_ReleaseResources_KeepsLeaked(t *testing.T) {
+	r := NewContainerResources("test")
+	r.AddFunc("ok", func(context.Context) error { return nil })
+	r.AddFunc("leaky", func(context.Context) error { return errors.New("busy") })
+	if err := ReleaseResources(context.Background(), r, nil, true); err == nil {
+		t.Fatal("expected release to fail")
+	}
+	l := r.List()
+	if len(l) != 1 || l[0].Type != "leaky" || l[0].Owner != "test" {
+		t.Fatalf("expected the leaked resource to be tracked, got %v", l)
+	}
+}
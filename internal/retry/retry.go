@@ -0,0 +1,165 @@
+// Package retry retries operations against the platform services (HCS, HNS
+// and the layer storage APIs) that fail with errors known to be transient.
+package retry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Error codes that are returned when the platform is busy, and that are safe
+// to retry an idempotent operation on.
+const (
+	errorSharingViolation  = syscall.Errno(0x20)
+	errorLockViolation     = syscall.Errno(0x21)
+	errorBusy              = syscall.Errno(0xaa)
+	errorRetry             = syscall.Errno(0x4d5)
+	rpcServerUnavailable   = syscall.Errno(0x6ba)
+	rpcServerTooBusy       = syscall.Errno(0x6bb)
+	hcsConnectionTimeout   = syscall.Errno(0x80370109)
+	hcsServiceNotAvailable = syscall.Errno(0x80370114)
+	hcsOperationTimeout    = syscall.Errno(0x80370118)
+)
+
+const (
+	facilityMask              = 0xffff0000
+	facilityWin32HResult      = 0x80070000
+	facilityVmcomputeHResult  = 0x80370000
+	facilityVmcomputeNTStatus = 0xc0370000
+)
+
+const (
+	defaultAttempts          = 5
+	defaultInitialBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff        = 2 * time.Second
+	defaultBackoffMultiplier = 2
+)
+
+var transientErrors = map[syscall.Errno]struct{}{
+	errorSharingViolation:  {},
+	errorLockViolation:     {},
+	errorBusy:              {},
+	errorRetry:             {},
+	rpcServerUnavailable:   {},
+	rpcServerTooBusy:       {},
+	hcsConnectionTimeout:   {},
+	hcsServiceNotAvailable: {},
+	hcsOperationTimeout:    {},
+}
+
+// normalize maps the HRESULT and NTSTATUS forms of the error codes to the
+// form used in `transientErrors`.
+func normalize(code syscall.Errno) syscall.Errno {
+	switch uint32(code) & facilityMask {
+	case facilityWin32HResult:
+		return code & 0xffff
+	case facilityVmcomputeNTStatus:
+		return code&^facilityMask | facilityVmcomputeHResult
+	}
+	return code
+}
+
+// IsTransient returns true if `err`, or an error it wraps, is an error code
+// that the platform returns when it is temporarily unable to complete a
+// request.
+func IsTransient(err error) bool {
+	var code syscall.Errno
+	if !errors.As(err, &code) {
+		return false
+	}
+	_, ok := transientErrors[normalize(code)]
+	return ok
+}
+
+// Policy controls how an operation is retried.
+type Policy struct {
+	// Attempts is the maximum number of times the operation is run. A value
+	// of 1 or less disables retries.
+	Attempts int
+	// InitialBackoff is the time waited before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time waited between retries.
+	MaxBackoff time.Duration
+	// Multiplier is the factor that the backoff grows by after every retry.
+	Multiplier float64
+	// IsTransient classifies the errors that are retried. If nil, the
+	// package level IsTransient is used.
+	IsTransient func(error) bool
+}
+
+// Default is the policy used by Do. It can be replaced by consumers of
+// hcsshim, and its attempts and backoff can be set by operators through the
+// environment.
+var Default = &Policy{
+	Attempts:       defaultAttempts,
+	InitialBackoff: defaultInitialBackoff,
+	MaxBackoff:     defaultMaxBackoff,
+	Multiplier:     defaultBackoffMultiplier,
+}
+
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("HCSSHIM_RETRY_ATTEMPTS")); err == nil && v > 0 {
+		Default.Attempts = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HCSSHIM_RETRY_BACKOFF_MS")); err == nil && v > 0 {
+		Default.InitialBackoff = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("HCSSHIM_RETRY_MAXBACKOFF_MS")); err == nil && v > 0 {
+		Default.MaxBackoff = time.Duration(v) * time.Millisecond
+	}
+}
+
+// Do runs `f` with the Default policy.
+func Do(ctx context.Context, op string, f func() error) error {
+	return Default.Do(ctx, op, f)
+}
+
+// Backoff returns the time to wait before retry number `retry`, starting
+// from 0.
+func (p *Policy) Backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < retry && d < p.MaxBackoff; i++ {
+		d = time.Duration(float64(d) * p.Multiplier)
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Do runs `f` until it succeeds, fails with an error that is not transient,
+// the attempts are exhausted, or `ctx` is done. The last error from `f` is
+// returned. `f` must be safe to run again after a transient failure.
+func (p *Policy) Do(ctx context.Context, op string, f func() error) error {
+	isTransient := p.IsTransient
+	if isTransient == nil {
+		isTransient = IsTransient
+	}
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= p.Attempts || !isTransient(err) {
+			return err
+		}
+		backoff := p.Backoff(attempt - 1)
+		log.G(ctx).WithFields(logrus.Fields{
+			logrus.ErrorKey: err,
+			"operation":     op,
+			"attempt":       attempt,
+			"backoff":       backoff.String(),
+		}).Warning("retrying operation after transient error")
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_IsTransient(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{err: errorBusy, transient: true},
+		{err: syscall.Errno(0x800700aa), transient: true},
+		{err: syscall.Errno(0xc0370109), transient: true},
+		{err: fmt.Errorf("open: %w", errorSharingViolation), transient: true},
+		{err: syscall.Errno(0x490), transient: false},
+		{err: errors.New("busy"), transient: false},
+		{err: nil, transient: false},
+	}
+	for _, tt := range tests {
+		if IsTransient(tt.err) != tt.transient {
+			t.Errorf("IsTransient(%v) expected %v", tt.err, tt.transient)
+		}
+	}
+}
+
+func Test_Policy_Backoff(t *testing.T) {
+	p := &Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := p.Backoff(i); d != expected {
+			t.Errorf("Backoff(%d) = %s, expected %s", i, d, expected)
+		}
+	}
+}
+
+func Test_Policy_Do(t *testing.T) {
+	p := &Policy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 2}
+
+	calls := 0
+	err := p.Do(context.Background(), "test", func() error {
+		calls++
+		if calls < 2 {
+			return errorBusy
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("expected success after a retry, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = p.Do(context.Background(), "test", func() error {
+		calls++
+		return errorBusy
+	})
+	if err != errorBusy || calls != 3 {
+		t.Fatalf("expected the attempts to be exhausted, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	notFound := syscall.Errno(0x490)
+	err = p.Do(context.Background(), "test", func() error {
+		calls++
+		return notFound
+	})
+	if err != notFound || calls != 1 {
+		t.Fatalf("expected no retry of a permanent error, got %v after %d calls", err, calls)
+	}
+}
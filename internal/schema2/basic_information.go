@@ -0,0 +1,15 @@
+/*
+ * HCS API
+ *
+ * No description provided (generated by Swagger Codegen https://github.com/swagger-api/swagger-codegen)
+ *
+ * API version: 2.1
+ * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ */
+
+package hcsschema
+
+// BasicInformation is the result of the "Basic" service property query.
+type BasicInformation struct {
+	SupportedSchemaVersions []Version `json:"SupportedSchemaVersions,omitempty"`
+}
@@ -0,0 +1,22 @@
+/*
+ * HCS API
+ *
+ * No description provided (generated by Swagger Codegen https://github.com/swagger-api/swagger-codegen)
+ *
+ * API version: 2.5
+ * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ */
+
+package hcsschema
+
+type DebugOptions struct {
+	// BugcheckSavedStateFileName is the path of the file that the state of the VM is saved to when the guest crashes.
+	BugcheckSavedStateFileName string `json:"BugcheckSavedStateFileName,omitempty"`
+
+	// BugcheckNoCrashdumpSavedStateFileName is the path of the file that the state of the VM is saved to when the guest crashes without being able to write a crash dump, such as early in boot.
+	BugcheckNoCrashdumpSavedStateFileName string `json:"BugcheckNoCrashdumpSavedStateFileName,omitempty"`
+
+	TripleFaultSavedStateFileName string `json:"TripleFaultSavedStateFileName,omitempty"`
+
+	FirmwareDumpFileName string `json:"FirmwareDumpFileName,omitempty"`
+}
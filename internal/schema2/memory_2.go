@@ -46,4 +46,11 @@ type Memory2 struct {
 	// TODO: This is pre-release support in schema 2.3. Need to add build number
 	// docs when a public build with this is out.
 	HighMMIOGapInMB uint64 `json:"HighMmioGapInMB,omitempty"`
+
+	// BackingPageSize is the size of the host pages backing the VM's memory.
+	// Large pages require the memory to be physically backed.
+	//
+	// TODO: This is pre-release support in schema 2.4. Need to add build number
+	// docs when a public build with this is out.
+	BackingPageSize MemoryBackingPageSize `json:"BackingPageSize,omitempty"`
 }
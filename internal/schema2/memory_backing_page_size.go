@@ -0,0 +1,17 @@
+/*
+ * HCS API
+ *
+ * No description provided (generated by Swagger Codegen https://github.com/swagger-api/swagger-codegen)
+ *
+ * API version: 2.4
+ * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ */
+
+package hcsschema
+
+type MemoryBackingPageSize string
+
+const (
+	MemoryBackingPageSizeSmall MemoryBackingPageSize = "Small"
+	MemoryBackingPageSizeLarge MemoryBackingPageSize = "Large"
+)
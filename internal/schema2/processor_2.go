@@ -17,4 +17,7 @@ type Processor2 struct {
 	Weight int32 `json:"Weight,omitempty"`
 
 	ExposeVirtualizationExtensions bool `json:"ExposeVirtualizationExtensions,omitempty"`
+
+	// This field is not generated by swagger. This was added manually.
+	HwThreadsPerCore int32 `json:"HwThreadsPerCore,omitempty"`
 }
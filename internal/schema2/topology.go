@@ -13,4 +13,6 @@ type Topology struct {
 	Memory *Memory2 `json:"Memory,omitempty"`
 
 	Processor *Processor2 `json:"Processor,omitempty"`
+
+	Numa *Numa `json:"Numa,omitempty"`
 }
@@ -21,6 +21,11 @@ func SchemaV21() *hcsschema.Version {
 	return &hcsschema.Version{Major: 2, Minor: 1}
 }
 
+// SchemaV24 makes it easy for callers to get a v2.4 schema version object
+func SchemaV24() *hcsschema.Version {
+	return &hcsschema.Version{Major: 2, Minor: 4}
+}
+
 // isSupported determines if a given schema version is supported
 func IsSupported(sv *hcsschema.Version) error {
 	if IsV10(sv) {
@@ -32,6 +37,12 @@ func IsSupported(sv *hcsschema.Version) error {
 		}
 		return nil
 	}
+	if IsV24(sv) {
+		if osversion.Get().Build < osversion.V21H2Server {
+			return fmt.Errorf("unsupported on this Windows build")
+		}
+		return nil
+	}
 	return fmt.Errorf("unknown schema version %s", String(sv))
 }
 
@@ -54,6 +65,15 @@ func IsV21(sv *hcsschema.Version) bool {
 	return false
 }
 
+// IsV24 determines if a given schema version object is 2.4, which is supported
+// from Windows Server 2022 onwards.
+func IsV24(sv *hcsschema.Version) bool {
+	if sv.Major == 2 && sv.Minor == 4 {
+		return true
+	}
+	return false
+}
+
 // String returns a JSON encoding of a schema version object
 func String(sv *hcsschema.Version) string {
 	b, err := json.Marshal(sv)
@@ -8,6 +8,7 @@ import (
 	fmt "fmt"
 	github_com_containerd_ttrpc "github.com/containerd/ttrpc"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	io "io"
 	math "math"
 	reflect "reflect"
@@ -32,6 +33,8 @@ type ExecProcessRequest struct {
 	Stdin                string   `protobuf:"bytes,4,opt,name=stdin,proto3" json:"stdin,omitempty"`
 	Stdout               string   `protobuf:"bytes,5,opt,name=stdout,proto3" json:"stdout,omitempty"`
 	Stderr               string   `protobuf:"bytes,6,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	User                 string   `protobuf:"bytes,7,opt,name=user,proto3" json:"user,omitempty"`
+	EnvFile              string   `protobuf:"bytes,8,opt,name=env_file,json=envFile,proto3" json:"env_file,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -342,6 +345,240 @@ func (m *PidResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_PidResponse proto.InternalMessageInfo
 
+type QuiesceRequest struct {
+	HostPaths            []string `protobuf:"bytes,1,rep,name=host_paths,json=hostPaths,proto3" json:"host_paths,omitempty"`
+	TimeoutInSeconds     uint32   `protobuf:"varint,2,opt,name=timeout_in_seconds,json=timeoutInSeconds,proto3" json:"timeout_in_seconds,omitempty"`
+	Thaw                 bool     `protobuf:"varint,3,opt,name=thaw,proto3" json:"thaw,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QuiesceRequest) Reset()      { *m = QuiesceRequest{} }
+func (*QuiesceRequest) ProtoMessage() {}
+func (*QuiesceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{8}
+}
+func (m *QuiesceRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuiesceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuiesceRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuiesceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuiesceRequest.Merge(m, src)
+}
+func (m *QuiesceRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuiesceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuiesceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuiesceRequest proto.InternalMessageInfo
+
+type QuiesceResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QuiesceResponse) Reset()      { *m = QuiesceResponse{} }
+func (*QuiesceResponse) ProtoMessage() {}
+func (*QuiesceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{9}
+}
+func (m *QuiesceResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuiesceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuiesceResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuiesceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuiesceResponse.Merge(m, src)
+}
+func (m *QuiesceResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuiesceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuiesceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuiesceResponse proto.InternalMessageInfo
+
+type ResizeDiskRequest struct {
+	HostPath             string   `protobuf:"bytes,1,opt,name=host_path,json=hostPath,proto3" json:"host_path,omitempty"`
+	SizeInBytes          uint64   `protobuf:"varint,2,opt,name=size_in_bytes,json=sizeInBytes,proto3" json:"size_in_bytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResizeDiskRequest) Reset()      { *m = ResizeDiskRequest{} }
+func (*ResizeDiskRequest) ProtoMessage() {}
+func (*ResizeDiskRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{10}
+}
+func (m *ResizeDiskRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ResizeDiskRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ResizeDiskRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ResizeDiskRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResizeDiskRequest.Merge(m, src)
+}
+func (m *ResizeDiskRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ResizeDiskRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResizeDiskRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResizeDiskRequest proto.InternalMessageInfo
+
+type ResizeDiskResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResizeDiskResponse) Reset()      { *m = ResizeDiskResponse{} }
+func (*ResizeDiskResponse) ProtoMessage() {}
+func (*ResizeDiskResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{11}
+}
+func (m *ResizeDiskResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ResizeDiskResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ResizeDiskResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ResizeDiskResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResizeDiskResponse.Merge(m, src)
+}
+func (m *ResizeDiskResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ResizeDiskResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResizeDiskResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResizeDiskResponse proto.InternalMessageInfo
+
+type NetworkInterfacesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NetworkInterfacesRequest) Reset()      { *m = NetworkInterfacesRequest{} }
+func (*NetworkInterfacesRequest) ProtoMessage() {}
+func (*NetworkInterfacesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{12}
+}
+func (m *NetworkInterfacesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NetworkInterfacesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NetworkInterfacesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NetworkInterfacesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NetworkInterfacesRequest.Merge(m, src)
+}
+func (m *NetworkInterfacesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *NetworkInterfacesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NetworkInterfacesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NetworkInterfacesRequest proto.InternalMessageInfo
+
+type NetworkInterfacesResponse struct {
+	InterfaceNames       map[string]string `protobuf:"bytes,1,rep,name=interface_names,json=interfaceNames,proto3" json:"interface_names,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *NetworkInterfacesResponse) Reset()      { *m = NetworkInterfacesResponse{} }
+func (*NetworkInterfacesResponse) ProtoMessage() {}
+func (*NetworkInterfacesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c7933dc6ffbb8784, []int{13}
+}
+func (m *NetworkInterfacesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NetworkInterfacesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NetworkInterfacesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NetworkInterfacesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NetworkInterfacesResponse.Merge(m, src)
+}
+func (m *NetworkInterfacesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *NetworkInterfacesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NetworkInterfacesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NetworkInterfacesResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*ExecProcessRequest)(nil), "containerd.runhcs.v1.diag.ExecProcessRequest")
 	proto.RegisterType((*ExecProcessResponse)(nil), "containerd.runhcs.v1.diag.ExecProcessResponse")
@@ -351,6 +588,13 @@ func init() {
 	proto.RegisterType((*ShareResponse)(nil), "containerd.runhcs.v1.diag.ShareResponse")
 	proto.RegisterType((*PidRequest)(nil), "containerd.runhcs.v1.diag.PidRequest")
 	proto.RegisterType((*PidResponse)(nil), "containerd.runhcs.v1.diag.PidResponse")
+	proto.RegisterType((*QuiesceRequest)(nil), "containerd.runhcs.v1.diag.QuiesceRequest")
+	proto.RegisterType((*QuiesceResponse)(nil), "containerd.runhcs.v1.diag.QuiesceResponse")
+	proto.RegisterType((*ResizeDiskRequest)(nil), "containerd.runhcs.v1.diag.ResizeDiskRequest")
+	proto.RegisterType((*ResizeDiskResponse)(nil), "containerd.runhcs.v1.diag.ResizeDiskResponse")
+	proto.RegisterType((*NetworkInterfacesRequest)(nil), "containerd.runhcs.v1.diag.NetworkInterfacesRequest")
+	proto.RegisterType((*NetworkInterfacesResponse)(nil), "containerd.runhcs.v1.diag.NetworkInterfacesResponse")
+	proto.RegisterMapType((map[string]string)(nil), "containerd.runhcs.v1.diag.NetworkInterfacesResponse.InterfaceNamesEntry")
 }
 
 func init() {
@@ -358,41 +602,58 @@ func init() {
 }
 
 var fileDescriptor_c7933dc6ffbb8784 = []byte{
-	// 534 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0x8e, 0x49, 0x93, 0xda, 0xd3, 0x50, 0xd0, 0x52, 0x21, 0xd7, 0x91, 0x4c, 0xb0, 0x04, 0x98,
-	0x03, 0x8e, 0x28, 0x07, 0x0e, 0x88, 0x0b, 0x3f, 0x12, 0x08, 0x21, 0x42, 0x72, 0xa9, 0x38, 0x10,
-	0x6d, 0xed, 0xc5, 0x5e, 0x35, 0xde, 0x0d, 0xbb, 0xeb, 0xd0, 0xde, 0x78, 0x0c, 0x1e, 0x80, 0x87,
-	0xe9, 0x91, 0x23, 0x47, 0x9a, 0x27, 0x41, 0xbb, 0x5e, 0x07, 0x2a, 0x44, 0xda, 0x9e, 0x32, 0xdf,
-	0xb7, 0xf3, 0xcd, 0xec, 0x7c, 0xb3, 0x31, 0x3c, 0xcd, 0xa9, 0x2a, 0xaa, 0x83, 0x24, 0xe5, 0xe5,
-	0xf0, 0x2d, 0x4d, 0x05, 0x97, 0xfc, 0x93, 0x1a, 0x16, 0xa9, 0x94, 0x05, 0x2d, 0x87, 0x94, 0x29,
-	0x22, 0x18, 0x9e, 0x0d, 0x35, 0xca, 0x28, 0xce, 0x57, 0x41, 0x32, 0x17, 0x5c, 0x71, 0xb4, 0x9b,
-	0x72, 0xa6, 0x30, 0x65, 0x44, 0x64, 0x89, 0xa8, 0x58, 0x91, 0xca, 0x64, 0xf1, 0x30, 0xd1, 0x09,
-	0xc1, 0x4e, 0xce, 0x73, 0x6e, 0xb2, 0x86, 0x3a, 0xaa, 0x05, 0xd1, 0x77, 0x07, 0xd0, 0xcb, 0x23,
-	0x92, 0x8e, 0x04, 0x4f, 0x89, 0x94, 0x63, 0xf2, 0xb9, 0x22, 0x52, 0x21, 0x04, 0x1b, 0x58, 0xe4,
-	0xd2, 0x77, 0x06, 0xed, 0xd8, 0x1b, 0x9b, 0x18, 0xf9, 0xb0, 0xf9, 0x85, 0x8b, 0xc3, 0x8c, 0x0a,
-	0xff, 0xca, 0xc0, 0x89, 0xbd, 0x71, 0x03, 0x51, 0x00, 0xae, 0x22, 0xa2, 0xa4, 0x0c, 0xcf, 0xfc,
-	0xf6, 0xc0, 0x89, 0xdd, 0xf1, 0x0a, 0xa3, 0x1d, 0xe8, 0x48, 0x95, 0x51, 0xe6, 0x6f, 0x18, 0x4d,
-	0x0d, 0xd0, 0x4d, 0xe8, 0x4a, 0x95, 0xf1, 0x4a, 0xf9, 0x1d, 0x43, 0x5b, 0x64, 0x79, 0x22, 0x84,
-	0xdf, 0x5d, 0xf1, 0x44, 0x88, 0x68, 0x0f, 0x6e, 0x9c, 0xb9, 0xa5, 0x9c, 0x73, 0x26, 0x09, 0xea,
-	0x83, 0x47, 0x8e, 0xa8, 0x9a, 0xa6, 0x3c, 0x23, 0xbe, 0x33, 0x70, 0xe2, 0xce, 0xd8, 0xd5, 0xc4,
-	0x73, 0x9e, 0x91, 0xe8, 0x1a, 0x5c, 0x9d, 0x28, 0x9c, 0x1e, 0x36, 0x43, 0x45, 0x6f, 0x60, 0xbb,
-	0x21, 0xac, 0xde, 0xb4, 0xd3, 0x8c, 0x11, 0x9b, 0x76, 0x1a, 0xa1, 0xdb, 0xd0, 0xcb, 0xb5, 0x64,
-	0x6a, 0x4f, 0xeb, 0x79, 0xb7, 0x0c, 0x57, 0x97, 0x88, 0x52, 0xe8, 0x4d, 0x0a, 0x2c, 0x48, 0xe3,
-	0x58, 0x1f, 0xbc, 0x82, 0x4b, 0x35, 0x9d, 0x63, 0x55, 0xd8, 0x6a, 0xae, 0x26, 0x46, 0x58, 0x15,
-	0x68, 0x17, 0xdc, 0x6a, 0x51, 0xd6, 0x67, 0xd6, 0xbb, 0x6a, 0x51, 0x9a, 0xa3, 0x3e, 0x78, 0x82,
-	0xe0, 0x6c, 0xca, 0xd9, 0xec, 0xb8, 0x31, 0x4f, 0x13, 0xef, 0xd8, 0xec, 0xd8, 0x8c, 0x50, 0x37,
-	0xa9, 0x2f, 0x1c, 0xf5, 0x00, 0x46, 0x34, 0x6b, 0x06, 0xba, 0x05, 0x5b, 0x06, 0xd9, 0x69, 0xae,
-	0x43, 0x7b, 0x4e, 0x33, 0xeb, 0x83, 0x0e, 0xf7, 0xbe, 0xb5, 0xc1, 0x9d, 0x14, 0xb4, 0x7c, 0x41,
-	0x71, 0x8e, 0x38, 0x6c, 0xeb, 0x5f, 0xed, 0xe3, 0x6b, 0xf6, 0x8a, 0x4b, 0x85, 0x1e, 0x24, 0xff,
-	0x7d, 0x2e, 0xc9, 0xbf, 0x8f, 0x22, 0x48, 0x2e, 0x9a, 0x6e, 0xef, 0x83, 0x01, 0x74, 0xc3, 0xda,
-	0x30, 0x14, 0xaf, 0x51, 0x9f, 0xd9, 0x53, 0x70, 0xff, 0x02, 0x99, 0xb6, 0xc5, 0x47, 0xf0, 0x4c,
-	0x0b, 0x6d, 0x12, 0xba, 0xb7, 0x4e, 0xf7, 0xd7, 0xae, 0x82, 0xf8, 0xfc, 0x44, 0x5b, 0x7f, 0x1f,
-	0x36, 0x75, 0xfd, 0x11, 0xcd, 0xd0, 0x9d, 0x35, 0xa2, 0x3f, 0x3b, 0x09, 0xee, 0x9e, 0x97, 0x56,
-	0x57, 0x7e, 0xf6, 0xfe, 0xe4, 0x34, 0x6c, 0xfd, 0x3c, 0x0d, 0x5b, 0x5f, 0x97, 0xa1, 0x73, 0xb2,
-	0x0c, 0x9d, 0x1f, 0xcb, 0xd0, 0xf9, 0xb5, 0x0c, 0x9d, 0x0f, 0x8f, 0x2f, 0xf7, 0x09, 0x78, 0xd2,
-	0x04, 0xfb, 0xad, 0x83, 0xae, 0xf9, 0x53, 0x3f, 0xfa, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x3e, 0xb1,
-	0xa6, 0x27, 0x46, 0x04, 0x00, 0x00,
+	// 804 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0x4f, 0x6f, 0x1b, 0x45,
+	0x14, 0xcf, 0x36, 0x71, 0x62, 0x3f, 0x27, 0x4e, 0x3b, 0x0d, 0x68, 0xb3, 0x11, 0xc6, 0xac, 0x04,
+	0x18, 0xd4, 0xae, 0x45, 0x8a, 0x04, 0x02, 0x71, 0xa0, 0xb4, 0xa8, 0x16, 0xa2, 0xb8, 0x6b, 0x0e,
+	0x15, 0x07, 0x56, 0x93, 0xdd, 0x89, 0x77, 0x64, 0xef, 0x8c, 0x33, 0x33, 0xeb, 0xd6, 0x9c, 0x10,
+	0x9f, 0xae, 0x47, 0x10, 0x17, 0x8e, 0xd4, 0x1f, 0x81, 0x4f, 0x80, 0x66, 0x67, 0x76, 0x1b, 0x2b,
+	0x89, 0xe3, 0xf4, 0xe4, 0xf7, 0x7e, 0xf3, 0xfe, 0xfc, 0xde, 0x9b, 0xf9, 0x79, 0xe1, 0x9b, 0x11,
+	0x55, 0x69, 0x7e, 0x12, 0xc4, 0x3c, 0xeb, 0xfd, 0x48, 0x63, 0xc1, 0x25, 0x3f, 0x55, 0xbd, 0x34,
+	0x96, 0x32, 0xa5, 0x59, 0x8f, 0x32, 0x45, 0x04, 0xc3, 0x93, 0x9e, 0xf6, 0x12, 0x8a, 0x47, 0x95,
+	0x11, 0x4c, 0x05, 0x57, 0x1c, 0x1d, 0xc6, 0x9c, 0x29, 0x4c, 0x19, 0x11, 0x49, 0x20, 0x72, 0x96,
+	0xc6, 0x32, 0x98, 0x7d, 0x16, 0xe8, 0x00, 0xef, 0x60, 0xc4, 0x47, 0xbc, 0x88, 0xea, 0x69, 0xcb,
+	0x24, 0xf8, 0x7f, 0x3b, 0x80, 0x1e, 0xbf, 0x24, 0xf1, 0x40, 0xf0, 0x98, 0x48, 0x19, 0x92, 0xb3,
+	0x9c, 0x48, 0x85, 0x10, 0x6c, 0x61, 0x31, 0x92, 0xae, 0xd3, 0xd9, 0xec, 0x36, 0xc2, 0xc2, 0x46,
+	0x2e, 0xec, 0xbc, 0xe0, 0x62, 0x9c, 0x50, 0xe1, 0xde, 0xea, 0x38, 0xdd, 0x46, 0x58, 0xba, 0xc8,
+	0x83, 0xba, 0x22, 0x22, 0xa3, 0x0c, 0x4f, 0xdc, 0xcd, 0x8e, 0xd3, 0xad, 0x87, 0x95, 0x8f, 0x0e,
+	0xa0, 0x26, 0x55, 0x42, 0x99, 0xbb, 0x55, 0xe4, 0x18, 0x07, 0xbd, 0x0b, 0xdb, 0x52, 0x25, 0x3c,
+	0x57, 0x6e, 0xad, 0x80, 0xad, 0x67, 0x71, 0x22, 0x84, 0xbb, 0x5d, 0xe1, 0x44, 0x08, 0xcd, 0x27,
+	0x97, 0x44, 0xb8, 0x3b, 0x05, 0x5a, 0xd8, 0xe8, 0x10, 0xea, 0x84, 0xcd, 0xa2, 0x53, 0x3a, 0x21,
+	0x6e, 0xdd, 0x10, 0x22, 0x6c, 0xf6, 0x3d, 0x9d, 0x10, 0xff, 0x18, 0xee, 0x2e, 0x0d, 0x25, 0xa7,
+	0x9c, 0x49, 0x82, 0x8e, 0xa0, 0x41, 0x5e, 0x52, 0x15, 0xc5, 0x3c, 0x21, 0xae, 0xd3, 0x71, 0xba,
+	0xb5, 0xb0, 0xae, 0x81, 0xef, 0x78, 0x42, 0xfc, 0x7d, 0xd8, 0x1b, 0x2a, 0x1c, 0x8f, 0xcb, 0x1d,
+	0xf8, 0x3f, 0x40, 0xab, 0x04, 0x6c, 0x7e, 0xc1, 0x4e, 0x23, 0xae, 0x53, 0xb2, 0xd3, 0x1e, 0xfa,
+	0x00, 0x76, 0x47, 0x3a, 0x25, 0xb2, 0xa7, 0x66, 0x3d, 0xcd, 0x02, 0x33, 0x25, 0xfc, 0x18, 0x76,
+	0x87, 0x29, 0x16, 0xa4, 0x5c, 0xf0, 0x11, 0x34, 0x52, 0x2e, 0x55, 0x34, 0xc5, 0x2a, 0xb5, 0xd5,
+	0xea, 0x1a, 0x18, 0x60, 0x95, 0xea, 0xc9, 0xf2, 0x59, 0x66, 0xce, 0xec, 0xaa, 0xf3, 0x59, 0x56,
+	0x1c, 0x1d, 0x41, 0x43, 0x10, 0x9c, 0x44, 0x9c, 0x4d, 0xe6, 0xe5, 0xae, 0x35, 0xf0, 0x13, 0x9b,
+	0xcc, 0x8b, 0x11, 0x4c, 0x13, 0x43, 0xd8, 0xdf, 0x05, 0x18, 0xd0, 0xa4, 0x1c, 0xe8, 0x7d, 0x68,
+	0x16, 0x9e, 0x9d, 0xe6, 0x36, 0x6c, 0x4e, 0x69, 0x62, 0xf7, 0xa0, 0x4d, 0xff, 0x0c, 0x5a, 0xcf,
+	0x72, 0x4a, 0x64, 0x5c, 0xd1, 0x7c, 0x0f, 0xa0, 0xa2, 0x59, 0xbe, 0x86, 0x46, 0xc9, 0x53, 0xa2,
+	0x7b, 0x80, 0x14, 0xcd, 0x08, 0xcf, 0x55, 0x44, 0x59, 0x24, 0x49, 0xcc, 0x59, 0x62, 0xc6, 0xdf,
+	0x0b, 0x6f, 0xdb, 0x93, 0x3e, 0x1b, 0x1a, 0x5c, 0x5f, 0xa2, 0x4a, 0xf1, 0x0b, 0x4b, 0xbb, 0xb0,
+	0xfd, 0x3b, 0xb0, 0x5f, 0xb5, 0xb4, 0xa4, 0x7f, 0x86, 0x3b, 0x21, 0x91, 0xf4, 0x37, 0xf2, 0x88,
+	0xca, 0xf1, 0x5a, 0xfb, 0xf2, 0x61, 0x4f, 0xc7, 0x6b, 0x0e, 0x27, 0x73, 0x45, 0x0c, 0x83, 0xad,
+	0xb0, 0xa9, 0xc1, 0x3e, 0x7b, 0xa8, 0x21, 0xff, 0x00, 0xd0, 0xf9, 0xaa, 0xb6, 0x97, 0x07, 0xee,
+	0x53, 0xa2, 0xf4, 0x3b, 0xee, 0x6b, 0x69, 0x9d, 0xe2, 0x98, 0x54, 0xf7, 0xff, 0x97, 0x03, 0x87,
+	0x97, 0x1c, 0xda, 0xed, 0x9d, 0xc1, 0x3e, 0x2d, 0xd1, 0x88, 0xe1, 0x8c, 0x98, 0xf5, 0x34, 0x8f,
+	0x9f, 0x04, 0x57, 0x6a, 0x30, 0xb8, 0xb2, 0x5c, 0x50, 0x41, 0x4f, 0x75, 0xa9, 0xc7, 0x4c, 0x89,
+	0x79, 0xd8, 0xa2, 0x4b, 0xa0, 0xf7, 0x2d, 0xdc, 0xbd, 0x24, 0x4c, 0xdf, 0xe3, 0x98, 0xcc, 0xed,
+	0x52, 0xb4, 0xa9, 0x35, 0x37, 0xc3, 0x93, 0x9c, 0xd8, 0xc7, 0x63, 0x9c, 0xaf, 0x6e, 0x7d, 0xe9,
+	0x1c, 0xff, 0x57, 0x83, 0xfa, 0x30, 0xa5, 0xd9, 0x23, 0x8a, 0x47, 0x88, 0x43, 0x4b, 0xff, 0x6a,
+	0xa5, 0xf4, 0xd9, 0x13, 0x2e, 0x15, 0xba, 0xbf, 0x82, 0xfb, 0xc5, 0x7f, 0x09, 0x2f, 0x58, 0x37,
+	0xdc, 0xee, 0x0c, 0x03, 0xe8, 0x86, 0x46, 0x12, 0xa8, 0xbb, 0x22, 0x7b, 0x49, 0x89, 0xde, 0x27,
+	0x6b, 0x44, 0xda, 0x16, 0xbf, 0x42, 0xa3, 0x68, 0xa1, 0x65, 0x80, 0x3e, 0x5e, 0x95, 0x77, 0x4e,
+	0x8d, 0x5e, 0xf7, 0xfa, 0x40, 0x5b, 0xff, 0x39, 0xec, 0xe8, 0xfa, 0x03, 0x9a, 0xa0, 0x0f, 0x57,
+	0x24, 0xbd, 0x51, 0x9d, 0xf7, 0xd1, 0x75, 0x61, 0xb6, 0x72, 0x02, 0x4d, 0x5d, 0xd9, 0xaa, 0x01,
+	0xad, 0x9a, 0x79, 0x59, 0xa4, 0xde, 0xa7, 0xeb, 0x84, 0xda, 0x2e, 0x99, 0xb9, 0xf3, 0x37, 0x52,
+	0x40, 0xf7, 0x56, 0x64, 0x5f, 0xd0, 0xa1, 0x77, 0x7f, 0xcd, 0x68, 0xdb, 0xee, 0x0f, 0x07, 0xde,
+	0xd1, 0xfd, 0x2e, 0x3c, 0x7c, 0xf4, 0xe0, 0x66, 0x32, 0x31, 0xdd, 0x3f, 0x7f, 0x1b, 0x6d, 0x3d,
+	0x7c, 0xf6, 0xea, 0x75, 0x7b, 0xe3, 0x9f, 0xd7, 0xed, 0x8d, 0xdf, 0x17, 0x6d, 0xe7, 0xd5, 0xa2,
+	0xed, 0xfc, 0xb9, 0x68, 0x3b, 0xff, 0x2e, 0xda, 0xce, 0x2f, 0x5f, 0xdc, 0xec, 0x6b, 0xfb, 0x75,
+	0x69, 0x3c, 0xdf, 0x38, 0xd9, 0x2e, 0xbe, 0x9f, 0x0f, 0xfe, 0x1f, 0x00, 0xfb, 0xae, 0x8d, 0xdd,
+	0xb1, 0x07, 0x00, 0x00,
 }
 
 func (m *ExecProcessRequest) Marshal() (dAtA []byte, err error) {
@@ -459,6 +720,18 @@ func (m *ExecProcessRequest) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintShimdiag(dAtA, i, uint64(len(m.Stderr)))
 		i += copy(dAtA[i:], m.Stderr)
 	}
+	if len(m.User) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintShimdiag(dAtA, i, uint64(len(m.User)))
+		i += copy(dAtA[i:], m.User)
+	}
+	if len(m.EnvFile) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintShimdiag(dAtA, i, uint64(len(m.EnvFile)))
+		i += copy(dAtA[i:], m.EnvFile)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -656,82 +929,274 @@ func (m *PidResponse) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func encodeVarintShimdiag(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *QuiesceRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+	return dAtA[:n], nil
 }
-func (m *ExecProcessRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *QuiesceRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Args) > 0 {
-		for _, s := range m.Args {
+	if len(m.HostPaths) > 0 {
+		for _, s := range m.HostPaths {
+			dAtA[i] = 0xa
+			i++
 			l = len(s)
-			n += 1 + l + sovShimdiag(uint64(l))
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
 		}
 	}
-	l = len(m.Workdir)
-	if l > 0 {
-		n += 1 + l + sovShimdiag(uint64(l))
-	}
-	if m.Terminal {
-		n += 2
-	}
-	l = len(m.Stdin)
-	if l > 0 {
-		n += 1 + l + sovShimdiag(uint64(l))
-	}
-	l = len(m.Stdout)
-	if l > 0 {
-		n += 1 + l + sovShimdiag(uint64(l))
+	if m.TimeoutInSeconds != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintShimdiag(dAtA, i, uint64(m.TimeoutInSeconds))
 	}
-	l = len(m.Stderr)
-	if l > 0 {
-		n += 1 + l + sovShimdiag(uint64(l))
+	if m.Thaw {
+		dAtA[i] = 0x18
+		i++
+		if m.Thaw {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ExecProcessResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *QuiesceResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *QuiesceResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ExitCode != 0 {
-		n += 1 + sovShimdiag(uint64(m.ExitCode))
-	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *StacksRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ResizeDiskRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ResizeDiskRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
+	if len(m.HostPath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintShimdiag(dAtA, i, uint64(len(m.HostPath)))
+		i += copy(dAtA[i:], m.HostPath)
+	}
+	if m.SizeInBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintShimdiag(dAtA, i, uint64(m.SizeInBytes))
+	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *StacksResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ResizeDiskResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ResizeDiskResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *NetworkInterfacesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NetworkInterfacesRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *NetworkInterfacesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NetworkInterfacesResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.InterfaceNames) > 0 {
+		for k, _ := range m.InterfaceNames {
+			dAtA[i] = 0xa
+			i++
+			v := m.InterfaceNames[k]
+			mapSize := 1 + len(k) + sovShimdiag(uint64(len(k))) + 1 + len(v) + sovShimdiag(uint64(len(v)))
+			i = encodeVarintShimdiag(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintShimdiag(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintShimdiag(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func encodeVarintShimdiag(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *ExecProcessRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			l = len(s)
+			n += 1 + l + sovShimdiag(uint64(l))
+		}
+	}
+	l = len(m.Workdir)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	if m.Terminal {
+		n += 2
+	}
+	l = len(m.Stdin)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	l = len(m.Stdout)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	l = len(m.Stderr)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	l = len(m.EnvFile)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ExecProcessResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ExitCode != 0 {
+		n += 1 + sovShimdiag(uint64(m.ExitCode))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *StacksRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *StacksResponse) Size() (n int) {
+	if m == nil {
+		return 0
 	}
 	var l int
 	_ = l
@@ -811,6 +1276,105 @@ func (m *PidResponse) Size() (n int) {
 	return n
 }
 
+func (m *QuiesceRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.HostPaths) > 0 {
+		for _, s := range m.HostPaths {
+			l = len(s)
+			n += 1 + l + sovShimdiag(uint64(l))
+		}
+	}
+	if m.TimeoutInSeconds != 0 {
+		n += 1 + sovShimdiag(uint64(m.TimeoutInSeconds))
+	}
+	if m.Thaw {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *QuiesceResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ResizeDiskRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.HostPath)
+	if l > 0 {
+		n += 1 + l + sovShimdiag(uint64(l))
+	}
+	if m.SizeInBytes != 0 {
+		n += 1 + sovShimdiag(uint64(m.SizeInBytes))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ResizeDiskResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *NetworkInterfacesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *NetworkInterfacesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.InterfaceNames) > 0 {
+		for k, v := range m.InterfaceNames {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovShimdiag(uint64(len(k))) + 1 + len(v) + sovShimdiag(uint64(len(v)))
+			n += mapEntrySize + 1 + sovShimdiag(uint64(mapEntrySize))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovShimdiag(x uint64) (n int) {
 	for {
 		n++
@@ -835,6 +1399,8 @@ func (this *ExecProcessRequest) String() string {
 		`Stdin:` + fmt.Sprintf("%v", this.Stdin) + `,`,
 		`Stdout:` + fmt.Sprintf("%v", this.Stdout) + `,`,
 		`Stderr:` + fmt.Sprintf("%v", this.Stderr) + `,`,
+		`User:` + fmt.Sprintf("%v", this.User) + `,`,
+		`EnvFile:` + fmt.Sprintf("%v", this.EnvFile) + `,`,
 		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
 		`}`,
 	}, "")
@@ -917,28 +1483,107 @@ func (this *PidResponse) String() string {
 	}, "")
 	return s
 }
-func valueToStringShimdiag(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *QuiesceRequest) String() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	s := strings.Join([]string{`&QuiesceRequest{`,
+		`HostPaths:` + fmt.Sprintf("%v", this.HostPaths) + `,`,
+		`TimeoutInSeconds:` + fmt.Sprintf("%v", this.TimeoutInSeconds) + `,`,
+		`Thaw:` + fmt.Sprintf("%v", this.Thaw) + `,`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
 }
-
-type ShimDiagService interface {
-	DiagExecInHost(ctx context.Context, req *ExecProcessRequest) (*ExecProcessResponse, error)
-	DiagStacks(ctx context.Context, req *StacksRequest) (*StacksResponse, error)
-	DiagShare(ctx context.Context, req *ShareRequest) (*ShareResponse, error)
-	DiagPid(ctx context.Context, req *PidRequest) (*PidResponse, error)
+func (this *QuiesceResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QuiesceResponse{`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
 }
-
-func RegisterShimDiagService(srv *github_com_containerd_ttrpc.Server, svc ShimDiagService) {
-	srv.Register("containerd.runhcs.v1.diag.ShimDiag", map[string]github_com_containerd_ttrpc.Method{
-		"DiagExecInHost": func(ctx context.Context, unmarshal func(interface{}) error) (interface{}, error) {
-			var req ExecProcessRequest
-			if err := unmarshal(&req); err != nil {
-				return nil, err
+func (this *ResizeDiskRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ResizeDiskRequest{`,
+		`HostPath:` + fmt.Sprintf("%v", this.HostPath) + `,`,
+		`SizeInBytes:` + fmt.Sprintf("%v", this.SizeInBytes) + `,`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ResizeDiskResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ResizeDiskResponse{`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *NetworkInterfacesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&NetworkInterfacesRequest{`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *NetworkInterfacesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForInterfaceNames := make([]string, 0, len(this.InterfaceNames))
+	for k, _ := range this.InterfaceNames {
+		keysForInterfaceNames = append(keysForInterfaceNames, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForInterfaceNames)
+	mapStringForInterfaceNames := "map[string]string{"
+	for _, k := range keysForInterfaceNames {
+		mapStringForInterfaceNames += fmt.Sprintf("%v: %v,", k, this.InterfaceNames[k])
+	}
+	mapStringForInterfaceNames += "}"
+	s := strings.Join([]string{`&NetworkInterfacesResponse{`,
+		`InterfaceNames:` + mapStringForInterfaceNames + `,`,
+		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringShimdiag(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+
+type ShimDiagService interface {
+	DiagExecInHost(ctx context.Context, req *ExecProcessRequest) (*ExecProcessResponse, error)
+	DiagStacks(ctx context.Context, req *StacksRequest) (*StacksResponse, error)
+	DiagShare(ctx context.Context, req *ShareRequest) (*ShareResponse, error)
+	DiagPid(ctx context.Context, req *PidRequest) (*PidResponse, error)
+	DiagQuiesce(ctx context.Context, req *QuiesceRequest) (*QuiesceResponse, error)
+	DiagResizeDisk(ctx context.Context, req *ResizeDiskRequest) (*ResizeDiskResponse, error)
+	DiagNetworkInterfaces(ctx context.Context, req *NetworkInterfacesRequest) (*NetworkInterfacesResponse, error)
+}
+
+func RegisterShimDiagService(srv *github_com_containerd_ttrpc.Server, svc ShimDiagService) {
+	srv.Register("containerd.runhcs.v1.diag.ShimDiag", map[string]github_com_containerd_ttrpc.Method{
+		"DiagExecInHost": func(ctx context.Context, unmarshal func(interface{}) error) (interface{}, error) {
+			var req ExecProcessRequest
+			if err := unmarshal(&req); err != nil {
+				return nil, err
 			}
 			return svc.DiagExecInHost(ctx, &req)
 		},
@@ -963,6 +1608,27 @@ func RegisterShimDiagService(srv *github_com_containerd_ttrpc.Server, svc ShimDi
 			}
 			return svc.DiagPid(ctx, &req)
 		},
+		"DiagQuiesce": func(ctx context.Context, unmarshal func(interface{}) error) (interface{}, error) {
+			var req QuiesceRequest
+			if err := unmarshal(&req); err != nil {
+				return nil, err
+			}
+			return svc.DiagQuiesce(ctx, &req)
+		},
+		"DiagResizeDisk": func(ctx context.Context, unmarshal func(interface{}) error) (interface{}, error) {
+			var req ResizeDiskRequest
+			if err := unmarshal(&req); err != nil {
+				return nil, err
+			}
+			return svc.DiagResizeDisk(ctx, &req)
+		},
+		"DiagNetworkInterfaces": func(ctx context.Context, unmarshal func(interface{}) error) (interface{}, error) {
+			var req NetworkInterfacesRequest
+			if err := unmarshal(&req); err != nil {
+				return nil, err
+			}
+			return svc.DiagNetworkInterfaces(ctx, &req)
+		},
 	})
 }
 
@@ -1007,6 +1673,30 @@ func (c *shimDiagClient) DiagPid(ctx context.Context, req *PidRequest) (*PidResp
 	}
 	return &resp, nil
 }
+
+func (c *shimDiagClient) DiagQuiesce(ctx context.Context, req *QuiesceRequest) (*QuiesceResponse, error) {
+	var resp QuiesceResponse
+	if err := c.client.Call(ctx, "containerd.runhcs.v1.diag.ShimDiag", "DiagQuiesce", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *shimDiagClient) DiagResizeDisk(ctx context.Context, req *ResizeDiskRequest) (*ResizeDiskResponse, error) {
+	var resp ResizeDiskResponse
+	if err := c.client.Call(ctx, "containerd.runhcs.v1.diag.ShimDiag", "DiagResizeDisk", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *shimDiagClient) DiagNetworkInterfaces(ctx context.Context, req *NetworkInterfacesRequest) (*NetworkInterfacesResponse, error) {
+	var resp NetworkInterfacesResponse
+	if err := c.client.Call(ctx, "containerd.runhcs.v1.diag.ShimDiag", "DiagNetworkInterfaces", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
 func (m *ExecProcessRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -1211,11 +1901,512 @@ func (m *ExecProcessRequest) Unmarshal(dAtA []byte) error {
 			if postIndex < 0 {
 				return ErrInvalidLengthShimdiag
 			}
-			if postIndex > l {
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stderr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.User = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EnvFile", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EnvFile = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExecProcessResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExecProcessResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExecProcessResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExitCode", wireType)
+			}
+			m.ExitCode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExitCode |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StacksRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StacksRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StacksRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StacksResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StacksResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StacksResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stacks", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stacks = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GuestStacks", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GuestStacks = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShareRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShareRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShareRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HostPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.HostPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UvmPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UvmPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ReadOnly = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShareResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Stderr = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShareResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShareResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -1241,7 +2432,7 @@ func (m *ExecProcessRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExecProcessResponse) Unmarshal(dAtA []byte) error {
+func (m *PidRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1264,31 +2455,12 @@ func (m *ExecProcessResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExecProcessResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: PidRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExecProcessResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PidRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExitCode", wireType)
-			}
-			m.ExitCode = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowShimdiag
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ExitCode |= int32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -1314,7 +2486,7 @@ func (m *ExecProcessResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *StacksRequest) Unmarshal(dAtA []byte) error {
+func (m *PidResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1337,12 +2509,31 @@ func (m *StacksRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: StacksRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: PidResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: StacksRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PidResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Pid", wireType)
+			}
+			m.Pid = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Pid |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -1368,7 +2559,7 @@ func (m *StacksRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *StacksResponse) Unmarshal(dAtA []byte) error {
+func (m *QuiesceRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1391,15 +2582,15 @@ func (m *StacksResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: StacksResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: QuiesceRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: StacksResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: QuiesceRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Stacks", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field HostPaths", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1427,13 +2618,13 @@ func (m *StacksResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Stacks = string(dAtA[iNdEx:postIndex])
+			m.HostPaths = append(m.HostPaths, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GuestStacks", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeoutInSeconds", wireType)
 			}
-			var stringLen uint64
+			m.TimeoutInSeconds = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowShimdiag
@@ -1443,24 +2634,85 @@ func (m *StacksResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.TimeoutInSeconds |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Thaw", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowShimdiag
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Thaw = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipShimdiag(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthShimdiag
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthShimdiag
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.GuestStacks = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuiesceResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowShimdiag
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuiesceResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuiesceResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -1486,7 +2738,7 @@ func (m *StacksResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShareRequest) Unmarshal(dAtA []byte) error {
+func (m *ResizeDiskRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1509,10 +2761,10 @@ func (m *ShareRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShareRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ResizeDiskRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShareRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ResizeDiskRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1548,42 +2800,10 @@ func (m *ShareRequest) Unmarshal(dAtA []byte) error {
 			m.HostPath = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UvmPath", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowShimdiag
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthShimdiag
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthShimdiag
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.UvmPath = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadOnly", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
 			}
-			var v int
+			m.SizeInBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowShimdiag
@@ -1593,12 +2813,11 @@ func (m *ShareRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.SizeInBytes |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.ReadOnly = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -1624,7 +2843,7 @@ func (m *ShareRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShareResponse) Unmarshal(dAtA []byte) error {
+func (m *ResizeDiskResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1647,10 +2866,10 @@ func (m *ShareResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShareResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ResizeDiskResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShareResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ResizeDiskResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -1678,7 +2897,7 @@ func (m *ShareResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PidRequest) Unmarshal(dAtA []byte) error {
+func (m *NetworkInterfacesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1701,10 +2920,10 @@ func (m *PidRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PidRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: NetworkInterfacesRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PidRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: NetworkInterfacesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -1732,7 +2951,7 @@ func (m *PidRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PidResponse) Unmarshal(dAtA []byte) error {
+func (m *NetworkInterfacesResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1755,17 +2974,17 @@ func (m *PidResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PidResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: NetworkInterfacesResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PidResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: NetworkInterfacesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Pid", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InterfaceNames", wireType)
 			}
-			m.Pid = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowShimdiag
@@ -1775,11 +2994,122 @@ func (m *PidResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Pid |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthShimdiag
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.InterfaceNames == nil {
+				m.InterfaceNames = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowShimdiag
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowShimdiag
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowShimdiag
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipShimdiag(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					if (iNdEx + skippy) < 0 {
+						return ErrInvalidLengthShimdiag
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.InterfaceNames[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipShimdiag(dAtA[iNdEx:])
@@ -0,0 +1,56 @@
+package shimdiag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// Test_Descriptor checks the compiled-in descriptor against the generated
+// types and the ShimDiag service, so a message or RPC added to shimdiag.proto
+// without regenerating the descriptor is caught here.
+func Test_Descriptor(t *testing.T) {
+	var fd *descriptor.FileDescriptorProto
+	for _, msg := range []descriptor.Message{&ExecProcessRequest{}, &ExecProcessResponse{}, &StacksRequest{}, &StacksResponse{}, &ShareRequest{}, &ShareResponse{}, &PidRequest{}, &PidResponse{}, &QuiesceRequest{}, &QuiesceResponse{}, &ResizeDiskRequest{}, &ResizeDiskResponse{}} {
+		var md *descriptor.DescriptorProto
+		fd, md = descriptor.ForMessage(msg)
+		fields := make(map[int32]string)
+		for _, f := range md.Field {
+			fields[f.GetNumber()] = f.GetName()
+		}
+		props := proto.GetProperties(reflect.TypeOf(msg).Elem())
+		for _, p := range props.Prop {
+			if p.Tag == 0 {
+				continue
+			}
+			if name, ok := fields[int32(p.Tag)]; !ok || name != p.OrigName {
+				t.Errorf("%s: field %d %q is not in the descriptor (found %q)", md.GetName(), p.Tag, p.OrigName, name)
+			}
+			delete(fields, int32(p.Tag))
+		}
+		for num, name := range fields {
+			t.Errorf("%s: descriptor field %d %q has no Go field", md.GetName(), num, name)
+		}
+	}
+
+	if len(fd.Service) != 1 {
+		t.Fatalf("expected one service in the descriptor, found %d", len(fd.Service))
+	}
+	methods := make(map[string]bool)
+	for _, m := range fd.Service[0].Method {
+		methods[m.GetName()] = true
+	}
+	svc := reflect.TypeOf((*ShimDiagService)(nil)).Elem()
+	for i := 0; i < svc.NumMethod(); i++ {
+		name := svc.Method(i).Name
+		if !methods[name] {
+			t.Errorf("RPC %q is not in the descriptor", name)
+		}
+		delete(methods, name)
+	}
+	for name := range methods {
+		t.Errorf("descriptor RPC %q is not in ShimDiagService", name)
+	}
+}
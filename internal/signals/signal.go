@@ -102,9 +102,11 @@ func ValidateSigstrWCOW(sigstr string, signalsSupported bool) (*guestrequest.Sig
 
 		var signalString guestrequest.SignalValueWCOW
 		switch sigstr {
-		case "CTRLC":
+		case "CTRLC", "INT":
+			// SIGINT is what a terminal sends on Ctrl-C.
 			signalString = guestrequest.SignalValueWCOWCtrlC
-		case "CTRLBREAK":
+		case "CTRLBREAK", "QUIT":
+			// SIGQUIT is what a terminal sends on Ctrl-\.
 			signalString = guestrequest.SignalValueWCOWCtrlBreak
 		case "CTRLCLOSE":
 			signalString = guestrequest.SignalValueWCOWCtrlClose
@@ -161,6 +163,10 @@ func ValidateLCOW(signal int, signalsSupported bool) (*guestrequest.SignalProces
 // semantics which will be properly translated to CTRLSHUTDOWN and `Terminate`.
 // To detect when WCOW needs to `Terminate` the return signal will be `nil` and
 // the return error will be `nil`.
+//
+// SIGINT and SIGQUIT are translated to CTRLC and CTRLBREAK as they are by
+// name in ValidateSigstrWCOW, so the value 2 is SIGINT rather than CTRLCLOSE,
+// which can only be sent by name.
 func ValidateWCOW(signal int, signalsSupported bool) (*guestrequest.SignalProcessOptionsWCOW, error) {
 	if !signalsSupported {
 		// If signals arent supported we just validate that its a known signal.
@@ -179,12 +185,12 @@ func ValidateWCOW(signal int, signalsSupported bool) (*guestrequest.SignalProces
 
 		var signalString guestrequest.SignalValueWCOW
 		switch signal {
-		case ctrlC:
+		case ctrlC, sigInt:
+			// SIGINT is what a terminal sends on Ctrl-C.
 			signalString = guestrequest.SignalValueWCOWCtrlC
-		case ctrlBreak:
+		case ctrlBreak, sigQuit:
+			// SIGQUIT is what a terminal sends on Ctrl-\.
 			signalString = guestrequest.SignalValueWCOWCtrlBreak
-		case ctrlClose:
-			signalString = guestrequest.SignalValueWCOWCtrlClose
 		case ctrlLogOff:
 			signalString = guestrequest.SignalValueWCOWCtrlLogOff
 		case ctrlShutdown, sigTerm:
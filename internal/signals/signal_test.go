@@ -149,10 +149,18 @@ func Test_ValidateSigstr_WCOW_SignalsSupported(t *testing.T) {
 			"0",
 			guestrequest.SignalValueWCOWCtrlC,
 		},
+		{
+			"INT",
+			guestrequest.SignalValueWCOWCtrlC,
+		},
 		{
 			"CtrlBreak",
 			guestrequest.SignalValueWCOWCtrlBreak,
 		},
+		{
+			"QUIT",
+			guestrequest.SignalValueWCOWCtrlBreak,
+		},
 		{
 			"1",
 			guestrequest.SignalValueWCOWCtrlBreak,
@@ -163,7 +171,11 @@ func Test_ValidateSigstr_WCOW_SignalsSupported(t *testing.T) {
 		},
 		{
 			"2",
-			guestrequest.SignalValueWCOWCtrlClose,
+			guestrequest.SignalValueWCOWCtrlC,
+		},
+		{
+			"3",
+			guestrequest.SignalValueWCOWCtrlBreak,
 		},
 		{
 			"CtrlLogOff",
@@ -211,6 +223,9 @@ func Test_ValidateSigstr_WCOW_SignalsSupported(t *testing.T) {
 			if ret == nil {
 				t.Fatalf("expected non-nil ret for signal: %v", c.value)
 			}
+			if ret.Signal != c.result {
+				t.Fatalf("expected signal: %v, got: %v for signal: %v", c.result, ret.Signal, c.value)
+			}
 		}
 	}
 }
@@ -271,8 +286,12 @@ func Test_ValidateWCOW_SignalsSupported(t *testing.T) {
 			guestrequest.SignalValueWCOWCtrlBreak,
 		},
 		{
-			ctrlClose,
-			guestrequest.SignalValueWCOWCtrlClose,
+			sigInt,
+			guestrequest.SignalValueWCOWCtrlC,
+		},
+		{
+			sigQuit,
+			guestrequest.SignalValueWCOWCtrlBreak,
 		},
 		{
 			ctrlLogOff,
@@ -1,6 +1,8 @@
 package signals
 
 const (
+	sigInt  = 0x2
+	sigQuit = 0x3
 	sigKill = 0x9
 	sigTerm = 0xf
 )
@@ -42,10 +44,11 @@ var signalMapLcow = map[string]int{
 	"XFSZ":   0x19,
 }
 
+// The values of the Windows console control events. CTRL_CLOSE_EVENT (0x2) is
+// not accepted by value as it is the value of SIGINT, which is sent instead.
 const (
 	ctrlC        = 0x0
 	ctrlBreak    = 0x1
-	ctrlClose    = 0x2
 	ctrlLogOff   = 0x5
 	ctrlShutdown = 0x6
 )
@@ -0,0 +1,94 @@
+// +build windows
+
+// Package timezone builds the registry changes that set the time zone of a
+// Windows container, so that containers do not have to use the time zone of
+// the host.
+//
+// A container reads its time zone from the TimeZoneInformation key of its
+// system hive. The key is written with the definition of the time zone in the
+// host's time zone database.
+package timezone
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// timeZonesKey is the host's time zone database, with a subkey per time
+	// zone ID.
+	timeZonesKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Time Zones`
+	// timeZoneInformationKey is the key of the active time zone in the
+	// system hive.
+	timeZoneInformationKey = `CurrentControlSet\Control\TimeZoneInformation`
+)
+
+// tziSize is the size of the `TZI` value of a time zone, which is a
+// REG_TZI_FORMAT.
+const tziSize = 44
+
+// RegistryValues returns the values of the system hive of a container that
+// set its time zone to the Windows time zone ID `id`, such as
+// `Pacific Standard Time`. `id` must be a time zone known to the host.
+func RegistryValues(id string) ([]hcsschema.RegistryValue, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, timeZonesKey+`\`+id, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s': %s", id, err)
+	}
+	defer k.Close()
+
+	tzi, _, err := k.GetBinaryValue("TZI")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time zone '%s': %s", id, err)
+	}
+	if len(tzi) != tziSize {
+		return nil, fmt.Errorf("time zone '%s' has an invalid TZI of %d bytes", id, len(tzi))
+	}
+	standardName, err := displayName(k, "MUI_Std", "Std")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time zone '%s': %s", id, err)
+	}
+	daylightName, err := displayName(k, "MUI_Dlt", "Dlt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time zone '%s': %s", id, err)
+	}
+
+	key := &hcsschema.RegistryKey{
+		Hive: "System",
+		Name: timeZoneInformationKey,
+	}
+	dword := func(name string, v int32) hcsschema.RegistryValue {
+		return hcsschema.RegistryValue{Key: key, Name: name, Type_: "DWord", DWordValue: v}
+	}
+	str := func(name, v string) hcsschema.RegistryValue {
+		return hcsschema.RegistryValue{Key: key, Name: name, Type_: "String", StringValue: v}
+	}
+	bin := func(name string, v []byte) hcsschema.RegistryValue {
+		return hcsschema.RegistryValue{Key: key, Name: name, Type_: "Binary", BinaryValue: base64.StdEncoding.EncodeToString(v)}
+	}
+	return []hcsschema.RegistryValue{
+		str("TimeZoneKeyName", id),
+		dword("Bias", int32(binary.LittleEndian.Uint32(tzi[0:4]))),
+		dword("StandardBias", int32(binary.LittleEndian.Uint32(tzi[4:8]))),
+		dword("DaylightBias", int32(binary.LittleEndian.Uint32(tzi[8:12]))),
+		bin("StandardStart", tzi[12:28]),
+		bin("DaylightStart", tzi[28:44]),
+		str("StandardName", standardName),
+		str("DaylightName", daylightName),
+		dword("DynamicDaylightTimeDisabled", 0),
+	}, nil
+}
+
+// displayName returns the string `mui`, which refers to a localized resource,
+// or `name` if the time zone has no `mui`.
+func displayName(k registry.Key, mui, name string) (string, error) {
+	v, _, err := k.GetStringValue(mui)
+	if err == registry.ErrNotExist {
+		v, _, err = k.GetStringValue(name)
+	}
+	return v, err
+}
@@ -0,0 +1,70 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+// lcowBinfmtMountPath is the path in the LCOW UVM where the binfmt extension
+// is mounted.
+const lcowBinfmtMountPath = "/run/binfmt"
+
+// emulatedArchitectures maps the architectures that can be emulated, as named
+// by OCI image platforms, to the qemu-user names of their interpreters.
+var emulatedArchitectures = map[string]string{
+	"arm":     "arm",
+	"arm64":   "aarch64",
+	"ppc64le": "ppc64le",
+	"riscv64": "riscv64",
+	"s390x":   "s390x",
+}
+
+func verifyEmulatedArchitectures(archs []string) error {
+	for _, a := range archs {
+		if _, ok := emulatedArchitectures[a]; !ok {
+			return fmt.Errorf("architecture %q can not be emulated", a)
+		}
+	}
+	return nil
+}
+
+// configureBinfmt mounts the binfmt extension and has the guest register its
+// qemu-user interpreters for the emulated architectures, if there are any.
+//
+// The extension is a read-only ext4 VHD that holds a statically linked
+// `qemu-<name>-static` interpreter for each architecture at its root. It is
+// installed with the boot files rather than provided by the pod, so only the
+// interpreters that the host administrator approved run in the guest.
+func (uvm *UtilityVM) configureBinfmt(ctx context.Context) error {
+	if len(uvm.emulatedArchitectures) == 0 {
+		return nil
+	}
+	// The extension is hot added rather than in the VM document, so HCS
+	// does not grant the VM access to it.
+	if _, err := uvm.AddSCSI(ctx, uvm.binfmtFile, lcowBinfmtMountPath, true, VMAccessTypeIndividual); err != nil {
+		return fmt.Errorf("failed to add binfmt extension: %s", err)
+	}
+	var settings guestrequest.LCOWBinfmt
+	for _, a := range uvm.emulatedArchitectures {
+		settings.Interpreters = append(settings.Interpreters, guestrequest.LCOWBinfmtInterpreter{
+			Architecture: emulatedArchitectures[a],
+			Path:         path.Join(lcowBinfmtMountPath, "qemu-"+emulatedArchitectures[a]+"-static"),
+		})
+	}
+	request := &hcsschema.ModifySettingRequest{
+		GuestRequest: guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypeBinfmt,
+			RequestType:  requesttype.Add,
+			Settings:     settings,
+		},
+	}
+	if err := uvm.modify(ctx, request); err != nil {
+		return fmt.Errorf("failed to register binfmt interpreters: %s", err)
+	}
+	return nil
+}
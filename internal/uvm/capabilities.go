@@ -17,6 +17,25 @@ func (uvm *UtilityVM) DeleteContainerStateSupported() bool {
 	return uvm.guestCaps.DeleteContainerStateSupported
 }
 
+// ErofsLayersSupported returns `true` if the guest can mount EROFS layer
+// images and combine them with an overlay, instead of mounting each layer as
+// ext4.
+func (uvm *UtilityVM) ErofsLayersSupported() bool {
+	if uvm.gc == nil {
+		return false
+	}
+	return uvm.guestCaps.ErofsLayersSupported
+}
+
+// CoreSchedulingSupported returns `true` if the guest can give the processes
+// of a container a Linux core scheduling cookie.
+func (uvm *UtilityVM) CoreSchedulingSupported() bool {
+	if uvm.gc == nil {
+		return false
+	}
+	return uvm.guestCaps.CoreSchedulingSupported
+}
+
 // Capabilities returns the protocol version and the guest defined capabilities.
 // This should only be used for testing.
 func (uvm *UtilityVM) Capabilities() (uint32, schema1.GuestDefinedCapabilities) {
@@ -2,6 +2,8 @@ package uvm
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
@@ -29,6 +31,21 @@ func (uvm *UtilityVM) CombineLayersWCOW(ctx context.Context, layerPaths []hcssch
 	return uvm.modify(ctx, msr)
 }
 
+// CombineLayersOptions are the optional settings for combining LCOW layers.
+type CombineLayersOptions struct {
+	// Erofs is set if the layers are EROFS images rather than ext4. The guest
+	// must support EROFS layers, see ErofsLayersSupported.
+	Erofs bool
+	// ScratchQuotaInBytes limits the size of the writable layer on the scratch
+	// filesystem, which may be shared with other containers. 0 is unlimited.
+	ScratchQuotaInBytes uint64
+	// TmpfsScratchSizeInBytes, if non-zero, backs the writable layer with a
+	// tmpfs of this size mounted by the guest at the scratch path, rather than
+	// the filesystem already mounted there. It can not be combined with
+	// ScratchQuotaInBytes as the size of the tmpfs is the limit.
+	TmpfsScratchSizeInBytes uint64
+}
+
 // CombineLayersLCOW combines `layerPaths` and optionally `scratchPath` into an
 // overlay filesystem at `rootfsPath`. If `scratchPath` is empty the overlay
 // will be read only.
@@ -36,23 +53,56 @@ func (uvm *UtilityVM) CombineLayersWCOW(ctx context.Context, layerPaths []hcssch
 // NOTE: `layerPaths`, `scrathPath`, and `rootfsPath` are paths from within the
 // UVM.
 func (uvm *UtilityVM) CombineLayersLCOW(ctx context.Context, layerPaths []string, scratchPath, rootfsPath string) error {
+	return uvm.CombineLayersLCOWWithOptions(ctx, layerPaths, scratchPath, rootfsPath, nil)
+}
+
+// CombineLayersLCOWWithOptions is CombineLayersLCOW with the settings in
+// `opts`, which may be nil.
+func (uvm *UtilityVM) CombineLayersLCOWWithOptions(ctx context.Context, layerPaths []string, scratchPath, rootfsPath string, opts *CombineLayersOptions) error {
 	if uvm.operatingSystem != "linux" {
 		return errNotSupported
 	}
-
-	layers := []hcsschema.Layer{}
+	if opts == nil {
+		opts = &CombineLayersOptions{}
+	}
+	settings := guestrequest.CombinedLayers{
+		ContainerRootPath:       rootfsPath,
+		ScratchPath:             scratchPath,
+		ScratchQuotaInBytes:     opts.ScratchQuotaInBytes,
+		ScratchTmpfsSizeInBytes: opts.TmpfsScratchSizeInBytes,
+	}
+	if opts.Erofs {
+		if !uvm.ErofsLayersSupported() {
+			return errNotSupported
+		}
+		settings.LayerFilesystem = guestrequest.LayerFilesystemErofs
+	}
+	if settings.ScratchQuotaInBytes != 0 && scratchPath == "" {
+		return errors.New("a scratch quota requires a scratch path")
+	}
+	if settings.ScratchTmpfsSizeInBytes != 0 {
+		if scratchPath == "" {
+			return errors.New("a tmpfs scratch requires a scratch path")
+		}
+		if settings.ScratchQuotaInBytes != 0 {
+			return errors.New("a scratch quota can not be combined with a tmpfs scratch")
+		}
+		memory, err := uvm.GetAssignedMemoryInBytes(ctx)
+		if err != nil {
+			return err
+		}
+		if err := verifyTmpfsScratchSize(settings.ScratchTmpfsSizeInBytes, memory); err != nil {
+			return err
+		}
+	}
 	for _, l := range layerPaths {
-		layers = append(layers, hcsschema.Layer{Path: l})
+		settings.Layers = append(settings.Layers, hcsschema.Layer{Path: l})
 	}
 	msr := &hcsschema.ModifySettingRequest{
 		GuestRequest: guestrequest.GuestRequest{
 			ResourceType: guestrequest.ResourceTypeCombinedLayers,
 			RequestType:  requesttype.Add,
-			Settings: guestrequest.CombinedLayers{
-				ContainerRootPath: rootfsPath,
-				Layers:            layers,
-				ScratchPath:       scratchPath,
-			},
+			Settings:     settings,
 		},
 	}
 	return uvm.modify(ctx, msr)
@@ -73,3 +123,16 @@ func (uvm *UtilityVM) RemoveCombinedLayers(ctx context.Context, rootfsPath strin
 	}
 	return uvm.modify(ctx, msr)
 }
+
+// verifyTmpfsScratchSize verifies that a tmpfs scratch of `size` bytes holds at
+// least a page and fits in the `memory` bytes of the utility VM, as the tmpfs
+// is backed by the memory of the guest.
+func verifyTmpfsScratchSize(size, memory uint64) error {
+	if size < bytesPerPage {
+		return fmt.Errorf("tmpfs scratch of %d bytes is smaller than a page", size)
+	}
+	if size >= memory {
+		return fmt.Errorf("tmpfs scratch of %d bytes does not fit in the %d bytes of memory of the utility VM", size, memory)
+	}
+	return nil
+}
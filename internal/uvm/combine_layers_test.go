@@ -0,0 +1,17 @@
+package uvm
+
+import "testing"
+
+func Test_VerifyTmpfsScratchSize(t *testing.T) {
+	const memory = 1024 * bytesPerMB
+	for _, size := range []uint64{bytesPerPage, 512 * bytesPerMB} {
+		if err := verifyTmpfsScratchSize(size, memory); err != nil {
+			t.Fatalf("size %d should not have failed with error: %s", size, err)
+		}
+	}
+	for _, size := range []uint64{1, bytesPerPage - 1, memory, 2 * memory} {
+		if err := verifyTmpfsScratchSize(size, memory); err == nil {
+			t.Fatalf("size %d should have failed", size)
+		}
+	}
+}
@@ -67,7 +67,7 @@ func (ca *computeAgent) DeleteNIC(ctx context.Context, req *computeagent.DeleteN
 
 func setupAndServe(ctx context.Context, caAddr string, vm *UtilityVM) error {
 	// Setup compute agent service
-	l, err := winio.ListenPipe(caAddr, nil)
+	l, err := winio.ListenPipe(caAddr, &winio.PipeConfig{SecurityDescriptor: vm.computeAgentSecurityDescriptor})
 	if err != nil {
 		return errors.Wrapf(err, "failed to listen on %s", caAddr)
 	}
@@ -0,0 +1,263 @@
+package uvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	winio "github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+)
+
+const (
+	// consoleBufferSize is the amount of the most recent console output that
+	// is kept for readers, so that a reader opened after a kernel panic still
+	// sees it and a slow reader does not stall the console of the guest.
+	consoleBufferSize = 64 * 1024
+	// consoleConnectTimeout is how long to wait for the console pipe of a
+	// starting utility VM to be created.
+	consoleConnectTimeout = 10 * time.Second
+)
+
+// ErrConsoleNotCaptured is returned by ConsoleReader for a utility VM whose
+// serial console is not captured.
+var ErrConsoleNotCaptured = errors.New("the serial console of the utility VM is not captured")
+
+// consoleCapture captures the output of the serial console of a utility VM
+// from the named pipe backing its COM port, writing it to the console log file
+// and to the readers returned by ConsoleReader.
+type consoleCapture struct {
+	pipePath string
+	logFile  string
+
+	m       sync.Mutex
+	conn    net.Conn
+	file    *os.File
+	history []byte
+	readers map[*consoleReader]struct{}
+	closed  bool
+}
+
+func newConsoleCapture(pipePath, logFile string) *consoleCapture {
+	return &consoleCapture{
+		pipePath: pipePath,
+		logFile:  logFile,
+		readers:  make(map[*consoleReader]struct{}),
+	}
+}
+
+// start connects to the console pipe of the started utility VM and copies its
+// output until the pipe is closed.
+func (c *consoleCapture) start(ctx context.Context) (err error) {
+	var conn net.Conn
+	deadline := time.Now().Add(consoleConnectTimeout)
+	for {
+		timeout := time.Until(deadline)
+		conn, err = winio.DialPipe(c.pipePath, &timeout)
+		if err == nil || !os.IsNotExist(err) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+
+	var file *os.File
+	if c.logFile != "" {
+		if err := os.MkdirAll(filepath.Dir(c.logFile), 0700); err != nil {
+			conn.Close()
+			return err
+		}
+		file, err = os.OpenFile(c.logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	c.m.Lock()
+	if c.closed {
+		c.m.Unlock()
+		conn.Close()
+		if file != nil {
+			file.Close()
+		}
+		return nil
+	}
+	c.conn = conn
+	c.file = file
+	c.m.Unlock()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				c.write(ctx, buf[:n])
+			}
+			if err != nil {
+				c.close()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *consoleCapture) write(ctx context.Context, p []byte) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil {
+			log.G(ctx).WithError(err).WithField("path", c.logFile).Warning("failed to write console log, closing it")
+			c.file.Close()
+			c.file = nil
+		}
+	}
+	c.history = append(c.history, p...)
+	if over := len(c.history) - consoleBufferSize; over > 0 {
+		c.history = append(c.history[:0], c.history[over:]...)
+	}
+	for r := range c.readers {
+		r.write(p)
+	}
+}
+
+// close disconnects from the console pipe and ends the readers once they have
+// read the output buffered for them.
+func (c *consoleCapture) close() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+	for r := range c.readers {
+		r.end()
+	}
+	c.readers = nil
+}
+
+func (c *consoleCapture) newReader() *consoleReader {
+	r := &consoleReader{c: c, done: make(chan struct{})}
+	r.cond = sync.NewCond(&r.m)
+	c.m.Lock()
+	defer c.m.Unlock()
+	r.buf.Write(c.history)
+	if c.closed {
+		r.end()
+	} else {
+		c.readers[r] = struct{}{}
+	}
+	return r
+}
+
+// consoleReader is a reader of the console output of a utility VM. It buffers
+// up to `consoleBufferSize` bytes of the output not yet read, dropping the
+// oldest output when it falls behind.
+type consoleReader struct {
+	c    *consoleCapture
+	m    sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	// ended is set once no more output is written to the reader.
+	ended bool
+	once  sync.Once
+	done  chan struct{}
+}
+
+func (r *consoleReader) write(p []byte) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.buf.Write(p)
+	if over := r.buf.Len() - consoleBufferSize; over > 0 {
+		r.buf.Next(over)
+	}
+	r.cond.Broadcast()
+}
+
+func (r *consoleReader) end() {
+	r.once.Do(func() {
+		r.m.Lock()
+		r.ended = true
+		r.cond.Broadcast()
+		r.m.Unlock()
+		close(r.done)
+	})
+}
+
+// Read reads the console output, blocking until there is output. Returns
+// io.EOF once the console is disconnected and the buffered output is read, or
+// the reader is closed.
+func (r *consoleReader) Read(p []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for r.buf.Len() == 0 && !r.ended {
+		r.cond.Wait()
+	}
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
+
+// Close stops the reader, discarding the output not yet read.
+func (r *consoleReader) Close() error {
+	r.c.m.Lock()
+	delete(r.c.readers, r)
+	r.c.m.Unlock()
+	r.m.Lock()
+	r.buf.Reset()
+	r.m.Unlock()
+	r.end()
+	return nil
+}
+
+// ConsoleReader returns a reader of the serial console output of the utility
+// VM, starting with up to the last 64KB of output before the call. The reader
+// returns io.EOF once the utility VM stops, and is closed when `ctx` is done.
+//
+// This is only supported for LCOW utility VMs created with `CaptureConsole`
+// or `ConsoleLogFile`. Returns ErrConsoleNotCaptured otherwise.
+func (uvm *UtilityVM) ConsoleReader(ctx context.Context) (io.ReadCloser, error) {
+	if uvm.console == nil {
+		return nil, ErrConsoleNotCaptured
+	}
+	r := uvm.console.newReader()
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-r.done:
+		}
+	}()
+	return r, nil
+}
+
+// startConsoleCapture starts capturing the serial console of the started
+// utility VM, if enabled. The console is diagnostics only, so a failure to
+// capture it is logged rather than failing the start.
+func (uvm *UtilityVM) startConsoleCapture(ctx context.Context) {
+	if uvm.console == nil {
+		return
+	}
+	if err := uvm.console.start(ctx); err != nil {
+		log.G(ctx).WithError(err).WithField(logfields.UVMID, uvm.id).Warning("failed to capture the serial console")
+		uvm.console.close()
+	}
+}
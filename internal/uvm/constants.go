@@ -9,6 +9,10 @@ const (
 	// utility VM
 	MaxVPMEMCount = 128
 
+	// MaxSCSIControllers is the maximum number of SCSI controllers that may be
+	// added to a utility VM
+	MaxSCSIControllers = 4
+
 	// DefaultVPMEMCount is the default number of VPMem devices that may be added to an LCOW
 	// utility VM if the create request doesn't specify how many.
 	DefaultVPMEMCount = 64
@@ -25,6 +29,9 @@ const (
 	// LCOWNvidiaMountPath is the path format in LCOW UVM where nvidia tools are mounted
 	// keep this value in sync with opengcs
 	LCOWNvidiaMountPath = "/run/nvidia"
+	// LCOWVolumePathFmt is the path format in the LCOW UVM where pod volumes
+	// are mounted
+	LCOWVolumePathFmt = "/run/volumes/%s"
 	// WCOWGlobalMountPrefix is the path prefix format in the WCOW UVM where mounts are added
 	WCOWGlobalMountPrefix = "C:\\mounts\\m%d"
 	// RootfsPath is part of the container's rootfs path
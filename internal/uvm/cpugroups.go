@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/cpugroup"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 )
@@ -53,3 +55,69 @@ func (uvm *UtilityVM) setCPUGroup(ctx context.Context, id string) error {
 func (uvm *UtilityVM) unsetCPUGroup(ctx context.Context) error {
 	return uvm.setCPUGroup(ctx, cpugroup.NullGroupID)
 }
+
+// verifyProcessorAffinityOptions checks that processor affinity is not
+// combined with an existing cpugroup.
+func verifyProcessorAffinityOptions(opts *Options) error {
+	if (len(opts.ProcessorAffinity) != 0 || len(opts.ProcessorAffinityNUMANodes) != 0) && opts.CPUGroupID != "" {
+		return errors.New("ProcessorAffinity cannot be used with CPUGroupID")
+	}
+	return nil
+}
+
+// affinityLogicalProcessors returns the sorted, de-duplicated set of host
+// logical processors made up of `lps` and every logical processor on the NUMA
+// nodes `nodes`. It is an error to name a logical processor or NUMA node that
+// is not in `topology`.
+func affinityLogicalProcessors(topology *hcsschema.ProcessorTopology, lps []uint32, nodes []uint8) ([]uint32, error) {
+	known := make(map[uint32]bool)
+	nodeLPs := make(map[uint8][]uint32)
+	for _, lp := range topology.LogicalProcessors {
+		known[lp.LpIndex] = true
+		nodeLPs[lp.NodeNumber] = append(nodeLPs[lp.NodeNumber], lp.LpIndex)
+	}
+	set := make(map[uint32]bool)
+	for _, lp := range lps {
+		if !known[lp] {
+			return nil, fmt.Errorf("logical processor %d does not exist on the host", lp)
+		}
+		set[lp] = true
+	}
+	for _, node := range nodes {
+		if _, ok := nodeLPs[node]; !ok {
+			return nil, fmt.Errorf("NUMA node %d does not exist on the host", node)
+		}
+		for _, lp := range nodeLPs[node] {
+			set[lp] = true
+		}
+	}
+	result := make([]uint32, 0, len(set))
+	for lp := range set {
+		result = append(result, lp)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+// configureProcessorAffinity creates a cpugroup owned by the UVM containing
+// the logical processors requested in `opts`, which the UVM is then added to
+// on start. Does nothing if no affinity is requested.
+func (uvm *UtilityVM) configureProcessorAffinity(ctx context.Context, opts *Options, topology *hcsschema.ProcessorTopology) error {
+	if len(opts.ProcessorAffinity) == 0 && len(opts.ProcessorAffinityNUMANodes) == 0 {
+		return nil
+	}
+	lps, err := affinityLogicalProcessors(topology, opts.ProcessorAffinity, opts.ProcessorAffinityNUMANodes)
+	if err != nil {
+		return fmt.Errorf("invalid processor affinity: %s", err)
+	}
+	id, err := guid.NewV4()
+	if err != nil {
+		return err
+	}
+	if err := cpugroup.Create(ctx, id.String(), lps); err != nil {
+		return err
+	}
+	uvm.cpuGroupID = id.String()
+	uvm.affinityCPUGroupID = uvm.cpuGroupID
+	return nil
+}
@@ -0,0 +1,43 @@
+package uvm
+
+import (
+	"reflect"
+	"testing"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+func Test_AffinityLogicalProcessors(t *testing.T) {
+	topology := &hcsschema.ProcessorTopology{
+		LogicalProcessorCount: 4,
+		LogicalProcessors: []hcsschema.LogicalProcessor{
+			{LpIndex: 0, NodeNumber: 0},
+			{LpIndex: 1, NodeNumber: 0},
+			{LpIndex: 2, NodeNumber: 1},
+			{LpIndex: 3, NodeNumber: 1},
+		},
+	}
+
+	lps, err := affinityLogicalProcessors(topology, []uint32{3, 0, 3}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(lps, []uint32{0, 3}) {
+		t.Fatalf("expected [0 3], got %v", lps)
+	}
+
+	lps, err = affinityLogicalProcessors(topology, []uint32{0}, []uint8{1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(lps, []uint32{0, 2, 3}) {
+		t.Fatalf("expected [0 2 3], got %v", lps)
+	}
+
+	if _, err := affinityLogicalProcessors(topology, []uint32{4}, nil); err == nil {
+		t.Fatal("expected error for unknown logical processor")
+	}
+	if _, err := affinityLogicalProcessors(topology, nil, []uint8{2}); err == nil {
+		t.Fatal("expected error for unknown NUMA node")
+	}
+}
@@ -2,8 +2,26 @@ package uvm
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	// maxProcessorLimit is the value of `ProcessorLimit` and
+	// `ProcessorReservation` that means 100% of a vCPU.
+	maxProcessorLimit = 100000
+	// maxProcessorWeight is the highest value of `ProcessorWeight`.
+	maxProcessorWeight = 10000
+	// minCFSQuota is the smallest CFS quota in microseconds accepted by the
+	// Linux kernel.
+	minCFSQuota = 1000
 )
 
 // UpdateCPULimits updates the CPU limits of the utility vm
@@ -15,3 +33,181 @@ func (uvm *UtilityVM) UpdateCPULimits(ctx context.Context, limits *hcsschema.Pro
 
 	return uvm.modify(ctx, req)
 }
+
+// ContainerCPUQuota is the CFS quota of a container running in the UVM as
+// last set by the caller. `Quota` of `0` or less means the container is not
+// limited and it is left alone.
+type ContainerCPUQuota struct {
+	Container cow.Container
+	Quota     int64
+	Period    uint64
+}
+
+// rescaleCFSQuota returns `quota` scaled by the change of the UVM processor
+// limit from `oldLimit` to `newLimit`.
+func rescaleCFSQuota(quota int64, oldLimit, newLimit int32) int64 {
+	if quota <= 0 || oldLimit == newLimit {
+		return quota
+	}
+	if oldLimit == 0 {
+		oldLimit = maxProcessorLimit
+	}
+	if newLimit == 0 {
+		newLimit = maxProcessorLimit
+	}
+	q := quota * int64(newLimit) / int64(oldLimit)
+	if q < minCFSQuota {
+		q = minCFSQuota
+	}
+	return q
+}
+
+func updateContainerCPUQuota(ctx context.Context, c cow.Container, quota int64, period uint64) error {
+	cpu := &specs.LinuxCPU{Quota: &quota}
+	if period != 0 {
+		cpu.Period = &period
+	}
+	req := guestrequest.GuestRequest{
+		ResourceType: guestrequest.ResourceTypeContainerConstraints,
+		RequestType:  requesttype.Update,
+		Settings: guestrequest.LCOWContainerConstraints{
+			Linux: specs.LinuxResources{CPU: cpu},
+		},
+	}
+	return c.Modify(ctx, req)
+}
+
+// UpdateProcessorLimit changes the processor limit of the UVM to `limit` and
+// proportionally rescales the CFS quota of each of `containers` so that no
+// container is left with a quota exceeding the UVM's new capacity. On success
+// the `Quota` of each entry is updated to the value now in effect. Containers
+// can only be rescaled in an LCOW UVM.
+//
+// When scaling down the containers are updated before the UVM, and when
+// scaling up after it, so the guest is never over its quota. If any step
+// fails the changes already made are reverted.
+func (uvm *UtilityVM) UpdateProcessorLimit(ctx context.Context, limit int32, containers []*ContainerCPUQuota) (err error) {
+	if uvm.operatingSystem != "linux" && len(containers) != 0 {
+		return errNotSupported
+	}
+	if limit < 0 || limit > maxProcessorLimit {
+		return fmt.Errorf("processor limit must be between 0 and %d", maxProcessorLimit)
+	}
+
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	oldLimit := uvm.processorLimit
+	effective := func(l int32) int32 {
+		if l == 0 {
+			return maxProcessorLimit
+		}
+		return l
+	}
+	scaleDown := effective(limit) < effective(oldLimit)
+
+	var updated []*ContainerCPUQuota
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, c := range updated {
+			if rerr := updateContainerCPUQuota(ctx, c.Container, c.Quota, c.Period); rerr != nil {
+				log.G(ctx).WithField(logfields.UVMID, uvm.id).WithError(rerr).Warning("failed to restore container CPU quota")
+			}
+		}
+	}()
+	rescaleContainers := func() error {
+		for _, c := range containers {
+			q := rescaleCFSQuota(c.Quota, oldLimit, limit)
+			if q == c.Quota {
+				continue
+			}
+			if err := updateContainerCPUQuota(ctx, c.Container, q, c.Period); err != nil {
+				return fmt.Errorf("failed to update container CPU quota: %s", err)
+			}
+			updated = append(updated, c)
+		}
+		return nil
+	}
+
+	if scaleDown {
+		if err := rescaleContainers(); err != nil {
+			return err
+		}
+	}
+	limits := &hcsschema.ProcessorLimits{Limit: uint64(effective(limit))}
+	if uvm.schedulerHintsApplied {
+		limits.Weight = uvm.schedulerHints.Weight
+		limits.Reservation = uvm.schedulerHints.Reservation
+	}
+	if err := uvm.UpdateCPULimits(ctx, limits); err != nil {
+		return err
+	}
+	if !scaleDown {
+		if err := rescaleContainers(); err != nil {
+			limits.Limit = uint64(effective(oldLimit))
+			if rerr := uvm.UpdateCPULimits(ctx, limits); rerr != nil {
+				log.G(ctx).WithField(logfields.UVMID, uvm.id).WithError(rerr).Warning("failed to restore UVM processor limit")
+			}
+			return err
+		}
+	}
+
+	uvm.processorLimit = limit
+	for _, c := range updated {
+		c.Quota = rescaleCFSQuota(c.Quota, oldLimit, limit)
+	}
+	return nil
+}
+
+// UpdateProcessorWeight changes the relative weight of the vCPUs of the UVM
+// to `weight`, leaving its processor limit and reservation unchanged.
+func (uvm *UtilityVM) UpdateProcessorWeight(ctx context.Context, weight int32) error {
+	if weight <= 0 || weight > maxProcessorWeight {
+		return fmt.Errorf("processor weight must be between 1 and %d", maxProcessorWeight)
+	}
+	return uvm.UpdateCPULimits(ctx, &hcsschema.ProcessorLimits{Weight: uint64(weight)})
+}
+
+// schedulerHintLimits returns the processor limits that apply the scheduler
+// hints requested in `opts`, or nil if none were requested.
+func schedulerHintLimits(opts *Options) *hcsschema.ProcessorLimits {
+	if opts.ProcessorReservation == 0 && !opts.LatencySensitive {
+		return nil
+	}
+	limits := &hcsschema.ProcessorLimits{
+		Limit:       uint64(opts.ProcessorLimit),
+		Weight:      uint64(opts.ProcessorWeight),
+		Reservation: uint64(opts.ProcessorReservation),
+	}
+	if opts.LatencySensitive {
+		if limits.Reservation == 0 {
+			limits.Reservation = maxProcessorLimit
+		}
+		if limits.Weight == 0 {
+			limits.Weight = maxProcessorWeight
+		}
+	}
+	return limits
+}
+
+// applySchedulerHints applies the scheduler hints requested at create to the
+// running UVM. Hosts that don't support them are detected by the update
+// failing, in which case the UVM continues without them.
+func (uvm *UtilityVM) applySchedulerHints(ctx context.Context) {
+	if uvm.schedulerHints == nil {
+		return
+	}
+	if err := uvm.UpdateCPULimits(ctx, uvm.schedulerHints); err != nil {
+		log.G(ctx).WithField(logfields.UVMID, uvm.id).WithError(err).Warning("host does not support UVM scheduler hints, continuing without them")
+		return
+	}
+	uvm.schedulerHintsApplied = true
+}
+
+// SchedulerHintsApplied returns if the scheduler hints requested with
+// `ProcessorReservation` or `LatencySensitive` are in effect.
+func (uvm *UtilityVM) SchedulerHintsApplied() bool {
+	return uvm.schedulerHintsApplied
+}
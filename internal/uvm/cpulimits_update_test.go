@@ -0,0 +1,44 @@
+package uvm
+
+import (
+	"testing"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+func Test_SchedulerHintLimits(t *testing.T) {
+	if l := schedulerHintLimits(&Options{ProcessorLimit: 50000}); l != nil {
+		t.Fatalf("expected no limits without hints, got %+v", l)
+	}
+
+	l := schedulerHintLimits(&Options{LatencySensitive: true, ProcessorLimit: 50000})
+	expected := hcsschema.ProcessorLimits{Limit: 50000, Weight: maxProcessorWeight, Reservation: maxProcessorLimit}
+	if l == nil || *l != expected {
+		t.Fatalf("expected %+v, got %+v", expected, l)
+	}
+
+	l = schedulerHintLimits(&Options{LatencySensitive: true, ProcessorReservation: 20000, ProcessorWeight: 500})
+	expected = hcsschema.ProcessorLimits{Weight: 500, Reservation: 20000}
+	if l == nil || *l != expected {
+		t.Fatalf("expected explicit values to be kept %+v, got %+v", expected, l)
+	}
+}
+
+func Test_RescaleCFSQuota(t *testing.T) {
+	tests := []struct {
+		quota              int64
+		oldLimit, newLimit int32
+		expected           int64
+	}{
+		{quota: 200000, oldLimit: 0, newLimit: 50000, expected: 100000},
+		{quota: 100000, oldLimit: 50000, newLimit: 0, expected: 200000},
+		{quota: 100000, oldLimit: 50000, newLimit: 50000, expected: 100000},
+		{quota: -1, oldLimit: 100000, newLimit: 10000, expected: -1},
+		{quota: 5000, oldLimit: 100000, newLimit: 1000, expected: minCFSQuota},
+	}
+	for _, tt := range tests {
+		if q := rescaleCFSQuota(tt.quota, tt.oldLimit, tt.newLimit); q != tt.expected {
+			t.Errorf("rescaleCFSQuota(%d, %d, %d) = %d, expected %d", tt.quota, tt.oldLimit, tt.newLimit, q, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/osversion"
+)
+
+const (
+	// crashDumpSuffixWCOW and crashDumpSuffixLCOW are appended to the ID of
+	// the utility VM to get the name of the dump that the host writes when
+	// the guest crashes: the memory dump of the bugcheck for WCOW, and the
+	// saved state of the VM, with the memory of the guest, for LCOW.
+	crashDumpSuffixWCOW = ".dmp"
+	crashDumpSuffixLCOW = ".vmrs"
+	// gcsFailureDumpSuffix is appended to the ID of the utility VM to get the
+	// name of the saved state that is written when the GCS connection fails.
+	gcsFailureDumpSuffix = "-gcs.vmrs"
+)
+
+// prepareCrashDump sets `dir` as the directory that the crash dumps of the
+// utility VM are written to, creating it if needed and removing the stale
+// dumps of an earlier utility VM with the same ID.
+func (uvm *UtilityVM) prepareCrashDump(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if uvm.operatingSystem == "linux" && osversion.Get().Build < osversion.V21H2Server {
+		return fmt.Errorf("LCOW crash dumps require Windows build %d or later", osversion.V21H2Server)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create crash dump directory: %s", err)
+	}
+	uvm.crashDumpDirectory = dir
+	for _, path := range uvm.crashDumpPaths() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale crash dump: %s", err)
+		}
+	}
+	return nil
+}
+
+// crashDumpPaths returns the paths of the dump of a guest crash and of the
+// dump of a GCS failure.
+func (uvm *UtilityVM) crashDumpPaths() []string {
+	suffix := crashDumpSuffixWCOW
+	if uvm.operatingSystem == "linux" {
+		suffix = crashDumpSuffixLCOW
+	}
+	return []string{
+		filepath.Join(uvm.crashDumpDirectory, uvm.id+suffix),
+		filepath.Join(uvm.crashDumpDirectory, uvm.id+gcsFailureDumpSuffix),
+	}
+}
+
+// windowsCrashReporting returns the crash reporting settings that have the host
+// write the memory dump of a bugcheck of the guest to the crash dump directory.
+func (uvm *UtilityVM) windowsCrashReporting() *hcsschema.GuestCrashReporting {
+	if uvm.crashDumpDirectory == "" {
+		return nil
+	}
+	return &hcsschema.GuestCrashReporting{
+		WindowsCrashSettings: &hcsschema.WindowsCrashReporting{
+			DumpFileName: uvm.crashDumpPaths()[0],
+		},
+	}
+}
+
+// linuxDebugOptions returns the debug options that have the host save the
+// state of the VM to the crash dump directory when the Linux kernel panics,
+// which it reports to the host through the Hyper-V crash registers.
+func (uvm *UtilityVM) linuxDebugOptions() *hcsschema.DebugOptions {
+	if uvm.crashDumpDirectory == "" {
+		return nil
+	}
+	path := uvm.crashDumpPaths()[0]
+	return &hcsschema.DebugOptions{
+		BugcheckSavedStateFileName:            path,
+		BugcheckNoCrashdumpSavedStateFileName: path,
+	}
+}
+
+// dumpOnGCSFailure writes the saved state of the VM, with the memory of the
+// guest, to the crash dump directory once the GCS connection has failed for
+// good, so that the state of a hung or crashed GCS can be inspected. The VM is
+// resumed once saved.
+func (uvm *UtilityVM) dumpOnGCSFailure(gcsErr error) {
+	if uvm.crashDumpDirectory == "" {
+		return
+	}
+	// The connection of a guest that crashed or shut down fails as well.
+	select {
+	case <-uvm.exitCh:
+		return
+	default:
+	}
+	ctx := context.Background()
+	path := uvm.crashDumpPaths()[1]
+	l := log.G(ctx).WithField(logfields.UVMID, uvm.id).WithField("path", path)
+	l.WithError(gcsErr).Warning("GCS connection failed, dumping utility VM")
+
+	if !uvm.IsPaused() {
+		if err := uvm.hcsSystem.Pause(ctx); err != nil {
+			l.WithError(err).Warning("failed to pause utility VM for dump")
+			return
+		}
+		defer func() {
+			if err := uvm.hcsSystem.Resume(ctx); err != nil {
+				l.WithError(err).Warning("failed to resume utility VM after dump")
+			}
+		}()
+	}
+	saveOptions := hcsschema.SaveOptions{
+		SaveType:          hcsComputeSystemSaveToFile,
+		SaveStateFilePath: path,
+	}
+	if err := uvm.hcsSystem.Save(ctx, saveOptions); err != nil {
+		l.WithError(err).Warning("failed to dump utility VM")
+	}
+}
+
+// CrashDump returns the path of the crash dump of the utility VM, or false if
+// its guest did not crash, its GCS connection did not fail, or it was created
+// without `CrashDumpDirectory`.
+//
+// For WCOW the dump of a guest crash is the memory dump of the bugcheck, and
+// for LCOW the saved state of the VM, which holds the memory of the guest and
+// can be converted to a core dump of the kernel. The dump of a GCS failure is
+// the saved state of the VM for both.
+func (uvm *UtilityVM) CrashDump() (string, bool) {
+	if uvm.crashDumpDirectory == "" {
+		return "", false
+	}
+	for _, path := range uvm.crashDumpPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 
 	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/cpugroup"
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/mergemaps"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/schemaversion"
@@ -47,6 +50,17 @@ type Options struct {
 	// commit, set to true.
 	EnableDeferredCommit bool
 
+	// EnableColdHint allows the guest to report memory it is not using to the
+	// host so that it can be reclaimed. Defaults to false. Requires
+	// `AllowOvercommit`.
+	EnableColdHint bool
+
+	// EnableLargePages backs the UVM's memory with host large pages, which
+	// improves TLB behavior for memory bound workloads. Requires physically
+	// backed memory. If the host is out of large pages the UVM is created
+	// with small pages instead, see `LargePagesBacked`.
+	EnableLargePages bool
+
 	// ProcessorCount sets the number of vCPU's. If `0` will default to platform
 	// default.
 	ProcessorCount int32
@@ -59,6 +73,45 @@ type Options struct {
 	// when scheduling. If `0` will default to platform default.
 	ProcessorWeight int32
 
+	// ProcessorReservation sets the percentage of each vCPU, in the same units
+	// as `ProcessorLimit`, that the host guarantees to the UVM. If `0` no
+	// capacity is reserved.
+	ProcessorReservation int32
+
+	// LatencySensitive asks the host to favor scheduling latency for the UVM's
+	// vCPUs by reserving their full capacity and giving them the highest
+	// weight, unless `ProcessorReservation` or `ProcessorWeight` are set. The
+	// hints are applied once the UVM is started; if the host does not accept
+	// them the UVM runs without them and `SchedulerHintsApplied` is false.
+	LatencySensitive bool
+
+	// HwThreadsPerCore sets the number of hardware threads of each virtual
+	// core exposed to the UVM. `1` disables SMT in the guest, values above
+	// `1` require the host core scheduler. If `0` will default to platform
+	// default.
+	HwThreadsPerCore int32
+
+	// RequireCoreScheduler fails the creation of the UVM unless the host uses
+	// the core hypervisor scheduler, which never runs the vCPUs of different
+	// VMs on sibling hardware threads of the same core.
+	RequireCoreScheduler bool
+
+	// EnableNestedVirtualization exposes the virtualization extensions of the
+	// host processors to the UVM, so that its guest can run a hypervisor such
+	// as KVM or Hyper-V. A UVM with nested virtualization can not be saved.
+	EnableNestedVirtualization bool
+
+	// EnableTPM adds a TPM 2.0 device to the UVM. Requires UEFI boot for LCOW,
+	// and a guest kernel with a TPM driver.
+	EnableTPM bool
+	// TPMStateDirectory is a host directory owned by the caller that the TPM
+	// state of the UVM is kept in, as a guest state file (.vmgs) named after
+	// the UVM, so that keys sealed by the guest survive a save and restore or
+	// a detach and attach of the UVM. The file is created with the UVM and
+	// removed when it is closed. Requires `EnableTPM`. Defaults to "" (the
+	// TPM state is transient and cleared when the UVM stops).
+	TPMStateDirectory string
+
 	// StorageQoSIopsMaximum sets the maximum number of Iops. If `0` will
 	// default to the platform default.
 	StorageQoSIopsMaximum int32
@@ -85,11 +138,39 @@ type Options struct {
 	// CPUGroupID set the ID of a CPUGroup on the host that the UVM should be added to on start.
 	// Defaults to an empty string which indicates the UVM should not be added to any CPUGroup.
 	CPUGroupID string
+
+	// ProcessorAffinity sets the host logical processors that the UVM's vCPUs
+	// are restricted to. ProcessorAffinityNUMANodes adds every logical
+	// processor of the given host NUMA nodes to the set. If either is set a
+	// CPUGroup owned by the UVM is created with these logical processors and
+	// `CPUGroupID` must be empty. Defaults to no affinity.
+	ProcessorAffinity          []uint32
+	ProcessorAffinityNUMANodes []uint8
+	// NUMANodes sets the virtual NUMA nodes of the UVM, which together must
+	// have all of its vCPUs and memory. Requires `AllowOvercommit` to be
+	// false. Defaults to none (the platform default flat topology).
+	NUMANodes []NUMANode
 	// NetworkConfigProxy holds the address of the network config proxy service.
 	// This != "" determines whether to start the ComputeAgent TTRPC service
 	// that receives the UVMs set of NICs from this proxy instead of enumerating
 	// the endpoints locally.
 	NetworkConfigProxy string
+	// ComputeAgentSecurityDescriptor is the SDDL applied to the ComputeAgent
+	// named pipe. If empty the default named pipe ACL is used.
+	ComputeAgentSecurityDescriptor string
+	// NodeShares are the host directories shared read-only into the UVM when
+	// it starts, which containers mount with a `NodeSharePrefix` mount source.
+	// Defaults to none.
+	NodeShares []NodeShare
+	// SavedStatePath is the path of a utility VM state saved by Save. If set
+	// the UVM is restored from it, and only `ID` and `Owner` of the other
+	// options are used. Defaults to "" (the UVM is created from scratch).
+	SavedStatePath string
+	// CrashDumpDirectory is the host directory that the crash dumps of the
+	// guest are written to when it crashes or its GCS connection fails, see
+	// `CrashDump`. LCOW requires Windows Server 2022 or later. Defaults to ""
+	// (no crash dumps).
+	CrashDumpDirectory string
 }
 
 // compares the create opts used during template creation with the create opts
@@ -103,19 +184,19 @@ func verifyCloneUvmCreateOpts(templateOpts, cloneOpts *OptionsWCOW) bool {
 	// 4. AdditionalHCSDocumentJSON
 
 	// Save the original values of the fields that we want to ignore and replace them with
-	// the same values as that of the other object. So that we can simply use `==` operator.
+	// the same values as that of the other object. So that we can simply compare them.
 	templateIDBackup := templateOpts.ID
 	templateAdditionalJsonBackup := templateOpts.AdditionHCSDocumentJSON
 	templateOpts.ID = cloneOpts.ID
 	templateOpts.AdditionHCSDocumentJSON = cloneOpts.AdditionHCSDocumentJSON
 
-	// We can't use `==` operator on structs which include slices in them. So compare the
-	// Layerfolders separately and then directly compare the Options struct.
+	// Compare the Layerfolders separately as the scratch layer may differ and then
+	// directly compare the Options struct.
 	result := (len(templateOpts.LayerFolders) == len(cloneOpts.LayerFolders))
 	for i := 0; result && i < len(templateOpts.LayerFolders)-1; i++ {
 		result = result && (templateOpts.LayerFolders[i] == cloneOpts.LayerFolders[i])
 	}
-	result = result && (*templateOpts.Options == *cloneOpts.Options)
+	result = result && reflect.DeepEqual(*templateOpts.Options, *cloneOpts.Options)
 
 	// set original values
 	templateOpts.ID = templateIDBackup
@@ -130,8 +211,26 @@ func verifyOptions(ctx context.Context, options interface{}) error {
 		if opts.EnableDeferredCommit && !opts.AllowOvercommit {
 			return errors.New("EnableDeferredCommit is not supported on physically backed VMs")
 		}
-		if opts.SCSIControllerCount > 1 {
-			return errors.New("SCSI controller count must be 0 or 1") // Future extension here for up to 4
+		if err := verifyProcessorAffinityOptions(opts.Options); err != nil {
+			return err
+		}
+		if err := verifyNodeShares(opts.NodeShares); err != nil {
+			return err
+		}
+		if opts.ProcessorReservation < 0 || opts.ProcessorReservation > maxProcessorLimit {
+			return fmt.Errorf("ProcessorReservation must be between 0 and %d", maxProcessorLimit)
+		}
+		if opts.HwThreadsPerCore < 0 {
+			return errors.New("HwThreadsPerCore must not be negative")
+		}
+		if opts.EnableColdHint && !opts.AllowOvercommit {
+			return errors.New("EnableColdHint is not supported on physically backed VMs")
+		}
+		if opts.EnableLargePages && opts.AllowOvercommit {
+			return errors.New("EnableLargePages is only supported on physically backed VMs")
+		}
+		if opts.SCSIControllerCount > MaxSCSIControllers {
+			return fmt.Errorf("SCSI controller count cannot be greater than %d", MaxSCSIControllers)
 		}
 		if opts.VPMemDeviceCount > MaxVPMEMCount {
 			return fmt.Errorf("VPMem device count cannot be greater than %d", MaxVPMEMCount)
@@ -152,10 +251,78 @@ func verifyOptions(ctx context.Context, options interface{}) error {
 		if opts.EnableColdDiscardHint && osversion.Get().Build < 18967 {
 			return errors.New("EnableColdDiscardHint is not supported on builds older than 18967")
 		}
+		if opts.Persistent {
+			if !opts.ExternalGuestConnection {
+				return errors.New("Persistent requires ExternalGuestConnection")
+			}
+			if len(opts.Volumes) != 0 || opts.EnableDNSProxy {
+				return errors.New("Persistent is not supported with pod volumes or a DNS proxy")
+			}
+		}
+		if len(opts.EmulatedArchitectures) != 0 {
+			if !opts.ExternalGuestConnection {
+				return errors.New("EmulatedArchitectures requires ExternalGuestConnection")
+			}
+			if opts.Persistent || opts.SCSIControllerCount == 0 {
+				return errors.New("EmulatedArchitectures requires a SCSI controller and is not supported with Persistent")
+			}
+			if err := verifyEmulatedArchitectures(opts.EmulatedArchitectures); err != nil {
+				return err
+			}
+		}
+		if len(opts.Extensions) != 0 {
+			if opts.Persistent || opts.SCSIControllerCount == 0 {
+				return errors.New("Extensions requires a SCSI controller and is not supported with Persistent")
+			}
+			if opts.SandboxID == "" {
+				return errors.New("Extensions requires SandboxID")
+			}
+		}
+		if opts.EnableDNSProxy && opts.DNSProxyUpstream == "" {
+			return errors.New("EnableDNSProxy requires DNSProxyUpstream")
+		}
+		if len(opts.ForwardedPorts) != 0 {
+			if !opts.AllowForwardedPorts {
+				return errors.New("ForwardedPorts requires AllowForwardedPorts")
+			}
+			if !opts.ExternalGuestConnection {
+				return errors.New("ForwardedPorts requires ExternalGuestConnection")
+			}
+		}
+		if opts.CoreScheduling && !opts.ExternalGuestConnection {
+			return errors.New("CoreScheduling requires ExternalGuestConnection")
+		}
+		if len(opts.CoreSchedulingGroups) != 0 && !opts.CoreScheduling {
+			return errors.New("CoreSchedulingGroups requires CoreScheduling")
+		}
+		if opts.EnableTPM && opts.KernelDirect {
+			return errors.New("EnableTPM requires UEFI boot and is not supported with KernelDirect")
+		}
+		if opts.TPMStateDirectory != "" && !opts.EnableTPM {
+			return errors.New("TPMStateDirectory requires EnableTPM")
+		}
 	case *OptionsWCOW:
 		if opts.EnableDeferredCommit && !opts.AllowOvercommit {
 			return errors.New("EnableDeferredCommit is not supported on physically backed VMs")
 		}
+		if err := verifyProcessorAffinityOptions(opts.Options); err != nil {
+			return err
+		}
+		if err := verifyNodeShares(opts.NodeShares); err != nil {
+			return err
+		}
+		if opts.ProcessorReservation < 0 || opts.ProcessorReservation > maxProcessorLimit {
+			return fmt.Errorf("ProcessorReservation must be between 0 and %d", maxProcessorLimit)
+		}
+		if opts.HwThreadsPerCore < 0 {
+			return errors.New("HwThreadsPerCore must not be negative")
+		}
+		if opts.EnableColdHint && !opts.AllowOvercommit {
+			return errors.New("EnableColdHint is not supported on physically backed VMs")
+		}
+		if opts.EnableLargePages && opts.AllowOvercommit {
+			return errors.New("EnableLargePages is only supported on physically backed VMs")
+		}
 		if len(opts.LayerFolders) < 2 {
 			return errors.New("at least 2 LayerFolders must be supplied")
 		}
@@ -171,6 +338,12 @@ func verifyOptions(ctx context.Context, options interface{}) error {
 		if opts.IsTemplate && opts.FullyPhysicallyBacked {
 			return errors.New("Template can not be created from a full physically backed UVM")
 		}
+		if opts.TPMStateDirectory != "" && !opts.EnableTPM {
+			return errors.New("TPMStateDirectory requires EnableTPM")
+		}
+		if opts.IsTemplate && opts.EnableTPM {
+			return errors.New("Template can not be created from a UVM with a TPM")
+		}
 	}
 	return nil
 }
@@ -238,6 +411,43 @@ func (uvm *UtilityVM) create(ctx context.Context, doc interface{}) error {
 	return nil
 }
 
+// createFromDoc merges `additionalJSON` into `doc` and creates the compute
+// system. If large page backing was requested and the host fails to create
+// the VM with it, the VM is created again backed by small pages.
+func (uvm *UtilityVM) createFromDoc(ctx context.Context, doc *hcsschema.ComputeSystem, additionalJSON string) error {
+	create := func() error {
+		fullDoc, err := mergemaps.MergeJSON(doc, ([]byte)(additionalJSON))
+		if err != nil {
+			return fmt.Errorf("failed to merge additional JSON '%s': %s", additionalJSON, err)
+		}
+		return uvm.create(ctx, fullDoc)
+	}
+
+	memory := doc.VirtualMachine.ComputeTopology.Memory
+	largePages := memory.BackingPageSize == hcsschema.MemoryBackingPageSizeLarge
+	err := create()
+	// Only fall back when the host is out of large pages, as any other failure
+	// would fail with small pages as well.
+	if err != nil && largePages && hcs.IsNoSystemResources(err) {
+		log.G(ctx).WithField(logfields.UVMID, uvm.id).WithError(err).Warning("failed to create UVM backed by large pages, falling back to small pages")
+		memory.BackingPageSize = ""
+		largePages = false
+		err = create()
+	}
+	if err != nil {
+		return fmt.Errorf("error while creating the compute system: %s", err)
+	}
+	uvm.createDoc = doc
+	uvm.additionalJSON = additionalJSON
+	uvm.largePages = largePages
+	return nil
+}
+
+// LargePagesBacked returns if the UVM's memory is backed by host large pages.
+func (uvm *UtilityVM) LargePagesBacked() bool {
+	return uvm.largePages
+}
+
 // Close terminates and releases resources associated with the utility VM.
 func (uvm *UtilityVM) Close() (err error) {
 	ctx, span := trace.StartSpan(context.Background(), "uvm::Close")
@@ -255,10 +465,37 @@ func (uvm *UtilityVM) Close() (err error) {
 		_ = uvm.Wait()
 	}
 
+	uvm.stopPlan9Watchers()
+	uvm.removeVolumeFiles(ctx)
+	uvm.removeTPMState(ctx)
+	uvm.releaseEndpoints(ctx)
+
+	if uvm.affinityCPUGroupID != "" {
+		if err := cpugroup.Delete(ctx, uvm.affinityCPUGroupID); err != nil {
+			log.G(ctx).WithError(err).Warn("failed to delete processor affinity cpugroup")
+		}
+		uvm.affinityCPUGroupID = ""
+	}
+
+	for _, pf := range uvm.portForwards {
+		pf.listener.Close()
+		pf.guestListener.Close()
+	}
+	uvm.portForwards = nil
+
+	if uvm.dnsProxyListener != nil {
+		uvm.dnsProxyListener.Close()
+		uvm.dnsProxyListener = nil
+	}
+
 	if err := uvm.CloseGCSConnection(); err != nil {
 		log.G(ctx).Errorf("close GCS connection failed: %s", err)
 	}
 
+	if uvm.console != nil {
+		uvm.console.close()
+	}
+
 	// outputListener will only be nil for a Create -> Stop without a Start. In
 	// this case we have no goroutine processing output so its safe to close the
 	// channel here.
@@ -275,6 +512,9 @@ func (uvm *UtilityVM) Close() (err error) {
 
 // CreateContainer creates a container in the utility VM.
 func (uvm *UtilityVM) CreateContainer(ctx context.Context, id string, settings interface{}) (cow.Container, error) {
+	if uvm.IsPaused() {
+		return nil, ErrPaused
+	}
 	if uvm.gc != nil {
 		c, err := uvm.gc.CreateContainer(ctx, id, settings)
 		if err != nil {
@@ -298,6 +538,9 @@ func (uvm *UtilityVM) CreateContainer(ctx context.Context, id string, settings i
 
 // CreateProcess creates a process in the utility VM.
 func (uvm *UtilityVM) CreateProcess(ctx context.Context, settings interface{}) (cow.Process, error) {
+	if uvm.IsPaused() {
+		return nil, ErrPaused
+	}
 	if uvm.gc != nil {
 		return uvm.gc.CreateProcess(ctx, settings)
 	}
@@ -380,6 +623,41 @@ func (uvm *UtilityVM) DevicesPhysicallyBacked() bool {
 	return uvm.devicesPhysicallyBacked
 }
 
+// OCIHooksPath returns the guest directory that the OCI hooks of containers
+// must be in to be run by the guest, or "" if hooks are not run.
+func (uvm *UtilityVM) OCIHooksPath() string {
+	return uvm.ociHooksPath
+}
+
+// ReadOnlyRootfs returns `true` if every container in the UVM must have a
+// read-only root file system.
+func (uvm *UtilityVM) ReadOnlyRootfs() bool {
+	return uvm.readOnlyRootfs
+}
+
+// CoreSchedulingCookie returns the core scheduling cookie that the container
+// named `name` gets in the UVM: `group:<group>` for a container in one of the
+// `CoreSchedulingGroups`, sharing the cookie of the group, `container` for any
+// other container, including one without a name, which gets a cookie of its
+// own, or "" if the UVM was created without `CoreScheduling`.
+//
+// The groups are set when the UVM is created, so that a container can not
+// join the group of another container.
+func (uvm *UtilityVM) CoreSchedulingCookie(name string) string {
+	if !uvm.coreScheduling {
+		return ""
+	}
+	if group, ok := uvm.coreSchedulingGroups[name]; ok && name != "" {
+		return "group:" + group
+	}
+	return "container"
+}
+
+// TPMEnabled returns `true` if the UVM has a TPM device.
+func (uvm *UtilityVM) TPMEnabled() bool {
+	return uvm.tpmEnabled
+}
+
 // Closes the external GCS connection if it is being used and also closes the
 // listener for GCS connection.
 func (uvm *UtilityVM) CloseGCSConnection() (err error) {
@@ -7,7 +7,9 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Microsoft/hcsshim/internal/ncproxyttrpc"
 	"github.com/containerd/ttrpc"
@@ -15,13 +17,13 @@ import (
 	"github.com/Microsoft/go-winio"
 	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/gcs"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
-	"github.com/Microsoft/hcsshim/internal/mergemaps"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/ownership"
 	"github.com/Microsoft/hcsshim/internal/processorinfo"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
-	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	"github.com/Microsoft/hcsshim/osversion"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -50,6 +52,12 @@ const (
 	// UncompressedKernelFile is the default file name for an uncompressed
 	// kernel used to boot LCOW with KernelDirect.
 	UncompressedKernelFile = "vmlinux"
+	// BinfmtFile is the file name of the binfmt extension with the qemu-user
+	// interpreters for `EmulatedArchitectures`.
+	BinfmtFile = "binfmt.vhd"
+	// ExtensionsDirectory is the directory under the boot files with the
+	// extension VHDs that can be attached with `Extensions`.
+	ExtensionsDirectory = "extensions"
 )
 
 // OptionsLCOW are the set of options passed to CreateLCOW() to create a utility vm.
@@ -63,7 +71,9 @@ type OptionsLCOW struct {
 	KernelBootOptions     string              // Additional boot options for the kernel
 	EnableGraphicsConsole bool                // If true, enable a graphics console for the utility VM
 	ConsolePipe           string              // The named pipe path to use for the serial console.  eg \\.\pipe\vmpipe
-	SCSIControllerCount   uint32              // The number of SCSI controllers. Defaults to 1. Currently we only support 0 or 1.
+	CaptureConsole        bool                // Whether the serial console output is captured by the UVM, see `ConsoleReader`. Can not be used with `ConsolePipe`. Defaults to false
+	ConsoleLogFile        string              // If set, the host file that the captured serial console output is appended to, creating its directory if needed. Implies `CaptureConsole`. Defaults to "" (not written to a file)
+	SCSIControllerCount   uint32              // The number of SCSI controllers. Defaults to 1. Up to `MaxSCSIControllers` are supported.
 	UseGuestConnection    bool                // Whether the HCS should connect to the UVM's GCS. Defaults to true
 	ExecCommandLine       string              // The command line to exec from init. Defaults to GCS
 	ForwardStdout         bool                // Whether stdout will be forwarded from the executed program. Defaults to false
@@ -74,6 +84,31 @@ type OptionsLCOW struct {
 	PreferredRootFSType   PreferredRootFSType // If `KernelFile` is `InitrdFile` use `PreferredRootFSTypeInitRd`. If `KernelFile` is `VhdFile` use `PreferredRootFSTypeVHD`
 	EnableColdDiscardHint bool                // Whether the HCS should use cold discard hints. Defaults to false
 	VPCIEnabled           bool                // Whether the kernel should enable pci
+	EnableGuestDHCP       bool                // Whether hot-added NICs are configured by a DHCP client in the guest instead of statically. Defaults to false
+	DisableIPv6RA         bool                // Whether the guest should ignore IPv6 router advertisements on hot-added NICs. Defaults to false
+	EnableDNSProxy        bool                // Whether guest DNS queries are relayed over vsock to `DNSProxyUpstream`. Defaults to false
+	DNSProxyUpstream      string              // The host address (host[:port]) of the DNS server that `EnableDNSProxy` relays to, set by the host administrator. Defaults to ""
+	ForwardedPorts        []uint16            // Guest TCP ports relayed from the same port on the host loopback address. Requires `ExternalGuestConnection` and `AllowForwardedPorts`. Defaults to none
+	AllowForwardedPorts   bool                // Whether `ForwardedPorts` can be set, set by the host administrator. Defaults to false
+	Volumes               []VolumeOptions     // Pod volumes created in the UVM once started, by the caller. Defaults to none
+	OCIHooksPath          string              // If set, the guest directory that the OCI hooks of containers are run from. Defaults to "" (hooks are ignored)
+	GCSWatchdogTimeout    uint32              // If non-zero, the number of seconds after which a GCS operation without a response fails the GCS connection. Defaults to 0 (5 minutes)
+	GCSRecoveryTimeout    uint32              // If non-zero, the number of seconds to wait for a restarted GCS to reconnect after the GCS connection fails, including when `GCSWatchdogTimeout` expires. Containers the restarted GCS no longer knows of are reported as exited. Requires `ExternalGuestConnection` and a guest init that restarts the GCS when it exits. Defaults to 0 (no recovery)
+	Plan9MSize            uint32              // The maximum 9P message size negotiated by Plan9 mounts in the guest. Defaults to 0 (the guest default)
+	Plan9Cache            string              // The v9fs cache mode of Plan9 mounts in the guest, "none", "loose" or "mmap". "loose" does not see changes made on the host after a file is cached. Defaults to "" (the guest default, "none")
+	EntropySeedBytes      uint32              // The number of bytes of host random data that the guest entropy pool is seeded with at boot, up to `MaxEntropySeedBytes`. 0 disables seeding. Defaults to 512
+	RequireEntropySeed    bool                // Whether the UVM fails to start if the entropy seed can not be delivered. Defaults to true
+	Plan9ChangeNotify     bool                // Whether changes to the host directories of Plan9 shares are relayed to the guest, so that inotify watches on the mounts see them. Requires guest support. Defaults to false
+	Persistent            bool                // Whether the UVM can outlive the process that created it, see `Detach` and `Attach`. Requires `ExternalGuestConnection` and a guest init that restarts the GCS. Defaults to false
+	HvSocketAllowList     []string            // If non-nil, the hvsocket service IDs (or vsock ports) that the guest accepts inbound connections to, refusing all others. Requires `ExternalGuestConnection` and guest support. Defaults to nil (no filtering)
+	ScratchTrimInterval   uint32              // If non-zero, the number of seconds between trims of the writable SCSI disks, see `TrimSCSI`. Requires `ExternalGuestConnection` and guest support. Defaults to 0 (no periodic trim)
+	EmulatedArchitectures []string            // The foreign architectures, such as "arm64", whose binaries run through the qemu-user interpreters of `BinfmtFile` under `BootFilesPath`. Requires `ExternalGuestConnection` and guest support. Defaults to nil (no emulation)
+	Extensions            []string            // The names of the extensions in `ExtensionsDirectory` under `BootFilesPath` that the guest mounts read-only under the sandbox mounts of `SandboxID`, so that containers mount them with `sandbox:///extensions/<name>`. Each must be in `ApprovedExtensions`. Defaults to nil (none)
+	ApprovedExtensions    []string            // The names of the extensions that `Extensions` can list, set by the host administrator. Defaults to nil (no extensions can be attached)
+	SandboxID             string              // The ID of the pod sandbox, or of the only container, that the UVM hosts. Required by `Extensions`. Defaults to ""
+	ReadOnlyRootfs        bool                // Whether every container in the UVM has a read-only root file system, with tmpfs mounts on its writable paths. Set by the host administrator. Defaults to false (each container chooses)
+	CoreScheduling        bool                // Whether every container in the UVM gets a Linux core scheduling cookie, so that its processes never share the SMT siblings of a core with the processes of another container, see `CoreSchedulingCookie`. Requires `ExternalGuestConnection` and guest support. Defaults to false
+	CoreSchedulingGroups  map[string]string   // The core scheduling groups of the containers of the UVM, by container name. The containers of a group share a cookie, and the others get a cookie of their own. Requires `CoreScheduling`. Defaults to nil (no groups)
 }
 
 // defaultLCOWOSBootFilesPath returns the default path used to locate the LCOW
@@ -107,6 +142,8 @@ func NewDefaultOptionsLCOW(id, owner string) *OptionsLCOW {
 		KernelBootOptions:     "",
 		EnableGraphicsConsole: false,
 		ConsolePipe:           "",
+		CaptureConsole:        false,
+		ConsoleLogFile:        "",
 		SCSIControllerCount:   1,
 		UseGuestConnection:    true,
 		ExecCommandLine:       fmt.Sprintf("/bin/gcs -v4 -log-format json -loglevel %s", logrus.StandardLogger().Level.String()),
@@ -118,6 +155,29 @@ func NewDefaultOptionsLCOW(id, owner string) *OptionsLCOW {
 		PreferredRootFSType:   PreferredRootFSTypeInitRd,
 		EnableColdDiscardHint: false,
 		VPCIEnabled:           false,
+		EnableGuestDHCP:       false,
+		DisableIPv6RA:         false,
+		EnableDNSProxy:        false,
+		DNSProxyUpstream:      "",
+		ForwardedPorts:        nil,
+		AllowForwardedPorts:   false,
+		Volumes:               nil,
+		OCIHooksPath:          "",
+		GCSWatchdogTimeout:    0,
+		GCSRecoveryTimeout:    0,
+		Plan9MSize:            0,
+		Plan9Cache:            "",
+		Plan9ChangeNotify:     false,
+		Persistent:            false,
+		HvSocketAllowList:     nil,
+		ScratchTrimInterval:   0,
+		EmulatedArchitectures: nil,
+		Extensions:            nil,
+		ReadOnlyRootfs:        false,
+		CoreScheduling:        false,
+		CoreSchedulingGroups:  nil,
+		EntropySeedBytes:      entropyBytes,
+		RequireEntropySeed:    true,
 	}
 
 	if _, err := os.Stat(filepath.Join(opts.BootFilesPath, VhdFile)); err == nil {
@@ -155,14 +215,50 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	span.AddAttributes(trace.StringAttribute(logfields.UVMID, opts.ID))
 	log.G(ctx).WithField("options", fmt.Sprintf("%+v", opts)).Debug("uvm::CreateLCOW options")
 
+	if opts.SavedStatePath != "" {
+		return restore(ctx, opts.Options, "linux")
+	}
+
+	if opts.EntropySeedBytes > MaxEntropySeedBytes {
+		return nil, fmt.Errorf("entropy seed of %d bytes exceeds the maximum of %d bytes", opts.EntropySeedBytes, MaxEntropySeedBytes)
+	}
+
+	hvsocketAllowList, err := normalizeHvSocketAllowList(opts.HvSocketAllowList)
+	if err != nil {
+		return nil, err
+	}
+	if hvsocketAllowList != nil && !opts.ExternalGuestConnection {
+		return nil, errors.New("HvSocketAllowList requires ExternalGuestConnection")
+	}
+	if opts.ScratchTrimInterval != 0 && !opts.ExternalGuestConnection {
+		return nil, errors.New("ScratchTrimInterval requires ExternalGuestConnection")
+	}
+
+	switch guestrequest.Plan9Cache(opts.Plan9Cache) {
+	case "", guestrequest.Plan9CacheNone, guestrequest.Plan9CacheLoose, guestrequest.Plan9CacheMmap:
+	default:
+		return nil, fmt.Errorf("invalid plan9 cache mode %q", opts.Plan9Cache)
+	}
+	captureConsole := opts.CaptureConsole || opts.ConsoleLogFile != ""
+	if captureConsole && opts.ConsolePipe != "" {
+		return nil, errors.New("CaptureConsole can not be used with ConsolePipe")
+	}
+
 	// We dont serialize OutputHandler so if it is missing we need to put it back to the default.
 	if opts.OutputHandler == nil {
 		opts.OutputHandler = parseLogrus(opts.ID)
 	}
 
+	owner := opts.Owner
+	if opts.Persistent {
+		// A persistent UVM outlives its creator by design, so it must not be
+		// cleaned up as an orphan.
+		owner = ownership.Owner(owner)
+	}
+
 	uvm := &UtilityVM{
 		id:                      opts.ID,
-		owner:                   opts.Owner,
+		owner:                   owner,
 		operatingSystem:         "linux",
 		scsiControllerCount:     opts.SCSIControllerCount,
 		vpmemMaxCount:           opts.VPMemDeviceCount,
@@ -171,7 +267,43 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 		physicallyBacked:        !opts.AllowOvercommit,
 		devicesPhysicallyBacked: opts.FullyPhysicallyBacked,
 		cpuGroupID:              opts.CPUGroupID,
+		guestDHCP:               opts.EnableGuestDHCP,
+		disableIPv6RA:           opts.DisableIPv6RA,
+		forwardedPorts:          opts.ForwardedPorts,
+		ociHooksPath:            opts.OCIHooksPath,
+		readOnlyRootfs:          opts.ReadOnlyRootfs,
+		coreScheduling:          opts.CoreScheduling,
+		coreSchedulingGroups:    opts.CoreSchedulingGroups,
+		nodeShares:              opts.NodeShares,
+		gcsWatchdogTimeout:      time.Duration(opts.GCSWatchdogTimeout) * time.Second,
+		gcsRecoveryTimeout:      time.Duration(opts.GCSRecoveryTimeout) * time.Second,
+		plan9ChangeNotify:       opts.Plan9ChangeNotify,
+		plan9MSize:              opts.Plan9MSize,
+		plan9Cache:              guestrequest.Plan9Cache(opts.Plan9Cache),
+		entropySeedBytes:        opts.EntropySeedBytes,
+		requireEntropySeed:      opts.RequireEntropySeed,
+		persistent:              opts.Persistent,
+		hvsocketAllowList:       hvsocketAllowList,
+		scratchTrimInterval:     time.Duration(opts.ScratchTrimInterval) * time.Second,
+		emulatedArchitectures:   opts.EmulatedArchitectures,
+		binfmtFile:              filepath.Join(opts.BootFilesPath, BinfmtFile),
+		extensions:              opts.Extensions,
+		sandboxID:               opts.SandboxID,
+		extensionsPath:          filepath.Join(opts.BootFilesPath, ExtensionsDirectory),
+		schedulerHints:          schedulerHintLimits(opts.Options),
+		processorLimit:          opts.ProcessorLimit,
 		createOpts:              opts,
+		memoryHints: MemoryHints{
+			ColdHint:        opts.EnableColdHint,
+			ColdDiscardHint: opts.EnableColdDiscardHint,
+		},
+	}
+
+	if err := uvm.prepareCrashDump(opts.CrashDumpDirectory); err != nil {
+		return nil, err
+	}
+	if captureConsole {
+		uvm.console = newConsoleCapture(fmt.Sprintf(`\\.\pipe\%s-console`, opts.ID), opts.ConsoleLogFile)
 	}
 
 	defer func() {
@@ -188,11 +320,23 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	if _, err := os.Stat(rootfsFullPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("boot file: '%s' not found", rootfsFullPath)
 	}
+	if len(opts.EmulatedArchitectures) != 0 {
+		if _, err := os.Stat(uvm.binfmtFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("binfmt extension: '%s' not found", uvm.binfmtFile)
+		}
+	}
+	if err := verifyExtensions(uvm.extensionsPath, opts.Extensions, opts.ApprovedExtensions); err != nil {
+		return nil, err
+	}
 
 	if err := verifyOptions(ctx, opts); err != nil {
 		return nil, errors.Wrap(err, errBadUVMOpts.Error())
 	}
 
+	if err := verifySchedulerOptions(ctx, opts.Options); err != nil {
+		return nil, errors.Wrap(err, errBadUVMOpts.Error())
+	}
+
 	processorTopology, err := processorinfo.HostProcessorInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get host processor information: %s", err)
@@ -202,31 +346,49 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	// a user CPU count if the setting is not possible.
 	uvm.processorCount = uvm.normalizeProcessorCount(ctx, opts.ProcessorCount, processorTopology)
 
+	if err := uvm.configureProcessorAffinity(ctx, opts.Options, processorTopology); err != nil {
+		return nil, err
+	}
+
 	// Align the requested memory size.
 	memorySizeInMB := uvm.normalizeMemorySize(ctx, opts.MemorySizeInMB)
+	numa, err := numaTopology(opts.NUMANodes, uvm.processorCount, memorySizeInMB, opts.AllowOvercommit)
+	if err != nil {
+		return nil, err
+	}
+	schemaVersion, err := numaSchemaVersion(numa)
+	if err != nil {
+		return nil, err
+	}
+	uvm.numaNodeCount = uint32(len(opts.NUMANodes))
 
 	doc := &hcsschema.ComputeSystem{
 		Owner:                             uvm.owner,
-		SchemaVersion:                     schemaversion.SchemaV21(),
-		ShouldTerminateOnLastHandleClosed: true,
+		SchemaVersion:                     schemaVersion,
+		ShouldTerminateOnLastHandleClosed: !opts.Persistent,
 		VirtualMachine: &hcsschema.VirtualMachine{
-			StopOnReset: true,
-			Chipset:     &hcsschema.Chipset{},
+			StopOnReset:  true,
+			Chipset:      &hcsschema.Chipset{},
+			DebugOptions: uvm.linuxDebugOptions(),
 			ComputeTopology: &hcsschema.Topology{
 				Memory: &hcsschema.Memory2{
 					SizeInMB:              memorySizeInMB,
 					AllowOvercommit:       opts.AllowOvercommit,
 					EnableDeferredCommit:  opts.EnableDeferredCommit,
+					EnableColdHint:        opts.EnableColdHint,
 					EnableColdDiscardHint: opts.EnableColdDiscardHint,
 					LowMMIOGapInMB:        opts.LowMMIOGapInMB,
 					HighMMIOBaseInMB:      opts.HighMMIOBaseInMB,
 					HighMMIOGapInMB:       opts.HighMMIOGapInMB,
 				},
 				Processor: &hcsschema.Processor2{
-					Count:  uvm.processorCount,
-					Limit:  opts.ProcessorLimit,
-					Weight: opts.ProcessorWeight,
+					Count:                          uvm.processorCount,
+					Limit:                          opts.ProcessorLimit,
+					Weight:                         opts.ProcessorWeight,
+					HwThreadsPerCore:               opts.HwThreadsPerCore,
+					ExposeVirtualizationExtensions: opts.EnableNestedVirtualization,
 				},
+				Numa: numa,
 			},
 			Devices: &hcsschema.Devices{
 				HvSocket: &hcsschema.HvSocket2{
@@ -257,11 +419,11 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	}
 
 	if uvm.scsiControllerCount > 0 {
-		// TODO: JTERRY75 - this should enumerate scsicount and add an entry per value.
-		doc.VirtualMachine.Devices.Scsi = map[string]hcsschema.Scsi{
-			"0": {
+		doc.VirtualMachine.Devices.Scsi = make(map[string]hcsschema.Scsi)
+		for i := 0; i < int(uvm.scsiControllerCount); i++ {
+			doc.VirtualMachine.Devices.Scsi[strconv.Itoa(i)] = hcsschema.Scsi{
 				Attachments: make(map[string]hcsschema.Attachment),
-			},
+			}
 		}
 	}
 	if uvm.vpmemMaxCount > 0 {
@@ -308,6 +470,13 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 				NamedPipe: opts.ConsolePipe,
 			},
 		}
+	} else if uvm.console != nil {
+		kernelArgs += " 8250_core.nr_uarts=1 8250_core.skip_txen_test=1 console=ttyS0,115200"
+		doc.VirtualMachine.Devices.ComPorts = map[string]hcsschema.ComPort{
+			"0": {
+				NamedPipe: uvm.console.pipePath,
+			},
+		}
 	} else {
 		kernelArgs += " 8250_core.nr_uarts=0"
 	}
@@ -334,11 +503,14 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	}
 
 	// Inject initial entropy over vsock during init launch.
-	initArgs := fmt.Sprintf("-e %d", entropyVsockPort)
+	initArgs := ""
+	if opts.EntropySeedBytes != 0 {
+		initArgs = fmt.Sprintf("-e %d ", entropyVsockPort)
+	}
 
 	// With default options, run GCS with stderr pointing to the vsock port
 	// created below in order to forward guest logs to logrus.
-	initArgs += " /bin/vsockexec"
+	initArgs += "/bin/vsockexec"
 
 	if opts.ForwardStdout {
 		initArgs += fmt.Sprintf(" -o %d", linuxLogVsockPort)
@@ -377,20 +549,28 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 		}
 	}
 
-	fullDoc, err := mergemaps.MergeJSON(doc, ([]byte)(opts.AdditionHCSDocumentJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to merge additional JSON '%s': %s", opts.AdditionHCSDocumentJSON, err)
+	if opts.EnableLargePages {
+		doc.VirtualMachine.ComputeTopology.Memory.BackingPageSize = hcsschema.MemoryBackingPageSizeLarge
 	}
-
-	err = uvm.create(ctx, fullDoc)
-	if err != nil {
-		return nil, fmt.Errorf("error while creating the compute system: %s", err)
+	if err = uvm.configureTPM(ctx, opts.Options, doc); err != nil {
+		return nil, err
+	}
+	if err = uvm.createFromDoc(ctx, doc, opts.AdditionHCSDocumentJSON); err != nil {
+		return nil, err
 	}
 
 	// Cerate a socket to inject entropy during boot.
-	uvm.entropyListener, err = uvm.listenVsock(entropyVsockPort)
-	if err != nil {
-		return nil, err
+	if opts.EntropySeedBytes != 0 {
+		uvm.entropyListener, err = uvm.listenVsock(entropyVsockPort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.EnableDNSProxy {
+		if err := uvm.startDNSProxy(ctx, opts.DNSProxyUpstream); err != nil {
+			return nil, fmt.Errorf("failed to start DNS proxy: %s", err)
+		}
 	}
 
 	// Create a socket that the executed program can send to. This is usually
@@ -421,6 +601,7 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 		}
 		client := ttrpc.NewClient(conn, ttrpc.WithOnClose(func() { conn.Close() }))
 		uvm.ncProxyClient = ncproxyttrpc.NewNetworkConfigProxyClient(client)
+		uvm.computeAgentSecurityDescriptor = opts.ComputeAgentSecurityDescriptor
 	}
 
 	return uvm, nil
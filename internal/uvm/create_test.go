@@ -35,3 +35,95 @@ func TestCreateClone(t *testing.T) {
 		t.Fatalf("CreateWCOW should fail when IsClone is true and TemplateConfig is not provided")
 	}
 }
+
+func TestCoreSchedulingCookie(t *testing.T) {
+	uvm := &UtilityVM{
+		coreScheduling:       true,
+		coreSchedulingGroups: map[string]string{"app": "web", "sidecar": "web"},
+	}
+	for name, expected := range map[string]string{
+		"app":     "group:web",
+		"sidecar": "group:web",
+		"db":      "container",
+		"":        "container",
+	} {
+		if actual := uvm.CoreSchedulingCookie(name); actual != expected {
+			t.Fatalf("expected cookie %q for container %q, got: %q", expected, name, actual)
+		}
+	}
+	uvm.coreScheduling = false
+	if actual := uvm.CoreSchedulingCookie("app"); actual != "" {
+		t.Fatalf("expected no cookie without core scheduling, got: %q", actual)
+	}
+}
+
+func TestVerifyOptionsCoreSchedulingGroups(t *testing.T) {
+	opts := NewDefaultOptionsLCOW(t.Name(), "")
+	opts.CoreSchedulingGroups = map[string]string{"app": "web"}
+	err := verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != "CoreSchedulingGroups requires CoreScheduling" {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyOptionsForwardedPorts(t *testing.T) {
+	opts := NewDefaultOptionsLCOW(t.Name(), "")
+	opts.ForwardedPorts = []uint16{8080}
+	err := verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != "ForwardedPorts requires AllowForwardedPorts" {
+		t.Fatal(err)
+	}
+	opts.AllowForwardedPorts = true
+	opts.ExternalGuestConnection = false
+	err = verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != "ForwardedPorts requires ExternalGuestConnection" {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyOptionsDNSProxy(t *testing.T) {
+	opts := NewDefaultOptionsLCOW(t.Name(), "")
+	opts.EnableDNSProxy = true
+	err := verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != "EnableDNSProxy requires DNSProxyUpstream" {
+		t.Fatal(err)
+	}
+	opts.DNSProxyUpstream = "10.0.0.53"
+	if err := verifyOptions(context.Background(), opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+}
+
+func TestVerifyOptionsColdHint(t *testing.T) {
+	lopts := NewDefaultOptionsLCOW(t.Name(), "")
+	lopts.EnableColdHint = true
+	if err := verifyOptions(context.Background(), lopts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	lopts.AllowOvercommit = false
+	err := verifyOptions(context.Background(), lopts)
+	if err == nil || err.Error() != "EnableColdHint is not supported on physically backed VMs" {
+		t.Fatal(err)
+	}
+
+	wopts := NewDefaultOptionsWCOW(t.Name(), "")
+	wopts.EnableColdHint = true
+	wopts.AllowOvercommit = false
+	err = verifyOptions(context.Background(), wopts)
+	if err == nil || err.Error() != "EnableColdHint is not supported on physically backed VMs" {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyOptionsSCSIControllerCount(t *testing.T) {
+	opts := NewDefaultOptionsLCOW(t.Name(), "")
+	opts.SCSIControllerCount = MaxSCSIControllers
+	if err := verifyOptions(context.Background(), opts); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	opts.SCSIControllerCount = MaxSCSIControllers + 1
+	err := verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != fmt.Sprintf("SCSI controller count cannot be greater than %d", MaxSCSIControllers) {
+		t.Fatal(err)
+	}
+}
@@ -11,12 +11,10 @@ import (
 	"github.com/Microsoft/hcsshim/internal/gcs"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
-	"github.com/Microsoft/hcsshim/internal/mergemaps"
 	"github.com/Microsoft/hcsshim/internal/ncproxyttrpc"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/processorinfo"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
-	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	"github.com/Microsoft/hcsshim/internal/uvmfolder"
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	"github.com/Microsoft/hcsshim/internal/wcow"
@@ -76,6 +74,10 @@ func (uvm *UtilityVM) startExternalGcsListener(ctx context.Context) error {
 }
 
 func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW, uvmFolder string) (*hcsschema.ComputeSystem, error) {
+	if err := verifySchedulerOptions(ctx, opts.Options); err != nil {
+		return nil, errors.Wrap(err, errBadUVMOpts.Error())
+	}
+
 	processorTopology, err := processorinfo.HostProcessorInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get host processor information: %s", err)
@@ -85,8 +87,21 @@ func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW, uv
 	// a user CPU count if the setting is not possible.
 	uvm.processorCount = uvm.normalizeProcessorCount(ctx, opts.ProcessorCount, processorTopology)
 
+	if err := uvm.configureProcessorAffinity(ctx, opts.Options, processorTopology); err != nil {
+		return nil, err
+	}
+
 	// Align the requested memory size.
 	memorySizeInMB := uvm.normalizeMemorySize(ctx, opts.MemorySizeInMB)
+	numa, err := numaTopology(opts.NUMANodes, uvm.processorCount, memorySizeInMB, opts.AllowOvercommit)
+	if err != nil {
+		return nil, err
+	}
+	schemaVersion, err := numaSchemaVersion(numa)
+	if err != nil {
+		return nil, err
+	}
+	uvm.numaNodeCount = uint32(len(opts.NUMANodes))
 
 	// UVM rootfs share is readonly.
 	vsmbOpts := uvm.DefaultVSMBOptions(true)
@@ -125,7 +140,7 @@ func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW, uv
 
 	doc := &hcsschema.ComputeSystem{
 		Owner:                             uvm.owner,
-		SchemaVersion:                     schemaversion.SchemaV21(),
+		SchemaVersion:                     schemaVersion,
 		ShouldTerminateOnLastHandleClosed: true,
 		VirtualMachine: &hcsschema.VirtualMachine{
 			StopOnReset: true,
@@ -145,15 +160,19 @@ func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW, uv
 					// EnableHotHint is not compatible with physical.
 					EnableHotHint:        opts.AllowOvercommit,
 					EnableDeferredCommit: opts.EnableDeferredCommit,
+					EnableColdHint:       opts.EnableColdHint,
 					LowMMIOGapInMB:       opts.LowMMIOGapInMB,
 					HighMMIOBaseInMB:     opts.HighMMIOBaseInMB,
 					HighMMIOGapInMB:      opts.HighMMIOGapInMB,
 				},
 				Processor: &hcsschema.Processor2{
-					Count:  uvm.processorCount,
-					Limit:  opts.ProcessorLimit,
-					Weight: opts.ProcessorWeight,
+					Count:                          uvm.processorCount,
+					Limit:                          opts.ProcessorLimit,
+					Weight:                         opts.ProcessorWeight,
+					HwThreadsPerCore:               opts.HwThreadsPerCore,
+					ExposeVirtualizationExtensions: opts.EnableNestedVirtualization,
 				},
+				Numa: numa,
 			},
 			Devices: &hcsschema.Devices{
 				HvSocket: &hcsschema.HvSocket2{
@@ -163,7 +182,8 @@ func prepareConfigDoc(ctx context.Context, uvm *UtilityVM, opts *OptionsWCOW, uv
 						DefaultBindSecurityDescriptor: "D:P(A;;FA;;;SY)(A;;FA;;;BA)",
 					},
 				},
-				VirtualSmb: virtualSMB,
+				VirtualSmb:          virtualSMB,
+				GuestCrashReporting: uvm.windowsCrashReporting(),
 			},
 		},
 	}
@@ -206,6 +226,10 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 	span.AddAttributes(trace.StringAttribute(logfields.UVMID, opts.ID))
 	log.G(ctx).WithField("options", fmt.Sprintf("%+v", opts)).Debug("uvm::CreateWCOW options")
 
+	if opts.SavedStatePath != "" {
+		return restore(ctx, opts.Options, "windows")
+	}
+
 	uvm := &UtilityVM{
 		id:                      opts.ID,
 		owner:                   opts.Owner,
@@ -217,7 +241,15 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		physicallyBacked:        !opts.AllowOvercommit,
 		devicesPhysicallyBacked: opts.FullyPhysicallyBacked,
 		cpuGroupID:              opts.CPUGroupID,
+		nodeShares:              opts.NodeShares,
+		schedulerHints:          schedulerHintLimits(opts.Options),
+		processorLimit:          opts.ProcessorLimit,
 		createOpts:              *opts,
+		memoryHints: MemoryHints{
+			// EnableHotHint is not compatible with physical.
+			HotHint:  opts.AllowOvercommit,
+			ColdHint: opts.EnableColdHint,
+		},
 	}
 
 	defer func() {
@@ -230,6 +262,10 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		return nil, errors.Wrap(err, errBadUVMOpts.Error())
 	}
 
+	if err := uvm.prepareCrashDump(opts.CrashDumpDirectory); err != nil {
+		return nil, err
+	}
+
 	uvmFolder, err := uvmfolder.LocateUVMFolder(ctx, opts.LayerFolders)
 	if err != nil {
 		return nil, fmt.Errorf("failed to locate utility VM folder from layer folders: %s", err)
@@ -315,14 +351,14 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		uvm.IsTemplate = true
 	}
 
-	fullDoc, err := mergemaps.MergeJSON(doc, ([]byte)(opts.AdditionHCSDocumentJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to merge additional JSON '%s': %s", opts.AdditionHCSDocumentJSON, err)
+	if opts.EnableLargePages {
+		doc.VirtualMachine.ComputeTopology.Memory.BackingPageSize = hcsschema.MemoryBackingPageSizeLarge
 	}
-
-	err = uvm.create(ctx, fullDoc)
-	if err != nil {
-		return nil, fmt.Errorf("error while creating the compute system: %s", err)
+	if err = uvm.configureTPM(ctx, opts.Options, doc); err != nil {
+		return nil, err
+	}
+	if err = uvm.createFromDoc(ctx, doc, opts.AdditionHCSDocumentJSON); err != nil {
+		return nil, err
 	}
 
 	// All clones MUST use external gcs connection
@@ -340,6 +376,7 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		}
 		client := ttrpc.NewClient(conn, ttrpc.WithOnClose(func() { conn.Close() }))
 		uvm.ncProxyClient = ncproxyttrpc.NewNetworkConfigProxyClient(client)
+		uvm.computeAgentSecurityDescriptor = opts.ComputeAgentSecurityDescriptor
 	}
 
 	return uvm, nil
@@ -0,0 +1,43 @@
+package uvm
+
+import (
+	"context"
+	"net"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/sirupsen/logrus"
+)
+
+// dnsProxyVsockPort is the vsock port that the LCOW guest's stub resolver
+// connects to in order to reach the host DNS proxy.
+const dnsProxyVsockPort = 53
+
+// startDNSProxy listens on `dnsProxyVsockPort` and relays DNS over TCP
+// streams from the guest to `upstream` on the host's network stack. This lets
+// pods use host resolver configuration (split DNS, VPN) without exposing the
+// host network to the guest.
+func (uvm *UtilityVM) startDNSProxy(ctx context.Context, upstream string) error {
+	if _, _, err := net.SplitHostPort(upstream); err != nil {
+		upstream = net.JoinHostPort(upstream, "53")
+	}
+	l, err := uvm.listenVsock(dnsProxyVsockPort)
+	if err != nil {
+		return err
+	}
+	uvm.dnsProxyListener = l
+
+	entry := log.G(ctx).WithFields(logrus.Fields{
+		logfields.UVMID: uvm.id,
+		"upstream":      upstream,
+	})
+	go relayListener(entry, l, func() (net.Conn, error) {
+		return net.Dial("tcp", upstream)
+	})
+	return nil
+}
+
+// DNSProxyEnabled returns if the host DNS proxy is serving this UVM.
+func (uvm *UtilityVM) DNSProxyEnabled() bool {
+	return uvm.dnsProxyListener != nil
+}
@@ -0,0 +1,74 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+)
+
+// lcowSandboxMountsFmt is the path in the LCOW UVM that the guest resolves the
+// `sandbox://` mounts of the containers of a sandbox against, formatted with
+// the sandbox ID.
+const lcowSandboxMountsFmt = "/run/gcs/c/%s/sandboxMounts"
+
+// lcowExtensionsDir is the directory under the sandbox mounts in which each
+// extension is mounted in a directory of its name, so that containers mount
+// an extension with `sandbox:///extensions/<name>`.
+const lcowExtensionsDir = "extensions"
+
+// extensionNameRegex is the format of the name of an extension.
+var extensionNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// verifyExtensions returns an error if any of the extensions `names` is not in
+// `approved` or is not an extension VHD, `<name>.vhd`, in `dir`.
+//
+// Only the extensions that the host administrator installed with the boot
+// files and approved in the shim options can be attached, such as debug tools
+// or GPU userspace libraries, so that a pod can not mount arbitrary disks into
+// the guest.
+func verifyExtensions(dir string, names, approved []string) error {
+	for _, name := range names {
+		if !extensionNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid extension name: '%s'", name)
+		}
+		if !isApprovedExtension(name, approved) {
+			return fmt.Errorf("extension '%s' is not approved", name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, name+".vhd")); err != nil {
+			return fmt.Errorf("extension '%s' is not installed in '%s': %s", name, dir, err)
+		}
+	}
+	return nil
+}
+
+func isApprovedExtension(name string, approved []string) bool {
+	for _, a := range approved {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionMountPath returns the path in the guest that the extension `name`
+// is mounted at.
+func (uvm *UtilityVM) extensionMountPath(name string) string {
+	return path.Join(fmt.Sprintf(lcowSandboxMountsFmt, uvm.sandboxID), lcowExtensionsDir, name)
+}
+
+// attachExtensions attaches the extension VHDs read-only and mounts them in the
+// guest under the sandbox mounts of the sandbox of the UVM.
+func (uvm *UtilityVM) attachExtensions(ctx context.Context) error {
+	for _, name := range uvm.extensions {
+		hostPath := filepath.Join(uvm.extensionsPath, name+".vhd")
+		// The extensions are hot added rather than in the VM document, so
+		// HCS does not grant the VM access to them.
+		if _, err := uvm.AddSCSI(ctx, hostPath, uvm.extensionMountPath(name), true, VMAccessTypeIndividual); err != nil {
+			return fmt.Errorf("failed to attach extension '%s': %s", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package uvm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyExtensions(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"gdb", "perf"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".vhd"), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	approved := []string{"gdb", "bpftrace"}
+
+	if err := verifyExtensions(dir, []string{"gdb"}, approved); err != nil {
+		t.Fatalf("expected approved and installed extension to be valid, got: %s", err)
+	}
+	for _, names := range [][]string{
+		// installed but not approved
+		{"perf"},
+		// approved but not installed
+		{"bpftrace"},
+		{"../gdb"},
+		{"gdb", "perf"},
+	} {
+		if err := verifyExtensions(dir, names, approved); err == nil {
+			t.Fatalf("expected extensions %v to be rejected", names)
+		}
+	}
+	if err := verifyExtensions(dir, []string{"gdb"}, nil); err == nil {
+		t.Fatal("expected extensions to be rejected without approved extensions")
+	}
+}
+
+func TestExtensionMountPath(t *testing.T) {
+	uvm := &UtilityVM{sandboxID: "pod"}
+	if actual, expected := uvm.extensionMountPath("gdb"), "/run/gcs/c/pod/sandboxMounts/extensions/gdb"; actual != expected {
+		t.Fatalf("expected mount path %q, got: %q", expected, actual)
+	}
+}
+
+func TestVerifyOptionsExtensionsSandboxID(t *testing.T) {
+	opts := NewDefaultOptionsLCOW(t.Name(), "")
+	opts.Extensions = []string{"gdb"}
+	err := verifyOptions(context.Background(), opts)
+	if err == nil || err.Error() != "Extensions requires SandboxID" {
+		t.Fatal(err)
+	}
+}
@@ -3,7 +3,11 @@ package uvm
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/Microsoft/go-winio"
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 )
@@ -41,3 +45,84 @@ func (uvm *UtilityVM) RemoveHvSocketService(ctx context.Context, sid string) err
 	}
 	return uvm.modify(ctx, request)
 }
+
+// normalizeHvSocketAllowList returns the service IDs of the entries of
+// `allowList`, each of which is a service ID or a vsock port. A nil list is
+// returned as nil.
+func normalizeHvSocketAllowList(allowList []string) ([]string, error) {
+	if allowList == nil {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(allowList))
+	for _, entry := range allowList {
+		if port, err := strconv.ParseUint(entry, 10, 32); err == nil {
+			ids = append(ids, winio.VsockServiceID(uint32(port)).String())
+			continue
+		}
+		g, err := guid.FromString(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hvsocket allow list entry %q: not a service ID or vsock port", entry)
+		}
+		ids = append(ids, g.String())
+	}
+	return ids, nil
+}
+
+// configureHvSocketFirewall has the guest refuse inbound hvsocket connections
+// to services that are not in the allow list, if there is one. The vsock ports
+// of the port forwards are always allowed. It is called again whenever the
+// allow list changes or the GCS restarts, replacing the previous allow list.
+func (uvm *UtilityVM) configureHvSocketFirewall(ctx context.Context) error {
+	uvm.m.Lock()
+	ids := uvm.hvsocketFirewallL()
+	uvm.m.Unlock()
+	if ids == nil {
+		return nil
+	}
+	request := &hcsschema.ModifySettingRequest{
+		GuestRequest: guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypeHvSocketFirewall,
+			RequestType:  requesttype.Add,
+			Settings: guestrequest.LCOWHvSocketFirewall{
+				AllowedServiceIDs: ids,
+			},
+		},
+	}
+	if err := uvm.modify(ctx, request); err != nil {
+		return fmt.Errorf("failed to configure hvsocket firewall: %s", err)
+	}
+	return nil
+}
+
+// hvsocketFirewallL returns the service IDs that the hvsocket firewall of the
+// guest allows, or nil if there is no firewall. `uvm.m` must be held.
+func (uvm *UtilityVM) hvsocketFirewallL() []string {
+	if uvm.hvsocketAllowList == nil {
+		return nil
+	}
+	ids := append([]string(nil), uvm.hvsocketAllowList...)
+	for _, port := range uvm.forwardedPorts {
+		ids = append(ids, winio.VsockServiceID(firstPortForwardVsockPort+uint32(port)).String())
+	}
+	return ids
+}
+
+// allowHvSocketService adds `id` to the allow list of the hvsocket firewall of
+// the guest, if there is one.
+func (uvm *UtilityVM) allowHvSocketService(ctx context.Context, id string) error {
+	uvm.m.Lock()
+	ids := uvm.hvsocketFirewallL()
+	if ids == nil {
+		uvm.m.Unlock()
+		return nil
+	}
+	for _, allowed := range ids {
+		if allowed == id {
+			uvm.m.Unlock()
+			return nil
+		}
+	}
+	uvm.hvsocketAllowList = append(uvm.hvsocketAllowList, id)
+	uvm.m.Unlock()
+	return uvm.configureHvSocketFirewall(ctx)
+}
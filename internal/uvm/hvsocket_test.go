@@ -0,0 +1,24 @@
+package uvm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Microsoft/go-winio"
+)
+
+func Test_HvSocketFirewall_AllowsPortForwards(t *testing.T) {
+	uvm := &UtilityVM{forwardedPorts: []uint16{8080}}
+	if ids := uvm.hvsocketFirewallL(); ids != nil {
+		t.Fatalf("expected no firewall without an allow list, got: %v", ids)
+	}
+
+	uvm.hvsocketAllowList = []string{winio.VsockServiceID(5000).String()}
+	expected := []string{
+		winio.VsockServiceID(5000).String(),
+		winio.VsockServiceID(firstPortForwardVsockPort + 8080).String(),
+	}
+	if ids := uvm.hvsocketFirewallL(); !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected allowed service IDs %v, got: %v", expected, ids)
+	}
+}
@@ -2,9 +2,12 @@ package uvm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/osversion"
 )
 
 const (
@@ -12,9 +15,17 @@ const (
 	bytesPerMB   = 1024 * 1024
 )
 
+// memoryUpdateTimeout is the time that UpdateMemory waits for the memory
+// assigned to a physically backed UVM to reach its new size.
+const memoryUpdateTimeout = 30 * time.Second
+
 // UpdateMemory makes a call to the VM's orchestrator to update the VM's size in MB
 // Internally, HCS will get the number of pages this corresponds to and attempt to assign
 // pages to numa nodes evenly
+//
+// The memory of a physically backed UVM is assigned once the guest has added
+// or removed it, so for such a UVM UpdateMemory waits until the assigned
+// memory is the new size.
 func (uvm *UtilityVM) UpdateMemory(ctx context.Context, sizeInBytes uint64) error {
 	requestedSizeInMB := sizeInBytes / bytesPerMB
 	actual := uvm.normalizeMemorySize(ctx, requestedSizeInMB)
@@ -22,7 +33,29 @@ func (uvm *UtilityVM) UpdateMemory(ctx context.Context, sizeInBytes uint64) erro
 		ResourcePath: memoryResourcePath,
 		Settings:     actual,
 	}
-	return uvm.modify(ctx, req)
+	if err := uvm.modify(ctx, req); err != nil {
+		return err
+	}
+	if !uvm.physicallyBacked {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, memoryUpdateTimeout)
+	defer cancel()
+	for {
+		assigned, err := uvm.GetAssignedMemoryInBytes(ctx)
+		if err != nil {
+			return err
+		}
+		if assigned == actual*bytesPerMB {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("guest did not resize its memory to %d bytes, %d bytes are assigned: %s", actual*bytesPerMB, assigned, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
 }
 
 // GetAssignedMemoryInBytes returns the amount of assigned memory for the UVM in bytes
@@ -44,3 +77,69 @@ func (uvm *UtilityVM) GetAssignedMemoryInBytes(ctx context.Context) (uint64, err
 	memInBytes := pages * bytesPerPage
 	return memInBytes, nil
 }
+
+// MemoryHints are the dynamic memory hints enabled for a UVM.
+type MemoryHints struct {
+	HotHint         bool
+	ColdHint        bool
+	ColdDiscardHint bool
+}
+
+// MemoryHints returns the dynamic memory hints currently enabled for the UVM.
+func (uvm *UtilityVM) MemoryHints() MemoryHints {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	return uvm.memoryHints
+}
+
+// MemoryHintSettings are the dynamic memory hints of a running UVM that can be
+// changed with `UpdateMemoryHints`. A nil field is left unchanged.
+type MemoryHintSettings struct {
+	// EnableHotHint allows the guest to hint to the host when it needs more
+	// memory. Not supported on physically backed UVMs.
+	EnableHotHint *bool
+	// EnableColdHint allows the guest to hint to the host when memory it is not
+	// using can be reclaimed.
+	EnableColdHint *bool
+	// EnableColdDiscardHint allows the guest to trim non-zeroed pages from its
+	// working set.
+	EnableColdDiscardHint *bool
+}
+
+// UpdateMemoryHints changes the dynamic memory hints of the running UVM, so
+// that density can be traded against performance without recreating it.
+//
+// The HCS schema in use has no setting for the dynamic memory buffer
+// percentage so it cannot be changed here.
+func (uvm *UtilityVM) UpdateMemoryHints(ctx context.Context, settings *MemoryHintSettings) error {
+	if uvm.physicallyBacked && settings.EnableHotHint != nil && *settings.EnableHotHint {
+		return errors.New("EnableHotHint is not supported on physically backed VMs")
+	}
+	if settings.EnableColdDiscardHint != nil && *settings.EnableColdDiscardHint && osversion.Get().Build < 18967 {
+		return errors.New("EnableColdDiscardHint is not supported on builds older than 18967")
+	}
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	for _, h := range []struct {
+		path    string
+		value   *bool
+		current *bool
+	}{
+		{memoryHotHintResourcePath, settings.EnableHotHint, &uvm.memoryHints.HotHint},
+		{memoryColdHintResourcePath, settings.EnableColdHint, &uvm.memoryHints.ColdHint},
+		{memoryDiscardHintResourcePath, settings.EnableColdDiscardHint, &uvm.memoryHints.ColdDiscardHint},
+	} {
+		if h.value == nil {
+			continue
+		}
+		req := &hcsschema.ModifySettingRequest{
+			ResourcePath: h.path,
+			Settings:     *h.value,
+		}
+		if err := uvm.modify(ctx, req); err != nil {
+			return fmt.Errorf("failed to update %s: %s", h.path, err)
+		}
+		*h.current = *h.value
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package uvm
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_UpdateMemoryHints_Rejected(t *testing.T) {
+	enable := true
+	uvm := &UtilityVM{physicallyBacked: true}
+	err := uvm.UpdateMemoryHints(context.Background(), &MemoryHintSettings{EnableHotHint: &enable, EnableColdHint: &enable})
+	if err == nil || err.Error() != "EnableHotHint is not supported on physically backed VMs" {
+		t.Fatal(err)
+	}
+	// A rejected update must not change any hint.
+	if hints := uvm.MemoryHints(); hints != (MemoryHints{}) {
+		t.Fatalf("expected no hints to be enabled, got %+v", hints)
+	}
+}
+
+func Test_UpdateMemoryHints_NoChanges(t *testing.T) {
+	// Nothing is sent to the UVM when no hint is set.
+	uvm := &UtilityVM{memoryHints: MemoryHints{HotHint: true}}
+	if err := uvm.UpdateMemoryHints(context.Background(), &MemoryHintSettings{}); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if hints := uvm.MemoryHints(); hints != (MemoryHints{HotHint: true}) {
+		t.Fatalf("expected the hints to be unchanged, got %+v", hints)
+	}
+}
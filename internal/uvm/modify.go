@@ -7,10 +7,14 @@ import (
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/pkg/errors"
 )
 
 // Modify modifies the compute system by sending a request to HCS.
 func (uvm *UtilityVM) modify(ctx context.Context, doc *hcsschema.ModifySettingRequest) (err error) {
+	if uvm.IsPaused() {
+		return ErrPaused
+	}
 	if doc.GuestRequest == nil || uvm.gc == nil {
 		return uvm.hcsSystem.Modify(ctx, doc)
 	}
@@ -34,7 +38,7 @@ func (uvm *UtilityVM) modify(ctx context.Context, doc *hcsschema.ModifySettingRe
 	}
 	err = uvm.gc.Modify(ctx, doc.GuestRequest)
 	if err != nil {
-		return fmt.Errorf("guest modify: %s", err)
+		return errors.Wrap(err, "guest modify")
 	}
 	if doc.ResourcePath != "" && doc.RequestType == requesttype.Remove {
 		err = uvm.hcsSystem.Modify(ctx, &hostdoc)
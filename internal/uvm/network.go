@@ -2,9 +2,12 @@ package uvm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strings"
 
 	"github.com/Microsoft/hcsshim/internal/ncproxyttrpc"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/hns"
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/ownership"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/osversion"
@@ -292,6 +296,9 @@ func (endpoints *NetworkEndpoints) Release(ctx context.Context) error {
 				"netID":      endpoints.Namespace,
 			}).Warn("removing endpoint from namespace: does not exist")
 		}
+		if err := ownership.ReleaseEndpoint(endpoint); err != nil {
+			log.G(ctx).WithError(err).WithField("endpointID", endpoint).Warning("failed to remove the owner of the endpoint")
+		}
 	}
 	endpoints.EndpointIDs = nil
 	err := hns.RemoveNamespace(endpoints.Namespace)
@@ -526,6 +533,96 @@ func getNetworkModifyRequest(adapterID string, requestType string, settings inte
 	}
 }
 
+// guestInterfaceName returns the name of the network interface in an LCOW
+// guest for the NIC with `nicID`. Linux limits interface names to 15
+// characters so only a prefix of the ID is used.
+func guestInterfaceName(nicID string) string {
+	name := "eth-" + strings.ReplaceAll(nicID, "-", "")
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	return name
+}
+
+// NetworkInterfaceNames returns a map of HNS endpoint ID to the network
+// interface name in the guest for every NIC added to the network namespace
+// matching `nsID`. This is only supported for LCOW.
+//
+// If no network namespace matches `nsID` returns `ErrNetNSNotFound`.
+func (uvm *UtilityVM) NetworkInterfaceNames(nsID string) (map[string]string, error) {
+	if uvm.operatingSystem != "linux" {
+		return nil, errors.New("guest network interface names are only supported for LCOW")
+	}
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	ns, ok := uvm.namespaces[nsID]
+	if !ok {
+		return nil, ErrNetNSNotFound
+	}
+	names := make(map[string]string, len(ns.nics))
+	for endpointID, ninfo := range ns.nics {
+		if ninfo == nil {
+			continue
+		}
+		names[endpointID] = guestInterfaceName(ninfo.ID)
+	}
+	return names, nil
+}
+
+// getNetworkIsolation returns the VLAN and VSID tags set by the policies on
+// `endpoint`. A tag that is not set is returned as `0`.
+func getNetworkIsolation(endpoint *hns.HNSEndpoint) (vlan, vsid uint32, err error) {
+	for _, raw := range endpoint.Policies {
+		// IsolationPolicy is a superset of VlanPolicy and VsidPolicy.
+		var policy hns.IsolationPolicy
+		if err := json.Unmarshal(raw, &policy); err != nil {
+			return 0, 0, fmt.Errorf("failed to parse policy on endpoint %s: %s", endpoint.Id, err)
+		}
+		switch policy.Type {
+		case hns.VLAN:
+			vlan = uint32(policy.VLAN)
+		case hns.VSID:
+			vsid = uint32(policy.VSID)
+		case hns.Isolation:
+			if policy.VLAN != 0 {
+				vlan = uint32(policy.VLAN)
+			}
+			if policy.VSID != 0 {
+				vsid = uint32(policy.VSID)
+			}
+		}
+	}
+	return vlan, vsid, nil
+}
+
+// getSecondaryIPAddresses returns the IP configurations of `endpoint` other
+// than its primary IPv4 and IPv6 addresses. The HNS v1 endpoint only carries a
+// single address of each family so the full set is queried through HCN.
+func getSecondaryIPAddresses(endpoint *hns.HNSEndpoint) ([]guestrequest.LCOWIPAddress, error) {
+	hcnEndpoint, err := hcn.GetEndpointByID(endpoint.Id)
+	if err != nil {
+		return nil, err
+	}
+	return secondaryIPAddresses(endpoint, hcnEndpoint.IpConfigurations), nil
+}
+
+// secondaryIPAddresses returns the addresses of `ipConfigs` other than the
+// primary IPv4 and IPv6 addresses of `endpoint`. Invalid addresses are skipped.
+func secondaryIPAddresses(endpoint *hns.HNSEndpoint, ipConfigs []hcn.IpConfig) []guestrequest.LCOWIPAddress {
+	var secondary []guestrequest.LCOWIPAddress
+	for _, ipConfig := range ipConfigs {
+		ip := net.ParseIP(ipConfig.IpAddress)
+		if ip == nil || ip.Equal(endpoint.IPAddress) || ip.Equal(endpoint.IPv6Address) {
+			continue
+		}
+		secondary = append(secondary, guestrequest.LCOWIPAddress{
+			IPAddress:    ipConfig.IpAddress,
+			PrefixLength: ipConfig.PrefixLength,
+		})
+	}
+	return secondary
+}
+
 // addNIC adds a nic to the Utility VM.
 func (uvm *UtilityVM) addNIC(ctx context.Context, id string, endpoint *hns.HNSEndpoint) error {
 	// First a pre-add. This is a guest-only request and is only done on Windows.
@@ -567,21 +664,14 @@ func (uvm *UtilityVM) addNIC(ctx context.Context, id string, endpoint *hns.HNSEn
 	} else {
 		// Verify this version of LCOW supports Network HotAdd
 		if uvm.isNetworkNamespaceSupported() {
+			adapter, err := uvm.lcowNetworkAdapter(ctx, id, endpoint)
+			if err != nil {
+				return err
+			}
 			request.GuestRequest = guestrequest.GuestRequest{
 				ResourceType: guestrequest.ResourceTypeNetwork,
 				RequestType:  requesttype.Add,
-				Settings: &guestrequest.LCOWNetworkAdapter{
-					NamespaceID:     endpoint.Namespace.ID,
-					ID:              id,
-					MacAddress:      endpoint.MacAddress,
-					IPAddress:       endpoint.IPAddress.String(),
-					PrefixLength:    endpoint.PrefixLength,
-					GatewayAddress:  endpoint.GatewayAddress,
-					DNSSuffix:       endpoint.DNSSuffix,
-					DNSServerList:   endpoint.DNSServerList,
-					EnableLowMetric: endpoint.EnableLowMetric,
-					EncapOverhead:   endpoint.EncapOverhead,
-				},
+				Settings:     adapter,
 			}
 		}
 	}
@@ -589,10 +679,85 @@ func (uvm *UtilityVM) addNIC(ctx context.Context, id string, endpoint *hns.HNSEn
 	if err := uvm.modify(ctx, &request); err != nil {
 		return err
 	}
-
+	uvm.claimEndpoint(ctx, endpoint.Id)
 	return nil
 }
 
+// lcowNetworkAdapter returns the guest settings of the adapter `id` for
+// `endpoint`. With guest DHCP the guest's DHCP client configures the adapter,
+// so none of the static addresses of the endpoint are sent.
+func (uvm *UtilityVM) lcowNetworkAdapter(ctx context.Context, id string, endpoint *hns.HNSEndpoint) (*guestrequest.LCOWNetworkAdapter, error) {
+	adapter := &guestrequest.LCOWNetworkAdapter{
+		NamespaceID:     endpoint.Namespace.ID,
+		ID:              id,
+		InterfaceName:   guestInterfaceName(id),
+		MacAddress:      endpoint.MacAddress,
+		DNSSuffix:       endpoint.DNSSuffix,
+		DNSServerList:   endpoint.DNSServerList,
+		EnableLowMetric: endpoint.EnableLowMetric,
+		EncapOverhead:   endpoint.EncapOverhead,
+	}
+	adapter.DisableIPv6RouterAdvertisements = uvm.disableIPv6RA
+	if uvm.DNSProxyEnabled() {
+		adapter.HostDNSProxyPort = dnsProxyVsockPort
+	}
+	vlan, vsid, err := getNetworkIsolation(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	adapter.VlanID = vlan
+	adapter.VSID = vsid
+	if uvm.guestDHCP {
+		// Addressing is managed externally.
+		adapter.EnableDHCP = true
+		return adapter, nil
+	}
+	adapter.IPAddress = endpoint.IPAddress.String()
+	adapter.PrefixLength = endpoint.PrefixLength
+	adapter.GatewayAddress = endpoint.GatewayAddress
+	if len(endpoint.IPv6Address) != 0 {
+		adapter.IPv6Address = endpoint.IPv6Address.String()
+		adapter.IPv6PrefixLength = endpoint.IPv6PrefixLength
+		adapter.IPv6GatewayAddress = endpoint.GatewayAddressV6
+	}
+	// The secondary addresses are optional, so an endpoint that HCN can not
+	// be queried for is added with its primary addresses only, as before they
+	// were supported.
+	secondaryIPs, err := getSecondaryIPAddresses(endpoint)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("endpoint", endpoint.Id).Warning("failed to query secondary IP addresses of endpoint")
+	}
+	adapter.SecondaryIPAddresses = secondaryIPs
+	return adapter, nil
+}
+
+// claimEndpoint records the endpoint `id` as owned by the owner of the utility
+// VM, so that it is cleaned up with the utility VM if its owner dies. The
+// record is only used to find orphans, so a failure is logged.
+func (uvm *UtilityVM) claimEndpoint(ctx context.Context, id string) {
+	if err := ownership.ClaimEndpoint(id, uvm.owner); err != nil {
+		log.G(ctx).WithError(err).WithField("endpoint", id).Warning("failed to record the owner of the endpoint")
+	}
+}
+
+func (uvm *UtilityVM) releaseEndpoint(ctx context.Context, id string) {
+	if err := ownership.ReleaseEndpoint(id); err != nil {
+		log.G(ctx).WithError(err).WithField("endpoint", id).Warning("failed to remove the owner of the endpoint")
+	}
+}
+
+// releaseEndpoints removes the owner records of the endpoints of the NICs of
+// the closed utility VM.
+func (uvm *UtilityVM) releaseEndpoints(ctx context.Context) {
+	for _, ns := range uvm.namespaces {
+		for _, ninfo := range ns.nics {
+			if ninfo != nil {
+				uvm.releaseEndpoint(ctx, ninfo.Endpoint.Id)
+			}
+		}
+	}
+}
+
 func (uvm *UtilityVM) removeNIC(ctx context.Context, id string, endpoint *hns.HNSEndpoint) error {
 	request := hcsschema.ModifySettingRequest{
 		RequestType:  requesttype.Remove,
@@ -628,6 +793,7 @@ func (uvm *UtilityVM) removeNIC(ctx context.Context, id string, endpoint *hns.HN
 	if err := uvm.modify(ctx, &request); err != nil {
 		return err
 	}
+	uvm.releaseEndpoint(ctx, endpoint.Id)
 	return nil
 }
 
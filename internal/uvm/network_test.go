@@ -0,0 +1,171 @@
+package uvm
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/hcn"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/hns"
+)
+
+func Test_GetNetworkIsolation(t *testing.T) {
+	endpoint := &hns.HNSEndpoint{
+		Id: t.Name(),
+		Policies: []json.RawMessage{
+			json.RawMessage(`{"Type":"OutBoundNAT"}`),
+			json.RawMessage(`{"Type":"VLAN","VLAN":100}`),
+			json.RawMessage(`{"Type":"VSID","VSID":4096}`),
+		},
+	}
+	vlan, vsid, err := getNetworkIsolation(endpoint)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if vlan != 100 {
+		t.Fatalf("expected VLAN 100, got: %d", vlan)
+	}
+	if vsid != 4096 {
+		t.Fatalf("expected VSID 4096, got: %d", vsid)
+	}
+}
+
+func Test_GetNetworkIsolation_NoPolicies(t *testing.T) {
+	vlan, vsid, err := getNetworkIsolation(&hns.HNSEndpoint{Id: t.Name()})
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if vlan != 0 || vsid != 0 {
+		t.Fatalf("expected untagged endpoint, got VLAN %d VSID %d", vlan, vsid)
+	}
+}
+
+func Test_LCOWNetworkAdapter_GuestDHCP(t *testing.T) {
+	uvm := &UtilityVM{guestDHCP: true}
+	endpoint := &hns.HNSEndpoint{
+		Id:               t.Name(),
+		Namespace:        &hns.Namespace{ID: "ns"},
+		MacAddress:       "00-15-5D-00-00-01",
+		IPAddress:        net.ParseIP("10.0.0.2"),
+		PrefixLength:     24,
+		GatewayAddress:   "10.0.0.1",
+		IPv6Address:      net.ParseIP("fd00::2"),
+		IPv6PrefixLength: 64,
+		GatewayAddressV6: "fd00::1",
+	}
+	adapter, err := uvm.lcowNetworkAdapter(context.Background(), "nic", endpoint)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if !adapter.EnableDHCP {
+		t.Fatal("expected DHCP to be enabled")
+	}
+	if adapter.IPAddress != "" || adapter.PrefixLength != 0 || adapter.GatewayAddress != "" {
+		t.Fatalf("expected no static IPv4 address, got: %+v", adapter)
+	}
+	if adapter.IPv6Address != "" || adapter.IPv6PrefixLength != 0 || adapter.IPv6GatewayAddress != "" {
+		t.Fatalf("expected no static IPv6 address, got: %+v", adapter)
+	}
+	if len(adapter.SecondaryIPAddresses) != 0 {
+		t.Fatalf("expected no secondary IP addresses, got: %+v", adapter.SecondaryIPAddresses)
+	}
+	if adapter.MacAddress != endpoint.MacAddress || adapter.NamespaceID != "ns" {
+		t.Fatalf("unexpected adapter: %+v", adapter)
+	}
+}
+
+func Test_GuestInterfaceName(t *testing.T) {
+	for _, tc := range []struct {
+		nicID string
+		name  string
+	}{
+		// The first 11 hex characters of the ID, without its dashes.
+		{"2c8f9e46-3a1b-4d5e-9f60-7a8b9c0d1e2f", "eth-2c8f9e463a1"},
+		{"2c8f9e463a1", "eth-2c8f9e463a1"},
+		// IDs shorter than 11 characters are used as is.
+		{"2c8f-9e", "eth-2c8f9e"},
+		{"", "eth-"},
+	} {
+		if name := guestInterfaceName(tc.nicID); name != tc.name {
+			t.Fatalf("expected %q for NIC %q, got: %q", tc.name, tc.nicID, name)
+		}
+	}
+}
+
+func Test_NetworkInterfaceNames(t *testing.T) {
+	uvm := &UtilityVM{
+		operatingSystem: "linux",
+		namespaces: map[string]*namespaceInfo{
+			"ns": {
+				nics: map[string]*nicInfo{
+					"endpoint1": {ID: "2c8f9e46-3a1b-4d5e-9f60-7a8b9c0d1e2f"},
+					"endpoint2": {ID: "0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"},
+				},
+			},
+		},
+	}
+	names, err := uvm.NetworkInterfaceNames("ns")
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	expected := map[string]string{
+		"endpoint1": "eth-2c8f9e463a1",
+		"endpoint2": "eth-0a1b2c3d4e5",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got: %v", expected, names)
+	}
+
+	if _, err := uvm.NetworkInterfaceNames("other"); err != ErrNetNSNotFound {
+		t.Fatalf("expected %s, got: %v", ErrNetNSNotFound, err)
+	}
+
+	uvm.operatingSystem = "windows"
+	if _, err := uvm.NetworkInterfaceNames("ns"); err == nil {
+		t.Fatal("expected an error for a WCOW UVM")
+	}
+}
+
+func Test_SecondaryIPAddresses(t *testing.T) {
+	endpoint := &hns.HNSEndpoint{
+		Id:          t.Name(),
+		IPAddress:   net.ParseIP("10.0.0.2"),
+		IPv6Address: net.ParseIP("fd00::2"),
+	}
+	for _, tc := range []struct {
+		name      string
+		ipConfigs []hcn.IpConfig
+		expected  []guestrequest.LCOWIPAddress
+	}{
+		{"none", nil, nil},
+		{
+			"primary only",
+			[]hcn.IpConfig{{IpAddress: "10.0.0.2", PrefixLength: 24}, {IpAddress: "fd00::2", PrefixLength: 64}},
+			nil,
+		},
+		{
+			"secondary",
+			[]hcn.IpConfig{
+				{IpAddress: "10.0.0.2", PrefixLength: 24},
+				{IpAddress: "10.0.1.5", PrefixLength: 32},
+				{IpAddress: "fd00::5", PrefixLength: 128},
+			},
+			[]guestrequest.LCOWIPAddress{
+				{IPAddress: "10.0.1.5", PrefixLength: 32},
+				{IPAddress: "fd00::5", PrefixLength: 128},
+			},
+		},
+		{
+			"invalid",
+			[]hcn.IpConfig{{IpAddress: "10.0.1"}, {IpAddress: ""}, {IpAddress: "10.0.1.6", PrefixLength: 24}},
+			[]guestrequest.LCOWIPAddress{{IPAddress: "10.0.1.6", PrefixLength: 24}},
+		},
+	} {
+		if actual := secondaryIPAddresses(endpoint, tc.ipConfigs); !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("%s: expected %+v, got: %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
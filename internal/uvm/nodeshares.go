@@ -0,0 +1,90 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	// LCOWNodeSharePathFmt is the path format in the LCOW UVM where node
+	// shares are mounted.
+	LCOWNodeSharePathFmt = "/run/nodeshares/%s"
+	// NodeSharePrefix is the prefix of the source of a container mount of a
+	// node share, followed by the name of the share and an optional path in
+	// the share, such as `nodeshare://certs/ca.pem`.
+	NodeSharePrefix = "nodeshare://"
+)
+
+// ErrNodeShareNotFound is returned when a container mounts a node share that
+// was not added to its UVM.
+var ErrNodeShareNotFound = errors.New("node share not found")
+
+// NodeShare is a read-only host directory that is shared into the UVM when it
+// starts, such as a CA bundle or time zone data that every pod of the node
+// uses, so that containers can mount it without a share of their own.
+type NodeShare struct {
+	// Name is the name that containers mount the share by.
+	Name string
+	// HostPath is the absolute path of the host directory.
+	HostPath string
+}
+
+var nodeShareNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// verifyNodeShares returns an error if any of `shares` is not a uniquely named
+// host directory.
+func verifyNodeShares(shares []NodeShare) error {
+	names := make(map[string]bool)
+	for _, s := range shares {
+		if !nodeShareNameRegex.MatchString(s.Name) {
+			return fmt.Errorf("invalid node share name: '%s'", s.Name)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("duplicate node share name: '%s'", s.Name)
+		}
+		names[s.Name] = true
+		if !filepath.IsAbs(s.HostPath) {
+			return fmt.Errorf("node share '%s' path '%s' is not absolute", s.Name, s.HostPath)
+		}
+		st, err := os.Stat(s.HostPath)
+		if err != nil {
+			return fmt.Errorf("node share '%s': %s", s.Name, err)
+		}
+		if !st.IsDir() {
+			return fmt.Errorf("node share '%s' path '%s' is not a directory", s.Name, s.HostPath)
+		}
+	}
+	return nil
+}
+
+// addNodeShares shares the node share directories read-only into the UVM, as
+// Plan9 shares mounted at `LCOWNodeSharePathFmt` for LCOW and as VSMB shares
+// for WCOW.
+func (uvm *UtilityVM) addNodeShares(ctx context.Context) error {
+	for _, s := range uvm.nodeShares {
+		var err error
+		if uvm.operatingSystem == "windows" {
+			_, err = uvm.AddVSMB(ctx, s.HostPath, uvm.DefaultVSMBOptions(true))
+		} else {
+			_, err = uvm.AddPlan9(ctx, s.HostPath, fmt.Sprintf(LCOWNodeSharePathFmt, s.Name), true, false, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add node share '%s': %s", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// NodeShare returns the node share `name`.
+func (uvm *UtilityVM) NodeShare(name string) (NodeShare, error) {
+	for _, s := range uvm.nodeShares {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return NodeShare{}, fmt.Errorf("%q: %w", name, ErrNodeShareNotFound)
+}
@@ -0,0 +1,83 @@
+package uvm
+
+import (
+	"errors"
+	"fmt"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/schemaversion"
+)
+
+// NUMANode is a virtual NUMA node of a UVM.
+type NUMANode struct {
+	// ProcessorCount is the number of vCPUs of the node.
+	ProcessorCount uint32
+	// MemorySizeInMB is the memory of the node, a multiple of 2MB.
+	MemorySizeInMB uint64
+}
+
+// numaTopology returns the virtual NUMA topology with `nodes` of a UVM with
+// `processorCount` vCPUs and `memorySizeInMB` of memory, or nil if `nodes` is
+// empty. Each node is its own virtual socket.
+func numaTopology(nodes []NUMANode, processorCount int32, memorySizeInMB uint64, allowOvercommit bool) (*hcsschema.Numa, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	if allowOvercommit {
+		return nil, errors.New("virtual NUMA nodes require a physically backed UVM")
+	}
+	if len(nodes) > 64 {
+		return nil, fmt.Errorf("at most 64 virtual NUMA nodes are supported, got %d", len(nodes))
+	}
+	numa := &hcsschema.Numa{
+		VirtualNodeCount: uint8(len(nodes)),
+	}
+	var processors, memory uint64
+	for i, n := range nodes {
+		if n.ProcessorCount == 0 || n.MemorySizeInMB == 0 {
+			return nil, fmt.Errorf("virtual NUMA node %d must have vCPUs and memory", i)
+		}
+		if n.MemorySizeInMB%2 != 0 {
+			return nil, fmt.Errorf("memory of virtual NUMA node %d must be a multiple of 2MB", i)
+		}
+		processors += uint64(n.ProcessorCount)
+		memory += n.MemorySizeInMB
+		numa.Settings = append(numa.Settings, hcsschema.NumaSetting{
+			VirtualNodeNumber:   uint32(i),
+			VirtualSocketNumber: uint32(i),
+			CountOfProcessors:   n.ProcessorCount,
+			CountOfMemoryBlocks: n.MemorySizeInMB,
+		})
+	}
+	if processors != uint64(processorCount) {
+		return nil, fmt.Errorf("virtual NUMA nodes have %d vCPUs but the UVM has %d", processors, processorCount)
+	}
+	if memory != memorySizeInMB {
+		return nil, fmt.Errorf("virtual NUMA nodes have %dMB of memory but the UVM has %dMB", memory, memorySizeInMB)
+	}
+	return numa, nil
+}
+
+// numaSchemaVersion returns the schema version of the document of a UVM with
+// the virtual NUMA topology `numa`. The Numa settings of the compute topology
+// were added in schema 2.4, so a UVM with virtual NUMA nodes fails to create on
+// a host that does not support it rather than getting a flat topology.
+func numaSchemaVersion(numa *hcsschema.Numa) (*hcsschema.Version, error) {
+	if numa == nil {
+		return schemaversion.SchemaV21(), nil
+	}
+	sv := schemaversion.SchemaV24()
+	if err := schemaversion.IsSupported(sv); err != nil {
+		return nil, fmt.Errorf("virtual NUMA nodes require schema %s: %s", schemaversion.String(sv), err)
+	}
+	return sv, nil
+}
+
+// NUMANodeCount returns the number of virtual NUMA nodes of the UVM, which is
+// one for a UVM without a virtual NUMA topology.
+func (uvm *UtilityVM) NUMANodeCount() uint32 {
+	if uvm.numaNodeCount == 0 {
+		return 1
+	}
+	return uvm.numaNodeCount
+}
@@ -0,0 +1,101 @@
+package uvm
+
+import (
+	"testing"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+func TestNUMATopology(t *testing.T) {
+	nodes := []NUMANode{
+		{ProcessorCount: 2, MemorySizeInMB: 1024},
+		{ProcessorCount: 4, MemorySizeInMB: 2048},
+	}
+	numa, err := numaTopology(nodes, 6, 3072, false)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if numa.VirtualNodeCount != 2 || len(numa.Settings) != 2 {
+		t.Fatalf("expected 2 virtual nodes, got: %+v", numa)
+	}
+	expected := hcsschema.NumaSetting{
+		VirtualNodeNumber:   1,
+		VirtualSocketNumber: 1,
+		CountOfProcessors:   4,
+		CountOfMemoryBlocks: 2048,
+	}
+	if numa.Settings[1] != expected {
+		t.Fatalf("expected node setting %+v, got: %+v", expected, numa.Settings[1])
+	}
+}
+
+func TestNUMATopologyNoNodes(t *testing.T) {
+	numa, err := numaTopology(nil, 2, 1024, true)
+	if err != nil || numa != nil {
+		t.Fatalf("expected no topology, got: %+v, %v", numa, err)
+	}
+}
+
+func TestNUMATopologyInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		nodes           []NUMANode
+		processorCount  int32
+		memorySizeInMB  uint64
+		allowOvercommit bool
+	}{
+		"overcommit": {
+			nodes:           []NUMANode{{ProcessorCount: 2, MemorySizeInMB: 1024}},
+			processorCount:  2,
+			memorySizeInMB:  1024,
+			allowOvercommit: true,
+		},
+		"empty node": {
+			nodes:          []NUMANode{{ProcessorCount: 2, MemorySizeInMB: 1024}, {}},
+			processorCount: 2,
+			memorySizeInMB: 1024,
+		},
+		"odd memory": {
+			nodes:          []NUMANode{{ProcessorCount: 2, MemorySizeInMB: 1023}},
+			processorCount: 2,
+			memorySizeInMB: 1023,
+		},
+		"processor mismatch": {
+			nodes:          []NUMANode{{ProcessorCount: 2, MemorySizeInMB: 1024}},
+			processorCount: 4,
+			memorySizeInMB: 1024,
+		},
+		"memory mismatch": {
+			nodes:          []NUMANode{{ProcessorCount: 2, MemorySizeInMB: 1024}},
+			processorCount: 2,
+			memorySizeInMB: 2048,
+		},
+		"too many nodes": {
+			nodes:          make([]NUMANode, 65),
+			processorCount: 65,
+			memorySizeInMB: 65 * 2,
+		},
+	} {
+		if _, err := numaTopology(tc.nodes, tc.processorCount, tc.memorySizeInMB, tc.allowOvercommit); err == nil {
+			t.Fatalf("%s: expected topology to be rejected", name)
+		}
+	}
+}
+
+func TestNUMANodeCount(t *testing.T) {
+	if count := (&UtilityVM{}).NUMANodeCount(); count != 1 {
+		t.Fatalf("expected 1 node for a flat topology, got: %d", count)
+	}
+	if count := (&UtilityVM{numaNodeCount: 2}).NUMANodeCount(); count != 2 {
+		t.Fatalf("expected 2 nodes, got: %d", count)
+	}
+}
+
+func TestNUMASchemaVersionNoNodes(t *testing.T) {
+	sv, err := numaSchemaVersion(nil)
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if sv.Major != 2 || sv.Minor != 1 {
+		t.Fatalf("expected schema 2.1 without virtual NUMA nodes, got: %+v", sv)
+	}
+}
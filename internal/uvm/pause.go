@@ -0,0 +1,59 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	"go.opencensus.io/trace"
+)
+
+// ErrPaused is returned by the operations on a paused utility VM that need it
+// to run, which fail rather than wait for the utility VM to be resumed.
+var ErrPaused = errors.New("utility VM is paused")
+
+// Pause pauses the utility VM, freezing its guest and containers until it is
+// resumed. While paused, adding or removing devices and creating containers
+// and processes fail with ErrPaused. Pausing a paused utility VM does nothing.
+func (uvm *UtilityVM) Pause(ctx context.Context) (err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Pause")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute(logfields.UVMID, uvm.id))
+
+	// Mark the utility VM paused first so that no new operation starts while
+	// it is being paused.
+	if !atomic.CompareAndSwapInt32(&uvm.paused, 0, 1) {
+		return nil
+	}
+	if err := uvm.hcsSystem.Pause(ctx); err != nil {
+		atomic.StoreInt32(&uvm.paused, 0)
+		return err
+	}
+	return nil
+}
+
+// Resume resumes the utility VM paused by Pause. Resuming a utility VM that is
+// not paused does nothing.
+func (uvm *UtilityVM) Resume(ctx context.Context) (err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Resume")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute(logfields.UVMID, uvm.id))
+
+	if atomic.LoadInt32(&uvm.paused) == 0 {
+		return nil
+	}
+	if err := uvm.hcsSystem.Resume(ctx); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&uvm.paused, 0)
+	return nil
+}
+
+// IsPaused returns true if the utility VM is paused by Pause.
+func (uvm *UtilityVM) IsPaused() bool {
+	return atomic.LoadInt32(&uvm.paused) != 0
+}
@@ -0,0 +1,388 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/gcs"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/regstate"
+	"github.com/Microsoft/hcsshim/internal/reservation"
+	"go.opencensus.io/trace"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	persistentConfigRoot = "PersistentUVM"
+	persistentConfigKey  = "Config"
+)
+
+// persistentAttachTimeout is the time that Attach waits for the GCS of a
+// detached utility VM to connect.
+const persistentAttachTimeout = 2 * time.Minute
+
+// persistentConfig is the state of a detached persistent utility VM that is
+// needed to attach to it. It is stored in the registry from Detach until the
+// next Attach.
+//
+// The counters are kept so that the resources added after an attach do not
+// reuse the names of resources that the guest may still know of.
+type persistentConfig struct {
+	Owner                          string
+	ProcessorCount                 int32
+	NUMANodeCount                  uint32
+	PhysicallyBacked               bool
+	LargePages                     bool
+	DevicesPhysicallyBacked        bool
+	VPMemMaxCount                  uint32
+	VPMemMaxSizeBytes              uint64
+	VPMemRootFSFile                string
+	SCSIControllerCount            uint32
+	ContainerCounter               uint64
+	Plan9Counter                   uint64
+	MountCounter                   uint64
+	SocketRelayCounter             uint32
+	CPUGroupID                     string
+	AffinityCPUGroupID             string
+	ComputeAgentSecurityDescriptor string
+	GuestDHCP                      bool
+	DisableIPv6RA                  bool
+	ForwardedPorts                 []uint16
+	HvSocketAllowList              []string
+	OCIHooksPath                   string
+	ReadOnlyRootfs                 bool
+	CoreScheduling                 bool
+	CoreSchedulingGroups           map[string]string
+	TPMEnabled                     bool
+	TPMStateFile                   string
+	NodeShares                     []NodeShare
+	GCSWatchdogTimeout             time.Duration
+	GCSRecoveryTimeout             time.Duration
+	Plan9ChangeNotify              bool
+	Plan9MSize                     uint32
+	Plan9Cache                     guestrequest.Plan9Cache
+	ScratchTrimInterval            time.Duration
+}
+
+// IsPersistent returns true if the utility VM can be detached.
+func (uvm *UtilityVM) IsPersistent() bool {
+	return uvm.persistent
+}
+
+// SetReservation sets the host capacity reservation that is detached along with
+// the utility VM, so that it is kept for the process that attaches next.
+func (uvm *UtilityVM) SetReservation(r *reservation.Reservation) {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	uvm.reservation = r
+}
+
+// Detach releases the utility VM from this process without terminating it, so
+// that another process can take it over with Attach. All containers and the
+// devices added for them must have been removed. The GCS connection is closed,
+// and the guest is expected to restart the GCS so that it connects to the
+// process that attaches next.
+//
+// The host side services of the utility VM (port forwards and stats) stop
+// until it is attached. `uvm` must not be used once detached.
+func (uvm *UtilityVM) Detach(ctx context.Context) (err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Detach")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute(logfields.UVMID, uvm.id))
+
+	if !uvm.persistent {
+		return errors.New("only a persistent utility VM can be detached")
+	}
+
+	uvm.m.Lock()
+	config, err := uvm.persistentConfigL()
+	res := uvm.reservation
+	uvm.m.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := storePersistentConfig(uvm.id, config); err != nil {
+		return fmt.Errorf("failed to store persistent utility VM config: %s", err)
+	}
+	if res != nil {
+		if err := res.Detach(); err != nil {
+			log.G(ctx).WithError(err).Warning("failed to detach capacity reservation of utility VM")
+		}
+	}
+
+	windows.Close(uvm.vmmemProcess)
+	for _, pf := range uvm.portForwards {
+		pf.listener.Close()
+		pf.guestListener.Close()
+	}
+	uvm.portForwards = nil
+	if err := uvm.CloseGCSConnection(); err != nil {
+		log.G(ctx).WithError(err).Warning("failed to close GCS connection of detached utility VM")
+	}
+	log.G(ctx).WithField(logfields.UVMID, uvm.id).Info("detached persistent utility VM")
+	return uvm.hcsSystem.Close()
+}
+
+// persistentConfigL returns the persistent config of the utility VM, or an
+// error if it still has devices that were added for containers. `uvm.m` must
+// be held.
+func (uvm *UtilityVM) persistentConfigL() (*persistentConfig, error) {
+	if len(uvm.namespaces) != 0 || len(uvm.vpciDevices) != 0 || len(uvm.volumes) != 0 {
+		return nil, errors.New("a utility VM with network namespaces, assigned devices or volumes can not be detached")
+	}
+	for _, controller := range uvm.scsiLocations {
+		for _, sm := range controller {
+			if sm != nil {
+				return nil, fmt.Errorf("a utility VM with the SCSI disk %s attached can not be detached", sm.HostPath)
+			}
+		}
+	}
+	var rootfs string
+	for i, vi := range uvm.vpmemDevices {
+		if vi == nil {
+			continue
+		}
+		// Device 0 may hold the root file system that the UVM booted from.
+		if i != 0 || vi.uvmPath != "/" {
+			return nil, fmt.Errorf("a utility VM with the VPMem device %s attached can not be detached", vi.hostPath)
+		}
+		rootfs = vi.hostPath
+	}
+	config := uvm.configL()
+	config.VPMemRootFSFile = rootfs
+	return config, nil
+}
+
+// configL returns the config of the utility VM that Attach and restore create
+// it again from. `uvm.m` must be held.
+func (uvm *UtilityVM) configL() *persistentConfig {
+	return &persistentConfig{
+		Owner:                          uvm.owner,
+		ProcessorCount:                 uvm.processorCount,
+		NUMANodeCount:                  uvm.numaNodeCount,
+		PhysicallyBacked:               uvm.physicallyBacked,
+		LargePages:                     uvm.largePages,
+		DevicesPhysicallyBacked:        uvm.devicesPhysicallyBacked,
+		VPMemMaxCount:                  uvm.vpmemMaxCount,
+		VPMemMaxSizeBytes:              uvm.vpmemMaxSizeBytes,
+		SCSIControllerCount:            uvm.scsiControllerCount,
+		ContainerCounter:               uvm.containerCounter,
+		Plan9Counter:                   uvm.plan9Counter,
+		MountCounter:                   uvm.mountCounter,
+		SocketRelayCounter:             uvm.socketRelayCounter,
+		CPUGroupID:                     uvm.cpuGroupID,
+		AffinityCPUGroupID:             uvm.affinityCPUGroupID,
+		ComputeAgentSecurityDescriptor: uvm.computeAgentSecurityDescriptor,
+		GuestDHCP:                      uvm.guestDHCP,
+		DisableIPv6RA:                  uvm.disableIPv6RA,
+		ForwardedPorts:                 uvm.forwardedPorts,
+		HvSocketAllowList:              uvm.hvsocketAllowList,
+		OCIHooksPath:                   uvm.ociHooksPath,
+		ReadOnlyRootfs:                 uvm.readOnlyRootfs,
+		CoreScheduling:                 uvm.coreScheduling,
+		CoreSchedulingGroups:           uvm.coreSchedulingGroups,
+		TPMEnabled:                     uvm.tpmEnabled,
+		TPMStateFile:                   uvm.tpmStateFile,
+		NodeShares:                     uvm.nodeShares,
+		GCSWatchdogTimeout:             uvm.gcsWatchdogTimeout,
+		GCSRecoveryTimeout:             uvm.gcsRecoveryTimeout,
+		Plan9ChangeNotify:              uvm.plan9ChangeNotify,
+		Plan9MSize:                     uvm.plan9MSize,
+		Plan9Cache:                     uvm.plan9Cache,
+		ScratchTrimInterval:            uvm.scratchTrimInterval,
+	}
+}
+
+// Attach takes over the detached persistent utility VM `id`, accepting the
+// connection of its restarted GCS. Once attached the utility VM is no longer
+// detached, so only one process can attach to it. It is closed like a utility
+// VM returned by CreateLCOW, or detached again.
+func Attach(ctx context.Context, id string) (_ *UtilityVM, err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Attach")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute(logfields.UVMID, id))
+
+	config, err := loadPersistentConfig(id)
+	if err != nil {
+		if regstate.IsNotFoundError(err) {
+			return nil, fmt.Errorf("utility VM %s is not a detached persistent utility VM", id)
+		}
+		return nil, err
+	}
+	system, err := hcs.OpenComputeSystem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = system.Close()
+		}
+	}()
+	properties, err := system.Properties(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uvm := newFromConfig(id, config)
+	uvm.runtimeID = properties.RuntimeID
+	uvm.operatingSystem = "linux"
+	uvm.hcsSystem = system
+	uvm.persistent = true
+	uvm.exitCh = make(chan struct{})
+	if config.VPMemRootFSFile != "" {
+		uvm.vpmemDevices[0] = &vpmemInfo{
+			hostPath: config.VPMemRootFSFile,
+			uvmPath:  "/",
+			refCount: 1,
+		}
+	}
+	go func() {
+		err := uvm.hcsSystem.Wait()
+		if err == nil {
+			err = uvm.hcsSystem.ExitError()
+		}
+		uvm.exitErr = err
+		close(uvm.exitCh)
+	}()
+
+	l, err := uvm.listenVsock(gcs.LinuxGcsVsockPort)
+	if err != nil {
+		return nil, err
+	}
+	actx, cancel := context.WithTimeout(ctx, persistentAttachTimeout)
+	conn, err := uvm.acceptAndClose(actx, l)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("GCS of detached utility VM did not connect: %s", err)
+	}
+	gcc := &gcs.GuestConnectionConfig{
+		Conn:     conn,
+		Log:      log.G(ctx).WithField(logfields.UVMID, uvm.id),
+		IoListen: gcs.HvsockIoListen(uvm.runtimeID),
+		Timeout:  uvm.gcsWatchdogTimeout,
+		Failed:   uvm.dumpOnGCSFailure,
+	}
+	if uvm.gcsRecoveryTimeout != 0 {
+		gcc.Reconnect = uvm.reconnectGCS
+		gcc.Reconnected = uvm.restoreGuestState
+	}
+	uvm.gc, err = gcc.Connect(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = uvm.gc.Close()
+		}
+	}()
+	uvm.guestCaps = *uvm.gc.Capabilities()
+	uvm.protocol = uvm.gc.Protocol()
+
+	// The guest may have restarted the GCS with its default settings, so the
+	// hvsocket firewall is configured again before anything else runs.
+	if err = uvm.configureHvSocketFirewall(ctx); err != nil {
+		return nil, err
+	}
+	if err = uvm.startPortForwards(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start port forwards: %s", err)
+	}
+	if uvm.scratchTrimInterval != 0 {
+		go uvm.trimPeriodically()
+	}
+	// The vmmem process handle was closed by Detach in the previous process.
+	if _, err := uvm.getVMMEMProcess(ctx); err != nil {
+		log.G(ctx).WithError(err).Warning("failed to find vmmem process of attached utility VM")
+	}
+	if err := removePersistentConfig(id); err != nil {
+		log.G(ctx).WithError(err).Warning("failed to remove persistent utility VM config")
+	}
+	log.G(ctx).WithField(logfields.UVMID, uvm.id).Info("attached persistent utility VM")
+	return uvm, nil
+}
+
+// newFromConfig returns the utility VM `id` with the config `config`, without a
+// compute system.
+func newFromConfig(id string, config *persistentConfig) *UtilityVM {
+	return &UtilityVM{
+		id:                             id,
+		owner:                          config.Owner,
+		processorCount:                 config.ProcessorCount,
+		numaNodeCount:                  config.NUMANodeCount,
+		physicallyBacked:               config.PhysicallyBacked,
+		largePages:                     config.LargePages,
+		devicesPhysicallyBacked:        config.DevicesPhysicallyBacked,
+		containerCounter:               config.ContainerCounter,
+		vpmemMaxCount:                  config.VPMemMaxCount,
+		vpmemMaxSizeBytes:              config.VPMemMaxSizeBytes,
+		scsiControllerCount:            config.SCSIControllerCount,
+		vpciDevices:                    make(map[string]*VPCIDevice),
+		plan9Counter:                   config.Plan9Counter,
+		mountCounter:                   config.MountCounter,
+		socketRelayCounter:             config.SocketRelayCounter,
+		cpuGroupID:                     config.CPUGroupID,
+		affinityCPUGroupID:             config.AffinityCPUGroupID,
+		computeAgentSecurityDescriptor: config.ComputeAgentSecurityDescriptor,
+		guestDHCP:                      config.GuestDHCP,
+		disableIPv6RA:                  config.DisableIPv6RA,
+		forwardedPorts:                 config.ForwardedPorts,
+		hvsocketAllowList:              config.HvSocketAllowList,
+		ociHooksPath:                   config.OCIHooksPath,
+		readOnlyRootfs:                 config.ReadOnlyRootfs,
+		coreScheduling:                 config.CoreScheduling,
+		coreSchedulingGroups:           config.CoreSchedulingGroups,
+		tpmEnabled:                     config.TPMEnabled,
+		tpmStateFile:                   config.TPMStateFile,
+		nodeShares:                     config.NodeShares,
+		gcsWatchdogTimeout:             config.GCSWatchdogTimeout,
+		gcsRecoveryTimeout:             config.GCSRecoveryTimeout,
+		plan9ChangeNotify:              config.Plan9ChangeNotify,
+		plan9MSize:                     config.Plan9MSize,
+		plan9Cache:                     config.Plan9Cache,
+		scratchTrimInterval:            config.ScratchTrimInterval,
+	}
+}
+
+func loadPersistentConfig(id string) (*persistentConfig, error) {
+	sk, err := regstate.Open(persistentConfigRoot, false)
+	if err != nil {
+		return nil, err
+	}
+	defer sk.Close()
+
+	var config persistentConfig
+	if err := sk.Get(id, persistentConfigKey, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func storePersistentConfig(id string, config *persistentConfig) error {
+	sk, err := regstate.Open(persistentConfigRoot, false)
+	if err != nil {
+		return err
+	}
+	defer sk.Close()
+
+	return sk.Create(id, persistentConfigKey, config)
+}
+
+func removePersistentConfig(id string) error {
+	sk, err := regstate.Open(persistentConfigRoot, false)
+	if err != nil {
+		return err
+	}
+	defer sk.Close()
+
+	if err := sk.Remove(id); err != nil && !regstate.IsNotFoundError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package uvm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+)
+
+func newPersistentTestUVM() *UtilityVM {
+	return &UtilityVM{
+		id:                      "persist",
+		owner:                   "owner",
+		processorCount:          2,
+		numaNodeCount:           2,
+		physicallyBacked:        true,
+		vpmemMaxCount:           4,
+		vpmemMaxSizeBytes:       1024,
+		scsiControllerCount:     1,
+		containerCounter:        3,
+		plan9Counter:            4,
+		mountCounter:            5,
+		socketRelayCounter:      6,
+		guestDHCP:               true,
+		forwardedPorts:          []uint16{8080},
+		hvsocketAllowList:       []string{"0000a000-facb-11e6-bd58-64006a7986d3"},
+		ociHooksPath:            "/hooks",
+		readOnlyRootfs:          true,
+		coreScheduling:          true,
+		coreSchedulingGroups:    map[string]string{"app": "web"},
+		tpmEnabled:              true,
+		nodeShares:              []NodeShare{{Name: "share", HostPath: `C:\share`}},
+		gcsWatchdogTimeout:      time.Minute,
+		gcsRecoveryTimeout:      time.Second,
+		plan9MSize:              65536,
+		plan9Cache:              guestrequest.Plan9Cache("loose"),
+		scratchTrimInterval:     time.Hour,
+		devicesPhysicallyBacked: true,
+	}
+}
+
+func Test_PersistentConfig_RoundTrip(t *testing.T) {
+	uvm := newPersistentTestUVM()
+	uvm.vpmemDevices[0] = &vpmemInfo{hostPath: `C:\rootfs.vhd`, uvmPath: "/", refCount: 1}
+
+	config, err := uvm.persistentConfigL()
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if config.VPMemRootFSFile != `C:\rootfs.vhd` {
+		t.Fatalf("expected the root file system to be saved, got: %q", config.VPMemRootFSFile)
+	}
+	// The config is stored in the registry as JSON between Detach and Attach.
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %s", err)
+	}
+	var loaded persistentConfig
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		t.Fatalf("failed to unmarshal config: %s", err)
+	}
+
+	attached := newFromConfig(uvm.id, &loaded)
+	if !reflect.DeepEqual(attached.configL(), uvm.configL()) {
+		t.Fatalf("expected config %+v after attach, got: %+v", uvm.configL(), attached.configL())
+	}
+	if !reflect.DeepEqual(attached.hvsocketAllowList, uvm.hvsocketAllowList) {
+		t.Fatalf("expected hvsocket allow list %v after attach, got: %v", uvm.hvsocketAllowList, attached.hvsocketAllowList)
+	}
+}
+
+func Test_PersistentConfig_DevicesAttached(t *testing.T) {
+	uvm := newPersistentTestUVM()
+	uvm.scsiLocations[0][1] = &SCSIMount{HostPath: `C:\scratch.vhdx`}
+	if _, err := uvm.persistentConfigL(); err == nil {
+		t.Fatal("expected an error for a utility VM with a SCSI disk attached")
+	}
+
+	uvm = newPersistentTestUVM()
+	uvm.vpmemDevices[1] = &vpmemInfo{hostPath: `C:\layer.vhd`, uvmPath: "/run/layers/p1"}
+	if _, err := uvm.persistentConfigL(); err == nil {
+		t.Fatal("expected an error for a utility VM with a VPMem layer attached")
+	}
+}
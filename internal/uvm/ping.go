@@ -0,0 +1,16 @@
+package uvm
+
+import (
+	"context"
+)
+
+// Ping checks that the utility VM is responsive. If the GCS connection is
+// owned by the host the GCS is pinged, otherwise the HCS is queried for the
+// properties of the utility VM.
+func (uvm *UtilityVM) Ping(ctx context.Context) error {
+	if uvm.gc != nil {
+		return uvm.gc.Ping(ctx)
+	}
+	_, err := uvm.hcsSystem.Properties(ctx)
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/osversion"
@@ -17,6 +18,9 @@ type Plan9Share struct {
 	// UVM resource belongs to
 	vm            *UtilityVM
 	name, uvmPath string
+	// watcher relays the changes to the host directory to the guest, if
+	// change notifications are enabled.
+	watcher *plan9Watcher
 }
 
 // Release frees the resources of the corresponding Plan9 share
@@ -86,6 +90,8 @@ func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath str
 				ShareName: name,
 				Port:      plan9Port,
 				ReadOnly:  readOnly,
+				MSize:     uvm.plan9MSize,
+				Cache:     uvm.plan9Cache,
 			},
 		},
 	}
@@ -93,12 +99,38 @@ func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath str
 	if err := uvm.modify(ctx, modification); err != nil {
 		return nil, err
 	}
+	uvm.m.Lock()
+	if uvm.plan9Shares == nil {
+		uvm.plan9Shares = make(map[string]hcsschema.Plan9Share)
+	}
+	uvm.plan9Shares[name] = modification.Settings.(hcsschema.Plan9Share)
+	uvm.m.Unlock()
 
-	return &Plan9Share{
+	share := &Plan9Share{
 		vm:      uvm,
 		name:    name,
 		uvmPath: uvmPath,
-	}, nil
+	}
+	if uvm.plan9ChangeNotify {
+		// The watcher outlives this request, so it must not use its context.
+		var names []string
+		if restrict {
+			names = allowedNames
+		}
+		w, err := uvm.watchPlan9(context.Background(), hostPath, uvmPath, names)
+		if err != nil {
+			log.G(ctx).WithError(err).WithField("hostPath", hostPath).Warning("failed to watch plan9 share for changes")
+		} else {
+			uvm.m.Lock()
+			if uvm.plan9Watchers == nil {
+				uvm.plan9Watchers = make(map[*plan9Watcher]struct{})
+			}
+			uvm.plan9Watchers[w] = struct{}{}
+			uvm.m.Unlock()
+			share.watcher = w
+		}
+	}
+	return share, nil
 }
 
 // RemovePlan9 removes a Plan9 share from a utility VM. Each Plan9 share is ref-counted
@@ -107,6 +139,12 @@ func (uvm *UtilityVM) RemovePlan9(ctx context.Context, share *Plan9Share) error
 	if uvm.operatingSystem != "linux" {
 		return errNotSupported
 	}
+	if share.watcher != nil {
+		share.watcher.stop()
+		uvm.m.Lock()
+		delete(uvm.plan9Watchers, share.watcher)
+		uvm.m.Unlock()
+	}
 
 	modification := &hcsschema.ModifySettingRequest{
 		RequestType: requesttype.Remove,
@@ -129,5 +167,8 @@ func (uvm *UtilityVM) RemovePlan9(ctx context.Context, share *Plan9Share) error
 	if err := uvm.modify(ctx, modification); err != nil {
 		return fmt.Errorf("failed to remove plan9 share %s from %s: %+v: %s", share.name, uvm.id, modification, err)
 	}
+	uvm.m.Lock()
+	delete(uvm.plan9Shares, share.name)
+	uvm.m.Unlock()
 	return nil
 }
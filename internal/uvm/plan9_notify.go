@@ -0,0 +1,264 @@
+package uvm
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// plan9ChangeNotifyDelay is the time that changes to a Plan9 share are
+// collected for before they are sent to the guest, so that a burst of changes
+// (such as a configmap update, which swaps a directory of files) is sent as
+// one request.
+const plan9ChangeNotifyDelay = 100 * time.Millisecond
+
+const plan9ChangeNotifyFilter = windows.FILE_NOTIFY_CHANGE_FILE_NAME |
+	windows.FILE_NOTIFY_CHANGE_DIR_NAME |
+	windows.FILE_NOTIFY_CHANGE_ATTRIBUTES |
+	windows.FILE_NOTIFY_CHANGE_SIZE |
+	windows.FILE_NOTIFY_CHANGE_LAST_WRITE
+
+// plan9Watcher relays the changes to the host directory of a Plan9 share to
+// the guest, which generates inotify events for them on the mount. The Plan9
+// protocol has no change notifications, so without this workloads watching a
+// share only see changes by polling.
+type plan9Watcher struct {
+	vm      *UtilityVM
+	uvmPath string
+	// allowed are the file names that a restricted share exposes, or nil if
+	// the whole directory is shared.
+	allowed map[string]bool
+	h       windows.Handle
+	o       windows.Overlapped
+
+	// stopEv is signaled to cancel the pending read. mu protects it from
+	// being signaled after it is closed by the reader.
+	mu       sync.Mutex
+	stopEv   windows.Handle
+	exited   bool
+	stopOnce sync.Once
+	done     chan struct{}
+	// wg tracks the read and send goroutines.
+	wg sync.WaitGroup
+}
+
+// watchPlan9 starts relaying the changes to `hostPath` to the guest mount
+// `uvmPath`.
+func (uvm *UtilityVM) watchPlan9(ctx context.Context, hostPath, uvmPath string, allowedNames []string) (*plan9Watcher, error) {
+	p, err := windows.UTF16PtrFromString(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(p,
+		windows.FILE_LIST_DIRECTORY,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil,
+		windows.OPEN_EXISTING,
+		windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OVERLAPPED,
+		0)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	stopEv, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		windows.CloseHandle(ev)
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	w := &plan9Watcher{
+		vm:      uvm,
+		uvmPath: uvmPath,
+		h:       h,
+		stopEv:  stopEv,
+		done:    make(chan struct{}),
+	}
+	w.o.HEvent = ev
+	if allowedNames != nil {
+		w.allowed = make(map[string]bool)
+		for _, n := range allowedNames {
+			w.allowed[strings.ToLower(n)] = true
+		}
+	}
+	changes := make(chan []guestrequest.LCOWFileChange)
+	w.wg.Add(2)
+	go w.read(ctx, changes)
+	go w.send(ctx, changes)
+	return w, nil
+}
+
+// read reads the changes to the directory until the watcher is stopped.
+func (w *plan9Watcher) read(ctx context.Context, changes chan<- []guestrequest.LCOWFileChange) {
+	defer w.wg.Done()
+	defer func() {
+		w.mu.Lock()
+		w.exited = true
+		windows.CloseHandle(w.stopEv)
+		windows.CloseHandle(w.o.HEvent)
+		windows.CloseHandle(w.h)
+		w.mu.Unlock()
+		close(changes)
+	}()
+	buf := make([]byte, 64*1024)
+	for {
+		err := windows.ReadDirectoryChanges(w.h, &buf[0], uint32(len(buf)), !w.restricted(), plan9ChangeNotifyFilter, nil, &w.o, 0)
+		var n uint32
+		if err == nil {
+			var ev uint32
+			ev, err = windows.WaitForMultipleObjects([]windows.Handle{w.o.HEvent, w.stopEv}, false, windows.INFINITE)
+			if err == nil && ev != windows.WAIT_OBJECT_0 {
+				_ = windows.CancelIoEx(w.h, &w.o)
+			}
+			if err == nil {
+				err = windows.GetOverlappedResult(w.h, &w.o, &n, true)
+			}
+		}
+		if err != nil {
+			if err != windows.ERROR_OPERATION_ABORTED {
+				log.G(ctx).WithError(err).WithField("uvmPath", w.uvmPath).Warning("failed to read plan9 share changes")
+			}
+			return
+		}
+		var c []guestrequest.LCOWFileChange
+		if n == 0 {
+			// The buffer overflowed and the changes were lost.
+			c = []guestrequest.LCOWFileChange{{Action: guestrequest.FileChangeOverflow}}
+		} else {
+			c = w.parse(buf[:n])
+		}
+		if len(c) == 0 {
+			continue
+		}
+		select {
+		case changes <- c:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// parse parses the FILE_NOTIFY_INFORMATION records in `b`, dropping the
+// changes to files that the share does not expose.
+func (w *plan9Watcher) parse(b []byte) []guestrequest.LCOWFileChange {
+	var changes []guestrequest.LCOWFileChange
+	for off := uint32(0); off < uint32(len(b)); {
+		info := (*windows.FileNotifyInformation)(unsafe.Pointer(&b[off]))
+		name := (*[windows.MAX_LONG_PATH]uint16)(unsafe.Pointer(&info.FileName))[: info.FileNameLength/2 : info.FileNameLength/2]
+		p := path.Clean(strings.ReplaceAll(windows.UTF16ToString(name), `\`, "/"))
+		if !w.restricted() || w.allowed[strings.ToLower(p)] {
+			var action guestrequest.FileChangeAction
+			switch info.Action {
+			case windows.FILE_ACTION_ADDED, windows.FILE_ACTION_RENAMED_NEW_NAME:
+				action = guestrequest.FileChangeAdded
+			case windows.FILE_ACTION_REMOVED, windows.FILE_ACTION_RENAMED_OLD_NAME:
+				action = guestrequest.FileChangeRemoved
+			default:
+				action = guestrequest.FileChangeModified
+			}
+			changes = append(changes, guestrequest.LCOWFileChange{Path: p, Action: action})
+		}
+		if info.NextEntryOffset == 0 {
+			break
+		}
+		off += info.NextEntryOffset
+	}
+	return changes
+}
+
+func (w *plan9Watcher) restricted() bool {
+	return w.allowed != nil
+}
+
+// send collects the changes read for `plan9ChangeNotifyDelay` and sends them
+// to the guest, until the changes are closed, the watcher is stopped or the
+// guest fails to take them.
+func (w *plan9Watcher) send(ctx context.Context, changes <-chan []guestrequest.LCOWFileChange) {
+	defer w.wg.Done()
+	for c := range changes {
+		t := time.NewTimer(plan9ChangeNotifyDelay)
+	collect:
+		for {
+			select {
+			case more, ok := <-changes:
+				if !ok {
+					break collect
+				}
+				c = append(c, more...)
+			case <-t.C:
+				break collect
+			}
+		}
+		t.Stop()
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		request := &hcsschema.ModifySettingRequest{
+			GuestRequest: guestrequest.GuestRequest{
+				ResourceType: guestrequest.ResourceTypeMappedDirectoryChanges,
+				RequestType:  requesttype.Update,
+				Settings: guestrequest.LCOWMappedDirectoryChanges{
+					MountPath: w.uvmPath,
+					Changes:   c,
+				},
+			},
+		}
+		if err := w.vm.modify(ctx, request); err != nil {
+			log.G(ctx).WithError(err).WithFields(logrus.Fields{
+				logfields.UVMID: w.vm.id,
+				"uvmPath":       w.uvmPath,
+			}).Warning("failed to relay plan9 share changes, no longer relaying changes")
+			w.cancel()
+			return
+		}
+	}
+}
+
+// cancel stops relaying changes without waiting for the watcher to exit. It is
+// safe to call multiple times.
+func (w *plan9Watcher) cancel() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.mu.Lock()
+		if !w.exited {
+			_ = windows.SetEvent(w.stopEv)
+		}
+		w.mu.Unlock()
+	})
+}
+
+// stop stops relaying changes and waits for the watcher to exit, so that no
+// changes are sent to the guest once it returns. It is safe to call multiple
+// times, but not from the watcher's own goroutines.
+func (w *plan9Watcher) stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// stopPlan9Watchers stops relaying the changes of every Plan9 share of the
+// utility VM.
+func (uvm *UtilityVM) stopPlan9Watchers() {
+	uvm.m.Lock()
+	watchers := uvm.plan9Watchers
+	uvm.plan9Watchers = nil
+	uvm.m.Unlock()
+	for w := range watchers {
+		w.stop()
+	}
+}
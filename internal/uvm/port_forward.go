@@ -0,0 +1,126 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/sirupsen/logrus"
+)
+
+// firstPortForwardVsockPort is the base of the vsock port range used for
+// loopback port forwards. The vsock port for a forward is this base plus the
+// guest TCP port.
+const firstPortForwardVsockPort = 0x50010000
+
+// PortForward relays TCP connections made to a port on the host loopback
+// address to the same port on localhost in an LCOW guest.
+type PortForward struct {
+	// UVM the resource belongs to
+	vm *UtilityVM
+	// Port is the TCP port on both the host loopback address and the guest.
+	Port uint16
+	// VsockPort is the vsock port the guest connects to for this forward.
+	VsockPort     uint32
+	listener      net.Listener
+	guestListener net.Listener
+}
+
+// Release removes the forward from the guest and closes its listeners.
+func (pf *PortForward) Release(ctx context.Context) error {
+	pf.listener.Close()
+	pf.guestListener.Close()
+	return pf.vm.modifyPortForward(ctx, requesttype.Remove, pf.Port, pf.VsockPort)
+}
+
+func (uvm *UtilityVM) modifyPortForward(ctx context.Context, rType string, port uint16, vsockPort uint32) error {
+	request := &hcsschema.ModifySettingRequest{
+		GuestRequest: guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypePortForward,
+			RequestType:  rType,
+			Settings: guestrequest.LCOWPortForward{
+				Port:      port,
+				VsockPort: vsockPort,
+			},
+		},
+	}
+	if err := uvm.modify(ctx, request); err != nil {
+		return fmt.Errorf("failed to %s port forward for port %d: %s", rType, port, err)
+	}
+	return nil
+}
+
+// AddPortForward relays connections made to `port` on the host loopback
+// address to `port` on localhost in the guest. As the host cannot dial into
+// the guest, the guest is expected to keep a spare connection open to the
+// returned forward's `VsockPort`; each connection accepted on the host is
+// paired with the next connection accepted from the guest.
+//
+// This is intended for single node development setups where reaching a debug
+// endpoint in a pod should not require HNS NAT rules.
+func (uvm *UtilityVM) AddPortForward(ctx context.Context, port uint16) (_ *PortForward, err error) {
+	if uvm.operatingSystem != "linux" {
+		return nil, errNotSupported
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("invalid port forward port %d", port)
+	}
+	vsockPort := firstPortForwardVsockPort + uint32(port)
+	vl, err := uvm.listenVsock(vsockPort)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			vl.Close()
+		}
+	}()
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			l.Close()
+		}
+	}()
+	if err := uvm.allowHvSocketService(ctx, winio.VsockServiceID(vsockPort).String()); err != nil {
+		return nil, err
+	}
+	if err := uvm.modifyPortForward(ctx, requesttype.Add, port, vsockPort); err != nil {
+		return nil, err
+	}
+
+	entry := log.G(ctx).WithFields(logrus.Fields{
+		logfields.UVMID: uvm.id,
+		"port":          port,
+	})
+	go relayListener(entry, l, vl.Accept)
+	return &PortForward{
+		vm:            uvm,
+		Port:          port,
+		VsockPort:     vsockPort,
+		listener:      l,
+		guestListener: vl,
+	}, nil
+}
+
+// startPortForwards adds a port forward for every port in `forwardedPorts`.
+// Called once the guest connection is established.
+func (uvm *UtilityVM) startPortForwards(ctx context.Context) error {
+	for _, port := range uvm.forwardedPorts {
+		pf, err := uvm.AddPortForward(ctx, port)
+		if err != nil {
+			return err
+		}
+		uvm.portForwards = append(uvm.portForwards, pf)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultQuiesceTimeout is the time after which the guest thaws the file
+// systems frozen by QuiesceSCSI if they have not been thawed.
+const DefaultQuiesceTimeout = time.Minute
+
+// QuiesceSCSI freezes the guest file systems of the SCSI disks `hostPaths`, such
+// as the scratch disks of containers, so that the host can snapshot the disks
+// crash-consistently. Writes to the file systems block until the returned
+// function thaws them, or until `timeout` elapses and the guest thaws them by
+// itself. The returned function must be called once the snapshots are taken.
+//
+// This is only supported for LCOW utility VMs with a GCS connection.
+func (uvm *UtilityVM) QuiesceSCSI(ctx context.Context, hostPaths []string, timeout time.Duration) (thaw func(context.Context) error, err error) {
+	if uvm.operatingSystem != "linux" || uvm.gc == nil {
+		return nil, errors.New("quiescing disks is only supported for LCOW utility VMs with a GCS connection")
+	}
+	if timeout == 0 {
+		timeout = DefaultQuiesceTimeout
+	}
+
+	uvm.m.Lock()
+	var mountPaths []string
+	for _, hostPath := range hostPaths {
+		sm, err := uvm.findSCSIAttachment(ctx, hostPath)
+		if err != nil {
+			uvm.m.Unlock()
+			return nil, fmt.Errorf("failed to find SCSI disk %s: %s", hostPath, err)
+		}
+		if sm.UVMPath == "" {
+			uvm.m.Unlock()
+			return nil, fmt.Errorf("SCSI disk %s is not mounted in the guest", hostPath)
+		}
+		// A read-only file system has no writes to flush.
+		if !sm.readOnly {
+			mountPaths = append(mountPaths, sm.UVMPath)
+		}
+	}
+	uvm.m.Unlock()
+
+	freeze := guestrequest.LCOWFilesystemFreeze{
+		MountPaths:       mountPaths,
+		TimeoutInSeconds: uint32(timeout / time.Second),
+	}
+	if len(mountPaths) != 0 {
+		if err := uvm.modifyFilesystemFreeze(ctx, requesttype.Add, freeze); err != nil {
+			return nil, fmt.Errorf("failed to freeze file systems: %s", err)
+		}
+		log.G(ctx).WithFields(logrus.Fields{
+			logfields.UVMID: uvm.id,
+			"mountPaths":    mountPaths,
+		}).Debug("froze file systems")
+	}
+	return func(ctx context.Context) error {
+		if len(mountPaths) == 0 {
+			return nil
+		}
+		if err := uvm.modifyFilesystemFreeze(ctx, requesttype.Remove, freeze); err != nil {
+			return fmt.Errorf("failed to thaw file systems: %s", err)
+		}
+		return nil
+	}, nil
+}
+
+func (uvm *UtilityVM) modifyFilesystemFreeze(ctx context.Context, rType string, settings guestrequest.LCOWFilesystemFreeze) error {
+	return uvm.modify(ctx, &hcsschema.ModifySettingRequest{
+		GuestRequest: guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypeFilesystemFreeze,
+			RequestType:  rType,
+			Settings:     settings,
+		},
+	})
+}
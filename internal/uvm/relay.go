@@ -0,0 +1,59 @@
+package uvm
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// closeWriter is implemented by connections that support half-close, such as
+// TCP and hvsock connections.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// relayListener accepts connections on `l` until it is closed. For each
+// accepted connection a new connection is made with `dial` and data is copied
+// in both directions until both sides are done. Failures are logged to
+// `entry`.
+func relayListener(entry *logrus.Entry, l net.Listener, dial func() (net.Conn, error)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if err := trapClosedConnErr(err); err != nil {
+				entry.WithError(err).Warn("relay accept failed")
+			}
+			return
+		}
+		go relayConn(entry, conn, dial)
+	}
+}
+
+// relayConn dials a connection with `dial` and copies data between it and
+// `conn`. Both connections are closed on return.
+func relayConn(entry *logrus.Entry, conn net.Conn, dial func() (net.Conn, error)) {
+	defer conn.Close()
+	target, err := dial()
+	if err != nil {
+		entry.WithError(err).Warn("relay dial failed")
+		return
+	}
+	defer target.Close()
+
+	var wg sync.WaitGroup
+	copyHalf := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		if cw, ok := dst.(closeWriter); ok {
+			_ = cw.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+	wg.Add(2)
+	go copyHalf(target, conn)
+	go copyHalf(conn, target)
+	wg.Wait()
+}
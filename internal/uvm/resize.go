@@ -0,0 +1,137 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"unsafe"
+
+	"github.com/Microsoft/go-winio/vhd"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// ResizeSCSI grows the VHD of the SCSI disk `hostPath`, such as a UVM or
+// container scratch, to `sizeInBytes` while the utility VM is running. The VHD
+// is expanded on the host, the attachment is updated so that the VM sees the
+// new size, and if the disk is mounted in the guest the guest is asked to grow
+// the file system on it (ext4 for LCOW, NTFS for WCOW) to fill the disk.
+//
+// Disks can only be grown, and `sizeInBytes` must be a multiple of 512. If the
+// utility VM fails to take the new size the VHD is shrunk back. This requires a
+// GCS connection and a guest that handles update requests of mapped virtual
+// disks.
+func (uvm *UtilityVM) ResizeSCSI(ctx context.Context, hostPath string, sizeInBytes uint64) error {
+	if uvm.gc == nil {
+		return errors.New("resizing disks is only supported for utility VMs with a GCS connection")
+	}
+	if sizeInBytes == 0 || sizeInBytes%512 != 0 {
+		return fmt.Errorf("disk size %d is not a positive multiple of 512", sizeInBytes)
+	}
+
+	uvm.m.Lock()
+	sm, err := uvm.findSCSIAttachment(ctx, hostPath)
+	if err != nil {
+		uvm.m.Unlock()
+		return fmt.Errorf("failed to find SCSI disk %s: %s", hostPath, err)
+	}
+	if sm.readOnly || sm.attachmentType != "VirtualDisk" {
+		uvm.m.Unlock()
+		return fmt.Errorf("SCSI disk %s is not a writable virtual disk", hostPath)
+	}
+	attachment := hcsschema.Attachment{
+		Path:  sm.HostPath,
+		Type_: sm.attachmentType,
+	}
+	configureCSVAttachment(ctx, &attachment)
+	modification := &hcsschema.ModifySettingRequest{
+		RequestType:  requesttype.Update,
+		Settings:     attachment,
+		ResourcePath: fmt.Sprintf(scsiResourceFormat, strconv.Itoa(sm.Controller), sm.LUN),
+	}
+	if sm.UVMPath != "" {
+		guestReq := guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypeMappedVirtualDisk,
+			RequestType:  requesttype.Update,
+		}
+		if uvm.operatingSystem == "windows" {
+			guestReq.Settings = guestrequest.WCOWMappedVirtualDisk{
+				ContainerPath: sm.UVMPath,
+				Lun:           sm.LUN,
+			}
+		} else {
+			guestReq.Settings = guestrequest.LCOWMappedVirtualDisk{
+				MountPath:  sm.UVMPath,
+				Lun:        uint8(sm.LUN),
+				Controller: uint8(sm.Controller),
+			}
+		}
+		modification.GuestRequest = guestReq
+	}
+	uvm.m.Unlock()
+
+	log.G(ctx).WithFields(logrus.Fields{
+		logfields.UVMID: uvm.id,
+		"hostPath":      hostPath,
+		"size":          sizeInBytes,
+	}).Debug("resizing SCSI disk")
+
+	currentSize, err := vhdVirtualSize(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to get size of VHD %s: %s", hostPath, err)
+	}
+	if sizeInBytes < currentSize {
+		return fmt.Errorf("SCSI disk %s cannot be shrunk from %d to %d bytes", hostPath, currentSize, sizeInBytes)
+	}
+	if sizeInBytes == currentSize {
+		return nil
+	}
+
+	if err := resizeVHD(hostPath, sizeInBytes, winapi.ResizeVirtualDiskFlagNone); err != nil {
+		return fmt.Errorf("failed to expand VHD %s: %s", hostPath, err)
+	}
+	if err := uvm.modify(ctx, modification); err != nil {
+		// The guest file system was not grown, so nothing was written past
+		// the old size and the VHD can be shrunk back.
+		if rerr := resizeVHD(hostPath, currentSize, winapi.ResizeVirtualDiskFlagAllowUnsafeVirtualSize); rerr != nil {
+			log.G(ctx).WithError(rerr).WithField("hostPath", hostPath).Warning("failed to restore size of VHD")
+		}
+		return fmt.Errorf("failed to resize SCSI disk %s: %s", hostPath, err)
+	}
+	return nil
+}
+
+// vhdVirtualSize returns the virtual size of the VHD at `path`.
+func vhdVirtualSize(path string) (uint64, error) {
+	h, err := vhd.OpenVirtualDisk(path, vhd.VirtualDiskAccessNone, vhd.OpenVirtualDiskFlagNone)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(windows.Handle(h))
+
+	info := winapi.GetVirtualDiskInfoSize{Version: winapi.GetVirtualDiskInfoSizeVersion}
+	size := uint32(unsafe.Sizeof(info))
+	if err := winapi.GetVirtualDiskInformation(windows.Handle(h), &size, &info, nil); err != nil {
+		return 0, err
+	}
+	return info.VirtualSize, nil
+}
+
+// resizeVHD sets the virtual size of the VHD at `path` to `sizeInBytes`.
+func resizeVHD(path string, sizeInBytes uint64, flags uint32) error {
+	h, err := vhd.OpenVirtualDisk(path, vhd.VirtualDiskAccessNone, vhd.OpenVirtualDiskFlagNone)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(windows.Handle(h))
+
+	params := winapi.ResizeVirtualDiskParameters{Version: 1, NewSize: sizeInBytes}
+	return winapi.ResizeVirtualDisk(windows.Handle(h), flags, &params, nil)
+}
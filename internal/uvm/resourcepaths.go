@@ -4,6 +4,9 @@ package uvm
 const (
 	gpuResourcePath                  string = "VirtualMachine/ComputeTopology/Gpu"
 	memoryResourcePath               string = "VirtualMachine/ComputeTopology/Memory/SizeInMB"
+	memoryHotHintResourcePath        string = "VirtualMachine/ComputeTopology/Memory/EnableHotHint"
+	memoryColdHintResourcePath       string = "VirtualMachine/ComputeTopology/Memory/EnableColdHint"
+	memoryDiscardHintResourcePath    string = "VirtualMachine/ComputeTopology/Memory/EnableColdDiscardHint"
 	cpuGroupResourcePath             string = "VirtualMachine/ComputeTopology/Processor/CpuGroup"
 	idledResourcePath                string = "VirtualMachine/ComputeTopology/Processor/IdledProcessors"
 	cpuFrequencyPowerCapResourcePath string = "VirtualMachine/ComputeTopology/Processor/CpuFrequencyPowerCap"
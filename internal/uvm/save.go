@@ -0,0 +1,479 @@
+package uvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/Microsoft/hcsshim/internal/gcs"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/wclayer"
+	"go.opencensus.io/trace"
+)
+
+const (
+	hcsComputeSystemSaveToFile = "ToFile"
+	// savedConfigSuffix is appended to the path of a saved state file to get
+	// the path of the file that the host side state of the UVM is saved to.
+	savedConfigSuffix = ".config.json"
+)
+
+// savedSCSIMount is a SCSI disk attached to a saved utility VM.
+type savedSCSIMount struct {
+	HostPath       string
+	UVMPath        string
+	AttachmentType string
+	Controller     int
+	LUN            int32
+	ReadOnly       bool
+	RefCount       uint32
+}
+
+// savedVSMBShare is a VSMB share of a saved utility VM, stored at `Key` in
+// the directory or file shares.
+type savedVSMBShare struct {
+	Key          string
+	HostPath     string
+	Name         string
+	GuestPath    string
+	AllowedFiles []string
+	Options      hcsschema.VirtualSmbShareOptions
+	RefCount     uint32
+}
+
+// savedVPMemDevice is a VPMem device attached to a saved utility VM.
+type savedVPMemDevice struct {
+	DeviceNumber uint32
+	HostPath     string
+	UVMPath      string
+	RefCount     uint32
+}
+
+// savedState is the host side state of a utility VM saved by Save, which is
+// stored next to the saved state file of the compute system.
+type savedState struct {
+	persistentConfig
+	OperatingSystem         string
+	ExternalGuestConnection bool
+	Persistent              bool
+	// GCSRestarts is true if the guest restarts the GCS when it exits, so
+	// that a restored LCOW utility VM can connect to it.
+	GCSRestarts bool
+	// Document is the document that the utility VM was created from, with
+	// the devices that were attached to it when it was saved.
+	Document       *hcsschema.ComputeSystem
+	AdditionalJSON string
+	VSMBCounter    uint64
+	SCSI           []savedSCSIMount
+	VSMBDirShares  []savedVSMBShare
+	VSMBFileShares []savedVSMBShare
+	VPMem          []savedVPMemDevice
+	Plan9          []hcsschema.Plan9Share
+	// Namespaces are the NICs of the network namespaces of the utility VM.
+	Namespaces map[string][]nicInfo
+}
+
+// Save saves the running utility VM to the file `path` so that it can be
+// restored later by creating a utility VM with `Options.SavedStatePath` set to
+// `path`. The host side state of the utility VM, such as its SCSI disks, VSMB
+// and Plan9 shares and NICs, is saved alongside it so that the utility VM that
+// is restored from it can remove them again, e.g. with RemoveSCSI.
+//
+// The utility VM is stopped once saved and must then only be closed. A utility
+// VM with assigned devices or volumes can not be saved, and the VSMB shares of
+// a WCOW utility VM must use the options set by SetSaveableVSMBOptions. A LCOW
+// utility VM with an external guest connection must be persistent or have a
+// GCS recovery timeout, as the GCS must be restarted to connect to the
+// restored utility VM. Host
+// services such as the DNS proxy, output forwarding and the change
+// notifications of Plan9 shares are not restored.
+func (uvm *UtilityVM) Save(ctx context.Context, path string) (err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Save")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(
+		trace.StringAttribute(logfields.UVMID, uvm.id),
+		trace.StringAttribute("path", path))
+
+	if uvm.createDoc == nil {
+		return errors.New("only a utility VM created by this process can be saved")
+	}
+	if t := uvm.createDoc.VirtualMachine.ComputeTopology; t != nil && t.Processor != nil && t.Processor.ExposeVirtualizationExtensions {
+		return errors.New("a utility VM with nested virtualization can not be saved")
+	}
+
+	uvm.m.Lock()
+	state, err := uvm.savedStateL()
+	uvm.m.Unlock()
+	if err != nil {
+		return err
+	}
+	// Do not stop a utility VM that can not be restored.
+	if err := validateSavedState(state, uvm.operatingSystem); err != nil {
+		return err
+	}
+
+	// A utility VM paused by Pause is saved as it is.
+	wasPaused := uvm.IsPaused()
+	if !wasPaused {
+		if err := uvm.hcsSystem.Pause(ctx); err != nil {
+			return fmt.Errorf("failed to pause utility VM: %s", err)
+		}
+	}
+	saveOptions := hcsschema.SaveOptions{
+		SaveType:          hcsComputeSystemSaveToFile,
+		SaveStateFilePath: path,
+	}
+	if err := uvm.hcsSystem.Save(ctx, saveOptions); err != nil {
+		if wasPaused {
+			return fmt.Errorf("failed to save utility VM: %s", err)
+		}
+		if rerr := uvm.hcsSystem.Resume(ctx); rerr != nil {
+			log.G(ctx).WithError(rerr).Warning("failed to resume utility VM after failed save")
+		}
+		return fmt.Errorf("failed to save utility VM: %s", err)
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+savedConfigSuffix, b, 0600); err != nil {
+		return fmt.Errorf("failed to write saved utility VM config: %s", err)
+	}
+
+	// The processor affinity cpugroup now belongs to the restored utility VM,
+	// so it must not be deleted when this one is closed.
+	uvm.affinityCPUGroupID = ""
+	// The same goes for the TPM state, which the saved state refers to.
+	uvm.tpmStateFile = ""
+	if err := uvm.hcsSystem.Terminate(ctx); err != nil {
+		log.G(ctx).WithError(err).Warning("failed to terminate saved utility VM")
+	}
+	_ = uvm.Wait()
+	log.G(ctx).WithField(logfields.UVMID, uvm.id).Info("saved utility VM")
+	return nil
+}
+
+// savedStateL returns the saved state of the utility VM, or an error if it has
+// devices that can not be saved. `uvm.m` must be held.
+//
+// The devices of the saved document are those attached when saved: the
+// devices of the creation document that were removed since are left out, and
+// those added since are added.
+func (uvm *UtilityVM) savedStateL() (*savedState, error) {
+	if len(uvm.vpciDevices) != 0 || len(uvm.volumes) != 0 {
+		return nil, errors.New("a utility VM with assigned devices or volumes can not be saved")
+	}
+
+	// Copy the document so that a failed save leaves it untouched.
+	b, err := json.Marshal(uvm.createDoc)
+	if err != nil {
+		return nil, err
+	}
+	var doc *hcsschema.ComputeSystem
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	devices := doc.VirtualMachine.Devices
+
+	state := &savedState{
+		persistentConfig:        *uvm.configL(),
+		OperatingSystem:         uvm.operatingSystem,
+		ExternalGuestConnection: uvm.gc != nil,
+		Persistent:              uvm.persistent,
+		GCSRestarts:             uvm.persistent || uvm.gcsRecoveryTimeout != 0,
+		Document:                doc,
+		AdditionalJSON:          uvm.additionalJSON,
+		VSMBCounter:             uvm.vsmbCounter,
+		Namespaces:              make(map[string][]nicInfo),
+	}
+
+	for key, c := range devices.Scsi {
+		created := c.Attachments
+		c.Attachments = make(map[string]hcsschema.Attachment)
+		controller, _ := strconv.Atoi(key)
+		if controller >= len(uvm.scsiLocations) {
+			devices.Scsi[key] = c
+			continue
+		}
+		for lun, sm := range uvm.scsiLocations[controller] {
+			if sm == nil {
+				continue
+			}
+			state.SCSI = append(state.SCSI, savedSCSIMount{
+				HostPath:       sm.HostPath,
+				UVMPath:        sm.UVMPath,
+				AttachmentType: sm.attachmentType,
+				Controller:     sm.Controller,
+				LUN:            sm.LUN,
+				ReadOnly:       sm.readOnly,
+				RefCount:       sm.refCount,
+			})
+			// A disk attached since creation keeps the settings it was
+			// created with, such as the scratch of a WCOW utility VM.
+			if a, ok := created[strconv.Itoa(lun)]; ok && a.Path == sm.HostPath {
+				c.Attachments[strconv.Itoa(lun)] = a
+				continue
+			}
+			c.Attachments[strconv.Itoa(lun)] = hcsschema.Attachment{
+				Path:     sm.HostPath,
+				Type_:    sm.attachmentType,
+				ReadOnly: sm.readOnly,
+			}
+		}
+		devices.Scsi[key] = c
+	}
+
+	// The shares of the creation document that are not tracked are the boot
+	// shares of a WCOW utility VM, which can not be removed.
+	tracked := make(map[string]bool)
+	for _, m := range []map[string]*VSMBShare{uvm.vsmbDirShares, uvm.vsmbFileShares} {
+		for _, share := range m {
+			tracked[share.name] = true
+		}
+	}
+	var vsmbShares []hcsschema.VirtualSmbShare
+	if devices.VirtualSmb != nil {
+		for _, share := range devices.VirtualSmb.Shares {
+			if !tracked[share.Name] {
+				vsmbShares = append(vsmbShares, share)
+			}
+		}
+	}
+	saveVSMB := func(m map[string]*VSMBShare) []savedVSMBShare {
+		var shares []savedVSMBShare
+		for key, share := range m {
+			shares = append(shares, savedVSMBShare{
+				Key:          key,
+				HostPath:     share.HostPath,
+				Name:         share.name,
+				GuestPath:    share.guestPath,
+				AllowedFiles: share.allowedFiles,
+				Options:      share.options,
+				RefCount:     share.refCount,
+			})
+			options := share.options
+			vsmbShares = append(vsmbShares, hcsschema.VirtualSmbShare{
+				Name:         share.name,
+				Path:         share.HostPath,
+				Options:      &options,
+				AllowedFiles: share.allowedFiles,
+			})
+		}
+		return shares
+	}
+	state.VSMBDirShares = saveVSMB(uvm.vsmbDirShares)
+	state.VSMBFileShares = saveVSMB(uvm.vsmbFileShares)
+	if devices.VirtualSmb != nil {
+		devices.VirtualSmb.Shares = vsmbShares
+	}
+
+	if devices.VirtualPMem != nil {
+		created := devices.VirtualPMem.Devices
+		devices.VirtualPMem.Devices = nil
+		for i, vi := range uvm.vpmemDevices {
+			if vi == nil {
+				continue
+			}
+			state.VPMem = append(state.VPMem, savedVPMemDevice{
+				DeviceNumber: uint32(i),
+				HostPath:     vi.hostPath,
+				UVMPath:      vi.uvmPath,
+				RefCount:     vi.refCount,
+			})
+			if devices.VirtualPMem.Devices == nil {
+				devices.VirtualPMem.Devices = make(map[string]hcsschema.VirtualPMemDevice)
+			}
+			// The root file system that a LCOW utility VM booted from is
+			// tracked with the path it was given rather than the full path.
+			if d, ok := created[strconv.Itoa(i)]; ok && (d.HostPath == vi.hostPath || vi.uvmPath == "/") {
+				devices.VirtualPMem.Devices[strconv.Itoa(i)] = d
+				continue
+			}
+			devices.VirtualPMem.Devices[strconv.Itoa(i)] = hcsschema.VirtualPMemDevice{
+				HostPath:    vi.hostPath,
+				ReadOnly:    true,
+				ImageFormat: "Vhd1",
+			}
+		}
+	}
+
+	if devices.Plan9 != nil {
+		devices.Plan9.Shares = nil
+		for _, share := range uvm.plan9Shares {
+			state.Plan9 = append(state.Plan9, share)
+			devices.Plan9.Shares = append(devices.Plan9.Shares, share)
+		}
+	}
+
+	devices.NetworkAdapters = nil
+	for id, ns := range uvm.namespaces {
+		nics := []nicInfo{}
+		for _, ninfo := range ns.nics {
+			if ninfo == nil {
+				continue
+			}
+			nics = append(nics, *ninfo)
+			if devices.NetworkAdapters == nil {
+				devices.NetworkAdapters = make(map[string]hcsschema.NetworkAdapter)
+			}
+			devices.NetworkAdapters[ninfo.ID] = hcsschema.NetworkAdapter{
+				EndpointId: ninfo.Endpoint.Id,
+				MacAddress: ninfo.Endpoint.MacAddress,
+			}
+		}
+		state.Namespaces[id] = nics
+	}
+	return state, nil
+}
+
+// validateSavedState returns an error if `state` can not be restored as a
+// `operatingSystem` utility VM.
+func validateSavedState(state *savedState, operatingSystem string) error {
+	if state.OperatingSystem != operatingSystem {
+		return fmt.Errorf("the saved utility VM is not a %s utility VM", operatingSystem)
+	}
+	// A restored LCOW utility VM listens for the GCS on a new socket, which
+	// the GCS of the saved one only connects to once restarted by the guest.
+	if operatingSystem == "linux" && state.ExternalGuestConnection && !state.GCSRestarts {
+		return errors.New("the saved utility VM does not restart its GCS, it must be persistent or have a GCS recovery timeout")
+	}
+	return nil
+}
+
+// newFromSavedState returns the utility VM `id` that tracks the devices of
+// `state`, without creating its compute system.
+func newFromSavedState(id string, state *savedState) *UtilityVM {
+	uvm := newFromConfig(id, &state.persistentConfig)
+	uvm.operatingSystem = state.OperatingSystem
+	uvm.persistent = state.Persistent
+	uvm.restored = true
+	uvm.vsmbCounter = state.VSMBCounter
+	uvm.vsmbDirShares = make(map[string]*VSMBShare)
+	uvm.vsmbFileShares = make(map[string]*VSMBShare)
+
+	for _, sm := range state.SCSI {
+		uvm.scsiLocations[sm.Controller][sm.LUN] = newSCSIMount(uvm, sm.HostPath, sm.UVMPath, sm.AttachmentType, sm.RefCount, sm.Controller, sm.LUN, sm.ReadOnly)
+	}
+	restoreVSMB := func(shares []savedVSMBShare, m map[string]*VSMBShare) {
+		for _, s := range shares {
+			m[s.Key] = &VSMBShare{
+				vm:              uvm,
+				HostPath:        s.HostPath,
+				refCount:        s.RefCount,
+				name:            s.Name,
+				allowedFiles:    s.AllowedFiles,
+				guestPath:       s.GuestPath,
+				options:         s.Options,
+				serialVersionID: vsmbCurrentSerialVersionID,
+			}
+		}
+	}
+	restoreVSMB(state.VSMBDirShares, uvm.vsmbDirShares)
+	restoreVSMB(state.VSMBFileShares, uvm.vsmbFileShares)
+	for _, vi := range state.VPMem {
+		uvm.vpmemDevices[vi.DeviceNumber] = &vpmemInfo{
+			hostPath: vi.HostPath,
+			uvmPath:  vi.UVMPath,
+			refCount: vi.RefCount,
+		}
+	}
+	for _, share := range state.Plan9 {
+		if uvm.plan9Shares == nil {
+			uvm.plan9Shares = make(map[string]hcsschema.Plan9Share)
+		}
+		uvm.plan9Shares[share.Name] = share
+	}
+	for id, nics := range state.Namespaces {
+		if uvm.namespaces == nil {
+			uvm.namespaces = make(map[string]*namespaceInfo)
+		}
+		ns := &namespaceInfo{nics: make(map[string]*nicInfo)}
+		for i := range nics {
+			ns.nics[nics[i].Endpoint.Id] = &nics[i]
+		}
+		uvm.namespaces[id] = ns
+	}
+	return uvm
+}
+
+// restore creates the utility VM saved at `opts.SavedStatePath`, which must be
+// a `operatingSystem` utility VM. It is started like a utility VM created from
+// scratch, but the guest is not set up again.
+func restore(ctx context.Context, opts *Options, operatingSystem string) (_ *UtilityVM, err error) {
+	b, err := ioutil.ReadFile(opts.SavedStatePath + savedConfigSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved utility VM config: %s", err)
+	}
+	var state savedState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("invalid saved utility VM config: %s", err)
+	}
+	if err := validateSavedState(&state, operatingSystem); err != nil {
+		return nil, fmt.Errorf("can not restore the utility VM saved at %s: %s", opts.SavedStatePath, err)
+	}
+
+	uvm := newFromSavedState(opts.ID, &state)
+	if opts.Owner != "" {
+		uvm.owner = opts.Owner
+	}
+	defer func() {
+		if err != nil {
+			// Keep the processor affinity cpugroup and the TPM state for
+			// another restore.
+			uvm.affinityCPUGroupID = ""
+			uvm.tpmStateFile = ""
+			uvm.Close()
+		}
+	}()
+
+	// The disks must be accessible to the restored utility VM, which may have
+	// a different ID than the one that was saved.
+	for _, sm := range state.SCSI {
+		if sm.AttachmentType == "VirtualDisk" {
+			if err := wclayer.GrantVmAccess(ctx, uvm.id, sm.HostPath); err != nil {
+				return nil, fmt.Errorf("failed to grant VM access to %s: %s", sm.HostPath, err)
+			}
+		}
+	}
+	for _, vi := range state.VPMem {
+		if err := wclayer.GrantVmAccess(ctx, uvm.id, vi.HostPath); err != nil {
+			return nil, fmt.Errorf("failed to grant VM access to %s: %s", vi.HostPath, err)
+		}
+	}
+
+	doc := state.Document
+	doc.Owner = uvm.owner
+	doc.VirtualMachine.RestoreState = &hcsschema.RestoreState{
+		SaveStateFilePath: opts.SavedStatePath,
+	}
+	if err := uvm.createFromDoc(ctx, doc, state.AdditionalJSON); err != nil {
+		return nil, err
+	}
+	for _, nics := range state.Namespaces {
+		for _, nic := range nics {
+			uvm.claimEndpoint(ctx, nic.Endpoint.Id)
+		}
+	}
+
+	if state.ExternalGuestConnection {
+		if operatingSystem == "windows" {
+			err = uvm.startExternalGcsListener(ctx)
+		} else {
+			// The guest must restart the GCS for it to connect to the new
+			// listener, as for a detached persistent utility VM.
+			uvm.gcListener, err = uvm.listenVsock(gcs.LinuxGcsVsockPort)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	log.G(ctx).WithField(logfields.UVMID, uvm.id).Info("restored utility VM")
+	return uvm, nil
+}
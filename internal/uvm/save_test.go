@@ -0,0 +1,163 @@
+package uvm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/hns"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+func newSaveTestUVM() *UtilityVM {
+	uvm := newPersistentTestUVM()
+	uvm.operatingSystem = "linux"
+	uvm.persistent = true
+	uvm.createDoc = &hcsschema.ComputeSystem{
+		VirtualMachine: &hcsschema.VirtualMachine{
+			Devices: &hcsschema.Devices{
+				Scsi: map[string]hcsschema.Scsi{
+					"0": {
+						Attachments: map[string]hcsschema.Attachment{
+							"0": {Path: `C:\removed.vhdx`, Type_: "VirtualDisk"},
+							"1": {Path: `C:\created.vhdx`, Type_: "VirtualDisk", CaptureIoAttributionContext: true},
+						},
+					},
+				},
+				VirtualPMem: &hcsschema.VirtualPMemController{
+					MaximumCount: 4,
+					Devices: map[string]hcsschema.VirtualPMemDevice{
+						"0": {HostPath: `C:\uvm\rootfs.vhd`, ReadOnly: true, ImageFormat: "Vhd1"},
+					},
+				},
+				Plan9: &hcsschema.Plan9{},
+			},
+		},
+	}
+	uvm.scsiLocations[0][1] = newSCSIMount(uvm, `C:\created.vhdx`, "/run/created", "VirtualDisk", 1, 0, 1, false)
+	uvm.scsiLocations[0][2] = newSCSIMount(uvm, `C:\added.vhdx`, "/run/added", "VirtualDisk", 2, 0, 2, true)
+	uvm.vpmemDevices[0] = &vpmemInfo{hostPath: "rootfs.vhd", uvmPath: "/", refCount: 1}
+	uvm.vpmemDevices[1] = &vpmemInfo{hostPath: `C:\layer.vhd`, uvmPath: "/run/layers/p1", refCount: 1}
+	uvm.plan9Shares = map[string]hcsschema.Plan9Share{
+		"p1": {Name: "p1", AccessName: "p1", Path: `C:\share`, Port: 564},
+	}
+	uvm.namespaces = map[string]*namespaceInfo{
+		"ns": {nics: map[string]*nicInfo{
+			"ep": {ID: "nic", Endpoint: &hns.HNSEndpoint{Id: "ep", MacAddress: "00-15-5D-00-00-01"}},
+		}},
+	}
+	return uvm
+}
+
+func Test_SavedState_Devices(t *testing.T) {
+	uvm := newSaveTestUVM()
+	state, err := uvm.savedStateL()
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	devices := state.Document.VirtualMachine.Devices
+
+	attachments := devices.Scsi["0"].Attachments
+	if _, ok := attachments["0"]; ok {
+		t.Fatal("expected the removed SCSI disk to be left out of the saved document")
+	}
+	if a := attachments["1"]; !a.CaptureIoAttributionContext {
+		t.Fatalf("expected the SCSI disk of the creation document to keep its settings, got: %+v", a)
+	}
+	if a := attachments["2"]; a.Path != `C:\added.vhdx` || !a.ReadOnly {
+		t.Fatalf("expected the added SCSI disk in the saved document, got: %+v", a)
+	}
+	if d := devices.VirtualPMem.Devices["0"]; d.HostPath != `C:\uvm\rootfs.vhd` {
+		t.Fatalf("expected the root file system of the creation document, got: %+v", d)
+	}
+	if d := devices.VirtualPMem.Devices["1"]; d.HostPath != `C:\layer.vhd` {
+		t.Fatalf("expected the added VPMem device in the saved document, got: %+v", d)
+	}
+	if len(devices.Plan9.Shares) != 1 || devices.Plan9.Shares[0].Name != "p1" {
+		t.Fatalf("expected the Plan9 share in the saved document, got: %+v", devices.Plan9.Shares)
+	}
+	if n := devices.NetworkAdapters["nic"]; n.EndpointId != "ep" {
+		t.Fatalf("expected the NIC in the saved document, got: %+v", devices.NetworkAdapters)
+	}
+	// The creation document is left untouched.
+	if _, ok := uvm.createDoc.VirtualMachine.Devices.Scsi["0"].Attachments["0"]; !ok {
+		t.Fatal("expected the creation document to be unchanged")
+	}
+}
+
+func Test_SavedState_RoundTrip(t *testing.T) {
+	uvm := newSaveTestUVM()
+	state, err := uvm.savedStateL()
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	// The state is stored as JSON next to the saved state file.
+	b, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("failed to marshal saved state: %s", err)
+	}
+	var loaded savedState
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		t.Fatalf("failed to unmarshal saved state: %s", err)
+	}
+	if err := validateSavedState(&loaded, "linux"); err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+
+	restored := newFromSavedState("restored", &loaded)
+	if !restored.restored || !restored.persistent {
+		t.Fatal("expected a restored persistent utility VM")
+	}
+	if !reflect.DeepEqual(restored.configL(), uvm.configL()) {
+		t.Fatalf("expected config %+v after restore, got: %+v", uvm.configL(), restored.configL())
+	}
+	for lun := 0; lun < 3; lun++ {
+		expected, actual := uvm.scsiLocations[0][lun], restored.scsiLocations[0][lun]
+		if (expected == nil) != (actual == nil) {
+			t.Fatalf("expected SCSI disk %v at LUN %d after restore, got: %v", expected, lun, actual)
+		}
+		if expected == nil {
+			continue
+		}
+		if actual.HostPath != expected.HostPath || actual.UVMPath != expected.UVMPath ||
+			actual.refCount != expected.refCount || actual.readOnly != expected.readOnly || actual.vm != restored {
+			t.Fatalf("expected SCSI disk %+v at LUN %d after restore, got: %+v", expected, lun, actual)
+		}
+	}
+	for i, expected := range uvm.vpmemDevices {
+		if !reflect.DeepEqual(restored.vpmemDevices[i], expected) {
+			t.Fatalf("expected VPMem device %+v at %d after restore, got: %+v", expected, i, restored.vpmemDevices[i])
+		}
+	}
+	if !reflect.DeepEqual(restored.plan9Shares, uvm.plan9Shares) {
+		t.Fatalf("expected Plan9 shares %+v after restore, got: %+v", uvm.plan9Shares, restored.plan9Shares)
+	}
+	if nic := restored.namespaces["ns"].nics["ep"]; nic == nil || nic.ID != "nic" {
+		t.Fatalf("expected the NIC after restore, got: %+v", restored.namespaces["ns"])
+	}
+}
+
+func Test_ValidateSavedState(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		state   savedState
+		os      string
+		isValid bool
+	}{
+		{"LCOW", savedState{OperatingSystem: "linux"}, "linux", true},
+		{"WCOW", savedState{OperatingSystem: "windows", ExternalGuestConnection: true}, "windows", true},
+		{"OperatingSystem", savedState{OperatingSystem: "windows"}, "linux", false},
+		{"GCSRestarts", savedState{OperatingSystem: "linux", ExternalGuestConnection: true, GCSRestarts: true}, "linux", true},
+		{"NoGCSRestarts", savedState{OperatingSystem: "linux", ExternalGuestConnection: true}, "linux", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSavedState(&test.state, test.os)
+			if test.isValid && err != nil {
+				t.Fatalf("should not have failed with error: %s", err)
+			}
+			if !test.isValid && err == nil {
+				t.Fatal("should have failed with an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/processorinfo"
+)
+
+// verifySchedulerOptions fails if the SMT settings in `opts` can not be
+// honored by the hypervisor scheduler of the host. The host is only queried
+// if a setting depends on the scheduler.
+func verifySchedulerOptions(ctx context.Context, opts *Options) error {
+	if opts.HwThreadsPerCore <= 1 && !opts.RequireCoreScheduler {
+		return nil
+	}
+	scheduler, err := processorinfo.HostSchedulerType(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the host hypervisor scheduler type: %s", err)
+	}
+	if scheduler == processorinfo.SchedulerTypeCore {
+		return nil
+	}
+	if opts.RequireCoreScheduler {
+		return fmt.Errorf("hardware isolation of the UVM's vCPUs requires the core hypervisor scheduler but the host uses the %s scheduler; run `bcdedit /set hypervisorschedulertype core` and reboot the host", scheduler)
+	}
+	return fmt.Errorf("HwThreadsPerCore %d requires the core hypervisor scheduler but the host uses the %s scheduler; run `bcdedit /set hypervisorschedulertype core` and reboot the host, or set HwThreadsPerCore to 1", opts.HwThreadsPerCore, scheduler)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Microsoft/go-winio/pkg/security"
 	"github.com/Microsoft/hcsshim/internal/copyfile"
+	"github.com/Microsoft/hcsshim/internal/csv"
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
@@ -79,6 +80,9 @@ type SCSIMount struct {
 	readOnly bool
 	// "VirtualDisk" or "PassThru" disk attachment type.
 	attachmentType string
+	// trimmed is set once the guest file system on the disk is trimmed, so
+	// that its VHD is compacted once it is removed.
+	trimmed bool
 	// serialization ID
 	serialVersionID uint32
 }
@@ -118,8 +122,8 @@ func newSCSIMount(uvm *UtilityVM, hostPath, uvmPath, attachmentType string, refC
 // SCSI controllers associated with a utility VM to use.
 // Lock must be held when calling this function
 func (uvm *UtilityVM) allocateSCSISlot(ctx context.Context) (int, int, error) {
-	for controller, luns := range uvm.scsiLocations {
-		for lun, sm := range luns {
+	for controller := 0; controller < int(uvm.scsiControllerCount); controller++ {
+		for lun, sm := range uvm.scsiLocations[controller] {
 			// If sm is nil, we have found an open slot so we allocate a new SCSIMount
 			if sm == nil {
 				return controller, lun, nil
@@ -208,6 +212,14 @@ func (uvm *UtilityVM) RemoveSCSI(ctx context.Context, hostPath string) error {
 	}
 	log.G(ctx).WithFields(sm.logFormat()).Debug("removed SCSI location")
 	uvm.scsiLocations[sm.Controller][sm.LUN] = nil
+
+	// The VHD is compacted with the lock held so that it is not attached again
+	// while it is compacted.
+	if sm.trimmed && sm.attachmentType == "VirtualDisk" {
+		if err := compactVHD(sm.HostPath); err != nil {
+			log.G(ctx).WithError(err).WithFields(sm.logFormat()).Warning("failed to compact trimmed VHD")
+		}
+	}
 	return nil
 }
 
@@ -272,18 +284,21 @@ func (uvm *UtilityVM) addSCSIActual(ctx context.Context, hostPath, uvmPath, atta
 		return nil, ErrNoSCSIControllers
 	}
 
-	// Note: Can remove this check post-RS5 if multiple controllers are supported
-	if sm.Controller > 0 {
+	if sm.Controller >= int(uvm.scsiControllerCount) {
 		return nil, ErrTooManyAttachments
 	}
 
+	attachment := hcsschema.Attachment{
+		Path:     sm.HostPath,
+		Type_:    attachmentType,
+		ReadOnly: readOnly,
+	}
+	if attachmentType == "VirtualDisk" {
+		configureCSVAttachment(ctx, &attachment)
+	}
 	SCSIModification := &hcsschema.ModifySettingRequest{
-		RequestType: requesttype.Add,
-		Settings: hcsschema.Attachment{
-			Path:     sm.HostPath,
-			Type_:    attachmentType,
-			ReadOnly: readOnly,
-		},
+		RequestType:  requesttype.Add,
+		Settings:     attachment,
 		ResourcePath: fmt.Sprintf(scsiResourceFormat, strconv.Itoa(sm.Controller), sm.LUN),
 	}
 
@@ -310,7 +325,7 @@ func (uvm *UtilityVM) addSCSIActual(ctx context.Context, hostPath, uvmPath, atta
 	}
 
 	if err := uvm.modify(ctx, SCSIModification); err != nil {
-		return nil, fmt.Errorf("failed to modify UVM with new SCSI mount: %s", err)
+		return nil, errors.Wrap(err, "failed to modify UVM with new SCSI mount")
 	}
 	return sm, nil
 }
@@ -361,6 +376,22 @@ func (uvm *UtilityVM) GetScsiUvmPath(ctx context.Context, hostPath string) (stri
 	return sm.UVMPath, err
 }
 
+// configureCSVAttachment adjusts `a` for a VHD on a Cluster Shared Volume.
+// Writable VHDs are attached uncached so that no writes are lost if another
+// node opens the VHD after a failover.
+func configureCSVAttachment(ctx context.Context, a *hcsschema.Attachment) {
+	onCSV, err := csv.IsCSV(a.Path)
+	if err != nil || !onCSV {
+		return
+	}
+	if !a.ReadOnly {
+		a.CachingMode = "Uncached"
+	}
+	if redirected, err := csv.IsRedirected(a.Path); err == nil && redirected {
+		log.G(ctx).WithField("path", a.Path).Warning("VHD is in CSV redirected mode, all of its IO goes through the coordinator node")
+	}
+}
+
 // grantAccess helper function to grant access to a file for the vm or vm group
 func grantAccess(ctx context.Context, uvmID string, hostPath string, vmAccess VMAccessType) error {
 	switch vmAccess {
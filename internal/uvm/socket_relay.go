@@ -0,0 +1,132 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// firstSocketRelayVsockPort is the first vsock port used for socket
+	// relays. It is well above the ports used by the GCS for stdio.
+	firstSocketRelayVsockPort = 0x50000000
+
+	// LCOWSocketRelayConnectPrefix is the mount source prefix telling the guest
+	// to create a UNIX socket at the mount destination and relay each
+	// connection to it by connecting to the vsock port that follows the prefix.
+	// example: vsock-connect://1342177280 destination:/var/run/docker.sock
+	LCOWSocketRelayConnectPrefix = "vsock-connect://"
+	// LCOWSocketRelayListenPrefix is the mount source prefix telling the guest
+	// to keep a spare connection open to the vsock port that follows the
+	// prefix and relay each connection taken by the host to the UNIX socket at
+	// the mount destination.
+	LCOWSocketRelayListenPrefix = "vsock-listen://"
+)
+
+// SocketRelay relays connections between a named pipe on the host and a UNIX
+// socket in an LCOW guest over vsock.
+type SocketRelay struct {
+	// UVM the resource belongs to
+	vm *UtilityVM
+	// HostPath is the named pipe on the host.
+	HostPath string
+	// Port is the vsock port used between the host and the guest.
+	Port     uint32
+	listener net.Listener
+	// guestListener accepts connections from the guest when relaying from a
+	// guest socket to a host named pipe.
+	guestListener net.Listener
+}
+
+// Release stops the relay and closes its listeners. Connections already being
+// relayed are left to finish on their own.
+func (r *SocketRelay) Release(ctx context.Context) error {
+	if r.guestListener != nil {
+		r.guestListener.Close()
+	}
+	if err := r.listener.Close(); err != nil {
+		return fmt.Errorf("failed to close socket relay for %s: %s", r.HostPath, err)
+	}
+	return nil
+}
+
+func (uvm *UtilityVM) nextSocketRelayPort() uint32 {
+	return firstSocketRelayVsockPort + atomic.AddUint32(&uvm.socketRelayCounter, 1) - 1
+}
+
+func (uvm *UtilityVM) socketRelayLogEntry(ctx context.Context, hostPath string, port uint32) *logrus.Entry {
+	return log.G(ctx).WithFields(logrus.Fields{
+		logfields.UVMID: uvm.id,
+		"hostPath":      hostPath,
+		"port":          port,
+	})
+}
+
+// AddPipeToSocketRelay exposes the host named pipe `hostPath` to the guest.
+// The guest is expected to connect to the returned relay's `Port` for every
+// connection made to its UNIX socket, each of which is relayed to a new
+// connection to `hostPath`.
+func (uvm *UtilityVM) AddPipeToSocketRelay(ctx context.Context, hostPath string) (*SocketRelay, error) {
+	if uvm.operatingSystem != "linux" {
+		return nil, errNotSupported
+	}
+	if !IsPipe(hostPath) {
+		return nil, fmt.Errorf("%s is not a named pipe", hostPath)
+	}
+	port := uvm.nextSocketRelayPort()
+	l, err := uvm.listenVsock(port)
+	if err != nil {
+		return nil, err
+	}
+	go relayListener(uvm.socketRelayLogEntry(ctx, hostPath, port), l, func() (net.Conn, error) {
+		return winio.DialPipe(hostPath, nil)
+	})
+	return &SocketRelay{
+		vm:       uvm,
+		HostPath: hostPath,
+		Port:     port,
+		listener: l,
+	}, nil
+}
+
+// AddSocketToPipeRelay creates the named pipe `hostPath` on the host and
+// relays every connection made to it to the guest's UNIX socket. As the host
+// cannot dial into the guest, the guest is expected to keep a spare connection
+// to the returned relay's `Port` open at all times; each connection accepted on
+// the named pipe is paired with the next connection accepted from the guest.
+func (uvm *UtilityVM) AddSocketToPipeRelay(ctx context.Context, hostPath string) (_ *SocketRelay, err error) {
+	if uvm.operatingSystem != "linux" {
+		return nil, errNotSupported
+	}
+	if !IsPipe(hostPath) {
+		return nil, fmt.Errorf("%s is not a named pipe", hostPath)
+	}
+	port := uvm.nextSocketRelayPort()
+	vl, err := uvm.listenVsock(port)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			vl.Close()
+		}
+	}()
+	l, err := winio.ListenPipe(hostPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	go relayListener(uvm.socketRelayLogEntry(ctx, hostPath, port), l, vl.Accept)
+	return &SocketRelay{
+		vm:            uvm,
+		HostPath:      hostPath,
+		Port:          port,
+		listener:      l,
+		guestListener: vl,
+	}, nil
+}
@@ -13,14 +13,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/eventlog"
 	"github.com/Microsoft/hcsshim/internal/gcs"
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	"github.com/Microsoft/hcsshim/internal/schema1"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -33,6 +36,11 @@ import (
 // generally misunderstood.
 const entropyBytes = 512
 
+// MaxEntropySeedBytes is the maximum size of the entropy seed of a Linux UVM.
+// It is the size of the input pool of older kernels; a larger seed credits no
+// more entropy.
+const MaxEntropySeedBytes = 4096
+
 type gcsLogEntryStandard struct {
 	Time    time.Time    `json:"time"`
 	Level   logrus.Level `json:"level"`
@@ -151,6 +159,19 @@ func (uvm *UtilityVM) configureHvSocketForGCS(ctx context.Context) (err error) {
 
 // Start synchronously starts the utility VM.
 func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
+	ctx, span := trace.StartSpan(ctx, "uvm::Start")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute(logfields.UVMID, uvm.id))
+	defer func() {
+		if err != nil {
+			eventlog.Error(ctx, eventlog.EventUVMBootFailure, "a utility VM failed to start", map[string]interface{}{
+				logfields.UVMID: uvm.id,
+				"error":         err,
+			})
+		}
+	}()
+
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	g, gctx := errgroup.WithContext(ctx)
 	defer func() {
@@ -163,19 +184,22 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 	// call to Start() will block until the GCS launches, and this cannot occur
 	// until the host accepts and closes the entropy connection.
 	if uvm.entropyListener != nil {
-		g.Go(func() error {
-			conn, err := uvm.acceptAndClose(gctx, uvm.entropyListener)
-			uvm.entropyListener = nil
-			if err != nil {
-				return fmt.Errorf("failed to connect to entropy socket: %s", err)
-			}
-			defer conn.Close()
-			_, err = io.CopyN(conn, rand.Reader, entropyBytes)
-			if err != nil {
-				return fmt.Errorf("failed to write entropy: %s", err)
-			}
-			return nil
-		})
+		l := uvm.entropyListener
+		uvm.entropyListener = nil
+		if uvm.requireEntropySeed {
+			g.Go(func() error {
+				return uvm.seedEntropy(gctx, l)
+			})
+		} else {
+			// An optional seed is not waited for, so that a guest that does
+			// not take it does not hold up the start until the timeout. The
+			// listener is closed once the start completes.
+			go func() {
+				if err := uvm.seedEntropy(gctx, l); err != nil {
+					log.G(ctx).WithError(err).Warning("failed to seed guest entropy")
+				}
+			}()
+		}
 	}
 
 	if uvm.outputListener != nil {
@@ -205,6 +229,8 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		}
 	}()
 
+	uvm.startConsoleCapture(ctx)
+
 	// assign the VM to the cpugroup specified, if any
 	if uvm.cpuGroupID != "" {
 		if err := uvm.SetCPUGroup(ctx, uvm.cpuGroupID); err != nil {
@@ -212,6 +238,8 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		}
 	}
 
+	uvm.applySchedulerHints(ctx)
+
 	// Start waiting on the utility VM.
 	uvm.exitCh = make(chan struct{})
 	go func() {
@@ -241,8 +269,14 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 			Conn:     conn,
 			Log:      log.G(ctx).WithField(logfields.UVMID, uvm.id),
 			IoListen: gcs.HvsockIoListen(uvm.runtimeID),
+			Timeout:  uvm.gcsWatchdogTimeout,
+			Failed:   uvm.dumpOnGCSFailure,
 		}
-		uvm.gc, err = gcc.Connect(ctx, !uvm.IsClone)
+		if uvm.operatingSystem == "linux" && uvm.gcsRecoveryTimeout != 0 {
+			gcc.Reconnect = uvm.reconnectGCS
+			gcc.Reconnected = uvm.restoreGuestState
+		}
+		uvm.gc, err = gcc.Connect(ctx, !uvm.IsClone && !uvm.restored)
 		if err != nil {
 			return err
 		}
@@ -253,6 +287,24 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		if err = uvm.configureHvSocketForGCS(ctx); err != nil {
 			return fmt.Errorf("failed to do initial GCS setup: %s", err)
 		}
+
+		if !uvm.restored {
+			if err = uvm.configureHvSocketFirewall(ctx); err != nil {
+				return err
+			}
+
+			if err = uvm.configureBinfmt(ctx); err != nil {
+				return err
+			}
+		}
+
+		if err = uvm.startPortForwards(ctx); err != nil {
+			return fmt.Errorf("failed to start port forwards: %s", err)
+		}
+
+		if uvm.scratchTrimInterval != 0 {
+			go uvm.trimPeriodically()
+		}
 	} else {
 		// Cache the guest connection properties.
 		properties, err := uvm.hcsSystem.Properties(ctx, schema1.PropertyTypeGuestConnection)
@@ -263,6 +315,74 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		uvm.protocol = properties.GuestConnectionInfo.ProtocolVersion
 	}
 
+	// The extensions and node shares of a restored UVM were saved with it.
+	if uvm.restored {
+		return nil
+	}
+
+	if err = uvm.attachExtensions(ctx); err != nil {
+		return err
+	}
+
+	if err = uvm.addNodeShares(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reconnectGCS waits for a restarted GCS to connect to the GCS port, for up to
+// the GCS recovery timeout.
+//
+// The GCS is restarted by the guest init once it exits. A GCS that crashes
+// exits on its own; one that hangs has its connection closed by the host when
+// an operation exceeds the GCS watchdog timeout, which makes it exit once its
+// bridge sees the connection close. A GCS that is hung so badly that it does
+// not is not recovered, and the guest connection terminates when the recovery
+// timeout expires.
+func (uvm *UtilityVM) reconnectGCS(ctx context.Context) (io.ReadWriteCloser, error) {
+	l, err := uvm.listenVsock(gcs.LinuxGcsVsockPort)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, uvm.gcsRecoveryTimeout)
+	defer cancel()
+	conn, err := uvm.acceptAndClose(ctx, l)
+	if err != nil {
+		return nil, fmt.Errorf("GCS did not reconnect: %s", err)
+	}
+	return conn, nil
+}
+
+// restoreGuestState restores the guest configuration that a restarted GCS
+// lost, the hvsocket firewall and the guest side of the port forwards, before
+// any other operation is issued on the new connection. The state of the
+// containers and processes is re-synchronized by the guest connection, and
+// the devices and mounts of the guest are unaffected by the restart.
+func (uvm *UtilityVM) restoreGuestState(ctx context.Context) error {
+	if err := uvm.configureHvSocketFirewall(ctx); err != nil {
+		return err
+	}
+	for _, pf := range uvm.portForwards {
+		if err := uvm.modifyPortForward(ctx, requesttype.Add, pf.Port, pf.VsockPort); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedEntropy sends the entropy seed to the init process of a Linux UVM once it
+// connects to the entropy listener `l`.
+func (uvm *UtilityVM) seedEntropy(ctx context.Context, l net.Listener) error {
+	conn, err := uvm.acceptAndClose(ctx, l)
+	if err != nil {
+		return fmt.Errorf("failed to connect to entropy socket: %s", err)
+	}
+	defer conn.Close()
+	_, err = io.CopyN(conn, rand.Reader, int64(uvm.entropySeedBytes))
+	if err != nil {
+		return fmt.Errorf("failed to write entropy: %s", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,65 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Microsoft/go-winio/pkg/security"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"github.com/sirupsen/logrus"
+)
+
+// configureTPM adds a TPM 2.0 device to `doc` if `opts.EnableTPM` is set. The
+// TPM state is kept in a new guest state file in `opts.TPMStateDirectory`,
+// which the VM is granted access to, or is transient if it is not set.
+func (uvm *UtilityVM) configureTPM(ctx context.Context, opts *Options, doc *hcsschema.ComputeSystem) error {
+	if !opts.EnableTPM {
+		return nil
+	}
+	doc.VirtualMachine.SecuritySettings = &hcsschema.SecuritySettings{EnableTpm: true}
+	uvm.tpmEnabled = true
+	if opts.TPMStateDirectory == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(opts.TPMStateDirectory, 0700); err != nil {
+		return fmt.Errorf("failed to create TPM state directory: %s", err)
+	}
+	path := filepath.Join(opts.TPMStateDirectory, uvm.id+".vmgs")
+	// A state file left behind by an earlier UVM with the same ID, such as one
+	// of a shim that crashed, must not be handed to this UVM.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale TPM state file: %s", err)
+	}
+	if err := winapi.HcsCreateEmptyGuestStateFile(path); err != nil {
+		return fmt.Errorf("failed to create TPM state file: %s", err)
+	}
+	uvm.tpmStateFile = path
+	log.G(ctx).WithField("path", path).Debug("granting vm group access")
+	if err := security.GrantVmGroupAccess(path); err != nil {
+		return fmt.Errorf("failed to grant access to TPM state file: %s", err)
+	}
+	doc.VirtualMachine.GuestState = &hcsschema.GuestState{
+		GuestStateFilePath: path,
+	}
+	return nil
+}
+
+// removeTPMState removes the TPM state file of the closed UVM, if any.
+func (uvm *UtilityVM) removeTPMState(ctx context.Context) {
+	if uvm.tpmStateFile == "" {
+		return
+	}
+	if err := os.Remove(uvm.tpmStateFile); err != nil && !os.IsNotExist(err) {
+		log.G(ctx).WithError(err).WithFields(logrus.Fields{
+			logfields.UVMID: uvm.id,
+			"path":          uvm.tpmStateFile,
+		}).Warning("failed to remove TPM state file")
+	}
+	uvm.tpmStateFile = ""
+}
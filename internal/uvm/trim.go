@@ -0,0 +1,124 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Microsoft/go-winio/vhd"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"golang.org/x/sys/windows"
+)
+
+// TrimSCSI trims the guest file systems of the writable SCSI disks `hostPaths`,
+// or of all the writable virtual disks mounted in the guest if `hostPaths` is
+// nil. This reclaims the space of the files that containers deleted from their
+// scratch.
+//
+// The dynamic VHDs backing the disks can not be compacted while they are
+// attached, so a trimmed VHD is compacted on the host once it is removed from
+// the utility VM, see `RemoveSCSI`.
+//
+// This is only supported for LCOW utility VMs with a GCS connection.
+func (uvm *UtilityVM) TrimSCSI(ctx context.Context, hostPaths []string) error {
+	if uvm.operatingSystem != "linux" || uvm.gc == nil {
+		return errors.New("trimming disks is only supported for LCOW utility VMs with a GCS connection")
+	}
+
+	// Only the guest paths are used once the lock is released, as the mounts
+	// can be removed in the meantime.
+	var (
+		mounts     []*SCSIMount
+		mountPaths []string
+	)
+	uvm.m.Lock()
+	if hostPaths == nil {
+		for _, luns := range uvm.scsiLocations {
+			for _, sm := range luns {
+				if sm != nil && sm.UVMPath != "" && !sm.readOnly && sm.attachmentType == "VirtualDisk" {
+					mounts = append(mounts, sm)
+				}
+			}
+		}
+	} else {
+		for _, hostPath := range hostPaths {
+			sm, err := uvm.findSCSIAttachment(ctx, hostPath)
+			if err != nil {
+				uvm.m.Unlock()
+				return fmt.Errorf("failed to find SCSI disk %s: %s", hostPath, err)
+			}
+			if sm.UVMPath == "" || sm.readOnly {
+				uvm.m.Unlock()
+				return fmt.Errorf("SCSI disk %s is not mounted writable in the guest", hostPath)
+			}
+			mounts = append(mounts, sm)
+		}
+	}
+	for _, sm := range mounts {
+		mountPaths = append(mountPaths, sm.UVMPath)
+	}
+	uvm.m.Unlock()
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	trim := guestrequest.LCOWFilesystemTrim{MountPaths: mountPaths}
+	request := &hcsschema.ModifySettingRequest{
+		GuestRequest: guestrequest.GuestRequest{
+			ResourceType: guestrequest.ResourceTypeFilesystemTrim,
+			RequestType:  requesttype.Update,
+			Settings:     trim,
+		},
+	}
+	if err := uvm.modify(ctx, request); err != nil {
+		return fmt.Errorf("failed to trim file systems: %s", err)
+	}
+
+	uvm.m.Lock()
+	for _, sm := range mounts {
+		sm.trimmed = true
+	}
+	uvm.m.Unlock()
+	return nil
+}
+
+// compactVHD compacts the dynamic VHD at `path`, shrinking it to the blocks
+// that are in use. The VHD must not be attached to a VM.
+func compactVHD(path string) error {
+	h, err := vhd.OpenVirtualDisk(path, vhd.VirtualDiskAccessNone, vhd.OpenVirtualDiskFlagNone)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(windows.Handle(h))
+
+	params := winapi.CompactVirtualDiskParameters{Version: 1}
+	return winapi.CompactVirtualDisk(windows.Handle(h), winapi.CompactVirtualDiskFlagNone, &params, nil)
+}
+
+// trimPeriodically trims the writable SCSI disks every `uvm.scratchTrimInterval`
+// until the utility VM exits.
+func (uvm *UtilityVM) trimPeriodically() {
+	ctx := context.Background()
+	l := log.G(ctx).WithField(logfields.UVMID, uvm.id)
+	t := time.NewTicker(uvm.scratchTrimInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-uvm.exitCh:
+			return
+		case <-t.C:
+		}
+		start := time.Now()
+		if err := uvm.TrimSCSI(ctx, nil); err != nil {
+			l.WithError(err).Warning("failed to trim scratch disks")
+			continue
+		}
+		l.WithField("duration", time.Since(start)).Debug("trimmed scratch disks")
+	}
+}
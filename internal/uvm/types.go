@@ -5,13 +5,17 @@ package uvm
 import (
 	"net"
 	"sync"
+	"time"
 
 	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/gcs"
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/hns"
 	"github.com/Microsoft/hcsshim/internal/ncproxyttrpc"
+	"github.com/Microsoft/hcsshim/internal/reservation"
 	"github.com/Microsoft/hcsshim/internal/schema1"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"golang.org/x/sys/windows"
 )
 
@@ -48,7 +52,9 @@ type UtilityVM struct {
 	gcListener       net.Listener         // The GCS connection listener
 	gc               *gcs.GuestConnection // The GCS connection
 	processorCount   int32
+	numaNodeCount    uint32     // The number of virtual NUMA nodes, or 0 for a flat topology
 	physicallyBacked bool       // If the uvm is backed by physical memory and not virtual memory
+	largePages       bool       // If the uvm's memory is backed by host large pages
 	m                sync.Mutex // Lock for adding/removing devices
 
 	exitErr error
@@ -85,21 +91,41 @@ type UtilityVM struct {
 	vpmemMaxSizeBytes uint64                    // The max size of the layer in bytes per vPMem device.
 
 	// SCSI devices that are mapped into a Windows or Linux utility VM
-	scsiLocations       [4][64]*SCSIMount // Hyper-V supports 4 controllers, 64 slots per controller. Limited to 1 controller for now though.
-	scsiControllerCount uint32            // Number of SCSI controllers in the utility VM
+	scsiLocations       [MaxSCSIControllers][64]*SCSIMount // Hyper-V supports 4 controllers, 64 slots per controller.
+	scsiControllerCount uint32                             // Number of SCSI controllers in the utility VM
 
 	vpciDevices map[string]*VPCIDevice // map of device instance id to vpci device
 
 	// Plan9 are directories mapped into a Linux utility VM
 	plan9Counter uint64 // Each newly-added plan9 share has a counter used as its ID in the ResourceURI and for the name
+	// plan9Shares are the settings of the Plan9 shares in the utility VM by
+	// name, so that they can be added again when it is restored. Access must
+	// be done with `m` held.
+	plan9Shares map[string]hcsschema.Plan9Share
 
 	namespaces map[string]*namespaceInfo
 
+	// console captures the serial console output, or is nil if it is not
+	// captured.
+	console *consoleCapture
+	// crashDumpDirectory is the host directory that the crash dumps of the
+	// guest are written to, or "" if crash dumps are not collected.
+	crashDumpDirectory string
+
 	outputListener       net.Listener
 	outputProcessingDone chan struct{}
 	outputHandler        OutputHandler
 
 	entropyListener net.Listener
+	// entropySeedBytes is the size of the entropy seed sent on
+	// entropyListener, and requireEntropySeed whether the UVM fails to start
+	// if it is not delivered.
+	entropySeedBytes   uint32
+	requireEntropySeed bool
+
+	// dnsProxyListener is the vsock listener for the host DNS proxy. nil if
+	// the proxy is not enabled.
+	dnsProxyListener net.Listener
 
 	// Handle to the vmmem process associated with this UVM. Used to look up
 	// memory metrics for the UVM.
@@ -110,6 +136,15 @@ type UtilityVM struct {
 	// open.
 	vmmemOnce sync.Once
 
+	// socketRelayCounter is the number of socket relays that have been added
+	// to the UVM. Used to allocate a unique vsock port for every relay.
+	// Access to this variable should be done atomically.
+	socketRelayCounter uint32
+
+	// volumes are the pod volumes registered on the UVM by name. Access must
+	// be done with `m` held.
+	volumes map[string]*Volume
+
 	// mountCounter is the number of mounts that have been added to the UVM
 	// This is used in generating a unique mount path inside the UVM for every mount.
 	// Access to this variable should be done atomically.
@@ -117,6 +152,37 @@ type UtilityVM struct {
 
 	// cpuGroupID is the ID of the cpugroup on the host that this UVM is assigned to
 	cpuGroupID string
+	// memoryHints are the dynamic memory hints currently enabled for the UVM.
+	// Access must be done with `m` held after creation.
+	memoryHints MemoryHints
+
+	// schedulerHints are the processor limits applied on start for the
+	// requested scheduler hints, nil if none were requested.
+	// schedulerHintsApplied is set once the host accepted them.
+	schedulerHints        *hcsschema.ProcessorLimits
+	schedulerHintsApplied bool
+
+	// processorLimit is the current processor limit of the UVM, `0` meaning
+	// the host default of 100%. Access must be done with `m` held.
+	processorLimit int32
+
+	// affinityCPUGroupID is the ID of the cpugroup created for this UVM's
+	// processor affinity, if any. It is deleted when the UVM is closed.
+	affinityCPUGroupID string
+
+	// createDoc and additionalJSON are the document that the compute system
+	// was created from, which Save adds the current devices of the utility VM
+	// to.
+	createDoc      *hcsschema.ComputeSystem
+	additionalJSON string
+
+	// restored is true if the UVM was restored from a saved state, in which
+	// case the guest is not set up again when it starts.
+	restored bool
+
+	// paused is 1 while the UVM is paused by Pause. Access must be done
+	// atomically.
+	paused int32
 
 	// specifies if this UVM is created to be saved as a template
 	IsTemplate bool
@@ -134,6 +200,102 @@ type UtilityVM struct {
 	// Network config proxy client. If nil then this wasn't requested and the
 	// uvms network will be configured locally.
 	ncProxyClient ncproxyttrpc.NetworkConfigProxyService
+	// computeAgentSecurityDescriptor is the SDDL of the ComputeAgent named
+	// pipe, or "" for the default ACL.
+	computeAgentSecurityDescriptor string
+
+	// guestDHCP indicates that hot-added NICs should be configured by a DHCP
+	// client in the guest rather than with the HNS endpoint's static settings.
+	// Only applies to LCOW.
+	guestDHCP bool
+
+	// disableIPv6RA indicates that the guest should not accept IPv6 router
+	// advertisements on hot-added NICs. Only applies to LCOW.
+	disableIPv6RA bool
+
+	// forwardedPorts are the guest TCP ports relayed from the host loopback
+	// address once the UVM is started, and portForwards the resulting
+	// forwards. Only applies to LCOW.
+	forwardedPorts []uint16
+	portForwards   []*PortForward
+
+	// ociHooksPath is the guest directory that the OCI hooks of containers
+	// must be in to be run by the guest, or "" if hooks are not run. Only
+	// applies to LCOW.
+	ociHooksPath string
+
+	// nodeShares are the host directories shared read-only into the UVM when
+	// it starts.
+	nodeShares []NodeShare
+
+	// readOnlyRootfs is whether every container in the UVM must have a
+	// read-only root file system. Only applies to LCOW.
+	readOnlyRootfs bool
+
+	// coreScheduling is whether every container in the UVM gets a core
+	// scheduling cookie, and coreSchedulingGroups are the groups of containers
+	// that share a cookie, by container name. Only applies to LCOW.
+	coreScheduling       bool
+	coreSchedulingGroups map[string]string
+
+	// tpmEnabled is whether the UVM has a TPM device.
+	tpmEnabled bool
+	// tpmStateFile is the guest state file that the TPM state of the UVM is
+	// kept in, which is removed when the UVM is closed. Empty if the TPM
+	// state is transient.
+	tpmStateFile string
+
+	// gcsWatchdogTimeout is the time after which a GCS operation without a
+	// response fails the GCS connection, or 0 for the default.
+	// gcsRecoveryTimeout is the time to wait for a restarted GCS to reconnect
+	// after the GCS connection fails, or 0 if the connection is not
+	// recovered. Only applies to LCOW.
+	gcsWatchdogTimeout time.Duration
+	gcsRecoveryTimeout time.Duration
+
+	// plan9ChangeNotify is true if the changes to the host directories
+	// of Plan9 shares are relayed to the guest. Only applies to LCOW.
+	plan9ChangeNotify bool
+	// plan9Watchers are the watchers relaying the changes of the Plan9
+	// shares, so that they are stopped when the utility VM is closed. Access
+	// must be done with `m` held.
+	plan9Watchers map[*plan9Watcher]struct{}
+
+	// plan9MSize and plan9Cache are the 9P message size and v9fs cache mode
+	// of Plan9 mounts in the guest. Only applies to LCOW.
+	plan9MSize uint32
+	plan9Cache guestrequest.Plan9Cache
+
+	// persistent is true if the UVM can be detached from this process and
+	// attached to by another, rather than being terminated when closed. Only
+	// applies to LCOW.
+	persistent bool
+	// reservation is the host capacity reservation of the UVM, which is
+	// detached along with the UVM rather than released. Access must be done
+	// with `m` held.
+	reservation *reservation.Reservation
+
+	// hvsocketAllowList are the hvsocket service IDs that the guest accepts
+	// inbound connections to, or nil if they are not filtered. Only applies
+	// to LCOW.
+	hvsocketAllowList []string
+
+	// scratchTrimInterval is the interval between trims of the writable SCSI
+	// disks, or 0 if they are not trimmed periodically. Only applies to LCOW.
+	scratchTrimInterval time.Duration
+
+	// emulatedArchitectures are the foreign architectures whose binaries the
+	// guest runs through the qemu-user interpreters of the binfmt extension at
+	// `binfmtFile`. Only applies to LCOW.
+	emulatedArchitectures []string
+	binfmtFile            string
+
+	// extensions are the names of the extension VHDs in `extensionsPath` that
+	// are mounted in the guest under the sandbox mounts of `sandboxID`. Only
+	// applies to LCOW.
+	extensions     []string
+	extensionsPath string
+	sandboxID      string
 
 	// networkSetup handles the logic for setting up and tearing down any network configuration
 	// for the Utility VM.
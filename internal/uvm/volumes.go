@@ -0,0 +1,92 @@
+package uvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+)
+
+// VolumeMedium is what backs a pod volume.
+type VolumeMedium string
+
+const (
+	// VolumeMediumTmpfs backs a pod volume with the memory of the UVM.
+	VolumeMediumTmpfs VolumeMedium = "tmpfs"
+	// VolumeMediumScratch backs a pod volume with its own scratch VHD.
+	VolumeMediumScratch VolumeMedium = "scratch"
+)
+
+// ErrVolumeNotFound is returned when a pod volume is not registered on the
+// UVM.
+var ErrVolumeNotFound = errors.New("pod volume not found")
+
+// VolumeOptions describes a pod volume.
+type VolumeOptions struct {
+	Name   string
+	Medium VolumeMedium
+	// SizeInBytes is the size limit of the volume. 0 uses the default of the
+	// medium.
+	SizeInBytes uint64
+}
+
+// Volume is a pod volume created in the UVM for the lifetime of the pod, that
+// containers of the pod mount by name.
+type Volume struct {
+	VolumeOptions
+	// UVMPath is where the volume is mounted in the UVM.
+	UVMPath string
+	// HostPath is the VHD backing a scratch volume on the host, which is
+	// removed when the UVM is closed.
+	HostPath string
+}
+
+// RegisterVolume records the pod volume `v` as created in the UVM.
+func (uvm *UtilityVM) RegisterVolume(v *Volume) error {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	if _, ok := uvm.volumes[v.Name]; ok {
+		return fmt.Errorf("pod volume %q already exists", v.Name)
+	}
+	if uvm.volumes == nil {
+		uvm.volumes = make(map[string]*Volume)
+	}
+	uvm.volumes[v.Name] = v
+	return nil
+}
+
+// UnregisterVolume removes the record of the pod volume `name`.
+func (uvm *UtilityVM) UnregisterVolume(name string) {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	delete(uvm.volumes, name)
+}
+
+// Volume returns the pod volume `name`.
+func (uvm *UtilityVM) Volume(name string) (*Volume, error) {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	v, ok := uvm.volumes[name]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrVolumeNotFound)
+	}
+	return v, nil
+}
+
+// removeVolumeFiles removes the host files of the pod volumes once the UVM
+// has been terminated.
+func (uvm *UtilityVM) removeVolumeFiles(ctx context.Context) {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+	for _, v := range uvm.volumes {
+		if v.HostPath == "" {
+			continue
+		}
+		if err := os.Remove(v.HostPath); err != nil && !os.IsNotExist(err) {
+			log.G(ctx).WithError(err).WithField("volume", v.Name).Warning("failed to remove pod volume")
+		}
+	}
+	uvm.volumes = nil
+}
@@ -1,6 +1,7 @@
 package uvm
 
 import (
+	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/logfields"
 	"github.com/sirupsen/logrus"
 )
@@ -16,3 +17,9 @@ func (uvm *UtilityVM) Wait() error {
 
 	return err
 }
+
+// Subscribe returns a subscription to the exit, guest connection and modify
+// notifications of the utility VM.
+func (uvm *UtilityVM) Subscribe() *hcs.Subscription {
+	return uvm.hcsSystem.Subscribe()
+}
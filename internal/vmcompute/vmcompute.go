@@ -2,6 +2,7 @@ package vmcompute
 
 import (
 	gcontext "context"
+	"encoding/json"
 	"syscall"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/timeout"
 	"go.opencensus.io/trace"
 )
@@ -348,7 +350,7 @@ func HcsModifyServiceSettings(ctx gcontext.Context, settings string) (result str
 		}
 		oc.SetSpanStatus(span, hr)
 	}()
-	span.AddAttributes(trace.StringAttribute("settings", settings))
+	span.AddAttributes(trace.StringAttribute("settings", redactServiceSettings(settings)))
 
 	return result, execute(ctx, timeout.SyscallWatcher, func() error {
 		var resultp *uint16
@@ -360,6 +362,31 @@ func HcsModifyServiceSettings(ctx gcontext.Context, settings string) (result str
 	})
 }
 
+// redactServiceSettings returns the service settings `settings` to add to a
+// span. The credential spec of a container credential guard request is
+// redacted, as it may hold secrets such as the input of a CCG plugin.
+func redactServiceSettings(settings string) string {
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(settings), &req); err != nil {
+		return log.Redacted
+	}
+	if req["PropertyType"] != string(hcsschema.PTContainerCredentialGuard) {
+		return settings
+	}
+	if s, ok := req["Settings"].(map[string]interface{}); ok {
+		if d, ok := s["OperationDetails"].(map[string]interface{}); ok {
+			if _, ok := d["CredentialSpec"]; ok {
+				d["CredentialSpec"] = log.Redacted
+			}
+		}
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return log.Redacted
+	}
+	return string(b)
+}
+
 func HcsRegisterComputeSystemCallback(ctx gcontext.Context, computeSystem HcsSystem, callback uintptr, context uintptr) (callbackHandle HcsCallback, hr error) {
 	ctx, span := trace.StartSpan(ctx, "HcsRegisterComputeSystemCallback")
 	defer span.End()
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Microsoft/hcsshim/internal/hcserror"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"go.opencensus.io/trace"
 )
 
@@ -14,12 +15,14 @@ import (
 // An activated layer must later be deactivated via DeactivateLayer.
 func ActivateLayer(ctx context.Context, path string) (err error) {
 	title := "hcsshim::ActivateLayer"
-	ctx, span := trace.StartSpan(ctx, title) //nolint:ineffassign,staticcheck
+	ctx, span := trace.StartSpan(ctx, title)
 	defer span.End()
 	defer func() { oc.SetSpanStatus(span, err) }()
 	span.AddAttributes(trace.StringAttribute("path", path))
 
-	err = activateLayer(&stdDriverInfo, path)
+	err = retry.Do(ctx, title, func() error {
+		return activateLayer(&stdDriverInfo, path)
+	})
 	if err != nil {
 		return hcserror.New(err, title+" - failed", "")
 	}
@@ -25,6 +25,11 @@ type baseLayerWriter struct {
 	err          error
 	hasUtilityVM bool
 	dirInfo      []dirInfo
+
+	deferBasicInfo bool
+	// pendingInfo is the basic information of the current file when it is
+	// deferred until the file is closed.
+	pendingInfo *winio.FileBasicInfo
 }
 
 type dirInfo struct {
@@ -56,9 +61,15 @@ func reapplyDirectoryTimes(root *os.File, dis []dirInfo) error {
 func (w *baseLayerWriter) closeCurrentFile() error {
 	if w.f != nil {
 		err := w.bw.Close()
+		if err == nil {
+			if err = setBasicInfo(w.f, w.pendingInfo); err != nil {
+				err = hcserror.New(err, "Failed to SetFileBasicInfo", w.f.Name())
+			}
+		}
 		err2 := w.f.Close()
 		w.f = nil
 		w.bw = nil
+		w.pendingInfo = nil
 		if err != nil {
 			return err
 		}
@@ -69,6 +80,23 @@ func (w *baseLayerWriter) closeCurrentFile() error {
 	return nil
 }
 
+func (w *baseLayerWriter) SetDeferBasicInfo(deferred bool) {
+	w.deferBasicInfo = deferred
+}
+
+func (w *baseLayerWriter) Preallocate(size int64) error {
+	return preallocate(w.f, size)
+}
+
+func (w *baseLayerWriter) CloseFile() (err error) {
+	defer func() {
+		if err != nil {
+			w.err = err
+		}
+	}()
+	return w.closeCurrentFile()
+}
+
 func (w *baseLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) (err error) {
 	defer func() {
 		if err != nil {
@@ -104,12 +132,19 @@ func (w *baseLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) (err e
 		return hcserror.New(err, "Failed to safefile.OpenRelative", name)
 	}
 
-	err = winio.SetFileBasicInfo(f, fileInfo)
-	if err != nil {
-		return hcserror.New(err, "Failed to SetFileBasicInfo", name)
+	var pendingInfo *winio.FileBasicInfo
+	if w.deferBasicInfo && extraFlags&winapi.FILE_DIRECTORY_FILE == 0 {
+		fi := *fileInfo
+		pendingInfo = &fi
+	} else {
+		err = winio.SetFileBasicInfo(f, fileInfo)
+		if err != nil {
+			return hcserror.New(err, "Failed to SetFileBasicInfo", name)
+		}
 	}
 
 	w.f = f
+	w.pendingInfo = pendingInfo
 	w.bw = winio.NewBackupFileWriter(f, true)
 	f = nil
 	return nil
@@ -0,0 +1,44 @@
+package wclayer
+
+import (
+	"os"
+	"unsafe"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// FilterFriendlyLayerWriter is implemented by the LayerWriters returned by
+// NewLayerWriter. It configures an import mode that minimizes the operations
+// that antivirus and other file system filter drivers intercept for each
+// file, as each intercepted operation can stall the import while the file is
+// scanned.
+type FilterFriendlyLayerWriter interface {
+	// SetDeferBasicInfo defers setting the times and attributes of each file
+	// until all of its data has been written, so that its metadata is changed
+	// once, after the data, rather than before and again by every write.
+	SetDeferBasicInfo(deferred bool)
+	// Preallocate reserves `size` bytes for the current file so that it is
+	// not extended by each write. It must be called after Add.
+	Preallocate(size int64) error
+	// CloseFile closes the current file once all of its data has been
+	// written, rather than on the next call to Add, AddLink, Remove or Close.
+	CloseFile() error
+}
+
+// preallocate sets the allocation size of `f` to `size`. Allocation beyond
+// the end of the file is released when the file is closed.
+func preallocate(f *os.File, size int64) error {
+	if f == nil || size <= 0 {
+		return nil
+	}
+	return windows.SetFileInformationByHandle(windows.Handle(f.Fd()), windows.FileAllocationInfo, (*byte)(unsafe.Pointer(&size)), uint32(unsafe.Sizeof(size)))
+}
+
+// setBasicInfo sets the basic information of `f` to `fi`, if any.
+func setBasicInfo(f *os.File, fi *winio.FileBasicInfo) error {
+	if f == nil || fi == nil {
+		return nil
+	}
+	return winio.SetFileBasicInfo(f, fi)
+}
@@ -3,12 +3,16 @@ package wclayer
 import (
 	"context"
 
+	"github.com/Microsoft/go-winio/pkg/security"
+	"github.com/Microsoft/hcsshim/internal/csv"
 	"github.com/Microsoft/hcsshim/internal/hcserror"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"go.opencensus.io/trace"
 )
 
-// GrantVmAccess adds access to a file for a given VM
+// GrantVmAccess adds access to a file for a given VM. For a file on a Cluster
+// Shared Volume the virtual machines group is granted access instead, as per
+// VM grants fail while the file is in redirected mode.
 func GrantVmAccess(ctx context.Context, vmid string, filepath string) (err error) {
 	title := "hcsshim::GrantVmAccess"
 	ctx, span := trace.StartSpan(ctx, title) //nolint:ineffassign,staticcheck
@@ -18,6 +22,9 @@ func GrantVmAccess(ctx context.Context, vmid string, filepath string) (err error
 		trace.StringAttribute("vm-id", vmid),
 		trace.StringAttribute("path", filepath))
 
+	if onCSV, _ := csv.IsCSV(filepath); onCSV {
+		return security.GrantVmGroupAccess(filepath)
+	}
 	err = grantVmAccess(vmid, filepath)
 	if err != nil {
 		return hcserror.New(err, title+" - failed", "")
@@ -347,6 +347,10 @@ type legacyLayerWriter struct {
 	PendingLinks    []pendingLink
 	pendingDirs     []pendingDir
 	currentIsDir    bool
+	deferBasicInfo  bool
+	// currentInfo is the basic information of the current file when it is
+	// deferred until the file is closed.
+	currentInfo *winio.FileBasicInfo
 }
 
 // newLegacyLayerWriter returns a LayerWriter that can write the contaler layer
@@ -458,14 +462,41 @@ func (w *legacyLayerWriter) reset() error {
 		w.backupWriter = nil
 	}
 	if w.currentFile != nil {
+		err := setBasicInfo(w.currentFile, w.currentInfo)
 		w.currentFile.Close()
 		w.currentFile = nil
 		w.currentFileName = ""
 		w.currentFileRoot = nil
+		w.currentInfo = nil
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+func (w *legacyLayerWriter) SetDeferBasicInfo(deferred bool) {
+	w.deferBasicInfo = deferred
+}
+
+func (w *legacyLayerWriter) Preallocate(size int64) error {
+	return preallocate(w.currentFile, size)
+}
+
+func (w *legacyLayerWriter) CloseFile() error {
+	return w.reset()
+}
+
+// applyBasicInfo sets the basic information of the new file `f` to `fi`, or
+// returns it to be set when `f` is closed if it is deferred.
+func (w *legacyLayerWriter) applyBasicInfo(f *os.File, fi *winio.FileBasicInfo) (*winio.FileBasicInfo, error) {
+	if w.deferBasicInfo && fi.FileAttributes&syscall.FILE_ATTRIBUTE_DIRECTORY == 0 {
+		deferred := *fi
+		return &deferred, nil
+	}
+	return nil, winio.SetFileBasicInfo(f, fi)
+}
+
 // copyFileWithMetadata copies a file using the backup/restore APIs in order to preserve metadata
 func copyFileWithMetadata(srcRoot, destRoot *os.File, subPath string, isDir bool) (fileInfo *winio.FileBasicInfo, err error) {
 	src, err := safefile.OpenRelative(
@@ -644,7 +675,7 @@ func (w *legacyLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) erro
 			}
 		}()
 
-		err = winio.SetFileBasicInfo(f, fileInfo)
+		info, err := w.applyBasicInfo(f, fileInfo)
 		if err != nil {
 			return err
 		}
@@ -652,6 +683,7 @@ func (w *legacyLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) erro
 		w.backupWriter = winio.NewBackupFileWriter(f, true)
 		w.bufWriter.Reset(w.backupWriter)
 		w.currentFile = f
+		w.currentInfo = info
 		w.currentFileName = name
 		w.currentFileRoot = w.destRoot
 		w.addedFiles[name] = true
@@ -682,7 +714,7 @@ func (w *legacyLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) erro
 
 	strippedFi := *fileInfo
 	strippedFi.FileAttributes = 0
-	err = winio.SetFileBasicInfo(f, &strippedFi)
+	info, err := w.applyBasicInfo(f, &strippedFi)
 	if err != nil {
 		return err
 	}
@@ -701,6 +733,7 @@ func (w *legacyLayerWriter) Add(name string, fileInfo *winio.FileBasicInfo) erro
 	}
 
 	w.currentFile = f
+	w.currentInfo = info
 	w.currentFileName = name
 	w.currentFileRoot = w.root
 	w.addedFiles[name] = true
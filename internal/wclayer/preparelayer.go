@@ -7,6 +7,7 @@ import (
 
 	"github.com/Microsoft/hcsshim/internal/hcserror"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"go.opencensus.io/trace"
 )
 
@@ -36,7 +37,9 @@ func PrepareLayer(ctx context.Context, path string, parentLayerPaths []string) (
 	// call to prepareLayer at a time vastly reduces the chance of a timeout.
 	prepareLayerLock.Lock()
 	defer prepareLayerLock.Unlock()
-	err = prepareLayer(&stdDriverInfo, path, layers)
+	err = retry.Do(ctx, title, func() error {
+		return prepareLayer(&stdDriverInfo, path, layers)
+	})
 	if err != nil {
 		return hcserror.New(err, title+" - failed", "")
 	}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Microsoft/hcsshim/internal/hcserror"
 	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/retry"
 	"go.opencensus.io/trace"
 )
 
@@ -12,12 +13,14 @@ import (
 // the given id.
 func UnprepareLayer(ctx context.Context, path string) (err error) {
 	title := "hcsshim::UnprepareLayer"
-	ctx, span := trace.StartSpan(ctx, title) //nolint:ineffassign,staticcheck
+	ctx, span := trace.StartSpan(ctx, title)
 	defer span.End()
 	defer func() { oc.SetSpanStatus(span, err) }()
 	span.AddAttributes(trace.StringAttribute("path", path))
 
-	err = unprepareLayer(&stdDriverInfo, path)
+	err = retry.Do(ctx, title, func() error {
+		return unprepareLayer(&stdDriverInfo, path)
+	})
 	if err != nil {
 		return hcserror.New(err, title+" - failed", "")
 	}
@@ -0,0 +1,3 @@
+package winapi
+
+//sys HcsCreateEmptyGuestStateFile(guestStateFilePath string) (hr error) = computecore.HcsCreateEmptyGuestStateFile?
@@ -0,0 +1,40 @@
+package winapi
+
+//sys CompactVirtualDisk(handle windows.Handle, flags uint32, parameters *CompactVirtualDiskParameters, overlapped *windows.Overlapped) (win32err error) = virtdisk.CompactVirtualDisk
+
+// CompactVirtualDiskParameters is the COMPACT_VIRTUAL_DISK_PARAMETERS struct.
+type CompactVirtualDiskParameters struct {
+	Version  uint32
+	Reserved uint32
+}
+
+const CompactVirtualDiskFlagNone = 0x0
+
+//sys ResizeVirtualDisk(handle windows.Handle, flags uint32, parameters *ResizeVirtualDiskParameters, overlapped *windows.Overlapped) (win32err error) = virtdisk.ResizeVirtualDisk
+
+// ResizeVirtualDiskParameters is the RESIZE_VIRTUAL_DISK_PARAMETERS struct.
+type ResizeVirtualDiskParameters struct {
+	Version  uint32
+	Reserved uint32
+	NewSize  uint64
+}
+
+const (
+	ResizeVirtualDiskFlagNone                   = 0x0
+	ResizeVirtualDiskFlagAllowUnsafeVirtualSize = 0x1
+)
+
+//sys GetVirtualDiskInformation(handle windows.Handle, infoSize *uint32, info *GetVirtualDiskInfoSize, sizeUsed *uint32) (win32err error) = virtdisk.GetVirtualDiskInformation
+
+// GetVirtualDiskInfoSize is the GET_VIRTUAL_DISK_INFO struct with the Size
+// member of its union, returned for GET_VIRTUAL_DISK_INFO_SIZE.
+type GetVirtualDiskInfoSize struct {
+	Version      uint32
+	_            uint32
+	VirtualSize  uint64
+	PhysicalSize uint64
+	BlockSize    uint32
+	SectorSize   uint32
+}
+
+const GetVirtualDiskInfoSizeVersion = 0x1
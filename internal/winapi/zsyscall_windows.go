@@ -37,12 +37,14 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
-	modiphlpapi = windows.NewLazySystemDLL("iphlpapi.dll")
-	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
-	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
-	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
-	modpsapi    = windows.NewLazySystemDLL("psapi.dll")
-	modcfgmgr32 = windows.NewLazySystemDLL("cfgmgr32.dll")
+	modiphlpapi    = windows.NewLazySystemDLL("iphlpapi.dll")
+	modkernel32    = windows.NewLazySystemDLL("kernel32.dll")
+	modntdll       = windows.NewLazySystemDLL("ntdll.dll")
+	modadvapi32    = windows.NewLazySystemDLL("advapi32.dll")
+	modpsapi       = windows.NewLazySystemDLL("psapi.dll")
+	modcfgmgr32    = windows.NewLazySystemDLL("cfgmgr32.dll")
+	modvirtdisk    = windows.NewLazySystemDLL("virtdisk.dll")
+	modcomputecore = windows.NewLazySystemDLL("computecore.dll")
 
 	procSetJobCompartmentId                    = modiphlpapi.NewProc("SetJobCompartmentId")
 	procSearchPathW                            = modkernel32.NewProc("SearchPathW")
@@ -71,6 +73,10 @@ var (
 	procNtOpenDirectoryObject                  = modntdll.NewProc("NtOpenDirectoryObject")
 	procNtQueryDirectoryObject                 = modntdll.NewProc("NtQueryDirectoryObject")
 	procRtlNtStatusToDosError                  = modntdll.NewProc("RtlNtStatusToDosError")
+	procCompactVirtualDisk                     = modvirtdisk.NewProc("CompactVirtualDisk")
+	procResizeVirtualDisk                      = modvirtdisk.NewProc("ResizeVirtualDisk")
+	procGetVirtualDiskInformation              = modvirtdisk.NewProc("GetVirtualDiskInformation")
+	procHcsCreateEmptyGuestStateFile           = modcomputecore.NewProc("HcsCreateEmptyGuestStateFile")
 )
 
 func SetJobCompartmentId(handle windows.Handle, compartmentId uint32) (win32Err error) {
@@ -362,3 +368,50 @@ func RtlNtStatusToDosError(status uint32) (winerr error) {
 	}
 	return
 }
+
+func CompactVirtualDisk(handle windows.Handle, flags uint32, parameters *CompactVirtualDiskParameters, overlapped *windows.Overlapped) (win32err error) {
+	r0, _, _ := syscall.Syscall6(procCompactVirtualDisk.Addr(), 4, uintptr(handle), uintptr(flags), uintptr(unsafe.Pointer(parameters)), uintptr(unsafe.Pointer(overlapped)), 0, 0)
+	if r0 != 0 {
+		win32err = syscall.Errno(r0)
+	}
+	return
+}
+
+func ResizeVirtualDisk(handle windows.Handle, flags uint32, parameters *ResizeVirtualDiskParameters, overlapped *windows.Overlapped) (win32err error) {
+	r0, _, _ := syscall.Syscall6(procResizeVirtualDisk.Addr(), 4, uintptr(handle), uintptr(flags), uintptr(unsafe.Pointer(parameters)), uintptr(unsafe.Pointer(overlapped)), 0, 0)
+	if r0 != 0 {
+		win32err = syscall.Errno(r0)
+	}
+	return
+}
+
+func GetVirtualDiskInformation(handle windows.Handle, infoSize *uint32, info *GetVirtualDiskInfoSize, sizeUsed *uint32) (win32err error) {
+	r0, _, _ := syscall.Syscall6(procGetVirtualDiskInformation.Addr(), 4, uintptr(handle), uintptr(unsafe.Pointer(infoSize)), uintptr(unsafe.Pointer(info)), uintptr(unsafe.Pointer(sizeUsed)), 0, 0)
+	if r0 != 0 {
+		win32err = syscall.Errno(r0)
+	}
+	return
+}
+
+func HcsCreateEmptyGuestStateFile(guestStateFilePath string) (hr error) {
+	var _p0 *uint16
+	_p0, hr = syscall.UTF16PtrFromString(guestStateFilePath)
+	if hr != nil {
+		return
+	}
+	return _HcsCreateEmptyGuestStateFile(_p0)
+}
+
+func _HcsCreateEmptyGuestStateFile(guestStateFilePath *uint16) (hr error) {
+	if hr = procHcsCreateEmptyGuestStateFile.Find(); hr != nil {
+		return
+	}
+	r0, _, _ := syscall.Syscall(procHcsCreateEmptyGuestStateFile.Addr(), 1, uintptr(unsafe.Pointer(guestStateFilePath)), 0, 0)
+	if int32(r0) < 0 {
+		if r0&0x1fff0000 == 0x00070000 {
+			r0 &= 0xffff
+		}
+		hr = syscall.Errno(r0)
+	}
+	return
+}
@@ -13,60 +13,153 @@ func layerPath(info *DriverInfo, id string) string {
 	return filepath.Join(info.HomeDir, id)
 }
 
+// Deprecated: Use ActivateLayerContext.
 func ActivateLayer(info DriverInfo, id string) error {
-	return wclayer.ActivateLayer(context.Background(), layerPath(&info, id))
+	return ActivateLayerContext(context.Background(), info, id)
 }
+
+func ActivateLayerContext(ctx context.Context, info DriverInfo, id string) error {
+	return wclayer.ActivateLayer(ctx, layerPath(&info, id))
+}
+
+// Deprecated: Use CreateLayerContext.
 func CreateLayer(info DriverInfo, id, parent string) error {
-	return wclayer.CreateLayer(context.Background(), layerPath(&info, id), parent)
+	return CreateLayerContext(context.Background(), info, id, parent)
 }
 
-// New clients should use CreateScratchLayer instead. Kept in to preserve API compatibility.
+func CreateLayerContext(ctx context.Context, info DriverInfo, id, parent string) error {
+	return wclayer.CreateLayer(ctx, layerPath(&info, id), parent)
+}
+
+// New clients should use CreateScratchLayerContext instead. Kept in to preserve API compatibility.
+//
+// Deprecated: Use CreateScratchLayerContext.
 func CreateSandboxLayer(info DriverInfo, layerId, parentId string, parentLayerPaths []string) error {
-	return wclayer.CreateScratchLayer(context.Background(), layerPath(&info, layerId), parentLayerPaths)
+	return CreateScratchLayerContext(context.Background(), info, layerId, parentId, parentLayerPaths)
 }
+
+// Deprecated: Use CreateScratchLayerContext.
 func CreateScratchLayer(info DriverInfo, layerId, parentId string, parentLayerPaths []string) error {
-	return wclayer.CreateScratchLayer(context.Background(), layerPath(&info, layerId), parentLayerPaths)
+	return CreateScratchLayerContext(context.Background(), info, layerId, parentId, parentLayerPaths)
 }
+
+func CreateScratchLayerContext(ctx context.Context, info DriverInfo, layerId, parentId string, parentLayerPaths []string) error {
+	return wclayer.CreateScratchLayer(ctx, layerPath(&info, layerId), parentLayerPaths)
+}
+
+// Deprecated: Use DeactivateLayerContext.
 func DeactivateLayer(info DriverInfo, id string) error {
-	return wclayer.DeactivateLayer(context.Background(), layerPath(&info, id))
+	return DeactivateLayerContext(context.Background(), info, id)
+}
+
+func DeactivateLayerContext(ctx context.Context, info DriverInfo, id string) error {
+	return wclayer.DeactivateLayer(ctx, layerPath(&info, id))
 }
+
+// Deprecated: Use DestroyLayerContext.
 func DestroyLayer(info DriverInfo, id string) error {
-	return wclayer.DestroyLayer(context.Background(), layerPath(&info, id))
+	return DestroyLayerContext(context.Background(), info, id)
 }
 
-// New clients should use ExpandScratchSize instead. Kept in to preserve API compatibility.
+func DestroyLayerContext(ctx context.Context, info DriverInfo, id string) error {
+	return wclayer.DestroyLayer(ctx, layerPath(&info, id))
+}
+
+// New clients should use ExpandScratchSizeContext instead. Kept in to preserve API compatibility.
+//
+// Deprecated: Use ExpandScratchSizeContext.
 func ExpandSandboxSize(info DriverInfo, layerId string, size uint64) error {
-	return wclayer.ExpandScratchSize(context.Background(), layerPath(&info, layerId), size)
+	return ExpandScratchSizeContext(context.Background(), info, layerId, size)
 }
+
+// Deprecated: Use ExpandScratchSizeContext.
 func ExpandScratchSize(info DriverInfo, layerId string, size uint64) error {
-	return wclayer.ExpandScratchSize(context.Background(), layerPath(&info, layerId), size)
+	return ExpandScratchSizeContext(context.Background(), info, layerId, size)
+}
+
+func ExpandScratchSizeContext(ctx context.Context, info DriverInfo, layerId string, size uint64) error {
+	return wclayer.ExpandScratchSize(ctx, layerPath(&info, layerId), size)
 }
+
+// Deprecated: Use ExportLayerContext.
 func ExportLayer(info DriverInfo, layerId string, exportFolderPath string, parentLayerPaths []string) error {
-	return wclayer.ExportLayer(context.Background(), layerPath(&info, layerId), exportFolderPath, parentLayerPaths)
+	return ExportLayerContext(context.Background(), info, layerId, exportFolderPath, parentLayerPaths)
+}
+
+func ExportLayerContext(ctx context.Context, info DriverInfo, layerId string, exportFolderPath string, parentLayerPaths []string) error {
+	return wclayer.ExportLayer(ctx, layerPath(&info, layerId), exportFolderPath, parentLayerPaths)
 }
+
+// Deprecated: Use GetLayerMountPathContext.
 func GetLayerMountPath(info DriverInfo, id string) (string, error) {
-	return wclayer.GetLayerMountPath(context.Background(), layerPath(&info, id))
+	return GetLayerMountPathContext(context.Background(), info, id)
+}
+
+func GetLayerMountPathContext(ctx context.Context, info DriverInfo, id string) (string, error) {
+	return wclayer.GetLayerMountPath(ctx, layerPath(&info, id))
 }
+
+// Deprecated: Use GetSharedBaseImagesContext.
 func GetSharedBaseImages() (imageData string, err error) {
-	return wclayer.GetSharedBaseImages(context.Background())
+	return GetSharedBaseImagesContext(context.Background())
+}
+
+func GetSharedBaseImagesContext(ctx context.Context) (imageData string, err error) {
+	return wclayer.GetSharedBaseImages(ctx)
 }
+
+// Deprecated: Use ImportLayerContext.
 func ImportLayer(info DriverInfo, layerID string, importFolderPath string, parentLayerPaths []string) error {
-	return wclayer.ImportLayer(context.Background(), layerPath(&info, layerID), importFolderPath, parentLayerPaths)
+	return ImportLayerContext(context.Background(), info, layerID, importFolderPath, parentLayerPaths)
+}
+
+func ImportLayerContext(ctx context.Context, info DriverInfo, layerID string, importFolderPath string, parentLayerPaths []string) error {
+	return wclayer.ImportLayer(ctx, layerPath(&info, layerID), importFolderPath, parentLayerPaths)
 }
+
+// Deprecated: Use LayerExistsContext.
 func LayerExists(info DriverInfo, id string) (bool, error) {
-	return wclayer.LayerExists(context.Background(), layerPath(&info, id))
+	return LayerExistsContext(context.Background(), info, id)
+}
+
+func LayerExistsContext(ctx context.Context, info DriverInfo, id string) (bool, error) {
+	return wclayer.LayerExists(ctx, layerPath(&info, id))
 }
+
+// Deprecated: Use PrepareLayerContext.
 func PrepareLayer(info DriverInfo, layerId string, parentLayerPaths []string) error {
-	return wclayer.PrepareLayer(context.Background(), layerPath(&info, layerId), parentLayerPaths)
+	return PrepareLayerContext(context.Background(), info, layerId, parentLayerPaths)
+}
+
+func PrepareLayerContext(ctx context.Context, info DriverInfo, layerId string, parentLayerPaths []string) error {
+	return wclayer.PrepareLayer(ctx, layerPath(&info, layerId), parentLayerPaths)
 }
+
+// Deprecated: Use ProcessBaseLayerContext.
 func ProcessBaseLayer(path string) error {
-	return wclayer.ProcessBaseLayer(context.Background(), path)
+	return ProcessBaseLayerContext(context.Background(), path)
+}
+
+func ProcessBaseLayerContext(ctx context.Context, path string) error {
+	return wclayer.ProcessBaseLayer(ctx, path)
 }
+
+// Deprecated: Use ProcessUtilityVMImageContext.
 func ProcessUtilityVMImage(path string) error {
-	return wclayer.ProcessUtilityVMImage(context.Background(), path)
+	return ProcessUtilityVMImageContext(context.Background(), path)
+}
+
+func ProcessUtilityVMImageContext(ctx context.Context, path string) error {
+	return wclayer.ProcessUtilityVMImage(ctx, path)
 }
+
+// Deprecated: Use UnprepareLayerContext.
 func UnprepareLayer(info DriverInfo, layerId string) error {
-	return wclayer.UnprepareLayer(context.Background(), layerPath(&info, layerId))
+	return UnprepareLayerContext(context.Background(), info, layerId)
+}
+
+func UnprepareLayerContext(ctx context.Context, info DriverInfo, layerId string) error {
+	return wclayer.UnprepareLayer(ctx, layerPath(&info, layerId))
 }
 
 type DriverInfo struct {
@@ -76,8 +169,13 @@ type DriverInfo struct {
 
 type GUID [16]byte
 
+// Deprecated: Use NameToGuidContext.
 func NameToGuid(name string) (id GUID, err error) {
-	g, err := wclayer.NameToGuid(context.Background(), name)
+	return NameToGuidContext(context.Background(), name)
+}
+
+func NameToGuidContext(ctx context.Context, name string) (id GUID, err error) {
+	g, err := wclayer.NameToGuid(ctx, name)
 	return g.ToWindowsArray(), err
 }
 
@@ -94,14 +192,24 @@ func (g *GUID) ToString() string {
 
 type LayerReader = wclayer.LayerReader
 
+// Deprecated: Use NewLayerReaderContext.
 func NewLayerReader(info DriverInfo, layerID string, parentLayerPaths []string) (LayerReader, error) {
-	return wclayer.NewLayerReader(context.Background(), layerPath(&info, layerID), parentLayerPaths)
+	return NewLayerReaderContext(context.Background(), info, layerID, parentLayerPaths)
+}
+
+func NewLayerReaderContext(ctx context.Context, info DriverInfo, layerID string, parentLayerPaths []string) (LayerReader, error) {
+	return wclayer.NewLayerReader(ctx, layerPath(&info, layerID), parentLayerPaths)
 }
 
 type LayerWriter = wclayer.LayerWriter
 
+// Deprecated: Use NewLayerWriterContext.
 func NewLayerWriter(info DriverInfo, layerID string, parentLayerPaths []string) (LayerWriter, error) {
-	return wclayer.NewLayerWriter(context.Background(), layerPath(&info, layerID), parentLayerPaths)
+	return NewLayerWriterContext(context.Background(), info, layerID, parentLayerPaths)
+}
+
+func NewLayerWriterContext(ctx context.Context, info DriverInfo, layerID string, parentLayerPaths []string) (LayerWriter, error) {
+	return wclayer.NewLayerWriter(ctx, layerPath(&info, layerID), parentLayerPaths)
 }
 
 type WC_LAYER_DESCRIPTOR = wclayer.WC_LAYER_DESCRIPTOR
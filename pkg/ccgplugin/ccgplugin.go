@@ -0,0 +1,66 @@
+// Package ccgplugin defines the protocol between hcsshim and the executables
+// that retrieve the input of Container Credential Guard (CCG) plugins.
+//
+// A gMSA credential spec for a non domain joined host names the CCG plugin
+// that ccg.exe loads to fetch the credentials of the host account, and the
+// input passed to it, in its `ActiveDirectoryConfig.HostAccountConfig`
+// section. When the shim creates a CCG instance for a credential spec whose
+// plugin has an executable registered with hcsshim, it runs the executable
+// and replaces the plugin input with the one returned. This lets a cloud
+// provider keep a reference to a secret in the credential spec, for example
+// the name of a key vault entry, and resolve it to the credentials only when
+// the container starts.
+//
+// The executable receives a JSON encoded Request on stdin and writes a JSON
+// encoded Response to stdout. A non-zero exit code fails the creation of the
+// container, with stderr as the error message.
+package ccgplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Request is sent to a plugin executable.
+type Request struct {
+	// ContainerID is the ID of the container the CCG instance is created for.
+	ContainerID string `json:"containerId"`
+	// PluginGUID is the GUID of the CCG plugin named by the credential spec.
+	PluginGUID string `json:"pluginGuid"`
+	// PluginInput is the plugin input of the credential spec.
+	PluginInput string `json:"pluginInput"`
+}
+
+// Response is returned by a plugin executable.
+type Response struct {
+	// PluginInput replaces the plugin input of the credential spec.
+	PluginInput string `json:"pluginInput"`
+}
+
+// Handler retrieves the plugin input for a request.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Serve implements the plugin executable side of the protocol by reading the
+// request from stdin, calling `h` and writing its response to stdout. It
+// exits the process with a non-zero exit code if `h` fails.
+func Serve(ctx context.Context, h Handler) {
+	if err := serve(ctx, os.Stdin, os.Stdout, h); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(ctx context.Context, r io.Reader, w io.Writer, h Handler) error {
+	var req Request
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode request: %s", err)
+	}
+	resp, err := h(ctx, &req)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,172 @@
+// +build windows
+
+// Package firewall manages the Windows Firewall rules that allow inbound
+// traffic to the published ports of containers. Every rule is created in a
+// rule group named after the container that owns it, so that all the rules of
+// a container can be removed together even if the process that created them
+// exited without cleaning up.
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// groupPrefix is the prefix of the firewall rule group of every owner.
+const groupPrefix = "hcsshim/"
+
+// Protocol is the transport protocol of a published port.
+type Protocol string
+
+const (
+	ProtocolTCP Protocol = "TCP"
+	ProtocolUDP Protocol = "UDP"
+)
+
+// PortRule allows inbound traffic to a published port.
+type PortRule struct {
+	Protocol Protocol
+	Port     uint16
+}
+
+func (r PortRule) String() string {
+	return fmt.Sprintf("%s/%d", strings.ToLower(string(r.Protocol)), r.Port)
+}
+
+// ownerRegex matches the container IDs that rules can be owned by. It
+// prevents owners from being interpreted by PowerShell.
+var ownerRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.@-]*$`)
+
+func group(owner string) (string, error) {
+	if !ownerRegex.MatchString(owner) {
+		return "", errors.Errorf("invalid firewall rule owner %q", owner)
+	}
+	return groupPrefix + owner, nil
+}
+
+// AddPortRules creates a rule allowing inbound traffic for each of `rules` in
+// the group of `owner`, typically the ID of the container that publishes the
+// ports. On failure the rules already created for `owner` are removed.
+func AddPortRules(ctx context.Context, owner string, rules []PortRule) (err error) {
+	g, err := group(owner)
+	if err != nil {
+		return err
+	}
+	var script strings.Builder
+	for _, r := range rules {
+		if r.Protocol != ProtocolTCP && r.Protocol != ProtocolUDP {
+			return errors.Errorf("unsupported firewall rule protocol %q", r.Protocol)
+		}
+		if r.Port == 0 {
+			return errors.New("firewall rule port must be non-zero")
+		}
+		fmt.Fprintf(&script,
+			"New-NetFirewallRule -DisplayName '%s %s' -Group '%s' -Direction Inbound -Action Allow -Protocol %s -LocalPort %d | Out-Null\n",
+			g, r, g, r.Protocol, r.Port)
+	}
+	if script.Len() == 0 {
+		return nil
+	}
+	defer func() {
+		if err != nil {
+			_ = RemovePortRules(ctx, owner)
+		}
+	}()
+	if _, err := powershell(ctx, script.String()); err != nil {
+		return errors.Wrapf(err, "failed to add firewall rules for %s", owner)
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"owner": owner,
+		"rules": rules,
+	}).Debug("added firewall rules")
+	return nil
+}
+
+// RemovePortRules removes all the rules in the group of `owner`. It is not an
+// error if `owner` has no rules.
+func RemovePortRules(ctx context.Context, owner string) error {
+	g, err := group(owner)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf("Get-NetFirewallRule -Group '%s' -ErrorAction SilentlyContinue | Remove-NetFirewallRule", g)
+	if _, err := powershell(ctx, script); err != nil {
+		return errors.Wrapf(err, "failed to remove firewall rules for %s", owner)
+	}
+	log.G(ctx).WithField("owner", owner).Debug("removed firewall rules")
+	return nil
+}
+
+// Owners returns the owners that have firewall rules, so that callers can
+// remove the rules of containers that no longer exist.
+func Owners(ctx context.Context) ([]string, error) {
+	script := fmt.Sprintf("Get-NetFirewallRule -Group '%s*' -ErrorAction SilentlyContinue | ForEach-Object { $_.Group }", groupPrefix)
+	out, err := powershell(ctx, script)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list firewall rules")
+	}
+	seen := make(map[string]struct{})
+	var owners []string
+	for _, line := range strings.Split(out, "\n") {
+		owner := strings.TrimPrefix(strings.TrimSpace(line), groupPrefix)
+		if owner == "" || !ownerRegex.MatchString(owner) {
+			continue
+		}
+		if _, ok := seen[owner]; !ok {
+			seen[owner] = struct{}{}
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
+
+// powershell runs `script` and returns its standard output.
+func powershell(ctx context.Context, script string) (string, error) {
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", "$ErrorActionPreference = 'Stop'\n"+script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// NamespacePortRules returns the rules for the ports published by the NAT
+// policies of the endpoints in the HNS namespace `namespaceID`.
+func NamespacePortRules(namespaceID string) ([]PortRule, error) {
+	endpoints, err := hns.GetNamespaceEndpoints(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	var rules []PortRule
+	for _, id := range endpoints {
+		endpoint, err := hns.GetHNSEndpointByID(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range endpoint.Policies {
+			var policy hns.NatPolicy
+			if err := json.Unmarshal(raw, &policy); err != nil || policy.Type != hns.Nat || policy.ExternalPort == 0 {
+				continue
+			}
+			rules = append(rules, PortRule{
+				Protocol: Protocol(strings.ToUpper(policy.Protocol)),
+				Port:     policy.ExternalPort,
+			})
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,146 @@
+// Package gcs provides access to the guest compute service (GCS) of a utility
+// VM over its bridge connection, so that tooling outside of hcsshim can
+// interact with a UVM's guest without reimplementing the bridge protocol.
+package gcs
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/gcs"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/sirupsen/logrus"
+)
+
+// LinuxGcsVsockPort is the vsock port that the Linux GCS connects to on the
+// host.
+const LinuxGcsVsockPort = gcs.LinuxGcsVsockPort
+
+// WindowsGcsHvsockServiceID is the hvsock service ID that the Windows GCS
+// connects to on the host.
+var WindowsGcsHvsockServiceID = gcs.WindowsGcsHvsockServiceID
+
+// Process is a process running in the guest.
+type Process = cow.Process
+
+// GuestDefinedCapabilities are the capabilities reported by the guest.
+type GuestDefinedCapabilities = schema1.GuestDefinedCapabilities
+
+// Statistics are the resource usage statistics of a container in the guest.
+type Statistics = hcsschema.Statistics
+
+// GuestConnection is a connection to the GCS of a utility VM.
+type GuestConnection interface {
+	// OS returns the operating system of the guest, "windows" or "linux".
+	OS() string
+	// Protocol returns the bridge protocol version negotiated with the guest.
+	Protocol() uint32
+	// Capabilities returns the capabilities reported by the guest.
+	Capabilities() *GuestDefinedCapabilities
+	// Modify sends a modify settings request for the UVM to the guest.
+	Modify(ctx context.Context, settings interface{}) error
+	// CreateProcess creates a process in the UVM, outside of any container.
+	CreateProcess(ctx context.Context, settings interface{}) (Process, error)
+	// ExecInContainer creates a process in the container `cid`.
+	ExecInContainer(ctx context.Context, cid string, settings interface{}) (Process, error)
+	// ContainerStatistics returns the statistics of the container `cid`.
+	ContainerStatistics(ctx context.Context, cid string) (*Statistics, error)
+	// CopyFile writes the contents of `r` to `guestPath` in the UVM.
+	CopyFile(ctx context.Context, guestPath string, r io.Reader) error
+	// DumpStacks returns the stacks of the GCS.
+	DumpStacks(ctx context.Context) (string, error)
+	// Close closes the connection.
+	Close() error
+}
+
+// Config contains the options for connecting to a GCS.
+type Config struct {
+	// Conn is the connection that the GCS made to the host. It will be
+	// closed when there is an error or the GuestConnection is closed.
+	Conn io.ReadWriteCloser
+	// VMID is the runtime ID of the UVM, used to listen for the stdio
+	// connections of processes.
+	VMID guid.GUID
+	// Log specifies the logrus entry to use for async log messages.
+	Log *logrus.Entry
+}
+
+// Connect starts the bridge protocol on `cfg.Conn`. The guest only makes one
+// connection to the host, so this cannot be used for a UVM that is managed
+// by a shim.
+func Connect(ctx context.Context, cfg *Config) (GuestConnection, error) {
+	l := cfg.Log
+	if l == nil {
+		l = logrus.NewEntry(logrus.StandardLogger())
+	}
+	gcc := &gcs.GuestConnectionConfig{
+		Conn:     cfg.Conn,
+		Log:      l,
+		IoListen: gcs.HvsockIoListen(cfg.VMID),
+	}
+	gc, err := gcc.Connect(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return &guestConnection{gc: gc}, nil
+}
+
+type guestConnection struct {
+	gc *gcs.GuestConnection
+}
+
+var _ GuestConnection = &guestConnection{}
+
+func (c *guestConnection) OS() string {
+	return c.gc.OS()
+}
+
+func (c *guestConnection) Protocol() uint32 {
+	return c.gc.Protocol()
+}
+
+func (c *guestConnection) Capabilities() *GuestDefinedCapabilities {
+	return c.gc.Capabilities()
+}
+
+func (c *guestConnection) Modify(ctx context.Context, settings interface{}) error {
+	return c.gc.Modify(ctx, settings)
+}
+
+func (c *guestConnection) CreateProcess(ctx context.Context, settings interface{}) (Process, error) {
+	return c.gc.CreateProcess(ctx, settings)
+}
+
+func (c *guestConnection) ExecInContainer(ctx context.Context, cid string, settings interface{}) (Process, error) {
+	return c.gc.ExecInContainer(ctx, cid, settings)
+}
+
+func (c *guestConnection) ContainerStatistics(ctx context.Context, cid string) (*Statistics, error) {
+	props, err := c.gc.ContainerPropertiesV2(ctx, cid, hcsschema.PTStatistics)
+	if err != nil {
+		return nil, err
+	}
+	return props.Statistics, nil
+}
+
+func (c *guestConnection) CopyFile(ctx context.Context, guestPath string, r io.Reader) error {
+	if c.gc.OS() != "linux" {
+		return errors.New("copying files is only supported for Linux guests")
+	}
+	copyCmd := cmd.CommandContext(ctx, c.gc, "sh", "-c", `cat > "$0"`, guestPath)
+	copyCmd.Stdin = r
+	return copyCmd.Run()
+}
+
+func (c *guestConnection) DumpStacks(ctx context.Context) (string, error) {
+	return c.gc.DumpStacks(ctx)
+}
+
+func (c *guestConnection) Close() error {
+	return c.gc.Close()
+}
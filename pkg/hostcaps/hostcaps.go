@@ -0,0 +1,165 @@
+// +build windows
+
+// Package hostcaps reports which hcsshim features are usable on the current
+// host, so that callers do not need to keep their own tables of the Windows
+// builds and HCS schema versions each feature requires.
+package hostcaps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/osversion"
+	"golang.org/x/sys/windows"
+)
+
+// Feature is an hcsshim feature that depends on the host.
+type Feature string
+
+const (
+	// FeatureCimFS is support for Composite Image File System layers.
+	FeatureCimFS Feature = "CimFS"
+	// FeatureGPUPartitioning is support for assigning GPU partitions (GPU-P)
+	// to utility VMs.
+	FeatureGPUPartitioning Feature = "GPUPartitioning"
+	// FeatureSNPIsolation is support for AMD SEV-SNP isolated utility VMs.
+	FeatureSNPIsolation Feature = "SNPIsolation"
+	// FeatureDynamicMemory is support for resizing the memory of, and
+	// updating the memory hints of, a running utility VM.
+	FeatureDynamicMemory Feature = "DynamicMemory"
+	// FeatureHvSocketLoopback is support for hvsocket connections between
+	// processes on the host.
+	FeatureHvSocketLoopback Feature = "HvSocketLoopback"
+)
+
+// SchemaVersion is an HCS schema version.
+type SchemaVersion struct {
+	Major int32
+	Minor int32
+}
+
+func (v SchemaVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// less returns true if `v` is an older version than `o`.
+func (v SchemaVersion) less(o SchemaVersion) bool {
+	return v.Major < o.Major || (v.Major == o.Major && v.Minor < o.Minor)
+}
+
+// requirement is what a feature requires of the host.
+type requirement struct {
+	// minBuild is the first Windows build that supports the feature.
+	minBuild uint16
+	// minSchema is the oldest HCS schema version that supports the feature.
+	minSchema SchemaVersion
+	// probe, if non-nil, checks for support that can not be inferred from
+	// the build and schema version.
+	probe func() bool
+}
+
+var requirements = map[Feature]requirement{
+	FeatureCimFS: {
+		minBuild:  osversion.V21H2Server,
+		minSchema: SchemaVersion{2, 1},
+		probe:     dllPresent("cimfs.dll"),
+	},
+	FeatureGPUPartitioning: {
+		minBuild:  osversion.V21H2Server,
+		minSchema: SchemaVersion{2, 3},
+	},
+	FeatureSNPIsolation: {
+		minBuild:  osversion.V21H2Server,
+		minSchema: SchemaVersion{2, 5},
+	},
+	FeatureDynamicMemory: {
+		minBuild:  osversion.RS5,
+		minSchema: SchemaVersion{2, 1},
+	},
+	FeatureHvSocketLoopback: {
+		minBuild:  osversion.V19H1,
+		minSchema: SchemaVersion{2, 1},
+	},
+}
+
+// Capabilities are the capabilities of the host.
+type Capabilities struct {
+	// Build is the Windows build of the host.
+	Build uint16
+	// SchemaVersions are the HCS schema versions supported by the host.
+	SchemaVersions []SchemaVersion
+	// Features are the features usable on the host.
+	Features map[Feature]bool
+}
+
+// Supports returns true if `f` is usable on the host.
+func (c *Capabilities) Supports(f Feature) bool {
+	return c.Features[f]
+}
+
+// supportsSchema returns true if the host supports a schema version of the
+// same major version as `v` and at least its minor version.
+func (c *Capabilities) supportsSchema(v SchemaVersion) bool {
+	for _, s := range c.SchemaVersions {
+		if s.Major == v.Major && !s.less(v) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	detectOnce sync.Once
+	detected   *Capabilities
+	detectErr  error
+)
+
+// Detect returns the capabilities of the host. The host is only queried on
+// the first call.
+func Detect(ctx context.Context) (*Capabilities, error) {
+	detectOnce.Do(func() {
+		detected, detectErr = detect(ctx)
+	})
+	return detected, detectErr
+}
+
+func detect(ctx context.Context) (*Capabilities, error) {
+	c := &Capabilities{
+		Build:    osversion.Build(),
+		Features: make(map[Feature]bool),
+	}
+	q := hcsschema.PropertyQuery{
+		PropertyTypes: []hcsschema.PropertyType{hcsschema.PTBasic},
+	}
+	serviceProps, err := hcs.GetServiceProperties(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve HCS basic information: %s", err)
+	}
+	if len(serviceProps.Properties) != 1 {
+		return nil, errors.New("wrong number of service properties present")
+	}
+	info := &hcsschema.BasicInformation{}
+	if err := json.Unmarshal(serviceProps.Properties[0], info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal HCS basic information: %s", err)
+	}
+	for _, v := range info.SupportedSchemaVersions {
+		c.SchemaVersions = append(c.SchemaVersions, SchemaVersion{v.Major, v.Minor})
+	}
+	for f, r := range requirements {
+		c.Features[f] = c.Build >= r.minBuild && c.supportsSchema(r.minSchema) && (r.probe == nil || r.probe())
+	}
+	return c, nil
+}
+
+// dllPresent returns a probe that checks that the system DLL `name` can be
+// loaded.
+func dllPresent(name string) func() bool {
+	return func() bool {
+		return windows.NewLazySystemDLL(name).Load() == nil
+	}
+}
@@ -0,0 +1,242 @@
+//go:build go1.16
+// +build go1.16
+
+// Package layerfs provides a read only io/fs view of the merged contents of
+// a chain of Windows container layers, so that their files can be walked
+// without activating the layers or mounting them on the host.
+package layerfs
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	filesPath      = "Files"
+	tombstonesFile = "tombstones.txt"
+	tombstonesHdr  = "\xef\xbb\xbfVersion 1.0"
+)
+
+type layer struct {
+	files fs.FS
+	// tombstones are the lower cased paths, relative to the layer's files,
+	// of the files deleted in this layer.
+	tombstones map[string]struct{}
+}
+
+// FS is the merged view of a chain of layers. It implements fs.FS,
+// fs.StatFS and fs.ReadDirFS.
+type FS struct {
+	layers []*layer
+}
+
+var (
+	_ fs.StatFS    = &FS{}
+	_ fs.ReadDirFS = &FS{}
+)
+
+// New returns the merged view of the layers at `layerPaths`, ordered from
+// the top-most layer to the base layer in the same way as parentLayerPaths.
+// Each layer is a directory in the legacy layer format with its contents
+// under `Files` and, for layers other than a base layer, the files it
+// deletes listed in `tombstones.txt`.
+func New(layerPaths []string) (*FS, error) {
+	f := &FS{}
+	for _, p := range layerPaths {
+		ts, err := readTombstones(p)
+		if err != nil {
+			return nil, err
+		}
+		f.layers = append(f.layers, &layer{
+			files:      os.DirFS(filepath.Join(p, filesPath)),
+			tombstones: ts,
+		})
+	}
+	return f, nil
+}
+
+func readTombstones(layerPath string) (map[string]struct{}, error) {
+	ts := make(map[string]struct{})
+	tf, err := os.Open(filepath.Join(layerPath, tombstonesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ts, nil
+		}
+		return nil, err
+	}
+	defer tf.Close()
+	s := bufio.NewScanner(tf)
+	if !s.Scan() || s.Text() != tombstonesHdr {
+		return nil, errors.New("invalid tombstones file")
+	}
+	for s.Scan() {
+		t := strings.TrimPrefix(strings.Replace(s.Text(), `\`, "/", -1), "/")
+		if t != "" {
+			ts[strings.ToLower(t)] = struct{}{}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// deleted returns if `name` or one of its parents is deleted in `l`.
+func (l *layer) deleted(name string) bool {
+	for n := strings.ToLower(name); n != "." && n != "/"; n = path.Dir(n) {
+		if _, ok := l.tombstones[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the index of the top-most layer that contains `name` and its
+// file info.
+func (f *FS) find(name string) (int, fs.FileInfo, error) {
+	for i, l := range f.layers {
+		fi, err := fs.Stat(l.files, name)
+		if err == nil {
+			return i, fi, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return 0, nil, err
+		}
+		if l.deleted(name) {
+			break
+		}
+	}
+	return 0, nil, fs.ErrNotExist
+}
+
+// Open opens the file `name` from the top-most layer containing it. Opening
+// a directory returns the merged directory of all layers.
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	i, fi, err := f.find(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if !fi.IsDir() {
+		return f.layers[i].files.Open(name)
+	}
+	entries, err := f.readDir(name, i)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &dir{info: fi, entries: entries}, nil
+}
+
+// Stat returns the file info of `name` in the top-most layer containing it.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	_, fi, err := f.find(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return fi, nil
+}
+
+// ReadDir returns the merged entries of the directory `name`, sorted by
+// file name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	i, fi, err := f.find(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !fi.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return f.readDir(name, i)
+}
+
+// readDir merges the entries of the directory `name` from layer `top` down.
+// Entries in upper layers hide those with the same name below them, and the
+// merge stops at a layer that deletes the directory or has a file in its
+// place.
+func (f *FS) readDir(name string, top int) ([]fs.DirEntry, error) {
+	seen := make(map[string]struct{})
+	var entries []fs.DirEntry
+	for _, l := range f.layers[top:] {
+		des, err := fs.ReadDir(l.files, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				if l.deleted(name) {
+					break
+				}
+				continue
+			}
+			if fi, serr := fs.Stat(l.files, name); serr == nil && !fi.IsDir() {
+				break
+			}
+			return nil, err
+		}
+		for _, de := range des {
+			key := strings.ToLower(de.Name())
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			entries = append(entries, de)
+		}
+		// Hide the entries this layer deletes from the layers below.
+		for t := range l.tombstones {
+			if path.Dir(t) == strings.ToLower(name) {
+				seen[path.Base(t)] = struct{}{}
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// dir is an open merged directory.
+type dir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = &dir{}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
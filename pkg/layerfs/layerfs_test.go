@@ -0,0 +1,85 @@
+//go:build go1.16
+// +build go1.16
+
+package layerfs
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func writeLayer(t *testing.T, files map[string]string, tombstones []string) string {
+	t.Helper()
+	root, err := ioutil.TempDir("", "layerfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	for name, content := range files {
+		p := filepath.Join(root, filesPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tombstones != nil {
+		content := tombstonesHdr + "\n"
+		for _, ts := range tombstones {
+			content += ts + "\n"
+		}
+		if err := ioutil.WriteFile(filepath.Join(root, tombstonesFile), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func Test_LayerFS(t *testing.T) {
+	base := writeLayer(t, map[string]string{
+		"Windows/a.txt":      "base a",
+		"Windows/b.txt":      "base b",
+		"Program Files/x.db": "x",
+	}, nil)
+	top := writeLayer(t, map[string]string{
+		"Windows/a.txt": "top a",
+		"Windows/c.txt": "top c",
+	}, []string{`\Windows\b.txt`, `\Program Files`})
+
+	f, err := New([]string{top, base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := fs.ReadFile(f, "Windows/a.txt")
+	if err != nil || string(b) != "top a" {
+		t.Fatalf("expected the top layer's file, got %q: %v", b, err)
+	}
+	if _, err := f.Stat("Windows/b.txt"); err == nil {
+		t.Fatal("expected the deleted file to not exist")
+	}
+	if _, err := f.Stat("Program Files/x.db"); err == nil {
+		t.Fatal("expected the file in the deleted directory to not exist")
+	}
+
+	entries, err := f.ReadDir("Windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "a.txt" || names[1] != "c.txt" {
+		t.Fatalf("unexpected merged directory entries %v", names)
+	}
+
+	if err := fstest.TestFS(f, "Windows/a.txt", "Windows/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,252 @@
+// Package layerstore finds and removes the Windows container layer
+// directories in a layer store that are no longer part of any layer chain in
+// use, which are left behind by failed imports and crashes.
+package layerstore
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/wclayer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	layerChainFile = "layerchain.json"
+	// holdsDir is the directory in the store root that holds are recorded in.
+	// Directories in the store root starting with "." are never collected.
+	holdsDir = ".holds"
+)
+
+// GCOptions are the options for GarbageCollect.
+type GCOptions struct {
+	// Roots are the layers that are in use, typically the top-most layer of
+	// every image and the scratch layer of every container. A root and every
+	// layer in its `layerchain.json` is kept. Roots do not need to be in the
+	// store, but at least one root is required.
+	Roots []string
+	// GracePeriod keeps orphaned layers that were modified more recently than
+	// this, so that layers still being imported are not removed.
+	GracePeriod time.Duration
+	// DryRun only reports the layers that would be removed.
+	DryRun bool
+	// InUse, if set, reports whether the layer at `path` is in use by the
+	// caller, such as a layer of a container being created. Layers mounted on
+	// the host are always in use.
+	InUse func(path string) bool
+}
+
+// GCResult is the result of GarbageCollect.
+type GCResult struct {
+	// Removed are the paths of the orphaned layers that were removed, or that
+	// would have been removed for a dry run.
+	Removed []string
+	// Held are the paths of the orphaned layers that were kept because of a
+	// hold.
+	Held []string
+	// InUse are the paths of the orphaned layers that were kept because they
+	// are mounted on the host or `GCOptions.InUse` reported them in use.
+	InUse []string
+	// Failed are the paths of the orphaned layers that could not be checked
+	// or removed.
+	Failed []string
+}
+
+// layerMounted reports whether the layer at `path` is activated and mounted
+// on the host, in which case its mount path is a volume rather than `path`.
+// It is a variable so that tests can replace it.
+var layerMounted = func(ctx context.Context, path string) (bool, error) {
+	mountPath, err := wclayer.GetLayerMountPath(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	return mountPath != "" && normalize(mountPath) != normalize(path), nil
+}
+
+// GarbageCollect removes the layer directories directly under `storeRoot`
+// that are not referenced by the layer chain of any of `opts.Roots`, have no
+// hold, are not in use and are older than the grace period.
+//
+// The parents of an orphaned layer that is kept, because of its hold, grace
+// period or use, are kept as well. Directories that are not layers are never
+// removed, nor is anything if the chain of a root or of a kept layer can not
+// be read.
+//
+// The caller must ensure that no layer is created with one of the orphaned
+// layers as a parent while GarbageCollect is running.
+func GarbageCollect(ctx context.Context, storeRoot string, opts *GCOptions) (*GCResult, error) {
+	if len(opts.Roots) == 0 {
+		return nil, errors.New("no roots given, every layer would be removed")
+	}
+	referenced := make(map[string]struct{})
+	reference := func(layerPath string) error {
+		chain, err := layerChain(layerPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read layer chain of %s", layerPath)
+		}
+		for _, l := range append(chain, layerPath) {
+			referenced[normalize(l)] = struct{}{}
+		}
+		return nil
+	}
+	for _, root := range opts.Roots {
+		if err := reference(root); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := ioutil.ReadDir(storeRoot)
+	if err != nil {
+		return nil, err
+	}
+	result := &GCResult{}
+	var orphans []string
+	for _, fi := range entries {
+		if !fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(storeRoot, fi.Name())
+		if _, ok := referenced[normalize(p)]; ok || !isLayer(p) {
+			continue
+		}
+		keep := false
+		if time.Since(fi.ModTime()) < opts.GracePeriod {
+			keep = true
+		} else if holds, err := Holds(storeRoot, fi.Name()); err != nil {
+			return nil, err
+		} else if len(holds) != 0 {
+			result.Held = append(result.Held, p)
+			keep = true
+		} else if mounted, err := layerMounted(ctx, p); err != nil {
+			log.G(ctx).WithFields(logrus.Fields{
+				logrus.ErrorKey: err,
+				"path":          p,
+			}).Warning("failed to check if orphaned layer is mounted")
+			result.Failed = append(result.Failed, p)
+			keep = true
+		} else if mounted || (opts.InUse != nil && opts.InUse(p)) {
+			result.InUse = append(result.InUse, p)
+			keep = true
+		}
+		if keep {
+			if err := reference(p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		orphans = append(orphans, p)
+	}
+
+	for _, p := range orphans {
+		// The layer is a parent of a kept orphan.
+		if _, ok := referenced[normalize(p)]; ok {
+			continue
+		}
+		if !opts.DryRun {
+			if err := wclayer.DestroyLayer(ctx, p); err != nil {
+				log.G(ctx).WithFields(logrus.Fields{
+					logrus.ErrorKey: err,
+					"path":          p,
+				}).Warning("failed to remove orphaned layer")
+				result.Failed = append(result.Failed, p)
+				continue
+			}
+		}
+		result.Removed = append(result.Removed, p)
+	}
+	return result, nil
+}
+
+// isLayer reports whether `p` looks like a layer directory: a read-only layer
+// has a `Files` directory and a scratch layer a `sandbox.vhdx`, and either has
+// a `layerchain.json` unless it is a base layer.
+func isLayer(p string) bool {
+	for _, name := range []string{"Files", "sandbox.vhdx", layerChainFile} {
+		if _, err := os.Stat(filepath.Join(p, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// layerChain returns the parent layers of `layerPath`. A base layer has no
+// `layerchain.json`.
+func layerChain(layerPath string) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(layerPath, layerChainFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var chain []string
+	if err := json.Unmarshal(b, &chain); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// normalize returns `p` in the form used to compare layer paths.
+func normalize(p string) string {
+	return strings.ToLower(filepath.Clean(p))
+}
+
+// Hold prevents GarbageCollect from removing the layer `name` in `storeRoot`
+// until `label` is released, for example while an image referencing it is
+// being pulled.
+func Hold(storeRoot, name, label string) error {
+	if err := validateHold(name, label); err != nil {
+		return err
+	}
+	dir := filepath.Join(storeRoot, holdsDir, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, label), nil, 0600)
+}
+
+// ReleaseHold removes the hold `label` of the layer `name` in `storeRoot`.
+func ReleaseHold(storeRoot, name, label string) error {
+	if err := validateHold(name, label); err != nil {
+		return err
+	}
+	dir := filepath.Join(storeRoot, holdsDir, name)
+	if err := os.Remove(filepath.Join(dir, label)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Remove the layer's directory once its last hold is released.
+	_ = os.Remove(dir)
+	return nil
+}
+
+// Holds returns the labels of the holds on the layer `name` in `storeRoot`.
+func Holds(storeRoot, name string) ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(storeRoot, holdsDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var labels []string
+	for _, e := range entries {
+		labels = append(labels, e.Name())
+	}
+	return labels, nil
+}
+
+func validateHold(name, label string) error {
+	for _, s := range []string{name, label} {
+		if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\:`) {
+			return errors.Errorf("invalid layer name or hold label %q", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package layerstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setupTestStore creates a store with the base layer `base`, the layer `app`
+// on top of it that is the root given to GarbageCollect, and the orphaned
+// layer `orphan` on top of `base`, and returns the store root.
+func setupTestStore(t *testing.T) string {
+	root, err := ioutil.TempDir("", "layerstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createTestLayer(t, root, "base")
+	createTestLayer(t, root, "app", "base")
+	createTestLayer(t, root, "orphan", "base")
+	// A directory that is not a layer is never removed.
+	if err := os.Mkdir(filepath.Join(root, "notalayer"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func createTestLayer(t *testing.T, root, name string, parents ...string) string {
+	p := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Join(p, "Files"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if len(parents) == 0 {
+		return p
+	}
+	var chain []string
+	for _, parent := range parents {
+		chain = append(chain, filepath.Join(root, parent))
+	}
+	b, err := json.Marshal(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p, layerChainFile), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// stubLayerMounted replaces the layer mount check with `f` until the returned
+// function is called.
+func stubLayerMounted(f func(ctx context.Context, path string) (bool, error)) func() {
+	old := layerMounted
+	layerMounted = f
+	return func() { layerMounted = old }
+}
+
+func notMounted(ctx context.Context, path string) (bool, error) {
+	return false, nil
+}
+
+func Test_GarbageCollect_NoRoots(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+
+	if _, err := GarbageCollect(context.Background(), root, &GCOptions{DryRun: true}); err == nil {
+		t.Fatal("expected GarbageCollect without roots to fail")
+	}
+}
+
+func Test_GarbageCollect_DryRun(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	defer stubLayerMounted(notMounted)()
+
+	result, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{filepath.Join(root, "app")},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(root, "orphan")
+	if !reflect.DeepEqual(result.Removed, []string{orphan}) {
+		t.Fatalf("expected only %s to be removed, got: %+v", orphan, result)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("expected dry run to keep %s: %s", orphan, err)
+	}
+}
+
+func Test_GarbageCollect_GracePeriod(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	defer stubLayerMounted(notMounted)()
+
+	result, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:       []string{filepath.Join(root, "app")},
+		GracePeriod: time.Hour,
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 0 {
+		t.Fatalf("expected new layers to be kept, got: %+v", result)
+	}
+}
+
+func Test_GarbageCollect_HoldKeepsParents(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	defer stubLayerMounted(notMounted)()
+	// `held` is an orphan whose parent `orphan` is only referenced by it.
+	held := createTestLayer(t, root, "held", "orphan", "base")
+	if err := Hold(root, "held", "pull"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{filepath.Join(root, "app")},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 0 || !reflect.DeepEqual(result.Held, []string{held}) {
+		t.Fatalf("expected %s and its parents to be held, got: %+v", held, result)
+	}
+
+	if err := ReleaseHold(root, "held", "pull"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{filepath.Join(root, "app")},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{held, filepath.Join(root, "orphan")}; !reflect.DeepEqual(result.Removed, expected) {
+		t.Fatalf("expected %v to be removed once released, got: %+v", expected, result)
+	}
+}
+
+func Test_GarbageCollect_InUse(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	mounted := createTestLayer(t, root, "mounted", "base")
+	orphan := filepath.Join(root, "orphan")
+	defer stubLayerMounted(func(ctx context.Context, path string) (bool, error) {
+		return path == mounted, nil
+	})()
+
+	result, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{filepath.Join(root, "app")},
+		DryRun: true,
+		InUse: func(path string) bool {
+			return path == orphan
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 0 || !reflect.DeepEqual(result.InUse, []string{mounted, orphan}) {
+		t.Fatalf("expected the mounted and used layers to be kept, got: %+v", result)
+	}
+}
+
+func Test_GarbageCollect_MountCheckFailure(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	defer stubLayerMounted(func(ctx context.Context, path string) (bool, error) {
+		return false, errors.New("mount check failed")
+	})()
+
+	result, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{filepath.Join(root, "app")},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(root, "orphan")
+	if len(result.Removed) != 0 || !reflect.DeepEqual(result.Failed, []string{orphan}) {
+		t.Fatalf("expected %s to be kept as failed, got: %+v", orphan, result)
+	}
+}
+
+func Test_GarbageCollect_InvalidRootChain(t *testing.T) {
+	root := setupTestStore(t)
+	defer os.RemoveAll(root)
+	defer stubLayerMounted(notMounted)()
+	app := filepath.Join(root, "app")
+	if err := ioutil.WriteFile(filepath.Join(app, layerChainFile), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GarbageCollect(context.Background(), root, &GCOptions{
+		Roots:  []string{app},
+		DryRun: true,
+	}); err == nil {
+		t.Fatal("expected GarbageCollect to fail on an unreadable root chain")
+	}
+}
@@ -0,0 +1,413 @@
+package ociwclayer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// The media types of the OCI and Docker image formats that are understood by
+// ImportImage.
+const (
+	mediaTypeImageIndex         = "application/vnd.oci.image.index.v1+json"
+	mediaTypeImageManifest      = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeLayer              = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeLayerGzip          = "application/vnd.oci.image.layer.v1.tar+gzip"
+	mediaTypeLayerForeign       = "application/vnd.oci.image.layer.nondistributable.v1.tar"
+	mediaTypeLayerForeignGzip   = "application/vnd.oci.image.layer.nondistributable.v1.tar+gzip"
+	mediaTypeDockerLayerGzip    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	mediaTypeDockerLayerForeign = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+)
+
+const (
+	annotationRefName = "org.opencontainers.image.ref.name"
+	layoutFile        = "oci-layout"
+	indexFile         = "index.json"
+	blobsDir          = "blobs"
+	layerTempSuffix   = ".importing"
+	// maxManifestSize is the size limit of manifests, indexes and configs,
+	// which are read into memory.
+	maxManifestSize = 4 << 20
+)
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	URLs        []string          `json:"urls,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *platform         `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	OSVersion    string `json:"os.version,omitempty"`
+}
+
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+type manifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+type imageConfig struct {
+	OS string `json:"os"`
+}
+
+// ImportImageOptions selects the image in an OCI image layout to import.
+type ImportImageOptions struct {
+	// Ref is the `org.opencontainers.image.ref.name` annotation of the image
+	// in the layout's index. If empty the index must contain a single image.
+	Ref string
+	// Architecture selects the manifest of a multi-platform image. It
+	// defaults to the architecture of the host.
+	Architecture string
+}
+
+// ImportImage imports the layers of the Windows image in the OCI image layout
+// at `layoutPath` into directories under `layersRoot`, without a docker
+// daemon or snapshotter involved. Layers that were imported before, with the
+// same parents, are reused.
+//
+// The paths of the imported layers are returned ordered from the top-most
+// layer to the base layer, as expected by the `parentLayerPaths` of a
+// container's scratch layer.
+//
+// The caller must ensure that the thread or process has acquired backup and
+// restore privileges.
+func ImportImage(ctx context.Context, layoutPath, layersRoot string, opts *ImportImageOptions) ([]string, error) {
+	if opts == nil {
+		opts = &ImportImageOptions{}
+	}
+	if _, err := os.Stat(filepath.Join(layoutPath, layoutFile)); err != nil {
+		return nil, errors.Wrap(err, "not an OCI image layout")
+	}
+	var idx index
+	if err := readJSONFile(filepath.Join(layoutPath, indexFile), &idx); err != nil {
+		return nil, err
+	}
+	desc, err := selectImage(idx.Manifests, opts.Ref)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readManifest(layoutPath, desc, opts)
+	if err != nil {
+		return nil, err
+	}
+	var config imageConfig
+	if err := readBlobJSON(layoutPath, m.Config, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to read image config")
+	}
+	if config.OS != "windows" {
+		return nil, fmt.Errorf("image has os %q, only windows images can be imported", config.OS)
+	}
+	if err := os.MkdirAll(layersRoot, 0); err != nil {
+		return nil, err
+	}
+
+	// parents is ordered from the top-most layer to the base layer.
+	var parents []string
+	chain := ""
+	for _, layer := range m.Layers {
+		chain = chainID(chain, layer.Digest)
+		layerPath := filepath.Join(layersRoot, chain)
+		if _, err := os.Stat(layerPath); err == nil {
+			log.G(ctx).WithField("layer", layer.Digest).Debug("reusing imported layer")
+		} else {
+			if err := importLayerBlob(ctx, layoutPath, layer, layerPath, parents); err != nil {
+				return nil, errors.Wrapf(err, "failed to import layer %s", layer.Digest)
+			}
+		}
+		parents = append([]string{layerPath}, parents...)
+	}
+	return parents, nil
+}
+
+// ImportImageFromTar imports the layers of the Windows image in the OCI image
+// layout archived in the tar stream `r`, as produced by `docker save` or
+// `ctr images export`. See ImportImage.
+func ImportImageFromTar(ctx context.Context, r io.Reader, layersRoot string, opts *ImportImageOptions) ([]string, error) {
+	layoutPath, err := ioutil.TempDir("", "oci-layout")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(layoutPath)
+	if err := extractLayout(ctx, r, layoutPath); err != nil {
+		return nil, errors.Wrap(err, "failed to extract image layout")
+	}
+	return ImportImage(ctx, layoutPath, layersRoot, opts)
+}
+
+// extractLayout writes the regular files of the tar stream `r` under `root`.
+func extractLayout(ctx context.Context, r io.Reader, root string) error {
+	t := tar.NewReader(r)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		hdr, err := t.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean("/" + hdr.Name)[1:]
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0); err != nil {
+			return err
+		}
+		f, err := os.Create(p)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, t)
+		cerr := f.Close()
+		if err != nil {
+			return err
+		}
+		if cerr != nil {
+			return cerr
+		}
+	}
+}
+
+// selectImage returns the descriptor in `manifests` with the ref name `ref`.
+func selectImage(manifests []descriptor, ref string) (descriptor, error) {
+	if ref == "" {
+		if len(manifests) != 1 {
+			return descriptor{}, fmt.Errorf("image layout contains %d images, a ref must be given", len(manifests))
+		}
+		return manifests[0], nil
+	}
+	for _, d := range manifests {
+		if d.Annotations[annotationRefName] == ref {
+			return d, nil
+		}
+	}
+	return descriptor{}, fmt.Errorf("image %q not found in image layout", ref)
+}
+
+// readManifest returns the manifest for `desc`, resolving an index to the
+// manifest of the Windows image for the requested architecture.
+func readManifest(layoutPath string, desc descriptor, opts *ImportImageOptions) (*manifest, error) {
+	arch := opts.Architecture
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	// An index may refer to other indexes, but not endlessly.
+	for i := 0; i < 4; i++ {
+		switch desc.MediaType {
+		case mediaTypeImageManifest, mediaTypeDockerManifest:
+			var m manifest
+			if err := readBlobJSON(layoutPath, desc, &m); err != nil {
+				return nil, errors.Wrap(err, "failed to read image manifest")
+			}
+			return &m, nil
+		case mediaTypeImageIndex, mediaTypeDockerManifestList:
+			var idx index
+			if err := readBlobJSON(layoutPath, desc, &idx); err != nil {
+				return nil, errors.Wrap(err, "failed to read image index")
+			}
+			found := false
+			for _, d := range idx.Manifests {
+				if d.Platform == nil || (d.Platform.OS == "windows" && d.Platform.Architecture == arch) {
+					desc, found = d, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("image has no manifest for windows/%s", arch)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported manifest media type %q", desc.MediaType)
+		}
+	}
+	return nil, errors.New("image index is nested too deeply")
+}
+
+// importLayerBlob imports the layer `desc` to `layerPath`. The layer is
+// imported to a temporary directory that is renamed once the import has
+// completed and the blob has been verified, so that a partially imported
+// layer is never reused.
+func importLayerBlob(ctx context.Context, layoutPath string, desc descriptor, layerPath string, parents []string) (err error) {
+	compressed := false
+	switch desc.MediaType {
+	case mediaTypeLayer, mediaTypeLayerForeign:
+	case mediaTypeLayerGzip, mediaTypeLayerForeignGzip, mediaTypeDockerLayerGzip, mediaTypeDockerLayerForeign:
+		compressed = true
+	default:
+		return fmt.Errorf("unsupported layer media type %q", desc.MediaType)
+	}
+	f, v, err := openBlob(layoutPath, desc)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) && len(desc.URLs) != 0 {
+			return fmt.Errorf("foreign layer is not in the image layout, it must be fetched from %s", desc.URLs[0])
+		}
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = v
+	if compressed {
+		gz, err := gzip.NewReader(v)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tempPath := layerPath + layerTempSuffix
+	if err := os.RemoveAll(tempPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rerr := os.RemoveAll(tempPath); rerr != nil {
+				log.G(ctx).WithFields(logrus.Fields{
+					logrus.ErrorKey: rerr,
+					"path":          tempPath,
+				}).Warning("failed to remove partially imported layer")
+			}
+		}
+	}()
+	if _, err := ImportLayerFromTar(ctx, r, tempPath, parents); err != nil {
+		return err
+	}
+	// The tar stream may end before the blob does, the rest of it must still
+	// be read to verify the digest. Reading the gzip stream to its end also
+	// verifies its checksum.
+	if compressed {
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			return err
+		}
+	}
+	if err := v.drain(); err != nil {
+		return err
+	}
+	if err := v.verify(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, layerPath)
+}
+
+func readJSONFile(p string, v interface{}) error {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func readBlobJSON(layoutPath string, desc descriptor, v interface{}) error {
+	if desc.Size > maxManifestSize {
+		return fmt.Errorf("blob %s of size %d is too large", desc.Digest, desc.Size)
+	}
+	f, vr, err := openBlob(layoutPath, desc)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(vr)
+	if err != nil {
+		return err
+	}
+	if err := vr.verify(); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verifiedReader computes the digest of a blob as it is read.
+type verifiedReader struct {
+	r      io.Reader
+	h      hash.Hash
+	digest string
+	size   int64
+	n      int64
+}
+
+func (v *verifiedReader) Read(b []byte) (int, error) {
+	n, err := v.r.Read(b)
+	v.n += int64(n)
+	return n, err
+}
+
+// drain reads the rest of the blob.
+func (v *verifiedReader) drain() error {
+	_, err := io.Copy(ioutil.Discard, v)
+	return err
+}
+
+// verify returns an error if the data read so far does not match the
+// descriptor of the blob.
+func (v *verifiedReader) verify() error {
+	if v.n != v.size {
+		return fmt.Errorf("blob %s has size %d, expected %d", v.digest, v.n, v.size)
+	}
+	if d := "sha256:" + hex.EncodeToString(v.h.Sum(nil)); d != v.digest {
+		return fmt.Errorf("blob %s has digest %s", v.digest, d)
+	}
+	return nil
+}
+
+// openBlob opens the blob for `desc` in the image layout. The returned
+// reader verifies the blob against `desc`.
+func openBlob(layoutPath string, desc descriptor) (*os.File, *verifiedReader, error) {
+	parts := strings.SplitN(desc.Digest, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return nil, nil, fmt.Errorf("unsupported digest %q", desc.Digest)
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || len(parts[1]) != sha256.Size*2 {
+		return nil, nil, fmt.Errorf("invalid digest %q", desc.Digest)
+	}
+	f, err := os.Open(filepath.Join(layoutPath, blobsDir, parts[0], parts[1]))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to open blob %s", desc.Digest)
+	}
+	h := sha256.New()
+	return f, &verifiedReader{
+		r:      io.TeeReader(f, h),
+		h:      h,
+		digest: desc.Digest,
+		size:   desc.Size,
+	}, nil
+}
+
+// chainID returns the name of the directory that the layer `digest` is
+// imported to on top of the chain of layers `parent`.
+func chainID(parent, digest string) string {
+	if parent == "" {
+		return strings.TrimPrefix(digest, "sha256:")
+	}
+	h := sha256.Sum256([]byte(parent + " " + digest))
+	return hex.EncodeToString(h[:])
+}
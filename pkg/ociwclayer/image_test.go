@@ -0,0 +1,193 @@
+package ociwclayer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBlob writes `data` to the image layout `layoutPath` and returns its
+// descriptor.
+func writeBlob(t *testing.T, layoutPath, mediaType string, data []byte) descriptor {
+	h := sha256.Sum256(data)
+	d := hex.EncodeToString(h[:])
+	dir := filepath.Join(layoutPath, blobsDir, "sha256")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, d), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return descriptor{MediaType: mediaType, Digest: "sha256:" + d, Size: int64(len(data))}
+}
+
+func writeJSONBlob(t *testing.T, layoutPath, mediaType string, v interface{}) descriptor {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeBlob(t, layoutPath, mediaType, b)
+}
+
+func tempLayout(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ociwclayer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestVerifiedReader_Drain(t *testing.T) {
+	layout := tempLayout(t)
+	defer os.RemoveAll(layout)
+	desc := writeBlob(t, layout, mediaTypeLayer, []byte("layer data followed by padding"))
+
+	f, v, err := openBlob(layout, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := io.ReadFull(v, make([]byte, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.verify(); err == nil {
+		t.Fatal("expected a partially read blob to fail verification")
+	}
+	if err := v.drain(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.verify(); err != nil {
+		t.Fatalf("expected a drained blob to verify, got: %s", err)
+	}
+}
+
+// drainAndVerify reads all of the blob `desc` and verifies it.
+func drainAndVerify(t *testing.T, layout string, desc descriptor) error {
+	f, v, err := openBlob(layout, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := v.drain(); err != nil {
+		t.Fatal(err)
+	}
+	return v.verify()
+}
+
+func TestVerifiedReader_SizeMismatch(t *testing.T) {
+	layout := tempLayout(t)
+	defer os.RemoveAll(layout)
+	desc := writeBlob(t, layout, mediaTypeLayer, []byte("layer data"))
+	desc.Size++
+
+	if err := drainAndVerify(t, layout, desc); err == nil {
+		t.Fatal("expected blob with the wrong size to fail verification")
+	}
+}
+
+func TestVerifiedReader_DigestMismatch(t *testing.T) {
+	layout := tempLayout(t)
+	defer os.RemoveAll(layout)
+	desc := writeBlob(t, layout, mediaTypeLayer, []byte("layer data"))
+	// Replace the content of the blob, keeping its name and size.
+	p := filepath.Join(layout, blobsDir, "sha256", strings.TrimPrefix(desc.Digest, "sha256:"))
+	if err := ioutil.WriteFile(p, []byte("LAYER DATA"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := drainAndVerify(t, layout, desc); err == nil {
+		t.Fatal("expected blob with the wrong digest to fail verification")
+	}
+}
+
+func TestOpenBlob_InvalidDigest(t *testing.T) {
+	for _, d := range []string{
+		"sha512:" + strings.Repeat("0", 128),
+		"sha256:abc",
+		"sha256:../../../../etc/passwd",
+		"nodigest",
+	} {
+		if _, _, err := openBlob(".", descriptor{Digest: d}); err == nil {
+			t.Fatalf("expected digest %q to be rejected", d)
+		}
+	}
+}
+
+func TestImportLayerBlob_UnsupportedMediaType(t *testing.T) {
+	err := importLayerBlob(context.Background(), ".", descriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+zstd"}, "layer", nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported layer media type") {
+		t.Fatalf("expected unsupported media type error, got: %v", err)
+	}
+}
+
+func TestImportLayerBlob_MissingForeignLayer(t *testing.T) {
+	layout := tempLayout(t)
+	defer os.RemoveAll(layout)
+	desc := descriptor{
+		MediaType: mediaTypeDockerLayerForeign,
+		Digest:    "sha256:" + strings.Repeat("0", 64),
+		URLs:      []string{"https://mcr.microsoft.com/layer"},
+	}
+	err := importLayerBlob(context.Background(), layout, desc, filepath.Join(layout, "layer"), nil)
+	if err == nil || !strings.Contains(err.Error(), "must be fetched from") {
+		t.Fatalf("expected missing foreign layer error, got: %v", err)
+	}
+}
+
+func TestSelectImage(t *testing.T) {
+	a := descriptor{Digest: "a", Annotations: map[string]string{annotationRefName: "latest"}}
+	b := descriptor{Digest: "b", Annotations: map[string]string{annotationRefName: "ltsc2022"}}
+
+	if d, err := selectImage([]descriptor{a}, ""); err != nil || d.Digest != "a" {
+		t.Fatalf("expected the only image, got: %+v, %v", d, err)
+	}
+	if _, err := selectImage([]descriptor{a, b}, ""); err == nil {
+		t.Fatal("expected a ref to be required with several images")
+	}
+	if d, err := selectImage([]descriptor{a, b}, "ltsc2022"); err != nil || d.Digest != "b" {
+		t.Fatalf("expected image b, got: %+v, %v", d, err)
+	}
+	if _, err := selectImage([]descriptor{a, b}, "missing"); err == nil {
+		t.Fatal("expected an unknown ref to be rejected")
+	}
+}
+
+func TestReadManifest_Index(t *testing.T) {
+	layout := tempLayout(t)
+	defer os.RemoveAll(layout)
+	linux := writeJSONBlob(t, layout, mediaTypeImageManifest, manifest{})
+	linux.Platform = &platform{OS: "linux", Architecture: "amd64"}
+	windows := writeJSONBlob(t, layout, mediaTypeImageManifest, manifest{
+		Layers: []descriptor{{MediaType: mediaTypeLayerGzip, Digest: "sha256:layer"}},
+	})
+	windows.Platform = &platform{OS: "windows", Architecture: "amd64"}
+	idx := writeJSONBlob(t, layout, mediaTypeImageIndex, index{Manifests: []descriptor{linux, windows}})
+
+	m, err := readManifest(layout, idx, &ImportImageOptions{Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("should not have failed with error: %s", err)
+	}
+	if len(m.Layers) != 1 || m.Layers[0].Digest != "sha256:layer" {
+		t.Fatalf("expected the windows manifest, got: %+v", m)
+	}
+	if _, err := readManifest(layout, idx, &ImportImageOptions{Architecture: "arm64"}); err == nil {
+		t.Fatal("expected an index without a manifest for the architecture to be rejected")
+	}
+}
+
+func TestChainID(t *testing.T) {
+	if id := chainID("", "sha256:abc"); id != "abc" {
+		t.Fatalf("expected the digest of a base layer, got: %s", id)
+	}
+	a, b := chainID("x", "sha256:abc"), chainID("y", "sha256:abc")
+	if a == b || a == "abc" {
+		t.Fatalf("expected distinct chain IDs for distinct parents, got: %s, %s", a, b)
+	}
+}
@@ -7,13 +7,17 @@ package functional
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/Microsoft/hcsshim/internal/cmd"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/Microsoft/hcsshim/osversion"
 	testutilities "github.com/Microsoft/hcsshim/test/functional/utilities"
+	"github.com/sirupsen/logrus"
 )
 
 // TestPlan9 tests adding/removing Plan9 shares to/from a v2 Linux utility VM
@@ -43,3 +47,91 @@ func TestPlan9(t *testing.T) {
 		}
 	}
 }
+
+// plan9BenchConfigs are the Plan9 mount options that the Plan9 benchmarks are
+// run with, so that the message sizes and cache modes can be compared.
+var plan9BenchConfigs = []struct {
+	name  string
+	msize uint32
+	cache string
+}{
+	{"default", 0, ""},
+	{"msize-64KiB", 64 * 1024, ""},
+	{"msize-512KiB", 512 * 1024, ""},
+	{"cache-loose", 0, "loose"},
+	{"cache-mmap", 0, "mmap"},
+	{"msize-512KiB-cache-loose", 512 * 1024, "loose"},
+}
+
+// runBenchPlan9 runs `command` `b.N` times in a utility VM with `dir` shared at
+// /tmp/bench, as a sub-benchmark for each of `plan9BenchConfigs`. Each
+// iteration processes `bytes` bytes, if non-zero.
+func runBenchPlan9(b *testing.B, dir string, bytes int64, command ...string) {
+	// Cant use testutilities here because its `testing.B` not `testing.T`
+	logrus.SetOutput(ioutil.Discard)
+	for _, config := range plan9BenchConfigs {
+		config := config
+		b.Run(config.name, func(b *testing.B) {
+			opts := uvm.NewDefaultOptionsLCOW(strings.ReplaceAll(b.Name(), "/", "_"), "")
+			opts.Plan9MSize = config.msize
+			opts.Plan9Cache = config.cache
+			vm, err := uvm.CreateLCOW(context.Background(), opts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer vm.Close()
+			if err := vm.Start(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := vm.AddPlan9(context.Background(), dir, "/tmp/bench", true, false, nil); err != nil {
+				b.Fatalf("AddPlan9 failed: %s", err)
+			}
+			if bytes != 0 {
+				b.SetBytes(bytes)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := cmd.Command(vm, command[0], command[1:]...).Run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPlan9Read measures the throughput of sequential reads of a large
+// file on a Plan9 share.
+func BenchmarkPlan9Read(b *testing.B) {
+	const size = 64 * 1024 * 1024
+	dir, err := ioutil.TempDir("", "plan9bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), make([]byte, size), 0644); err != nil {
+		b.Fatal(err)
+	}
+	runBenchPlan9(b, dir, size, "dd", "if=/tmp/bench/file", "of=/dev/null", "bs=1M")
+}
+
+// BenchmarkPlan9Stat measures the time to stat every file of a directory tree
+// on a Plan9 share, which dominates builds of bind mounted workspaces.
+func BenchmarkPlan9Stat(b *testing.B) {
+	dir, err := ioutil.TempDir("", "plan9bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i := 0; i < 10; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(sub, 0755); err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < 100; j++ {
+			if err := ioutil.WriteFile(filepath.Join(sub, fmt.Sprintf("file%d", j)), nil, 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	runBenchPlan9(b, dir, 0, "find", "/tmp/bench", "-type", "f", "-newer", "/tmp/bench")
+}
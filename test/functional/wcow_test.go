@@ -385,7 +385,7 @@ func TestWCOWArgonShim(t *testing.T) {
 	}()
 
 	// This is a cheat but stops us re-writing exactly the same code just for test
-	argonShimLocalMountPath, err := layerspkg.MountContainerLayers(context.Background(), append(imageLayers, argonShimScratchDir), "", nil)
+	argonShimLocalMountPath, err := layerspkg.MountContainerLayers(context.Background(), append(imageLayers, argonShimScratchDir), "", nil, 0)
 	if err != nil {
 		t.Fatal(err)
 	}